@@ -0,0 +1,225 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package boot
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/snapcore/snapd/bootloader"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/snap"
+)
+
+func init() {
+	gadget.SlotSwitcherForUpdate = slotSwitcherForUpdate
+}
+
+// abSlotsBootloader returns the bootloader through which the firmware is
+// told which A/B slots to boot, that is the recovery bootloader on
+// ubuntu-seed on systems using a modeenv, or the only bootloader otherwise.
+// A nil bootloader is returned when it does not support A/B slots.
+func abSlotsBootloader(hasModeenv bool) (bootloader.ABSlotsBootloader, error) {
+	var bl bootloader.Bootloader
+	var err error
+	if hasModeenv {
+		bl, err = bootloader.Find(InitramfsUbuntuSeedDir, &bootloader.Options{
+			Role: bootloader.RoleRecovery,
+		})
+	} else {
+		bl, err = bootloader.Find("", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	absb, ok := bl.(bootloader.ABSlotsBootloader)
+	if !ok {
+		return nil, nil
+	}
+	return absb, nil
+}
+
+func slotSwitcherForUpdate() (gadget.SlotSwitcher, error) {
+	absb, err := abSlotsBootloader(osutil.FileExists(dirs.SnapModeenvFile))
+	if err != nil {
+		return nil, fmt.Errorf("cannot find bootloader for A/B slots: %v", err)
+	}
+	if absb == nil {
+		return nil, fmt.Errorf("bootloader does not support A/B slots")
+	}
+	return &abSlotSwitcher{bl: absb}, nil
+}
+
+// abSlotSwitcher implements gadget.SlotSwitcher on top of a bootloader
+// supporting A/B slots. Trying a slot mirrors trying a kernel, the slot is
+// set together with the "try" status, which the firmware moves to "trying"
+// when booting it and which is committed by MarkBootSuccessful.
+type abSlotSwitcher struct {
+	bl bootloader.ABSlotsBootloader
+}
+
+func (s *abSlotSwitcher) ActiveSlot(group string) (string, error) {
+	state, err := s.bl.ABSlotState(group)
+	if err != nil {
+		return "", err
+	}
+	if state.Active == "" {
+		// slot "a" is booted until switched
+		return gadget.SlotA, nil
+	}
+	return state.Active, nil
+}
+
+func (s *abSlotSwitcher) SetTrySlot(group, slot string) error {
+	state, err := s.bl.ABSlotState(group)
+	if err != nil {
+		return err
+	}
+	if state.Active == "" {
+		// record the slot to fall back to explicitly
+		state.Active = gadget.SlotA
+	}
+	state.Try = slot
+	state.Status = TryStatus
+	return s.bl.SetABSlotState(group, state)
+}
+
+func (s *abSlotSwitcher) ClearTrySlot(group string) error {
+	state, err := s.bl.ABSlotState(group)
+	if err != nil {
+		return err
+	}
+	state.Try = ""
+	state.Status = DefaultStatus
+	return s.bl.SetABSlotState(group, state)
+}
+
+// markSuccessfulABSlots commits the slots tried during the current boot,
+// and drops the try of slots that the firmware did not boot or fell back
+// from, the same way this is done for kernels. Only a "trying" status, as
+// set by the gadget boot script, tells that the try slot was booted, see
+// bootloader.ABSlotsBootloader.
+func markSuccessfulABSlots(hasModeenv bool) error {
+	absb, err := abSlotsBootloader(hasModeenv)
+	if err != nil {
+		return err
+	}
+	if absb == nil {
+		return nil
+	}
+
+	groups, err := absb.ABSlotGroups()
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		state, err := absb.ABSlotState(group)
+		if err != nil {
+			return err
+		}
+		if state.Try == "" && state.Status == DefaultStatus {
+			// nothing being tried
+			continue
+		}
+		if state.Status == TryingStatus {
+			state.Active = state.Try
+		} else {
+			logger.Noticef("slot %q of group %q was not booted, keeping slot %q", state.Try, group, state.Active)
+		}
+		state.Try = ""
+		state.Status = DefaultStatus
+		if err := absb.SetABSlotState(group, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TriedABSlots returns the slots of the A/B slot groups of raw gadget
+// structures which are set to be tried on the next boot, by group.
+func TriedABSlots(dev snap.Device) (map[string]string, error) {
+	absb, err := abSlotsBootloader(dev.HasModeenv())
+	if err != nil {
+		return nil, err
+	}
+	if absb == nil {
+		return nil, nil
+	}
+
+	groups, err := absb.ABSlotGroups()
+	if err != nil {
+		return nil, err
+	}
+	var tried map[string]string
+	for _, group := range groups {
+		state, err := absb.ABSlotState(group)
+		if err != nil {
+			return nil, err
+		}
+		if state.Try == "" {
+			continue
+		}
+		if tried == nil {
+			tried = make(map[string]string)
+		}
+		tried[group] = state.Try
+	}
+	return tried, nil
+}
+
+// CheckABSlotsBooted checks that the given slots, as returned by TriedABSlots
+// before a reboot, were booted and committed, similar to what is done with
+// GetCurrentBoot for kernels. It returns ErrBootNameAndRevisionNotReady while
+// the tries were not committed or dropped by MarkBootSuccessful yet, and an
+// error if the firmware fell back from any of the slots.
+func CheckABSlotsBooted(dev snap.Device, slots map[string]string) error {
+	if len(slots) == 0 {
+		return nil
+	}
+	absb, err := abSlotsBootloader(dev.HasModeenv())
+	if err != nil {
+		return err
+	}
+	if absb == nil {
+		return fmt.Errorf("bootloader does not support A/B slots")
+	}
+
+	groups := make([]string, 0, len(slots))
+	for group := range slots {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		state, err := absb.ABSlotState(group)
+		if err != nil {
+			return err
+		}
+		if state.Try != "" {
+			return ErrBootNameAndRevisionNotReady
+		}
+		if state.Active != slots[group] {
+			return fmt.Errorf("slot %q of group %q was not booted, the firmware fell back to slot %q", slots[group], group, state.Active)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package boot_test
+
+import (
+	"errors"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/boot"
+	"github.com/snapcore/snapd/boot/boottest"
+	"github.com/snapcore/snapd/bootloader"
+	"github.com/snapcore/snapd/bootloader/bootloadertest"
+	"github.com/snapcore/snapd/gadget"
+)
+
+type abSlotsSuite struct {
+	baseBootenvSuite
+
+	bootloader *bootloadertest.MockABSlotsBootloader
+}
+
+var _ = Suite(&abSlotsSuite{})
+
+func (s *abSlotsSuite) SetUpTest(c *C) {
+	s.baseBootenvSuite.SetUpTest(c)
+
+	s.bootloader = bootloadertest.Mock("mock", c.MkDir()).WithABSlots()
+	s.forceBootloader(s.bootloader)
+}
+
+func (s *abSlotsSuite) TestSlotSwitcherTryAndClear(c *C) {
+	switcher, err := gadget.SlotSwitcherForUpdate()
+	c.Assert(err, IsNil)
+
+	// slot "a" is active until switched
+	active, err := switcher.ActiveSlot("bootloader")
+	c.Assert(err, IsNil)
+	c.Check(active, Equals, gadget.SlotA)
+
+	err = switcher.SetTrySlot("bootloader", gadget.SlotB)
+	c.Assert(err, IsNil)
+	c.Check(s.bootloader.ABSlotStates, DeepEquals, map[string]*bootloader.ABSlotState{
+		"bootloader": {Active: "a", Try: "b", Status: boot.TryStatus},
+	})
+
+	// the active slot does not change until the try is committed
+	active, err = switcher.ActiveSlot("bootloader")
+	c.Assert(err, IsNil)
+	c.Check(active, Equals, gadget.SlotA)
+
+	err = switcher.ClearTrySlot("bootloader")
+	c.Assert(err, IsNil)
+	c.Check(s.bootloader.ABSlotStates, DeepEquals, map[string]*bootloader.ABSlotState{
+		"bootloader": {Active: "a", Status: boot.DefaultStatus},
+	})
+}
+
+func (s *abSlotsSuite) TestSlotSwitcherErrors(c *C) {
+	switcher, err := gadget.SlotSwitcherForUpdate()
+	c.Assert(err, IsNil)
+
+	s.bootloader.SetABSlotErr = errors.New("set fail")
+	err = switcher.SetTrySlot("bootloader", gadget.SlotB)
+	c.Check(err, ErrorMatches, "set fail")
+	err = switcher.ClearTrySlot("bootloader")
+	c.Check(err, ErrorMatches, "set fail")
+}
+
+func (s *abSlotsSuite) TestSlotSwitcherUnsupportedBootloader(c *C) {
+	s.forceBootloader(bootloadertest.Mock("mock", c.MkDir()))
+
+	_, err := gadget.SlotSwitcherForUpdate()
+	c.Assert(err, ErrorMatches, "bootloader does not support A/B slots")
+
+	s.forceBootloader(nil)
+	_, err = gadget.SlotSwitcherForUpdate()
+	c.Assert(err, ErrorMatches, "cannot find bootloader for A/B slots: cannot determine bootloader")
+}
+
+func (s *abSlotsSuite) TestMarkBootSuccessfulCommitsTriedSlots(c *C) {
+	coreDev := boottest.MockDevice("some-snap")
+
+	s.bootloader.SetABSlotState("bootloader", &bootloader.ABSlotState{Active: "a", Try: "b", Status: boot.TryingStatus})
+	s.bootloader.SetABSlotState("firmware", &bootloader.ABSlotState{Active: "b"})
+
+	err := boot.MarkBootSuccessful(coreDev)
+	c.Assert(err, IsNil)
+
+	expected := map[string]*bootloader.ABSlotState{
+		// committed
+		"bootloader": {Active: "b", Status: boot.DefaultStatus},
+		// untouched
+		"firmware": {Active: "b"},
+	}
+	c.Check(s.bootloader.ABSlotStates, DeepEquals, expected)
+
+	// do it again, verify its still valid
+	err = boot.MarkBootSuccessful(coreDev)
+	c.Assert(err, IsNil)
+	c.Check(s.bootloader.ABSlotStates, DeepEquals, expected)
+}
+
+func (s *abSlotsSuite) TestMarkBootSuccessfulDropsSlotsNotBooted(c *C) {
+	coreDev := boottest.MockDevice("some-snap")
+
+	// the firmware never got to boot the try slot
+	s.bootloader.SetABSlotState("bootloader", &bootloader.ABSlotState{Active: "a", Try: "b", Status: boot.TryStatus})
+	// the firmware fell back to the active slot and reset the status
+	s.bootloader.SetABSlotState("firmware", &bootloader.ABSlotState{Active: "b", Try: "a", Status: boot.DefaultStatus})
+
+	err := boot.MarkBootSuccessful(coreDev)
+	c.Assert(err, IsNil)
+
+	c.Check(s.bootloader.ABSlotStates, DeepEquals, map[string]*bootloader.ABSlotState{
+		"bootloader": {Active: "a", Status: boot.DefaultStatus},
+		"firmware":   {Active: "b", Status: boot.DefaultStatus},
+	})
+}
+
+func (s *abSlotsSuite) TestMarkBootSuccessfulSlotsError(c *C) {
+	coreDev := boottest.MockDevice("some-snap")
+
+	s.bootloader.SetABSlotState("bootloader", &bootloader.ABSlotState{Active: "a", Try: "b", Status: boot.TryingStatus})
+	s.bootloader.SetABSlotErr = errors.New("set fail")
+
+	err := boot.MarkBootSuccessful(coreDev)
+	c.Assert(err, ErrorMatches, "cannot mark boot successful: set fail")
+}
+
+// runABSlotsBootScript simulates the gadget boot script handling the A/B
+// slot variables on boot, as documented in bootloader.ABSlotsBootloader, and
+// returns the slots booted for each group.
+func runABSlotsBootScript(c *C, bl *bootloadertest.MockABSlotsBootloader) map[string]string {
+	booted := make(map[string]string)
+	groups, err := bl.ABSlotGroups()
+	c.Assert(err, IsNil)
+	for _, group := range groups {
+		state, err := bl.ABSlotState(group)
+		c.Assert(err, IsNil)
+		slot := state.Active
+		switch state.Status {
+		case boot.TryStatus:
+			state.Status = boot.TryingStatus
+			slot = state.Try
+		case boot.TryingStatus:
+			state.Status = boot.DefaultStatus
+		}
+		if slot == "" {
+			slot = gadget.SlotA
+		}
+		c.Assert(bl.SetABSlotState(group, state), IsNil)
+		booted[group] = slot
+	}
+	return booted
+}
+
+func (s *abSlotsSuite) TestBootScriptTrySlotHappy(c *C) {
+	coreDev := boottest.MockDevice("some-snap")
+
+	switcher, err := gadget.SlotSwitcherForUpdate()
+	c.Assert(err, IsNil)
+	c.Assert(switcher.SetTrySlot("bootloader", gadget.SlotB), IsNil)
+	tried, err := boot.TriedABSlots(coreDev)
+	c.Assert(err, IsNil)
+	c.Check(tried, DeepEquals, map[string]string{"bootloader": gadget.SlotB})
+
+	// the try slot is booted
+	c.Check(runABSlotsBootScript(c, s.bootloader), DeepEquals, map[string]string{
+		"bootloader": gadget.SlotB,
+	})
+	// the try is not committed yet
+	c.Check(boot.CheckABSlotsBooted(coreDev, tried), Equals, boot.ErrBootNameAndRevisionNotReady)
+	c.Assert(boot.MarkBootSuccessful(coreDev), IsNil)
+	c.Check(boot.CheckABSlotsBooted(coreDev, tried), IsNil)
+
+	tried, err = boot.TriedABSlots(coreDev)
+	c.Assert(err, IsNil)
+	c.Check(tried, HasLen, 0)
+
+	active, err := switcher.ActiveSlot("bootloader")
+	c.Assert(err, IsNil)
+	c.Check(active, Equals, gadget.SlotB)
+
+	// and keeps being booted
+	c.Check(runABSlotsBootScript(c, s.bootloader), DeepEquals, map[string]string{
+		"bootloader": gadget.SlotB,
+	})
+	c.Check(s.bootloader.ABSlotStates, DeepEquals, map[string]*bootloader.ABSlotState{
+		"bootloader": {Active: "b", Status: boot.DefaultStatus},
+	})
+}
+
+func (s *abSlotsSuite) TestBootScriptTrySlotFallback(c *C) {
+	coreDev := boottest.MockDevice("some-snap")
+
+	switcher, err := gadget.SlotSwitcherForUpdate()
+	c.Assert(err, IsNil)
+	c.Assert(switcher.SetTrySlot("bootloader", gadget.SlotB), IsNil)
+	tried, err := boot.TriedABSlots(coreDev)
+	c.Assert(err, IsNil)
+
+	// the try slot is booted but fails before the boot is marked as
+	// successful
+	c.Check(runABSlotsBootScript(c, s.bootloader), DeepEquals, map[string]string{
+		"bootloader": gadget.SlotB,
+	})
+	// the boot script falls back to the active slot on the next boot
+	c.Check(runABSlotsBootScript(c, s.bootloader), DeepEquals, map[string]string{
+		"bootloader": gadget.SlotA,
+	})
+	c.Check(boot.CheckABSlotsBooted(coreDev, tried), Equals, boot.ErrBootNameAndRevisionNotReady)
+	c.Assert(boot.MarkBootSuccessful(coreDev), IsNil)
+
+	active, err := switcher.ActiveSlot("bootloader")
+	c.Assert(err, IsNil)
+	c.Check(active, Equals, gadget.SlotA)
+	c.Check(s.bootloader.ABSlotStates, DeepEquals, map[string]*bootloader.ABSlotState{
+		"bootloader": {Active: "a", Status: boot.DefaultStatus},
+	})
+
+	// the fallback is reported
+	err = boot.CheckABSlotsBooted(coreDev, tried)
+	c.Check(err, ErrorMatches, `slot "b" of group "bootloader" was not booted, the firmware fell back to slot "a"`)
+}
+
+func (s *abSlotsSuite) TestBootScriptNotHandlingSlots(c *C) {
+	coreDev := boottest.MockDevice("some-snap")
+
+	switcher, err := gadget.SlotSwitcherForUpdate()
+	c.Assert(err, IsNil)
+	c.Assert(switcher.SetTrySlot("bootloader", gadget.SlotB), IsNil)
+
+	// a boot script unaware of the A/B slot variables leaves the status
+	// untouched, the try is then dropped
+	c.Assert(boot.MarkBootSuccessful(coreDev), IsNil)
+
+	active, err := switcher.ActiveSlot("bootloader")
+	c.Assert(err, IsNil)
+	c.Check(active, Equals, gadget.SlotA)
+	c.Check(s.bootloader.ABSlotStates, DeepEquals, map[string]*bootloader.ABSlotState{
+		"bootloader": {Active: "a", Status: boot.DefaultStatus},
+	})
+}
+
+func (s *abSlotsSuite) TestTriedABSlotsUnsupportedBootloader(c *C) {
+	coreDev := boottest.MockDevice("some-snap")
+	s.forceBootloader(bootloadertest.Mock("mock", c.MkDir()))
+
+	tried, err := boot.TriedABSlots(coreDev)
+	c.Assert(err, IsNil)
+	c.Check(tried, IsNil)
+
+	// nothing to check
+	c.Check(boot.CheckABSlotsBooted(coreDev, nil), IsNil)
+	err = boot.CheckABSlotsBooted(coreDev, map[string]string{"bootloader": gadget.SlotB})
+	c.Check(err, ErrorMatches, "bootloader does not support A/B slots")
+}
@@ -407,6 +407,12 @@ func MarkBootSuccessful(dev snap.Device) error {
 			return fmt.Errorf(errPrefix, err)
 		}
 	}
+
+	// commit or drop the A/B slots of raw gadget structures tried during
+	// this boot
+	if err := markSuccessfulABSlots(dev.HasModeenv()); err != nil {
+		return fmt.Errorf(errPrefix, err)
+	}
 	return nil
 }
 
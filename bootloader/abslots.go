@@ -0,0 +1,122 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package bootloader
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/snapcore/snapd/strutil"
+)
+
+// ABSlotState is the state of a group of A/B paired raw gadget structures,
+// as seen by the firmware.
+type ABSlotState struct {
+	// Active is the slot known to boot, empty when never switched.
+	Active string
+	// Try is the slot to be tried on the next boot.
+	Try string
+	// Status is the status of the try, with the same values and
+	// transitions as kernel_status: "try" is set by snapd, the boot
+	// firmware changes it to "trying" when booting the try slot, and
+	// falls back to the active slot if it finds "trying" already set.
+	Status string
+}
+
+// ABSlotsBootloader is a bootloader through which the boot firmware is told
+// which of the A/B slots of paired raw gadget structures to boot from.
+//
+// snapd only ever sets a slot to try, the switch itself relies on the boot
+// script of the gadget, which for each group listed in snapd_ab_groups must
+// act on the snapd_ab_status_<group> variable as follows:
+//
+//   - "try": set it to "trying" and boot from snapd_ab_try_slot_<group>
+//   - "trying": the tried slot failed to boot, set it to "" and boot from
+//     snapd_ab_slot_<group>
+//   - otherwise: boot from snapd_ab_slot_<group>, or from slot "a" if unset
+//
+// The variables must be written back to the environment before booting.
+// A boot script not moving the status to "trying" makes snapd consider the
+// try slot as not booted, and the try is dropped when the boot is marked as
+// successful.
+type ABSlotsBootloader interface {
+	Bootloader
+
+	// ABSlotGroups returns the groups for which some state is recorded.
+	ABSlotGroups() ([]string, error)
+	// ABSlotState returns the recorded state of the given group.
+	ABSlotState(group string) (*ABSlotState, error)
+	// SetABSlotState records the state of the given group.
+	SetABSlotState(group string, state *ABSlotState) error
+}
+
+const abSlotGroupsVar = "snapd_ab_groups"
+
+func abSlotVars(group string) (active, try, status string) {
+	return "snapd_ab_slot_" + group, "snapd_ab_try_slot_" + group, "snapd_ab_status_" + group
+}
+
+// abSlotGroupsFromBootVars returns the groups listed in the boot variables
+// of the given bootloader.
+func abSlotGroupsFromBootVars(bl Bootloader) ([]string, error) {
+	m, err := bl.GetBootVars(abSlotGroupsVar)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(m[abSlotGroupsVar]), nil
+}
+
+// abSlotStateFromBootVars returns the state of the group kept in the boot
+// variables of the given bootloader.
+func abSlotStateFromBootVars(bl Bootloader, group string) (*ABSlotState, error) {
+	activeVar, tryVar, statusVar := abSlotVars(group)
+	m, err := bl.GetBootVars(activeVar, tryVar, statusVar)
+	if err != nil {
+		return nil, err
+	}
+	return &ABSlotState{
+		Active: m[activeVar],
+		Try:    m[tryVar],
+		Status: m[statusVar],
+	}, nil
+}
+
+// setABSlotStateInBootVars keeps the state of the group in the boot
+// variables of the given bootloader, adding the group to the list of known
+// groups if needed.
+func setABSlotStateInBootVars(bl Bootloader, group string, state *ABSlotState) error {
+	if group == "" {
+		return fmt.Errorf("internal error: slot group is unset")
+	}
+	groups, err := abSlotGroupsFromBootVars(bl)
+	if err != nil {
+		return err
+	}
+	activeVar, tryVar, statusVar := abSlotVars(group)
+	m := map[string]string{
+		activeVar: state.Active,
+		tryVar:    state.Try,
+		statusVar: state.Status,
+	}
+	if !strutil.ListContains(groups, group) {
+		m[abSlotGroupsVar] = strings.Join(append(groups, group), " ")
+	}
+	return bl.SetBootVars(m)
+}
@@ -20,9 +20,10 @@
 package assets
 
 var (
-	RegisterInternal           = registerInternal
-	RegisterSnippetForEditions = registerSnippetForEditions
-	RegisterGrubSnippets       = registerGrubSnippets
+	RegisterInternal            = registerInternal
+	RegisterSnippetForEditions  = registerSnippetForEditions
+	RegisterGrubSnippets        = registerGrubSnippets
+	RegisterSystemdBootSnippets = registerSystemdBootSnippets
 )
 
 func MockCleanState() (restore func()) {
@@ -0,0 +1,65 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package assets_test
+
+import (
+	"bytes"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/arch"
+	"github.com/snapcore/snapd/arch/archtest"
+	"github.com/snapcore/snapd/bootloader/assets"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type systemdBootAssetsTestSuite struct {
+	testutil.BaseTest
+}
+
+var _ = Suite(&systemdBootAssetsTestSuite{})
+
+func (s *systemdBootAssetsTestSuite) TestLoaderConf(c *C) {
+	a := assets.Internal("systemd-boot-loader.conf")
+	c.Assert(a, NotNil)
+	idx := bytes.IndexRune(a, '\n')
+	c.Assert(idx, Not(Equals), -1)
+	c.Check(string(a[:idx]), Equals, "# Snapd-Boot-Config-Edition: 1")
+	c.Check(string(a), testutil.Contains, "\ndefault snapd-kernel.conf\n")
+}
+
+func (s *systemdBootAssetsTestSuite) TestCmdlineSnippetEditions(c *C) {
+	for _, tc := range []struct {
+		arch arch.ArchitectureType
+		snip []byte
+	}{
+		{"amd64", []byte("console=ttyS0,115200n8 console=tty1 panic=-1")},
+		{"arm64", []byte("panic=-1")},
+	} {
+		r := archtest.MockArchitecture(tc.arch)
+		defer r()
+		r = assets.MockCleanState()
+		defer r()
+		assets.RegisterSystemdBootSnippets()
+
+		snip := assets.SnippetForEdition("systemd-boot-loader.conf:static-cmdline", 1)
+		c.Check(snip, DeepEquals, tc.snip, Commentf(string(tc.arch)))
+	}
+}
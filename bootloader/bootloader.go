@@ -366,6 +366,7 @@ var (
 		newAndroidBoot,
 		newLk,
 		newPiboot,
+		newSystemdBoot,
 	}
 )
 
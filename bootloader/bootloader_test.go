@@ -130,6 +130,7 @@ func (s *bootenvTestSuite) TestInstallBootloaderConfigFromGadget(c *C) {
 			gadgetFile: "piboot.conf",
 			sysFile:    "/boot/piboot/piboot.conf",
 		},
+		{name: "systemd-boot", gadgetFile: "systemd-boot.conf", sysFile: "/loader/loader.conf"},
 	} {
 		mockGadgetDir := c.MkDir()
 		rootDir := c.MkDir()
@@ -27,6 +27,7 @@ import (
 	"github.com/snapcore/snapd/bootloader"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
 )
 
 // MockBootloader mocks the bootloader interface and records all
@@ -73,6 +74,7 @@ var _ bootloader.NotScriptableBootloader = (*MockExtractedRecoveryKernelNotScrip
 var _ bootloader.ExtractedRecoveryKernelImageBootloader = (*MockExtractedRecoveryKernelNotScriptableBootloader)(nil)
 var _ bootloader.RebootBootloader = (*MockRebootBootloader)(nil)
 var _ bootloader.RecoveryBootConfigBootloader = (*MockBootloader)(nil)
+var _ bootloader.ABSlotsBootloader = (*MockABSlotsBootloader)(nil)
 
 func Mock(name, bootdir string) *MockBootloader {
 	return &MockBootloader{
@@ -683,3 +685,54 @@ func (b *MockBootloader) WithRebootBootloader() *MockRebootBootloader {
 		MockBootloader: b,
 	}
 }
+
+// MockABSlotsBootloader mocks a bootloader implementing the
+// bootloader.ABSlotsBootloader interface.
+type MockABSlotsBootloader struct {
+	*MockBootloader
+
+	ABSlotStates     map[string]*bootloader.ABSlotState
+	ABSlotGroupsList []string
+	SetABSlotErr     error
+}
+
+// WithABSlots derives a MockABSlotsBootloader from a base MockBootloader.
+func (b *MockBootloader) WithABSlots() *MockABSlotsBootloader {
+	return &MockABSlotsBootloader{
+		MockBootloader: b,
+		ABSlotStates:   make(map[string]*bootloader.ABSlotState),
+	}
+}
+
+// ABSlotGroups returns the groups with a recorded state; part of
+// ABSlotsBootloader.
+func (b *MockABSlotsBootloader) ABSlotGroups() ([]string, error) {
+	b.maybePanic("ABSlotGroups")
+	return b.ABSlotGroupsList, nil
+}
+
+// ABSlotState returns the recorded state of the group; part of
+// ABSlotsBootloader.
+func (b *MockABSlotsBootloader) ABSlotState(group string) (*bootloader.ABSlotState, error) {
+	b.maybePanic("ABSlotState")
+	state := &bootloader.ABSlotState{}
+	if recorded, ok := b.ABSlotStates[group]; ok {
+		*state = *recorded
+	}
+	return state, nil
+}
+
+// SetABSlotState records the state of the group; part of
+// ABSlotsBootloader.
+func (b *MockABSlotsBootloader) SetABSlotState(group string, state *bootloader.ABSlotState) error {
+	b.maybePanic("SetABSlotState")
+	if b.SetABSlotErr != nil {
+		return b.SetABSlotErr
+	}
+	recorded := *state
+	b.ABSlotStates[group] = &recorded
+	if !strutil.ListContains(b.ABSlotGroupsList, group) {
+		b.ABSlotGroupsList = append(b.ABSlotGroupsList, group)
+	}
+	return nil
+}
@@ -17,7 +17,7 @@
  *
  */
 
-// Package efi supports reading EFI variables.
+// Package efi supports reading and writing EFI variables.
 package efi
 
 import (
@@ -47,7 +47,9 @@ const (
 )
 
 var (
-	openEFIVar = openEFIVarImpl
+	openEFIVar   = openEFIVarImpl
+	writeEFIVar  = writeEFIVarImpl
+	deleteEFIVar = deleteEFIVarImpl
 )
 
 const expectedEFIvarfsDir = "/sys/firmware/efi/efivars"
@@ -58,24 +60,27 @@ const expectedEFIvarfsDir = "/sys/firmware/efi/efivars"
 // populated by shim.
 const loaderDevicePartUUID = "LoaderDevicePartUUID-4a67b082-0a4c-41cf-b6c7-440b29bb8c4f"
 
-func openEFIVarImpl(name string) (r io.ReadCloser, attr VariableAttr, size int64, err error) {
+// efivarfsVarPath returns the path of the given EFI variable in the efivars
+// filesystem, or ErrNoEFISystem if the efivars filesystem is not mounted.
+func efivarfsVarPath(name string) (string, error) {
 	mounts, err := osutil.LoadMountInfo()
 	if err != nil {
-		return nil, 0, 0, err
+		return "", err
 	}
-	found := false
 	for _, mnt := range mounts {
-		if mnt.MountDir == expectedEFIvarfsDir {
-			if mnt.FsType == "efivarfs" {
-				found = true
-				break
-			}
+		if mnt.MountDir == expectedEFIvarfsDir && mnt.FsType == "efivarfs" {
+			return filepath.Join(dirs.GlobalRootDir, expectedEFIvarfsDir, name), nil
 		}
 	}
-	if !found {
-		return nil, 0, 0, ErrNoEFISystem
+	return "", ErrNoEFISystem
+}
+
+func openEFIVarImpl(name string) (r io.ReadCloser, attr VariableAttr, size int64, err error) {
+	varPath, err := efivarfsVarPath(name)
+	if err != nil {
+		return nil, 0, 0, err
 	}
-	varf, err := os.Open(filepath.Join(dirs.GlobalRootDir, expectedEFIvarfsDir, name))
+	varf, err := os.Open(varPath)
 	if err != nil {
 		return nil, 0, 0, err
 	}
@@ -103,6 +108,74 @@ func cannotReadError(name string, err error) error {
 	return fmt.Errorf("cannot read EFI var %q: %v", name, err)
 }
 
+// clearImmutable drops the immutable flag that efivarfs sets on most variable
+// files, which would otherwise prevent modifying or removing them.
+func clearImmutable(varPath string) error {
+	varf, err := os.Open(varPath)
+	if err != nil {
+		return err
+	}
+	defer varf.Close()
+	attr, err := osutil.GetAttr(varf)
+	if err != nil {
+		// not all filesystems support file attributes, in which case
+		// the file cannot be immutable either
+		return nil
+	}
+	if attr&osutil.FS_IMMUTABLE_FL == 0 {
+		return nil
+	}
+	return osutil.SetAttr(varf, attr&^osutil.FS_IMMUTABLE_FL)
+}
+
+func deleteEFIVarImpl(name string) error {
+	varPath, err := efivarfsVarPath(name)
+	if err != nil {
+		return err
+	}
+	if err := clearImmutable(varPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := os.Remove(varPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func writeEFIVarImpl(name string, attr VariableAttr, data []byte) error {
+	varPath, err := efivarfsVarPath(name)
+	if err != nil {
+		return err
+	}
+	// efivarfs replaces the variable on write only when the append
+	// attribute is not set, but does not support truncating the file, so
+	// delete the variable first as libefivar does
+	if err := deleteEFIVarImpl(name); err != nil {
+		return err
+	}
+	// the attributes and the value must be written at once
+	buf := make([]byte, 4+len(data))
+	binary.LittleEndian.PutUint32(buf, uint32(attr))
+	copy(buf[4:], data)
+
+	varf, err := os.OpenFile(varPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := varf.Write(buf); err != nil {
+		varf.Close()
+		return err
+	}
+	return varf.Close()
+}
+
+func cannotWriteError(name string, err error) error {
+	return fmt.Errorf("cannot write EFI var %q: %v", name, err)
+}
+
 // ReadVarBytes will attempt to read the bytes of the value of the
 // specified EFI variable, specified by its full name composed of the
 // variable name and vendor ID. It also returns the attribute value
@@ -165,6 +238,46 @@ func ReadVarString(name string) (string, VariableAttr, error) {
 	return b.String(), attr, nil
 }
 
+// WriteVarBytes will attempt to write the value of the specified EFI
+// variable, specified by its full name composed of the variable name and
+// vendor ID, with the given attributes. Any existing value is replaced. It
+// expects to use the efivars filesystem at /sys/firmware/efi/efivars.
+func WriteVarBytes(name string, attr VariableAttr, data []byte) error {
+	if err := writeEFIVar(name, attr, data); err != nil {
+		if err == ErrNoEFISystem {
+			return err
+		}
+		return cannotWriteError(name, err)
+	}
+	return nil
+}
+
+// WriteVarString will attempt to write the string value of the specified
+// EFI variable, specified by its full name composed of the variable name and
+// vendor ID, with the given attributes. The string is encoded as a NUL
+// terminated UTF16 string, as expected by systemd-boot and ReadVarString.
+func WriteVarString(name string, attr VariableAttr, value string) error {
+	r16 := append(utf16.Encode([]rune(value)), 0)
+	b := &bytes.Buffer{}
+	if err := binary.Write(b, binary.LittleEndian, r16); err != nil {
+		return cannotWriteError(name, err)
+	}
+	return WriteVarBytes(name, attr, b.Bytes())
+}
+
+// DeleteVar will attempt to delete the specified EFI variable, specified by
+// its full name composed of the variable name and vendor ID. It is not an
+// error if the variable does not exist.
+func DeleteVar(name string) error {
+	if err := deleteEFIVar(name); err != nil {
+		if err == ErrNoEFISystem {
+			return err
+		}
+		return fmt.Errorf("cannot delete EFI var %q: %v", name, err)
+	}
+	return nil
+}
+
 // ReadLoaderDevicePartUUID reads the EFI LoaderDevicePartUUID variable and
 // returns the partition UUID as a lowercase string. It returns ("", ErrNoEFISystem)
 // when EFI is not available.
@@ -178,10 +291,13 @@ func ReadLoaderDevicePartUUID() (string, error) {
 }
 
 // MockVars mocks EFI variables as read by ReadVar*, only to be used
-// from tests. Set vars to nil to mock a non-EFI system.
+// from tests. Set vars to nil to mock a non-EFI system. Variables written
+// or deleted with WriteVar* and DeleteVar update the given maps.
 func MockVars(vars map[string][]byte, attrs map[string]VariableAttr) (restore func()) {
 	osutil.MustBeTestBinary("MockVars only to be used from tests")
 	old := openEFIVar
+	oldWrite := writeEFIVar
+	oldDelete := deleteEFIVar
 	openEFIVar = func(name string) (io.ReadCloser, VariableAttr, int64, error) {
 		if vars == nil {
 			return nil, 0, 0, ErrNoEFISystem
@@ -195,8 +311,28 @@ func MockVars(vars map[string][]byte, attrs map[string]VariableAttr) (restore fu
 		}
 		return nil, 0, 0, fmt.Errorf("EFI variable %s not mocked", name)
 	}
+	writeEFIVar = func(name string, attr VariableAttr, data []byte) error {
+		if vars == nil {
+			return ErrNoEFISystem
+		}
+		vars[name] = append([]byte(nil), data...)
+		if attrs != nil {
+			attrs[name] = attr
+		}
+		return nil
+	}
+	deleteEFIVar = func(name string) error {
+		if vars == nil {
+			return ErrNoEFISystem
+		}
+		delete(vars, name)
+		delete(attrs, name)
+		return nil
+	}
 
 	return func() {
 		openEFIVar = old
+		writeEFIVar = oldWrite
+		deleteEFIVar = oldDelete
 	}
 }
@@ -171,3 +171,82 @@ func (s *efiVarsSuite) TestStringOddSize(c *C) {
 	_, _, err := efi.ReadVarString("a")
 	c.Check(err, ErrorMatches, `EFI var "a" is not a valid UTF16 string, it has an extra byte`)
 }
+
+func (s *efiVarsSuite) TestWriteVarBytes(c *C) {
+	varPath := filepath.Join(s.rootdir, "/sys/firmware/efi/efivars", "my-cool-efi-var")
+	err := os.WriteFile(varPath, []byte("\x06\x00\x00\x00\x01\x02\x03\x04"), 0644)
+	c.Assert(err, IsNil)
+
+	err = efi.WriteVarBytes("my-cool-efi-var", efi.VariableNonVolatile|efi.VariableBootServiceAccess|efi.VariableRuntimeAccess, []byte("\x05"))
+	c.Assert(err, IsNil)
+	c.Check(varPath, testutil.FileEquals, "\x07\x00\x00\x00\x05")
+
+	data, attr, err := efi.ReadVarBytes("my-cool-efi-var")
+	c.Assert(err, IsNil)
+	c.Check(attr, Equals, efi.VariableNonVolatile|efi.VariableBootServiceAccess|efi.VariableRuntimeAccess)
+	c.Check(string(data), Equals, "\x05")
+}
+
+func (s *efiVarsSuite) TestWriteVarString(c *C) {
+	err := efi.WriteVarString("my-cool-efi-var", efi.VariableBootServiceAccess|efi.VariableRuntimeAccess, "foo.conf")
+	c.Assert(err, IsNil)
+
+	varPath := filepath.Join(s.rootdir, "/sys/firmware/efi/efivars", "my-cool-efi-var")
+	c.Check(varPath, testutil.FileEquals, append([]byte("\x06\x00\x00\x00"), bootloadertest.UTF16Bytes("foo.conf")...))
+
+	v, _, err := efi.ReadVarString("my-cool-efi-var")
+	c.Assert(err, IsNil)
+	c.Check(v, Equals, "foo.conf")
+}
+
+func (s *efiVarsSuite) TestDeleteVar(c *C) {
+	varPath := filepath.Join(s.rootdir, "/sys/firmware/efi/efivars", "my-cool-efi-var")
+	err := os.WriteFile(varPath, []byte("\x06\x00\x00\x00\x01"), 0644)
+	c.Assert(err, IsNil)
+
+	c.Assert(efi.DeleteVar("my-cool-efi-var"), IsNil)
+	c.Check(varPath, testutil.FileAbsent)
+
+	// deleting a missing variable is not an error
+	c.Assert(efi.DeleteVar("my-cool-efi-var"), IsNil)
+}
+
+func (s *efiVarsSuite) TestWriteNoEFISystem(c *C) {
+	// no efivarfs
+	osutil.MockMountInfo("")
+
+	err := efi.WriteVarString("my-cool-efi-var", efi.VariableRuntimeAccess, "foo")
+	c.Check(err, Equals, efi.ErrNoEFISystem)
+	err = efi.DeleteVar("my-cool-efi-var")
+	c.Check(err, Equals, efi.ErrNoEFISystem)
+}
+
+func (s *efiVarsSuite) TestWriteError(c *C) {
+	if os.Geteuid() == 0 {
+		c.Skip("permissions are not enforced for root")
+	}
+	efivarsDir := filepath.Join(s.rootdir, "/sys/firmware/efi/efivars")
+	c.Assert(os.Chmod(efivarsDir, 0500), IsNil)
+	defer os.Chmod(efivarsDir, 0755)
+
+	err := efi.WriteVarString("my-cool-efi-var", efi.VariableRuntimeAccess, "foo")
+	c.Check(err, ErrorMatches, `cannot write EFI var "my-cool-efi-var": open .*: permission denied`)
+}
+
+func (s *efiVarsSuite) TestMockVarsWrite(c *C) {
+	vars := map[string][]byte{
+		"a": []byte("\x01"),
+	}
+	attrs := map[string]efi.VariableAttr{}
+	restore := efi.MockVars(vars, attrs)
+	defer restore()
+
+	c.Assert(efi.WriteVarString("b", efi.VariableNonVolatile, "foo"), IsNil)
+	c.Check(vars["b"], DeepEquals, bootloadertest.UTF16Bytes("foo"))
+	c.Check(attrs["b"], Equals, efi.VariableNonVolatile)
+
+	c.Assert(efi.DeleteVar("a"), IsNil)
+	c.Check(vars, HasLen, 1)
+	_, _, err := efi.ReadVarBytes("a")
+	c.Check(err, ErrorMatches, `cannot read EFI var "a": EFI variable a not mocked`)
+}
@@ -373,3 +373,7 @@ var (
 	ConfigAssetFrom                      = configAssetFrom
 	StaticCommandLineForGrubAssetEdition = staticCommandLineForGrubAssetEdition
 )
+
+func NewSystemdBoot(rootdir string, opts *Options) RecoveryAwareBootloader {
+	return newSystemdBoot(rootdir, opts).(RecoveryAwareBootloader)
+}
@@ -0,0 +1,923 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package bootloader
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/snapcore/snapd/arch"
+	"github.com/snapcore/snapd/bootloader/assets"
+	"github.com/snapcore/snapd/bootloader/efi"
+	"github.com/snapcore/snapd/bootloader/grubenv"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/kcmdline"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snapfile"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// systemdBoot implements the required interfaces
+var (
+	_ Bootloader                        = (*systemdBoot)(nil)
+	_ RecoveryAwareBootloader           = (*systemdBoot)(nil)
+	_ ExtractedRunKernelImageBootloader = (*systemdBoot)(nil)
+	_ TrustedAssetsBootloader           = (*systemdBoot)(nil)
+)
+
+const (
+	// systemdVendorGUID is the vendor ID of the EFI variables defined by
+	// the systemd boot loader interface.
+	systemdVendorGUID = "4a67b082-0a4c-41cf-b6c7-440b29bb8c4f"
+	// loaderEntryOneShotVar holds the ID of the loader entry systemd-boot
+	// boots next, the variable is removed by systemd-boot when read.
+	loaderEntryOneShotVar = "LoaderEntryOneShot-" + systemdVendorGUID
+	// loaderEntrySelectedVar holds the ID of the loader entry booted by
+	// systemd-boot for the current boot.
+	loaderEntrySelectedVar = "LoaderEntrySelected-" + systemdVendorGUID
+
+	// systemdBootKernelEntry is the loader entry of the run mode kernel,
+	// booted by default as set in the managed loader.conf.
+	systemdBootKernelEntry = "snapd-kernel.conf"
+	// systemdBootTryKernelEntryPrefix is the prefix of the loader entry of
+	// the kernel being tried, the entry is named after the kernel snap so
+	// that the ID of the entry booted for the current boot does not match a
+	// try kernel enabled afterwards.
+	systemdBootTryKernelEntryPrefix = "snapd-try-kernel-"
+	// systemdBootRecoveryEntryPrefix is the prefix of the loader entries
+	// of the recovery systems on the ESP, there is one entry for each mode
+	// a recovery system can be booted in.
+	systemdBootRecoveryEntryPrefix = "snapd-recovery-"
+
+	// systemdBootEnvFile is the file carrying the snapd boot variables,
+	// using the grubenv format.
+	systemdBootEnvFile = "snapd.env"
+
+	systemdBootConfigAsset = "systemd-boot-loader.conf"
+)
+
+// systemdBoot manages systemd-boot booting unified kernel images. The
+// recovery bootloader lives on the ESP (ubuntu-seed) with the systemd-boot
+// binary and its configuration, while the run mode bootloader manages the
+// loader entries and the extracted kernel images on the boot partition
+// (ubuntu-boot), which systemd-boot finds as an XBOOTLDR partition. The
+// recovery systems get loader entries on the ESP booting the kernel images
+// extracted next to their kernel snaps. Trying a kernel, or booting a
+// recovery system, is done by booting its loader entry once through the
+// LoaderEntryOneShot EFI variable.
+type systemdBoot struct {
+	rootdir string
+	// basedir is the root of the ESP or boot partition relative to
+	// rootdir
+	basedir string
+
+	runMode               bool
+	recovery              bool
+	nativePartitionLayout bool
+	prepareImageTime      bool
+}
+
+// newSystemdBoot creates a new systemd-boot bootloader object
+func newSystemdBoot(rootdir string, opts *Options) Bootloader {
+	b := &systemdBoot{rootdir: rootdir}
+	if opts != nil {
+		b.runMode = opts.Role == RoleRunMode
+		b.recovery = opts.Role == RoleRecovery
+		b.nativePartitionLayout = opts.NoSlashBoot || b.recovery
+		b.prepareImageTime = opts.PrepareImageTime
+	}
+	if b.runMode && !b.nativePartitionLayout {
+		// there is no /boot bind mount of the boot partition for
+		// systemd-boot, use the partition mount point instead
+		b.basedir = "run/mnt/ubuntu-boot"
+	}
+	return b
+}
+
+func (b *systemdBoot) Name() string {
+	return "systemd-boot"
+}
+
+func (b *systemdBoot) dir() string {
+	if b.rootdir == "" {
+		panic("internal error: unset rootdir")
+	}
+	return filepath.Join(b.rootdir, b.basedir)
+}
+
+func (b *systemdBoot) loaderConf() string {
+	return filepath.Join(b.dir(), "loader/loader.conf")
+}
+
+func (b *systemdBoot) entriesDir() string {
+	return filepath.Join(b.dir(), "loader/entries")
+}
+
+func (b *systemdBoot) envFile() string {
+	return filepath.Join(b.dir(), "loader", systemdBootEnvFile)
+}
+
+func (b *systemdBoot) kernelsDir() string {
+	return filepath.Join(b.dir(), "EFI/ubuntu")
+}
+
+// Present returns whether the configuration of systemd-boot is found for the
+// recovery bootloader, or the boot variables of snapd for the run mode one.
+func (b *systemdBoot) Present() (bool, error) {
+	if b.runMode {
+		return osutil.FileExists(b.envFile()), nil
+	}
+	return osutil.FileExists(b.loaderConf()), nil
+}
+
+func (b *systemdBoot) RequiredByGadget(gadgetDir string) bool {
+	return checkForBlMarker(b, gadgetDir)
+}
+
+func (b *systemdBoot) initEnv() error {
+	if osutil.FileExists(b.envFile()) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(b.envFile()), 0755); err != nil {
+		return err
+	}
+	return grubenv.NewEnv(b.envFile()).Save()
+}
+
+func (b *systemdBoot) InstallBootConfig(gadgetDir string, opts *Options) error {
+	switch {
+	case opts != nil && opts.Role == RoleRecovery:
+		// install managed config for the ESP
+		if err := genericSetBootConfigFromAsset(b.loaderConf(), systemdBootConfigAsset); err != nil {
+			return err
+		}
+	case opts != nil && opts.Role == RoleRunMode:
+		// the loader entries are written when the kernel is enabled
+		if err := os.MkdirAll(b.entriesDir(), 0755); err != nil {
+			return err
+		}
+	default:
+		gadgetFile := filepath.Join(gadgetDir, b.Name()+".conf")
+		if err := genericInstallBootConfig(gadgetFile, b.loaderConf()); err != nil {
+			return err
+		}
+	}
+	return b.initEnv()
+}
+
+// SetRecoverySystemEnv saves the given variables of the recovery system and
+// makes it bootable by systemd-boot. The kernel image of the recovery kernel
+// snap is extracted next to the snap file on the ESP, as systemd-boot cannot
+// load it from the snap, and a loader entry is written for each mode the
+// recovery system can be booted in.
+func (b *systemdBoot) SetRecoverySystemEnv(recoverySystemDir string, values map[string]string) error {
+	if recoverySystemDir == "" {
+		return fmt.Errorf("internal error: recoverySystemDir unset")
+	}
+	recoverySystemEnv := filepath.Join(b.rootdir, recoverySystemDir, systemdBootEnvFile)
+	if err := os.MkdirAll(filepath.Dir(recoverySystemEnv), 0755); err != nil {
+		return err
+	}
+	env := grubenv.NewEnv(recoverySystemEnv)
+	for k, v := range values {
+		env.Set(k, v)
+	}
+	if err := env.Save(); err != nil {
+		return err
+	}
+
+	label := filepath.Base(recoverySystemDir)
+	kernelSnap := env.Get("snapd_recovery_kernel")
+	if kernelSnap == "" {
+		return fmt.Errorf("internal error: recovery kernel of system %q unset", label)
+	}
+	if err := b.extractRecoveryKernelImage(kernelSnap); err != nil {
+		return fmt.Errorf("cannot extract kernel image of recovery system %q: %v", label, err)
+	}
+	for _, mode := range systemdBootRecoveryModes {
+		if err := b.writeRecoveryEntry(label, mode.mode, mode.sortKey, kernelSnap, env); err != nil {
+			return err
+		}
+	}
+	return b.removeStaleRecoveryEntries()
+}
+
+func (b *systemdBoot) GetRecoverySystemEnv(recoverySystemDir string, key string) (string, error) {
+	if recoverySystemDir == "" {
+		return "", fmt.Errorf("internal error: recoverySystemDir unset")
+	}
+	recoverySystemEnv := filepath.Join(b.rootdir, recoverySystemDir, systemdBootEnvFile)
+	env := grubenv.NewEnv(recoverySystemEnv)
+	if err := env.Load(); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return env.Get(key), nil
+}
+
+// GetBootVars returns the values of the given boot variables. As
+// systemd-boot cannot modify the boot variables, a "try" kernel_status is
+// reported as "trying" when the current boot used the loader entry of the
+// try kernel, which is what the grub boot script would have recorded.
+func (b *systemdBoot) GetBootVars(names ...string) (map[string]string, error) {
+	env := grubenv.NewEnv(b.envFile())
+	if err := env.Load(); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string, len(names))
+	for _, name := range names {
+		out[name] = env.Get(name)
+	}
+	if out["kernel_status"] == "try" && b.bootedTryKernelEntry() {
+		out["kernel_status"] = "trying"
+	}
+	return out, nil
+}
+
+// bootedTryKernelEntry returns whether the current boot used the loader
+// entry of the currently enabled try kernel.
+func (b *systemdBoot) bootedTryKernelEntry() bool {
+	tryEntry, err := b.tryKernelEntry()
+	if err != nil {
+		return false
+	}
+	selected, _, err := efi.ReadVarString(loaderEntrySelectedVar)
+	if err != nil {
+		// not booted by systemd-boot
+		return false
+	}
+	return selected == tryEntry
+}
+
+// SetBootVars sets the given boot variables. Changes to the kernel command
+// line are propagated to the loader entries, while setting kernel_status to
+// "try" makes systemd-boot boot the try kernel once on the next boot.
+// Likewise, setting snapd_recovery_mode on the recovery bootloader makes
+// systemd-boot boot the loader entry of snapd_recovery_system in that mode
+// once.
+func (b *systemdBoot) SetBootVars(values map[string]string) error {
+	env := grubenv.NewEnv(b.envFile())
+	if err := env.Load(); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	cmdlineChanged := false
+	for k, v := range values {
+		if env.Get(k) == v {
+			continue
+		}
+		env.Set(k, v)
+		if k == "snapd_extra_cmdline_args" || k == "snapd_full_cmdline_args" {
+			cmdlineChanged = true
+		}
+	}
+	if err := env.Save(); err != nil {
+		return err
+	}
+
+	if cmdlineChanged {
+		if err := b.updateEntriesCommandLine(env); err != nil {
+			return err
+		}
+	}
+	if status, ok := values["kernel_status"]; ok {
+		return b.setTryKernelBoot(status == "try")
+	}
+	// the recovery system to boot is only selected on a running system,
+	// the EFI variables are not those of the target system when
+	// preparing an image
+	if mode, ok := values["snapd_recovery_mode"]; ok && !b.runMode && !b.prepareImageTime {
+		return b.setRecoveryBoot(mode, env.Get("snapd_recovery_system"))
+	}
+	return nil
+}
+
+// setTryKernelBoot sets or clears the LoaderEntryOneShot EFI variable to boot
+// the try kernel on the next boot.
+func (b *systemdBoot) setTryKernelBoot(try bool) error {
+	if !try {
+		err := efi.DeleteVar(loaderEntryOneShotVar)
+		if err != nil && err != efi.ErrNoEFISystem {
+			return err
+		}
+		return nil
+	}
+	tryEntry, err := b.tryKernelEntry()
+	if err != nil {
+		return fmt.Errorf("cannot boot try kernel: %v", err)
+	}
+	attr := efi.VariableNonVolatile | efi.VariableBootServiceAccess | efi.VariableRuntimeAccess
+	if err := efi.WriteVarString(loaderEntryOneShotVar, attr, tryEntry); err != nil {
+		return fmt.Errorf("cannot boot try kernel: %v", err)
+	}
+	return nil
+}
+
+func (b *systemdBoot) ExtractKernelAssets(s snap.PlaceInfo, snapf snap.Container) error {
+	// the kernel images of recovery systems are extracted when their
+	// environment is set
+	if !b.runMode {
+		return nil
+	}
+	return extractKernelAssetsToBootDir(
+		filepath.Join(b.kernelsDir(), s.Filename()),
+		snapf,
+		[]string{"kernel.efi"},
+	)
+}
+
+func (b *systemdBoot) RemoveKernelAssets(s snap.PlaceInfo) error {
+	return removeKernelAssetsFromBootDir(b.kernelsDir(), s)
+}
+
+// ExtractedRunKernelImageBootloader helper methods
+
+func tryKernelEntryName(s snap.PlaceInfo) string {
+	return systemdBootTryKernelEntryPrefix + strings.TrimSuffix(s.Filename(), ".snap") + ".conf"
+}
+
+// tryKernelEntry returns the name of the loader entry of the try kernel.
+func (b *systemdBoot) tryKernelEntry() (string, error) {
+	matches, err := filepath.Glob(filepath.Join(b.entriesDir(), systemdBootTryKernelEntryPrefix+"*.conf"))
+	if err != nil {
+		return "", err
+	}
+	switch len(matches) {
+	case 0:
+		return "", ErrNoTryKernelRef
+	case 1:
+		return filepath.Base(matches[0]), nil
+	default:
+		return "", fmt.Errorf("cannot use multiple try kernel loader entries")
+	}
+}
+
+// kernelEfiPath returns the path of the extracted kernel image of the given
+// kernel snap, relative to the root of the partition.
+func kernelEfiPath(s snap.PlaceInfo) string {
+	return filepath.Join("/EFI/ubuntu", s.Filename(), "kernel.efi")
+}
+
+// recoveryKernelEfiPath returns the path of the kernel image extracted from
+// the given recovery kernel snap, next to the snap file.
+func recoveryKernelEfiPath(kernelSnap string) string {
+	return strings.TrimSuffix(kernelSnap, ".snap") + ".efi"
+}
+
+// systemdBootRecoveryModes are the modes a recovery system can be booted in,
+// with the sort keys of their loader entries. Loader entries with a sort key
+// are listed before those without one, so the install entry is listed first
+// on a system which has no run mode loader entry yet.
+var systemdBootRecoveryModes = []struct {
+	mode    string
+	sortKey string
+}{
+	{"install", "snapd-1-install"},
+	{"recover", "snapd-2-recover"},
+	{"factory-reset", "snapd-3-factory-reset"},
+}
+
+func recoveryEntryName(label, mode string) string {
+	return systemdBootRecoveryEntryPrefix + label + "-" + mode + ".conf"
+}
+
+// extractRecoveryKernelImage extracts the kernel image of the given recovery
+// kernel snap, unless it was extracted already for another recovery system.
+func (b *systemdBoot) extractRecoveryKernelImage(kernelSnap string) error {
+	dst := filepath.Join(b.rootdir, recoveryKernelEfiPath(kernelSnap))
+	if osutil.FileExists(dst) {
+		return nil
+	}
+	snapf, err := snapfile.Open(filepath.Join(b.rootdir, kernelSnap))
+	if err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dst), ".kernel-efi-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := extractKernelAssetsToBootDir(tmpDir, snapf, []string{"kernel.efi"}); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(tmpDir, "kernel.efi"), dst)
+}
+
+func (b *systemdBoot) writeRecoveryEntry(label, mode, sortKey, kernelSnap string, env *grubenv.Env) error {
+	pieces := CommandLineComponents{
+		ModeArg:   "snapd_recovery_mode=" + mode,
+		SystemArg: "snapd_recovery_system=" + label,
+	}
+	if full := env.Get("snapd_full_cmdline_args"); full != "" {
+		pieces.FullArgs = full
+	} else {
+		pieces.ExtraArgs = env.Get("snapd_extra_cmdline_args")
+	}
+	cmdline, err := b.CommandLine(pieces)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "title Ubuntu Core %s using %s\n", mode, label)
+	fmt.Fprintf(&buf, "sort-key %s\n", sortKey)
+	fmt.Fprintf(&buf, "version %s\n", label)
+	fmt.Fprintf(&buf, "efi %s\n", recoveryKernelEfiPath(kernelSnap))
+	fmt.Fprintf(&buf, "options %s\n", cmdline)
+
+	if err := os.MkdirAll(b.entriesDir(), 0755); err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(filepath.Join(b.entriesDir(), recoveryEntryName(label, mode)), buf.Bytes(), 0644, 0)
+}
+
+// removeStaleRecoveryEntries removes the loader entries of the recovery
+// systems which no longer exist.
+func (b *systemdBoot) removeStaleRecoveryEntries() error {
+	systems, err := filepath.Glob(filepath.Join(b.dir(), "systems/*"))
+	if err != nil {
+		return err
+	}
+	keep := make(map[string]bool, len(systems)*len(systemdBootRecoveryModes))
+	for _, system := range systems {
+		for _, mode := range systemdBootRecoveryModes {
+			keep[recoveryEntryName(filepath.Base(system), mode.mode)] = true
+		}
+	}
+	entries, err := filepath.Glob(filepath.Join(b.entriesDir(), systemdBootRecoveryEntryPrefix+"*.conf"))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if keep[filepath.Base(entry)] {
+			continue
+		}
+		if err := os.Remove(entry); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// setRecoveryBoot makes systemd-boot boot the given recovery system in the
+// given mode once on the next boot, through the LoaderEntryOneShot EFI
+// variable. For the run mode, a recovery loader entry set to be booted is
+// cleared instead.
+func (b *systemdBoot) setRecoveryBoot(mode, label string) error {
+	if mode == "" || mode == "run" {
+		oneShot, _, err := efi.ReadVarString(loaderEntryOneShotVar)
+		if err != nil || !strings.HasPrefix(oneShot, systemdBootRecoveryEntryPrefix) {
+			// not set or not a recovery system
+			return nil
+		}
+		return efi.DeleteVar(loaderEntryOneShotVar)
+	}
+	if label == "" {
+		return fmt.Errorf("cannot boot recovery system in mode %q: recovery system unset", mode)
+	}
+	entry := recoveryEntryName(label, mode)
+	if !osutil.FileExists(filepath.Join(b.entriesDir(), entry)) {
+		return fmt.Errorf("cannot boot recovery system %q in mode %q: loader entry %s does not exist", label, mode, entry)
+	}
+	attr := efi.VariableNonVolatile | efi.VariableBootServiceAccess | efi.VariableRuntimeAccess
+	if err := efi.WriteVarString(loaderEntryOneShotVar, attr, entry); err != nil {
+		return fmt.Errorf("cannot boot recovery system %q in mode %q: %v", label, mode, err)
+	}
+	return nil
+}
+
+// entryCommandLine returns the kernel command line of the run mode loader
+// entries, built like the grub boot script does from the boot variables.
+func (b *systemdBoot) entryCommandLine(env *grubenv.Env) (string, error) {
+	pieces := CommandLineComponents{
+		ModeArg: "snapd_recovery_mode=run",
+	}
+	if full := env.Get("snapd_full_cmdline_args"); full != "" {
+		pieces.FullArgs = full
+	} else {
+		pieces.ExtraArgs = env.Get("snapd_extra_cmdline_args")
+	}
+	return b.CommandLine(pieces)
+}
+
+func (b *systemdBoot) writeKernelEntry(name string, s snap.PlaceInfo, env *grubenv.Env) error {
+	// check that the kernel snap has been extracted already so we don't
+	// inadvertently create an entry that cannot boot
+	efiPath := kernelEfiPath(s)
+	if !osutil.FileExists(filepath.Join(b.dir(), efiPath)) {
+		return fmt.Errorf("cannot enable %s at %s: %v", name, efiPath, os.ErrNotExist)
+	}
+	cmdline, err := b.entryCommandLine(env)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "title Ubuntu Core\n")
+	fmt.Fprintf(&buf, "version %s\n", strings.TrimSuffix(s.Filename(), ".snap"))
+	fmt.Fprintf(&buf, "efi %s\n", efiPath)
+	fmt.Fprintf(&buf, "options %s\n", cmdline)
+
+	if err := os.MkdirAll(b.entriesDir(), 0755); err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(filepath.Join(b.entriesDir(), name), buf.Bytes(), 0644, 0)
+}
+
+func (b *systemdBoot) readKernelEntry(name string) (snap.PlaceInfo, error) {
+	entry := filepath.Join(b.entriesDir(), name)
+	f, err := os.Open(entry)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read loader entry %s: %v", name, err)
+	}
+	defer f.Close()
+
+	var efiPath string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if key == "efi" {
+			efiPath = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read loader entry %s: %v", name, err)
+	}
+	if efiPath == "" {
+		return nil, fmt.Errorf("cannot find kernel image in loader entry %s", name)
+	}
+	// check that the entry can boot before continuing
+	if !osutil.FileExists(filepath.Join(b.dir(), efiPath)) {
+		return nil, fmt.Errorf("cannot use loader entry %s: %s does not exist", name, efiPath)
+	}
+
+	kernelSnapFileName := filepath.Base(filepath.Dir(efiPath))
+	sn, err := snap.ParsePlaceInfoFromSnapFileName(kernelSnapFileName)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot parse kernel snap file name from loader entry %s: %v",
+			name,
+			err,
+		)
+	}
+	return sn, nil
+}
+
+// updateEntriesCommandLine rewrites the existing loader entries with the
+// kernel command line from the given boot variables.
+func (b *systemdBoot) updateEntriesCommandLine(env *grubenv.Env) error {
+	names := []string{systemdBootKernelEntry}
+	if tryEntry, err := b.tryKernelEntry(); err == nil {
+		names = append(names, tryEntry)
+	}
+	for _, name := range names {
+		if !osutil.FileExists(filepath.Join(b.entriesDir(), name)) {
+			continue
+		}
+		s, err := b.readKernelEntry(name)
+		if err != nil {
+			return err
+		}
+		if err := b.writeKernelEntry(name, s, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *systemdBoot) loadEnvForEntries() (*grubenv.Env, error) {
+	env := grubenv.NewEnv(b.envFile())
+	if err := env.Load(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return env, nil
+}
+
+// actual ExtractedRunKernelImageBootloader methods
+
+// EnableKernel writes the default loader entry, booting the referenced
+// kernel snap. EnableKernel() will fail if the kernel image of the
+// referenced kernel snap was not extracted.
+func (b *systemdBoot) EnableKernel(s snap.PlaceInfo) error {
+	env, err := b.loadEnvForEntries()
+	if err != nil {
+		return err
+	}
+	return b.writeKernelEntry(systemdBootKernelEntry, s, env)
+}
+
+// EnableTryKernel writes the loader entry of the try kernel, booting the
+// referenced kernel snap. The entry is only booted once kernel_status is set
+// to "try". EnableTryKernel() will fail if the kernel image of the referenced
+// kernel snap was not extracted.
+func (b *systemdBoot) EnableTryKernel(s snap.PlaceInfo) error {
+	env, err := b.loadEnvForEntries()
+	if err != nil {
+		return err
+	}
+	name := tryKernelEntryName(s)
+	if err := b.writeKernelEntry(name, s, env); err != nil {
+		return err
+	}
+	// drop the entries of previously tried kernels
+	return b.removeTryKernelEntries(name)
+}
+
+func (b *systemdBoot) removeTryKernelEntries(except string) error {
+	matches, err := filepath.Glob(filepath.Join(b.entriesDir(), systemdBootTryKernelEntryPrefix+"*.conf"))
+	if err != nil {
+		return err
+	}
+	for _, m := range matches {
+		if filepath.Base(m) == except {
+			continue
+		}
+		if err := os.Remove(m); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// DisableTryKernel removes the loader entry of the try kernel if it exists.
+// A LoaderEntryOneShot EFI variable left behind then makes systemd-boot
+// fall back to the default entry.
+func (b *systemdBoot) DisableTryKernel() error {
+	return b.removeTryKernelEntries("")
+}
+
+// Kernel returns the kernel snap booted by the default loader entry.
+func (b *systemdBoot) Kernel() (snap.PlaceInfo, error) {
+	return b.readKernelEntry(systemdBootKernelEntry)
+}
+
+// TryKernel returns the kernel snap currently being tried if it exists and
+// ErrNoTryKernelRef if there is no loader entry for a try kernel. Note if the
+// entry exists but its kernel image does not an error will be returned.
+func (b *systemdBoot) TryKernel() (snap.PlaceInfo, error) {
+	tryEntry, err := b.tryKernelEntry()
+	if err != nil {
+		return nil, err
+	}
+	return b.readKernelEntry(tryEntry)
+}
+
+// UpdateBootConfig updates the systemd-boot configuration on the ESP only if
+// it is already managed and has a lower edition. The run mode bootloader has
+// no configuration of its own.
+//
+// Implements TrustedAssetsBootloader for the systemd-boot bootloader.
+func (b *systemdBoot) UpdateBootConfig() (bool, error) {
+	if b.runMode {
+		return false, nil
+	}
+	return genericUpdateBootConfigFromAssets(b.loaderConf(), systemdBootConfigAsset)
+}
+
+// ManagedAssets returns a list relative paths to boot assets inside the root
+// directory of the filesystem.
+//
+// Implements TrustedAssetsBootloader for the systemd-boot bootloader.
+func (b *systemdBoot) ManagedAssets() []string {
+	if b.runMode {
+		return nil
+	}
+	return []string{
+		filepath.Join(b.basedir, "loader/loader.conf"),
+	}
+}
+
+func (b *systemdBoot) staticCommandLine() (string, error) {
+	edition, err := editionFromInternalConfigAsset(systemdBootConfigAsset)
+	if err != nil {
+		return "", err
+	}
+	cmdline := assets.SnippetForEdition(systemdBootConfigAsset+":static-cmdline", edition)
+	return string(cmdline), nil
+}
+
+// CommandLine returns the kernel command line composed of mode and system
+// arguments, followed by either the built-in static arguments and any extra
+// arguments or a separate set of arguments provided in the components. The
+// static arguments are those of the current edition of the managed
+// configuration, as the loader entries carrying the command line are
+// rewritten by snapd whenever it changes.
+//
+// Implements TrustedAssetsBootloader for the systemd-boot bootloader.
+func (b *systemdBoot) CommandLine(pieces CommandLineComponents) (string, error) {
+	if err := pieces.Validate(); err != nil {
+		return "", err
+	}
+
+	var nonSnapdCmdline string
+	if pieces.FullArgs == "" {
+		staticCmdline, err := b.staticCommandLine()
+		if err != nil {
+			return "", err
+		}
+		keepDefaultArgs := kcmdline.RemoveMatchingFilter(staticCmdline, pieces.RemoveArgs)
+		nonSnapdCmdline = strutil.JoinNonEmpty(append(keepDefaultArgs, pieces.ExtraArgs), " ")
+	} else {
+		nonSnapdCmdline = pieces.FullArgs
+	}
+	args, err := kcmdline.Split(nonSnapdCmdline)
+	if err != nil {
+		return "", fmt.Errorf("cannot use badly formatted kernel command line: %v", err)
+	}
+	snapdArgs := make([]string, 0, 2)
+	if pieces.ModeArg != "" {
+		snapdArgs = append(snapdArgs, pieces.ModeArg)
+	}
+	if pieces.SystemArg != "" {
+		snapdArgs = append(snapdArgs, pieces.SystemArg)
+	}
+	return strings.Join(append(snapdArgs, args...), " "), nil
+}
+
+// CandidateCommandLine is the same as CommandLine, the loader entries always
+// use the command line of the managed built-in boot assets.
+//
+// Implements TrustedAssetsBootloader for the systemd-boot bootloader.
+func (b *systemdBoot) CandidateCommandLine(pieces CommandLineComponents) (string, error) {
+	return b.CommandLine(pieces)
+}
+
+// DefaultCommandLine returns the default kernel command-line used by
+// the bootloader excluding the recovery mode and system parameters.
+func (b *systemdBoot) DefaultCommandLine(candidate bool) (string, error) {
+	return b.staticCommandLine()
+}
+
+// systemdBootAssetPath contains the paths for assets in the boot chain.
+type systemdBootAssetPath struct {
+	defaultBinary taggedPath
+	systemdBinary taggedPath
+}
+
+// systemdBootAssetsForArch contains the paths of the systemd-boot binary on
+// the ESP, either installed as the default boot binary or at its own
+// location, for different architectures.
+var systemdBootAssetsForArch = map[string]systemdBootAssetPath{
+	"amd64": {
+		defaultBinary: taggedPath{
+			tag:  "boot",
+			path: filepath.Join("EFI/boot/", "bootx64.efi"),
+		},
+		systemdBinary: taggedPath{
+			tag:  "systemd",
+			path: filepath.Join("EFI/systemd/", "systemd-bootx64.efi"),
+		},
+	},
+	"arm64": {
+		defaultBinary: taggedPath{
+			tag:  "boot",
+			path: filepath.Join("EFI/boot/", "bootaa64.efi"),
+		},
+		systemdBinary: taggedPath{
+			tag:  "systemd",
+			path: filepath.Join("EFI/systemd/", "systemd-bootaa64.efi"),
+		},
+	},
+}
+
+func (b *systemdBoot) getBootAssetsForArch() (*systemdBootAssetPath, error) {
+	if b.prepareImageTime {
+		return nil, fmt.Errorf("internal error: retrieving boot assets at prepare image time")
+	}
+	archi := arch.DpkgArchitecture()
+	assets, ok := systemdBootAssetsForArch[archi]
+	if !ok {
+		return nil, fmt.Errorf("cannot find systemd-boot assets for %q", archi)
+	}
+	return &assets, nil
+}
+
+// getRecoveryModeTrustedAssets returns the list of ordered asset chains
+// loading systemd-boot from the ESP.
+func (b *systemdBoot) getRecoveryModeTrustedAssets() ([][]taggedPath, error) {
+	assets, err := b.getBootAssetsForArch()
+	if err != nil {
+		return nil, err
+	}
+	return [][]taggedPath{{assets.systemdBinary}, {assets.defaultBinary}}, nil
+}
+
+// TrustedAssets returns the map of relative paths to asset identifers. The
+// relative paths are relative to the bootloader's rootdir. The run mode
+// bootloader has no trusted assets, as systemd-boot directly loads the kernel
+// image from the boot partition.
+func (b *systemdBoot) TrustedAssets() (map[string]string, error) {
+	if !b.nativePartitionLayout {
+		return nil, fmt.Errorf("internal error: trusted assets called without native host-partition layout")
+	}
+	ret := make(map[string]string)
+	if !b.recovery {
+		return ret, nil
+	}
+	chains, err := b.getRecoveryModeTrustedAssets()
+	if err != nil {
+		return nil, err
+	}
+	for _, chain := range chains {
+		for _, asset := range chain {
+			ret[asset.path] = asset.Id()
+		}
+	}
+	return ret, nil
+}
+
+// RecoveryBootChains returns the list of load chains for recovery modes.
+// It should be called on a RoleRecovery bootloader.
+func (b *systemdBoot) RecoveryBootChains(kernelPath string) ([][]BootFile, error) {
+	if !b.recovery {
+		return nil, fmt.Errorf("not a recovery bootloader")
+	}
+
+	assetsSet, err := b.getRecoveryModeTrustedAssets()
+	if err != nil {
+		return nil, err
+	}
+	chains := make([][]BootFile, 0, len(assetsSet))
+	for _, assets := range assetsSet {
+		chain := make([]BootFile, 0, len(assets)+1)
+		for _, ta := range assets {
+			chain = append(chain, NewBootFile("", ta.path, RoleRecovery))
+		}
+		// add the kernel image extracted next to the recovery kernel
+		// snap, which is what the recovery loader entries boot
+		chain = append(chain, NewBootFile("", recoveryKernelEfiPath(kernelPath), RoleRecovery))
+		chains = append(chains, chain)
+	}
+
+	return chains, nil
+}
+
+// BootChains returns the list of load chains for run mode.
+// It should be called on a RoleRecovery bootloader passing the
+// RoleRunMode bootloader.
+func (b *systemdBoot) BootChains(runBl Bootloader, kernelPath string) ([][]BootFile, error) {
+	if !b.recovery {
+		return nil, fmt.Errorf("not a recovery bootloader")
+	}
+	sdRunBl, ok := runBl.(*systemdBoot)
+	if !ok {
+		return nil, fmt.Errorf("run mode bootloader must be %s", b.Name())
+	}
+	kernel, err := snap.ParsePlaceInfoFromSnapFileName(filepath.Base(kernelPath))
+	if err != nil {
+		return nil, err
+	}
+	// systemd-boot loads the kernel image extracted on the boot partition
+	kernelEfi := filepath.Join(sdRunBl.dir(), kernelEfiPath(kernel))
+
+	assetsSet, err := b.getRecoveryModeTrustedAssets()
+	if err != nil {
+		return nil, err
+	}
+	chains := make([][]BootFile, 0, len(assetsSet))
+	for _, assets := range assetsSet {
+		chain := make([]BootFile, 0, len(assets)+1)
+		for _, ta := range assets {
+			chain = append(chain, NewBootFile("", ta.path, RoleRecovery))
+		}
+		chain = append(chain, NewBootFile("", kernelEfi, RoleRunMode))
+		chains = append(chains, chain)
+	}
+
+	return chains, nil
+}
+
+func (b *systemdBoot) RevocationTriggeringAssets() ([]string, error) {
+	if !b.recovery {
+		return nil, nil
+	}
+
+	assets, err := b.getBootAssetsForArch()
+	if err != nil {
+		return nil, err
+	}
+	return []string{assets.systemdBinary.Id(), assets.defaultBinary.Id()}, nil
+}
@@ -0,0 +1,653 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package bootloader_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/arch/archtest"
+	"github.com/snapcore/snapd/bootloader"
+	"github.com/snapcore/snapd/bootloader/assets"
+	"github.com/snapcore/snapd/bootloader/bootloadertest"
+	"github.com/snapcore/snapd/bootloader/efi"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snapfile"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+const (
+	loaderEntryOneShotVar  = "LoaderEntryOneShot-4a67b082-0a4c-41cf-b6c7-440b29bb8c4f"
+	loaderEntrySelectedVar = "LoaderEntrySelected-4a67b082-0a4c-41cf-b6c7-440b29bb8c4f"
+)
+
+type systemdBootTestSuite struct {
+	testutil.BaseTest
+
+	rootdir string
+	// espDir is the fake ubuntu-seed ESP
+	espDir string
+	// bootDir is the fake ubuntu-boot partition
+	bootDir    string
+	efivarsDir string
+}
+
+var _ = Suite(&systemdBootTestSuite{})
+
+func (s *systemdBootTestSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.rootdir = c.MkDir()
+	dirs.SetRootDir(s.rootdir)
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+
+	s.espDir = c.MkDir()
+	s.bootDir = c.MkDir()
+
+	// fake efivarfs
+	s.efivarsDir = filepath.Join(s.rootdir, "/sys/firmware/efi/efivars")
+	c.Assert(os.MkdirAll(s.efivarsDir, 0755), IsNil)
+	s.AddCleanup(osutil.MockMountInfo(
+		"38 24 0:32 / /sys/firmware/efi/efivars rw,nosuid,nodev,noexec,relatime shared:13 - efivarfs efivarfs rw"))
+
+	s.AddCleanup(snap.MockSanitizePlugsSlots(func(snapInfo *snap.Info) {}))
+	s.AddCleanup(archtest.MockArchitecture("amd64"))
+	s.AddCleanup(assets.MockSnippetsForEdition("systemd-boot-loader.conf:static-cmdline", []assets.ForEditions{
+		{FirstEdition: 1, Snippet: []byte("console=ttyS0 panic=-1")},
+	}))
+}
+
+func (s *systemdBootTestSuite) runBootloader(c *C) bootloader.ExtractedRunKernelImageBootloader {
+	opts := &bootloader.Options{Role: bootloader.RoleRunMode, NoSlashBoot: true}
+	b := bootloader.NewSystemdBoot(s.bootDir, opts)
+	c.Assert(b.InstallBootConfig("", opts), IsNil)
+	return b.(bootloader.ExtractedRunKernelImageBootloader)
+}
+
+func (s *systemdBootTestSuite) mockExtractedKernel(c *C, sn snap.PlaceInfo) {
+	kernelEfi := filepath.Join(s.bootDir, "EFI/ubuntu", sn.Filename(), "kernel.efi")
+	c.Assert(os.MkdirAll(filepath.Dir(kernelEfi), 0755), IsNil)
+	c.Assert(os.WriteFile(kernelEfi, []byte("UKI"), 0644), IsNil)
+}
+
+func (s *systemdBootTestSuite) writeEFIVar(c *C, name, value string) {
+	data := append([]byte("\x06\x00\x00\x00"), bootloadertest.UTF16Bytes(value)...)
+	c.Assert(os.WriteFile(filepath.Join(s.efivarsDir, name), data, 0644), IsNil)
+}
+
+func (s *systemdBootTestSuite) TestNewSystemdBoot(c *C) {
+	b := bootloader.NewSystemdBoot(s.espDir, &bootloader.Options{Role: bootloader.RoleRecovery})
+	c.Check(b.Name(), Equals, "systemd-boot")
+
+	present, err := b.Present()
+	c.Assert(err, IsNil)
+	c.Check(present, Equals, false)
+
+	c.Assert(b.InstallBootConfig("", &bootloader.Options{Role: bootloader.RoleRecovery}), IsNil)
+	c.Check(filepath.Join(s.espDir, "loader/loader.conf"), testutil.FileEquals, string(assets.Internal("systemd-boot-loader.conf")))
+	c.Check(filepath.Join(s.espDir, "loader/snapd.env"), testutil.FilePresent)
+
+	present, err = b.Present()
+	c.Assert(err, IsNil)
+	c.Check(present, Equals, true)
+
+	found, err := bootloader.Find(s.espDir, &bootloader.Options{Role: bootloader.RoleRecovery})
+	c.Assert(err, IsNil)
+	c.Check(found.Name(), Equals, "systemd-boot")
+}
+
+func (s *systemdBootTestSuite) TestRunModeInstallBootConfig(c *C) {
+	opts := &bootloader.Options{Role: bootloader.RoleRunMode, NoSlashBoot: true}
+	b := bootloader.NewSystemdBoot(s.bootDir, opts)
+	present, err := b.Present()
+	c.Assert(err, IsNil)
+	c.Check(present, Equals, false)
+
+	c.Assert(b.InstallBootConfig("", opts), IsNil)
+	c.Check(filepath.Join(s.bootDir, "loader/entries"), testutil.FilePresent)
+	c.Check(filepath.Join(s.bootDir, "loader/loader.conf"), testutil.FileAbsent)
+
+	found, err := bootloader.Find(s.bootDir, opts)
+	c.Assert(err, IsNil)
+	c.Check(found.Name(), Equals, "systemd-boot")
+
+	// at runtime the boot partition is found under /run/mnt
+	rootdir := c.MkDir()
+	b = bootloader.NewSystemdBoot(rootdir, &bootloader.Options{Role: bootloader.RoleRunMode})
+	c.Assert(b.InstallBootConfig("", opts), IsNil)
+	c.Check(filepath.Join(rootdir, "run/mnt/ubuntu-boot/loader/snapd.env"), testutil.FilePresent)
+}
+
+func (s *systemdBootTestSuite) TestRequiredByGadget(c *C) {
+	gadgetDir := c.MkDir()
+	b := bootloader.NewSystemdBoot(s.espDir, nil)
+	c.Check(b.RequiredByGadget(gadgetDir), Equals, false)
+
+	c.Assert(os.WriteFile(filepath.Join(gadgetDir, "systemd-boot.conf"), nil, 0644), IsNil)
+	c.Check(b.RequiredByGadget(gadgetDir), Equals, true)
+}
+
+func (s *systemdBootTestSuite) TestExtractKernelAssets(c *C) {
+	b := s.runBootloader(c)
+
+	files := [][]string{
+		{"kernel.efi", "UKI"},
+		{"kernel.img", "kernel"},
+	}
+	si := &snap.SideInfo{
+		RealName: "ubuntu-kernel",
+		Revision: snap.R(1),
+	}
+	fn := snaptest.MakeTestSnapWithFiles(c, packageKernel, files)
+	snapf, err := snapfile.Open(fn)
+	c.Assert(err, IsNil)
+	info, err := snap.ReadInfoFromSnapFile(snapf, si)
+	c.Assert(err, IsNil)
+
+	c.Assert(b.ExtractKernelAssets(info, snapf), IsNil)
+	c.Check(filepath.Join(s.bootDir, "EFI/ubuntu/ubuntu-kernel_1.snap/kernel.efi"), testutil.FileEquals, "UKI")
+	c.Check(filepath.Join(s.bootDir, "EFI/ubuntu/ubuntu-kernel_1.snap/kernel.img"), testutil.FileAbsent)
+
+	c.Assert(b.RemoveKernelAssets(info), IsNil)
+	c.Check(filepath.Join(s.bootDir, "EFI/ubuntu/ubuntu-kernel_1.snap"), testutil.FileAbsent)
+
+	// nothing is extracted for recovery systems
+	rb := bootloader.NewSystemdBoot(s.espDir, &bootloader.Options{Role: bootloader.RoleRecovery})
+	c.Assert(rb.ExtractKernelAssets(info, snapf), IsNil)
+	c.Check(filepath.Join(s.espDir, "EFI"), testutil.FileAbsent)
+}
+
+func (s *systemdBootTestSuite) TestEnableKernel(c *C) {
+	b := s.runBootloader(c)
+
+	sn, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_1.snap")
+	c.Assert(err, IsNil)
+
+	_, err = b.Kernel()
+	c.Check(err, ErrorMatches, "cannot read loader entry snapd-kernel.conf: .* no such file or directory")
+
+	err = b.EnableKernel(sn)
+	c.Check(err, ErrorMatches, "cannot enable snapd-kernel.conf at /EFI/ubuntu/pc-kernel_1.snap/kernel.efi: file does not exist")
+
+	s.mockExtractedKernel(c, sn)
+	c.Assert(b.EnableKernel(sn), IsNil)
+	c.Check(filepath.Join(s.bootDir, "loader/entries/snapd-kernel.conf"), testutil.FileEquals, `title Ubuntu Core
+version pc-kernel_1
+efi /EFI/ubuntu/pc-kernel_1.snap/kernel.efi
+options snapd_recovery_mode=run console=ttyS0 panic=-1
+`)
+
+	kernel, err := b.Kernel()
+	c.Assert(err, IsNil)
+	c.Check(kernel, DeepEquals, sn)
+
+	_, err = b.TryKernel()
+	c.Check(err, Equals, bootloader.ErrNoTryKernelRef)
+
+	// the kernel image went away
+	c.Assert(os.RemoveAll(filepath.Join(s.bootDir, "EFI/ubuntu/pc-kernel_1.snap")), IsNil)
+	_, err = b.Kernel()
+	c.Check(err, ErrorMatches, "cannot use loader entry snapd-kernel.conf: /EFI/ubuntu/pc-kernel_1.snap/kernel.efi does not exist")
+}
+
+func (s *systemdBootTestSuite) TestTryKernelSuccessful(c *C) {
+	b := s.runBootloader(c)
+
+	sn1, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_1.snap")
+	c.Assert(err, IsNil)
+	sn2, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_2.snap")
+	c.Assert(err, IsNil)
+	s.mockExtractedKernel(c, sn1)
+	s.mockExtractedKernel(c, sn2)
+	c.Assert(b.EnableKernel(sn1), IsNil)
+	s.writeEFIVar(c, loaderEntrySelectedVar, "snapd-kernel.conf")
+
+	// setting a try kernel, as done by boot
+	c.Assert(b.EnableTryKernel(sn2), IsNil)
+	c.Assert(b.SetBootVars(map[string]string{"kernel_status": "try"}), IsNil)
+
+	tryEntry := filepath.Join(s.bootDir, "loader/entries/snapd-try-kernel-pc-kernel_2.conf")
+	c.Check(tryEntry, testutil.FileContains, "efi /EFI/ubuntu/pc-kernel_2.snap/kernel.efi\n")
+	oneShot, _, err := efi.ReadVarString(loaderEntryOneShotVar)
+	c.Assert(err, IsNil)
+	c.Check(oneShot, Equals, "snapd-try-kernel-pc-kernel_2.conf")
+
+	m, err := b.GetBootVars("kernel_status")
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, map[string]string{"kernel_status": "try"})
+
+	// systemd-boot consumed the one shot entry and booted the try kernel
+	c.Assert(os.Remove(filepath.Join(s.efivarsDir, loaderEntryOneShotVar)), IsNil)
+	s.writeEFIVar(c, loaderEntrySelectedVar, "snapd-try-kernel-pc-kernel_2.conf")
+
+	m, err = b.GetBootVars("kernel_status")
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, map[string]string{"kernel_status": "trying"})
+	tryKernel, err := b.TryKernel()
+	c.Assert(err, IsNil)
+	c.Check(tryKernel, DeepEquals, sn2)
+
+	// marking the try kernel successful, as done by boot
+	c.Assert(b.SetBootVars(map[string]string{"kernel_status": ""}), IsNil)
+	c.Assert(b.EnableKernel(sn2), IsNil)
+	c.Assert(b.DisableTryKernel(), IsNil)
+
+	c.Check(tryEntry, testutil.FileAbsent)
+	kernel, err := b.Kernel()
+	c.Assert(err, IsNil)
+	c.Check(kernel, DeepEquals, sn2)
+	_, err = b.TryKernel()
+	c.Check(err, Equals, bootloader.ErrNoTryKernelRef)
+	m, err = b.GetBootVars("kernel_status")
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, map[string]string{"kernel_status": ""})
+
+	// trying another kernel in the same boot is not mistaken for a boot
+	// of the new try kernel
+	sn3, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_3.snap")
+	c.Assert(err, IsNil)
+	s.mockExtractedKernel(c, sn3)
+	c.Assert(b.EnableTryKernel(sn3), IsNil)
+	c.Assert(b.SetBootVars(map[string]string{"kernel_status": "try"}), IsNil)
+	m, err = b.GetBootVars("kernel_status")
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, map[string]string{"kernel_status": "try"})
+}
+
+func (s *systemdBootTestSuite) TestTryKernelFallback(c *C) {
+	b := s.runBootloader(c)
+
+	sn1, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_1.snap")
+	c.Assert(err, IsNil)
+	sn2, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_2.snap")
+	c.Assert(err, IsNil)
+	s.mockExtractedKernel(c, sn1)
+	s.mockExtractedKernel(c, sn2)
+	c.Assert(b.EnableKernel(sn1), IsNil)
+	c.Assert(b.EnableTryKernel(sn2), IsNil)
+	c.Assert(b.SetBootVars(map[string]string{"kernel_status": "try"}), IsNil)
+
+	// the try kernel failed to boot and systemd-boot fell back to the
+	// default entry
+	c.Assert(os.Remove(filepath.Join(s.efivarsDir, loaderEntryOneShotVar)), IsNil)
+	s.writeEFIVar(c, loaderEntrySelectedVar, "snapd-kernel.conf")
+
+	m, err := b.GetBootVars("kernel_status")
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, map[string]string{"kernel_status": "try"})
+
+	// reverting, as done by boot
+	c.Assert(b.SetBootVars(map[string]string{"kernel_status": ""}), IsNil)
+	c.Assert(b.DisableTryKernel(), IsNil)
+	kernel, err := b.Kernel()
+	c.Assert(err, IsNil)
+	c.Check(kernel, DeepEquals, sn1)
+	c.Check(filepath.Join(s.efivarsDir, loaderEntryOneShotVar), testutil.FileAbsent)
+}
+
+func (s *systemdBootTestSuite) TestEnableTryKernelReplacesPrevious(c *C) {
+	b := s.runBootloader(c)
+
+	sn2, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_2.snap")
+	c.Assert(err, IsNil)
+	sn3, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_3.snap")
+	c.Assert(err, IsNil)
+	s.mockExtractedKernel(c, sn2)
+	s.mockExtractedKernel(c, sn3)
+
+	c.Assert(b.EnableTryKernel(sn2), IsNil)
+	c.Assert(b.EnableTryKernel(sn3), IsNil)
+	matches, err := filepath.Glob(filepath.Join(s.bootDir, "loader/entries/*"))
+	c.Assert(err, IsNil)
+	c.Check(matches, DeepEquals, []string{filepath.Join(s.bootDir, "loader/entries/snapd-try-kernel-pc-kernel_3.conf")})
+}
+
+func (s *systemdBootTestSuite) TestTryKernelNoEFISystem(c *C) {
+	b := s.runBootloader(c)
+	s.AddCleanup(efi.MockVars(nil, nil))
+
+	sn1, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_1.snap")
+	c.Assert(err, IsNil)
+	sn2, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_2.snap")
+	c.Assert(err, IsNil)
+	s.mockExtractedKernel(c, sn1)
+	s.mockExtractedKernel(c, sn2)
+	c.Assert(b.EnableKernel(sn1), IsNil)
+	c.Assert(b.EnableTryKernel(sn2), IsNil)
+
+	err = b.SetBootVars(map[string]string{"kernel_status": "try"})
+	c.Check(err, ErrorMatches, "cannot boot try kernel: not a supported EFI system")
+
+	// clearing the try boot is fine
+	c.Assert(b.SetBootVars(map[string]string{"kernel_status": ""}), IsNil)
+}
+
+func (s *systemdBootTestSuite) TestSetBootVarsCommandLine(c *C) {
+	b := s.runBootloader(c)
+
+	sn1, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_1.snap")
+	c.Assert(err, IsNil)
+	sn2, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_2.snap")
+	c.Assert(err, IsNil)
+	s.mockExtractedKernel(c, sn1)
+	s.mockExtractedKernel(c, sn2)
+	c.Assert(b.EnableKernel(sn1), IsNil)
+	c.Assert(b.EnableTryKernel(sn2), IsNil)
+
+	kernelEntry := filepath.Join(s.bootDir, "loader/entries/snapd-kernel.conf")
+	tryEntry := filepath.Join(s.bootDir, "loader/entries/snapd-try-kernel-pc-kernel_2.conf")
+
+	c.Assert(b.SetBootVars(map[string]string{
+		"snapd_extra_cmdline_args": "foo bar",
+	}), IsNil)
+	c.Check(kernelEntry, testutil.FileContains, "\noptions snapd_recovery_mode=run console=ttyS0 panic=-1 foo bar\n")
+	c.Check(tryEntry, testutil.FileContains, "\noptions snapd_recovery_mode=run console=ttyS0 panic=-1 foo bar\n")
+
+	c.Assert(b.SetBootVars(map[string]string{
+		"snapd_extra_cmdline_args": "",
+		"snapd_full_cmdline_args":  "baz",
+	}), IsNil)
+	c.Check(kernelEntry, testutil.FileContains, "\noptions snapd_recovery_mode=run baz\n")
+	c.Check(tryEntry, testutil.FileContains, "\noptions snapd_recovery_mode=run baz\n")
+
+	// an empty full command line is set to a single space
+	c.Assert(b.SetBootVars(map[string]string{
+		"snapd_full_cmdline_args": " ",
+	}), IsNil)
+	c.Check(kernelEntry, testutil.FileContains, "\noptions snapd_recovery_mode=run\n")
+
+	m, err := b.GetBootVars("snapd_extra_cmdline_args", "snapd_full_cmdline_args")
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, map[string]string{
+		"snapd_extra_cmdline_args": "",
+		"snapd_full_cmdline_args":  " ",
+	})
+
+	// the kernel command line matches the one used for sealing
+	tbl := b.(bootloader.TrustedAssetsBootloader)
+	cmdline, err := tbl.CommandLine(bootloader.CommandLineComponents{
+		ModeArg:   "snapd_recovery_mode=run",
+		ExtraArgs: "foo bar",
+	})
+	c.Assert(err, IsNil)
+	c.Check(cmdline, Equals, "snapd_recovery_mode=run console=ttyS0 panic=-1 foo bar")
+}
+
+func (s *systemdBootTestSuite) mockSeedKernel(c *C, path string) {
+	fn := snaptest.MakeTestSnapWithFiles(c, packageKernel, [][]string{
+		{"kernel.efi", "UKI"},
+	})
+	dst := filepath.Join(s.espDir, path)
+	c.Assert(os.MkdirAll(filepath.Dir(dst), 0755), IsNil)
+	c.Assert(osutil.CopyFile(fn, dst, 0), IsNil)
+}
+
+func (s *systemdBootTestSuite) TestRecoverySystemEnv(c *C) {
+	b := bootloader.NewSystemdBoot(s.espDir, &bootloader.Options{Role: bootloader.RoleRecovery})
+	s.mockSeedKernel(c, "snaps/pc-kernel_1.snap")
+
+	v, err := b.GetRecoverySystemEnv("systems/20260101", "snapd_recovery_kernel")
+	c.Assert(err, IsNil)
+	c.Check(v, Equals, "")
+
+	err = b.SetRecoverySystemEnv("systems/20260101", map[string]string{
+		"snapd_recovery_kernel":    "/snaps/pc-kernel_1.snap",
+		"snapd_extra_cmdline_args": "foo=bar",
+	})
+	c.Assert(err, IsNil)
+	c.Check(filepath.Join(s.espDir, "systems/20260101/snapd.env"), testutil.FilePresent)
+
+	v, err = b.GetRecoverySystemEnv("systems/20260101", "snapd_recovery_kernel")
+	c.Assert(err, IsNil)
+	c.Check(v, Equals, "/snaps/pc-kernel_1.snap")
+
+	// the kernel image was extracted next to the kernel snap
+	c.Check(filepath.Join(s.espDir, "snaps/pc-kernel_1.efi"), testutil.FileEquals, "UKI")
+	// with a loader entry for each mode
+	c.Check(filepath.Join(s.espDir, "loader/entries/snapd-recovery-20260101-recover.conf"), testutil.FileEquals, `title Ubuntu Core recover using 20260101
+sort-key snapd-2-recover
+version 20260101
+efi /snaps/pc-kernel_1.efi
+options snapd_recovery_mode=recover snapd_recovery_system=20260101 console=ttyS0 panic=-1 foo=bar
+`)
+	c.Check(filepath.Join(s.espDir, "loader/entries/snapd-recovery-20260101-install.conf"), testutil.FileContains,
+		"sort-key snapd-1-install\n")
+	c.Check(filepath.Join(s.espDir, "loader/entries/snapd-recovery-20260101-install.conf"), testutil.FileContains,
+		"options snapd_recovery_mode=install snapd_recovery_system=20260101 console=ttyS0 panic=-1 foo=bar\n")
+	c.Check(filepath.Join(s.espDir, "loader/entries/snapd-recovery-20260101-factory-reset.conf"), testutil.FileContains,
+		"options snapd_recovery_mode=factory-reset snapd_recovery_system=20260101 console=ttyS0 panic=-1 foo=bar\n")
+
+	// a recovery system with its own kernel and a full command line
+	s.mockSeedKernel(c, "systems/20260202/snaps/pc-kernel_x1.snap")
+	err = b.SetRecoverySystemEnv("systems/20260202", map[string]string{
+		"snapd_recovery_kernel":   "/systems/20260202/snaps/pc-kernel_x1.snap",
+		"snapd_full_cmdline_args": "full",
+	})
+	c.Assert(err, IsNil)
+	c.Check(filepath.Join(s.espDir, "systems/20260202/snaps/pc-kernel_x1.efi"), testutil.FileEquals, "UKI")
+	c.Check(filepath.Join(s.espDir, "loader/entries/snapd-recovery-20260202-recover.conf"), testutil.FileContains,
+		"efi /systems/20260202/snaps/pc-kernel_x1.efi\noptions snapd_recovery_mode=recover snapd_recovery_system=20260202 full\n")
+
+	// the entries of removed recovery systems are dropped
+	c.Assert(os.RemoveAll(filepath.Join(s.espDir, "systems/20260101")), IsNil)
+	err = b.SetRecoverySystemEnv("systems/20260202", map[string]string{
+		"snapd_recovery_kernel": "/systems/20260202/snaps/pc-kernel_x1.snap",
+	})
+	c.Assert(err, IsNil)
+	matches, err := filepath.Glob(filepath.Join(s.espDir, "loader/entries/*"))
+	c.Assert(err, IsNil)
+	c.Check(matches, DeepEquals, []string{
+		filepath.Join(s.espDir, "loader/entries/snapd-recovery-20260202-factory-reset.conf"),
+		filepath.Join(s.espDir, "loader/entries/snapd-recovery-20260202-install.conf"),
+		filepath.Join(s.espDir, "loader/entries/snapd-recovery-20260202-recover.conf"),
+	})
+
+	err = b.SetRecoverySystemEnv("systems/20260303", map[string]string{
+		"snapd_recovery_kernel": "/snaps/pc-kernel_2.snap",
+	})
+	c.Check(err, ErrorMatches, `cannot extract kernel image of recovery system "20260303": .*pc-kernel_2.snap.*`)
+	err = b.SetRecoverySystemEnv("systems/20260303", nil)
+	c.Check(err, ErrorMatches, `internal error: recovery kernel of system "20260303" unset`)
+
+	err = b.SetRecoverySystemEnv("", nil)
+	c.Check(err, ErrorMatches, "internal error: recoverySystemDir unset")
+	_, err = b.GetRecoverySystemEnv("", "foo")
+	c.Check(err, ErrorMatches, "internal error: recoverySystemDir unset")
+}
+
+func (s *systemdBootTestSuite) TestSetRecoveryBoot(c *C) {
+	opts := &bootloader.Options{Role: bootloader.RoleRecovery}
+	b := bootloader.NewSystemdBoot(s.espDir, opts)
+	c.Assert(b.InstallBootConfig("", opts), IsNil)
+	entriesDir := filepath.Join(s.espDir, "loader/entries")
+	c.Assert(os.MkdirAll(entriesDir, 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(entriesDir, "snapd-recovery-20260101-recover.conf"), nil, 0644), IsNil)
+
+	// rebooting into a recovery system, as done by boot
+	err := b.SetBootVars(map[string]string{
+		"snapd_recovery_system": "20260101",
+		"snapd_recovery_mode":   "recover",
+	})
+	c.Assert(err, IsNil)
+	oneShot, _, err := efi.ReadVarString(loaderEntryOneShotVar)
+	c.Assert(err, IsNil)
+	c.Check(oneShot, Equals, "snapd-recovery-20260101-recover.conf")
+
+	// back to run mode
+	c.Assert(b.SetBootVars(map[string]string{"snapd_recovery_mode": "run"}), IsNil)
+	c.Check(filepath.Join(s.efivarsDir, loaderEntryOneShotVar), testutil.FileAbsent)
+
+	// a try kernel boot is left alone
+	s.writeEFIVar(c, loaderEntryOneShotVar, "snapd-try-kernel-pc-kernel_2.conf")
+	c.Assert(b.SetBootVars(map[string]string{"snapd_recovery_mode": "run"}), IsNil)
+	oneShot, _, err = efi.ReadVarString(loaderEntryOneShotVar)
+	c.Assert(err, IsNil)
+	c.Check(oneShot, Equals, "snapd-try-kernel-pc-kernel_2.conf")
+
+	err = b.SetBootVars(map[string]string{
+		"snapd_recovery_system": "20260101",
+		"snapd_recovery_mode":   "install",
+	})
+	c.Check(err, ErrorMatches, `cannot boot recovery system "20260101" in mode "install": loader entry snapd-recovery-20260101-install.conf does not exist`)
+
+	// the EFI variables are not touched when preparing an image
+	c.Assert(os.Remove(filepath.Join(s.efivarsDir, loaderEntryOneShotVar)), IsNil)
+	b = bootloader.NewSystemdBoot(s.espDir, &bootloader.Options{Role: bootloader.RoleRecovery, PrepareImageTime: true})
+	err = b.SetBootVars(map[string]string{
+		"snapd_recovery_system": "20260101",
+		"snapd_recovery_mode":   "install",
+	})
+	c.Assert(err, IsNil)
+	c.Check(filepath.Join(s.efivarsDir, loaderEntryOneShotVar), testutil.FileAbsent)
+}
+
+func (s *systemdBootTestSuite) TestUpdateBootConfig(c *C) {
+	opts := &bootloader.Options{Role: bootloader.RoleRecovery}
+	b := bootloader.NewSystemdBoot(s.espDir, opts)
+	tbl := b.(bootloader.TrustedAssetsBootloader)
+	c.Check(tbl.ManagedAssets(), DeepEquals, []string{"loader/loader.conf"})
+
+	c.Assert(os.MkdirAll(filepath.Join(s.espDir, "loader"), 0755), IsNil)
+	loaderConf := filepath.Join(s.espDir, "loader/loader.conf")
+	c.Assert(os.WriteFile(loaderConf, []byte("# Snapd-Boot-Config-Edition: 1\nold"), 0644), IsNil)
+
+	restore := assets.MockInternal("systemd-boot-loader.conf", []byte("# Snapd-Boot-Config-Edition: 2\nnew"))
+	defer restore()
+
+	updated, err := tbl.UpdateBootConfig()
+	c.Assert(err, IsNil)
+	c.Check(updated, Equals, true)
+	c.Check(loaderConf, testutil.FileEquals, "# Snapd-Boot-Config-Edition: 2\nnew")
+
+	// the run mode bootloader has no boot config
+	rtbl := s.runBootloader(c).(bootloader.TrustedAssetsBootloader)
+	c.Check(rtbl.ManagedAssets(), HasLen, 0)
+	updated, err = rtbl.UpdateBootConfig()
+	c.Assert(err, IsNil)
+	c.Check(updated, Equals, false)
+}
+
+func (s *systemdBootTestSuite) TestTrustedAssets(c *C) {
+	rb := bootloader.NewSystemdBoot(s.espDir, &bootloader.Options{Role: bootloader.RoleRecovery})
+	rtbl := rb.(bootloader.TrustedAssetsBootloader)
+	ta, err := rtbl.TrustedAssets()
+	c.Assert(err, IsNil)
+	c.Check(ta, DeepEquals, map[string]string{
+		"EFI/boot/bootx64.efi":            "boot:bootx64.efi",
+		"EFI/systemd/systemd-bootx64.efi": "systemd:systemd-bootx64.efi",
+	})
+	revoking, err := rtbl.RevocationTriggeringAssets()
+	c.Assert(err, IsNil)
+	c.Check(revoking, DeepEquals, []string{"systemd:systemd-bootx64.efi", "boot:bootx64.efi"})
+
+	tbl := s.runBootloader(c).(bootloader.TrustedAssetsBootloader)
+	ta, err = tbl.TrustedAssets()
+	c.Assert(err, IsNil)
+	c.Check(ta, HasLen, 0)
+
+	b := bootloader.NewSystemdBoot(s.rootdir, &bootloader.Options{Role: bootloader.RoleRunMode})
+	_, err = b.(bootloader.TrustedAssetsBootloader).TrustedAssets()
+	c.Check(err, ErrorMatches, "internal error: trusted assets called without native host-partition layout")
+
+	b = bootloader.NewSystemdBoot(s.espDir, &bootloader.Options{Role: bootloader.RoleRecovery, PrepareImageTime: true})
+	_, err = b.(bootloader.TrustedAssetsBootloader).TrustedAssets()
+	c.Check(err, ErrorMatches, "internal error: retrieving boot assets at prepare image time")
+
+	restore := archtest.MockArchitecture("riscv64")
+	defer restore()
+	_, err = rtbl.TrustedAssets()
+	c.Check(err, ErrorMatches, `cannot find systemd-boot assets for "riscv64"`)
+}
+
+func (s *systemdBootTestSuite) TestBootChains(c *C) {
+	rb := bootloader.NewSystemdBoot(s.espDir, &bootloader.Options{Role: bootloader.RoleRecovery})
+	rtbl := rb.(bootloader.TrustedAssetsBootloader)
+
+	// the recovery chains load the kernel image extracted next to the
+	// recovery kernel snap
+	seedKernel := filepath.Join(s.espDir, "snaps/pc-kernel_1.snap")
+	chains, err := rtbl.RecoveryBootChains(seedKernel)
+	c.Assert(err, IsNil)
+	c.Check(chains, DeepEquals, [][]bootloader.BootFile{
+		{
+			{Path: "EFI/systemd/systemd-bootx64.efi", Role: bootloader.RoleRecovery},
+			{Path: filepath.Join(s.espDir, "snaps/pc-kernel_1.efi"), Role: bootloader.RoleRecovery},
+		}, {
+			{Path: "EFI/boot/bootx64.efi", Role: bootloader.RoleRecovery},
+			{Path: filepath.Join(s.espDir, "snaps/pc-kernel_1.efi"), Role: bootloader.RoleRecovery},
+		},
+	})
+
+	// the run mode chains load the kernel image extracted on the boot
+	// partition
+	runBl := bootloader.NewSystemdBoot(s.bootDir, &bootloader.Options{Role: bootloader.RoleRunMode, NoSlashBoot: true})
+	chains, err = rtbl.BootChains(runBl, "/var/lib/snapd/snaps/pc-kernel_2.snap")
+	c.Assert(err, IsNil)
+	c.Check(chains, DeepEquals, [][]bootloader.BootFile{
+		{
+			{Path: "EFI/systemd/systemd-bootx64.efi", Role: bootloader.RoleRecovery},
+			{Path: filepath.Join(s.bootDir, "EFI/ubuntu/pc-kernel_2.snap/kernel.efi"), Role: bootloader.RoleRunMode},
+		}, {
+			{Path: "EFI/boot/bootx64.efi", Role: bootloader.RoleRecovery},
+			{Path: filepath.Join(s.bootDir, "EFI/ubuntu/pc-kernel_2.snap/kernel.efi"), Role: bootloader.RoleRunMode},
+		},
+	})
+
+	_, err = rtbl.BootChains(runBl, "kernel.snap")
+	c.Check(err, ErrorMatches, `snap file name "kernel.snap" has invalid format .*`)
+	_, err = rtbl.BootChains(bootloader.NewGrub(s.bootDir, nil), "pc-kernel_2.snap")
+	c.Check(err, ErrorMatches, "run mode bootloader must be systemd-boot")
+
+	_, err = runBl.(bootloader.TrustedAssetsBootloader).RecoveryBootChains("kernel.snap")
+	c.Check(err, ErrorMatches, "not a recovery bootloader")
+	_, err = runBl.(bootloader.TrustedAssetsBootloader).BootChains(runBl, "kernel.snap")
+	c.Check(err, ErrorMatches, "not a recovery bootloader")
+}
+
+func (s *systemdBootTestSuite) TestDefaultCommandLine(c *C) {
+	tbl := bootloader.NewSystemdBoot(s.espDir, &bootloader.Options{Role: bootloader.RoleRecovery}).(bootloader.TrustedAssetsBootloader)
+	for _, candidate := range []bool{true, false} {
+		cmdline, err := tbl.DefaultCommandLine(candidate)
+		c.Assert(err, IsNil)
+		c.Check(cmdline, Equals, "console=ttyS0 panic=-1")
+	}
+
+	cmdline, err := tbl.CandidateCommandLine(bootloader.CommandLineComponents{
+		ModeArg:   "snapd_recovery_mode=recover",
+		SystemArg: "snapd_recovery_system=20260101",
+		FullArgs:  "foo",
+	})
+	c.Assert(err, IsNil)
+	c.Check(cmdline, Equals, "snapd_recovery_mode=recover snapd_recovery_system=20260101 foo")
+
+	_, err = tbl.CommandLine(bootloader.CommandLineComponents{
+		ExtraArgs: "foo",
+		FullArgs:  "bar",
+	})
+	c.Check(err, ErrorMatches, "cannot use both full and extra components of command line")
+	_, err = tbl.CommandLine(bootloader.CommandLineComponents{
+		FullArgs: `foo "bar`,
+	})
+	c.Check(err, ErrorMatches, "cannot use badly formatted kernel command line: .*")
+}
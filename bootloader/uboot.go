@@ -33,6 +33,7 @@ import (
 var (
 	_ Bootloader                             = (*uboot)(nil)
 	_ ExtractedRecoveryKernelImageBootloader = (*uboot)(nil)
+	_ ABSlotsBootloader                      = (*uboot)(nil)
 )
 
 type uboot struct {
@@ -176,6 +177,24 @@ func (u *uboot) GetBootVars(names ...string) (map[string]string, error) {
 	return getBootVarsFromEnv(env, names...), nil
 }
 
+// ABSlotGroups returns the groups of A/B paired structures for which some
+// state is kept in the environment.
+func (u *uboot) ABSlotGroups() ([]string, error) {
+	return abSlotGroupsFromBootVars(u)
+}
+
+// ABSlotState returns the state of the group of A/B paired structures kept
+// in the environment.
+func (u *uboot) ABSlotState(group string) (*ABSlotState, error) {
+	return abSlotStateFromBootVars(u, group)
+}
+
+// SetABSlotState keeps the state of the group of A/B paired structures in
+// the environment, from where the boot script picks it up.
+func (u *uboot) SetABSlotState(group string, state *ABSlotState) error {
+	return setABSlotStateInBootVars(u, group, state)
+}
+
 func (u *uboot) ExtractKernelAssets(s snap.PlaceInfo, snapf snap.Container) error {
 	dstDir := filepath.Join(u.dir(), s.Filename())
 	return extractKernelAssetsToBootDir(dstDir, snapf, ubootKernelAssets)
@@ -116,6 +116,49 @@ func (s *ubootTestSuite) TestUbootGetBootVarFwEnv(c *C) {
 	c.Assert(content, DeepEquals, map[string]string{"key2": "value2"})
 }
 
+func (s *ubootTestSuite) TestUbootABSlotState(c *C) {
+	bootloader.MockUbootFiles(c, s.rootdir, nil)
+	u := bootloader.NewUboot(s.rootdir, nil)
+	absb, ok := u.(bootloader.ABSlotsBootloader)
+	c.Assert(ok, Equals, true)
+
+	groups, err := absb.ABSlotGroups()
+	c.Assert(err, IsNil)
+	c.Check(groups, HasLen, 0)
+
+	state, err := absb.ABSlotState("bootloader")
+	c.Assert(err, IsNil)
+	c.Check(state, DeepEquals, &bootloader.ABSlotState{})
+
+	err = absb.SetABSlotState("bootloader", &bootloader.ABSlotState{Active: "a", Try: "b", Status: "try"})
+	c.Assert(err, IsNil)
+	err = absb.SetABSlotState("firmware", &bootloader.ABSlotState{Active: "b"})
+	c.Assert(err, IsNil)
+	err = absb.SetABSlotState("bootloader", &bootloader.ABSlotState{Active: "b"})
+	c.Assert(err, IsNil)
+
+	groups, err = absb.ABSlotGroups()
+	c.Assert(err, IsNil)
+	c.Check(groups, DeepEquals, []string{"bootloader", "firmware"})
+
+	state, err = absb.ABSlotState("bootloader")
+	c.Assert(err, IsNil)
+	c.Check(state, DeepEquals, &bootloader.ABSlotState{Active: "b"})
+
+	// the state is kept in variables the boot script can use
+	m, err := u.GetBootVars("snapd_ab_groups", "snapd_ab_slot_firmware", "snapd_ab_try_slot_bootloader", "snapd_ab_status_bootloader")
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, map[string]string{
+		"snapd_ab_groups":              "bootloader firmware",
+		"snapd_ab_slot_firmware":       "b",
+		"snapd_ab_try_slot_bootloader": "",
+		"snapd_ab_status_bootloader":   "",
+	})
+
+	err = absb.SetABSlotState("", &bootloader.ABSlotState{Active: "b"})
+	c.Assert(err, ErrorMatches, "internal error: slot group is unset")
+}
+
 func (s *ubootTestSuite) TestExtractKernelAssetsAndRemove(c *C) {
 	bootloader.MockUbootFiles(c, s.rootdir, nil)
 	u := bootloader.NewUboot(s.rootdir, nil)
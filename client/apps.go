@@ -26,11 +26,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/snap"
 )
@@ -56,6 +59,24 @@ type AppInfo struct {
 	Active      bool             `json:"active,omitempty"`
 	CommonID    string           `json:"common-id,omitempty"`
 	Activators  []AppActivator   `json:"activators,omitempty"`
+	Runtime     *AppRuntime      `json:"runtime,omitempty"`
+}
+
+// AppRuntime describes the runtime status of an app that is a system
+// service, as tracked by systemd during the current boot.
+type AppRuntime struct {
+	// Restarts is the number of times the service was restarted
+	// automatically.
+	Restarts int `json:"restarts"`
+	// ExitStatus is the exit status of the last main process of the
+	// service, or the signal that terminated it.
+	ExitStatus int `json:"exit-status"`
+	// ActiveSince is when the service last became active.
+	ActiveSince *time.Time `json:"active-since,omitempty"`
+	// MainPID is the PID of the main process of a running service.
+	MainPID int `json:"main-pid,omitempty"`
+	// Memory is the memory used by the service, if accounted.
+	Memory quantity.Size `json:"memory,omitempty"`
 }
 
 // MarshalJSON marshals the AppActivator in such a way to retain
@@ -103,6 +124,9 @@ type AppOptions struct {
 	// of the services for the current user, or the global enable status.
 	// For root-users, global is always implied.
 	Global bool
+	// Runtime if set, also returns the runtime status of system services,
+	// such as their restart count or the PID of their main process.
+	Runtime bool
 }
 
 // Apps returns information about all matching apps. Each name can be
@@ -119,6 +143,9 @@ func (client *Client) Apps(names []string, opts AppOptions) ([]*AppInfo, error)
 	if opts.Global {
 		q.Add("global", fmt.Sprintf("%t", opts.Global))
 	}
+	if opts.Runtime {
+		q.Add("runtime", "true")
+	}
 
 	var appInfos []*AppInfo
 	_, err := client.doSync("GET", "/v2/apps", q, nil, nil, &appInfos)
@@ -130,6 +157,39 @@ func (client *Client) Apps(names []string, opts AppOptions) ([]*AppInfo, error)
 type LogOptions struct {
 	N      int  // The maximum number of log lines to retrieve initially. If <0, no limit.
 	Follow bool // Whether to continue returning new lines as they appear
+
+	Since    time.Time // If set, only return lines logged at or after this time
+	Until    time.Time // If set, only return lines logged at or before this time
+	Priority string    // If set, only return lines of this priority, or range of priorities (e.g. "err", "debug..warning")
+	Boot     string    // If set, only return lines from this boot ID or offset (e.g. "-1")
+	Grep     string    // If set, only return lines whose message matches this pattern
+}
+
+func (opts *LogOptions) query(names []string) url.Values {
+	query := url.Values{}
+	if len(names) > 0 {
+		query.Set("names", strings.Join(names, ","))
+	}
+	query.Set("n", strconv.Itoa(opts.N))
+	if opts.Follow {
+		query.Set("follow", strconv.FormatBool(opts.Follow))
+	}
+	if !opts.Since.IsZero() {
+		query.Set("since", opts.Since.Format(time.RFC3339))
+	}
+	if !opts.Until.IsZero() {
+		query.Set("until", opts.Until.Format(time.RFC3339))
+	}
+	if opts.Priority != "" {
+		query.Set("priority", opts.Priority)
+	}
+	if opts.Boot != "" {
+		query.Set("boot", opts.Boot)
+	}
+	if opts.Grep != "" {
+		query.Set("grep", opts.Grep)
+	}
+	return query
 }
 
 // A Log holds the information of a single syslog entry
@@ -158,17 +218,7 @@ func (l Log) fmtLog(timezone *time.Location) string {
 	return fmt.Sprintf("%s %s[%s]: %s", l.Timestamp.In(timezone).Format(time.RFC3339), l.SID, l.PID, l.Message)
 }
 
-// Logs asks for the logs of a series of services, by name.
-func (client *Client) Logs(names []string, opts LogOptions) (<-chan Log, error) {
-	query := url.Values{}
-	if len(names) > 0 {
-		query.Set("names", strings.Join(names, ","))
-	}
-	query.Set("n", strconv.Itoa(opts.N))
-	if opts.Follow {
-		query.Set("follow", strconv.FormatBool(opts.Follow))
-	}
-
+func (client *Client) logsResponse(query url.Values) (*http.Response, error) {
 	rsp, err := client.raw(context.Background(), "GET", "/v2/logs", query, nil, nil)
 	if err != nil {
 		return nil, err
@@ -182,6 +232,15 @@ func (client *Client) Logs(names []string, opts LogOptions) (<-chan Log, error)
 		}
 		return nil, r.err(client, rsp.StatusCode)
 	}
+	return rsp, nil
+}
+
+// Logs asks for the logs of a series of services, by name.
+func (client *Client) Logs(names []string, opts LogOptions) (<-chan Log, error) {
+	rsp, err := client.logsResponse(opts.query(names))
+	if err != nil {
+		return nil, err
+	}
 
 	ch := make(chan Log, 20)
 	go func() {
@@ -215,6 +274,56 @@ func (client *Client) Logs(names []string, opts LogOptions) (<-chan Log, error)
 	return ch, nil
 }
 
+// A JournalEntry holds all the fields of a single journal entry, as output
+// by journalctl -o json.
+type JournalEntry map[string]json.RawMessage
+
+// JournalLogs asks for the logs of a series of services, by name, returning
+// the journal entries with all their fields. Once the entries channel is
+// closed, the errors channel yields any error that interrupted the stream
+// of entries, or nil if all of them were received.
+func (client *Client) JournalLogs(names []string, opts LogOptions) (<-chan JournalEntry, <-chan error, error) {
+	query := opts.query(names)
+	query.Set("format", "jsonl")
+	rsp, err := client.logsResponse(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ch := make(chan JournalEntry, 20)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		defer rsp.Body.Close()
+		defer close(ch)
+		// entries come in application/jsonl, one JSON object per line
+		dec := json.NewDecoder(rsp.Body)
+		for {
+			var entry JournalEntry
+			if err := dec.Decode(&entry); err != nil {
+				if err != io.EOF {
+					errCh <- fmt.Errorf("cannot decode log entry: %v", err)
+				}
+				return
+			}
+			// the daemon reports problems reading the journal as a
+			// final {"error": ...} line, journal fields are never
+			// lowercase
+			if raw, ok := entry["error"]; ok && len(entry) == 1 {
+				var msg string
+				if err := json.Unmarshal(raw, &msg); err != nil {
+					msg = string(raw)
+				}
+				errCh <- fmt.Errorf("cannot read logs: %s", msg)
+				return
+			}
+			ch <- entry
+		}
+	}()
+
+	return ch, errCh, nil
+}
+
 type UserSelection int
 
 const (
@@ -22,8 +22,10 @@ package client_test
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -77,7 +79,20 @@ func testClientAppsGlobal(cs *clientSuite, c *check.C) ([]*client.AppInfo, error
 	return services, err
 }
 
-var appcheckers = []func(*clientSuite, *check.C) ([]*client.AppInfo, error){testClientApps, testClientAppsService, testClientAppsGlobal}
+func testClientAppsRuntime(cs *clientSuite, c *check.C) ([]*client.AppInfo, error) {
+	services, err := cs.cli.Apps([]string{"foo", "bar"}, client.AppOptions{Service: true, Runtime: true})
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/apps")
+	c.Check(cs.req.Method, check.Equals, "GET")
+	query := cs.req.URL.Query()
+	c.Check(query, check.HasLen, 3)
+	c.Check(query.Get("names"), check.Equals, "foo,bar")
+	c.Check(query.Get("select"), check.Equals, "service")
+	c.Check(query.Get("runtime"), check.Equals, "true")
+
+	return services, err
+}
+
+var appcheckers = []func(*clientSuite, *check.C) ([]*client.AppInfo, error){testClientApps, testClientAppsService, testClientAppsGlobal, testClientAppsRuntime}
 
 func (cs *clientSuite) TestClientAppActivatorsMarshalJSON(c *check.C) {
 	appInfo := []*client.AppInfo{
@@ -248,6 +263,91 @@ func (cs *clientSuite) TestClientLogsOpts(c *check.C) {
 	}
 }
 
+func (cs *clientSuite) TestClientLogsFilterOpts(c *check.C) {
+	ch, err := cs.cli.Logs([]string{"foo"}, client.LogOptions{
+		N:        10,
+		Since:    time.Date(2021, time.April, 16, 15, 32, 21, 0, time.UTC),
+		Until:    time.Date(2021, time.April, 16, 16, 32, 21, 0, time.UTC),
+		Priority: "err",
+		Boot:     "-1",
+		Grep:     "refused",
+	})
+	c.Assert(err, check.IsNil)
+	for range ch {
+	}
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"names":    {"foo"},
+		"n":        {"10"},
+		"since":    {"2021-04-16T15:32:21Z"},
+		"until":    {"2021-04-16T16:32:21Z"},
+		"priority": {"err"},
+		"boot":     {"-1"},
+		"grep":     {"refused"},
+	})
+}
+
+func (cs *clientSuite) TestClientJournalLogs(c *check.C) {
+	cs.rsp = `
+{"MESSAGE":"hello","_PID":"42","PRIORITY":"6"}
+{"MESSAGE":[104,105],"_PID":"42","PRIORITY":"3"}
+`[1:]
+
+	ch, errCh, err := cs.cli.JournalLogs([]string{"foo"}, client.LogOptions{N: -1, Priority: "err"})
+	c.Assert(err, check.IsNil)
+	var entries []client.JournalEntry
+	for entry := range ch {
+		entries = append(entries, entry)
+	}
+	c.Check(<-errCh, check.IsNil)
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/logs")
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"names":    {"foo"},
+		"n":        {"-1"},
+		"priority": {"err"},
+		"format":   {"jsonl"},
+	})
+	c.Check(entries, check.DeepEquals, []client.JournalEntry{{
+		"MESSAGE":  json.RawMessage(`"hello"`),
+		"_PID":     json.RawMessage(`"42"`),
+		"PRIORITY": json.RawMessage(`"6"`),
+	}, {
+		"MESSAGE":  json.RawMessage(`[104,105]`),
+		"_PID":     json.RawMessage(`"42"`),
+		"PRIORITY": json.RawMessage(`"3"`),
+	}})
+}
+
+func (cs *clientSuite) TestClientJournalLogsStreamError(c *check.C) {
+	cs.rsp = `
+{"MESSAGE":"hello","_PID":"42","PRIORITY":"6"}
+{"error": "journal went away"}
+`[1:]
+
+	ch, errCh, err := cs.cli.JournalLogs([]string{"foo"}, client.LogOptions{N: -1})
+	c.Assert(err, check.IsNil)
+	var entries []client.JournalEntry
+	for entry := range ch {
+		entries = append(entries, entry)
+	}
+	c.Check(entries, check.DeepEquals, []client.JournalEntry{{
+		"MESSAGE":  json.RawMessage(`"hello"`),
+		"_PID":     json.RawMessage(`"42"`),
+		"PRIORITY": json.RawMessage(`"6"`),
+	}})
+	c.Check(<-errCh, check.ErrorMatches, `cannot read logs: journal went away`)
+}
+
+func (cs *clientSuite) TestClientJournalLogsTruncated(c *check.C) {
+	cs.rsp = `{"MESSAGE":"hel`
+
+	ch, errCh, err := cs.cli.JournalLogs([]string{"foo"}, client.LogOptions{N: -1})
+	c.Assert(err, check.IsNil)
+	for range ch {
+		c.Fatal("unexpected entry")
+	}
+	c.Check(<-errCh, check.ErrorMatches, `cannot decode log entry: unexpected EOF`)
+}
+
 func (cs *clientSuite) TestClientLogsNotFound(c *check.C) {
 	cs.rsp = `{"type":"error","status-code":404,"status":"Not Found","result":{"message":"snap \"foo\" not found","kind":"snap-not-found","value":"foo"}}`
 	cs.status = 404
@@ -0,0 +1,160 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Certificate holds information about a certificate that is part of the
+// certificate database managed by snapd.
+type Certificate struct {
+	// Name is empty for system certificates that are only provided through
+	// the system ca-certificates.crt bundle.
+	Name        string `json:"name,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+	// Origin is either "system" or "custom".
+	Origin string `json:"origin"`
+	// State is either "accepted" or "blocked".
+	State     string    `json:"state"`
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	NotBefore time.Time `json:"not-before"`
+	NotAfter  time.Time `json:"not-after"`
+}
+
+// CertificatesResult holds the certificates known to snapd together with the
+// generation of the merged certificate database currently in use.
+type CertificatesResult struct {
+	Generation   string         `json:"generation,omitempty"`
+	Certificates []*Certificate `json:"certificates"`
+}
+
+// CertificatesOptions carries options for Certificates.
+type CertificatesOptions struct {
+	// Origin, if set, restricts the result to "system" or "custom"
+	// certificates.
+	Origin string
+}
+
+type postCertificatesData struct {
+	Action      string `json:"action"`
+	Name        string `json:"name,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Content     string `json:"content,omitempty"`
+
+	AllowExpired bool `json:"allow-expired,omitempty"`
+}
+
+// Certificates lists the system and custom certificates known to snapd.
+func (client *Client) Certificates(opts *CertificatesOptions) (*CertificatesResult, error) {
+	q := url.Values{}
+	if opts != nil && opts.Origin != "" {
+		q.Set("origin", opts.Origin)
+	}
+
+	var res *CertificatesResult
+	if _, err := client.doSync("GET", "/v2/certificates", q, nil, nil, &res); err != nil {
+		return nil, fmt.Errorf("cannot list certificates: %w", err)
+	}
+	return res, nil
+}
+
+func (client *Client) postCertificates(data *postCertificatesData) (changeID string, err error) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(data); err != nil {
+		return "", err
+	}
+	return client.doAsync("POST", "/v2/certificates", nil, nil, &body)
+}
+
+// CertificateOptions carries options for AddCertificate and
+// UnblockCertificate.
+type CertificateOptions struct {
+	// AllowExpired allows the operation to include a custom certificate
+	// that has already expired in the certificate database.
+	AllowExpired bool
+}
+
+// AddCertificate adds, or replaces, the custom certificate with the given
+// name. The content must be a PEM or DER encoded certificate.
+func (client *Client) AddCertificate(name, content string, opts *CertificateOptions) (changeID string, err error) {
+	if name == "" || content == "" {
+		return "", fmt.Errorf("cannot add certificate without name and content")
+	}
+	if opts == nil {
+		opts = &CertificateOptions{}
+	}
+	return client.postCertificates(&postCertificatesData{
+		Action:       "add",
+		Name:         name,
+		Content:      content,
+		AllowExpired: opts.AllowExpired,
+	})
+}
+
+// RemoveCertificate removes the custom certificate with the given name.
+func (client *Client) RemoveCertificate(name string) (changeID string, err error) {
+	if name == "" {
+		return "", fmt.Errorf("cannot remove certificate without a name")
+	}
+	return client.postCertificates(&postCertificatesData{
+		Action: "remove",
+		Name:   name,
+	})
+}
+
+// CertificateRef identifies a certificate either by the name of a custom
+// certificate or by the fingerprint of a system certificate.
+type CertificateRef struct {
+	Name        string
+	Fingerprint string
+}
+
+func (client *Client) changeCertificateState(action string, ref CertificateRef, opts *CertificateOptions) (changeID string, err error) {
+	if (ref.Name == "") == (ref.Fingerprint == "") {
+		return "", fmt.Errorf("cannot %s certificate: exactly one of name or fingerprint must be provided", action)
+	}
+	if opts == nil {
+		opts = &CertificateOptions{}
+	}
+	return client.postCertificates(&postCertificatesData{
+		Action:       action,
+		Name:         ref.Name,
+		Fingerprint:  ref.Fingerprint,
+		AllowExpired: opts.AllowExpired,
+	})
+}
+
+// BlockCertificate excludes the given certificate from the merged
+// certificate database.
+func (client *Client) BlockCertificate(ref CertificateRef) (changeID string, err error) {
+	return client.changeCertificateState("block", ref, nil)
+}
+
+// UnblockCertificate includes a previously blocked certificate in the merged
+// certificate database again.
+func (client *Client) UnblockCertificate(ref CertificateRef, opts *CertificateOptions) (changeID string, err error) {
+	return client.changeCertificateState("unblock", ref, opts)
+}
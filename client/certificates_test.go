@@ -0,0 +1,137 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestCertificates(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"generation": "abcd",
+			"certificates": [{
+				"name": "my-ca",
+				"fingerprint": "1234",
+				"origin": "custom",
+				"state": "accepted",
+				"subject": "CN=my-ca",
+				"issuer": "CN=my-ca",
+				"not-before": "2026-01-01T00:00:00Z",
+				"not-after": "2027-01-01T00:00:00Z"
+			}]
+		}
+	}`
+
+	res, err := cs.cli.Certificates(&client.CertificatesOptions{Origin: "custom"})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/certificates")
+	c.Check(cs.req.URL.Query().Get("origin"), check.Equals, "custom")
+	c.Check(res, check.DeepEquals, &client.CertificatesResult{
+		Generation: "abcd",
+		Certificates: []*client.Certificate{{
+			Name:        "my-ca",
+			Fingerprint: "1234",
+			Origin:      "custom",
+			State:       "accepted",
+			Subject:     "CN=my-ca",
+			Issuer:      "CN=my-ca",
+			NotBefore:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			NotAfter:    time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		}},
+	})
+}
+
+func (cs *clientSuite) TestCertificatesError(c *check.C) {
+	cs.status = 500
+	cs.rsp = `{"type": "error", "result": {"message": "boom"}}`
+
+	_, err := cs.cli.Certificates(nil)
+	c.Check(err, check.ErrorMatches, "cannot list certificates: boom")
+}
+
+func (cs *clientSuite) checkCertificatesPost(c *check.C, expected map[string]any) {
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/certificates")
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var req map[string]any
+	c.Assert(json.Unmarshal(body, &req), check.IsNil)
+	c.Check(req, check.DeepEquals, expected)
+}
+
+func (cs *clientSuite) TestAddRemoveCertificate(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{"type": "async", "status-code": 202, "change": "42"}`
+
+	chgID, err := cs.cli.AddCertificate("my-ca", "PEM", nil)
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "42")
+	cs.checkCertificatesPost(c, map[string]any{"action": "add", "name": "my-ca", "content": "PEM"})
+
+	chgID, err = cs.cli.AddCertificate("my-ca", "PEM", &client.CertificateOptions{AllowExpired: true})
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "42")
+	cs.checkCertificatesPost(c, map[string]any{"action": "add", "name": "my-ca", "content": "PEM", "allow-expired": true})
+
+	chgID, err = cs.cli.RemoveCertificate("my-ca")
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "42")
+	cs.checkCertificatesPost(c, map[string]any{"action": "remove", "name": "my-ca"})
+
+	_, err = cs.cli.AddCertificate("my-ca", "", nil)
+	c.Check(err, check.ErrorMatches, "cannot add certificate without name and content")
+	_, err = cs.cli.RemoveCertificate("")
+	c.Check(err, check.ErrorMatches, "cannot remove certificate without a name")
+}
+
+func (cs *clientSuite) TestBlockUnblockCertificate(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{"type": "async", "status-code": 202, "change": "42"}`
+
+	chgID, err := cs.cli.BlockCertificate(client.CertificateRef{Fingerprint: "1234"})
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "42")
+	cs.checkCertificatesPost(c, map[string]any{"action": "block", "fingerprint": "1234"})
+
+	chgID, err = cs.cli.UnblockCertificate(client.CertificateRef{Name: "my-ca"}, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "42")
+	cs.checkCertificatesPost(c, map[string]any{"action": "unblock", "name": "my-ca"})
+
+	chgID, err = cs.cli.UnblockCertificate(client.CertificateRef{Name: "my-ca"}, &client.CertificateOptions{AllowExpired: true})
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "42")
+	cs.checkCertificatesPost(c, map[string]any{"action": "unblock", "name": "my-ca", "allow-expired": true})
+
+	_, err = cs.cli.BlockCertificate(client.CertificateRef{})
+	c.Check(err, check.ErrorMatches, "cannot block certificate: exactly one of name or fingerprint must be provided")
+	_, err = cs.cli.UnblockCertificate(client.CertificateRef{Name: "a", Fingerprint: "b"}, nil)
+	c.Check(err, check.ErrorMatches, "cannot unblock certificate: exactly one of name or fingerprint must be provided")
+}
@@ -171,6 +171,9 @@ const (
 	// ErrorKindInterfacesRequestsRuleConflict: a rule with conflicting path pattern and permissions already exists.
 	ErrorKindInterfacesRequestsRuleConflict ErrorKind = "interfaces-requests-rule-conflict"
 
+	// ErrorKindInterfacesRequestsRuleLocked: the rule is locked by the administrator and cannot be modified by the user.
+	ErrorKindInterfacesRequestsRuleLocked ErrorKind = "interfaces-requests-rule-locked"
+
 	// ErrorKindMissingSnapResourcePair: cannot find a snap-resource-pair when attempting to sideload a component.
 	ErrorKindMissingSnapResourcePair ErrorKind = "missing-snap-resource-pair"
 
@@ -147,3 +147,35 @@ func (client *Client) Disconnect(plugSnapName, plugName, slotSnapName, slotName
 		Slots:  []Slot{{Snap: slotSnapName, Name: slotName}},
 	})
 }
+
+// PolicyCheckResult holds the outcome of checking a candidate connection
+// against the declarations for either connecting or auto-connecting.
+type PolicyCheckResult struct {
+	Allowed bool     `json:"allowed"`
+	Error   string   `json:"error,omitempty"`
+	Rule    string   `json:"rule,omitempty"`
+	Trace   []string `json:"trace,omitempty"`
+}
+
+// ConnectionPolicyCheck describes how the connection policy applies to a
+// plug and a slot.
+type ConnectionPolicyCheck struct {
+	Plug        PlugRef           `json:"plug"`
+	Slot        SlotRef           `json:"slot"`
+	Interface   string            `json:"interface"`
+	Connect     PolicyCheckResult `json:"connect"`
+	AutoConnect PolicyCheckResult `json:"auto-connect"`
+}
+
+// CheckConnectionPolicy checks whether the given plug and slot are allowed
+// to be connected or auto-connected by the policy, without connecting them.
+func (client *Client) CheckConnectionPolicy(plugSnapName, plugName, slotSnapName, slotName string) (*ConnectionPolicyCheck, error) {
+	query := url.Values{}
+	query.Set("plug", plugSnapName+":"+plugName)
+	query.Set("slot", slotSnapName+":"+slotName)
+	var check ConnectionPolicyCheck
+	if _, err := client.doSync("GET", "/v2/interfaces/check", query, nil, nil, &check); err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
@@ -21,6 +21,7 @@ package client_test
 
 import (
 	"encoding/json"
+	"net/url"
 
 	"gopkg.in/check.v1"
 
@@ -266,3 +267,48 @@ func (cs *clientSuite) TestClientDisconnectForget(c *check.C) {
 		},
 	})
 }
+
+func (cs *clientSuite) TestClientCheckConnectionPolicy(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": {
+			"plug": {"snap": "consumer", "plug": "plug"},
+			"slot": {"snap": "producer", "slot": "slot"},
+			"interface": "test",
+			"connect": {
+				"allowed": true,
+				"rule": "slot rule of interface \"test\" in the base-declaration",
+				"trace": ["deny-connection does not match: not allowed", "allow-connection matches"]
+			},
+			"auto-connect": {
+				"allowed": false,
+				"error": "auto-connection not allowed by slot rule of interface \"test\"",
+				"rule": "slot rule of interface \"test\" in the base-declaration",
+				"trace": ["deny-auto-connection does not match: not allowed", "allow-auto-connection does not match: not allowed"]
+			}
+		}
+	}`
+	policyCheck, err := cs.cli.CheckConnectionPolicy("consumer", "plug", "producer", "slot")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces/check")
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"plug": []string{"consumer:plug"},
+		"slot": []string{"producer:slot"},
+	})
+	c.Check(policyCheck, check.DeepEquals, &client.ConnectionPolicyCheck{
+		Plug:      client.PlugRef{Snap: "consumer", Name: "plug"},
+		Slot:      client.SlotRef{Snap: "producer", Name: "slot"},
+		Interface: "test",
+		Connect: client.PolicyCheckResult{
+			Allowed: true,
+			Rule:    `slot rule of interface "test" in the base-declaration`,
+			Trace:   []string{"deny-connection does not match: not allowed", "allow-connection matches"},
+		},
+		AutoConnect: client.PolicyCheckResult{
+			Error: `auto-connection not allowed by slot rule of interface "test"`,
+			Rule:  `slot rule of interface "test" in the base-declaration`,
+			Trace: []string{"deny-auto-connection does not match: not allowed", "allow-auto-connection does not match: not allowed"},
+		},
+	})
+}
@@ -103,6 +103,9 @@ type SnapHealth struct {
 	Status    string        `json:"status"`
 	Message   string        `json:"message,omitempty"`
 	Code      string        `json:"code,omitempty"`
+	// Stale is set when the snap is checked periodically but has not
+	// reported its health for a while.
+	Stale bool `json:"stale,omitempty"`
 }
 
 type SnapRefreshInhibit struct {
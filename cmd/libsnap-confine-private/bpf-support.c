@@ -158,13 +158,18 @@ int bpf_load_prog(enum bpf_prog_type type, const struct bpf_insn *insns, size_t
 }
 
 int bpf_prog_attach(enum bpf_attach_type type, int cgroup_fd, int prog_fd) {
-    debug("attach type 0x%x program %d to cgroup %d", type, prog_fd, cgroup_fd);
+    return bpf_prog_attach_flags(type, cgroup_fd, prog_fd, 0);
+}
+
+int bpf_prog_attach_flags(enum bpf_attach_type type, int cgroup_fd, int prog_fd, unsigned int flags) {
+    debug("attach type 0x%x program %d to cgroup %d with flags 0x%x", type, prog_fd, cgroup_fd, flags);
     union bpf_attr attr;
     memset(&attr, 0, sizeof(attr));
 
     attr.attach_type = type;
     attr.target_fd = cgroup_fd;
     attr.attach_bpf_fd = prog_fd;
+    attr.attach_flags = flags;
 
     return sys_bpf(BPF_PROG_ATTACH, &attr, sizeof(attr));
 }
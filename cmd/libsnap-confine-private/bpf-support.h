@@ -49,6 +49,12 @@ int bpf_load_prog(enum bpf_prog_type type, const struct bpf_insn *insns, size_t
 
 int bpf_prog_attach(enum bpf_attach_type type, int cgroup_fd, int prog_fd);
 
+/**
+ * bpf_prog_attach_flags attaches a program to a cgroup like bpf_prog_attach,
+ * with the given attach flags, eg. BPF_F_ALLOW_MULTI.
+ */
+int bpf_prog_attach_flags(enum bpf_attach_type type, int cgroup_fd, int prog_fd, unsigned int flags);
+
 /**
  * bf_create_map creates a BPF map and returns a file descriptor handle to it.
  * The returned file descriptor has O_CLOEXEC flag set on it.
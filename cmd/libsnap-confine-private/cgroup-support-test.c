@@ -313,7 +313,23 @@ static void test_sc_cgroupv2_own_group_path_permission(cgroupv2_own_group_fixtur
     _test_sc_cgroupv2_own_group_path_die_with_message("cannot open *: Permission denied\n");
 }
 
+static void test_sc_cgroupv2_is_snap_cgroup(void) {
+    g_assert_true(sc_cgroup_v2_is_snap_cgroup("/user.slice/snap.foo.bar-1234-1234.scope", "snap.foo.bar"));
+    g_assert_true(sc_cgroup_v2_is_snap_cgroup("/system.slice/snap.foo.bar.service", "snap.foo.bar"));
+    g_assert_true(sc_cgroup_v2_is_snap_cgroup("snap.foo.hook.install-1234.scope", "snap.foo.hook.install"));
+    /* other apps of the same snap */
+    g_assert_false(sc_cgroup_v2_is_snap_cgroup("/user.slice/snap.foo.barbaz-1234.scope", "snap.foo.bar"));
+    g_assert_false(sc_cgroup_v2_is_snap_cgroup("/user.slice/snap.foo.baz-1234.scope", "snap.foo.bar"));
+    /* not a scope or service */
+    g_assert_false(sc_cgroup_v2_is_snap_cgroup("/user.slice/snap.foo.bar-1234.slice", "snap.foo.bar"));
+    g_assert_false(sc_cgroup_v2_is_snap_cgroup("/user.slice/snap.foo.bar", "snap.foo.bar"));
+    /* only the leaf is considered */
+    g_assert_false(sc_cgroup_v2_is_snap_cgroup("/snap.foo.bar.service/other.scope", "snap.foo.bar"));
+}
+
 static void __attribute__((constructor)) init(void) {
+    g_test_add_func("/cgroup/v2/is_snap_cgroup", test_sc_cgroupv2_is_snap_cgroup);
+
     g_test_add("/cgroup/v2/own_path_full_newline", cgroupv2_own_group_fixture,
                "0::/foo/bar/baz.slice/snap.foo.bar.1234-1234.scope\n", cgroupv2_own_group_set_up,
                test_sc_cgroupv2_own_group_path_simple_happy_scope, cgroupv2_own_group_tear_down);
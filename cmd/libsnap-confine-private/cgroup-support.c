@@ -234,3 +234,26 @@ char *sc_cgroup_v2_own_path_full(void) {
     }
     return own_group;
 }
+
+bool sc_cgroup_v2_is_snap_cgroup(const char *group, const char *expected_group_name) {
+    /* make a copy as basename may modify its input */
+    char copy[PATH_MAX] = {0};
+    strncpy(copy, group, sizeof(copy) - 1);
+    char *leaf = basename(copy);
+    /* expecting: snap.foo.bar-<uuid>.scope or snap.foo.bar.service, where
+       snap.foo.bar is the group name derived from security tag */
+    if (!sc_startswith(leaf, expected_group_name)) {
+        return false;
+    }
+    if (!sc_endswith(leaf, ".service") && !sc_endswith(leaf, ".scope")) {
+        return false;
+    }
+    /* we already know that the string is longer than the group name as it at
+       least ends with .service or .scope */
+    char uuid_or_svc_sep = leaf[strlen(expected_group_name)];
+    if (uuid_or_svc_sep != '-' && uuid_or_svc_sep != '.') {
+        return false;
+    }
+
+    return true;
+}
@@ -62,4 +62,14 @@ bool sc_cgroup_v2_is_tracking_snap(const char *snap_instance);
  */
 char *sc_cgroup_v2_own_path_full(void);
 
+/**
+ * sc_cgroup_v2_is_snap_cgroup checks that the cgroup looks like a snap specific
+ * one and matches the snap's expected cgroup name.
+ *
+ * The expected group name is derived from the security tag, eg. snap.foo.bar,
+ * and the group is expected to be either snap.foo.bar-<uuid>.scope or
+ * snap.foo.bar.service.
+ */
+bool sc_cgroup_v2_is_snap_cgroup(const char *group, const char *expected_group_name);
+
 #endif
@@ -297,31 +297,6 @@ static struct rlimit _sc_cgroup_v2_adjust_memlock_limit(void) {
     return old_limit;
 }
 
-// _sc_is_snap_cgroup checks that the cgroup looks like a snap specific one and
-// matches the snap's expected cgroup name.
-static bool _sc_is_snap_cgroup(const char *group, const char *expected_group_name) {
-    /* make a copy as basename may modify its input */
-    char copy[PATH_MAX] = {0};
-    strncpy(copy, group, sizeof(copy) - 1);
-    char *leaf = basename(copy);
-    /* expecting: snap.foo.bar-<uuid>.scope or snap.foo.bar.service, where
-       snap.foo.bar is the group name derived from security tag */
-    if (!sc_startswith(leaf, expected_group_name)) {
-        return false;
-    }
-    if (!sc_endswith(leaf, ".service") && !sc_endswith(leaf, ".scope")) {
-        return false;
-    }
-    /* we already know that the string is longer than the group name as it at
-       least ends with .service or .scope */
-    char uuid_or_svc_sep = leaf[strlen(expected_group_name)];
-    if (uuid_or_svc_sep != '-' && uuid_or_svc_sep != '.') {
-        return false;
-    }
-
-    return true;
-}
-
 static int _sc_cgroup_v2_init_bpf(sc_device_cgroup *self, int flags) {
     self->v2.devmap_fd = -1;
     self->v2.prog_fd = -1;
@@ -566,7 +541,7 @@ static void _sc_cgroup_v2_attach_pid_bpf(sc_device_cgroup *self, pid_t pid) {
     debug("process in cgroup %s", own_group);
 
     char *expected_unit_name SC_CLEANUP(sc_cleanup_string) = sc_security_tag_to_unit_name(self->security_tag);
-    if (!_sc_is_snap_cgroup(own_group, expected_unit_name)) {
+    if (!sc_cgroup_v2_is_snap_cgroup(own_group, expected_unit_name)) {
         /* we cannot proceed to install a device filtering program when the
          * process is not in a snap specific cgroup, as we would effectively
          * lock down the group that can be shared with other processes or even
@@ -0,0 +1,129 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+#include "config.h"
+
+#include <errno.h>
+#include <fcntl.h>
+#include <limits.h>
+#include <string.h>
+#include <unistd.h>
+
+#include "cgroup-support.h"
+#include "cleanup-funcs.h"
+#include "snap.h"
+#include "string-utils.h"
+#include "utils.h"
+
+#ifdef ENABLE_BPF
+#include "bpf-support.h"
+#endif
+#include "network-cgroup-support.h"
+
+/* max wait time for snapd to pin the network filter, which only happens once
+ * snapd has started after a reboot */
+static const size_t NETWORK_FILTER_MAX_WAIT = 120;
+
+#ifdef ENABLE_BPF
+/* the programs of a network filter, as pinned by snapd in
+ * /sys/fs/bpf/snap/net/<snap-instance>/<security-tag> */
+static const struct {
+    const char *name;
+    enum bpf_attach_type type;
+} sc_network_filter_programs[] = {
+    {"connect4", BPF_CGROUP_INET4_CONNECT},
+    {"connect6", BPF_CGROUP_INET6_CONNECT},
+    {"egress", BPF_CGROUP_INET_EGRESS},
+    {"sockops", BPF_CGROUP_SOCK_OPS},
+};
+
+static void _sc_network_cgroup_attach_filter_bpf(const char *snap_instance, const char *security_tag) {
+    /* bpffs does not allow dots in names, snapd replaces them with
+     * underscores */
+    char pin_name[PATH_MAX] = {0};
+    sc_must_snprintf(pin_name, sizeof(pin_name), "%s", security_tag);
+    for (char *c = pin_name; *c != '\0'; c++) {
+        if (*c == '.') {
+            *c = '_';
+        }
+    }
+
+    char filter_dir[PATH_MAX] = {0};
+    sc_must_snprintf(filter_dir, sizeof(filter_dir), "/sys/fs/bpf/snap/net/%s/%s", snap_instance, pin_name);
+    if (!sc_wait_for_file(filter_dir, NETWORK_FILTER_MAX_WAIT)) {
+        die("timeout waiting for network filter at %s", filter_dir);
+    }
+
+    char *own_group SC_CLEANUP(sc_cleanup_string) = sc_cgroup_v2_own_path_full();
+    if (own_group == NULL) {
+        die("cannot obtain own group path");
+    }
+    debug("process in cgroup %s", own_group);
+
+    char *expected_unit_name SC_CLEANUP(sc_cleanup_string) = sc_security_tag_to_unit_name(security_tag);
+    if (!sc_cgroup_v2_is_snap_cgroup(own_group, expected_unit_name)) {
+        /* the filter would otherwise restrict the network access of other
+         * processes sharing the group */
+        die("%s is not a snap cgroup for tag %s", own_group, security_tag);
+    }
+
+    char own_group_full_path[PATH_MAX] = {0};
+    sc_must_snprintf(own_group_full_path, sizeof(own_group_full_path), "/sys/fs/cgroup/%s", own_group);
+
+    int cgroup_fd SC_CLEANUP(sc_cleanup_close) = -1;
+    cgroup_fd = open(own_group_full_path, O_PATH | O_DIRECTORY | O_CLOEXEC | O_NOFOLLOW);
+    if (cgroup_fd < 0) {
+        die("cannot open own cgroup directory %s", own_group_full_path);
+    }
+
+    for (size_t i = 0; i < SC_ARRAY_SIZE(sc_network_filter_programs); i++) {
+        char prog_path[PATH_MAX] = {0};
+        sc_must_snprintf(prog_path, sizeof(prog_path), "%s/%s", filter_dir, sc_network_filter_programs[i].name);
+
+        int prog_fd SC_CLEANUP(sc_cleanup_close) = -1;
+        prog_fd = bpf_get_by_path(prog_path);
+        if (prog_fd < 0) {
+            die("cannot obtain network filter program %s", prog_path);
+        }
+        /* keep the programs attached to the group by others, eg. systemd,
+         * the program is already attached when the group of a service is
+         * reused */
+        if (bpf_prog_attach_flags(sc_network_filter_programs[i].type, cgroup_fd, prog_fd, BPF_F_ALLOW_MULTI) < 0 &&
+            errno != EEXIST) {
+            die("cannot attach network filter program %s", prog_path);
+        }
+    }
+    debug("network filter of %s attached", security_tag);
+}
+#endif /* ENABLE_BPF */
+
+void sc_network_cgroup_attach_filter(const char *snap_instance, const char *security_tag) {
+    char filter_file[PATH_MAX] = {0};
+    sc_must_snprintf(filter_file, sizeof(filter_file), "/var/lib/snapd/cgroup/%s.network", security_tag);
+    if (access(filter_file, F_OK) < 0) {
+        if (errno != ENOENT) {
+            die("cannot check network filter file %s", filter_file);
+        }
+        debug("no network filter for %s", security_tag);
+        return;
+    }
+#ifdef ENABLE_BPF
+    _sc_network_cgroup_attach_filter_bpf(snap_instance, security_tag);
+#else
+    (void)snap_instance;
+    die("cannot attach network filter of %s: BPF support is not enabled", security_tag);
+#endif
+}
@@ -0,0 +1,34 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+#ifndef SNAP_CONFINE_NETWORK_CGROUP_SUPPORT_H
+#define SNAP_CONFINE_NETWORK_CGROUP_SUPPORT_H
+
+/**
+ * sc_network_cgroup_attach_filter attaches the network filter pinned by snapd
+ * for the given security tag to the cgroup of the current process.
+ *
+ * Nothing is done unless snapd wrote the network filter file of the security
+ * tag in /var/lib/snapd/cgroup. Otherwise the call waits for snapd to pin the
+ * filter, which it does again when it starts after a reboot, and dies if the
+ * filter cannot be attached, so that the application does not run without
+ * its network restrictions. The filter requires cgroup v2 and the process
+ * must be in the snap specific cgroup of the security tag.
+ */
+void sc_network_cgroup_attach_filter(const char *snap_instance, const char *security_tag);
+
+#endif
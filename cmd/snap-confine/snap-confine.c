@@ -44,6 +44,7 @@
 #include "../libsnap-confine-private/feature.h"
 #include "../libsnap-confine-private/infofile.h"
 #include "../libsnap-confine-private/locking.h"
+#include "../libsnap-confine-private/network-cgroup-support.h"
 #include "../libsnap-confine-private/privs.h"
 #include "../libsnap-confine-private/secure-getenv.h"
 #include "../libsnap-confine-private/snap-dir.h"
@@ -835,6 +836,13 @@ static void enter_non_classic_execution_environment(sc_invocation *inv, struct s
             sc_device_cgroup_mode mode = device_cgroup_mode_for_snap(inv);
             sc_setup_device_cgroup(inv->security_tag, mode);
         }
+
+        // Restrict the destinations the application or hook can send traffic
+        // to, when its network plugs declare them. This requires tracking
+        // with cgroup v2, snapd does not set up network filters otherwise.
+        if (sc_cgroup_is_v2()) {
+            sc_network_cgroup_attach_filter(inv->snap_instance, inv->security_tag);
+        }
     }
 
     /**
@@ -0,0 +1,207 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdCertificates struct {
+	Origin      string `long:"origin" choice:"system" choice:"custom"`
+	Add         bool   `long:"add"`
+	Remove      bool   `long:"remove"`
+	Block       bool   `long:"block"`
+	Unblock     bool   `long:"unblock"`
+	Fingerprint string `long:"fingerprint"`
+	// AllowExpired applies to this invocation only
+	AllowExpired bool `long:"allow-expired"`
+	Positional   struct {
+		Name string `positional-arg-name:"<name>"`
+		File string `positional-arg-name:"<file>"`
+	} `positional-args:"yes"`
+	timeMixin
+	waitMixin
+}
+
+var shortCertificatesHelp = i18n.G("List or manage the certificates trusted by the system")
+var longCertificatesHelp = i18n.G(`
+The certificates command lists the system and custom certificates that snapd
+merges into the certificate database used by snaps, together with their state.
+
+Custom certificates are added with --add from a PEM or DER encoded file and
+removed with --remove. Any certificate can be excluded from the database with
+--block and included again with --unblock, custom certificates are identified
+by their name and system certificates by their --fingerprint.
+
+Adding or unblocking a custom certificate that has already expired is refused
+unless --allow-expired is given.
+`)
+
+func init() {
+	addCommand("certificates", shortCertificatesHelp, longCertificatesHelp, func() flags.Commander { return &cmdCertificates{} }, waitDescs.also(timeDescs).also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"origin": i18n.G("Only list certificates of the given origin"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"add": i18n.G("Add, or replace, the named custom certificate with the content of the given file"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"remove": i18n.G("Remove the named custom certificate"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"block": i18n.G("Exclude the given certificate from the certificate database"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"unblock": i18n.G("Include the given certificate in the certificate database again"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"fingerprint": i18n.G("Fingerprint of the system certificate to block or unblock"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"allow-expired": i18n.G("Allow adding or unblocking a custom certificate that has already expired"),
+	}), []argDesc{{
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<name>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("Name of a custom certificate"),
+	}, {
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<file>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("File with the certificate to add"),
+	}})
+}
+
+func (cmd *cmdCertificates) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	// check that only one action is used at a time
+	var action string
+	for _, a := range []struct {
+		name string
+		set  bool
+	}{
+		{"add", cmd.Add},
+		{"remove", cmd.Remove},
+		{"block", cmd.Block},
+		{"unblock", cmd.Unblock},
+	} {
+		if a.set {
+			if action != "" {
+				return fmt.Errorf(i18n.G("cannot use --%s and --%s together"), action, a.name)
+			}
+			action = a.name
+		}
+	}
+
+	if action == "" {
+		if cmd.Positional.Name != "" || cmd.Fingerprint != "" {
+			return fmt.Errorf(i18n.G("a certificate can only be given together with an action"))
+		}
+		return cmd.list()
+	}
+	if cmd.Origin != "" {
+		return fmt.Errorf(i18n.G("--origin can only be used when listing certificates"))
+	}
+	if cmd.Positional.File != "" && action != "add" {
+		return ErrExtraArgs
+	}
+	if cmd.AllowExpired && action != "add" && action != "unblock" {
+		return fmt.Errorf(i18n.G("--allow-expired can only be used with --add or --unblock"))
+	}
+	opts := &client.CertificateOptions{AllowExpired: cmd.AllowExpired}
+
+	var changeID string
+	var err error
+	switch action {
+	case "add":
+		if cmd.Fingerprint != "" {
+			return fmt.Errorf(i18n.G("cannot add a certificate by fingerprint"))
+		}
+		if cmd.Positional.Name == "" || cmd.Positional.File == "" {
+			return fmt.Errorf(i18n.G("the name of the certificate and the file to add are required"))
+		}
+		content, err := os.ReadFile(cmd.Positional.File)
+		if err != nil {
+			return fmt.Errorf(i18n.G("cannot read certificate: %v"), err)
+		}
+		changeID, err = cmd.client.AddCertificate(cmd.Positional.Name, string(content), opts)
+		if err != nil {
+			return err
+		}
+	case "remove":
+		if cmd.Fingerprint != "" {
+			return fmt.Errorf(i18n.G("cannot remove a certificate by fingerprint, block it instead"))
+		}
+		if cmd.Positional.Name == "" {
+			return fmt.Errorf(i18n.G("the name of the certificate to remove is required"))
+		}
+		changeID, err = cmd.client.RemoveCertificate(cmd.Positional.Name)
+	case "block", "unblock":
+		if (cmd.Positional.Name == "") == (cmd.Fingerprint == "") {
+			return fmt.Errorf(i18n.G("either the name of a custom certificate or the fingerprint of a system certificate is required"))
+		}
+		ref := client.CertificateRef{
+			Name:        cmd.Positional.Name,
+			Fingerprint: cmd.Fingerprint,
+		}
+		if action == "block" {
+			changeID, err = cmd.client.BlockCertificate(ref)
+		} else {
+			changeID, err = cmd.client.UnblockCertificate(ref, opts)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := cmd.wait(changeID); err != nil {
+		if err == noWait {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (cmd *cmdCertificates) list() error {
+	res, err := cmd.client.Certificates(&client.CertificatesOptions{Origin: cmd.Origin})
+	if err != nil {
+		return err
+	}
+	if len(res.Certificates) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("No certificates found."))
+		return nil
+	}
+
+	w := tabWriter()
+	fmt.Fprintln(w, i18n.G("Name\tFingerprint\tOrigin\tState\tExpires\tSubject"))
+	for _, cert := range res.Certificates {
+		name := cert.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", name, cert.Fingerprint, cert.Origin, cert.State, cmd.fmtTime(cert.NotAfter), cert.Subject)
+	}
+	w.Flush()
+	return nil
+}
@@ -0,0 +1,165 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cli_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	main "github.com/snapcore/snapd/cmd/snapd/cli"
+)
+
+type certificatesSuite struct {
+	BaseSnapSuite
+}
+
+var _ = check.Suite(&certificatesSuite{})
+
+const certificatesListJSON = `{"type": "sync", "status-code": 200, "result": {
+"generation": "3",
+"certificates": [
+  {"name": "corp-ca", "fingerprint": "aabb", "origin": "custom", "state": "accepted", "subject": "CN=Corp CA", "issuer": "CN=Corp CA", "not-before": "2026-01-01T00:00:00Z", "not-after": "2030-01-01T00:00:00Z"},
+  {"fingerprint": "ccdd", "origin": "system", "state": "blocked", "subject": "CN=Some Root", "issuer": "CN=Some Root", "not-before": "2020-01-01T00:00:00Z", "not-after": "2035-01-01T00:00:00Z"}
+]}}`
+
+func (s *certificatesSuite) TestCertificatesList(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/certificates")
+		c.Check(r.URL.RawQuery, check.Equals, "")
+		fmt.Fprintln(w, certificatesListJSON)
+	})
+
+	rest, err := main.Parser(main.Client()).ParseArgs([]string{"certificates", "--abs-time"})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(n, check.Equals, 1)
+	c.Check(s.Stdout(), check.Equals, `
+Name     Fingerprint  Origin  State     Expires               Subject
+corp-ca  aabb         custom  accepted  2030-01-01T00:00:00Z  CN=Corp CA
+-        ccdd         system  blocked   2035-01-01T00:00:00Z  CN=Some Root
+`[1:])
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *certificatesSuite) TestCertificatesListOriginEmpty(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Query().Get("origin"), check.Equals, "custom")
+		fmt.Fprintln(w, `{"type": "sync", "status-code": 200, "result": {"certificates": []}}`)
+	})
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"certificates", "--origin=custom"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "")
+	c.Check(s.Stderr(), check.Equals, "No certificates found.\n")
+}
+
+func (s *certificatesSuite) TestCertificatesActions(c *check.C) {
+	certFile := filepath.Join(c.MkDir(), "corp.pem")
+	c.Assert(os.WriteFile(certFile, []byte("-----BEGIN CERTIFICATE-----\n"), 0644), check.IsNil)
+
+	for _, t := range []struct {
+		args     []string
+		expected map[string]any
+	}{
+		{[]string{"--add", "corp-ca", certFile}, map[string]any{"action": "add", "name": "corp-ca", "content": "-----BEGIN CERTIFICATE-----\n"}},
+		{[]string{"--remove", "corp-ca"}, map[string]any{"action": "remove", "name": "corp-ca"}},
+		{[]string{"--block", "corp-ca"}, map[string]any{"action": "block", "name": "corp-ca"}},
+		{[]string{"--block", "--fingerprint", "ccdd"}, map[string]any{"action": "block", "fingerprint": "ccdd"}},
+		{[]string{"--unblock", "--fingerprint", "ccdd"}, map[string]any{"action": "unblock", "fingerprint": "ccdd"}},
+		{[]string{"--add", "--allow-expired", "corp-ca", certFile}, map[string]any{"action": "add", "name": "corp-ca", "content": "-----BEGIN CERTIFICATE-----\n", "allow-expired": true}},
+		{[]string{"--unblock", "--allow-expired", "corp-ca"}, map[string]any{"action": "unblock", "name": "corp-ca", "allow-expired": true}},
+	} {
+		comment := check.Commentf("%v", t.args)
+		n := 0
+		s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch n {
+			case 0:
+				c.Check(r.Method, check.Equals, "POST", comment)
+				c.Check(r.URL.Path, check.Equals, "/v2/certificates", comment)
+				var body map[string]any
+				c.Check(json.NewDecoder(r.Body).Decode(&body), check.IsNil, comment)
+				c.Check(body, check.DeepEquals, t.expected, comment)
+				w.WriteHeader(202)
+				fmt.Fprintln(w, `{"type":"async", "change": "42", "status-code": 202}`)
+			case 1:
+				c.Check(r.Method, check.Equals, "GET", comment)
+				c.Check(r.URL.Path, check.Equals, "/v2/changes/42", comment)
+				fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done"}}`)
+			default:
+				c.Fatalf("expected to get 2 requests, now on %d", n+1)
+			}
+			n++
+		})
+
+		rest, err := main.Parser(main.Client()).ParseArgs(append([]string{"certificates"}, t.args...))
+		c.Assert(err, check.IsNil, comment)
+		c.Check(rest, check.HasLen, 0)
+		c.Check(n, check.Equals, 2, comment)
+	}
+}
+
+func (s *certificatesSuite) TestCertificatesNoWait(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "POST")
+		w.WriteHeader(202)
+		fmt.Fprintln(w, `{"type":"async", "change": "42", "status-code": 202}`)
+	})
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"certificates", "--remove", "--no-wait", "corp-ca"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "42\n")
+}
+
+func (s *certificatesSuite) TestCertificatesInvalidArgs(c *check.C) {
+	for _, t := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--add", "--remove", "foo"}, `cannot use --add and --remove together`},
+		{[]string{"--block", "--unblock", "foo"}, `cannot use --block and --unblock together`},
+		{[]string{"foo"}, `a certificate can only be given together with an action`},
+		{[]string{"--fingerprint", "aabb"}, `a certificate can only be given together with an action`},
+		{[]string{"--remove", "--origin", "custom", "foo"}, `--origin can only be used when listing certificates`},
+		{[]string{"--add", "foo"}, `the name of the certificate and the file to add are required`},
+		{[]string{"--add", "--fingerprint", "aabb"}, `cannot add a certificate by fingerprint`},
+		{[]string{"--add", "foo", "/does/not/exist"}, `cannot read certificate: open /does/not/exist: no such file or directory`},
+		{[]string{"--remove"}, `the name of the certificate to remove is required`},
+		{[]string{"--remove", "--fingerprint", "aabb"}, `cannot remove a certificate by fingerprint, block it instead`},
+		{[]string{"--remove", "foo", "bar"}, `too many arguments for command`},
+		{[]string{"--remove", "--allow-expired", "foo"}, `--allow-expired can only be used with --add or --unblock`},
+		{[]string{"--block"}, `either the name of a custom certificate or the fingerprint of a system certificate is required`},
+		{[]string{"--unblock", "--fingerprint", "aabb", "foo"}, `either the name of a custom certificate or the fingerprint of a system certificate is required`},
+	} {
+		s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+			c.Fatalf("unexpected request for %v", t.args)
+		})
+		_, err := main.Parser(main.Client()).ParseArgs(append([]string{"certificates"}, t.args...))
+		c.Check(err, check.ErrorMatches, t.err, check.Commentf("%v", t.args))
+	}
+}
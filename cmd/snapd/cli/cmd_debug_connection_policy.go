@@ -0,0 +1,91 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdDebugConnectionPolicy struct {
+	clientMixin
+	Positionals struct {
+		PlugSpec connectPlugSpec `required:"yes"`
+		SlotSpec connectSlotSpec `required:"yes"`
+	} `positional-args:"true"`
+}
+
+func init() {
+	addDebugCommand("connection-policy",
+		"Check whether a plug and a slot may be connected",
+		"The connection-policy command shows how the interface policy applies to connecting and auto-connecting the given plug and slot, without connecting them.",
+		func() flags.Commander {
+			return &cmdDebugConnectionPolicy{}
+		}, nil, []argDesc{
+			// TRANSLATORS: This needs to begin with < and end with >
+			{name: i18n.G("<snap>:<plug>")},
+			// TRANSLATORS: This needs to begin with < and end with >
+			{name: i18n.G("<snap>:<slot>")},
+		})
+}
+
+func (x *cmdDebugConnectionPolicy) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	plug, slot := x.Positionals.PlugSpec, x.Positionals.SlotSpec
+	check, err := x.client.CheckConnectionPolicy(plug.Snap, plug.Name, slot.Snap, slot.Name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(Stdout, "plug: %s:%s\n", check.Plug.Snap, check.Plug.Name)
+	fmt.Fprintf(Stdout, "slot: %s:%s\n", check.Slot.Snap, check.Slot.Name)
+	fmt.Fprintf(Stdout, "interface: %s\n", check.Interface)
+	printPolicyCheckResult(Stdout, "connect", &check.Connect)
+	printPolicyCheckResult(Stdout, "auto-connect", &check.AutoConnect)
+	return nil
+}
+
+func printPolicyCheckResult(w io.Writer, what string, res *client.PolicyCheckResult) {
+	outcome := "allowed"
+	if !res.Allowed {
+		outcome = "denied"
+	}
+	fmt.Fprintf(w, "%s: %s\n", what, outcome)
+	if res.Error != "" {
+		fmt.Fprintf(w, "  error: %s\n", res.Error)
+	}
+	if res.Rule != "" {
+		fmt.Fprintf(w, "  rule: %s\n", res.Rule)
+	}
+	if len(res.Trace) > 0 {
+		fmt.Fprintf(w, "  trace:\n")
+		for _, step := range res.Trace {
+			fmt.Fprintf(w, "    - %s\n", step)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cli_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snapd/cli"
+)
+
+func (s *SnapSuite) TestDebugConnectionPolicy(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/interfaces/check")
+			c.Check(r.URL.Query().Get("plug"), check.Equals, "consumer:plug")
+			c.Check(r.URL.Query().Get("slot"), check.Equals, "producer:slot")
+			fmt.Fprintln(w, `{"type": "sync", "result": {
+"plug": {"snap": "consumer", "plug": "plug"},
+"slot": {"snap": "producer", "slot": "slot"},
+"interface": "test",
+"connect": {"allowed": true, "rule": "base-declaration plug rule", "trace": ["allow-connection matches"]},
+"auto-connect": {"allowed": false, "error": "auto-connection denied by plug rule of interface \"test\"", "rule": "base-declaration plug rule", "trace": ["deny-auto-connection matches"]}
+}}`)
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "connection-policy", "consumer:plug", "producer:slot"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `plug: consumer:plug
+slot: producer:slot
+interface: test
+connect: allowed
+  rule: base-declaration plug rule
+  trace:
+    - allow-connection matches
+auto-connect: denied
+  error: auto-connection denied by plug rule of interface "test"
+  rule: base-declaration plug rule
+  trace:
+    - deny-auto-connection matches
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestDebugConnectionPolicyMissingSlot(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "connection-policy", "consumer:plug"})
+	c.Assert(err, check.ErrorMatches, "the required argument `<snap>:<slot>` was not provided")
+}
@@ -59,8 +59,10 @@ type cmdPrepareImage struct {
 	ExtraSnaps               []string `long:"extra-snaps" hidden:"yes"` // DEPRECATED
 	RevisionsFile            string   `long:"revisions"`
 	WriteRevisionsFile       string   `long:"write-revisions" optional:"true" optional-value:"./seed.manifest"`
+	WriteSBOMFile            string   `long:"write-sbom" optional:"true" optional-value:"./seed.spdx.json"`
 	Validation               string   `long:"validation" choice:"ignore" choice:"enforce"`
 	AllowSnapdKernelMismatch bool     `long:"allow-snapd-kernel-mismatch"`
+	Verify                   bool     `long:"verify"`
 
 	// Filenames for extra assertions
 	ExtraAssertionFiles []string `long:"assert" value-name:"<filename>"`
@@ -76,7 +78,13 @@ creating device images.
 For core images it is not invoked directly but usually via
 ubuntu-image.
 
-For preparing classic images it supports a --classic mode`),
+For preparing classic images it supports a --classic mode.
+
+With --verify and --revisions no image is prepared, instead the seed
+previously prepared in the target directory is checked against the given
+manifest. Any difference in snap and component revisions or validation
+set sequences is reported and the command exits with status 3. A seed
+that cannot be verified at all results in exit status 1.`),
 		func() flags.Commander { return &cmdPrepareImage{} },
 		map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
@@ -102,6 +110,8 @@ For preparing classic images it supports a --classic mode`),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"write-revisions": i18n.G("Writes a manifest file containing references to the exact snap revisions used for the image. A path for the manifest is optional."),
 			// TRANSLATORS: This should not start with a lowercase letter.
+			"write-sbom": i18n.G("Writes an SPDX SBOM in JSON format describing the snaps, components, model and validation sets of the image. A path for the SBOM is optional."),
+			// TRANSLATORS: This should not start with a lowercase letter.
 			"channel": i18n.G("The channel to use"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"customize": i18n.G("Image customizations specified as JSON file."),
@@ -110,6 +120,8 @@ For preparing classic images it supports a --classic mode`),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"allow-snapd-kernel-mismatch": i18n.G("Whether a mismatch between versions of the snapd snap and snapd in kernel is allowed"),
 			// TRANSLATORS: This should not start with a lowercase letter.
+			"verify": i18n.G("Verify the seed in the target directory against the --revisions manifest instead of preparing an image"),
+			// TRANSLATORS: This should not start with a lowercase letter.
 			"assert": i18n.G("Include the assertion from the local file"),
 		}, []argDesc{
 			{
@@ -127,8 +139,21 @@ For preparing classic images it supports a --classic mode`),
 }
 
 var imagePrepare = image.Prepare
+var imageVerifySeed = image.VerifySeed
 var seedwriterReadManifest = seedwriter.ReadManifest
 
+// seedDriftExitCode is the exit status of prepare-image --verify when
+// the seed does not match the manifest.
+const seedDriftExitCode = 3
+
+type seedDriftError struct {
+	n int
+}
+
+func (e *seedDriftError) Error() string {
+	return fmt.Sprintf(i18n.NG("seed does not match manifest: %d difference found", "seed does not match manifest: %d differences found", e.n), e.n)
+}
+
 func (x *cmdPrepareImage) Execute(args []string) error {
 	// plug/slot sanitization is disabled (no-op) by default at the package
 	// level for "snap" command, for seed/seedwriter used by image however
@@ -142,10 +167,15 @@ func (x *cmdPrepareImage) Execute(args []string) error {
 		Channel:                  x.Channel,
 		Architecture:             x.Architecture,
 		SeedManifestPath:         x.WriteRevisionsFile,
+		SeedSBOMPath:             x.WriteSBOMFile,
 		AllowSnapdKernelMismatch: x.AllowSnapdKernelMismatch,
 		ExtraAssertionsFiles:     x.ExtraAssertionFiles,
 	}
 
+	if x.Verify && x.RevisionsFile == "" {
+		return fmt.Errorf("--verify cannot be used without --revisions")
+	}
+
 	if x.RevisionsFile != "" {
 		seedManifest, err := seedwriterReadManifest(x.RevisionsFile)
 		if err != nil {
@@ -154,6 +184,10 @@ func (x *cmdPrepareImage) Execute(args []string) error {
 		opts.SeedManifest = seedManifest
 	}
 
+	if x.Verify {
+		return verifySeed(x.Positional.TargetDir, opts.SeedManifest)
+	}
+
 	if x.Customize != "" {
 		custo, err := readImageCustomizations(x.Customize)
 		if err != nil {
@@ -208,6 +242,24 @@ func (x *cmdPrepareImage) Execute(args []string) error {
 	return imagePrepare(opts)
 }
 
+func verifySeed(prepareDir string, manifest *seedwriter.Manifest) error {
+	drift, err := imageVerifySeed(&image.VerifyOptions{
+		PrepareDir: prepareDir,
+		Manifest:   manifest,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot verify seed: %v", err)
+	}
+	for _, d := range drift {
+		fmt.Fprintf(Stdout, "%s\n", d)
+	}
+	if len(drift) != 0 {
+		return &seedDriftError{n: len(drift)}
+	}
+	fmt.Fprintln(Stdout, i18n.G("seed matches manifest"))
+	return nil
+}
+
 func readImageCustomizations(customizationsFile string) (*image.Customizations, error) {
 	f, err := os.Open(customizationsFile)
 	if err != nil {
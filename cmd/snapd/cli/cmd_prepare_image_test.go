@@ -20,6 +20,7 @@
 package cli_test
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 
@@ -257,6 +258,67 @@ func (s *SnapPrepareImageSuite) TestReadSeedManifest(c *C) {
 	})
 }
 
+func (s *SnapPrepareImageSuite) mockVerify(c *C, drift []*image.SeedDrift, verifyErr error) (restore func()) {
+	r1 := cmdsnap.MockImagePrepare(func(o *image.Options) error {
+		c.Fatalf("unexpected image prepare")
+		return nil
+	})
+	manifest := seedwriter.MockManifest(map[string]*seedwriter.ManifestSnapRevision{"snapd": {SnapName: "snapd", Revision: snap.R(100)}}, nil, nil, nil)
+	r2 := cmdsnap.MockSeedWriterReadManifest(func(manifestFile string) (*seedwriter.Manifest, error) {
+		c.Check(manifestFile, Equals, "seed.manifest")
+		return manifest, nil
+	})
+	r3 := cmdsnap.MockImageVerifySeed(func(opts *image.VerifyOptions) ([]*image.SeedDrift, error) {
+		c.Check(opts, DeepEquals, &image.VerifyOptions{
+			PrepareDir: "prepare-dir",
+			Manifest:   manifest,
+		})
+		return drift, verifyErr
+	})
+	return func() {
+		r3()
+		r2()
+		r1()
+	}
+}
+
+func (s *SnapPrepareImageSuite) TestPrepareImageVerify(c *C) {
+	defer s.mockVerify(c, nil, nil)()
+
+	rest, err := cmdsnap.Parser(cmdsnap.Client()).ParseArgs([]string{"prepare-image", "--verify", "--revisions", "seed.manifest", "model", "prepare-dir"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, "seed matches manifest\n")
+}
+
+func (s *SnapPrepareImageSuite) TestPrepareImageVerifyDrift(c *C) {
+	defer s.mockVerify(c, []*image.SeedDrift{
+		{Kind: "snap", Name: "snapd", Expected: "revision 100", Found: "revision 101"},
+		{Kind: "component", Name: "pc+kmod", Found: "revision 3"},
+	}, nil)()
+
+	_, err := cmdsnap.Parser(cmdsnap.Client()).ParseArgs([]string{"prepare-image", "--verify", "--revisions", "seed.manifest", "model", "prepare-dir"})
+	c.Assert(err, ErrorMatches, `seed does not match manifest: 2 differences found`)
+	c.Check(cmdsnap.ExitCodeFromError(err), Equals, 3)
+	c.Check(s.Stdout(), Equals, `snap "snapd": seed has revision 101 but manifest requires revision 100
+component "pc+kmod": revision 3 in the seed is not in the manifest
+`)
+}
+
+func (s *SnapPrepareImageSuite) TestPrepareImageVerifyError(c *C) {
+	defer s.mockVerify(c, nil, errors.New("cannot find a seed in prepare-dir"))()
+
+	_, err := cmdsnap.Parser(cmdsnap.Client()).ParseArgs([]string{"prepare-image", "--verify", "--revisions", "seed.manifest", "model", "prepare-dir"})
+	c.Assert(err, ErrorMatches, `cannot verify seed: cannot find a seed in prepare-dir`)
+	c.Check(cmdsnap.ExitCodeFromError(err), Equals, 1)
+	c.Check(s.Stdout(), Equals, "")
+}
+
+func (s *SnapPrepareImageSuite) TestPrepareImageVerifyNoRevisions(c *C) {
+	_, err := cmdsnap.Parser(cmdsnap.Client()).ParseArgs([]string{"prepare-image", "--verify", "model", "prepare-dir"})
+	c.Assert(err, ErrorMatches, `--verify cannot be used without --revisions`)
+}
+
 func (s *SnapPrepareImageSuite) TestPrepareImagePreseedArgError(c *C) {
 	_, err := cmdsnap.Parser(cmdsnap.Client()).ParseArgs([]string{"prepare-image", "--preseed-sign-key", "key", "model", "prepare-dir"})
 	c.Assert(err, ErrorMatches, `--preseed-sign-key cannot be used without --preseed`)
@@ -315,6 +377,36 @@ func (s *SnapPrepareImageSuite) TestPrepareImageWriteRevisions(c *C) {
 	})
 }
 
+func (s *SnapPrepareImageSuite) TestPrepareImageWriteSBOM(c *C) {
+	var opts *image.Options
+	prep := func(o *image.Options) error {
+		opts = o
+		return nil
+	}
+	r := cmdsnap.MockImagePrepare(prep)
+	defer r()
+
+	rest, err := cmdsnap.Parser(cmdsnap.Client()).ParseArgs([]string{"prepare-image", "model", "prepare-dir", "--write-sbom"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+
+	c.Check(opts, DeepEquals, &image.Options{
+		ModelFile:    "model",
+		PrepareDir:   "prepare-dir",
+		SeedSBOMPath: "./seed.spdx.json",
+	})
+
+	rest, err = cmdsnap.Parser(cmdsnap.Client()).ParseArgs([]string{"prepare-image", "model", "prepare-dir", "--write-sbom=/tmp/image.spdx.json"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+
+	c.Check(opts, DeepEquals, &image.Options{
+		ModelFile:    "model",
+		PrepareDir:   "prepare-dir",
+		SeedSBOMPath: "/tmp/image.spdx.json",
+	})
+}
+
 func (s *SnapPrepareImageSuite) TestPrepareImageValidation(c *C) {
 	var opts *image.Options
 	prep := func(o *image.Options) error {
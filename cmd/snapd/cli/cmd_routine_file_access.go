@@ -117,7 +117,7 @@ func (x *cmdRoutineFileAccess) Execute(args []string) error {
 	var promptingRunning bool
 	// Avoid checking prompting unless it is relevant, i.e. has an interface
 	// which is mediated by prompting.
-	if hasHome {
+	if hasHome || hasRemovableMedia {
 		// XXX: should features.AppArmorPrompting.IsEnabled() be used instead of
 		// querying the API for /v2/system-info?
 		sysInfo, err := x.client.SysInfo()
@@ -218,8 +218,9 @@ func (x *cmdRoutineFileAccess) checkAccess(snap *client.Snap, hasHome, hasRemova
 	}
 
 	// Snaps with removable-media plugged can access removable
-	// media mount points.
-	if hasRemovableMedia {
+	// media mount points. If prompting is running, then the file access
+	// should be proxied, as for the home interface.
+	if hasRemovableMedia && !promptingRunning {
 		if pathHasPrefix(pathParts, []string{"mnt"}) || pathHasPrefix(pathParts, []string{"media"}) || pathHasPrefix(pathParts, []string{"run", "media"}) {
 			return FileAccessReadWrite, nil
 		}
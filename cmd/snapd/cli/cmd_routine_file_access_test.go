@@ -246,6 +246,15 @@ func (s *SnapRoutineFileAccessSuite) TestAccessRemovableMedia(c *C) {
 	s.checkAccess(c, "/run/media/path/file.txt", "read-write\n")
 }
 
+func (s *SnapRoutineFileAccessSuite) TestAccessRemovableMediaAppArmorPromptingSupportedAndEnabled(c *C) {
+	s.setUpClient(c, false, false, true, false, true, true)
+	s.checkBasicAccess(c)
+
+	s.checkAccess(c, "/mnt/path/file.txt", "hidden\n")
+	s.checkAccess(c, "/media/path/file.txt", "hidden\n")
+	s.checkAccess(c, "/run/media/path/file.txt", "hidden\n")
+}
+
 func (s *SnapRoutineFileAccessSuite) TestAccessSystemPackagesDocInterfaceHidden(c *C) {
 	s.setUpClient(c, false, false, false, false, false, false)
 	s.checkBasicAccess(c)
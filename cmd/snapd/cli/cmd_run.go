@@ -50,6 +50,7 @@ import (
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/osutil/strace"
 	"github.com/snapcore/snapd/osutil/user"
+	"github.com/snapcore/snapd/overlord/certstate"
 	"github.com/snapcore/snapd/sandbox/cgroup"
 	"github.com/snapcore/snapd/sandbox/selinux"
 	"github.com/snapcore/snapd/snap"
@@ -1676,6 +1677,7 @@ func (x *cmdRun) runSnapConfine(info *snap.Info, runner runnable, beforeExec fun
 	}
 
 	snapenv.ExtendEnvForRun(env, info, runner.App(), runner.Component(), opts)
+	setCertificatesEnv(env, info.InstanceName())
 
 	if len(xauthPath) > 0 {
 		// Environment is not nil here because it comes from
@@ -1922,3 +1924,23 @@ func getSnapDirOptions(snapName string) (*dirs.SnapDirOptions, error) {
 var cgroupCreateTransientScopeForTracking = cgroup.CreateTransientScopeForTracking
 var cgroupConfirmSystemdServiceTracking = cgroup.ConfirmSystemdServiceTracking
 var cgroupConfirmSystemdAppTracking = cgroup.ConfirmSystemdAppTracking
+
+// setCertificatesEnv points TLS libraries which honour SSL_CERT_FILE and
+// SSL_CERT_DIR at the certificate database snapd maintains for the snap,
+// which includes the custom certificates restricted to it. Snaps without
+// such a database keep using the certificates of their base. Values set by
+// the user are left untouched.
+func setCertificatesEnv(env osutil.Environment, instanceName string) {
+	certsDir := certstate.SnapCertificatesDir(instanceName)
+	bundle := filepath.Join(certsDir, "ca-certificates.crt")
+	if !osutil.FileExists(bundle) {
+		// no custom certificates are restricted to the snap
+		return
+	}
+	if _, ok := env["SSL_CERT_FILE"]; !ok {
+		env["SSL_CERT_FILE"] = bundle
+	}
+	if _, ok := env["SSL_CERT_DIR"]; !ok {
+		env["SSL_CERT_DIR"] = certsDir
+	}
+}
@@ -285,6 +285,66 @@ func (s *RunSuite) TestSnapRunAppIntegration(c *check.C) {
 	c.Check(execEnv, testutil.Contains, fmt.Sprintf("TMPDIR=%s", tmpdir))
 }
 
+func (s *RunSuite) TestSnapRunAppCertificatesEnv(c *check.C) {
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+
+	snaptest.MockSnapCurrent(c, string(mockYamlForNameBase("snapname", "")), &snap.SideInfo{
+		Revision: snap.R("x2"),
+	})
+
+	var execEnv []string
+	restorer := snaprun.MockSyscallExec(func(arg0 string, args []string, envv []string) error {
+		execEnv = envv
+		return nil
+	})
+	defer restorer()
+
+	for _, name := range []string{"SSL_CERT_FILE", "SSL_CERT_DIR"} {
+		if value, ok := os.LookupEnv(name); ok {
+			os.Unsetenv(name)
+			defer os.Setenv(name, value)
+		}
+	}
+
+	run := func() {
+		execEnv = nil
+		_, err := snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--", "snapname.app"})
+		c.Assert(err, check.IsNil)
+	}
+
+	// no certificate database, nothing is set
+	run()
+	for _, e := range execEnv {
+		c.Check(strings.HasPrefix(e, "SSL_CERT_"), check.Equals, false, check.Commentf(e))
+	}
+
+	// the system view only, the certificates of the base are used
+	pkiDir := dirs.SnapdPKIV1Dir
+	c.Assert(os.MkdirAll(filepath.Join(pkiDir, "published", "gen1"), 0755), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(pkiDir, "published", "gen1", "ca-certificates.crt"), nil, 0644), check.IsNil)
+	c.Assert(os.Symlink("published/gen1", filepath.Join(pkiDir, "merged")), check.IsNil)
+	run()
+	for _, e := range execEnv {
+		c.Check(strings.HasPrefix(e, "SSL_CERT_"), check.Equals, false, check.Commentf(e))
+	}
+
+	// the dedicated view of the snap
+	c.Assert(os.MkdirAll(filepath.Join(pkiDir, "snap-published", "snapname", "gen2"), 0755), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(pkiDir, "snap-published", "snapname", "gen2", "ca-certificates.crt"), nil, 0644), check.IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(pkiDir, "snap-merged"), 0755), check.IsNil)
+	c.Assert(os.Symlink("../snap-published/snapname/gen2", filepath.Join(pkiDir, "snap-merged", "snapname")), check.IsNil)
+	run()
+	c.Check(execEnv, testutil.Contains, "SSL_CERT_FILE="+filepath.Join(pkiDir, "snap-merged", "snapname", "ca-certificates.crt"))
+	c.Check(execEnv, testutil.Contains, "SSL_CERT_DIR="+filepath.Join(pkiDir, "snap-merged", "snapname"))
+
+	// values set by the user are kept
+	os.Setenv("SSL_CERT_FILE", "/my/bundle.crt")
+	defer os.Unsetenv("SSL_CERT_FILE")
+	run()
+	c.Check(execEnv, testutil.Contains, "SSL_CERT_FILE=/my/bundle.crt")
+	c.Check(execEnv, testutil.Contains, "SSL_CERT_DIR="+filepath.Join(pkiDir, "snap-merged", "snapname"))
+}
+
 func checkHintFileNotLocked(c *check.C, snapName string) {
 	flock, err := openHintFileLock(snapName)
 	c.Assert(err, check.IsNil)
@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/jessevdk/go-flags"
 
@@ -34,11 +35,13 @@ import (
 
 type svcStatus struct {
 	clientMixin
+	timeMixin
 	Positional struct {
 		ServiceNames []serviceName
 	} `positional-args:"yes"`
-	Global bool `long:"global" short:"g"`
-	User   bool `long:"user" short:"u"`
+	Global  bool `long:"global" short:"g"`
+	User    bool `long:"user" short:"u"`
+	Verbose bool `long:"verbose"`
 }
 
 type svcLogs struct {
@@ -64,6 +67,10 @@ status of the user services for the root user itself, --user can be provided.
 If executed as a non-root user, the 'Startup'|'Current' status of user services 
 will be the current status for the invoking user. To view the global enablement
 status of user services, --global can be provided.
+
+With --verbose, the runtime status of system services during the current boot
+is also shown: how many times they were restarted, the exit status of their
+last main process, since when they are active, their main PID and memory use.
 `)
 	shortLogsHelp = i18n.G("Retrieve logs for services")
 	longLogsHelp  = i18n.G(`
@@ -94,12 +101,14 @@ func init() {
 		// TRANSLATORS: This should not start with a lowercase letter.
 		desc: i18n.G("A service specification, which can be just a snap name (for all services in the snap), or <snap>.<app> for a single service."),
 	}}
-	addCommand("services", shortServicesHelp, longServicesHelp, func() flags.Commander { return &svcStatus{} }, map[string]string{
+	addCommand("services", shortServicesHelp, longServicesHelp, func() flags.Commander { return &svcStatus{} }, timeDescs.also(map[string]string{
 		// TRANSLATORS: This should not start with a lowercase letter.
 		"global": i18n.G("Show the global enable status for user services instead of the status for the current user."),
 		// TRANSLATORS: This should not start with a lowercase letter.
 		"user": i18n.G("Show the current status of the user services instead of the global enable status."),
-	}, argdescs)
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"verbose": i18n.G("Show the runtime status of system services"),
+	}), argdescs)
 	addCommand("logs", shortLogsHelp, longLogsHelp, func() flags.Commander { return &svcLogs{} },
 		timeDescs.also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
@@ -168,6 +177,7 @@ func (s *svcStatus) Execute(args []string) error {
 	services, err := s.client.Apps(svcNames(s.Positional.ServiceNames), client.AppOptions{
 		Service: true,
 		Global:  isGlobal,
+		Runtime: s.Verbose,
 	})
 	if err != nil {
 		return err
@@ -181,15 +191,46 @@ func (s *svcStatus) Execute(args []string) error {
 	w := tabWriter()
 	defer w.Flush()
 
-	fmt.Fprintln(w, i18n.G("Service\tStartup\tCurrent\tNotes"))
+	if s.Verbose {
+		fmt.Fprintln(w, i18n.G("Service\tStartup\tCurrent\tRestarts\tExit\tSince\tPID\tMemory\tNotes"))
+	} else {
+		fmt.Fprintln(w, i18n.G("Service\tStartup\tCurrent\tNotes"))
+	}
 	for _, svc := range services {
-		fmt.Fprintln(w, clientutil.FmtServiceStatus(svc, clientutil.FmtServiceStatusOptions{
+		status := clientutil.FmtServiceStatus(svc, clientutil.FmtServiceStatusOptions{
 			IsUserGlobal: isGlobal,
-		}))
+		})
+		if s.Verbose {
+			// insert the runtime status before the notes
+			i := strings.LastIndexByte(status, '\t')
+			status = status[:i] + "\t" + s.fmtRuntime(svc.Runtime) + status[i:]
+		}
+		fmt.Fprintln(w, status)
 	}
 	return nil
 }
 
+// fmtRuntime formats the runtime status of a service as the restarts, exit,
+// since, PID and memory columns.
+func (s *svcStatus) fmtRuntime(rt *client.AppRuntime) string {
+	if rt == nil {
+		return "-\t-\t-\t-\t-"
+	}
+	since := "-"
+	if rt.ActiveSince != nil {
+		since = s.fmtTime(*rt.ActiveSince)
+	}
+	pid := "-"
+	if rt.MainPID != 0 {
+		pid = strconv.Itoa(rt.MainPID)
+	}
+	memory := "-"
+	if rt.Memory != 0 {
+		memory = strings.TrimSpace(fmtSize(int64(rt.Memory)))
+	}
+	return fmt.Sprintf("%d\t%d\t%s\t%s\t%s", rt.Restarts, rt.ExitStatus, since, pid, memory)
+}
+
 func (s *svcLogs) Execute(args []string) error {
 	if len(args) > 0 {
 		return ErrExtraArgs
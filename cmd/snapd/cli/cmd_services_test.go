@@ -367,6 +367,81 @@ foo.zed  enabled  active    -
 	}
 }
 
+func (s *appOpSuite) TestAppStatusVerbose(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.URL.Path, check.Equals, "/v2/apps")
+			c.Check(r.URL.Query(), check.HasLen, 3)
+			c.Check(r.URL.Query().Get("select"), check.Equals, "service")
+			c.Check(r.URL.Query().Get("runtime"), check.Equals, "true")
+			c.Check(r.Method, check.Equals, "GET")
+			w.WriteHeader(200)
+			enc := json.NewEncoder(w)
+			enc.Encode(map[string]any{
+				"type": "sync",
+				"result": []map[string]any{
+					{
+						"snap":         "foo",
+						"name":         "bar",
+						"daemon":       "simple",
+						"daemon-scope": "system",
+						"active":       true,
+						"enabled":      true,
+						"runtime": map[string]any{
+							"restarts":     2,
+							"exit-status":  0,
+							"active-since": "2021-04-16T15:32:21Z",
+							"main-pid":     1234,
+							"memory":       1048576,
+						},
+					}, {
+						"snap":         "foo",
+						"name":         "baz",
+						"daemon":       "simple",
+						"daemon-scope": "system",
+						"active":       false,
+						"enabled":      true,
+						"runtime": map[string]any{
+							"restarts":    5,
+							"exit-status": 1,
+						},
+					}, {
+						"snap":         "foo",
+						"name":         "qux",
+						"daemon":       "simple",
+						"daemon-scope": "user",
+						"active":       false,
+						"enabled":      true,
+					},
+				},
+				"status":      "OK",
+				"status-code": 200,
+			})
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+		n++
+	})
+
+	r := snap.MockUserCurrent(func() (*user.User, error) {
+		return &user.User{Uid: "0"}, nil
+	})
+	defer r()
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"services", "--verbose", "--abs-time"})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, `Service  Startup  Current   Restarts  Exit  Since                 PID   Memory  Notes
+foo.bar  enabled  active    2         0     2021-04-16T15:32:21Z  1234  1.05MB  -
+foo.baz  enabled  inactive  5         1     -                     -     -       -
+foo.qux  enabled  -         -         -     -                     -     -       user
+`)
+	c.Check(n, check.Equals, 1)
+}
+
 func (s *appOpSuite) TestAppStatusGlobal(c *check.C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
@@ -495,6 +495,10 @@ func MockSeedWriterReadManifest(f func(manifestFile string) (*seedwriter.Manifes
 	return restore
 }
 
+func MockImageVerifySeed(f func(opts *image.VerifyOptions) ([]*image.SeedDrift, error)) (restore func()) {
+	return testutil.Mock(&imageVerifySeed, f)
+}
+
 func MockGetSystemKeyRetryCount(f func() int) (restore func()) {
 	return testutil.Mock(&getSystemKeyRetryCount, f)
 }
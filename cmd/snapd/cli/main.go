@@ -452,6 +452,7 @@ func exitCodeFromError(err error) int {
 	var cmdlineFlagsError *flags.Error
 	var unknownCmdError unknownCommandError
 	var userSessionPreconditionErr *userSessionPreconditionError
+	var seedDriftErr *seedDriftError
 
 	switch {
 	case err == nil:
@@ -460,6 +461,8 @@ func exitCodeFromError(err error) int {
 		return userSessionPreconditionErr.code
 	case client.IsRetryable(err):
 		return 10
+	case errors.As(err, &seedDriftErr):
+		return seedDriftExitCode
 	case errors.As(err, &mksquashfsError):
 		return 20
 	case errors.As(err, &cmdlineFlagsError) || errors.As(err, &unknownCmdError):
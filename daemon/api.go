@@ -51,6 +51,7 @@ var api = []*Command{
 	snapDownloadCmd,
 	snapConfCmd,
 	interfacesCmd,
+	interfacesCheckCmd,
 	assertsCmd,
 	assertsFindManyCmd,
 	stateChangeCmd,
@@ -93,8 +94,11 @@ var api = []*Command{
 	requestsPromptCmd,
 	requestsRulesCmd,
 	requestsRuleCmd,
+	requestsRuleSetsCmd,
+	requestsHistoryCmd,
 	systemSecurebootCmd,
 	systemVolumesCmd,
+	certificatesCmd,
 }
 
 type featureEndpoint struct {
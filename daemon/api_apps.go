@@ -26,9 +26,11 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/snapcore/snapd/client/clientutil"
 	"github.com/snapcore/snapd/osutil/user"
@@ -39,6 +41,7 @@ import (
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/systemd"
 )
 
 var (
@@ -60,12 +63,15 @@ var (
 
 var serviceControlChangeKind = swfeats.RegisterChangeKind("service-control")
 
-var newStatusDecorator = func(ctx context.Context, isGlobal bool, uid string) clientutil.StatusDecorator {
+var newStatusDecorator = func(ctx context.Context, isGlobal bool, uid string, runtime bool) clientutil.StatusDecorator {
+	var sd *servicestate.StatusDecorator
 	if isGlobal {
-		return servicestate.NewStatusDecorator(progress.Null)
+		sd = servicestate.NewStatusDecorator(progress.Null)
 	} else {
-		return servicestate.NewStatusDecoratorForUid(progress.Null, ctx, uid)
+		sd = servicestate.NewStatusDecoratorForUid(progress.Null, ctx, uid)
 	}
+	sd.SetRuntime(runtime)
+	return sd
 }
 
 func readMaybeBoolValue(query url.Values, name string) (bool, error) {
@@ -95,6 +101,10 @@ func getAppsInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 	if err != nil {
 		return BadRequest(err.Error())
 	}
+	runtime, err := readMaybeBoolValue(query, "runtime")
+	if err != nil {
+		return BadRequest(err.Error())
+	}
 
 	appInfos, rspe := appInfosFor(c.d.overlord.State(), strutil.CommaSeparatedList(query.Get("names")), opts)
 	if rspe != nil {
@@ -112,7 +122,7 @@ func getAppsInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 		global = true
 	}
 
-	sd := newStatusDecorator(r.Context(), global, u.Uid)
+	sd := newStatusDecorator(r.Context(), global, u.Uid, runtime)
 	clientAppInfos, err := clientutil.ClientAppInfosFromSnapAppInfos(appInfos, sd)
 	if err != nil {
 		return InternalError("%v", err)
@@ -246,6 +256,20 @@ func getLogs(c *Command, r *http.Request, user *auth.UserState) Response {
 		follow = f
 	}
 
+	filter, err := logFilterFromQuery(query)
+	if err != nil {
+		return BadRequest("%v", err)
+	}
+	jsonLines := false
+	switch format := query.Get("format"); format {
+	case "", "json-seq":
+		// default
+	case "jsonl":
+		jsonLines = true
+	default:
+		return BadRequest(`invalid value for format: %q`, format)
+	}
+
 	// only services have logs for now
 	opts := appInfoOptions{service: true}
 	appInfos, rspe := appInfosFor(c.d.overlord.State(), strutil.CommaSeparatedList(query.Get("names")), opts)
@@ -256,7 +280,7 @@ func getLogs(c *Command, r *http.Request, user *auth.UserState) Response {
 		return AppNotFound("no matching services")
 	}
 
-	reader, err := servicestate.LogReader(appInfos, n, follow)
+	reader, err := servicestate.LogReader(appInfos, n, follow, filter)
 	if err != nil {
 		return InternalError("cannot get logs: %v", err)
 	}
@@ -264,7 +288,61 @@ func getLogs(c *Command, r *http.Request, user *auth.UserState) Response {
 	return &journalLineReaderSeqResponse{
 		ReadCloser: reader,
 		follow:     follow,
+		jsonLines:  jsonLines,
+	}
+}
+
+var (
+	logPriorityRegexp = regexp.MustCompile(`^(?:[0-7]|emerg|alert|crit|err|warning|notice|info|debug)$`)
+	logBootRegexp     = regexp.MustCompile(`^(?:[0-9a-f]{32})?(?:[+-]?[0-9]+)?$`)
+)
+
+// logFilterFromQuery returns the filter for the logs described by the since,
+// until, priority, boot and grep query parameters, or nil if there is none.
+func logFilterFromQuery(query url.Values) (*systemd.LogFilter, error) {
+	var filter systemd.LogFilter
+	for _, t := range []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	} {
+		s := query.Get(t.name)
+		if s == "" {
+			continue
+		}
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %q: %v", t.name, s, err)
+		}
+		*t.dst = v
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+		return nil, fmt.Errorf("invalid time range: until is before since")
+	}
+
+	if prio := query.Get("priority"); prio != "" {
+		// a single level or a range of levels
+		for _, level := range strings.SplitN(prio, "..", 2) {
+			if !logPriorityRegexp.MatchString(level) {
+				return nil, fmt.Errorf("invalid value for priority: %q", prio)
+			}
+		}
+		filter.Priority = prio
+	}
+	if boot := query.Get("boot"); boot != "" {
+		if !logBootRegexp.MatchString(boot) {
+			return nil, fmt.Errorf("invalid value for boot: %q", boot)
+		}
+		filter.Boot = boot
+	}
+	filter.Grep = query.Get("grep")
+
+	if filter == (systemd.LogFilter{}) {
+		return nil, nil
 	}
+	return &filter, nil
 }
 
 var servicestateControl = servicestate.Control
@@ -29,15 +29,18 @@ import (
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/client/clientutil"
 	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/servicestate"
@@ -64,6 +67,7 @@ type appsSuite struct {
 	jctlNs             []int
 	jctlFollows        []bool
 	jctlNamespaces     []bool
+	jctlFilters        []*systemd.LogFilter
 	jctlRCs            []io.ReadCloser
 	jctlErrs           []error
 	decoratorResults   map[string]appsSuiteDecoratorResult
@@ -74,11 +78,12 @@ type appsSuite struct {
 	infoA, infoB, infoC, infoD, infoE *snap.Info
 }
 
-func (s *appsSuite) journalctl(svcs []string, n int, follow, namespaces bool) (rc io.ReadCloser, err error) {
+func (s *appsSuite) journalctl(svcs []string, n int, follow, namespaces bool, filter *systemd.LogFilter) (rc io.ReadCloser, err error) {
 	s.jctlSvcses = append(s.jctlSvcses, svcs)
 	s.jctlNs = append(s.jctlNs, n)
 	s.jctlFollows = append(s.jctlFollows, follow)
 	s.jctlNamespaces = append(s.jctlNamespaces, namespaces)
+	s.jctlFilters = append(s.jctlFilters, filter)
 
 	if len(s.jctlErrs) > 0 {
 		err, s.jctlErrs = s.jctlErrs[0], s.jctlErrs[1:]
@@ -153,6 +158,7 @@ func (s *appsSuite) SetUpTest(c *check.C) {
 	s.jctlNs = nil
 	s.jctlFollows = nil
 	s.jctlNamespaces = nil
+	s.jctlFilters = nil
 	s.jctlRCs = nil
 	s.jctlErrs = nil
 
@@ -295,7 +301,8 @@ func (s *appsSuite) TestGetAppsInfoNames(c *check.C) {
 }
 
 func (s *appsSuite) TestGetAppsInfoServices(c *check.C) {
-	r := daemon.MockNewStatusDecorator(func(ctx context.Context, isGlobal bool, uid string) clientutil.StatusDecorator {
+	r := daemon.MockNewStatusDecorator(func(ctx context.Context, isGlobal bool, uid string, runtime bool) clientutil.StatusDecorator {
+		c.Check(runtime, check.Equals, false)
 		c.Check(isGlobal, check.Equals, true)
 		c.Check(uid, check.Equals, "0")
 		return s
@@ -435,7 +442,8 @@ func (s *appsSuite) DecorateWithStatus(appInfo *client.AppInfo, snapApp *snap.Ap
 }
 
 func (s *appsSuite) TestGetUserAppsInfoServices(c *check.C) {
-	r := daemon.MockNewStatusDecorator(func(ctx context.Context, isGlobal bool, uid string) clientutil.StatusDecorator {
+	r := daemon.MockNewStatusDecorator(func(ctx context.Context, isGlobal bool, uid string, runtime bool) clientutil.StatusDecorator {
+		c.Check(runtime, check.Equals, false)
 		c.Check(isGlobal, check.Equals, false)
 		c.Check(uid, check.Equals, "1337")
 		return s
@@ -500,6 +508,55 @@ func (s *appsSuite) TestGetUserAppsInfoServices(c *check.C) {
 	c.Check(sort.StringsAreSorted(appNames), check.Equals, true)
 }
 
+func (s *appsSuite) TestGetAppsInfoServicesWithRuntime(c *check.C) {
+	s.SysctlBufs = [][]byte{
+		[]byte(`
+Id=snap.snap-a.svc1.service
+Names=snap.snap-a.svc1.service
+Type=simple
+ActiveState=active
+UnitFileState=enabled
+NeedDaemonReload=no
+`[1:]),
+		[]byte(`
+Id=snap.snap-a.svc1.service
+NRestarts=2
+ExecMainStatus=0
+ActiveEnterTimestamp=Fri 2021-04-16 15:32:21 UTC
+MainPID=1234
+MemoryCurrent=1048576
+`[1:]),
+	}
+
+	req, err := http.NewRequest("GET", "/v2/apps?names=snap-a.svc1&runtime=true", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, check.Equals, 200)
+	c.Assert(rsp.Result, check.FitsTypeOf, []client.AppInfo{})
+	svcs := rsp.Result.([]client.AppInfo)
+	c.Assert(svcs, check.HasLen, 1)
+
+	activeSince := time.Date(2021, time.April, 16, 15, 32, 21, 0, time.UTC)
+	c.Assert(svcs[0].Runtime, check.NotNil)
+	c.Check(svcs[0].Runtime.ActiveSince.Equal(activeSince), check.Equals, true)
+	svcs[0].Runtime.ActiveSince = nil
+	c.Check(svcs[0].Runtime, check.DeepEquals, &client.AppRuntime{
+		Restarts: 2,
+		MainPID:  1234,
+		Memory:   quantity.SizeMiB,
+	})
+}
+
+func (s *appsSuite) TestGetAppsInfoBadRuntime(c *check.C) {
+	req, err := http.NewRequest("GET", "/v2/apps?runtime=potato", nil)
+	c.Assert(err, check.IsNil)
+
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Assert(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `invalid runtime parameter: "potato"`)
+}
+
 func (s *appsSuite) TestGetAppsInfoBadSelect(c *check.C) {
 	req, err := http.NewRequest("GET", "/v2/apps?select=potato", nil)
 	c.Assert(err, check.IsNil)
@@ -1001,6 +1058,83 @@ func (s *appsSuite) TestLogs(c *check.C) {
 `[1:])
 }
 
+func (s *appsSuite) TestLogsFilter(c *check.C) {
+	s.expectLogsAccess()
+
+	s.jctlRCs = []io.ReadCloser{io.NopCloser(strings.NewReader(""))}
+
+	q := url.Values{
+		"names":    {"snap-a.svc2"},
+		"since":    {"2021-04-16T15:32:21Z"},
+		"until":    {"2021-04-16T16:32:21+01:00"},
+		"priority": {"debug..warning"},
+		"boot":     {"-1"},
+		"grep":     {"connection (refused|reset)"},
+	}
+	req, err := http.NewRequest("GET", "/v2/logs?"+q.Encode(), nil)
+	c.Assert(err, check.IsNil)
+
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil, actionIsUnexpected).ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 200)
+
+	c.Assert(s.jctlFilters, check.HasLen, 1)
+	filter := s.jctlFilters[0]
+	c.Assert(filter, check.NotNil)
+	c.Check(filter.Since.Equal(time.Date(2021, time.April, 16, 15, 32, 21, 0, time.UTC)), check.Equals, true)
+	c.Check(filter.Until.Equal(time.Date(2021, time.April, 16, 15, 32, 21, 0, time.UTC)), check.Equals, true)
+	c.Check(filter.Priority, check.Equals, "debug..warning")
+	c.Check(filter.Boot, check.Equals, "-1")
+	c.Check(filter.Grep, check.Equals, "connection (refused|reset)")
+}
+
+func (s *appsSuite) TestLogsBadFilter(c *check.C) {
+	s.expectLogsAccess()
+
+	for _, t := range []struct {
+		query string
+		err   string
+	}{
+		{"since=yesterday", `invalid value for since: "yesterday": .*`},
+		{"until=2021-04-16", `invalid value for until: "2021-04-16": .*`},
+		{"since=2021-04-16T15:32:21Z&until=2021-04-16T14:32:21Z", `invalid time range: until is before since`},
+		{"priority=8", `invalid value for priority: "8"`},
+		{"priority=err..potato", `invalid value for priority: "err..potato"`},
+		{"boot=last", `invalid value for boot: "last"`},
+		{"format=xml", `invalid value for format: "xml"`},
+	} {
+		req, err := http.NewRequest("GET", "/v2/logs?"+t.query, nil)
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(t.query))
+		c.Check(rspe.Message, check.Matches, t.err, check.Commentf(t.query))
+	}
+	c.Check(s.jctlFilters, check.HasLen, 0)
+}
+
+func (s *appsSuite) TestLogsJSONLines(c *check.C) {
+	s.expectLogsAccess()
+
+	s.jctlRCs = []io.ReadCloser{io.NopCloser(strings.NewReader(`
+{"MESSAGE": "hello1 <world>", "SYSLOG_IDENTIFIER": "xyzzy", "_PID": "42", "PRIORITY": "6", "__REALTIME_TIMESTAMP": "42"}
+{"MESSAGE": [104, 105], "SYSLOG_IDENTIFIER": "xyzzy", "_PID": "42", "PRIORITY": "3", "__REALTIME_TIMESTAMP": "44"}
+	`))}
+
+	req, err := http.NewRequest("GET", "/v2/logs?names=snap-a.svc2&format=jsonl", nil)
+	c.Assert(err, check.IsNil)
+
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil, actionIsUnexpected).ServeHTTP(rec, req)
+
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(rec.Header().Get("Content-Type"), check.Equals, "application/jsonl")
+	c.Check(rec.Body.String(), check.Equals, `
+{"MESSAGE":"hello1 <world>","SYSLOG_IDENTIFIER":"xyzzy","_PID":"42","PRIORITY":"6","__REALTIME_TIMESTAMP":"42"}
+{"MESSAGE":[104,105],"SYSLOG_IDENTIFIER":"xyzzy","_PID":"42","PRIORITY":"3","__REALTIME_TIMESTAMP":"44"}
+`[1:])
+}
+
 func (s *appsSuite) TestLogsNoNamespaceOption(c *check.C) {
 	restore := systemd.MockSystemdVersion(237, nil)
 	defer restore()
@@ -0,0 +1,192 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/certstate"
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+)
+
+var (
+	certificatesCmd = &Command{
+		Path:        "/v2/certificates",
+		GET:         getCertificates,
+		POST:        postCertificates,
+		Actions:     []string{"add", "remove", "block", "unblock"},
+		ReadAccess:  authenticatedAccess{Polkit: polkitActionManageConfiguration},
+		WriteAccess: authenticatedAccess{Polkit: polkitActionManageConfiguration},
+	}
+)
+
+var manageCertificatesChangeKind = swfeats.RegisterChangeKind("manage-certificates")
+
+var (
+	certstateListCertificates             = certstate.ListCertificates
+	certstateCurrentCertificateGeneration = certstate.CurrentCertificateGeneration
+	certstateSetSystemCertificateState    = certstate.SetSystemCertificateState
+)
+
+type certificatesResponse struct {
+	// Generation is the published generation of the merged certificate
+	// database that is currently in use.
+	Generation   string                          `json:"generation,omitempty"`
+	Certificates []*certstate.CertificateDetails `json:"certificates"`
+}
+
+func getCertificates(c *Command, r *http.Request, user *auth.UserState) Response {
+	origin := r.URL.Query().Get("origin")
+	switch origin {
+	case "", certstate.CertificateOriginSystem, certstate.CertificateOriginCustom:
+	default:
+		return BadRequest("invalid origin %q", origin)
+	}
+
+	certs, err := certstateListCertificates()
+	if err != nil {
+		return InternalError("cannot list certificates: %v", err)
+	}
+	generation, err := certstateCurrentCertificateGeneration()
+	if err != nil {
+		return InternalError("cannot resolve current certificate generation: %v", err)
+	}
+
+	res := certificatesResponse{
+		Generation:   generation,
+		Certificates: []*certstate.CertificateDetails{},
+	}
+	for _, cert := range certs {
+		if origin != "" && cert.Origin != origin {
+			continue
+		}
+		res.Certificates = append(res.Certificates, cert)
+	}
+	return SyncResponse(res)
+}
+
+type postCertificatesData struct {
+	Action string `json:"action"`
+	// Name identifies a custom certificate.
+	Name string `json:"name,omitempty"`
+	// Fingerprint identifies a system certificate, for block and unblock.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Content is the PEM or DER encoded certificate to add.
+	Content string `json:"content,omitempty"`
+	// AllowExpired allows adding or unblocking a custom certificate that
+	// has already expired.
+	AllowExpired bool `json:"allow-expired,omitempty"`
+}
+
+func customCertificateKey(name, field string) string {
+	key := "pki.certs.custom." + name
+	if field != "" {
+		key += "." + field
+	}
+	return key
+}
+
+func postCertificates(c *Command, r *http.Request, user *auth.UserState) Response {
+	var data postCertificatesData
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&data); err != nil {
+		return BadRequest("cannot decode request body: %v", err)
+	}
+
+	if data.Name != "" && data.Fingerprint != "" {
+		return BadRequest("cannot specify both name and fingerprint")
+	}
+	if data.Name != "" {
+		if err := configcore.ValidateCustomCertificateName(data.Name); err != nil {
+			return BadRequest(err.Error())
+		}
+	}
+
+	if data.AllowExpired && data.Action != "add" && data.Action != "unblock" {
+		return BadRequest("allow-expired can only be used to add or unblock certificates")
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var ts *state.TaskSet
+	var summary string
+	var err error
+	switch data.Action {
+	case "add":
+		if data.Name == "" || data.Content == "" {
+			return BadRequest("cannot add certificate without name and content")
+		}
+		if _, err := certstate.ParseCertificateData([]byte(data.Content)); err != nil {
+			return BadRequest("invalid certificate content: %v", err)
+		}
+		ts, err = configstateConfigureInstalled(st, "core", map[string]any{
+			customCertificateKey(data.Name, "content"): data.Content,
+			customCertificateKey(data.Name, "state"):   certstate.CertificateStateAccepted,
+		}, 0)
+		summary = fmt.Sprintf("Add certificate %q", data.Name)
+	case "remove":
+		if data.Name == "" {
+			return BadRequest("cannot remove certificate without a name")
+		}
+		ts, err = configstateConfigureInstalled(st, "core", map[string]any{
+			customCertificateKey(data.Name, ""): nil,
+		}, 0)
+		summary = fmt.Sprintf("Remove certificate %q", data.Name)
+	case "block", "unblock":
+		certState := certstate.CertificateStateBlocked
+		if data.Action == "unblock" {
+			certState = certstate.CertificateStateAccepted
+		}
+		switch {
+		case data.Name != "":
+			ts, err = configstateConfigureInstalled(st, "core", map[string]any{
+				customCertificateKey(data.Name, "state"): certState,
+			}, 0)
+			summary = fmt.Sprintf("Change state of certificate %q to %s", data.Name, certState)
+		case data.Fingerprint != "":
+			ts, err = certstateSetSystemCertificateState(st, data.Fingerprint, certState)
+			if err != nil {
+				return BadRequest("cannot %s certificate: %v", data.Action, err)
+			}
+			summary = fmt.Sprintf("Change state of system certificate %s to %s", data.Fingerprint, certState)
+		default:
+			return BadRequest("cannot %s certificate without a name or fingerprint", data.Action)
+		}
+	default:
+		return BadRequest("unknown certificates action %q", data.Action)
+	}
+	if err != nil {
+		return InternalError("%v", err)
+	}
+	if data.AllowExpired {
+		certstate.AllowExpiredCertificates(ts)
+	}
+
+	chg := newChange(st, manageCertificatesChangeKind, summary, []*state.TaskSet{ts}, nil)
+	ensureStateSoon(st)
+	return AsyncResponse(nil, chg.ID())
+}
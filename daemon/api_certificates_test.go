@@ -0,0 +1,284 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/certstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var _ = check.Suite(&certificatesSuite{})
+
+type certificatesSuite struct {
+	apiBaseSuite
+
+	configured []map[string]any
+}
+
+func (s *certificatesSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+	s.daemon(c)
+
+	s.expectReadAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage-configuration"})
+	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage-configuration"})
+
+	s.configured = nil
+	s.AddCleanup(daemon.MockConfigstateConfigureInstalled(func(st *state.State, name string, patch map[string]any, flags int) (*state.TaskSet, error) {
+		c.Check(name, check.Equals, "core")
+		s.configured = append(s.configured, patch)
+		return state.NewTaskSet(st.NewTask("fake-configure", "...")), nil
+	}))
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {})
+	s.AddCleanup(restore)
+}
+
+func makeCertificatePEM(c *check.C, commonName string) string {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, check.IsNil)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	c.Assert(err, check.IsNil)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+var testCertificates = []*certstate.CertificateDetails{
+	{
+		Name:        "system-ca",
+		Fingerprint: "aaaa",
+		Origin:      certstate.CertificateOriginSystem,
+		State:       certstate.CertificateStateAccepted,
+		Subject:     "CN=system",
+		Issuer:      "CN=system",
+	},
+	{
+		Name:        "my-ca",
+		Fingerprint: "bbbb",
+		Origin:      certstate.CertificateOriginCustom,
+		State:       certstate.CertificateStateBlocked,
+		Subject:     "CN=custom",
+		Issuer:      "CN=custom",
+	},
+}
+
+func (s *certificatesSuite) mockCertificates() {
+	s.AddCleanup(daemon.MockCertstateListCertificates(func() ([]*certstate.CertificateDetails, error) {
+		return testCertificates, nil
+	}))
+	s.AddCleanup(daemon.MockCertstateCurrentCertificateGeneration(func() (string, error) {
+		return "1234abcd", nil
+	}))
+}
+
+func (s *certificatesSuite) TestGetCertificates(c *check.C) {
+	s.mockCertificates()
+
+	req, err := http.NewRequest("GET", "/v2/certificates", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsUnexpected)
+	c.Check(rsp.Result, check.DeepEquals, daemon.CertificatesResponse{
+		Generation:   "1234abcd",
+		Certificates: testCertificates,
+	})
+}
+
+func (s *certificatesSuite) TestGetCertificatesFilterOrigin(c *check.C) {
+	s.mockCertificates()
+
+	req, err := http.NewRequest("GET", "/v2/certificates?origin=custom", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsUnexpected)
+	c.Check(rsp.Result, check.DeepEquals, daemon.CertificatesResponse{
+		Generation:   "1234abcd",
+		Certificates: testCertificates[1:],
+	})
+
+	req, err = http.NewRequest("GET", "/v2/certificates?origin=other", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsUnexpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `invalid origin "other"`)
+}
+
+func (s *certificatesSuite) TestGetCertificatesError(c *check.C) {
+	s.AddCleanup(daemon.MockCertstateListCertificates(func() ([]*certstate.CertificateDetails, error) {
+		return nil, errors.New("boom")
+	}))
+
+	req, err := http.NewRequest("GET", "/v2/certificates", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsUnexpected)
+	c.Check(rspe.Status, check.Equals, 500)
+	c.Check(rspe.Message, check.Equals, "cannot list certificates: boom")
+}
+
+func (s *certificatesSuite) postCertificates(c *check.C, body string) *state.Change {
+	req, err := http.NewRequest("POST", "/v2/certificates", bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "manage-certificates")
+	return chg
+}
+
+func (s *certificatesSuite) TestPostAddCertificate(c *check.C) {
+	content := makeCertificatePEM(c, "custom")
+	body, err := json.Marshal(map[string]string{
+		"action":  "add",
+		"name":    "my-ca",
+		"content": content,
+	})
+	c.Assert(err, check.IsNil)
+
+	chg := s.postCertificates(c, string(body))
+	c.Check(chg.Summary(), check.Equals, `Add certificate "my-ca"`)
+	c.Check(s.configured, check.DeepEquals, []map[string]any{{
+		"pki.certs.custom.my-ca.content": content,
+		"pki.certs.custom.my-ca.state":   "accepted",
+	}})
+}
+
+func (s *certificatesSuite) TestPostAddCertificateAllowExpired(c *check.C) {
+	content := makeCertificatePEM(c, "custom")
+	body, err := json.Marshal(map[string]any{
+		"action":        "add",
+		"name":          "my-ca",
+		"content":       content,
+		"allow-expired": true,
+	})
+	c.Assert(err, check.IsNil)
+
+	allowed := s.postCertificates(c, string(body))
+	// not allowed by default
+	notAllowed := s.postCertificates(c, `{"action": "unblock", "name": "my-ca"}`)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	for _, tc := range []struct {
+		chg          *state.Change
+		allowExpired bool
+	}{
+		{allowed, true},
+		{notAllowed, false},
+	} {
+		c.Assert(tc.chg.Tasks(), check.HasLen, 1)
+		opts, err := certstate.RefreshOptionsForTask(tc.chg.Tasks()[0])
+		c.Assert(err, check.IsNil)
+		c.Check(opts.AllowExpired, check.Equals, tc.allowExpired)
+	}
+}
+
+func (s *certificatesSuite) TestPostRemoveCertificate(c *check.C) {
+	chg := s.postCertificates(c, `{"action": "remove", "name": "my-ca"}`)
+	c.Check(chg.Summary(), check.Equals, `Remove certificate "my-ca"`)
+	c.Check(s.configured, check.DeepEquals, []map[string]any{{
+		"pki.certs.custom.my-ca": nil,
+	}})
+}
+
+func (s *certificatesSuite) TestPostBlockUnblockCustomCertificate(c *check.C) {
+	chg := s.postCertificates(c, `{"action": "block", "name": "my-ca"}`)
+	c.Check(chg.Summary(), check.Equals, `Change state of certificate "my-ca" to blocked`)
+	chg = s.postCertificates(c, `{"action": "unblock", "name": "my-ca"}`)
+	c.Check(chg.Summary(), check.Equals, `Change state of certificate "my-ca" to accepted`)
+
+	c.Check(s.configured, check.DeepEquals, []map[string]any{
+		{"pki.certs.custom.my-ca.state": "blocked"},
+		{"pki.certs.custom.my-ca.state": "accepted"},
+	})
+}
+
+func (s *certificatesSuite) TestPostBlockUnblockSystemCertificate(c *check.C) {
+	var calls []string
+	s.AddCleanup(daemon.MockCertstateSetSystemCertificateState(func(st *state.State, fingerprint, certState string) (*state.TaskSet, error) {
+		calls = append(calls, fingerprint+":"+certState)
+		return state.NewTaskSet(st.NewTask("set-system-cert-state", "...")), nil
+	}))
+
+	chg := s.postCertificates(c, `{"action": "block", "fingerprint": "aaaa"}`)
+	c.Check(chg.Summary(), check.Equals, `Change state of system certificate aaaa to blocked`)
+	chg = s.postCertificates(c, `{"action": "unblock", "fingerprint": "aaaa"}`)
+	c.Check(chg.Summary(), check.Equals, `Change state of system certificate aaaa to accepted`)
+
+	c.Check(calls, check.DeepEquals, []string{"aaaa:blocked", "aaaa:accepted"})
+	c.Check(s.configured, check.HasLen, 0)
+}
+
+func (s *certificatesSuite) TestPostBlockSystemCertificateError(c *check.C) {
+	s.AddCleanup(daemon.MockCertstateSetSystemCertificateState(func(st *state.State, fingerprint, certState string) (*state.TaskSet, error) {
+		return nil, errors.New("boom")
+	}))
+
+	req, err := http.NewRequest("POST", "/v2/certificates", bytes.NewBufferString(`{"action": "block", "fingerprint": "aaaa"}`))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "cannot block certificate: boom")
+}
+
+func (s *certificatesSuite) TestPostCertificatesErrors(c *check.C) {
+	for _, tc := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action": "add", "name": "my-ca"}`, `cannot add certificate without name and content`},
+		{`{"action": "add", "name": "my-ca", "content": "garbage"}`, `invalid certificate content: .*`},
+		{`{"action": "add", "name": "my.ca", "content": "garbage"}`, `invalid certificate name: "my.ca"`},
+		{`{"action": "remove"}`, `cannot remove certificate without a name`},
+		{`{"action": "block"}`, `cannot block certificate without a name or fingerprint`},
+		{`{"action": "unblock", "name": "a", "fingerprint": "b"}`, `cannot specify both name and fingerprint`},
+		{`{"action": "frobnicate"}`, `unknown certificates action "frobnicate"`},
+		{`{"action": "remove", "name": "my-ca", "allow-expired": true}`, `allow-expired can only be used to add or unblock certificates`},
+		{`{"action": `, `cannot decode request body: .*`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/certificates", bytes.NewBufferString(tc.body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsUnexpected)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(tc.body))
+		c.Check(rspe.Message, check.Matches, tc.err, check.Commentf(tc.body))
+	}
+	c.Check(s.configured, check.HasLen, 0)
+}
@@ -44,6 +44,12 @@ var (
 		ReadAccess:  openAccess{},
 		WriteAccess: authenticatedAccess{Polkit: polkitActionManageInterfaces},
 	}
+
+	interfacesCheckCmd = &Command{
+		Path:       "/v2/interfaces/check",
+		GET:        checkInterfacesPolicy,
+		ReadAccess: openAccess{},
+	}
 )
 
 var (
@@ -251,6 +257,42 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 	return AsyncResponse(nil, change.ID())
 }
 
+// parsePlugOrSlotRef parses a "<snap>:<plug or slot>" reference, the snap
+// name may be omitted to refer to the system snap.
+func parsePlugOrSlotRef(ref string) (snapName, name string, err error) {
+	snapName, name, ok := strings.Cut(ref, ":")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid reference %q, expected <snap>:<name>", ref)
+	}
+	return ifacestate.RemapSnapFromRequest(snapName), name, nil
+}
+
+// checkInterfacesPolicy reports how the connection policy applies to the
+// plug and slot of the request, without connecting them.
+func checkInterfacesPolicy(c *Command, r *http.Request, user *auth.UserState) Response {
+	query := r.URL.Query()
+	plugSnap, plug, err := parsePlugOrSlotRef(query.Get("plug"))
+	if err != nil {
+		return BadRequest("cannot check connection policy: plug: %v", err)
+	}
+	slotSnap, slot, err := parsePlugOrSlotRef(query.Get("slot"))
+	if err != nil {
+		return BadRequest("cannot check connection policy: slot: %v", err)
+	}
+
+	check, err := c.d.overlord.InterfaceManager().CheckConnectionPolicy(plugSnap, plug, slotSnap, slot)
+	if err != nil {
+		return BadRequest("cannot check connection policy: %v", err)
+	}
+	return SyncResponse(&connectionPolicyCheckJSON{
+		Plug:        check.Plug,
+		Slot:        check.Slot,
+		Interface:   check.Interface,
+		Connect:     policyCheckResultJSON(check.Connect),
+		AutoConnect: policyCheckResultJSON(check.AutoConnect),
+	})
+}
+
 func snapNamesFromConns(conns []*interfaces.ConnRef) []string {
 	m := make(map[string]bool)
 	for _, conn := range conns {
@@ -36,6 +36,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/ifacetest"
 	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
 )
 
 var _ = check.Suite(&interfacesSuite{})
@@ -1211,3 +1212,91 @@ func (s *interfacesSuite) TestInterfacesAllDefaultDocURL(c *check.C) {
 		}
 	}
 }
+
+// Tests for GET /v2/interfaces/check
+
+func (s *interfacesSuite) checkConnectionPolicy(c *check.C, query string) map[string]any {
+	req, err := http.NewRequest("GET", "/v2/interfaces/check?"+query, nil)
+	c.Assert(err, check.IsNil)
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil, actionIsUnexpected).ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 200)
+	var body map[string]any
+	err = json.Unmarshal(rec.Body.Bytes(), &body)
+	c.Assert(err, check.IsNil)
+	return body["result"].(map[string]any)
+}
+
+func (s *interfacesSuite) TestCheckConnectionPolicy(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+
+	s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	// the snaps were installed with --dangerous and there is no rule for
+	// the test interface in the base-declaration
+	result := s.checkConnectionPolicy(c, "plug=consumer:plug&slot=producer:slot")
+	c.Check(result, check.DeepEquals, map[string]any{
+		"plug":      map[string]any{"snap": "consumer", "plug": "plug"},
+		"slot":      map[string]any{"snap": "producer", "slot": "slot"},
+		"interface": "test",
+		"connect": map[string]any{
+			"allowed": true,
+			"trace":   []any{`declarations not checked: no snap-declaration for "consumer", "producer"`},
+		},
+		"auto-connect": map[string]any{
+			"allowed": true,
+		},
+	})
+}
+
+func (s *interfacesSuite) TestCheckConnectionPolicyAutoConnectNotSupported(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{
+		InterfaceName: "test",
+		AutoConnectCallback: func(*snap.PlugInfo, *snap.SlotInfo) bool {
+			return false
+		},
+	})
+	defer restore()
+
+	s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	result := s.checkConnectionPolicy(c, "plug=consumer:plug&slot=producer:slot")
+	c.Check(result["connect"].(map[string]any)["allowed"], check.Equals, true)
+	c.Check(result["auto-connect"], check.DeepEquals, map[string]any{
+		"allowed": false,
+		"error":   `auto-connection not supported by interface "test" for this plug and slot`,
+	})
+}
+
+func (s *interfacesSuite) TestCheckConnectionPolicyErrors(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+
+	s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	for _, t := range []struct {
+		query string
+		err   string
+	}{
+		{"slot=producer:slot", `cannot check connection policy: plug: invalid reference "", expected <snap>:<name>`},
+		{"plug=consumer&slot=producer:slot", `cannot check connection policy: plug: invalid reference "consumer", expected <snap>:<name>`},
+		{"plug=consumer:plug&slot=producer:", `cannot check connection policy: slot: invalid reference "producer:", expected <snap>:<name>`},
+		{"plug=consumer:foo&slot=producer:slot", `cannot check connection policy: snap "consumer" has no plug named "foo"`},
+	} {
+		req, err := http.NewRequest("GET", "/v2/interfaces/check?"+t.query, nil)
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsUnexpected)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, t.err, check.Commentf(t.query))
+	}
+}
@@ -89,3 +89,22 @@ type connectionsJSON struct {
 	Plugs       []*plugJSON      `json:"plugs"`
 	Slots       []*slotJSON      `json:"slots"`
 }
+
+// policyCheckResultJSON aids in marshaling ifacestate.PolicyCheckResult
+// into JSON.
+type policyCheckResultJSON struct {
+	Allowed bool     `json:"allowed"`
+	Error   string   `json:"error,omitempty"`
+	Rule    string   `json:"rule,omitempty"`
+	Steps   []string `json:"trace,omitempty"`
+}
+
+// connectionPolicyCheckJSON aids in marshaling ifacestate.ConnectionPolicyCheck
+// into JSON.
+type connectionPolicyCheckJSON struct {
+	Plug        interfaces.PlugRef    `json:"plug"`
+	Slot        interfaces.SlotRef    `json:"slot"`
+	Interface   string                `json:"interface"`
+	Connect     policyCheckResultJSON `json:"connect"`
+	AutoConnect policyCheckResultJSON `json:"auto-connect"`
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/prompting"
 	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
+	"github.com/snapcore/snapd/interfaces/prompting/requesthistory"
 	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
 	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
 	"github.com/snapcore/snapd/overlord/auth"
@@ -89,6 +90,28 @@ var (
 		// authentication.
 		WriteAccess: interfaceOpenAccess{Interfaces: []string{"snap-interfaces-requests-control"}},
 	}
+
+	requestsRuleSetsCmd = &Command{
+		Path:       "/v2/interfaces/requests/rule-sets",
+		GET:        getRuleSets,
+		POST:       postRuleSets,
+		Actions:    []string{"import"},
+		ReadAccess: interfaceOpenAccess{Interfaces: []string{"snap-interfaces-requests-control"}},
+		// Imported rules may be locked against modification by the user, or
+		// apply to every user, so only administrators may import them.
+		WriteAccess: rootAccess{},
+	}
+
+	requestsHistoryCmd = &Command{
+		Path:       "/v2/interfaces/requests/history",
+		GET:        getHistory,
+		POST:       postHistory,
+		Actions:    []string{"clear"},
+		ReadAccess: interfaceOpenAccess{Interfaces: []string{"snap-interfaces-requests-control"}},
+		// postHistory can only clear the history of the user making the API
+		// request, so there is no need for polkit authentication.
+		WriteAccess: interfaceOpenAccess{Interfaces: []string{"snap-interfaces-requests-control"}},
+	}
 )
 
 var (
@@ -272,6 +295,12 @@ func promptingError(err error) *apiError {
 		if errors.As(err, &parseErr) {
 			apiErr.Value = (*promptingParseError)(parseErr)
 		}
+	case errors.Is(err, prompting_errors.ErrRuleLocked):
+		apiErr.Status = 403
+		apiErr.Kind = client.ErrorKindInterfacesRequestsRuleLocked
+	case errors.Is(err, prompting_errors.ErrImportedRuleHasSession):
+		apiErr.Status = 400
+		apiErr.Kind = client.ErrorKindInterfacesRequestsInvalidFields
 	case errors.Is(err, prompting_errors.ErrPatchedRuleHasNoPerms):
 		apiErr.Status = 400
 		apiErr.Kind = client.ErrorKindInterfacesRequestsPatchedRuleHasNoPermissions
@@ -366,6 +395,19 @@ type postRuleRequestBody struct {
 	PatchRule *patchRuleContents `json:"rule,omitempty"`
 }
 
+type postRuleSetsRequestBody struct {
+	Action string `json:"action"`
+	// Scope is either "user" (the default) or "system", for rules which
+	// apply to every user.
+	Scope   string                `json:"scope,omitempty"`
+	Replace bool                  `json:"replace,omitempty"`
+	RuleSet *requestrules.RuleSet `json:"rule-set,omitempty"`
+}
+
+type postHistoryRequestBody struct {
+	Action string `json:"action"`
+}
+
 func postInterfacesRequests(c *Command, r *http.Request, user *auth.UserState) Response {
 	ucred, err := ucrednetGet(r.RemoteAddr)
 	if err != nil {
@@ -721,3 +763,164 @@ func postRule(c *Command, r *http.Request, user *auth.UserState) Response {
 		})
 	}
 }
+
+// ruleSetsUserID returns the ID of the user whose rules are targeted by a
+// rule sets request with the given scope.
+//
+// Rule sets can only be imported by admins, so the UID of the connection is
+// never the one of the user whose rules should be replaced. Thus, if
+// requireUserID is true, the user-id parameter must be given for the user
+// scope.
+func ruleSetsUserID(r *http.Request, scope string, requireUserID bool) (uint32, Response) {
+	switch scope {
+	case "", "user":
+		if requireUserID && len(r.URL.Query()["user-id"]) == 0 {
+			return 0, BadRequest(`must include "user-id" parameter when scope is "user"`)
+		}
+		return getUserID(r)
+	case "system":
+		return requestrules.SystemWideUser, nil
+	default:
+		return 0, promptingError(&prompting_errors.UnsupportedValueError{
+			Field:     "scope",
+			Msg:       `"scope" field must be "user" or "system"`,
+			Value:     []string{scope},
+			Supported: []string{"user", "system"},
+		})
+	}
+}
+
+func getRuleSets(c *Command, r *http.Request, user *auth.UserState) Response {
+	userID, errorResp := ruleSetsUserID(r, r.URL.Query().Get("scope"), false)
+	if errorResp != nil {
+		return errorResp
+	}
+
+	if !getInterfaceManager(c).AppArmorPromptingRunning() {
+		return promptingNotRunningError()
+	}
+
+	ruleSet, err := getInterfaceManager(c).InterfacesRequestsManager().ExportRules(userID)
+	if err != nil {
+		return promptingError(err)
+	}
+
+	return SyncResponse(ruleSet)
+}
+
+func postRuleSets(c *Command, r *http.Request, user *auth.UserState) Response {
+	var postBody postRuleSetsRequestBody
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&postBody); err != nil {
+		if errors.Is(err, prompting_errors.ErrUnsupportedValue) || errors.Is(err, prompting_errors.ErrParseError) {
+			return promptingError(err)
+		}
+		return BadRequest("cannot decode request body for rule sets endpoint: %v", err)
+	}
+
+	switch postBody.Action {
+	case "import":
+		// all good
+	default:
+		return promptingError(&prompting_errors.UnsupportedValueError{
+			Field:     "action",
+			Msg:       `"action" field must be "import"`,
+			Value:     []string{postBody.Action},
+			Supported: []string{"import"},
+		})
+	}
+
+	if postBody.RuleSet == nil {
+		return promptingError(prompting_errors.NewMissingFieldError("rule-set", `must include "rule-set" field in request body when action is "import"`))
+	}
+
+	userID, errorResp := ruleSetsUserID(r, postBody.Scope, true)
+	if errorResp != nil {
+		return errorResp
+	}
+
+	if !getInterfaceManager(c).AppArmorPromptingRunning() {
+		return promptingNotRunningError()
+	}
+
+	imported, err := getInterfaceManager(c).InterfacesRequestsManager().ImportRules(userID, postBody.RuleSet, postBody.Replace)
+	if err != nil {
+		return promptingError(err)
+	}
+	if len(imported) == 0 {
+		imported = []*requestrules.Rule{}
+	}
+
+	return SyncResponse(imported)
+}
+
+func getHistory(c *Command, r *http.Request, user *auth.UserState) Response {
+	userID, errorResp := getUserID(r)
+	if errorResp != nil {
+		return errorResp
+	}
+
+	query := r.URL.Query()
+	after, err := parseOptionalTime(query.Get("after"))
+	if err != nil {
+		return BadRequest(`invalid "after" timestamp: %v`, err)
+	}
+	before, err := parseOptionalTime(query.Get("before"))
+	if err != nil {
+		return BadRequest(`invalid "before" timestamp: %v`, err)
+	}
+
+	if !getInterfaceManager(c).AppArmorPromptingRunning() {
+		return promptingNotRunningError()
+	}
+
+	filter := &requesthistory.Filter{
+		Snap:   query.Get("snap"),
+		After:  after,
+		Before: before,
+	}
+	entries, err := getInterfaceManager(c).InterfacesRequestsManager().History(userID, filter)
+	if err != nil {
+		return promptingError(err)
+	}
+	if len(entries) == 0 {
+		entries = []*requesthistory.Entry{}
+	}
+
+	return SyncResponse(entries)
+}
+
+func postHistory(c *Command, r *http.Request, user *auth.UserState) Response {
+	userID, errorResp := getUserID(r)
+	if errorResp != nil {
+		return errorResp
+	}
+
+	var postBody postHistoryRequestBody
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&postBody); err != nil {
+		return BadRequest("cannot decode request body for history endpoint: %v", err)
+	}
+
+	switch postBody.Action {
+	case "clear":
+		// all good
+	default:
+		return promptingError(&prompting_errors.UnsupportedValueError{
+			Field:     "action",
+			Msg:       `"action" field must be "clear"`,
+			Value:     []string{postBody.Action},
+			Supported: []string{"clear"},
+		})
+	}
+
+	if !getInterfaceManager(c).AppArmorPromptingRunning() {
+		return promptingNotRunningError()
+	}
+
+	if err := getInterfaceManager(c).InterfacesRequestsManager().ClearHistory(userID); err != nil {
+		return promptingError(err)
+	}
+
+	return SyncResponse(nil)
+}
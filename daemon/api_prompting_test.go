@@ -34,6 +34,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/prompting"
 	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
 	"github.com/snapcore/snapd/interfaces/prompting/patterns"
+	"github.com/snapcore/snapd/interfaces/prompting/requesthistory"
 	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
 	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
 	"github.com/snapcore/snapd/overlord/ifacestate/apparmorprompting"
@@ -50,6 +51,8 @@ type fakeInterfacesRequestsManager struct {
 	prompt       *requestprompts.Prompt
 	rule         *requestrules.Rule
 	satisfiedIDs []prompting.IDType
+	ruleSet      *requestrules.RuleSet
+	history      []*requesthistory.Entry
 	err          error
 
 	// Store most recent received values
@@ -66,6 +69,9 @@ type fakeInterfacesRequestsManager struct {
 	lifespan             prompting.LifespanType
 	duration             string
 	clientActivity       bool
+	replace              bool
+	historyFilter        *requesthistory.Filter
+	historyCleared       bool
 }
 
 func (m *fakeInterfacesRequestsManager) Ask(uid uint32, iface, snap string, pid int32, cgroup string) (prompting.OutcomeType, error) {
@@ -142,6 +148,30 @@ func (m *fakeInterfacesRequestsManager) RemoveRule(userID uint32, ruleID prompti
 	return m.rule, m.err
 }
 
+func (m *fakeInterfacesRequestsManager) ExportRules(userID uint32) (*requestrules.RuleSet, error) {
+	m.userID = userID
+	return m.ruleSet, m.err
+}
+
+func (m *fakeInterfacesRequestsManager) ImportRules(userID uint32, ruleSet *requestrules.RuleSet, replace bool) ([]*requestrules.Rule, error) {
+	m.userID = userID
+	m.ruleSet = ruleSet
+	m.replace = replace
+	return m.rules, m.err
+}
+
+func (m *fakeInterfacesRequestsManager) History(userID uint32, filter *requesthistory.Filter) ([]*requesthistory.Entry, error) {
+	m.userID = userID
+	m.historyFilter = filter
+	return m.history, m.err
+}
+
+func (m *fakeInterfacesRequestsManager) ClearHistory(userID uint32) error {
+	m.userID = userID
+	m.historyCleared = true
+	return m.err
+}
+
 type promptingSuite struct {
 	apiBaseSuite
 
@@ -2232,3 +2262,283 @@ func (s *promptingSuite) TestPostRuleErrors(c *C) {
 		s.manager.err = nil
 	}
 }
+
+func (s *promptingSuite) TestGetRuleSetsHappy(c *C) {
+	s.daemon(c)
+
+	s.manager.ruleSet = &requestrules.RuleSet{Rules: []*requestrules.Rule{
+		{
+			ID:        prompting.IDType(1234),
+			User:      1000,
+			Snap:      "firefox",
+			Interface: "home",
+		},
+	}}
+
+	for _, testCase := range []struct {
+		path         string
+		expectedUser uint32
+	}{
+		{"/v2/interfaces/requests/rule-sets", 1000},
+		{"/v2/interfaces/requests/rule-sets?scope=user", 1000},
+		{"/v2/interfaces/requests/rule-sets?scope=system", requestrules.SystemWideUser},
+	} {
+		rsp := s.makeSyncReq(c, "GET", testCase.path, 1000, nil)
+		c.Check(s.manager.userID, Equals, testCase.expectedUser)
+		ruleSet, ok := rsp.Result.(*requestrules.RuleSet)
+		c.Check(ok, Equals, true)
+		c.Check(ruleSet, DeepEquals, s.manager.ruleSet)
+	}
+}
+
+func (s *promptingSuite) TestGetRuleSetsErrors(c *C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/interfaces/requests/rule-sets?scope=group", nil)
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=1000;socket=;"
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, Equals, 400)
+	c.Check(rspe.Kind, Equals, client.ErrorKindInterfacesRequestsInvalidFields)
+	c.Check(rspe.Message, Equals, `"scope" field must be "user" or "system"`)
+
+	s.appArmorPromptingRunning = false
+	req, err = http.NewRequest("GET", "/v2/interfaces/requests/rule-sets", nil)
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=1000;socket=;"
+	rspe = s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, Equals, 500)
+	c.Check(rspe.Kind, Equals, client.ErrorKindAppArmorPromptingNotRunning)
+}
+
+func (s *promptingSuite) TestPostRuleSetsImportHappy(c *C) {
+	s.expectWriteAccess(daemon.RootAccess{})
+	s.daemon(c)
+
+	s.manager.rules = []*requestrules.Rule{
+		{
+			ID:          prompting.IDType(1234),
+			User:        requestrules.SystemWideUser,
+			Snap:        "firefox",
+			Interface:   "home",
+			AdminLocked: true,
+		},
+	}
+
+	body := []byte(`{"action":"import","scope":"system","replace":true,"rule-set":{"rules":[{"snap":"firefox","interface":"home","admin-locked":true,"constraints":{"path-pattern":"/home/test/secret/**","permissions":{"read":{"outcome":"deny","lifespan":"forever"}}}}]}}`)
+	rsp := s.makeSyncReq(c, "POST", "/v2/interfaces/requests/rule-sets", 0, body)
+
+	c.Check(s.manager.userID, Equals, requestrules.SystemWideUser)
+	c.Check(s.manager.replace, Equals, true)
+	c.Assert(s.manager.ruleSet, NotNil)
+	c.Assert(s.manager.ruleSet.Rules, HasLen, 1)
+	c.Check(s.manager.ruleSet.Rules[0].AdminLocked, Equals, true)
+	c.Check(s.manager.ruleSet.Rules[0].Constraints.PathPattern().String(), Equals, "/home/test/secret/**")
+
+	rules, ok := rsp.Result.([]*requestrules.Rule)
+	c.Check(ok, Equals, true)
+	c.Check(rules, DeepEquals, s.manager.rules)
+
+	// Import for a given user
+	body = []byte(`{"action":"import","rule-set":{"rules":[]}}`)
+	s.makeSyncReq(c, "POST", "/v2/interfaces/requests/rule-sets?user-id=1000", 0, body)
+	c.Check(s.manager.userID, Equals, uint32(1000))
+	c.Check(s.manager.replace, Equals, false)
+
+	body = []byte(`{"action":"import","scope":"user","rule-set":{"rules":[]}}`)
+	s.makeSyncReq(c, "POST", "/v2/interfaces/requests/rule-sets?user-id=1001", 0, body)
+	c.Check(s.manager.userID, Equals, uint32(1001))
+}
+
+func (s *promptingSuite) TestPostRuleSetsErrors(c *C) {
+	s.expectWriteAccess(daemon.RootAccess{})
+	s.daemon(c)
+
+	for _, testCase := range []struct {
+		body   string
+		status int
+		kind   client.ErrorKind
+		errStr string
+	}{
+		{
+			body:   `{"action":"import"`,
+			status: 400,
+			errStr: "cannot decode request body for rule sets endpoint:.*",
+		},
+		{
+			body:   `{"action":"import"}`,
+			status: 400,
+			kind:   client.ErrorKindInterfacesRequestsInvalidFields,
+			errStr: `must include "rule-set" field in request body when action is "import"`,
+		},
+		{
+			body:   `{"action":"import","scope":"group","rule-set":{"rules":[]}}`,
+			status: 400,
+			kind:   client.ErrorKindInterfacesRequestsInvalidFields,
+			errStr: `"scope" field must be "user" or "system"`,
+		},
+		{
+			body:   `{"action":"import","rule-set":{"rules":[]}}`,
+			status: 400,
+			errStr: `must include "user-id" parameter when scope is "user"`,
+		},
+		{
+			body:   `{"action":"import","scope":"user","rule-set":{"rules":[]}}`,
+			status: 400,
+			errStr: `must include "user-id" parameter when scope is "user"`,
+		},
+		{
+			body:   `{"action":"import","scope":"system","rule-set":{"rules":[{"snap":"firefox","interface":"home","constraints":{"path-pattern":"/home/test/**","permissions":{"read":{"outcome":"maybe","lifespan":"forever"}}}}]}}`,
+			status: 400,
+			kind:   client.ErrorKindInterfacesRequestsInvalidFields,
+			errStr: `invalid outcome: .*`,
+		},
+	} {
+		req, err := http.NewRequest("POST", "/v2/interfaces/requests/rule-sets", bytes.NewReader([]byte(testCase.body)))
+		c.Assert(err, IsNil)
+		req.RemoteAddr = "pid=100;uid=0;socket=;"
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, Equals, testCase.status, Commentf("body: %s", testCase.body))
+		c.Check(rspe.Kind, Equals, testCase.kind, Commentf("body: %s", testCase.body))
+		c.Check(rspe.Message, Matches, testCase.errStr, Commentf("body: %s", testCase.body))
+	}
+
+	// Unknown action
+	req, err := http.NewRequest("POST", "/v2/interfaces/requests/rule-sets", bytes.NewReader([]byte(`{"action":"export","rule-set":{"rules":[]}}`)))
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=0;socket=;"
+	rspe := s.errorReq(c, req, nil, actionIsUnexpected)
+	c.Check(rspe.Status, Equals, 400)
+	c.Check(rspe.Kind, Equals, client.ErrorKindInterfacesRequestsInvalidFields)
+	c.Check(rspe.Message, Equals, `"action" field must be "import"`)
+
+	// Errors from the manager
+	s.manager.err = prompting_errors.ErrImportedRuleHasSession
+	req, err = http.NewRequest("POST", "/v2/interfaces/requests/rule-sets?user-id=1000", bytes.NewReader([]byte(`{"action":"import","rule-set":{"rules":[]}}`)))
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=0;socket=;"
+	rspe = s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, Equals, 400)
+	c.Check(rspe.Kind, Equals, client.ErrorKindInterfacesRequestsInvalidFields)
+
+	s.manager.err = prompting_errors.ErrRuleLocked
+	req, err = http.NewRequest("POST", "/v2/interfaces/requests/rule-sets", bytes.NewReader([]byte(`{"action":"import","scope":"system","rule-set":{"rules":[]}}`)))
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=0;socket=;"
+	rspe = s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, Equals, 403)
+	c.Check(rspe.Kind, Equals, client.ErrorKindInterfacesRequestsRuleLocked)
+}
+
+func (s *promptingSuite) TestGetHistoryHappy(c *C) {
+	s.daemon(c)
+
+	timestamp := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	s.manager.history = []*requesthistory.Entry{
+		{
+			Timestamp:   timestamp,
+			User:        1000,
+			Snap:        "firefox",
+			Interface:   "home",
+			Path:        "/home/test/foo",
+			Permissions: []string{"read"},
+			Outcome:     prompting.OutcomeAllow,
+			Lifespan:    prompting.LifespanForever,
+			RuleID:      prompting.IDType(1234),
+			Resolution:  requesthistory.ResolutionReplied,
+		},
+	}
+
+	rsp := s.makeSyncReq(c, "GET", "/v2/interfaces/requests/history?snap=firefox&after=2026-10-01T00:00:00Z&before=2026-10-02T00:00:00Z", 1000, nil)
+	c.Check(s.manager.userID, Equals, uint32(1000))
+	c.Check(s.manager.historyFilter, DeepEquals, &requesthistory.Filter{
+		Snap:   "firefox",
+		After:  time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Before: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC),
+	})
+	entries, ok := rsp.Result.([]*requesthistory.Entry)
+	c.Assert(ok, Equals, true)
+	c.Check(entries, DeepEquals, s.manager.history)
+
+	// An empty history is returned as an empty list
+	s.manager.history = nil
+	rsp = s.makeSyncReq(c, "GET", "/v2/interfaces/requests/history", 1000, nil)
+	c.Check(s.manager.historyFilter, DeepEquals, &requesthistory.Filter{})
+	entries, ok = rsp.Result.([]*requesthistory.Entry)
+	c.Assert(ok, Equals, true)
+	c.Check(entries, HasLen, 0)
+	c.Check(entries, NotNil)
+}
+
+func (s *promptingSuite) TestGetHistoryErrors(c *C) {
+	s.daemon(c)
+
+	for _, testCase := range []struct {
+		path   string
+		errStr string
+	}{
+		{"/v2/interfaces/requests/history?after=yesterday", `invalid "after" timestamp: .*`},
+		{"/v2/interfaces/requests/history?before=tomorrow", `invalid "before" timestamp: .*`},
+	} {
+		req, err := http.NewRequest("GET", testCase.path, nil)
+		c.Assert(err, IsNil)
+		req.RemoteAddr = "pid=100;uid=1000;socket=;"
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, Equals, 400)
+		c.Check(rspe.Message, Matches, testCase.errStr)
+	}
+
+	s.appArmorPromptingRunning = false
+	req, err := http.NewRequest("GET", "/v2/interfaces/requests/history", nil)
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=1000;socket=;"
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, Equals, 500)
+	c.Check(rspe.Kind, Equals, client.ErrorKindAppArmorPromptingNotRunning)
+}
+
+func (s *promptingSuite) TestPostHistoryClearHappy(c *C) {
+	s.expectWriteAccess(daemon.InterfaceOpenAccess{Interfaces: []string{"snap-interfaces-requests-control"}})
+	s.daemon(c)
+
+	rsp := s.makeSyncReq(c, "POST", "/v2/interfaces/requests/history", 1000, []byte(`{"action":"clear"}`))
+	c.Check(rsp.Status, Equals, 200)
+	c.Check(s.manager.userID, Equals, uint32(1000))
+	c.Check(s.manager.historyCleared, Equals, true)
+}
+
+func (s *promptingSuite) TestPostHistoryErrors(c *C) {
+	s.expectWriteAccess(daemon.InterfaceOpenAccess{Interfaces: []string{"snap-interfaces-requests-control"}})
+	s.daemon(c)
+
+	req, err := http.NewRequest("POST", "/v2/interfaces/requests/history", bytes.NewReader([]byte(`{"action":"clear"`)))
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=1000;socket=;"
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, Equals, 400)
+	c.Check(rspe.Message, Matches, "cannot decode request body for history endpoint:.*")
+
+	req, err = http.NewRequest("POST", "/v2/interfaces/requests/history", bytes.NewReader([]byte(`{"action":"delete"}`)))
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=1000;socket=;"
+	rspe = s.errorReq(c, req, nil, actionIsUnexpected)
+	c.Check(rspe.Status, Equals, 400)
+	c.Check(rspe.Kind, Equals, client.ErrorKindInterfacesRequestsInvalidFields)
+	c.Check(rspe.Message, Equals, `"action" field must be "clear"`)
+
+	s.manager.err = prompting_errors.ErrPromptingClosed
+	req, err = http.NewRequest("POST", "/v2/interfaces/requests/history", bytes.NewReader([]byte(`{"action":"clear"}`)))
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=1000;socket=;"
+	rspe = s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, Equals, 503)
+	c.Check(s.manager.historyCleared, Equals, true)
+
+	s.appArmorPromptingRunning = false
+	req, err = http.NewRequest("POST", "/v2/interfaces/requests/history", bytes.NewReader([]byte(`{"action":"clear"}`)))
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=1000;socket=;"
+	rspe = s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, Equals, 500)
+	c.Check(rspe.Kind, Equals, client.ErrorKindAppArmorPromptingNotRunning)
+}
@@ -41,7 +41,7 @@ var (
 	}
 )
 
-var ctlcmdRun = ctlcmd.Run
+var ctlcmdRun = ctlcmd.RunWithContext
 
 func runSnapctl(c *Command, r *http.Request, user *auth.UserState) Response {
 	var snapctlPostData client.SnapCtlPostData
@@ -78,7 +78,11 @@ func runSnapctl(c *Command, r *http.Request, user *auth.UserState) Response {
 		features = strings.Split(header, ",")
 	}
 
-	stdout, stderr, changeID, err := ctlcmdRun(context, snapctlPostData.Args, ucred.Uid, features)
+	// Use daemon's tomb context so that commands which wait, such as
+	// wait-notice, give up when either the request goes away or the daemon
+	// is shutting down
+	ctx := c.d.tomb.Context(r.Context())
+	stdout, stderr, changeID, err := ctlcmdRun(ctx, context, snapctlPostData.Args, ucred.Uid, features)
 	if err != nil {
 		if e, ok := err.(*ctlcmd.UnsuccessfulError); ok {
 			result := map[string]any{
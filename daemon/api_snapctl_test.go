@@ -21,6 +21,7 @@ package daemon_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -62,7 +63,7 @@ func (s *snapctlSuite) TestSnapctlGetFeatures(c *check.C) {
 		return &daemon.Ucrednet{Uid: 100, Pid: 9999, Socket: dirs.SnapSocket}, nil
 	})()
 
-	defer daemon.MockCtlcmdRun(func(ctx *hookstate.Context, args []string, uid uint32, features []string) ([]byte, []byte, string, error) {
+	defer daemon.MockCtlcmdRun(func(_ context.Context, ctx *hookstate.Context, args []string, uid uint32, features []string) ([]byte, []byte, string, error) {
 		c.Check(features, check.DeepEquals, []string{"feat1", "feat2"})
 		return []byte("stdout output"), nil, "", nil
 	})()
@@ -89,7 +90,7 @@ func (s *snapctlSuite) TestSnapctlAsyncFeature(c *check.C) {
 		return &daemon.Ucrednet{Uid: 100, Pid: 9999, Socket: dirs.SnapSocket}, nil
 	})()
 
-	defer daemon.MockCtlcmdRun(func(ctx *hookstate.Context, args []string, uid uint32, features []string) ([]byte, []byte, string, error) {
+	defer daemon.MockCtlcmdRun(func(_ context.Context, ctx *hookstate.Context, args []string, uid uint32, features []string) ([]byte, []byte, string, error) {
 		c.Check(features, check.DeepEquals, []string{"async"})
 		return []byte("stdout output"), nil, "test-change-id", nil
 	})()
@@ -117,7 +118,7 @@ func (s *snapctlSuite) TestSnapctlForbiddenError(c *check.C) {
 		return &daemon.Ucrednet{Uid: 100, Pid: 9999, Socket: dirs.SnapSocket}, nil
 	})()
 
-	defer daemon.MockCtlcmdRun(func(ctx *hookstate.Context, arg []string, uid uint32, features []string) ([]byte, []byte, string, error) {
+	defer daemon.MockCtlcmdRun(func(_ context.Context, ctx *hookstate.Context, arg []string, uid uint32, features []string) ([]byte, []byte, string, error) {
 		return nil, nil, "", &ctlcmd.ForbiddenCommandError{}
 	})()
 
@@ -135,7 +136,7 @@ func (s *snapctlSuite) TestSnapctlForbiddenErrorWithStdin(c *check.C) {
 		return &daemon.Ucrednet{Uid: 100, Pid: 9999, Socket: dirs.SnapSocket}, nil
 	})()
 
-	defer daemon.MockCtlcmdRun(func(ctx *hookstate.Context, arg []string, uid uint32, features []string) ([]byte, []byte, string, error) {
+	defer daemon.MockCtlcmdRun(func(_ context.Context, ctx *hookstate.Context, arg []string, uid uint32, features []string) ([]byte, []byte, string, error) {
 		return nil, nil, "", &ctlcmd.ForbiddenCommandError{}
 	})()
 
@@ -154,7 +155,7 @@ func (s *snapctlSuite) TestSnapctlUnsuccesfulError(c *check.C) {
 		return &daemon.Ucrednet{Uid: 100, Pid: 9999, Socket: dirs.SnapSocket}, nil
 	})()
 
-	defer daemon.MockCtlcmdRun(func(ctx *hookstate.Context, arg []string, uid uint32, features []string) ([]byte, []byte, string, error) {
+	defer daemon.MockCtlcmdRun(func(_ context.Context, ctx *hookstate.Context, arg []string, uid uint32, features []string) ([]byte, []byte, string, error) {
 		return nil, nil, "", &ctlcmd.UnsuccessfulError{ExitCode: 123}
 	})()
 
@@ -178,7 +179,7 @@ func (s *snapctlSuite) TestSnapctlGenericError(c *check.C) {
 		return &daemon.Ucrednet{Uid: 0, Pid: 9999, Socket: dirs.SnapSocket}, nil
 	})()
 
-	defer daemon.MockCtlcmdRun(func(ctx *hookstate.Context, arg []string, uid uint32, features []string) ([]byte, []byte, string, error) {
+	defer daemon.MockCtlcmdRun(func(_ context.Context, ctx *hookstate.Context, arg []string, uid uint32, features []string) ([]byte, []byte, string, error) {
 		return nil, nil, "", errors.New("something broke")
 	})()
 
@@ -189,3 +190,29 @@ func (s *snapctlSuite) TestSnapctlGenericError(c *check.C) {
 	c.Check(rsp.Status, check.Equals, 400)
 	c.Check(rsp.Message, check.Equals, "snapctl: something broke")
 }
+
+func (s *snapctlSuite) TestSnapctlRequestContext(c *check.C) {
+	s.daemon(c)
+
+	defer daemon.MockUcrednetGet(func(string) (*daemon.Ucrednet, error) {
+		return &daemon.Ucrednet{Uid: 100, Pid: 9999, Socket: dirs.SnapSocket}, nil
+	})()
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer daemon.MockCtlcmdRun(func(ctx context.Context, _ *hookstate.Context, args []string, uid uint32, features []string) ([]byte, []byte, string, error) {
+		c.Check(args, check.DeepEquals, []string{"wait-notice"})
+		c.Check(ctx.Err(), check.IsNil)
+		// the command gives up when the request goes away
+		cancel()
+		<-ctx.Done()
+		return nil, nil, "", errors.New("request canceled")
+	})()
+
+	buf := bytes.NewBufferString(`{"context-id": "some-context", "args": ["wait-notice"]}`)
+	req, err := http.NewRequestWithContext(reqCtx, "POST", "/v2/snapctl", buf)
+	c.Assert(err, check.IsNil)
+	rsp := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Message, check.Equals, "snapctl: request canceled")
+}
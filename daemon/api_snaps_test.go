@@ -221,6 +221,33 @@ func (s *snapsSuite) TestSnapsInfoOnlyLocal(c *check.C) {
 	})
 }
 
+func (s *snapsSuite) TestSnapsInfoStaleHealth(c *check.C) {
+	s.expectSnapsReadAccess()
+	d := s.daemon(c)
+
+	s.mkInstalledInState(c, d, "local", "foo", "v1", snap.R(10), true, "health-check-interval: 5m\nhooks:\n  check-health:\n")
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("health", map[string]healthstate.HealthState{
+		"local": {Status: healthstate.OkayStatus, Revision: snap.R(10), Timestamp: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	})
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/snaps?sources=local", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	snaps := snapList(rsp.Result)
+	c.Assert(snaps, check.HasLen, 1)
+	c.Check(snaps[0]["health"], check.DeepEquals, map[string]any{
+		"status":    "okay",
+		"revision":  "10",
+		"timestamp": "2026-01-01T00:00:00Z",
+		"stale":     true,
+	})
+}
+
 func (s *snapsSuite) TestSnapsInfoAllMixedPublishers(c *check.C) {
 	s.expectSnapsReadAccess()
 	d := s.daemon(c)
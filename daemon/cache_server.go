@@ -0,0 +1,99 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+)
+
+// cacheServer serves the snap downloads cache read-only to peers on the local
+// network, when store.cache.listen is configured.
+type cacheServer struct {
+	listener net.Listener
+	serve    *http.Server
+	sto      cacheHandlerStore
+}
+
+// CanStandby implements standby.Opinionator. Peers may request snaps at any
+// time, so snapd must keep running while it serves its cache.
+func (cs *cacheServer) CanStandby() bool {
+	return false
+}
+
+type cacheHandlerStore interface {
+	CacheHandler() http.Handler
+	StopServingCache()
+}
+
+// stop stops serving the downloads cache and waits for the deltas being
+// generated for peers.
+func (cs *cacheServer) stop() {
+	cs.serve.Close()
+	cs.sto.StopServingCache()
+}
+
+// startCacheServer starts serving the snap downloads cache on the address
+// configured with store.cache.listen, if any. Changes to the address take
+// effect when snapd is restarted.
+func (d *Daemon) startCacheServer() error {
+	d.state.Lock()
+	tr := config.NewTransaction(d.state)
+	var listen string
+	err := tr.GetMaybe("core", "store.cache.listen", &listen)
+	sto := snapstate.Store(d.state, nil)
+	d.state.Unlock()
+	if err != nil {
+		return err
+	}
+	if listen == "" {
+		return nil
+	}
+
+	cacheSto, ok := sto.(cacheHandlerStore)
+	if !ok {
+		return fmt.Errorf("store does not support serving its downloads cache")
+	}
+	l, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	cs := &cacheServer{
+		listener: l,
+		serve:    &http.Server{Handler: cacheSto.CacheHandler()},
+		sto:      cacheSto,
+	}
+	d.cacheServer = cs
+	d.tomb.Go(func() error {
+		if err := cs.serve.Serve(l); !errors.Is(err, http.ErrServerClosed) &&
+			!errors.Is(err, net.ErrClosed) {
+			logger.Noticef("cannot serve snap downloads cache: %v", err)
+		}
+		return nil
+	})
+	logger.Noticef("serving snap downloads cache to peers on %s", l.Addr())
+	return nil
+}
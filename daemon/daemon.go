@@ -74,6 +74,7 @@ type Daemon struct {
 	state           *state.State
 	snapdListener   net.Listener
 	snapListener    net.Listener
+	cacheServer     *cacheServer
 	connTracker     *connTracker
 	serve           *http.Server
 	tomb            tomb.Tomb
@@ -344,6 +345,9 @@ func (d *Daemon) initStandbyHandling() {
 	d.standbyOpinions.AddOpinion(d.overlord)
 	d.standbyOpinions.AddOpinion(d.overlord.SnapManager())
 	d.standbyOpinions.AddOpinion(d.overlord.DeviceManager())
+	if d.cacheServer != nil {
+		d.standbyOpinions.AddOpinion(d.cacheServer)
+	}
 	d.standbyOpinions.Start()
 }
 
@@ -386,6 +390,11 @@ func (d *Daemon) Start(ctx context.Context) (err error) {
 		ConnState: d.connTracker.trackConn,
 	}
 
+	// serving the downloads cache to peers is best-effort
+	if err := d.startCacheServer(); err != nil {
+		logger.Noticef("cannot serve snap downloads cache to peers: %v", err)
+	}
+
 	// enable standby handling
 	d.initStandbyHandling()
 
@@ -578,6 +587,9 @@ func (d *Daemon) Stop(sigCh chan<- os.Signal) error {
 		time.Sleep(rebootNoticeWait - timeSpent)
 	}
 	d.snapdListener.Close()
+	if d.cacheServer != nil {
+		d.cacheServer.stop()
+	}
 	d.standbyOpinions.Stop()
 
 	// We're using the background context here because the tomb's
@@ -29,6 +29,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"syscall"
 	"testing"
@@ -43,6 +44,7 @@ import (
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
 	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/patch"
@@ -664,6 +666,37 @@ version: 1`, si)
 	c.Check(s.notified, check.DeepEquals, []string{extendedTimeoutUSec, "READY=1", "STOPPING=1"})
 }
 
+func (s *daemonSuite) TestStartStopServesDownloadsCache(c *check.C) {
+	d := s.newTestDaemon(c)
+	s.markSeeded(d)
+
+	st := d.overlord.State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("core", "store.cache.listen", "127.0.0.1:0")
+	tr.Commit()
+	st.Unlock()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, check.IsNil)
+	d.snapdListener = l
+
+	c.Assert(d.Start(context.Background()), check.IsNil)
+	c.Assert(d.cacheServer, check.NotNil)
+	c.Check(d.standbyOpinions.CanStandby(), check.Equals, false)
+
+	cacheURL := fmt.Sprintf("http://%s/v2/cache/snaps/%s", d.cacheServer.listener.Addr(), strings.Repeat("a", 96))
+	resp, err := http.Get(cacheURL)
+	c.Assert(err, check.IsNil)
+	resp.Body.Close()
+	c.Check(resp.StatusCode, check.Equals, 404)
+
+	c.Assert(d.Stop(nil), check.IsNil)
+
+	_, err = http.Get(cacheURL)
+	c.Check(err, check.NotNil)
+}
+
 func (s *daemonSuite) TestRestartWiring(c *check.C) {
 	d := s.newTestDaemon(c)
 
@@ -0,0 +1,53 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"github.com/snapcore/snapd/overlord/certstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type (
+	CertificatesResponse = certificatesResponse
+)
+
+func MockCertstateListCertificates(f func() ([]*certstate.CertificateDetails, error)) func() {
+	old := certstateListCertificates
+	certstateListCertificates = f
+	return func() {
+		certstateListCertificates = old
+	}
+}
+
+func MockCertstateCurrentCertificateGeneration(f func() (string, error)) func() {
+	old := certstateCurrentCertificateGeneration
+	certstateCurrentCertificateGeneration = f
+	return func() {
+		certstateCurrentCertificateGeneration = old
+	}
+}
+
+func MockCertstateSetSystemCertificateState(f func(st *state.State, fingerprint, certState string) (*state.TaskSet, error)) func() {
+	old := certstateSetSystemCertificateState
+	certstateSetSystemCertificateState = f
+	return func() {
+		certstateSetSystemCertificateState = old
+	}
+}
@@ -37,6 +37,7 @@ type PostPromptRequestBody postPromptRequestBody
 type AddRuleContents addRuleContents
 type RemoveRulesSelector removeRulesSelector
 type PatchRuleContents patchRuleContents
+type PostRuleSetsRequestBody postRuleSetsRequestBody
 
 type PostInterfacesRequestsResponse = postInterfacesRequestsResponse
 
@@ -20,10 +20,12 @@
 package daemon
 
 import (
+	"context"
+
 	"github.com/snapcore/snapd/overlord/hookstate"
 )
 
-func MockCtlcmdRun(mock func(*hookstate.Context, []string, uint32, []string) ([]byte, []byte, string, error)) (restore func()) {
+func MockCtlcmdRun(mock func(context.Context, *hookstate.Context, []string, uint32, []string) ([]byte, []byte, string, error)) (restore func()) {
 	oldCtlcmdRun := ctlcmdRun
 	ctlcmdRun = mock
 	return func() {
@@ -422,7 +422,7 @@ func MockOsReadlink(f func(string) (string, error)) func() {
 	}
 }
 
-func MockNewStatusDecorator(f func(ctx context.Context, isGlobal bool, uid string) clientutil.StatusDecorator) (restore func()) {
+func MockNewStatusDecorator(f func(ctx context.Context, isGlobal bool, uid string, runtime bool) clientutil.StatusDecorator) (restore func()) {
 	restore = testutil.Backup(&newStatusDecorator)
 	newStatusDecorator = f
 	return restore
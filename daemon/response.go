@@ -276,17 +276,25 @@ func (f fileResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // outputs the json dump of that, padded with RS and LF to make it a valid
 // json-seq response.
 //
+// If jsonLines is set, the journal entries are instead output unchanged, with
+// all their original fields, one per line.
+//
 // The reader is always closed when done (this is important for
 // osutil.WatingStdoutPipe).
 //
 // Tip: “jq” knows how to read this; “jq --seq” both reads and writes this.
 type journalLineReaderSeqResponse struct {
 	io.ReadCloser
-	follow bool
+	follow    bool
+	jsonLines bool
 }
 
 func (rr *journalLineReaderSeqResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json-seq")
+	if rr.jsonLines {
+		w.Header().Set("Content-Type", "application/jsonl")
+	} else {
+		w.Header().Set("Content-Type", "application/json-seq")
+	}
 
 	flusher, hasFlusher := w.(http.Flusher)
 
@@ -294,23 +302,38 @@ func (rr *journalLineReaderSeqResponse) ServeHTTP(w http.ResponseWriter, r *http
 	dec := json.NewDecoder(rr)
 	writer := bufio.NewWriter(w)
 	enc := json.NewEncoder(writer)
+	if rr.jsonLines {
+		// keep messages as they are in the journal
+		enc.SetEscapeHTML(false)
+	}
 	for {
-		var log systemd.Log
-		if err = dec.Decode(&log); err != nil {
-			break
-		}
+		if rr.jsonLines {
+			var entry json.RawMessage
+			if err = dec.Decode(&entry); err != nil {
+				break
+			}
+			// Encode compacts the entry and ends it with LF
+			if err = enc.Encode(entry); err != nil {
+				break
+			}
+		} else {
+			var log systemd.Log
+			if err = dec.Decode(&log); err != nil {
+				break
+			}
 
-		writer.WriteByte(0x1E) // RS -- see ascii(7), and RFC7464
+			writer.WriteByte(0x1E) // RS -- see ascii(7), and RFC7464
 
-		// ignore the error...
-		t, _ := log.Time()
-		if err = enc.Encode(client.Log{
-			Timestamp: t,
-			Message:   log.Message(),
-			SID:       log.SID(),
-			PID:       log.PID(),
-		}); err != nil {
-			break
+			// ignore the error...
+			t, _ := log.Time()
+			if err = enc.Encode(client.Log{
+				Timestamp: t,
+				Message:   log.Message(),
+				SID:       log.SID(),
+				PID:       log.PID(),
+			}); err != nil {
+				break
+			}
 		}
 
 		if rr.follow {
@@ -323,7 +346,11 @@ func (rr *journalLineReaderSeqResponse) ServeHTTP(w http.ResponseWriter, r *http
 		}
 	}
 	if err != nil && err != io.EOF {
-		fmt.Fprintf(writer, `\x1E{"error": %q}\n`, err)
+		if rr.jsonLines {
+			fmt.Fprintf(writer, "{\"error\": %q}\n", err)
+		} else {
+			fmt.Fprintf(writer, `\x1E{"error": %q}\n`, err)
+		}
 		logger.Noticef("cannot stream response; problem reading: %v", err)
 	}
 	if err := writer.Flush(); err != nil {
@@ -80,6 +80,10 @@ func localSnapInfo(st *state.State, name string) (aboutSnap, error) {
 	if err != nil {
 		return aboutSnap{}, err
 	}
+	clientHealth, err := clientHealthFromHealthstate(st, &snapst, health)
+	if err != nil {
+		return aboutSnap{}, err
+	}
 
 	userHold, gatingHold, err := getUserAndGatingHolds(st, name)
 	if err != nil {
@@ -91,7 +95,7 @@ func localSnapInfo(st *state.State, name string) (aboutSnap, error) {
 	return aboutSnap{
 		info:           info,
 		snapst:         &snapst,
-		health:         clientHealthFromHealthstate(health),
+		health:         clientHealth,
 		refreshInhibit: refreshInhibit,
 		hold:           userHold,
 		gatingHold:     gatingHold,
@@ -141,7 +145,10 @@ func allLocalSnapInfos(st *state.State, sel snapSelect, wanted map[string]bool)
 		if len(wanted) > 0 && !wanted[name] {
 			continue
 		}
-		health := clientHealthFromHealthstate(healths[name])
+		health, err := clientHealthFromHealthstate(st, snapst, healths[name])
+		if err != nil {
+			return nil, err
+		}
 
 		userHold, gatingHold, err := getUserAndGatingHolds(st, name)
 		if err != nil {
@@ -211,17 +218,27 @@ func allLocalSnapInfos(st *state.State, sel snapSelect, wanted map[string]bool)
 	return about, nil
 }
 
-func clientHealthFromHealthstate(h *healthstate.HealthState) *client.SnapHealth {
+func clientHealthFromHealthstate(st *state.State, snapst *snapstate.SnapState, h *healthstate.HealthState) (*client.SnapHealth, error) {
 	if h == nil {
-		return nil
+		return nil, nil
 	}
-	return &client.SnapHealth{
+	health := &client.SnapHealth{
 		Revision:  h.Revision,
 		Timestamp: h.Timestamp,
 		Status:    h.Status.String(),
 		Message:   h.Message,
 		Code:      h.Code,
 	}
+	// only active snaps are checked periodically
+	if snapst.Active {
+		if info, err := snapst.CurrentInfo(); err == nil {
+			health.Stale, err = healthstate.IsStale(st, info, h)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return health, nil
 }
 
 func clientSnapRefreshInhibit(st *state.State, snapst *snapstate.SnapState, instanceName string) *client.SnapRefreshInhibit {
@@ -0,0 +1,220 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package gadget
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// SlotSwitcher switches the firmware between the A/B slots of groups of
+// paired raw structures. Switching follows the same try/commit scheme as
+// kernel updates: the updated slot is only tried on the next boot and
+// becomes active once that boot has been marked as successful.
+type SlotSwitcher interface {
+	// ActiveSlot returns the slot of the group that is known to boot.
+	ActiveSlot(group string) (string, error)
+	// SetTrySlot requests the given slot of the group to be tried on the
+	// next boot.
+	SetTrySlot(group, slot string) error
+	// ClearTrySlot cancels a pending try of a slot of the group.
+	ClearTrySlot(group string) error
+}
+
+// SlotSwitcherForUpdate returns the switcher used for updating A/B paired
+// raw structures. It is set by the boot package.
+var SlotSwitcherForUpdate = func() (SlotSwitcher, error) {
+	return nil, errors.New("A/B slot switching is not supported")
+}
+
+// abSlotUpdater implements support for updating a raw structure that is
+// one of the A/B slots of a group. Only the inactive slot is ever written,
+// thus the content the firmware currently boots from is never modified and
+// no backup is needed. Once written and verified, the slot is set to be
+// tried on the next boot.
+type abSlotUpdater struct {
+	*RawStructureWriter
+	deviceLookup deviceLookupFunc
+	switcher     SlotSwitcher
+
+	trySet bool
+}
+
+// newABSlotUpdater returns an updater for the given A/B slot structure.
+// Update data will be loaded from the provided gadget content directory.
+func newABSlotUpdater(contentDir string, ps *LaidOutStructure, deviceLookup deviceLookupFunc, switcher SlotSwitcher) (*abSlotUpdater, error) {
+	if deviceLookup == nil {
+		return nil, fmt.Errorf("internal error: device lookup helper must be provided")
+	}
+	if switcher == nil {
+		return nil, fmt.Errorf("internal error: slot switcher must be provided")
+	}
+	if ps != nil && ps.VolumeStructure.Slot == nil {
+		return nil, fmt.Errorf("internal error: structure %s is not an A/B slot", ps)
+	}
+
+	rw, err := NewRawStructureWriter(contentDir, ps)
+	if err != nil {
+		return nil, err
+	}
+	return &abSlotUpdater{
+		RawStructureWriter: rw,
+		deviceLookup:       deviceLookup,
+		switcher:           switcher,
+	}, nil
+}
+
+func (u *abSlotUpdater) slot() *VolumeSlot {
+	return u.ps.VolumeStructure.Slot
+}
+
+// isActive returns whether the structure is the active slot of its group.
+func (u *abSlotUpdater) isActive() (bool, error) {
+	active, err := u.switcher.ActiveSlot(u.slot().Group)
+	if err != nil {
+		return false, fmt.Errorf("cannot determine active slot of group %q: %v", u.slot().Group, err)
+	}
+	return active == u.slot().Name, nil
+}
+
+// matchDevice identifies the device matching the configured structure, returns
+// device path and a shifted structure should any offset adjustments be needed
+func (u *abSlotUpdater) matchDevice() (device string, shifted *LaidOutStructure, err error) {
+	device, offs, err := u.deviceLookup(u.ps)
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot find device matching structure %v: %v", u.ps, err)
+	}
+
+	if offs == u.ps.StartOffset {
+		return device, u.ps, nil
+	}
+
+	structForDevice := ShiftStructureTo(*u.ps, offs)
+	return device, &structForDevice, nil
+}
+
+// Backup is a noop, the inactive slot does not hold any content the device
+// depends on.
+func (u *abSlotUpdater) Backup() error {
+	return nil
+}
+
+// Update writes the new content to the structure when it is the inactive
+// slot of its group, verifies it and sets it to be tried on the next boot.
+// ErrNoUpdate is returned for the active slot.
+func (u *abSlotUpdater) Update() error {
+	active, err := u.isActive()
+	if err != nil {
+		return err
+	}
+	if active {
+		// the other slot of the group receives the update
+		return ErrNoUpdate
+	}
+
+	device, structForDevice, err := u.matchDevice()
+	if err != nil {
+		return err
+	}
+
+	if err := u.write(device, structForDevice); err != nil {
+		return err
+	}
+	if err := u.verify(device, structForDevice); err != nil {
+		return err
+	}
+
+	if err := u.switcher.SetTrySlot(u.slot().Group, u.slot().Name); err != nil {
+		return fmt.Errorf("cannot set slot %q of group %q to be tried: %v", u.slot().Name, u.slot().Group, err)
+	}
+	u.trySet = true
+	return nil
+}
+
+func (u *abSlotUpdater) write(device string, structForDevice *LaidOutStructure) error {
+	disk, close, err := openDiskForWrite(device, structForDevice)
+	if err != nil {
+		return fmt.Errorf("cannot open device for writing: %v", err)
+	}
+	defer func() {
+		if err := close(); err != nil {
+			logger.Noticef("cannot close device: %v", err)
+		}
+	}()
+
+	for _, pc := range structForDevice.LaidOutContent {
+		if err := u.writeRawImage(disk, &pc); err != nil {
+			return fmt.Errorf("cannot write image %v: %v", pc, err)
+		}
+	}
+	if err := disk.Sync(); err != nil {
+		return fmt.Errorf("cannot sync device: %v", err)
+	}
+	return nil
+}
+
+// verify checks that the content read back from the device matches the
+// update images.
+func (u *abSlotUpdater) verify(device string, structForDevice *LaidOutStructure) error {
+	disk, err := os.OpenFile(device, os.O_RDONLY, 0)
+	if err != nil {
+		return fmt.Errorf("cannot open device for reading: %v", err)
+	}
+	defer disk.Close()
+
+	for _, pc := range structForDevice.LaidOutContent {
+		if _, err := disk.Seek(int64(pc.StartOffset), io.SeekStart); err != nil {
+			return fmt.Errorf("cannot seek to content start offset 0x%x: %v", pc.StartOffset, err)
+		}
+		writtenHash := crypto.SHA1.New()
+		if _, err := io.CopyN(writtenHash, disk, int64(pc.Size)); err != nil {
+			return fmt.Errorf("cannot read back image %v: %v", pc, err)
+		}
+		updateDigest, _, err := osutil.FileDigest(filepath.Join(u.contentDir, pc.Image), crypto.SHA1)
+		if err != nil {
+			return fmt.Errorf("cannot checksum update image: %v", err)
+		}
+		if !bytes.Equal(writtenHash.Sum(nil), updateDigest) {
+			return fmt.Errorf("cannot verify image %v: written data does not match the update", pc)
+		}
+	}
+	return nil
+}
+
+// Rollback cancels the try of the slot set by Update. The written content
+// is left in place as the firmware keeps booting the active slot.
+func (u *abSlotUpdater) Rollback() error {
+	if !u.trySet {
+		return nil
+	}
+	if err := u.switcher.ClearTrySlot(u.slot().Group); err != nil {
+		return fmt.Errorf("cannot clear try slot of group %q: %v", u.slot().Group, err)
+	}
+	u.trySet = false
+	return nil
+}
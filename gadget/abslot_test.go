@@ -0,0 +1,290 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package gadget_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type abSlotTestSuite struct {
+	testutil.BaseTest
+
+	dir      string
+	switcher *mockSlotSwitcher
+}
+
+var _ = Suite(&abSlotTestSuite{})
+
+type mockSlotSwitcher struct {
+	active map[string]string
+	try    map[string]string
+
+	activeErr error
+	setTryErr error
+	clearErr  error
+}
+
+func newMockSlotSwitcher() *mockSlotSwitcher {
+	return &mockSlotSwitcher{
+		active: map[string]string{},
+		try:    map[string]string{},
+	}
+}
+
+func (m *mockSlotSwitcher) ActiveSlot(group string) (string, error) {
+	if m.activeErr != nil {
+		return "", m.activeErr
+	}
+	if active, ok := m.active[group]; ok {
+		return active, nil
+	}
+	return gadget.SlotA, nil
+}
+
+func (m *mockSlotSwitcher) SetTrySlot(group, slot string) error {
+	if m.setTryErr != nil {
+		return m.setTryErr
+	}
+	m.try[group] = slot
+	return nil
+}
+
+func (m *mockSlotSwitcher) ClearTrySlot(group string) error {
+	if m.clearErr != nil {
+		return m.clearErr
+	}
+	delete(m.try, group)
+	return nil
+}
+
+func sizedContent(size int, content []byte) []byte {
+	data := make([]byte, size)
+	copy(data, content)
+	return data
+}
+
+func (s *abSlotTestSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.dir = c.MkDir()
+	s.switcher = newMockSlotSwitcher()
+}
+
+func (s *abSlotTestSuite) slotStructure(slot string) *gadget.LaidOutStructure {
+	return &gadget.LaidOutStructure{
+		OnDiskStructure: gadget.OnDiskStructure{
+			StartOffset: 1 * quantity.OffsetMiB,
+		},
+		VolumeStructure: &gadget.VolumeStructure{
+			Name:            "bootloader-" + slot,
+			Type:            "bare",
+			Size:            2048,
+			EnclosingVolume: &gadget.Volume{},
+			Slot:            &gadget.VolumeSlot{Group: "bootloader", Name: slot},
+		},
+		LaidOutContent: []gadget.LaidOutContent{
+			{
+				VolumeContent: &gadget.VolumeContent{
+					Image: "foo.img",
+				},
+				StartOffset: 1 * quantity.OffsetMiB,
+				Size:        128,
+			}, {
+				VolumeContent: &gadget.VolumeContent{
+					Image: "bar.img",
+				},
+				StartOffset: 1*quantity.OffsetMiB + 1024,
+				Size:        128,
+				Index:       1,
+			},
+		},
+	}
+}
+
+func (s *abSlotTestSuite) TestUpdateInactiveSlotHappy(c *C) {
+	partitionPath := filepath.Join(s.dir, "partition.img")
+	mutateFile(c, partitionPath, 2048, []mutateWrite{
+		{[]byte("foo foo foo"), 0},
+		{[]byte("bar bar bar"), 1024},
+	})
+	makeSizedFile(c, filepath.Join(s.dir, "foo.img"), 128, []byte("new foo"))
+	makeSizedFile(c, filepath.Join(s.dir, "bar.img"), 128, []byte("new bar"))
+
+	ps := s.slotStructure(gadget.SlotB)
+	u, err := gadget.NewABSlotUpdater(s.dir, ps, func(to *gadget.LaidOutStructure) (string, quantity.Offset, error) {
+		c.Check(to, DeepEquals, ps)
+		return partitionPath, 0, nil
+	}, s.switcher)
+	c.Assert(err, IsNil)
+
+	// nothing is backed up
+	c.Assert(u.Backup(), IsNil)
+
+	err = u.Update()
+	c.Assert(err, IsNil)
+
+	data, err := os.ReadFile(partitionPath)
+	c.Assert(err, IsNil)
+	c.Check(data[0:128], DeepEquals, sizedContent(128, []byte("new foo")))
+	c.Check(data[1024:1024+128], DeepEquals, sizedContent(128, []byte("new bar")))
+	c.Check(s.switcher.try, DeepEquals, map[string]string{"bootloader": gadget.SlotB})
+
+	// rollback cancels the try, the written data stays
+	err = u.Rollback()
+	c.Assert(err, IsNil)
+	c.Check(s.switcher.try, HasLen, 0)
+	data, err = os.ReadFile(partitionPath)
+	c.Assert(err, IsNil)
+	c.Check(data[0:128], DeepEquals, sizedContent(128, []byte("new foo")))
+}
+
+func (s *abSlotTestSuite) TestUpdateActiveSlotSkipped(c *C) {
+	emptyDiskPath := filepath.Join(s.dir, "disk-not-written.img")
+	mutateFile(c, emptyDiskPath, 0, nil)
+	makeSizedFile(c, filepath.Join(s.dir, "foo.img"), 128, []byte("new foo"))
+	makeSizedFile(c, filepath.Join(s.dir, "bar.img"), 128, []byte("new bar"))
+
+	s.switcher.active["bootloader"] = gadget.SlotB
+
+	u, err := gadget.NewABSlotUpdater(s.dir, s.slotStructure(gadget.SlotB), func(to *gadget.LaidOutStructure) (string, quantity.Offset, error) {
+		return emptyDiskPath, 0, nil
+	}, s.switcher)
+	c.Assert(err, IsNil)
+
+	c.Assert(u.Backup(), IsNil)
+	err = u.Update()
+	c.Assert(err, Equals, gadget.ErrNoUpdate)
+	c.Check(getFileSize(c, emptyDiskPath), Equals, int64(0))
+	c.Check(s.switcher.try, HasLen, 0)
+
+	// rollback is a noop
+	c.Assert(u.Rollback(), IsNil)
+	c.Check(getFileSize(c, emptyDiskPath), Equals, int64(0))
+}
+
+func (s *abSlotTestSuite) TestUpdateErrors(c *C) {
+	partitionPath := filepath.Join(s.dir, "partition.img")
+	mutateFile(c, partitionPath, 2048, nil)
+	makeSizedFile(c, filepath.Join(s.dir, "foo.img"), 128, []byte("new foo"))
+	makeSizedFile(c, filepath.Join(s.dir, "bar.img"), 128, []byte("new bar"))
+
+	lookup := func(to *gadget.LaidOutStructure) (string, quantity.Offset, error) {
+		return partitionPath, 0, nil
+	}
+
+	u, err := gadget.NewABSlotUpdater(s.dir, s.slotStructure(gadget.SlotA), lookup, s.switcher)
+	c.Assert(err, IsNil)
+	s.switcher.active["bootloader"] = gadget.SlotB
+
+	s.switcher.activeErr = errors.New("active fail")
+	err = u.Update()
+	c.Check(err, ErrorMatches, `cannot determine active slot of group "bootloader": active fail`)
+	s.switcher.activeErr = nil
+
+	s.switcher.setTryErr = errors.New("try fail")
+	err = u.Update()
+	c.Check(err, ErrorMatches, `cannot set slot "a" of group "bootloader" to be tried: try fail`)
+	s.switcher.setTryErr = nil
+	// nothing to clear on rollback
+	c.Check(u.Rollback(), IsNil)
+
+	c.Assert(u.Update(), IsNil)
+	s.switcher.clearErr = errors.New("clear fail")
+	err = u.Rollback()
+	c.Check(err, ErrorMatches, `cannot clear try slot of group "bootloader": clear fail`)
+
+	u, err = gadget.NewABSlotUpdater(s.dir, s.slotStructure(gadget.SlotA), func(to *gadget.LaidOutStructure) (string, quantity.Offset, error) {
+		return "", 0, errors.New("lookup fail")
+	}, s.switcher)
+	c.Assert(err, IsNil)
+	err = u.Update()
+	c.Check(err, ErrorMatches, `cannot find device matching structure #0 \("bootloader-a"\): lookup fail`)
+}
+
+func (s *abSlotTestSuite) TestUpdateVerifyFails(c *C) {
+	partitionPath := filepath.Join(s.dir, "partition.img")
+	mutateFile(c, partitionPath, 2048, nil)
+	makeSizedFile(c, filepath.Join(s.dir, "foo.img"), 128, []byte("new foo"))
+	// the image is larger than the declared size of the content, only the
+	// declared size is written and the read back data does not match
+	makeSizedFile(c, filepath.Join(s.dir, "bar.img"), 256, []byte("new bar"))
+
+	u, err := gadget.NewABSlotUpdater(s.dir, s.slotStructure(gadget.SlotB), func(to *gadget.LaidOutStructure) (string, quantity.Offset, error) {
+		return partitionPath, 0, nil
+	}, s.switcher)
+	c.Assert(err, IsNil)
+
+	err = u.Update()
+	c.Check(err, ErrorMatches, `cannot verify image #1 \("bar.img"@0x400\{128\}\): written data does not match the update`)
+	c.Check(s.switcher.try, HasLen, 0)
+}
+
+func (s *abSlotTestSuite) TestNewABSlotUpdaterInternalErrors(c *C) {
+	lookup := func(to *gadget.LaidOutStructure) (string, quantity.Offset, error) {
+		return "", 0, nil
+	}
+
+	ps := s.slotStructure(gadget.SlotA)
+	u, err := gadget.NewABSlotUpdater(s.dir, ps, nil, s.switcher)
+	c.Check(err, ErrorMatches, "internal error: device lookup helper must be provided")
+	c.Check(u, IsNil)
+
+	u, err = gadget.NewABSlotUpdater(s.dir, ps, lookup, nil)
+	c.Check(err, ErrorMatches, "internal error: slot switcher must be provided")
+	c.Check(u, IsNil)
+
+	ps.VolumeStructure.Slot = nil
+	u, err = gadget.NewABSlotUpdater(s.dir, ps, lookup, s.switcher)
+	c.Check(err, ErrorMatches, `internal error: structure #0 \("bootloader-a"\) is not an A/B slot`)
+	c.Check(u, IsNil)
+}
+
+func (s *abSlotTestSuite) TestUpdaterForSlotStructure(c *C) {
+	ps := s.slotStructure(gadget.SlotA)
+	loc := gadget.StructureLocation{
+		Device: "/dev/mmcblk0",
+		Offset: quantity.OffsetMiB,
+	}
+
+	restore := gadget.MockSlotSwitcherForUpdate(func() (gadget.SlotSwitcher, error) {
+		return s.switcher, nil
+	})
+	s.AddCleanup(restore)
+
+	updater, err := gadget.UpdaterForStructure(loc, nil, ps, s.dir, c.MkDir(), nil)
+	c.Assert(err, IsNil)
+	c.Check(updater, FitsTypeOf, &gadget.ABSlotUpdater{})
+
+	// without slot support the structure cannot be updated
+	restore = gadget.MockSlotSwitcherForUpdate(func() (gadget.SlotSwitcher, error) {
+		return nil, errors.New("no switcher")
+	})
+	s.AddCleanup(restore)
+
+	_, err = gadget.UpdaterForStructure(loc, nil, ps, s.dir, c.MkDir(), nil)
+	c.Assert(err, ErrorMatches, "no switcher")
+}
@@ -27,6 +27,7 @@ import (
 type (
 	MountedFilesystemUpdater = mountedFilesystemUpdater
 	RawStructureUpdater      = rawStructureUpdater
+	ABSlotUpdater            = abSlotUpdater
 	InvalidOffsetError       = invalidOffsetError
 )
 
@@ -36,6 +37,7 @@ var (
 	ValidateRole            = validateRole
 	ValidateVolume          = validateVolume
 	ValidateOffsetWrite     = validateOffsetWrite
+	ValidateSlotGroups      = validateSlotGroups
 
 	SetImplicitForVolumeStructure = setImplicitForVolumeStructure
 
@@ -52,6 +54,7 @@ var (
 	Flatten = flatten
 
 	NewRawStructureUpdater      = newRawStructureUpdater
+	NewABSlotUpdater            = newABSlotUpdater
 	NewMountedFilesystemUpdater = newMountedFilesystemUpdater
 
 	ParseRelativeOffset = parseRelativeOffset
@@ -112,3 +115,9 @@ func MockSetEMMCPartitionReadWrite(mock func(device string, rw bool) error) (res
 	setEMMCPartitionReadWrite = mock
 	return r
 }
+
+func MockSlotSwitcherForUpdate(f func() (SlotSwitcher, error)) (restore func()) {
+	r := testutil.Backup(&SlotSwitcherForUpdate)
+	SlotSwitcherForUpdate = f
+	return r
+}
@@ -119,6 +119,8 @@ var (
 	validVolumeName = regexp.MustCompile("^[a-zA-Z0-9][a-zA-Z0-9-]+$")
 	validTypeID     = regexp.MustCompile("^[0-9A-F]{2}$")
 	validGUUID      = regexp.MustCompile("^(?i)[0-9A-F]{8}-[0-9A-F]{4}-[0-9A-F]{4}-[0-9A-F]{4}-[0-9A-F]{12}$")
+	// slot group names end up in bootloader variable names
+	validSlotGroup = regexp.MustCompile("^[a-z][a-z0-9_]*$")
 )
 
 type KernelCmdline struct {
@@ -297,6 +299,10 @@ func (vs *VolumeStructure) Copy() *VolumeStructure {
 			}
 		}
 	}
+	if vs.Slot != nil {
+		slot := *vs.Slot
+		newVs.Slot = &slot
+	}
 	return &newVs
 }
 
@@ -368,6 +374,10 @@ type VolumeStructure struct {
 	// Content of the structure
 	Content []VolumeContent `yaml:"content" json:"content"`
 	Update  VolumeUpdate    `yaml:"update" json:"update"`
+	// Slot, when set, makes the structure one of the two A/B slots of a
+	// group of paired raw structures, of which the firmware boots the
+	// active one. Updates are written to the inactive slot.
+	Slot *VolumeSlot `yaml:"slot,omitempty" json:"slot,omitempty"`
 
 	// Note that the Device field will never be part of the yaml
 	// and just used as part of the POST /systems/<label> API that
@@ -586,6 +596,23 @@ type VolumeUpdate struct {
 	Preserve []string       `yaml:"preserve" json:"preserve"`
 }
 
+const (
+	// SlotA and SlotB are the names of the slots of a group of paired
+	// raw structures.
+	SlotA = "a"
+	SlotB = "b"
+)
+
+// VolumeSlot describes the membership of a raw structure in a group of A/B
+// paired structures.
+type VolumeSlot struct {
+	// Group is the name shared by both structures of the pair.
+	Group string `yaml:"group" json:"group"`
+	// Name is the slot of the structure within the group, either "a" or
+	// "b".
+	Name string `yaml:"name" json:"name"`
+}
+
 // VolumeAssignment is an optional set of volume-to-disk assignments
 // that can be specified. This is a nice way of reusing the same gadget
 // for multiple devices, or a way to map multiple volumes to the same disk
@@ -1548,6 +1575,9 @@ func validateVolume(vol *Volume) error {
 		if err := validateVolumeStructure(&s, vol); err != nil {
 			return fmt.Errorf("invalid structure %v: %v", fmtIndexAndName(idx, s.Name), err)
 		}
+		if err := validateStructureSlot(&s); err != nil {
+			return fmt.Errorf("invalid structure %v: %v", fmtIndexAndName(idx, s.Name), err)
+		}
 
 		if vol.Schema == schemaGPT && s.Offset != nil {
 			// If the block size is 512, the First Usable LBA must be greater than or equal to
@@ -1586,9 +1616,66 @@ func validateVolume(vol *Volume) error {
 		}
 	}
 
+	if err := validateSlotGroups(vol); err != nil {
+		return err
+	}
+
 	return validateCrossVolumeStructure(vol)
 }
 
+func validateStructureSlot(vs *VolumeStructure) error {
+	if vs.Slot == nil {
+		return nil
+	}
+	if vs.HasFilesystem() {
+		return errors.New("slots are only supported for non-filesystem structures")
+	}
+	if vs.Slot.Group == "" {
+		return errors.New("missing slot group")
+	}
+	if !validSlotGroup.MatchString(vs.Slot.Group) {
+		return fmt.Errorf("invalid slot group %q", vs.Slot.Group)
+	}
+	if vs.Slot.Name != SlotA && vs.Slot.Name != SlotB {
+		return fmt.Errorf("invalid slot name %q, must be %q or %q", vs.Slot.Name, SlotA, SlotB)
+	}
+	return nil
+}
+
+// validateSlotGroups checks that each group of A/B paired structures
+// consists of exactly one structure per slot, both of the same size.
+func validateSlotGroups(vol *Volume) error {
+	var groupNames []string
+	groups := make(map[string]map[string]*VolumeStructure)
+	for i := range vol.Structure {
+		vs := &vol.Structure[i]
+		if vs.Slot == nil {
+			continue
+		}
+		slots := groups[vs.Slot.Group]
+		if slots == nil {
+			slots = make(map[string]*VolumeStructure, 2)
+			groups[vs.Slot.Group] = slots
+			groupNames = append(groupNames, vs.Slot.Group)
+		}
+		if slots[vs.Slot.Name] != nil {
+			return fmt.Errorf("slot %q of group %q is declared more than once", vs.Slot.Name, vs.Slot.Group)
+		}
+		slots[vs.Slot.Name] = vs
+	}
+	for _, group := range groupNames {
+		slots := groups[group]
+		a, b := slots[SlotA], slots[SlotB]
+		if a == nil || b == nil {
+			return fmt.Errorf("slot group %q must declare both slots %q and %q", group, SlotA, SlotB)
+		}
+		if a.Size != b.Size {
+			return fmt.Errorf("slots of group %q have different sizes", group)
+		}
+	}
+	return nil
+}
+
 // isMBR returns whether the structure is the MBR and can be used before setImplicitForVolume
 func isMBR(vs *VolumeStructure) bool {
 	if vs.Role == schemaMBR {
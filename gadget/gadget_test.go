@@ -1823,6 +1823,97 @@ func (s *gadgetYamlTestSuite) TestValidateStructureUpdatePreserveDuplicates(c *C
 	c.Check(err, ErrorMatches, `duplicate "preserve" entry "foo"`)
 }
 
+func (s *gadgetYamlTestSuite) TestReadGadgetYamlSlots(c *C) {
+	gadgetYaml := `
+volumes:
+  pi:
+    bootloader: u-boot
+    structure:
+      - name: bootloader-a
+        type: bare
+        size: 1M
+        slot:
+          group: bootloader
+          name: a
+        content:
+          - image: u-boot.img
+      - name: bootloader-b
+        type: bare
+        size: 1M
+        slot:
+          group: bootloader
+          name: b
+        content:
+          - image: u-boot.img
+`
+	ginfo, err := gadget.InfoFromGadgetYaml([]byte(gadgetYaml), nil)
+	c.Assert(err, IsNil)
+	vol := ginfo.Volumes["pi"]
+	c.Assert(vol.Structure, HasLen, 2)
+	c.Check(vol.Structure[0].Slot, DeepEquals, &gadget.VolumeSlot{Group: "bootloader", Name: "a"})
+	c.Check(vol.Structure[1].Slot, DeepEquals, &gadget.VolumeSlot{Group: "bootloader", Name: "b"})
+
+	// slots are deep copied
+	cpy := vol.Copy()
+	cpy.Structure[0].Slot.Name = "b"
+	c.Check(vol.Structure[0].Slot.Name, Equals, "a")
+}
+
+func (s *gadgetYamlTestSuite) TestReadGadgetYamlSlotsInvalid(c *C) {
+	yamlTemplate := `
+volumes:
+  pi:
+    bootloader: u-boot
+    structure:
+      - name: bootloader-a
+        type: bare
+        size: 1M
+%s
+      - name: bootloader-b
+        type: bare
+        size: %s
+%s
+      - name: data
+        type: 83,0FC63DAF-8483-4772-8E79-3D69D8477DE4
+        filesystem: ext4
+        size: 1M
+%s
+`
+	slot := func(group, name string) string {
+		return fmt.Sprintf("        slot:\n          group: %s\n          name: %s", group, name)
+	}
+
+	for _, tc := range []struct {
+		slotA, sizeB, slotB, slotData string
+		err                           string
+	}{{
+		slotA: slot("bootloader", "a"), sizeB: "1M", slotB: slot("bootloader", "c"),
+		err: `invalid volume "pi": invalid structure #1 \("bootloader-b"\): invalid slot name "c", must be "a" or "b"`,
+	}, {
+		slotA: slot(`""`, "a"), sizeB: "1M", slotB: slot("bootloader", "b"),
+		err: `invalid volume "pi": invalid structure #0 \("bootloader-a"\): missing slot group`,
+	}, {
+		slotA: slot("boot-loader", "a"), sizeB: "1M", slotB: slot("boot-loader", "b"),
+		err: `invalid volume "pi": invalid structure #0 \("bootloader-a"\): invalid slot group "boot-loader"`,
+	}, {
+		slotA: slot("bootloader", "a"), sizeB: "1M", slotB: slot("bootloader", "a"),
+		err: `invalid volume "pi": slot "a" of group "bootloader" is declared more than once`,
+	}, {
+		slotA: slot("bootloader", "a"), sizeB: "1M", slotB: slot("other", "b"),
+		err: `invalid volume "pi": slot group "bootloader" must declare both slots "a" and "b"`,
+	}, {
+		slotA: slot("bootloader", "a"), sizeB: "2M", slotB: slot("bootloader", "b"),
+		err: `invalid volume "pi": slots of group "bootloader" have different sizes`,
+	}, {
+		slotA: slot("bootloader", "a"), sizeB: "1M", slotB: slot("bootloader", "b"), slotData: slot("data", "a"),
+		err: `invalid volume "pi": invalid structure #2 \("data"\): slots are only supported for non-filesystem structures`,
+	}} {
+		gadgetYaml := fmt.Sprintf(yamlTemplate, tc.slotA, tc.sizeB, tc.slotB, tc.slotData)
+		_, err := gadget.InfoFromGadgetYaml([]byte(gadgetYaml), nil)
+		c.Check(err, ErrorMatches, tc.err, Commentf("slots: %q %q %q", tc.slotA, tc.slotB, tc.slotData))
+	}
+}
+
 func (s *gadgetYamlTestSuite) TestValidateStructureSizeRequired(c *C) {
 
 	gv := &gadget.Volume{Schema: "gpt"}
@@ -4356,6 +4447,7 @@ func (s *gadgetYamlTestSuite) TestGadgetInfoHasSameYamlAndJsonTags(c *C) {
 	tagsValid(c, &gadget.VolumeContent{}, nil)
 	tagsValid(c, &gadget.RelativeOffset{}, nil)
 	tagsValid(c, &gadget.VolumeUpdate{}, nil)
+	tagsValid(c, &gadget.VolumeSlot{}, nil)
 }
 
 func (s *gadgetYamlTestSuite) TestGadgetInfoVolumeInternalFieldsNoJSON(c *C) {
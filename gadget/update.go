@@ -1586,6 +1586,20 @@ func arePartitionTypesCompatible(from, to *VolumeStructure) bool {
 	return isLegacyMBRTransition(from, to)
 }
 
+func areSlotsEqual(from, to *VolumeSlot) bool {
+	if from == nil || to == nil {
+		return from == to
+	}
+	return *from == *to
+}
+
+func fmtSlot(slot *VolumeSlot) string {
+	if slot == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%q of group %q", slot.Name, slot.Group)
+}
+
 // canUpdateStructure checks gadget compatibility on updates, looking only at
 // features that are not reflected on the installed disk (for this we check
 // elsewhere the new gadget against the actual disk content).
@@ -1620,6 +1634,9 @@ func canUpdateStructure(fromV *Volume, fromIdx int, toV *Volume, toIdx int) erro
 	if from.ID != to.ID {
 		return fmt.Errorf("cannot change structure ID from %q to %q", from.ID, to.ID)
 	}
+	if !areSlotsEqual(from.Slot, to.Slot) {
+		return fmt.Errorf("cannot change structure slot from %s to %s", fmtSlot(from.Slot), fmtSlot(to.Slot))
+	}
 	if to.HasFilesystem() {
 		if !from.HasFilesystem() {
 			return fmt.Errorf("cannot change a bare structure to filesystem one")
@@ -1874,6 +1891,13 @@ func updaterForStructureImpl(loc StructureLocation, fromPs *LaidOutStructure, ps
 		lookup := func(ps *LaidOutStructure) (device string, offs quantity.Offset, err error) {
 			return loc.Device, loc.Offset, nil
 		}
+		if ps.VolumeStructure.Slot != nil {
+			switcher, err := SlotSwitcherForUpdate()
+			if err != nil {
+				return nil, err
+			}
+			return newABSlotUpdater(newRootDir, ps, lookup, switcher)
+		}
 		return newRawStructureUpdater(newRootDir, ps, rollbackDir, lookup)
 	} else {
 		lookup := func(ps *LaidOutStructure) (string, error) {
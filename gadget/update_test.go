@@ -380,6 +380,25 @@ func (u *updateTestSuite) TestCanUpdateID(c *C) {
 	u.testCanUpdate(c, cases)
 }
 
+func (u *updateTestSuite) TestCanUpdateSlot(c *C) {
+	slotA := &gadget.VolumeSlot{Group: "bootloader", Name: "a"}
+	cases := []canUpdateTestCase{
+		{
+			from: gadget.VolumeStructure{Type: "bare", Slot: slotA, Offset: asOffsetPtr(0), EnclosingVolume: &gadget.Volume{}},
+			to:   gadget.VolumeStructure{Type: "bare", Slot: &gadget.VolumeSlot{Group: "bootloader", Name: "a"}, Offset: asOffsetPtr(0), EnclosingVolume: &gadget.Volume{}},
+		}, {
+			from: gadget.VolumeStructure{Type: "bare", Offset: asOffsetPtr(0), EnclosingVolume: &gadget.Volume{}},
+			to:   gadget.VolumeStructure{Type: "bare", Slot: slotA, Offset: asOffsetPtr(0), EnclosingVolume: &gadget.Volume{}},
+			err:  `cannot change structure slot from none to "a" of group "bootloader"`,
+		}, {
+			from: gadget.VolumeStructure{Type: "bare", Slot: slotA, Offset: asOffsetPtr(0), EnclosingVolume: &gadget.Volume{}},
+			to:   gadget.VolumeStructure{Type: "bare", Slot: &gadget.VolumeSlot{Group: "bootloader", Name: "b"}, Offset: asOffsetPtr(0), EnclosingVolume: &gadget.Volume{}},
+			err:  `cannot change structure slot from "a" of group "bootloader" to "b" of group "bootloader"`,
+		},
+	}
+	u.testCanUpdate(c, cases)
+}
+
 func (u *updateTestSuite) TestCanUpdateBareOrFilesystem(c *C) {
 	mokVol := &gadget.Volume{}
 	partFsVol := &gadget.Volume{Partial: []gadget.PartialProperty{gadget.PartialFilesystem}}
@@ -23,6 +23,7 @@ import (
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/gadget"
 	"github.com/snapcore/snapd/image/preseed"
+	"github.com/snapcore/snapd/seed"
 	"github.com/snapcore/snapd/store/tooling"
 	"github.com/snapcore/snapd/testutil"
 )
@@ -65,3 +66,9 @@ func MockSetupSeed(f func(tsto *tooling.ToolingStore, model *asserts.Model, opts
 	setupSeed = f
 	return r
 }
+
+func MockSeedOpen(f func(seedDir, label string) (seed.Seed, error)) (restore func()) {
+	r := testutil.Backup(&seedOpen)
+	seedOpen = f
+	return r
+}
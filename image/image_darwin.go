@@ -21,8 +21,30 @@ package image
 
 import (
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/seed/seedwriter"
 )
 
 func Prepare(opts *Options) error {
 	return osutil.ErrDarwin
 }
+
+type VerifyOptions struct {
+	PrepareDir  string
+	SystemLabel string
+	Manifest    *seedwriter.Manifest
+}
+
+type SeedDrift struct {
+	Kind     string
+	Name     string
+	Expected string
+	Found    string
+}
+
+func (d *SeedDrift) String() string {
+	return ""
+}
+
+func VerifySeed(opts *VerifyOptions) ([]*SeedDrift, error) {
+	return nil, osutil.ErrDarwin
+}
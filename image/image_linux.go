@@ -371,6 +371,7 @@ func newImageSeeder(tsto *tooling.ToolingStore, model *asserts.Model, opts *Opti
 		DefaultChannel:    opts.Channel,
 		Manifest:          opts.SeedManifest,
 		ManifestPath:      opts.SeedManifestPath,
+		SBOMPath:          opts.SeedSBOMPath,
 		EnforceValidation: opts.Customizations.Validation != "ignore",
 
 		TestSkipCopyUnverifiedModel: osutil.GetenvBool("UBUNTU_IMAGE_SKIP_COPY_UNVERIFIED_MODEL"),
@@ -60,6 +60,9 @@ type Options struct {
 	// SeedManifestPath if set, specifies the file path where the
 	// seed.manifest file should be written.
 	SeedManifestPath string
+	// SeedSBOMPath if set, specifies the file path where an SPDX SBOM
+	// (JSON) describing the seed contents should be written.
+	SeedSBOMPath string
 
 	// WideCohortKey can be used to supply a cohort covering all
 	// the snaps in the image, there is no generally suppported API
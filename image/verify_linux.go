@@ -0,0 +1,338 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package image
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/seed"
+	"github.com/snapcore/snapd/seed/seedwriter"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/timings"
+)
+
+var seedOpen = seed.Open
+
+// VerifyOptions holds the options for VerifySeed.
+type VerifyOptions struct {
+	// PrepareDir is the directory that was used as target by
+	// Prepare when building the image.
+	PrepareDir string
+	// SystemLabel selects the system to verify for UC20+ seeds, it
+	// can be left empty if the seed holds a single system.
+	SystemLabel string
+	// Manifest is the manifest the seed is verified against.
+	Manifest *seedwriter.Manifest
+}
+
+// SeedDrift describes a difference between a seed and a manifest. Either
+// of Expected or Found is empty if the entry is missing from the seed or
+// not listed in the manifest respectively.
+type SeedDrift struct {
+	// Kind is one of "model", "snap", "component" or "validation-set".
+	Kind string
+	Name string
+	// Expected is the model, revision or sequence from the manifest.
+	Expected string
+	// Found is the model, revision or sequence found in the seed.
+	Found string
+}
+
+func (d *SeedDrift) String() string {
+	switch {
+	case d.Found == "":
+		return fmt.Sprintf("%s %q: %s from manifest is missing from the seed", d.Kind, d.Name, d.Expected)
+	case d.Expected == "":
+		return fmt.Sprintf("%s %q: %s in the seed is not in the manifest", d.Kind, d.Name, d.Found)
+	default:
+		return fmt.Sprintf("%s %q: seed has %s but manifest requires %s", d.Kind, d.Name, d.Found, d.Expected)
+	}
+}
+
+func revisionDesc(rev snap.Revision) string {
+	return fmt.Sprintf("revision %s", rev)
+}
+
+func modelDesc(brandID, model string, revision int) string {
+	return fmt.Sprintf("%s/%s revision %d", brandID, model, revision)
+}
+
+func sequenceDesc(seq int, pinned bool) string {
+	if pinned {
+		return fmt.Sprintf("sequence %d (pinned)", seq)
+	}
+	return fmt.Sprintf("sequence %d", seq)
+}
+
+// findPrepareSeed locates the seed written by Prepare into prepareDir,
+// returning the seed directory and for UC20+ seeds the system label.
+func findPrepareSeed(prepareDir, label string) (seedDir, sysLabel string, err error) {
+	systemSeedDir := filepath.Join(prepareDir, "system-seed")
+	if osutil.IsDirectory(filepath.Join(systemSeedDir, "systems")) {
+		if label != "" {
+			return systemSeedDir, label, nil
+		}
+		systems, err := filepath.Glob(filepath.Join(systemSeedDir, "systems", "*"))
+		if err != nil {
+			return "", "", err
+		}
+		switch len(systems) {
+		case 0:
+			return "", "", fmt.Errorf("cannot find any system in %s", systemSeedDir)
+		case 1:
+			return systemSeedDir, filepath.Base(systems[0]), nil
+		default:
+			return "", "", fmt.Errorf("cannot select a system to verify, %s holds %d systems", systemSeedDir, len(systems))
+		}
+	}
+	if label != "" {
+		return "", "", fmt.Errorf("cannot use a system label, no UC20+ seed found in %s", prepareDir)
+	}
+
+	// Core 16/18 images are written under image/, classic ones
+	// directly into the prepare dir
+	for _, rootDir := range []string{filepath.Join(prepareDir, "image"), prepareDir} {
+		seedDir := dirs.SnapSeedDirUnder(rootDir)
+		if osutil.IsDirectory(seedDir) {
+			return seedDir, "", nil
+		}
+	}
+	return "", "", fmt.Errorf("cannot find a seed in %s", prepareDir)
+}
+
+// VerifySeed checks the seed previously built by Prepare into
+// opts.PrepareDir against opts.Manifest. The seed assertions are
+// cross-checked and the snaps and components verified against them
+// while loading, any error doing so is returned as such. The model
+// assertion, snap and component revisions and validation set sequences
+// that differ from the manifest are returned as drift. The model is only
+// compared if the manifest records one, older manifests do not.
+func VerifySeed(opts *VerifyOptions) ([]*SeedDrift, error) {
+	if opts.Manifest == nil {
+		return nil, fmt.Errorf("internal error: cannot verify a seed without a manifest")
+	}
+
+	seedDir, label, err := findPrepareSeed(opts.PrepareDir, opts.SystemLabel)
+	if err != nil {
+		return nil, err
+	}
+	sd, err := seedOpen(seedDir, label)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		Backstore: asserts.NewMemoryBackstore(),
+		Trusted:   trusted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	commitTo := func(b *asserts.Batch) error {
+		return b.CommitTo(db, nil)
+	}
+	if err := sd.LoadAssertions(db, commitTo); err != nil {
+		return nil, fmt.Errorf("cannot load seed assertions: %v", err)
+	}
+	tm := timings.New(nil)
+	if err := sd.LoadMeta(seed.AllModes, nil, tm); err != nil {
+		return nil, fmt.Errorf("cannot load seed metadata: %v", err)
+	}
+
+	var drift []*SeedDrift
+
+	if allowed := opts.Manifest.AllowedModel(); allowed != nil {
+		model := sd.Model()
+		if allowed.BrandID != model.BrandID() || allowed.Model != model.Model() || allowed.Revision != model.Revision() {
+			drift = append(drift, &SeedDrift{
+				Kind:     "model",
+				Name:     allowed.Unique(),
+				Expected: modelDesc(allowed.BrandID, allowed.Model, allowed.Revision),
+				Found:    modelDesc(model.BrandID(), model.Model(), model.Revision()),
+			})
+		}
+	}
+
+	// validation sets, snaps controlled by them are not listed
+	// in the manifest but have their revisions checked against
+	// the validation set assertions in the seed
+	vsDrift, vsRevisions, err := verifySeedValidationSets(db, sd.Model(), opts.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	drift = append(drift, vsDrift...)
+
+	seedSnaps := make(map[string]snap.Revision)
+	seedComps := make(map[string]snap.Revision)
+	err = sd.Iter(func(sn *seed.Snap) error {
+		seedSnaps[sn.SnapName()] = sn.SideInfo.Revision
+		for _, comp := range sn.Components {
+			seedComps[comp.CompSideInfo.Component.String()] = comp.CompSideInfo.Revision
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, allowed := range opts.Manifest.AllowedSnapRevisions() {
+		rev, ok := seedSnaps[allowed.SnapName]
+		if !ok {
+			drift = append(drift, &SeedDrift{Kind: "snap", Name: allowed.SnapName, Expected: revisionDesc(allowed.Revision)})
+			continue
+		}
+		if rev != allowed.Revision {
+			drift = append(drift, &SeedDrift{Kind: "snap", Name: allowed.SnapName, Expected: revisionDesc(allowed.Revision), Found: revisionDesc(rev)})
+		}
+	}
+	for _, name := range sortedKeys(seedSnaps) {
+		rev := seedSnaps[name]
+		if !opts.Manifest.AllowedSnapRevision(name).Unset() {
+			// already checked above
+			continue
+		}
+		vsRev, ok := vsRevisions[name]
+		switch {
+		case !ok:
+			drift = append(drift, &SeedDrift{Kind: "snap", Name: name, Found: revisionDesc(rev)})
+		case vsRev != rev:
+			drift = append(drift, &SeedDrift{Kind: "snap", Name: name, Expected: revisionDesc(vsRev), Found: revisionDesc(rev)})
+		}
+	}
+
+	manifestComps := make(map[string]bool)
+	for _, allowed := range opts.Manifest.AllowedComponentRevisions() {
+		name := allowed.Component.String()
+		manifestComps[name] = true
+		rev, ok := seedComps[name]
+		if !ok {
+			drift = append(drift, &SeedDrift{Kind: "component", Name: name, Expected: revisionDesc(allowed.Revision)})
+			continue
+		}
+		if rev != allowed.Revision {
+			drift = append(drift, &SeedDrift{Kind: "component", Name: name, Expected: revisionDesc(allowed.Revision), Found: revisionDesc(rev)})
+		}
+	}
+	for _, name := range sortedKeys(seedComps) {
+		if !manifestComps[name] {
+			drift = append(drift, &SeedDrift{Kind: "component", Name: name, Found: revisionDesc(seedComps[name])})
+		}
+	}
+
+	return drift, nil
+}
+
+func sortedKeys(m map[string]snap.Revision) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// verifySeedValidationSets compares the validation sets of the seed model
+// with the manifest, it also returns the snap revisions required by the
+// validation sets found in the seed.
+func verifySeedValidationSets(db asserts.RODatabase, model *asserts.Model, manifest *seedwriter.Manifest) ([]*SeedDrift, map[string]snap.Revision, error) {
+	var drift []*SeedDrift
+	vsRevisions := make(map[string]snap.Revision)
+
+	allowed := make(map[string]*seedwriter.ManifestValidationSet)
+	for _, vs := range manifest.AllowedValidationSets() {
+		allowed[vs.Unique()] = vs
+	}
+
+	for _, mvs := range model.ValidationSets() {
+		name := fmt.Sprintf("%s/%s", mvs.AccountID, mvs.Name)
+		vsa, err := findSeedValidationSet(db, mvs)
+		if err != nil {
+			return nil, nil, err
+		}
+		if vsa == nil {
+			if vs, ok := allowed[name]; ok {
+				drift = append(drift, &SeedDrift{Kind: "validation-set", Name: name, Expected: sequenceDesc(vs.Sequence, vs.Pinned)})
+			}
+			delete(allowed, name)
+			continue
+		}
+
+		pinned := mvs.Sequence > 0
+		found := sequenceDesc(vsa.Sequence(), pinned)
+		if vs, ok := allowed[name]; !ok {
+			drift = append(drift, &SeedDrift{Kind: "validation-set", Name: name, Found: found})
+		} else if vs.Sequence != vsa.Sequence() || vs.Pinned != pinned {
+			drift = append(drift, &SeedDrift{Kind: "validation-set", Name: name, Expected: sequenceDesc(vs.Sequence, vs.Pinned), Found: found})
+		}
+		delete(allowed, name)
+
+		for _, sn := range vsa.Snaps() {
+			if sn.Presence == asserts.PresenceInvalid || sn.Revision <= 0 {
+				continue
+			}
+			if _, ok := vsRevisions[sn.SnapName()]; !ok {
+				vsRevisions[sn.SnapName()] = snap.R(sn.Revision)
+			}
+		}
+	}
+
+	// validation sets from the manifest the model does not know about
+	for _, vs := range manifest.AllowedValidationSets() {
+		if _, ok := allowed[vs.Unique()]; ok {
+			drift = append(drift, &SeedDrift{Kind: "validation-set", Name: vs.Unique(), Expected: sequenceDesc(vs.Sequence, vs.Pinned)})
+		}
+	}
+	return drift, vsRevisions, nil
+}
+
+// findSeedValidationSet returns the validation set assertion for the model
+// validation set that is present in the seed, or nil if there is none.
+func findSeedValidationSet(db asserts.RODatabase, mvs *asserts.ModelValidationSet) (*asserts.ValidationSet, error) {
+	headers := map[string]string{
+		"series":     release.Series,
+		"account-id": mvs.AccountID,
+		"name":       mvs.Name,
+	}
+	if mvs.Sequence > 0 {
+		headers["sequence"] = fmt.Sprintf("%d", mvs.Sequence)
+	}
+	as, err := db.FindMany(asserts.ValidationSetType, headers)
+	if errors.Is(err, &asserts.NotFoundError{}) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var latest *asserts.ValidationSet
+	for _, a := range as {
+		vsa := a.(*asserts.ValidationSet)
+		if latest == nil || vsa.Sequence() > latest.Sequence() {
+			latest = vsa
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,288 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package image_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/image"
+	"github.com/snapcore/snapd/seed"
+	"github.com/snapcore/snapd/seed/seedwriter"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/naming"
+	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timings"
+)
+
+type verifySuite struct {
+	testutil.BaseTest
+
+	storeSigning *assertstest.StoreStack
+	prepareDir   string
+}
+
+var _ = Suite(&verifySuite{})
+
+func (s *verifySuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.storeSigning = assertstest.NewStoreStack("canonical", nil)
+	s.AddCleanup(image.MockTrusted(s.storeSigning.Trusted))
+	s.prepareDir = c.MkDir()
+}
+
+// fakeSeed implements the parts of seed.Seed used by image.VerifySeed
+type fakeSeed struct {
+	seed.Seed
+
+	model   *asserts.Model
+	snaps   []*seed.Snap
+	asserts []asserts.Assertion
+
+	loadMetaErr error
+}
+
+func (fs *fakeSeed) LoadAssertions(db asserts.RODatabase, commitTo func(*asserts.Batch) error) error {
+	batch := asserts.NewBatch(nil)
+	for _, a := range fs.asserts {
+		if err := batch.Add(a); err != nil {
+			return err
+		}
+	}
+	return commitTo(batch)
+}
+
+func (fs *fakeSeed) LoadMeta(mode string, handler seed.ContainerHandler, tm timings.Measurer) error {
+	return fs.loadMetaErr
+}
+
+func (fs *fakeSeed) Model() *asserts.Model {
+	return fs.model
+}
+
+func (fs *fakeSeed) Iter(f func(sn *seed.Snap) error) error {
+	for _, sn := range fs.snaps {
+		if err := f(sn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *verifySuite) model(c *C, vsSequence string) *asserts.Model {
+	vs := map[string]any{
+		"account-id": "canonical",
+		"name":       "base-set",
+		"mode":       "enforce",
+	}
+	if vsSequence != "" {
+		vs["sequence"] = vsSequence
+	}
+	headers := map[string]any{
+		"type":            "model",
+		"authority-id":    "canonical",
+		"series":          "16",
+		"brand-id":        "canonical",
+		"model":           "my-model",
+		"architecture":    "amd64",
+		"base":            "core22",
+		"grade":           "dangerous",
+		"validation-sets": []any{vs},
+		"snaps": []any{
+			map[string]any{
+				"name":            "pc-kernel",
+				"id":              "pckernelidididididididididididid",
+				"type":            "kernel",
+				"default-channel": "22",
+			},
+			map[string]any{
+				"name":            "pc",
+				"id":              "pcididididididididididididididid",
+				"type":            "gadget",
+				"default-channel": "22",
+			},
+		},
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+	return assertstest.FakeAssertion(headers).(*asserts.Model)
+}
+
+func (s *verifySuite) validationSet(c *C, sequence string) *asserts.ValidationSet {
+	vs, err := s.storeSigning.Sign(asserts.ValidationSetType, map[string]any{
+		"type":         "validation-set",
+		"authority-id": "canonical",
+		"series":       "16",
+		"account-id":   "canonical",
+		"name":         "base-set",
+		"sequence":     sequence,
+		"snaps": []any{
+			map[string]any{
+				"name":     "pc-kernel",
+				"id":       "pckernelidididididididididididid",
+				"presence": "required",
+				"revision": "7",
+			},
+		},
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	return vs.(*asserts.ValidationSet)
+}
+
+func (s *verifySuite) mockUC20Seed(c *C, fs *fakeSeed) {
+	c.Assert(os.MkdirAll(filepath.Join(s.prepareDir, "system-seed", "systems", "20260101"), 0755), IsNil)
+	s.AddCleanup(image.MockSeedOpen(func(seedDir, label string) (seed.Seed, error) {
+		c.Check(seedDir, Equals, filepath.Join(s.prepareDir, "system-seed"))
+		c.Check(label, Equals, "20260101")
+		return fs, nil
+	}))
+}
+
+func (s *verifySuite) fakeSeed(c *C, vsSequence string) *fakeSeed {
+	return &fakeSeed{
+		model:   s.model(c, ""),
+		asserts: []asserts.Assertion{s.storeSigning.StoreAccountKey(""), s.validationSet(c, vsSequence)},
+		snaps: []*seed.Snap{
+			{SideInfo: &snap.SideInfo{RealName: "pc-kernel", Revision: snap.R(7)}},
+			{
+				SideInfo: &snap.SideInfo{RealName: "pc", Revision: snap.R(128)},
+				Components: []seed.Component{{
+					CompSideInfo: *snap.NewComponentSideInfo(naming.NewComponentRef("pc", "kmod"), snap.R(3)),
+				}},
+			},
+		},
+	}
+}
+
+func (s *verifySuite) manifest(c *C) *seedwriter.Manifest {
+	manifest := seedwriter.NewManifest()
+	c.Assert(manifest.SetAllowedModel("canonical", "my-model", 0), IsNil)
+	c.Assert(manifest.SetAllowedValidationSet("canonical", "base-set", 2, false), IsNil)
+	c.Assert(manifest.SetAllowedSnapRevision("pc", snap.R(128)), IsNil)
+	c.Assert(manifest.SetAllowedComponentRevision(naming.NewComponentRef("pc", "kmod"), snap.R(3)), IsNil)
+	return manifest
+}
+
+func (s *verifySuite) TestVerifySeedNoDrift(c *C) {
+	s.mockUC20Seed(c, s.fakeSeed(c, "2"))
+
+	drift, err := image.VerifySeed(&image.VerifyOptions{
+		PrepareDir: s.prepareDir,
+		Manifest:   s.manifest(c),
+	})
+	c.Assert(err, IsNil)
+	c.Check(drift, HasLen, 0)
+}
+
+func (s *verifySuite) TestVerifySeedManifestWithoutModel(c *C) {
+	s.mockUC20Seed(c, s.fakeSeed(c, "2"))
+
+	// manifests written before the model was recorded
+	manifest := seedwriter.NewManifest()
+	c.Assert(manifest.SetAllowedValidationSet("canonical", "base-set", 2, false), IsNil)
+	c.Assert(manifest.SetAllowedSnapRevision("pc", snap.R(128)), IsNil)
+	c.Assert(manifest.SetAllowedComponentRevision(naming.NewComponentRef("pc", "kmod"), snap.R(3)), IsNil)
+
+	drift, err := image.VerifySeed(&image.VerifyOptions{
+		PrepareDir: s.prepareDir,
+		Manifest:   manifest,
+	})
+	c.Assert(err, IsNil)
+	c.Check(drift, HasLen, 0)
+}
+
+func (s *verifySuite) TestVerifySeedDrift(c *C) {
+	fs := s.fakeSeed(c, "3")
+	// pc-kernel is controlled by the validation set
+	fs.snaps[0].SideInfo.Revision = snap.R(8)
+	fs.snaps[1].Components[0].CompSideInfo.Revision = snap.R(4)
+	fs.snaps = append(fs.snaps, &seed.Snap{SideInfo: &snap.SideInfo{RealName: "extra", Revision: snap.R(-1)}})
+	s.mockUC20Seed(c, fs)
+
+	manifest := seedwriter.NewManifest()
+	c.Assert(manifest.SetAllowedModel("canonical", "my-model", 2), IsNil)
+	c.Assert(manifest.SetAllowedValidationSet("canonical", "base-set", 2, false), IsNil)
+	c.Assert(manifest.SetAllowedSnapRevision("pc", snap.R(128)), IsNil)
+	c.Assert(manifest.SetAllowedComponentRevision(naming.NewComponentRef("pc", "kmod"), snap.R(3)), IsNil)
+	c.Assert(manifest.SetAllowedSnapRevision("missing", snap.R(1)), IsNil)
+
+	drift, err := image.VerifySeed(&image.VerifyOptions{
+		PrepareDir: s.prepareDir,
+		Manifest:   manifest,
+	})
+	c.Assert(err, IsNil)
+	var msgs []string
+	for _, d := range drift {
+		msgs = append(msgs, d.String())
+	}
+	c.Check(msgs, DeepEquals, []string{
+		`model "canonical/my-model": seed has canonical/my-model revision 0 but manifest requires canonical/my-model revision 2`,
+		`validation-set "canonical/base-set": seed has sequence 3 but manifest requires sequence 2`,
+		`snap "missing": revision 1 from manifest is missing from the seed`,
+		`snap "extra": revision x1 in the seed is not in the manifest`,
+		`snap "pc-kernel": seed has revision 8 but manifest requires revision 7`,
+		`component "pc+kmod": seed has revision 4 but manifest requires revision 3`,
+	})
+}
+
+func (s *verifySuite) TestVerifySeedLoadError(c *C) {
+	fs := s.fakeSeed(c, "2")
+	fs.loadMetaErr = errors.New("cannot validate snap \"pc\" for model: boom")
+	s.mockUC20Seed(c, fs)
+
+	_, err := image.VerifySeed(&image.VerifyOptions{
+		PrepareDir: s.prepareDir,
+		Manifest:   s.manifest(c),
+	})
+	c.Assert(err, ErrorMatches, `cannot load seed metadata: cannot validate snap "pc" for model: boom`)
+}
+
+func (s *verifySuite) TestVerifySeedFindSeed(c *C) {
+	restore := image.MockSeedOpen(func(seedDir, label string) (seed.Seed, error) {
+		return nil, errors.New("opened")
+	})
+	defer restore()
+
+	opts := &image.VerifyOptions{PrepareDir: s.prepareDir, Manifest: seedwriter.NewManifest()}
+	_, err := image.VerifySeed(opts)
+	c.Check(err, ErrorMatches, `cannot find a seed in .*`)
+
+	// core 16/18
+	c.Assert(os.MkdirAll(dirs.SnapSeedDirUnder(filepath.Join(s.prepareDir, "image")), 0755), IsNil)
+	_, err = image.VerifySeed(opts)
+	c.Check(err, ErrorMatches, `opened`)
+
+	// UC20+ with multiple systems
+	for _, label := range []string{"20260101", "20260102"} {
+		c.Assert(os.MkdirAll(filepath.Join(s.prepareDir, "system-seed", "systems", label), 0755), IsNil)
+	}
+	_, err = image.VerifySeed(opts)
+	c.Check(err, ErrorMatches, `cannot select a system to verify, .*/system-seed holds 2 systems`)
+	opts.SystemLabel = "20260102"
+	_, err = image.VerifySeed(opts)
+	c.Check(err, ErrorMatches, `opened`)
+}
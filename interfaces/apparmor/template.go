@@ -338,6 +338,12 @@ var templateCommon = `
   # Read-only of snapd restart state for snapctl specifically
   /var/lib/snapd/maintenance.json r,
 
+  # Read-only of the certificate database snapd maintains for this snap,
+  # passed to it through SSL_CERT_FILE and SSL_CERT_DIR. The pointer to the
+  # database is a symlink to immutable generations of this snap only.
+  /var/lib/snapd/pki/v1/snap-published/@{SNAP_INSTANCE_NAME}/*/ r,
+  /var/lib/snapd/pki/v1/snap-published/@{SNAP_INSTANCE_NAME}/*/** r,
+
   # Read-only for the install directory
   # bind mount used here (see 'parallel installs', above)
   @{INSTALL_DIR}/{@{SNAP_NAME},@{SNAP_INSTANCE_NAME}}/                   r,
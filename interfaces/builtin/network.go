@@ -19,6 +19,15 @@
 
 package builtin
 
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/udev"
+	"github.com/snapcore/snapd/sandbox/ebpf"
+	"github.com/snapcore/snapd/snap"
+)
+
 const networkSummary = `allows access to the network`
 
 const networkBaseDeclarationSlots = `
@@ -90,8 +99,67 @@ socket AF_NETLINK - NETLINK_ROUTE
 socket AF_CONN
 `
 
+type networkInterface struct {
+	commonInterface
+}
+
+// NetworkEgressRules returns the destinations which the apps of a network
+// plug are restricted to send traffic to, as declared with the optional
+// "egress" attribute of the plug. The attribute is a list of <ip>[:<port>]
+// entries, restricted is false if the plug does not declare it.
+//
+// The udev backend enforces the rules with a network filter attached to the
+// apps when they start, on systems with cgroup v2. Traffic to the loopback
+// addresses, to DNS servers and replies to peers are always allowed.
+func NetworkEgressRules(plug interfaces.Attrer) (rules []ebpf.NetworkRule, restricted bool, err error) {
+	value, ok := plug.Lookup("egress")
+	if !ok {
+		return nil, false, nil
+	}
+	egress, ok := value.([]any)
+	if !ok {
+		return nil, false, fmt.Errorf(`network "egress" attribute must be a list of strings`)
+	}
+	if len(egress) > ebpf.NetworkMaxRules {
+		return nil, false, fmt.Errorf(`network "egress" attribute cannot have more than %d entries`, ebpf.NetworkMaxRules)
+	}
+	for _, e := range egress {
+		s, ok := e.(string)
+		if !ok {
+			return nil, false, fmt.Errorf(`network "egress" attribute must be a list of strings`)
+		}
+		rule, err := ebpf.ParseNetworkRule(s)
+		if err != nil {
+			return nil, false, fmt.Errorf(`network "egress" attribute is invalid: %v`, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, true, nil
+}
+
+func (iface *networkInterface) BeforePreparePlug(plug *snap.PlugInfo) error {
+	_, _, err := NetworkEgressRules(plug)
+	return err
+}
+
+func (iface *networkInterface) UDevConnectedPlug(spec *udev.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	if err := iface.commonInterface.UDevConnectedPlug(spec, plug, slot); err != nil {
+		return err
+	}
+	rules, restricted, err := NetworkEgressRules(plug)
+	if err != nil {
+		return err
+	}
+	if restricted {
+		spec.RestrictNetworkEgress(rules)
+	} else {
+		spec.AllowNetworkEgress()
+	}
+	return nil
+}
+
 func init() {
-	registerIface(&commonInterface{
+	registerIface(&networkInterface{commonInterface{
 		name:                  "network",
 		summary:               networkSummary,
 		implicitOnCore:        true,
@@ -99,5 +167,5 @@ func init() {
 		baseDeclarationSlots:  networkBaseDeclarationSlots,
 		connectedPlugAppArmor: networkConnectedPlugAppArmor,
 		connectedPlugSecComp:  networkConnectedPlugSecComp,
-	})
+	}})
 }
@@ -20,13 +20,18 @@
 package builtin_test
 
 import (
+	"fmt"
+	"regexp"
+
 	. "gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/builtin"
 	"github.com/snapcore/snapd/interfaces/seccomp"
+	"github.com/snapcore/snapd/interfaces/udev"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -74,6 +79,107 @@ func (s *NetworkInterfaceSuite) TestSanitizePlug(c *C) {
 	c.Assert(interfaces.BeforePreparePlug(s.iface, s.plugInfo), IsNil)
 }
 
+func (s *NetworkInterfaceSuite) TestSanitizePlugEgress(c *C) {
+	const mockSnapYaml = `name: other
+version: 1.0
+plugs:
+ net:
+  interface: network
+  egress: [10.0.0.1, "10.0.0.2:443", "[fd00::1]:53"]
+apps:
+ app:
+  command: foo
+  plugs: [net]
+`
+	plugInfo := MockPlug(c, mockSnapYaml, nil, "net")
+	c.Assert(interfaces.BeforePreparePlug(s.iface, plugInfo), IsNil)
+
+	rules, restricted, err := builtin.NetworkEgressRules(plugInfo)
+	c.Assert(err, IsNil)
+	c.Check(restricted, Equals, true)
+	c.Assert(rules, HasLen, 3)
+	c.Check(rules[0].String(), Equals, "10.0.0.1")
+	c.Check(rules[1].String(), Equals, "10.0.0.2:443")
+	c.Check(rules[2].String(), Equals, "[fd00::1]:53")
+
+	// no attribute, no restriction
+	rules, restricted, err = builtin.NetworkEgressRules(s.plugInfo)
+	c.Assert(err, IsNil)
+	c.Check(restricted, Equals, false)
+	c.Check(rules, HasLen, 0)
+}
+
+func (s *NetworkInterfaceSuite) TestSanitizePlugEgressErrors(c *C) {
+	for _, t := range []struct {
+		egress string
+		err    string
+	}{
+		{`10.0.0.1`, `network "egress" attribute must be a list of strings`},
+		{`[1]`, `network "egress" attribute must be a list of strings`},
+		{`[example.com]`, `network "egress" attribute is invalid: cannot parse network rule "example.com": "example.com" is not an IP address`},
+		{`["10.0.0.1:0"]`, `network "egress" attribute is invalid: cannot parse network rule "10.0.0.1:0": invalid port "0"`},
+	} {
+		mockSnapYaml := fmt.Sprintf(`name: other
+version: 1.0
+plugs:
+ net:
+  interface: network
+  egress: %s
+`, t.egress)
+		plugInfo := MockPlug(c, mockSnapYaml, nil, "net")
+		c.Check(interfaces.BeforePreparePlug(s.iface, plugInfo), ErrorMatches, regexp.QuoteMeta(t.err), Commentf(t.egress))
+	}
+}
+
+func (s *NetworkInterfaceSuite) TestUDevConnectedPlugEgress(c *C) {
+	const mockSnapYaml = `name: other
+version: 1.0
+plugs:
+ net:
+  interface: network
+  egress: [10.0.0.1, "10.0.0.2:443"]
+ net2:
+  interface: network
+  egress: ["[fd00::1]:53"]
+apps:
+ restricted:
+  command: foo
+  plugs: [net, net2]
+ unrestricted:
+  command: foo
+  plugs: [net, network]
+ other:
+  command: foo
+  plugs: [net2]
+`
+	info := snaptest.MockInfo(c, mockSnapYaml, nil)
+	appSet, err := interfaces.NewSnapAppSet(info, nil)
+	c.Assert(err, IsNil)
+
+	spec := udev.NewSpecification(appSet)
+	for _, name := range []string{"net", "net2", "network"} {
+		plug := interfaces.NewConnectedPlug(info.Plugs[name], appSet, nil, nil)
+		c.Assert(spec.AddConnectedPlug(s.iface, plug, s.slot), IsNil)
+	}
+
+	egress := spec.NetworkEgress()
+	c.Assert(egress, HasLen, 2)
+	var rules []string
+	for _, r := range egress["snap.other.restricted"] {
+		rules = append(rules, r.String())
+	}
+	c.Check(rules, DeepEquals, []string{"10.0.0.1", "10.0.0.2:443", "[fd00::1]:53"})
+	c.Assert(egress["snap.other.other"], HasLen, 1)
+	c.Check(egress["snap.other.other"][0].String(), Equals, "[fd00::1]:53")
+	// no network filter for apps bound to a plug without restriction
+	c.Check(egress["snap.other.unrestricted"], IsNil)
+
+	// nothing is restricted without the attribute
+	spec = udev.NewSpecification(s.plug.AppSet())
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.slot), IsNil)
+	c.Check(spec.NetworkEgress(), HasLen, 0)
+}
+
 func (s *NetworkInterfaceSuite) TestUsedSecuritySystems(c *C) {
 	// connected plugs have a non-nil security snippet for apparmor
 	apparmorSpec := apparmor.NewSpecification(s.plug.AppSet())
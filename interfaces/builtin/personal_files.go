@@ -27,6 +27,7 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/mount"
+	"github.com/snapcore/snapd/snap"
 )
 
 const personalFilesSummary = `allows access to personal files or directories`
@@ -51,6 +52,10 @@ const personalFilesConnectedPlugAppArmor = `
 # This is restricted because it gives file access to arbitrary locations.
 `
 
+// personalFilesMetadataTag associates AppArmor requests for personal files
+// with the personal-files interface when prompting.
+var personalFilesMetadataTag = apparmor.RegisterMetadataTagWithInterface("personal-files", "personal-files")
+
 type personalFilesInterface struct {
 	commonFilesInterface
 }
@@ -65,6 +70,32 @@ func validateSinglePathHome(np string) error {
 	return nil
 }
 
+// DetectPersonalFilesFromPath returns true if the given path corresponds to
+// an AppArmor rule of one of the given personal-files plugs, with $HOME
+// referring to the given home directory.
+//
+// XXX: this is only necessary until metadata tags are fully supported by the
+// AppArmor parser and kernel. Then, this function should be removed.
+func DetectPersonalFilesFromPath(path, home string, plugs []*snap.PlugInfo) bool {
+	if home == "" {
+		return false
+	}
+	for _, plug := range plugs {
+		for _, attr := range []string{"read", "write"} {
+			// BeforePreparePlug should prevent errors
+			paths, _ := stringListAttribute(plug, attr)
+			for _, p := range paths {
+				p = filepath.Join(home, strings.TrimPrefix(p, "$HOME/"))
+				// the rules cover the path itself and anything below it
+				if path == p || strings.HasPrefix(path, p+"/") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 func init() {
 	registerIface(&personalFilesInterface{
 		commonFilesInterface{
@@ -76,8 +107,9 @@ func init() {
 				baseDeclarationPlugs: personalFilesBaseDeclarationPlugs,
 				baseDeclarationSlots: personalFilesBaseDeclarationSlots,
 			},
-			apparmorHeader:    personalFilesConnectedPlugAppArmor,
-			extraPathValidate: validateSinglePathHome,
+			apparmorHeader:     personalFilesConnectedPlugAppArmor,
+			extraPathValidate:  validateSinglePathHome,
+			promptMetadataTags: []apparmor.MetadataTag{personalFilesMetadataTag},
 		},
 	})
 }
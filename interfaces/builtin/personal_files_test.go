@@ -29,6 +29,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/builtin"
 	"github.com/snapcore/snapd/interfaces/mount"
 	"github.com/snapcore/snapd/osutil"
+	apparmor_sandbox "github.com/snapcore/snapd/sandbox/apparmor"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
@@ -73,6 +74,46 @@ func (s *personalFilesInterfaceSuite) TestName(c *C) {
 	c.Assert(s.iface.Name(), Equals, "personal-files")
 }
 
+func (s *personalFilesInterfaceSuite) TestConnectedPlugAppArmorMetadataTags(c *C) {
+	restore := apparmor_sandbox.MockFeatures([]string{"policy:notify:user:tags"}, nil, []string{"tags"}, nil)
+	defer restore()
+
+	apparmorSpec := apparmor.NewSpecification(s.plug.AppSet())
+	err := apparmorSpec.AddConnectedPlug(s.iface, s.plug, s.slot)
+	c.Assert(err, IsNil)
+	snippet := apparmorSpec.SnippetForTag("snap.other.app")
+	c.Check(snippet, testutil.Contains, "\ntags=(personal-files) {\n")
+	c.Check(snippet, testutil.Contains, `###PROMPT### owner "@{HOME}/.read-dir{,/,/**}" rk,`)
+
+	iface, ok := apparmor.InterfaceForMetadataTag("personal-files")
+	c.Check(ok, Equals, true)
+	c.Check(iface, Equals, "personal-files")
+}
+
+func (s *personalFilesInterfaceSuite) TestDetectPersonalFilesFromPath(c *C) {
+	plugs := []*snap.PlugInfo{s.plugInfo}
+	for _, tc := range []struct {
+		path     string
+		home     string
+		expected bool
+	}{
+		{"/home/test/.read-dir", "/home/test", true},
+		{"/home/test/.read-dir/", "/home/test", true},
+		{"/home/test/.read-dir/foo/bar", "/home/test", true},
+		{"/home/test/.write-file", "/home/test", true},
+		{"/home/test/.local/share/dir1/dir2/target/foo", "/home/test", true},
+		{"/root/.read-file", "/root", true},
+		{"/home/test/.read-dir-other", "/home/test", false},
+		{"/home/test/.local/share", "/home/test", false},
+		{"/home/other/.read-dir", "/home/test", false},
+		{"/home/test/foo", "/home/test", false},
+		{"/home/test/.read-dir", "", false},
+	} {
+		c.Check(builtin.DetectPersonalFilesFromPath(tc.path, tc.home, plugs), Equals, tc.expected, Commentf("path: %s, home: %s", tc.path, tc.home))
+	}
+	c.Check(builtin.DetectPersonalFilesFromPath("/home/test/.read-dir", "/home/test", nil), Equals, false)
+}
+
 func (s *personalFilesInterfaceSuite) TestConnectedPlugAppArmorHappy(c *C) {
 	apparmorSpec := apparmor.NewSpecification(s.plug.AppSet())
 	err := apparmorSpec.AddConnectedPlug(s.iface, s.plug, s.slot)
@@ -82,13 +123,13 @@ func (s *personalFilesInterfaceSuite) TestConnectedPlugAppArmorHappy(c *C) {
 # Description: Can access specific personal files or directories in the 
 # users's home directory.
 # This is restricted because it gives file access to arbitrary locations.
-owner "@{HOME}/.read-dir{,/,/**}" rk,
-owner "@{HOME}/.read-file{,/,/**}" rk,
-owner "@{HOME}/.local/share/target{,/,/**}" rk,
-owner "@{HOME}/.write-dir{,/,/**}" rwkl,
-owner "@{HOME}/.write-file{,/,/**}" rwkl,
-owner "@{HOME}/.local/share/target{,/,/**}" rwkl,
-owner "@{HOME}/.local/share/dir1/dir2/target{,/,/**}" rwkl,
+###PROMPT### owner "@{HOME}/.read-dir{,/,/**}" rk,
+###PROMPT### owner "@{HOME}/.read-file{,/,/**}" rk,
+###PROMPT### owner "@{HOME}/.local/share/target{,/,/**}" rk,
+###PROMPT### owner "@{HOME}/.write-dir{,/,/**}" rwkl,
+###PROMPT### owner "@{HOME}/.write-file{,/,/**}" rwkl,
+###PROMPT### owner "@{HOME}/.local/share/target{,/,/**}" rwkl,
+###PROMPT### owner "@{HOME}/.local/share/dir1/dir2/target{,/,/**}" rwkl,
 `)
 
 	c.Check("\n"+strings.Join(apparmorSpec.UpdateNS(), "\n"), Equals, `
@@ -19,6 +19,13 @@
 
 package builtin
 
+import (
+	"strings"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+)
+
 const removableMediaSummary = `allows access to mounted removable storage`
 
 const removableMediaBaseDeclarationSlots = `
@@ -41,21 +48,50 @@ const removableMediaConnectedPlugAppArmor = `
 
 # Mount points could be in /run/media/<user>/* or /media/<user>/*
 /{,run/}media/*/ r,
-/{,run/}media/*/** mrwklix,
+###PROMPT### /{,run/}media/*/** mrwklix,
 
 # Allow read-only access to /mnt to enumerate items.
 /mnt/ r,
 # Allow write access to anything under /mnt
-/mnt/** mrwklix,
+###PROMPT### /mnt/** mrwklix,
 `
 
+// removableMediaMetadataTag associates AppArmor requests for removable media
+// with the removable-media interface when prompting.
+var removableMediaMetadataTag = apparmor.RegisterMetadataTagWithInterface("removable-media", "removable-media")
+
+type removableMediaInterface struct {
+	commonInterface
+}
+
+func (iface *removableMediaInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	spec.AddSnippet(apparmor.MetadataTagSnippet(removableMediaConnectedPlugAppArmor, []apparmor.MetadataTag{removableMediaMetadataTag}))
+	return nil
+}
+
+// DetectRemovableMediaFromPath returns true if the given path corresponds to
+// an AppArmor rule with the prompt prefix from the removable-media interface.
+//
+// XXX: this is only necessary until metadata tags are fully supported by the
+// AppArmor parser and kernel. Then, this function should be removed.
+func DetectRemovableMediaFromPath(path string) bool {
+	for _, prefix := range []string{"/media/", "/run/media/"} {
+		// The media directory itself and per-user directories are not
+		// prompted, only the mount points below them are.
+		if rest, ok := strings.CutPrefix(path, prefix); ok && strings.Contains(strings.TrimSuffix(rest, "/"), "/") {
+			return true
+		}
+	}
+	return strings.HasPrefix(path, "/mnt/") && path != "/mnt/"
+}
+
 func init() {
-	registerIface(&commonInterface{
+	registerIface(&removableMediaInterface{commonInterface{
 		name:                  "removable-media",
 		summary:               removableMediaSummary,
 		implicitOnCore:        true,
 		implicitOnClassic:     true,
 		baseDeclarationSlots:  removableMediaBaseDeclarationSlots,
 		connectedPlugAppArmor: removableMediaConnectedPlugAppArmor,
-	})
+	}})
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/builtin"
+	apparmor_sandbox "github.com/snapcore/snapd/sandbox/apparmor"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
 )
@@ -82,6 +83,49 @@ func (s *RemovableMediaInterfaceSuite) TestUsedSecuritySystems(c *C) {
 	c.Check(apparmorSpec.SnippetForTag("snap.client-snap.other"), testutil.Contains, "/mnt/** mrwklix,")
 }
 
+func (s *RemovableMediaInterfaceSuite) TestAppArmorPromptAndMetadataTags(c *C) {
+	apparmorSpec := apparmor.NewSpecification(s.plug.AppSet())
+	err := apparmorSpec.AddConnectedPlug(s.iface, s.plug, s.slot)
+	c.Assert(err, IsNil)
+	snippet := apparmorSpec.SnippetForTag("snap.client-snap.other")
+	c.Check(snippet, testutil.Contains, "###PROMPT### /{,run/}media/*/** mrwklix,")
+	c.Check(snippet, testutil.Contains, "###PROMPT### /mnt/** mrwklix,")
+	c.Check(snippet, Not(testutil.Contains), "tags=(")
+
+	restore := apparmor_sandbox.MockFeatures([]string{"policy:notify:user:tags"}, nil, []string{"tags"}, nil)
+	defer restore()
+
+	apparmorSpec = apparmor.NewSpecification(s.plug.AppSet())
+	err = apparmorSpec.AddConnectedPlug(s.iface, s.plug, s.slot)
+	c.Assert(err, IsNil)
+	c.Check(apparmorSpec.SnippetForTag("snap.client-snap.other"), testutil.Contains, "\ntags=(removable-media) {\n")
+
+	iface, ok := apparmor.InterfaceForMetadataTag("removable-media")
+	c.Check(ok, Equals, true)
+	c.Check(iface, Equals, "removable-media")
+}
+
+func (s *RemovableMediaInterfaceSuite) TestDetectRemovableMediaFromPath(c *C) {
+	for _, tc := range []struct {
+		path     string
+		expected bool
+	}{
+		{"/media/user/usb/file.txt", true},
+		{"/media/user/usb", true},
+		{"/run/media/user/usb/dir/", true},
+		{"/mnt/data", true},
+		{"/media/", false},
+		{"/media/user", false},
+		{"/media/user/", false},
+		{"/run/media/user/", false},
+		{"/mnt/", false},
+		{"/home/user/media/user/foo", false},
+		{"/dev/video0", false},
+	} {
+		c.Check(builtin.DetectRemovableMediaFromPath(tc.path), Equals, tc.expected, Commentf("path: %s", tc.path))
+	}
+}
+
 func (s *RemovableMediaInterfaceSuite) TestInterfaces(c *C) {
 	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
 }
@@ -0,0 +1,200 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/systemd"
+	"github.com/snapcore/snapd/snap"
+)
+
+const serviceOrderingSummary = `allows ordering services after the services of another snap`
+
+const serviceOrderingBaseDeclarationSlots = `
+  service-ordering:
+    allow-installation:
+      slot-snap-type:
+        - app
+    allow-auto-connection:
+      plug-publisher-id:
+        - $SLOT_PUBLISHER_ID
+`
+
+// serviceOrderingInterface orders the system services bound to the plug
+// after the system services bound to the connected slot, the slot services
+// are also pulled in when the plug services are started.
+type serviceOrderingInterface struct{}
+
+func (iface *serviceOrderingInterface) Name() string {
+	return "service-ordering"
+}
+
+func (iface *serviceOrderingInterface) StaticInfo() interfaces.StaticInfo {
+	return interfaces.StaticInfo{
+		Summary:              serviceOrderingSummary,
+		BaseDeclarationSlots: serviceOrderingBaseDeclarationSlots,
+	}
+}
+
+// systemServices returns the system services among the given apps, sorted
+// by name.
+func systemServices(apps map[string]*snap.AppInfo) []*snap.AppInfo {
+	var services []*snap.AppInfo
+	for _, app := range apps {
+		if app.IsService() && app.DaemonScope == snap.SystemDaemon {
+			services = append(services, app)
+		}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services
+}
+
+func (iface *serviceOrderingInterface) SystemdConnectedPlug(spec *systemd.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	slotServices := systemServices(slot.Apps())
+	if len(slotServices) == 0 {
+		return nil
+	}
+	units := make([]string, 0, len(slotServices))
+	for _, app := range slotServices {
+		units = append(units, app.ServiceName())
+	}
+	dropIn := &systemd.DropIn{
+		Wants: strings.Join(units, " "),
+		After: strings.Join(units, " "),
+	}
+
+	plugInfo := plug.Snap().Plugs[plug.Name()]
+	if plugInfo == nil {
+		return fmt.Errorf("internal error: snap %q has no %q plug", plug.Snap().InstanceName(), plug.Name())
+	}
+	suffix := fmt.Sprintf("service-ordering-%s-%s-%s", plug.Name(), slot.Snap().InstanceName(), slot.Name())
+	for _, app := range systemServices(plugInfo.Apps) {
+		if err := spec.AddServiceDropIn(app.ServiceName(), suffix, dropIn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (iface *serviceOrderingInterface) AutoConnect(*snap.PlugInfo, *snap.SlotInfo) bool {
+	return true
+}
+
+// CheckServiceOrderingCycle checks that connecting the given plug and slot
+// does not create a cycle in the start ordering of services. The ordering
+// between the services of each snap as well as the one from already
+// connected service-ordering plugs and slots is considered. Plugs of other
+// interfaces are ignored.
+func CheckServiceOrderingCycle(repo *interfaces.Repository, plug *snap.PlugInfo, slot *snap.SlotInfo) error {
+	ifaceName := (&serviceOrderingInterface{}).Name()
+	if plug.Interface != ifaceName {
+		return nil
+	}
+
+	// maps services to the ones that must start before them
+	after := make(map[string][]string)
+	addedSnaps := make(map[string]bool)
+	addSnap := func(info *snap.Info) {
+		if addedSnaps[info.InstanceName()] {
+			return
+		}
+		addedSnaps[info.InstanceName()] = true
+		for _, app := range info.Services() {
+			for _, other := range app.After {
+				if otherApp := info.Apps[other]; otherApp != nil {
+					after[app.ServiceName()] = append(after[app.ServiceName()], otherApp.ServiceName())
+				}
+			}
+			for _, other := range app.Before {
+				if otherApp := info.Apps[other]; otherApp != nil {
+					after[otherApp.ServiceName()] = append(after[otherApp.ServiceName()], app.ServiceName())
+				}
+			}
+		}
+	}
+	addConnection := func(plug *snap.PlugInfo, slot *snap.SlotInfo) {
+		addSnap(plug.Snap)
+		addSnap(slot.Snap)
+		for _, plugApp := range systemServices(plug.Apps) {
+			for _, slotApp := range systemServices(slot.Apps) {
+				after[plugApp.ServiceName()] = append(after[plugApp.ServiceName()], slotApp.ServiceName())
+			}
+		}
+	}
+
+	for _, otherPlug := range repo.AllPlugs(ifaceName) {
+		conns, err := repo.Connected(otherPlug.Snap.InstanceName(), otherPlug.Name)
+		if err != nil {
+			return err
+		}
+		for _, conn := range conns {
+			if otherSlot := repo.Slot(conn.SlotRef.Snap, conn.SlotRef.Name); otherSlot != nil {
+				addConnection(otherPlug, otherSlot)
+			}
+		}
+	}
+	addConnection(plug, slot)
+
+	// depth-first search from the services of the new plug, which are
+	// part of any new cycle
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	marks := make(map[string]int)
+	var path []string
+	var visit func(service string) []string
+	visit = func(service string) []string {
+		switch marks[service] {
+		case visiting:
+			for i, s := range path {
+				if s == service {
+					return append(append([]string{}, path[i:]...), service)
+				}
+			}
+		case visited:
+			return nil
+		}
+		marks[service] = visiting
+		path = append(path, service)
+		for _, before := range after[service] {
+			if cycle := visit(before); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		marks[service] = visited
+		return nil
+	}
+	for _, app := range systemServices(plug.Apps) {
+		if cycle := visit(app.ServiceName()); cycle != nil {
+			return fmt.Errorf("cannot order services of snap %q after services of snap %q: ordering cycle %s", plug.Snap.InstanceName(), slot.Snap.InstanceName(), strings.Join(cycle, " -> "))
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerIface(&serviceOrderingInterface{})
+}
@@ -0,0 +1,197 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/systemd"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type ServiceOrderingInterfaceSuite struct {
+	testutil.BaseTest
+
+	iface    interfaces.Interface
+	slot     *interfaces.ConnectedSlot
+	slotInfo *snap.SlotInfo
+	plug     *interfaces.ConnectedPlug
+	plugInfo *snap.PlugInfo
+}
+
+var _ = Suite(&ServiceOrderingInterfaceSuite{
+	iface: builtin.MustInterface("service-ordering"),
+})
+
+const serviceOrderingSlotYaml = `name: db
+version: 0
+apps:
+  postgres:
+    daemon: simple
+    slots: [db-ready]
+  psql:
+    command: psql
+slots:
+  db-ready:
+    interface: service-ordering
+`
+
+const serviceOrderingPlugYaml = `name: api
+version: 0
+apps:
+  api:
+    daemon: simple
+  worker:
+    daemon: simple
+    after: [api]
+  cli:
+    command: cli
+plugs:
+  db:
+    interface: service-ordering
+`
+
+func (s *ServiceOrderingInterfaceSuite) SetUpTest(c *C) {
+	s.slot, s.slotInfo = MockConnectedSlot(c, serviceOrderingSlotYaml, nil, "db-ready")
+	s.plug, s.plugInfo = MockConnectedPlug(c, serviceOrderingPlugYaml, nil, "db")
+}
+
+func (s *ServiceOrderingInterfaceSuite) TestName(c *C) {
+	c.Assert(s.iface.Name(), Equals, "service-ordering")
+}
+
+func (s *ServiceOrderingInterfaceSuite) TestSanitize(c *C) {
+	c.Assert(interfaces.BeforePrepareSlot(s.iface, s.slotInfo), IsNil)
+	c.Assert(interfaces.BeforePreparePlug(s.iface, s.plugInfo), IsNil)
+}
+
+func (s *ServiceOrderingInterfaceSuite) TestSystemdConnectedPlug(c *C) {
+	spec := &systemd.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.slot), IsNil)
+	dropIn := &systemd.DropIn{
+		Wants: "snap.db.postgres.service",
+		After: "snap.db.postgres.service",
+	}
+	// only the services bound to the unscoped plug are ordered
+	c.Check(spec.ServiceDropIns(), DeepEquals, map[string]map[string]*systemd.DropIn{
+		"snap.api.api.service": {
+			"service-ordering-db-db-db-ready": dropIn,
+		},
+		"snap.api.worker.service": {
+			"service-ordering-db-db-db-ready": dropIn,
+		},
+	})
+
+	// nothing for the slot side
+	spec = &systemd.Specification{}
+	c.Assert(spec.AddConnectedSlot(s.iface, s.plug, s.slot), IsNil)
+	c.Check(spec.ServiceDropIns(), IsNil)
+}
+
+func (s *ServiceOrderingInterfaceSuite) TestStaticInfo(c *C) {
+	si := interfaces.StaticInfoOf(s.iface)
+	c.Assert(si.ImplicitOnCore, Equals, false)
+	c.Assert(si.ImplicitOnClassic, Equals, false)
+	c.Assert(si.Summary, Equals, `allows ordering services after the services of another snap`)
+	c.Assert(si.BaseDeclarationSlots, testutil.Contains, "service-ordering")
+}
+
+func (s *ServiceOrderingInterfaceSuite) TestAutoConnect(c *C) {
+	c.Assert(s.iface.AutoConnect(s.plugInfo, s.slotInfo), Equals, true)
+}
+
+func (s *ServiceOrderingInterfaceSuite) TestInterfaces(c *C) {
+	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
+}
+
+func (s *ServiceOrderingInterfaceSuite) mockRepo(c *C, yamls ...string) *interfaces.Repository {
+	repo := interfaces.NewRepository()
+	c.Assert(repo.AddInterface(s.iface), IsNil)
+	for _, yaml := range yamls {
+		info := snaptest.MockInfo(c, yaml, nil)
+		appSet, err := interfaces.NewSnapAppSet(info, nil)
+		c.Assert(err, IsNil)
+		c.Assert(repo.AddAppSet(appSet), IsNil)
+	}
+	return repo
+}
+
+func (s *ServiceOrderingInterfaceSuite) connect(c *C, repo *interfaces.Repository, plugSnap, plug, slotSnap, slot string) {
+	connRef := interfaces.NewConnRef(repo.Plug(plugSnap, plug), repo.Slot(slotSnap, slot))
+	_, err := repo.Connect(connRef, nil, nil, nil, nil, nil)
+	c.Assert(err, IsNil)
+}
+
+func (s *ServiceOrderingInterfaceSuite) TestCheckServiceOrderingCycleHappy(c *C) {
+	repo := s.mockRepo(c, serviceOrderingSlotYaml, serviceOrderingPlugYaml)
+	err := builtin.CheckServiceOrderingCycle(repo, repo.Plug("api", "db"), repo.Slot("db", "db-ready"))
+	c.Check(err, IsNil)
+}
+
+func (s *ServiceOrderingInterfaceSuite) TestCheckServiceOrderingCycleOtherInterface(c *C) {
+	repo := s.mockRepo(c)
+	plugInfo := &snap.PlugInfo{Interface: "network"}
+	c.Check(builtin.CheckServiceOrderingCycle(repo, plugInfo, nil), IsNil)
+}
+
+func (s *ServiceOrderingInterfaceSuite) TestCheckServiceOrderingCycle(c *C) {
+	// db waits for api through its own plug
+	const dbYaml = `name: db
+version: 0
+apps:
+  postgres:
+    daemon: simple
+    plugs: [cache]
+slots:
+  db-ready:
+    interface: service-ordering
+plugs:
+  cache:
+    interface: service-ordering
+`
+	const apiYaml = `name: api
+version: 0
+apps:
+  api:
+    daemon: simple
+    slots: [api-ready]
+  worker:
+    daemon: simple
+    before: [api]
+    plugs: [db]
+plugs:
+  db:
+    interface: service-ordering
+slots:
+  api-ready:
+    interface: service-ordering
+`
+	repo := s.mockRepo(c, dbYaml, apiYaml)
+	s.connect(c, repo, "db", "cache", "api", "api-ready")
+
+	// worker -> postgres -> api -> worker, the last edge being
+	// ordering within the api snap
+	err := builtin.CheckServiceOrderingCycle(repo, repo.Plug("api", "db"), repo.Slot("db", "db-ready"))
+	c.Check(err, ErrorMatches, `cannot order services of snap "api" after services of snap "db": ordering cycle snap.api.worker.service -> snap.db.postgres.service -> snap.api.api.service -> snap.api.worker.service`)
+}
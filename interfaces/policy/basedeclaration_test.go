@@ -865,6 +865,7 @@ var (
 		"scsi-generic":              {"core"},
 		"sd-control":                {"core"},
 		"serial-port":               {"core", "gadget"},
+		"service-ordering":          {"app"},
 		"spi":                       {"core", "gadget"},
 		"screen-inhibit-control":    {"core", "app"},
 		"steam-support":             {"core"},
@@ -160,14 +160,16 @@ func checkPlugConnectionConstraints1(connc *ConnectCandidate, constraints *asser
 	return nil
 }
 
-func checkPlugConnectionAltConstraints(connc *ConnectCandidate, altConstraints []*asserts.PlugConnectionConstraints) (*asserts.PlugConnectionConstraints, error) {
+func checkPlugConnectionAltConstraints(connc *ConnectCandidate, which string, altConstraints []*asserts.PlugConnectionConstraints) (*asserts.PlugConnectionConstraints, error) {
 	var firstErr error
 	// OR of constraints
-	for _, constraints := range altConstraints {
+	for i, constraints := range altConstraints {
 		err := checkPlugConnectionConstraints1(connc, constraints)
 		if err == nil {
+			connc.trace.addAltMatch(which, i, len(altConstraints), nil)
 			return constraints, nil
 		}
+		connc.trace.addAltMatch(which, i, len(altConstraints), err)
 		if firstErr == nil {
 			firstErr = err
 		}
@@ -216,14 +218,16 @@ func checkSlotConnectionConstraints1(connc *ConnectCandidate, constraints *asser
 	return nil
 }
 
-func checkSlotConnectionAltConstraints(connc *ConnectCandidate, altConstraints []*asserts.SlotConnectionConstraints) (*asserts.SlotConnectionConstraints, error) {
+func checkSlotConnectionAltConstraints(connc *ConnectCandidate, which string, altConstraints []*asserts.SlotConnectionConstraints) (*asserts.SlotConnectionConstraints, error) {
 	var firstErr error
 	// OR of constraints
-	for _, constraints := range altConstraints {
+	for i, constraints := range altConstraints {
 		err := checkSlotConnectionConstraints1(connc, constraints)
 		if err == nil {
+			connc.trace.addAltMatch(which, i, len(altConstraints), nil)
 			return constraints, nil
 		}
+		connc.trace.addAltMatch(which, i, len(altConstraints), err)
 		if firstErr == nil {
 			firstErr = err
 		}
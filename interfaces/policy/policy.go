@@ -140,6 +140,42 @@ type ConnectCandidate struct {
 
 	// Are compatibility labels enabled?
 	CompatEnabled bool
+
+	// trace, if set, records how the declarations were evaluated
+	trace *CheckTrace
+}
+
+// CheckTrace describes how the rules of the declarations were evaluated when
+// checking a candidate connection.
+type CheckTrace struct {
+	// Rule describes the declaration rule that decided about the
+	// connection, it is empty if no rule applies to the interface.
+	Rule string
+	// Steps describes, in order, the evaluation of the alternative
+	// constraints of the deny and allow sections of the rule.
+	Steps []string
+}
+
+func (t *CheckTrace) setRule(format string, args ...any) {
+	if t == nil {
+		return
+	}
+	t.Rule = fmt.Sprintf(format, args...)
+}
+
+func (t *CheckTrace) addAltMatch(which string, i, n int, err error) {
+	if t == nil {
+		return
+	}
+	alt := which
+	if n > 1 {
+		alt = fmt.Sprintf("%s alternative %d of %d", which, i+1, n)
+	}
+	if err != nil {
+		t.Steps = append(t.Steps, fmt.Sprintf("%s does not match: %v", alt, err))
+	} else {
+		t.Steps = append(t.Steps, fmt.Sprintf("%s matches", alt))
+	}
 }
 
 func nestedGet(which string, attrs interfaces.Attrer, path string) (any, error) {
@@ -201,11 +237,11 @@ func (connc *ConnectCandidate) checkPlugRule(kind string, rule *asserts.PlugRule
 		denyConst = rule.DenyAutoConnection
 		allowConst = rule.AllowAutoConnection
 	}
-	if _, err := checkPlugConnectionAltConstraints(connc, denyConst); err == nil {
+	if _, err := checkPlugConnectionAltConstraints(connc, "deny-"+kind, denyConst); err == nil {
 		return nil, fmt.Errorf("%s denied by plug rule of interface %q%s", kind, connc.Plug.Interface(), context)
 	}
 
-	allowedConstraints, err := checkPlugConnectionAltConstraints(connc, allowConst)
+	allowedConstraints, err := checkPlugConnectionAltConstraints(connc, "allow-"+kind, allowConst)
 	if err != nil {
 		return nil, fmt.Errorf("%s not allowed by plug rule of interface %q%s", kind, connc.Plug.Interface(), context)
 	}
@@ -223,11 +259,11 @@ func (connc *ConnectCandidate) checkSlotRule(kind string, rule *asserts.SlotRule
 		denyConst = rule.DenyAutoConnection
 		allowConst = rule.AllowAutoConnection
 	}
-	if _, err := checkSlotConnectionAltConstraints(connc, denyConst); err == nil {
+	if _, err := checkSlotConnectionAltConstraints(connc, "deny-"+kind, denyConst); err == nil {
 		return nil, fmt.Errorf("%s denied by slot rule of interface %q%s", kind, connc.Plug.Interface(), context)
 	}
 
-	allowedConstraints, err := checkSlotConnectionAltConstraints(connc, allowConst)
+	allowedConstraints, err := checkSlotConnectionAltConstraints(connc, "allow-"+kind, allowConst)
 	if err != nil {
 		return nil, fmt.Errorf("%s not allowed by slot rule of interface %q%s", kind, connc.Plug.Interface(), context)
 	}
@@ -248,18 +284,22 @@ func (connc *ConnectCandidate) check(kind string) (interfaces.SideArity, error)
 
 	if plugDecl := connc.PlugSnapDeclaration; plugDecl != nil {
 		if rule := plugDecl.PlugRule(iface); rule != nil {
+			connc.trace.setRule("plug rule of interface %q in the snap-declaration of %q", iface, plugDecl.SnapName())
 			return connc.checkPlugRule(kind, rule, true)
 		}
 	}
 	if slotDecl := connc.SlotSnapDeclaration; slotDecl != nil {
 		if rule := slotDecl.SlotRule(iface); rule != nil {
+			connc.trace.setRule("slot rule of interface %q in the snap-declaration of %q", iface, slotDecl.SnapName())
 			return connc.checkSlotRule(kind, rule, true)
 		}
 	}
 	if rule := baseDecl.PlugRule(iface); rule != nil {
+		connc.trace.setRule("plug rule of interface %q in the base-declaration", iface)
 		return connc.checkPlugRule(kind, rule, false)
 	}
 	if rule := baseDecl.SlotRule(iface); rule != nil {
+		connc.trace.setRule("slot rule of interface %q in the base-declaration", iface)
 		return connc.checkSlotRule(kind, rule, false)
 	}
 	return nil, nil
@@ -285,6 +325,24 @@ func (connc *ConnectCandidate) CheckAutoConnect() (interfaces.SideArity, error)
 	return arity, nil
 }
 
+// TraceCheck is like Check but it also returns a trace of how the rules of
+// the declarations were evaluated.
+func (connc *ConnectCandidate) TraceCheck() (*CheckTrace, error) {
+	traced := *connc
+	traced.trace = &CheckTrace{}
+	err := traced.Check()
+	return traced.trace, err
+}
+
+// TraceCheckAutoConnect is like CheckAutoConnect but it also returns a trace
+// of how the rules of the declarations were evaluated.
+func (connc *ConnectCandidate) TraceCheckAutoConnect() (interfaces.SideArity, *CheckTrace, error) {
+	traced := *connc
+	traced.trace = &CheckTrace{}
+	arity, err := traced.CheckAutoConnect()
+	return arity, traced.trace, err
+}
+
 // InstallCandidateMinimalCheck represents a candidate snap installed with --dangerous flag that should pass minimum checks
 // against snap type (if present). It doesn't check interface attributes.
 type InstallCandidateMinimalCheck struct {
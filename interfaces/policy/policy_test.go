@@ -3286,3 +3286,55 @@ AXNpZw==`))
 	err = cand.Check()
 	c.Check(err, NotNil)
 }
+
+func (s *policySuite) TestTraceCheck(c *C) {
+	cand := policy.ConnectCandidate{
+		Plug:                interfaces.NewConnectedPlug(s.plugSnap.Plugs["plug-or-p1-s2"], s.plugAppSet, nil, nil),
+		Slot:                interfaces.NewConnectedSlot(s.slotSnap.Slots["plug-or-p1-s2"], s.slotAppSet, nil, nil),
+		PlugSnapDeclaration: s.plugDecl,
+		SlotSnapDeclaration: s.slotDecl,
+		BaseDeclaration:     s.baseDecl,
+	}
+	trace, err := cand.TraceCheck()
+	c.Check(err, ErrorMatches, `connection not allowed by plug rule of interface "plug-or"`)
+	c.Check(trace, DeepEquals, &policy.CheckTrace{
+		Rule: `plug rule of interface "plug-or" in the base-declaration`,
+		Steps: []string{
+			`deny-connection does not match: not allowed`,
+			`allow-connection alternative 1 of 2 does not match: attribute "s" value "S2" does not match ^(S1)$`,
+			`allow-connection alternative 2 of 2 does not match: attribute "p" value "P1" does not match ^(P2)$`,
+		},
+	})
+	// tracing has no side effects on the candidate
+	c.Check(cand.Check(), ErrorMatches, `connection not allowed by plug rule of interface "plug-or"`)
+
+	cand.Plug = interfaces.NewConnectedPlug(s.plugSnap.Plugs["snap-plug-deny"], s.plugAppSet, nil, nil)
+	cand.Slot = interfaces.NewConnectedSlot(s.slotSnap.Slots["snap-plug-deny"], s.slotAppSet, nil, nil)
+	trace, err = cand.TraceCheck()
+	c.Check(err, ErrorMatches, `connection denied by plug rule of interface "snap-plug-deny" for "plug-snap" snap`)
+	c.Check(trace, DeepEquals, &policy.CheckTrace{
+		Rule:  `plug rule of interface "snap-plug-deny" in the snap-declaration of "plug-snap"`,
+		Steps: []string{`deny-connection matches`},
+	})
+}
+
+func (s *policySuite) TestTraceCheckAutoConnect(c *C) {
+	cand := policy.ConnectCandidate{
+		Plug:            interfaces.NewConnectedPlug(s.plugSnap.Plugs["auto-base-slot-allow"], s.plugAppSet, nil, nil),
+		Slot:            interfaces.NewConnectedSlot(s.slotSnap.Slots["auto-base-slot-allow"], s.slotAppSet, nil, nil),
+		BaseDeclaration: s.baseDecl,
+	}
+	arity, trace, err := cand.TraceCheckAutoConnect()
+	c.Check(err, IsNil)
+	c.Check(arity.SlotsPerPlugAny(), Equals, false)
+	c.Check(trace.Rule, Equals, `slot rule of interface "auto-base-slot-allow" in the base-declaration`)
+	c.Check(trace.Steps, HasLen, 2)
+	c.Check(trace.Steps[1], Equals, `allow-auto-connection matches`)
+
+	// no rule applies
+	cand.Plug = interfaces.NewConnectedPlug(s.plugSnap.Plugs["random"], s.plugAppSet, nil, nil)
+	cand.Slot = interfaces.NewConnectedSlot(s.slotSnap.Slots["random"], s.slotAppSet, nil, nil)
+	_, trace, err = cand.TraceCheckAutoConnect()
+	c.Check(err, IsNil)
+	c.Check(trace, DeepEquals, &policy.CheckTrace{})
+}
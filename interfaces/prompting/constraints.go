@@ -73,7 +73,7 @@ type InterfaceSpecificConstraints interface {
 func parseInterfaceSpecificConstraints(iface string, constraintsJSON ConstraintsJSON, isPatch bool) (InterfaceSpecificConstraints, error) {
 	var interfaceSpecific InterfaceSpecificConstraints
 	switch iface {
-	case "home":
+	case "home", "removable-media", "personal-files":
 		interfaceSpecific = &InterfaceSpecificConstraintsHome{}
 	case "camera", "audio-record":
 		interfaceSpecific = &InterfaceSpecificConstraintsEmpty{}
@@ -92,6 +92,9 @@ func parseInterfaceSpecificConstraints(iface string, constraintsJSON Constraints
 	return interfaceSpecific, nil
 }
 
+// InterfaceSpecificConstraintsHome hold a path pattern, which is the only
+// interface-specific constraint for interfaces which mediate file access by
+// path, such as the home, removable-media, and personal-files interfaces.
 type InterfaceSpecificConstraintsHome struct {
 	Pattern *patterns.PathPattern
 }
@@ -888,9 +891,11 @@ var (
 	// List of permissions available for each interface. This also defines the
 	// order in which the permissions should be presented.
 	interfacePermissionsAvailable = map[string][]string{
-		"home":         {"read", "write", "execute"},
-		"camera":       {"access"},
-		"audio-record": {"access"},
+		"home":            {"read", "write", "execute"},
+		"removable-media": {"read", "write", "execute"},
+		"personal-files":  {"read", "write"},
+		"camera":          {"access"},
+		"audio-record":    {"access"},
 	}
 
 	// A mapping from interfaces which support AppArmor file permissions to
@@ -905,6 +910,15 @@ var (
 			"write":   notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_CREATE | notify.AA_MAY_DELETE | notify.AA_MAY_RENAME | notify.AA_MAY_SETATTR | notify.AA_MAY_CHMOD | notify.AA_MAY_LOCK | notify.AA_MAY_LINK,
 			"execute": notify.AA_MAY_EXEC | notify.AA_EXEC_MMAP,
 		},
+		"removable-media": {
+			"read":    notify.AA_MAY_READ | notify.AA_MAY_GETATTR,
+			"write":   notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_CREATE | notify.AA_MAY_DELETE | notify.AA_MAY_RENAME | notify.AA_MAY_SETATTR | notify.AA_MAY_CHMOD | notify.AA_MAY_LOCK | notify.AA_MAY_LINK,
+			"execute": notify.AA_MAY_EXEC | notify.AA_EXEC_MMAP,
+		},
+		"personal-files": {
+			"read":  notify.AA_MAY_READ | notify.AA_MAY_GETATTR,
+			"write": notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_CREATE | notify.AA_MAY_DELETE | notify.AA_MAY_RENAME | notify.AA_MAY_SETATTR | notify.AA_MAY_CHMOD | notify.AA_MAY_LOCK | notify.AA_MAY_LINK,
+		},
 		"camera": {
 			"access": notify.AA_MAY_READ | notify.AA_MAY_GETATTR | notify.AA_MAY_WRITE | notify.AA_MAY_APPEND,
 		},
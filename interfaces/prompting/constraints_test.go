@@ -147,6 +147,28 @@ func (s *constraintsSuite) TestParseInterfaceSpecificConstraintsHappy(c *C) {
 			},
 			expectedPathPattern: mustParsePathPattern(c, "/home/you/**/*.pdf"),
 		},
+		{
+			iface: "removable-media",
+			constraintsJSON: prompting.ConstraintsJSON{
+				"path-pattern": json.RawMessage(`"/media/me/usb/**"`),
+			},
+			isPatch: false,
+			expected: &prompting.InterfaceSpecificConstraintsHome{
+				Pattern: mustParsePathPattern(c, "/media/me/usb/**"),
+			},
+			expectedPathPattern: mustParsePathPattern(c, "/media/me/usb/**"),
+		},
+		{
+			iface: "personal-files",
+			constraintsJSON: prompting.ConstraintsJSON{
+				"path-pattern": json.RawMessage(`"/home/me/.config/foo/**"`),
+			},
+			isPatch: true,
+			expected: &prompting.InterfaceSpecificConstraintsHome{
+				Pattern: mustParsePathPattern(c, "/home/me/.config/foo/**"),
+			},
+			expectedPathPattern: mustParsePathPattern(c, "/home/me/.config/foo/**"),
+		},
 		{
 			iface:               "camera",
 			constraintsJSON:     prompting.ConstraintsJSON{},
@@ -2444,6 +2466,21 @@ func (s *constraintsSuite) TestAbstractPermissionsFromAppArmorPermissionsHappy(c
 			notify.AA_MAY_EXEC | notify.AA_MAY_WRITE | notify.AA_MAY_READ,
 			[]string{"read", "write", "execute"},
 		},
+		{
+			"removable-media",
+			notify.AA_MAY_EXEC | notify.AA_MAY_WRITE | notify.AA_MAY_READ,
+			[]string{"read", "write", "execute"},
+		},
+		{
+			"personal-files",
+			notify.AA_MAY_OPEN | notify.AA_MAY_READ,
+			[]string{"read"},
+		},
+		{
+			"personal-files",
+			notify.AA_MAY_CREATE | notify.AA_MAY_LOCK,
+			[]string{"write"},
+		},
 		{
 			"camera",
 			notify.AA_MAY_OPEN,
@@ -39,6 +39,8 @@ var (
 	// Validation errors which may be returned over the API
 	ErrPatchedRuleHasNoPerms   = errors.New("cannot patch rule to have no permissions")
 	ErrNewSessionRuleNoSession = errors.New(`cannot create rule with lifespan "session" when user session is not present`)
+	ErrRuleLocked              = errors.New("cannot modify rule locked by the administrator")
+	ErrImportedRuleHasSession  = errors.New(`cannot import rule with lifespan "session"`)
 
 	// Validation errors which should never be used directly apart from
 	// checking errors.Is(), and should otherwise always be wrapped in
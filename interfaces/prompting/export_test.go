@@ -20,6 +20,7 @@
 package prompting
 
 import (
+	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -44,3 +45,7 @@ var (
 func MockApparmorInterfaceForMetadataTag(f func(tag string) (string, bool)) (restore func()) {
 	return testutil.Mock(&apparmorInterfaceForMetadataTag, f)
 }
+
+func MockUserLookupId(f func(uid string) (*user.User, error)) (restore func()) {
+	return testutil.Mock(&userLookupId, f)
+}
@@ -33,17 +33,24 @@ import (
 	"github.com/snapcore/snapd/interfaces/builtin"
 	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify/listener"
 	"github.com/snapcore/snapd/sandbox/cgroup"
+	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/strutil"
 )
 
 var (
 	cgroupProcessPathInTrackingCgroup = cgroup.ProcessPathInTrackingCgroup
+	userLookupId                      = user.LookupId
 )
 
+// PersonalFilesPlugsFunc returns the connected personal-files plugs of the
+// given snap instance.
+type PersonalFilesPlugsFunc func(snap string) []*snap.PlugInfo
+
 // Request holds information about the content of a prompting request, as well
 // as a means to send a reply to the originator of that request.
 type Request struct {
@@ -81,6 +88,21 @@ type Request struct {
 // `sendResponse` function to actually send the resulting response back to the
 // kernel.
 func NewRequestFromListener(msg notify.MsgNotificationGeneric, sendResponse listener.SendResponseFunc) (*Request, error) {
+	return newRequestFromListener(msg, sendResponse, nil)
+}
+
+// NewRequestFromListenerFunc returns a function which parses requests like
+// [NewRequestFromListener], and which uses the given function to look up the
+// connected personal-files plugs of the snap which triggered a request, so
+// that requests for paths granted by those plugs are associated with the
+// personal-files interface rather than the home interface.
+func NewRequestFromListenerFunc(personalFilesPlugs PersonalFilesPlugsFunc) func(msg notify.MsgNotificationGeneric, sendResponse listener.SendResponseFunc) (*Request, error) {
+	return func(msg notify.MsgNotificationGeneric, sendResponse listener.SendResponseFunc) (*Request, error) {
+		return newRequestFromListener(msg, sendResponse, personalFilesPlugs)
+	}
+}
+
+func newRequestFromListener(msg notify.MsgNotificationGeneric, sendResponse listener.SendResponseFunc, personalFilesPlugs PersonalFilesPlugsFunc) (*Request, error) {
 	// XXX: we get the snap name from the process label in the message, but we
 	// could try to get it from the cgroup path instead.
 	snap := msg.ProcessLabel() // default to apparmor label, in case process is not a snap
@@ -98,12 +120,21 @@ func NewRequestFromListener(msg notify.MsgNotificationGeneric, sendResponse list
 			return nil, fmt.Errorf("cannot select interface from metadata tags: %w", err)
 		}
 		// There were no tags registered with a snapd interface, so we
-		// look at the path to decide whether it's "home" or "camera".
+		// look at the path to decide whether it's "home", "camera",
+		// "removable-media", or "personal-files". Requests from
+		// personal-files can only be told apart from home by comparing
+		// the path with the paths of the connected personal-files plugs
+		// of the snap.
 		// XXX: this is a temporary workaround until metadata tags are
 		// supported by the AppArmor parser and kernel.
-		if builtin.DetectCameraFromPath(path) {
+		switch {
+		case builtin.DetectCameraFromPath(path):
 			iface = "camera"
-		} else {
+		case builtin.DetectRemovableMediaFromPath(path):
+			iface = "removable-media"
+		case detectPersonalFiles(path, snap, msg.SubjectUID(), personalFilesPlugs):
+			iface = "personal-files"
+		default:
 			iface = "home"
 		}
 	}
@@ -149,6 +180,25 @@ func NewRequestFromListener(msg notify.MsgNotificationGeneric, sendResponse list
 	return req, nil
 }
 
+// detectPersonalFiles returns true if the given path is granted to the given
+// snap by one of its connected personal-files plugs, with $HOME referring to
+// the home directory of the user with the given UID.
+func detectPersonalFiles(path, snap string, uid uint32, personalFilesPlugs PersonalFilesPlugsFunc) bool {
+	if personalFilesPlugs == nil {
+		return false
+	}
+	plugs := personalFilesPlugs(snap)
+	if len(plugs) == 0 {
+		return false
+	}
+	u, err := userLookupId(strconv.FormatUint(uint64(uid), 10))
+	if err != nil {
+		logger.Noticef("cannot look up home directory of user %d, treating request as home: %v", uid, err)
+		return false
+	}
+	return builtin.DetectPersonalFilesFromPath(path, u.HomeDir, plugs)
+}
+
 func buildListenerRequestKey(iface string, id uint64) string {
 	return fmt.Sprintf("kernel:%s:%016X", iface, id)
 }
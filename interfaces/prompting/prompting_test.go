@@ -34,7 +34,10 @@ import (
 	"github.com/snapcore/snapd/interfaces/prompting"
 	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -247,6 +250,31 @@ func (s *promptingSuite) TestNewRequestFromListenerInterfaceSelection(c *C) {
 			},
 			"camera",
 		},
+		{
+			"/media/test/usb/foo",
+			func(tag string) (string, bool) {
+				return "", false
+			},
+			"removable-media",
+		},
+		{
+			"/mnt/foo",
+			func(tag string) (string, bool) {
+				return "", false
+			},
+			"removable-media",
+		},
+		{
+			"/home/test/.config/foo",
+			func(tag string) (string, bool) {
+				switch tag {
+				case "tag1", "tag3", "tag4":
+					return "personal-files", true
+				}
+				return "", false
+			},
+			"personal-files",
+		},
 	} {
 		restore := prompting.MockApparmorInterfaceForMetadataTag(testCase.ifaceForTag)
 		defer restore()
@@ -261,6 +289,72 @@ func (s *promptingSuite) TestNewRequestFromListenerInterfaceSelection(c *C) {
 	}
 }
 
+func (s *promptingSuite) TestNewRequestFromListenerFuncPersonalFiles(c *C) {
+	const snapYaml = `name: foo
+version: 1.0
+plugs:
+ personal-files:
+  read: [$HOME/.config/foo]
+  write: [$HOME/.local/share/foo]
+`
+	plug := snaptest.MockInfo(c, snapYaml, nil).Plugs["personal-files"]
+
+	restore := prompting.MockApparmorInterfaceForMetadataTag(func(tag string) (string, bool) {
+		return "", false
+	})
+	defer restore()
+	var lookups []string
+	restore = prompting.MockUserLookupId(func(uid string) (*user.User, error) {
+		lookups = append(lookups, uid)
+		return &user.User{Uid: uid, HomeDir: "/home/test"}, nil
+	})
+	defer restore()
+
+	var snaps []string
+	newRequest := prompting.NewRequestFromListenerFunc(func(instanceName string) []*snap.PlugInfo {
+		snaps = append(snaps, instanceName)
+		if instanceName == "foo" {
+			return []*snap.PlugInfo{plug}
+		}
+		return nil
+	})
+
+	for i, testCase := range []struct {
+		label         string
+		path          string
+		expectedIface string
+	}{
+		{"snap.foo.app", "/home/test/.config/foo", "personal-files"},
+		{"snap.foo.app", "/home/test/.local/share/foo/bar", "personal-files"},
+		{"snap.foo.app", "/home/test/.config/foobar", "home"},
+		{"snap.foo.app", "/home/test/Documents/foo", "home"},
+		{"snap.foo.app", "/media/test/usb/foo", "removable-media"},
+		{"snap.other.app", "/home/test/.config/foo", "home"},
+	} {
+		msg := newMsgNotificationFile(notify.ProtocolVersion(2), uint64(i), testCase.label, testCase.path, 0, 0b0100, nil)
+		msg.SUID = 1000
+
+		result, err := newRequest(msg, nil)
+		c.Assert(err, IsNil, Commentf("testCase %d: %+v", i, testCase))
+		c.Check(result.Interface, Equals, testCase.expectedIface, Commentf("testCase %d: %+v", i, testCase))
+		c.Check(result.Permissions, DeepEquals, []string{"read"}, Commentf("testCase %d: %+v", i, testCase))
+	}
+	// the plugs are only looked up for paths which may be personal files
+	c.Check(snaps, DeepEquals, []string{"foo", "foo", "foo", "foo", "other"})
+	// the home directory is only looked up for snaps with personal-files plugs
+	c.Check(lookups, DeepEquals, []string{"1000", "1000", "1000", "1000"})
+
+	// without a home directory, the request is associated with home
+	restore = prompting.MockUserLookupId(func(uid string) (*user.User, error) {
+		return nil, errors.New("boom")
+	})
+	defer restore()
+	msg := newMsgNotificationFile(notify.ProtocolVersion(2), 42, "snap.foo.app", "/home/test/.config/foo", 0, 0b0100, nil)
+	result, err := newRequest(msg, nil)
+	c.Assert(err, IsNil)
+	c.Check(result.Interface, Equals, "home")
+}
+
 func (s *promptingSuite) TestNewRequestFromListenerReply(c *C) {
 	var (
 		id      = uint64(0xabcd)
@@ -0,0 +1,273 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package requesthistory provides a persistent, bounded log of the outcomes
+// of prompts, so that users can later review which snaps accessed which
+// resources, and whether that access was allowed.
+package requesthistory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces/prompting"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// MaxEntriesLimit is the largest number of entries which may be kept for
+// each user.
+const MaxEntriesLimit = 10000
+
+const (
+	// ResolutionReplied indicates that a prompt was resolved by a reply from
+	// a prompting client.
+	ResolutionReplied = "replied"
+	// ResolutionTimeout indicates that a prompt was resolved according to the
+	// timeout policy for its interface, since no client replied in time.
+	ResolutionTimeout = "timeout"
+	// ResolutionRule indicates that a prompt was resolved by a rule which was
+	// added, patched, or imported while the prompt was outstanding.
+	ResolutionRule = "rule"
+)
+
+// Entry records the outcome of a single prompt.
+type Entry struct {
+	Timestamp   time.Time              `json:"timestamp"`
+	User        uint32                 `json:"user"`
+	Snap        string                 `json:"snap"`
+	Interface   string                 `json:"interface"`
+	Path        string                 `json:"path"`
+	Permissions []string               `json:"permissions"`
+	Outcome     prompting.OutcomeType  `json:"outcome"`
+	Lifespan    prompting.LifespanType `json:"lifespan"`
+	// RuleID is the ID of the rule which was created as a result of the
+	// prompt's outcome, if any.
+	RuleID     prompting.IDType `json:"rule-id,omitempty"`
+	Resolution string           `json:"resolution"`
+}
+
+// Filter selects history entries.
+type Filter struct {
+	// Snap, if non-empty, selects only entries for the given snap.
+	Snap string
+	// After, if non-zero, selects only entries recorded after this time.
+	After time.Time
+	// Before, if non-zero, selects only entries recorded before this time.
+	Before time.Time
+}
+
+func (f *Filter) matches(entry *Entry) bool {
+	if f == nil {
+		return true
+	}
+	if f.Snap != "" && entry.Snap != f.Snap {
+		return false
+	}
+	if !f.After.IsZero() && !entry.Timestamp.After(f.After) {
+		return false
+	}
+	if !f.Before.IsZero() && !entry.Timestamp.Before(f.Before) {
+		return false
+	}
+	return true
+}
+
+// History stores the outcomes of prompts for all users, oldest first, and
+// persists them to disk.
+type History struct {
+	mutex   sync.Mutex
+	path    string
+	entries []*Entry
+}
+
+// historyJSON is a helper type for wrapping the history for serialization
+// when storing to disk.
+type historyJSON struct {
+	Entries []*Entry `json:"entries"`
+}
+
+// New creates a new prompt history and loads existing entries from disk.
+//
+// If the existing history cannot be loaded, it is discarded and the history
+// starts out empty.
+func New() (*History, error) {
+	if err := os.MkdirAll(dirs.SnapInterfacesRequestsStateDir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create interfaces requests state directory: %w", err)
+	}
+	h := &History{
+		path: filepath.Join(dirs.SnapInterfacesRequestsStateDir, "request-history.json"),
+	}
+	if err := h.load(); err != nil {
+		logger.Noticef("cannot load prompt history: %v; using new empty history", err)
+		h.entries = nil
+	}
+	return h, nil
+}
+
+func (h *History) load() error {
+	f, err := os.Open(h.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	var wrapped historyJSON
+	if err := json.NewDecoder(f).Decode(&wrapped); err != nil {
+		return err
+	}
+	h.entries = wrapped.Entries
+	return nil
+}
+
+// save writes the history to disk.
+//
+// The caller must ensure that the history lock is held.
+func (h *History) save() error {
+	b, err := json.Marshal(historyJSON{Entries: h.entries})
+	if err != nil {
+		return fmt.Errorf("cannot marshal prompt history: %w", err)
+	}
+	return osutil.AtomicWriteFile(h.path, b, 0o600, 0)
+}
+
+// Record adds the given entry to the history. If the user of the entry then
+// has more than maxEntries entries, the oldest entries for that user are
+// discarded. If maxEntries is zero or negative, the history is disabled and
+// the entry is not recorded.
+func (h *History) Record(entry *Entry, maxEntries int) error {
+	if maxEntries <= 0 {
+		return nil
+	}
+	if maxEntries > MaxEntriesLimit {
+		maxEntries = MaxEntriesLimit
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	count := 1
+	for _, e := range h.entries {
+		if e.User == entry.User {
+			count++
+		}
+	}
+	entries := make([]*Entry, 0, len(h.entries)+1)
+	for _, e := range h.entries {
+		if e.User == entry.User && count > maxEntries {
+			count--
+			continue
+		}
+		entries = append(entries, e)
+	}
+	entries = append(entries, entry)
+
+	origEntries := h.entries
+	h.entries = entries
+	if err := h.save(); err != nil {
+		h.entries = origEntries
+		return err
+	}
+	return nil
+}
+
+// Trim discards the oldest entries of every user which has more than
+// maxEntries entries. If maxEntries is zero or negative, the history is
+// disabled and all entries are discarded.
+func (h *History) Trim(maxEntries int) error {
+	if maxEntries > MaxEntriesLimit {
+		maxEntries = MaxEntriesLimit
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// entries are kept oldest first, so count them from the newest
+	kept := make(map[uint32]int)
+	entries := make([]*Entry, len(h.entries))
+	n := len(entries)
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		e := h.entries[i]
+		if kept[e.User] >= maxEntries {
+			continue
+		}
+		kept[e.User]++
+		n--
+		entries[n] = e
+	}
+	if n == 0 {
+		return nil
+	}
+
+	origEntries := h.entries
+	h.entries = entries[n:]
+	if err := h.save(); err != nil {
+		h.entries = origEntries
+		return err
+	}
+	return nil
+}
+
+// Entries returns the history entries for the given user which match the
+// given filter, oldest first.
+func (h *History) Entries(user uint32, filter *Filter) []*Entry {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	entries := make([]*Entry, 0)
+	for _, e := range h.entries {
+		if e.User == user && filter.matches(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Clear removes all history entries for the given user.
+func (h *History) Clear(user uint32) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	entries := make([]*Entry, 0, len(h.entries))
+	for _, e := range h.entries {
+		if e.User != user {
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) == len(h.entries) {
+		return nil
+	}
+
+	origEntries := h.entries
+	h.entries = entries
+	if err := h.save(); err != nil {
+		h.entries = origEntries
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package requesthistory_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces/prompting"
+	"github.com/snapcore/snapd/interfaces/prompting/requesthistory"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type requesthistorySuite struct {
+	historyPath string
+}
+
+var _ = Suite(&requesthistorySuite{})
+
+func (s *requesthistorySuite) SetUpTest(c *C) {
+	dirs.SetRootDir(c.MkDir())
+	s.historyPath = filepath.Join(dirs.SnapInterfacesRequestsStateDir, "request-history.json")
+}
+
+func (s *requesthistorySuite) TearDownTest(c *C) {
+	dirs.SetRootDir("")
+}
+
+func newEntry(user uint32, snap string, path string, timestamp time.Time) *requesthistory.Entry {
+	return &requesthistory.Entry{
+		Timestamp:   timestamp,
+		User:        user,
+		Snap:        snap,
+		Interface:   "home",
+		Path:        path,
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanSingle,
+		Resolution:  requesthistory.ResolutionReplied,
+	}
+}
+
+func (s *requesthistorySuite) TestRecordAndFilter(c *C) {
+	h, err := requesthistory.New()
+	c.Assert(err, IsNil)
+
+	t0 := time.Now().Truncate(time.Second)
+	e1 := newEntry(1000, "firefox", "/home/test/a", t0)
+	e2 := newEntry(1000, "thunderbird", "/home/test/b", t0.Add(time.Minute))
+	e3 := newEntry(1000, "firefox", "/home/test/c", t0.Add(2*time.Minute))
+	e4 := newEntry(1001, "firefox", "/home/other/d", t0.Add(3*time.Minute))
+	for _, e := range []*requesthistory.Entry{e1, e2, e3, e4} {
+		c.Assert(h.Record(e, 100), IsNil)
+	}
+
+	c.Check(h.Entries(1000, nil), DeepEquals, []*requesthistory.Entry{e1, e2, e3})
+	c.Check(h.Entries(1001, nil), DeepEquals, []*requesthistory.Entry{e4})
+	c.Check(h.Entries(1002, nil), HasLen, 0)
+
+	c.Check(h.Entries(1000, &requesthistory.Filter{Snap: "firefox"}), DeepEquals, []*requesthistory.Entry{e1, e3})
+	c.Check(h.Entries(1000, &requesthistory.Filter{After: t0}), DeepEquals, []*requesthistory.Entry{e2, e3})
+	c.Check(h.Entries(1000, &requesthistory.Filter{Before: t0.Add(2 * time.Minute)}), DeepEquals, []*requesthistory.Entry{e1, e2})
+	c.Check(h.Entries(1000, &requesthistory.Filter{
+		Snap:   "firefox",
+		After:  t0,
+		Before: t0.Add(time.Hour),
+	}), DeepEquals, []*requesthistory.Entry{e3})
+
+	// History persists across restarts
+	h2, err := requesthistory.New()
+	c.Assert(err, IsNil)
+	entries := h2.Entries(1000, nil)
+	c.Assert(entries, HasLen, 3)
+	for i, e := range []*requesthistory.Entry{e1, e2, e3} {
+		c.Check(entries[i].Timestamp.Equal(e.Timestamp), Equals, true)
+		entries[i].Timestamp = e.Timestamp
+		c.Check(entries[i], DeepEquals, e)
+	}
+}
+
+func (s *requesthistorySuite) TestRecordBounded(c *C) {
+	h, err := requesthistory.New()
+	c.Assert(err, IsNil)
+
+	t0 := time.Now()
+	var entries []*requesthistory.Entry
+	for i := 0; i < 5; i++ {
+		e := newEntry(1000, "firefox", "/home/test/foo", t0.Add(time.Duration(i)*time.Second))
+		entries = append(entries, e)
+		c.Assert(h.Record(e, 3), IsNil)
+	}
+	other := newEntry(1001, "firefox", "/home/other/foo", t0)
+	c.Assert(h.Record(other, 3), IsNil)
+
+	// Only the newest entries for the user are kept, and other users are
+	// unaffected
+	c.Check(h.Entries(1000, nil), DeepEquals, entries[2:])
+	c.Check(h.Entries(1001, nil), DeepEquals, []*requesthistory.Entry{other})
+
+	// Lowering the bound discards more entries on the next record
+	e := newEntry(1000, "firefox", "/home/test/bar", t0.Add(time.Minute))
+	c.Assert(h.Record(e, 1), IsNil)
+	c.Check(h.Entries(1000, nil), DeepEquals, []*requesthistory.Entry{e})
+}
+
+func (s *requesthistorySuite) TestRecordDisabled(c *C) {
+	h, err := requesthistory.New()
+	c.Assert(err, IsNil)
+
+	c.Assert(h.Record(newEntry(1000, "firefox", "/home/test/foo", time.Now()), 0), IsNil)
+	c.Check(h.Entries(1000, nil), HasLen, 0)
+	c.Check(s.historyPath, testutil.FileAbsent)
+}
+
+func (s *requesthistorySuite) TestClear(c *C) {
+	h, err := requesthistory.New()
+	c.Assert(err, IsNil)
+
+	e1 := newEntry(1000, "firefox", "/home/test/foo", time.Now())
+	e2 := newEntry(1001, "firefox", "/home/other/foo", time.Now())
+	c.Assert(h.Record(e1, 10), IsNil)
+	c.Assert(h.Record(e2, 10), IsNil)
+
+	c.Assert(h.Clear(1000), IsNil)
+	c.Check(h.Entries(1000, nil), HasLen, 0)
+	c.Check(h.Entries(1001, nil), DeepEquals, []*requesthistory.Entry{e2})
+
+	// Clearing is persisted
+	h2, err := requesthistory.New()
+	c.Assert(err, IsNil)
+	c.Check(h2.Entries(1000, nil), HasLen, 0)
+	c.Check(h2.Entries(1001, nil), HasLen, 1)
+
+	// Clearing an empty history is fine
+	c.Check(h.Clear(1002), IsNil)
+}
+
+func (s *requesthistorySuite) TestTrim(c *C) {
+	h, err := requesthistory.New()
+	c.Assert(err, IsNil)
+
+	t0 := time.Now().Truncate(time.Second)
+	var entries []*requesthistory.Entry
+	for i := 0; i < 3; i++ {
+		e := newEntry(1000, "firefox", "/home/test/foo", t0.Add(time.Duration(i)*time.Minute))
+		c.Assert(h.Record(e, 10), IsNil)
+		entries = append(entries, e)
+	}
+	other := newEntry(1001, "firefox", "/home/other/foo", t0)
+	c.Assert(h.Record(other, 10), IsNil)
+
+	// Nothing to trim
+	c.Assert(h.Trim(3), IsNil)
+	c.Check(h.Entries(1000, nil), DeepEquals, entries)
+
+	// Only the newest entries of each user are kept
+	c.Assert(h.Trim(2), IsNil)
+	c.Check(h.Entries(1000, nil), DeepEquals, entries[1:])
+	c.Check(h.Entries(1001, nil), DeepEquals, []*requesthistory.Entry{other})
+
+	// Trimming is persisted
+	h2, err := requesthistory.New()
+	c.Assert(err, IsNil)
+	c.Check(h2.Entries(1000, nil), HasLen, 2)
+
+	// Disabling the history discards everything
+	c.Assert(h.Trim(0), IsNil)
+	c.Check(h.Entries(1000, nil), HasLen, 0)
+	c.Check(h.Entries(1001, nil), HasLen, 0)
+	h2, err = requesthistory.New()
+	c.Assert(err, IsNil)
+	c.Check(h2.Entries(1000, nil), HasLen, 0)
+	c.Check(h2.Entries(1001, nil), HasLen, 0)
+}
+
+func (s *requesthistorySuite) TestNewCorrupted(c *C) {
+	c.Assert(os.MkdirAll(dirs.SnapInterfacesRequestsStateDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(s.historyPath, []byte("not json"), 0o600), IsNil)
+
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	h, err := requesthistory.New()
+	c.Assert(err, IsNil)
+	c.Check(h.Entries(1000, nil), HasLen, 0)
+	c.Check(logbuf.String(), testutil.Contains, "cannot load prompt history")
+}
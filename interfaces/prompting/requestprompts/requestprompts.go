@@ -73,6 +73,11 @@ type Prompt struct {
 	Interface   string
 	Constraints *promptConstraints
 	requests    []*prompting.Request
+	// decisionTimer resolves the prompt according to the timeout policy for
+	// its interface if no reply is received before it fires. If nil, the
+	// prompt is held until a reply is received or the prompts for the user
+	// expire.
+	decisionTimer timeutil.Timer
 }
 
 // jsonPrompt defines the marshalled json structure of a Prompt.
@@ -201,7 +206,8 @@ type promptConstraints struct {
 }
 
 // promptConstraintsJSONHome defines the marshalled json structure of
-// promptConstraints for the home interface.
+// promptConstraints for interfaces which mediate file access by path, such as
+// the home, removable-media, and personal-files interfaces.
 type promptConstraintsJSONHome struct {
 	Path                 string   `json:"path"`
 	RequestedPermissions []string `json:"requested-permissions"`
@@ -224,7 +230,7 @@ func (pc *promptConstraints) MarshalJSON() ([]byte, error) {
 // corresponding to the given interface.
 func (pc *promptConstraints) marshalForInterface(iface string) ([]byte, error) {
 	switch iface {
-	case "home":
+	case "home", "removable-media", "personal-files":
 		constraintsJSON := &promptConstraintsJSONHome{
 			Path:                 pc.EscapedPath(),
 			RequestedPermissions: pc.outstandingPermissions,
@@ -417,6 +423,9 @@ func (udb *userPromptDB) remove(id prompting.IDType) (*Prompt, error) {
 	// Update the ID-index mapping of the moved prompt.
 	udb.ids[movedID] = index
 	delete(udb.ids, id)
+	if prompt.decisionTimer != nil {
+		prompt.decisionTimer.Stop()
+	}
 	return prompt, nil
 }
 
@@ -453,10 +462,23 @@ func (udb *userPromptDB) timeoutCallback(pdb *PromptDB, user uint32) {
 		pdb.mutex.Unlock()
 		return
 	}
-	expiredPrompts := udb.prompts
-	// Clear all outstanding prompts for the user
+	// Clear all outstanding prompts for the user, except those which have
+	// their own decision timeout, as those will be resolved according to the
+	// timeout policy for their interface once that timeout elapses.
+	var expiredPrompts []*Prompt
+	var keptPrompts []*Prompt
+	for _, p := range udb.prompts {
+		if p.decisionTimer != nil {
+			keptPrompts = append(keptPrompts, p)
+			continue
+		}
+		expiredPrompts = append(expiredPrompts, p)
+	}
 	udb.prompts = nil
 	udb.ids = make(map[prompting.IDType]int) // TODO:GOVERSION: clear() once we're on Go 1.21+
+	for _, p := range keptPrompts {
+		udb.add(p)
+	}
 
 	// Remove the request mappings now before unlocking the prompt DB
 	for _, p := range expiredPrompts {
@@ -1086,6 +1108,65 @@ func (pdb *PromptDB) Reply(user uint32, id prompting.IDType, outcome prompting.O
 	return prompt, nil
 }
 
+// SetDecisionTimeout arranges for the given callback to be called if the
+// prompt with the given ID is still outstanding after the given timeout.
+// The callback is expected to resolve the prompt using ResolveOnTimeout.
+//
+// Prompts with a decision timeout are exempt from the expiration of prompts
+// when there is no client activity for the user, so that they are always
+// resolved according to the timeout policy for their interface.
+//
+// The callback is called without the prompt DB lock held. If the prompt is
+// resolved before the timeout elapses, the callback is not called.
+func (pdb *PromptDB) SetDecisionTimeout(user uint32, id prompting.IDType, timeout time.Duration, callback func()) error {
+	pdb.mutex.Lock()
+	defer pdb.mutex.Unlock()
+	_, prompt, err := pdb.promptWithID(user, id, false)
+	if err != nil {
+		return err
+	}
+	if prompt.decisionTimer != nil {
+		prompt.decisionTimer.Stop()
+	}
+	prompt.decisionTimer = timeAfterFunc(timeout, callback)
+	return nil
+}
+
+// ResolveOnTimeout resolves the prompt with the given ID using the outcome of
+// the given timeout default, since no reply was received for the prompt
+// before the timeout configured for its interface elapsed.
+//
+// Records a notice for the prompt with the timeout default as the reason for
+// the resolution, and returns the prompt's former contents.
+func (pdb *PromptDB) ResolveOnTimeout(user uint32, id prompting.IDType, timeoutDefault prompting.TimeoutDefaultType) (*Prompt, error) {
+	pdb.mutex.Lock()
+	defer pdb.mutex.Unlock()
+	userEntry, prompt, err := pdb.promptWithID(user, id, false)
+	if err != nil {
+		return nil, err
+	}
+	outcome := timeoutDefault.Outcome()
+	if err = prompt.sendReply(outcome); err != nil {
+		return nil, err
+	}
+
+	for _, request := range prompt.requests {
+		delete(pdb.requestMap, request.Key)
+	}
+	pdb.saveRequestMap() // error should not occur
+
+	userEntry.remove(id)
+
+	data := map[string]string{
+		"resolved": "timeout",
+		"outcome":  string(outcome),
+		"reason":   string(timeoutDefault),
+	}
+	logger.Debugf("prompt timed out, resolved with default %q: %q", timeoutDefault, id)
+	pdb.notifyPrompt(user, id, data)
+	return prompt, nil
+}
+
 // HandleNewRule checks if any existing prompts are satisfied by the given rule
 // contents and, if so, sends back a decision to their requests.
 //
@@ -1218,6 +1299,11 @@ func (pdb *PromptDB) Close() error {
 	pdb.readyTimer.Stop()
 	for _, userEntry := range pdb.perUser {
 		userEntry.expirationTimer.Stop()
+		for _, prompt := range userEntry.prompts {
+			if prompt.decisionTimer != nil {
+				prompt.decisionTimer.Stop()
+			}
+		}
 	}
 
 	// Clear all outstanding prompts
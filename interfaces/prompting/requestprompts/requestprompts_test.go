@@ -1463,6 +1463,194 @@ func (s *requestpromptsSuite) TestReplyErrors(c *C) {
 	s.checkWrittenRequestMap(c, expectedMap)
 }
 
+func (s *requestpromptsSuite) TestResolveOnTimeout(c *C) {
+	var timers []*testtime.TestTimer
+	restore := requestprompts.MockTimeAfterFunc(func(d time.Duration, f func()) timeutil.Timer {
+		timer := testtime.AfterFunc(d, f)
+		timers = append(timers, timer)
+		return timer
+	})
+	defer restore()
+
+	noticeChan := make(chan noticeInfo, 1)
+	pdb, err := requestprompts.New(func(userID uint32, promptID prompting.IDType, data map[string]string) error {
+		c.Assert(userID, Equals, s.defaultUser)
+		noticeChan <- noticeInfo{
+			promptID: promptID,
+			data:     data,
+		}
+		return nil
+	})
+	c.Assert(err, IsNil)
+	defer pdb.Close()
+
+	metadata := &prompting.Metadata{
+		User:      s.defaultUser,
+		Snap:      "nextcloud",
+		PID:       123,
+		Cgroup:    "some-cgroup-path",
+		Interface: "home",
+	}
+	path := "/home/test/Documents/foo.txt"
+	requestedPermissions := []string{"read", "write"}
+	outstandingPermissions := []string{"write"}
+
+	for i, testCase := range []struct {
+		timeoutDefault prompting.TimeoutDefaultType
+		outcome        string
+		allowedPerms   []string
+	}{
+		{prompting.TimeoutDefaultDeny, "deny", []string{"read"}},
+		{prompting.TimeoutDefaultAllowOnce, "allow", []string{"read", "write"}},
+		{prompting.TimeoutDefaultAllowWithRule, "allow", []string{"read", "write"}},
+	} {
+		req, replyChan := newRequestWithReplyChan(fmt.Sprintf("fake:%d", i))
+		prompt, merged, err := pdb.AddOrMerge(metadata, path, requestedPermissions, outstandingPermissions, req)
+		c.Assert(err, IsNil)
+		c.Assert(merged, Equals, false)
+		checkCurrentNotices(c, noticeChan, prompt.ID, nil)
+
+		timedOut := make(chan struct{})
+		err = pdb.SetDecisionTimeout(metadata.User, prompt.ID, 30*time.Second, func() {
+			resolved, err := pdb.ResolveOnTimeout(metadata.User, prompt.ID, testCase.timeoutDefault)
+			c.Check(err, IsNil)
+			c.Check(resolved, Equals, prompt)
+			close(timedOut)
+		})
+		c.Assert(err, IsNil)
+		decisionTimer := timers[len(timers)-1]
+
+		// Prompt should not be resolved before the timeout elapses
+		decisionTimer.Elapse(30*time.Second - time.Nanosecond)
+		c.Check(decisionTimer.FireCount(), Equals, 0)
+
+		decisionTimer.Elapse(time.Nanosecond)
+		<-timedOut
+		expectedData := map[string]string{
+			"resolved": "timeout",
+			"outcome":  testCase.outcome,
+			"reason":   string(testCase.timeoutDefault),
+		}
+		checkCurrentNotices(c, noticeChan, prompt.ID, expectedData)
+		c.Check(waitForReply(c, replyChan), DeepEquals, testCase.allowedPerms)
+		s.checkWrittenRequestMap(c, map[string]requestprompts.RequestMapEntry{})
+
+		_, err = pdb.PromptWithID(metadata.User, prompt.ID, false)
+		c.Check(err, Equals, prompting_errors.ErrPromptNotFound)
+	}
+
+	// Resolving unknown prompts fails
+	_, err = pdb.ResolveOnTimeout(metadata.User, 1234, prompting.TimeoutDefaultDeny)
+	c.Check(err, Equals, prompting_errors.ErrPromptNotFound)
+	err = pdb.SetDecisionTimeout(metadata.User, 1234, time.Second, func() {})
+	c.Check(err, Equals, prompting_errors.ErrPromptNotFound)
+}
+
+func (s *requestpromptsSuite) TestDecisionTimeoutOutlivesExpiration(c *C) {
+	var timers []*testtime.TestTimer
+	restore := requestprompts.MockTimeAfterFunc(func(d time.Duration, f func()) timeutil.Timer {
+		timer := testtime.AfterFunc(d, f)
+		timers = append(timers, timer)
+		return timer
+	})
+	defer restore()
+
+	noticeChan := make(chan noticeInfo, 1)
+	pdb, err := requestprompts.New(func(userID uint32, promptID prompting.IDType, data map[string]string) error {
+		noticeChan <- noticeInfo{
+			promptID: promptID,
+			data:     data,
+		}
+		return nil
+	})
+	c.Assert(err, IsNil)
+	defer pdb.Close()
+
+	metadata := &prompting.Metadata{
+		User:      s.defaultUser,
+		Snap:      "nextcloud",
+		PID:       123,
+		Cgroup:    "some-cgroup-path",
+		Interface: "home",
+	}
+	permissions := []string{"read"}
+
+	req1, replyChan1 := newRequestWithReplyChan("fake:1")
+	prompt1, _, err := pdb.AddOrMerge(metadata, "/home/test/foo", permissions, permissions, req1)
+	c.Assert(err, IsNil)
+	checkCurrentNotices(c, noticeChan, prompt1.ID, nil)
+	c.Assert(timers, HasLen, 1)
+	expirationTimer := timers[0]
+
+	req2, replyChan2 := newRequestWithReplyChan("fake:2")
+	prompt2, _, err := pdb.AddOrMerge(metadata, "/home/test/bar", permissions, permissions, req2)
+	c.Assert(err, IsNil)
+	checkCurrentNotices(c, noticeChan, prompt2.ID, nil)
+
+	timedOut := make(chan struct{})
+	err = pdb.SetDecisionTimeout(metadata.User, prompt2.ID, time.Minute, func() {
+		_, err := pdb.ResolveOnTimeout(metadata.User, prompt2.ID, prompting.TimeoutDefaultAllowOnce)
+		c.Check(err, IsNil)
+		close(timedOut)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(timers, HasLen, 2)
+	decisionTimer := timers[1]
+
+	// Only the prompt without a decision timeout expires
+	expirationTimer.Elapse(requestprompts.InitialTimeout)
+	checkCurrentNotices(c, noticeChan, prompt1.ID, map[string]string{"resolved": "expired"})
+	c.Check(waitForReply(c, replyChan1), DeepEquals, []string{})
+	prompts, err := pdb.Prompts(metadata.User, false)
+	c.Assert(err, IsNil)
+	c.Check(prompts, DeepEquals, []*requestprompts.Prompt{prompt2})
+
+	decisionTimer.Elapse(time.Minute)
+	<-timedOut
+	checkCurrentNotices(c, noticeChan, prompt2.ID, map[string]string{"resolved": "timeout", "outcome": "allow", "reason": "allow-once"})
+	c.Check(waitForReply(c, replyChan2), DeepEquals, []string{"read"})
+}
+
+func (s *requestpromptsSuite) TestDecisionTimeoutStoppedOnReply(c *C) {
+	var timers []*testtime.TestTimer
+	restore := requestprompts.MockTimeAfterFunc(func(d time.Duration, f func()) timeutil.Timer {
+		timer := testtime.AfterFunc(d, f)
+		timers = append(timers, timer)
+		return timer
+	})
+	defer restore()
+
+	pdb, err := requestprompts.New(s.defaultNotifyPrompt)
+	c.Assert(err, IsNil)
+	defer pdb.Close()
+
+	metadata := &prompting.Metadata{
+		User:      s.defaultUser,
+		Snap:      "nextcloud",
+		PID:       123,
+		Cgroup:    "some-cgroup-path",
+		Interface: "home",
+	}
+	permissions := []string{"read"}
+
+	req, _ := newRequestWithReplyChan("fake:1")
+	prompt, _, err := pdb.AddOrMerge(metadata, "/home/test/foo", permissions, permissions, req)
+	c.Assert(err, IsNil)
+
+	err = pdb.SetDecisionTimeout(metadata.User, prompt.ID, time.Minute, func() {
+		c.Errorf("unexpected decision timeout")
+	})
+	c.Assert(err, IsNil)
+	decisionTimer := timers[len(timers)-1]
+	c.Check(decisionTimer.Active(), Equals, true)
+
+	_, err = pdb.Reply(metadata.User, prompt.ID, prompting.OutcomeAllow, true)
+	c.Assert(err, IsNil)
+	c.Check(decisionTimer.Active(), Equals, false)
+	decisionTimer.Elapse(time.Minute)
+	c.Check(decisionTimer.FireCount(), Equals, 0)
+}
+
 func (s *requestpromptsSuite) TestHandleNewRule(c *C) {
 	for _, testCase := range []struct {
 		requestedPath string
@@ -2359,6 +2547,32 @@ func (s *requestpromptsSuite) TestPromptMarshalJSON(c *C) {
 			outstandingPerms: []string{"write"},
 			expected:         `{"id":"0000000000000004","timestamp":"2024-08-14T09:47:03.350324989-05:00","snap":"firefox","pid":1234,"cgroup":"0::/user.slice/user-1000.slice/user@1000.service/app.slice/some-cgroup.scope","interface":"home","constraints":{"path":"/home/test/foo\\*\\?()\\[\\]\\{\\}'\",\\\\","requested-permissions":["write"],"available-permissions":["read","write","execute"]}}`,
 		},
+		{
+			metadata: &prompting.Metadata{
+				User:      s.defaultUser,
+				Snap:      "vlc",
+				PID:       4321,
+				Cgroup:    "0::/user.slice/user-1000.slice/user@1000.service/app.slice/some-cgroup.scope",
+				Interface: "removable-media",
+			},
+			path:             "/media/test/usb/movie.mkv",
+			requestedPerms:   []string{"read"},
+			outstandingPerms: []string{"read"},
+			expected:         `{"id":"0000000000000005","timestamp":"2024-08-14T09:47:03.350324989-05:00","snap":"vlc","pid":4321,"cgroup":"0::/user.slice/user-1000.slice/user@1000.service/app.slice/some-cgroup.scope","interface":"removable-media","constraints":{"path":"/media/test/usb/movie.mkv","requested-permissions":["read"],"available-permissions":["read","write","execute"]}}`,
+		},
+		{
+			metadata: &prompting.Metadata{
+				User:      s.defaultUser,
+				Snap:      "code",
+				PID:       8765,
+				Cgroup:    "0::/user.slice/user-1000.slice/user@1000.service/app.slice/some-cgroup.scope",
+				Interface: "personal-files",
+			},
+			path:             "/home/test/.gitconfig",
+			requestedPerms:   []string{"read", "write"},
+			outstandingPerms: []string{"write"},
+			expected:         `{"id":"0000000000000006","timestamp":"2024-08-14T09:47:03.350324989-05:00","snap":"code","pid":8765,"cgroup":"0::/user.slice/user-1000.slice/user@1000.service/app.slice/some-cgroup.scope","interface":"personal-files","constraints":{"path":"/home/test/.gitconfig","requested-permissions":["write"],"available-permissions":["read","write"]}}`,
+		},
 	} {
 		fakeRequest := &prompting.Request{Key: fmt.Sprintf("fake:%d", reqCount)}
 		reqCount++
@@ -26,6 +26,7 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -53,6 +54,10 @@ var (
 	errNoUserSession = errors.New("cannot find systemd user session tmpfs for user")
 )
 
+// SystemWideUser is the user ID associated with rules which apply to every
+// user. It is (uid_t)-1, which can never be the ID of an actual user.
+const SystemWideUser uint32 = math.MaxUint32
+
 // Rule stores the contents of a request rule.
 type Rule struct {
 	ID          prompting.IDType           `json:"id"`
@@ -61,6 +66,9 @@ type Rule struct {
 	Snap        string                     `json:"snap"`
 	Interface   string                     `json:"interface"`
 	Constraints *prompting.RuleConstraints `json:"constraints"`
+	// AdminLocked marks a rule managed by the administrator, which cannot
+	// be patched or removed through user requests.
+	AdminLocked bool `json:"admin-locked,omitempty"`
 }
 
 func (rule *Rule) UnmarshalJSON(data []byte) error {
@@ -71,6 +79,7 @@ func (rule *Rule) UnmarshalJSON(data []byte) error {
 		Snap        string                    `json:"snap"`
 		Interface   string                    `json:"interface"`
 		Constraints prompting.ConstraintsJSON `json:"constraints"`
+		AdminLocked bool                      `json:"admin-locked,omitempty"`
 	}
 	var intermediate ruleJSON
 	if err := json.Unmarshal(data, &intermediate); err != nil {
@@ -86,6 +95,7 @@ func (rule *Rule) UnmarshalJSON(data []byte) error {
 	rule.Snap = intermediate.Snap
 	rule.Interface = intermediate.Interface
 	rule.Constraints = constraints
+	rule.AdminLocked = intermediate.AdminLocked
 	return nil
 }
 
@@ -450,6 +460,12 @@ func (rdb *RuleDB) addOrMergeRule(rule *Rule, at prompting.At, save bool) (added
 		}
 		return rule, false, nil
 	}
+	if existingRule.AdminLocked != rule.AdminLocked {
+		// Rules managed by the administrator must not be extended by user
+		// rules, nor absorb them, since the result could not be modified by
+		// either the user or the administrator in a sensible way.
+		return nil, false, fmt.Errorf("%w: rule %s has identical path pattern", prompting_errors.ErrRuleLocked, existingRule.ID)
+	}
 
 	newPermissions := make(prompting.RulePermissionMap)
 	// Add any non-expired permissions from the existing rule. Each might later
@@ -1081,6 +1097,16 @@ func (rdb *RuleDB) makeNewRule(user uint32, snap string, iface string, constrain
 // IsRequestAllowed checks whether a request with the given parameters is
 // allowed or denied by existing rules.
 //
+// Rules which apply to every user are considered together with the rules of
+// the given user, with the following precedence:
+//  1. system-wide rules locked by the administrator
+//  2. rules of the given user locked by the administrator
+//  3. other rules of the given user
+//  4. system-wide rules which are not locked, acting as defaults
+//
+// The first of these which has a rule matching the path decides the outcome
+// for each permission, using path pattern precedence within that group.
+//
 // If any of the given permissions are allowed, they are returned as
 // allowedPerms. If any permissions are denied, then returns anyDenied as true.
 // If any of the given permissions were not matched by an existing rule, then
@@ -1120,12 +1146,42 @@ var isPathPermAllowed = (*RuleDB).isPathPermAllowed
 
 // isPathPermAllowed checks whether the given path with the given permission is
 // allowed or denied by existing rules for the given user, snap, and interface,
-// at the given point in time.
+// at the given point in time, honouring the precedence of system-wide rules
+// described in IsRequestAllowed.
 //
 // If no rule applies, returns prompting_errors.ErrNoMatchingRule.
 func (rdb *RuleDB) isPathPermAllowed(user uint32, snap string, iface string, path string, permission string, at prompting.At) (bool, error) {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
+	unlocked := func(e *variantEntry) bool { return !rdb.variantEntryLocked(e) }
+	tiers := []struct {
+		user    uint32
+		include func(e *variantEntry) bool
+	}{
+		{SystemWideUser, rdb.variantEntryLocked},
+		{user, rdb.variantEntryLocked},
+		{user, unlocked},
+		{SystemWideUser, unlocked},
+	}
+	for _, tier := range tiers {
+		allowed, err := rdb.matchPathPermission(tier.user, snap, iface, path, permission, at, tier.include)
+		if errors.Is(err, prompting_errors.ErrNoMatchingRule) {
+			continue
+		}
+		return allowed, err
+	}
+	return false, prompting_errors.ErrNoMatchingRule
+}
+
+// matchPathPermission checks whether the given path with the given permission
+// is allowed or denied by the rules of the given user, snap, and interface,
+// at the given point in time. If include is not nil, only variant entries for
+// which it returns true are considered.
+//
+// If no rule applies, returns prompting_errors.ErrNoMatchingRule.
+//
+// The caller must ensure that the database lock is held.
+func (rdb *RuleDB) matchPathPermission(user uint32, snap string, iface string, path string, permission string, at prompting.At, include func(e *variantEntry) bool) (bool, error) {
 	permissionMap := rdb.permissionDBForUserSnapInterfacePermission(user, snap, iface, permission)
 	if permissionMap == nil {
 		return false, prompting_errors.ErrNoMatchingRule
@@ -1136,6 +1192,9 @@ func (rdb *RuleDB) isPathPermAllowed(user uint32, snap string, iface string, pat
 		if variantEntry.expired(at) {
 			continue
 		}
+		if include != nil && !include(&variantEntry) {
+			continue
+		}
 
 		// Need to compare the path pattern variant, not the rule's path
 		// pattern, so that only variants which match are included,
@@ -1160,6 +1219,20 @@ func (rdb *RuleDB) isPathPermAllowed(user uint32, snap string, iface string, pat
 	return matchingEntry.Outcome.AsBool()
 }
 
+// variantEntryLocked returns true if any of the rules associated with the
+// given variant entry is locked by the administrator.
+//
+// The caller must ensure that the database lock is held.
+func (rdb *RuleDB) variantEntryLocked(e *variantEntry) bool {
+	for id := range e.RuleEntries {
+		rule, err := rdb.lookupRuleByID(id)
+		if err == nil && rule.AdminLocked {
+			return true
+		}
+	}
+	return false
+}
+
 // RuleWithID returns the rule with the given ID.
 // If the rule is not found, returns ErrRuleNotFound.
 // If the rule does not apply to the given user, returns
@@ -1267,6 +1340,8 @@ func (rdb *RuleDB) lookupRuleByIDForUser(user uint32, id prompting.IDType) (*Rul
 
 // RemoveRule the rule with the given ID from the rule database. If the rule
 // does not apply to the given user, returns prompting_errors.ErrRuleNotAllowed.
+// If the rule is locked by the administrator, returns
+// prompting_errors.ErrRuleLocked.
 // If successful, saves the database to disk.
 func (rdb *RuleDB) RemoveRule(user uint32, id prompting.IDType) (*Rule, error) {
 	rdb.mutex.Lock()
@@ -1281,6 +1356,9 @@ func (rdb *RuleDB) RemoveRule(user uint32, id prompting.IDType) (*Rule, error) {
 		// The rule doesn't exist or the user doesn't have access
 		return nil, err
 	}
+	if rule.AdminLocked {
+		return nil, prompting_errors.ErrRuleLocked
+	}
 
 	rdb.removeRuleByIDFromRulesList(id)
 	// We know the rule exists, so this should not error
@@ -1305,11 +1383,12 @@ func (rdb *RuleDB) RemoveRule(user uint32, id prompting.IDType) (*Rule, error) {
 
 // RemoveRulesForSnap removes all rules pertaining to the given snap for the
 // user with the given user ID.
+// Rules locked by the administrator are left in place.
 func (rdb *RuleDB) RemoveRulesForSnap(user uint32, snap string) ([]*Rule, error) {
 	rdb.mutex.Lock()
 	defer rdb.mutex.Unlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user && rule.Snap == snap
+		return rule.User == user && rule.Snap == snap && !rule.AdminLocked
 	}
 	rules := rdb.rulesInternal(ruleFilter)
 	if err := rdb.removeRulesInternal(user, rules); err != nil {
@@ -1364,11 +1443,12 @@ func (rdb *RuleDB) removeRulesInternal(user uint32, rules []*Rule) error {
 
 // RemoveRulesForInterface removes all rules pertaining to the given interface
 // for the user with the given user ID.
+// Rules locked by the administrator are left in place.
 func (rdb *RuleDB) RemoveRulesForInterface(user uint32, iface string) ([]*Rule, error) {
 	rdb.mutex.Lock()
 	defer rdb.mutex.Unlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user && rule.Interface == iface
+		return rule.User == user && rule.Interface == iface && !rule.AdminLocked
 	}
 	rules := rdb.rulesInternal(ruleFilter)
 	if err := rdb.removeRulesInternal(user, rules); err != nil {
@@ -1379,11 +1459,12 @@ func (rdb *RuleDB) RemoveRulesForInterface(user uint32, iface string) ([]*Rule,
 
 // RemoveRulesForSnapInterface removes all rules pertaining to the given snap
 // and interface for the user with the given user ID.
+// Rules locked by the administrator are left in place.
 func (rdb *RuleDB) RemoveRulesForSnapInterface(user uint32, snap string, iface string) ([]*Rule, error) {
 	rdb.mutex.Lock()
 	defer rdb.mutex.Unlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user && rule.Snap == snap && rule.Interface == iface
+		return rule.User == user && rule.Snap == snap && rule.Interface == iface && !rule.AdminLocked
 	}
 	rules := rdb.rulesInternal(ruleFilter)
 	if err := rdb.removeRulesInternal(user, rules); err != nil {
@@ -1410,7 +1491,7 @@ func (rdb *RuleDB) RemoveRulesForSnapInterface(user uint32, snap string, iface s
 // the timestamp of the rule is updated to the current time. If there is any
 // error while modifying the rule, the rule is rolled back to its previous
 // unmodified state, leaving the database unchanged. If the database is changed,
-// it is saved to disk.
+// it is saved to disk. Rules locked by the administrator cannot be patched.
 func (rdb *RuleDB) PatchRule(user uint32, id prompting.IDType, constraintsPatch *prompting.RuleConstraintsPatch) (r *Rule, err error) {
 	rdb.mutex.Lock()
 	defer rdb.mutex.Unlock()
@@ -1423,6 +1504,9 @@ func (rdb *RuleDB) PatchRule(user uint32, id prompting.IDType, constraintsPatch
 	if err != nil {
 		return nil, err
 	}
+	if origRule.AdminLocked {
+		return nil, prompting_errors.ErrRuleLocked
+	}
 
 	// XXX: we don't currently check whether the rule is fully expired or not.
 	// Do we want to support patching a rule for which all the permissions
@@ -0,0 +1,245 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package requestrules
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/interfaces/prompting"
+	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// RuleSet is a collection of rules which can be exported from a rule database
+// and imported into another one, for example to pre-seed rules on managed
+// machines.
+//
+// When importing, the ID, timestamp, and user of each rule are ignored, and
+// are instead assigned by the importing rule database.
+type RuleSet struct {
+	Rules []*Rule `json:"rules"`
+}
+
+// ImportOptions holds options for importing a rule set.
+type ImportOptions struct {
+	// Replace removes the existing rules managed by the administrator for the
+	// target user before importing the rule set. When importing system-wide
+	// rules, every existing system-wide rule is removed.
+	Replace bool
+}
+
+// ExportRules returns the rules which belong to the given user as a rule set.
+// Rules which apply to every user can be exported by passing SystemWideUser.
+//
+// Rules with lifespan "session" are tied to the current machine and are
+// omitted, as are expired rules.
+func (rdb *RuleDB) ExportRules(user uint32) *RuleSet {
+	rdb.mutex.RLock()
+	defer rdb.mutex.RUnlock()
+	ruleFilter := func(rule *Rule) bool {
+		return rule.User == user
+	}
+	ruleSet := &RuleSet{Rules: make([]*Rule, 0)}
+	for _, rule := range rdb.rulesInternal(ruleFilter) {
+		permissions := make(prompting.RulePermissionMap, len(rule.Constraints.Permissions))
+		for perm, entry := range rule.Constraints.Permissions {
+			if entry.Lifespan == prompting.LifespanSession {
+				continue
+			}
+			permissions[perm] = entry
+		}
+		if len(permissions) == 0 {
+			continue
+		}
+		exported := *rule
+		exported.Constraints = &prompting.RuleConstraints{
+			InterfaceSpecific: rule.Constraints.InterfaceSpecific,
+			Permissions:       permissions,
+		}
+		ruleSet.Rules = append(ruleSet.Rules, &exported)
+	}
+	return ruleSet
+}
+
+// ImportRules adds the rules in the given rule set to the rule database for
+// the given user, or for every user if the user is SystemWideUser. Rules which
+// are fully expired are skipped. Imported rules may be merged with existing
+// rules which have an identical path pattern and the same locked status.
+//
+// Rules with lifespan "session" cannot be imported, since session IDs are only
+// meaningful on the machine where they were assigned.
+//
+// The import is atomic: if any rule is invalid or conflicts with an existing
+// rule, or the database cannot be saved, the database is left unchanged.
+//
+// Returns the rules which were added or merged as a result of the import.
+func (rdb *RuleDB) ImportRules(user uint32, ruleSet *RuleSet, opts *ImportOptions) ([]*Rule, error) {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+
+	rdb.mutex.Lock()
+	defer rdb.mutex.Unlock()
+
+	if rdb.maxIDMmap.IsClosed() {
+		return nil, prompting_errors.ErrPromptingClosed
+	}
+
+	sessionID, err := make(userSessionIDCache).getUserSessionID(rdb, user)
+	if err != nil {
+		return nil, err
+	}
+	at := prompting.At{
+		Time:      time.Now(),
+		SessionID: sessionID,
+	}
+
+	toImport := make([]*Rule, 0, len(ruleSet.Rules))
+	for i, rule := range ruleSet.Rules {
+		if err := validateImportedRule(rule); err != nil {
+			return nil, fmt.Errorf("cannot import rule %d: %w", i, err)
+		}
+		newRule := &Rule{
+			Timestamp: at.Time,
+			User:      user,
+			Snap:      rule.Snap,
+			Interface: rule.Interface,
+			Constraints: &prompting.RuleConstraints{
+				InterfaceSpecific: rule.Constraints.InterfaceSpecific,
+				Permissions:       make(prompting.RulePermissionMap, len(rule.Constraints.Permissions)),
+			},
+			AdminLocked: rule.AdminLocked,
+		}
+		for perm, entry := range rule.Constraints.Permissions {
+			newRule.Constraints.Permissions[perm] = entry
+		}
+		if newRule.pruneExpired(at) == prompting.AllPermsExpired {
+			continue
+		}
+		toImport = append(toImport, newRule)
+	}
+
+	// Keep the original rules so that the database can be rebuilt if
+	// anything goes wrong.
+	origRules := make([]*Rule, len(rdb.rules))
+	copy(origRules, rdb.rules)
+
+	var replaced []*Rule
+	if opts.Replace {
+		for _, rule := range origRules {
+			if rule.User == user && (user == SystemWideUser || rule.AdminLocked) {
+				replaced = append(replaced, rule)
+			}
+		}
+	}
+	for _, rule := range replaced {
+		rdb.removeRuleByID(rule.ID)
+	}
+
+	imported := make([]*Rule, 0, len(toImport))
+	seen := make(map[prompting.IDType]bool)
+	const save = false
+	for _, rule := range toImport {
+		addedRule, merged, err := rdb.addOrMergeRule(rule, at, save)
+		if err != nil {
+			rdb.restoreRules(origRules)
+			return nil, fmt.Errorf("cannot import rule: %w", err)
+		}
+		if merged && seen[addedRule.ID] {
+			// The rule was merged into another one imported just now, so
+			// replace that entry with the merged result.
+			for i, prev := range imported {
+				if prev.ID == addedRule.ID {
+					imported[i] = addedRule
+				}
+			}
+			continue
+		}
+		seen[addedRule.ID] = true
+		imported = append(imported, addedRule)
+	}
+
+	if err := rdb.save(); err != nil {
+		rdb.restoreRules(origRules)
+		return nil, err
+	}
+
+	removedData := map[string]string{"removed": "removed"}
+	for _, rule := range replaced {
+		rdb.notifyRule(rule.User, rule.ID, removedData)
+	}
+	importedIDs := make([]string, 0, len(imported))
+	for _, rule := range imported {
+		importedIDs = append(importedIDs, rule.ID.String())
+		rdb.notifyRule(rule.User, rule.ID, nil)
+	}
+	logger.Debugf("imported rules: %q", importedIDs)
+	return imported, nil
+}
+
+// validateImportedRule checks that the given rule from a rule set can be
+// imported.
+func validateImportedRule(rule *Rule) error {
+	if rule == nil || rule.Constraints == nil {
+		return prompting_errors.NewMissingFieldError("constraints", `must have "constraints" field`)
+	}
+	if rule.Snap == "" {
+		return prompting_errors.NewMissingFieldError("snap", `must have non-empty "snap" field`)
+	}
+	if rule.Interface == "" {
+		return prompting_errors.NewMissingFieldError("interface", `must have non-empty "interface" field`)
+	}
+	for _, entry := range rule.Constraints.Permissions {
+		if entry.Lifespan == prompting.LifespanSession {
+			return prompting_errors.ErrImportedRuleHasSession
+		}
+	}
+	return nil
+}
+
+// restoreRules resets the rule database to contain exactly the given rules.
+// It is used to roll back changes which affect many rules at once.
+//
+// The caller must ensure that the database lock is held for writing.
+func (rdb *RuleDB) restoreRules(rules []*Rule) {
+	rdb.indexByID = make(map[prompting.IDType]int)
+	rdb.rules = make([]*Rule, 0, len(rules))
+	rdb.perUser = make(map[uint32]*userDB)
+
+	at := prompting.At{
+		Time: time.Now(),
+		// SessionID is set for each rule
+	}
+	sessionIDCache := make(userSessionIDCache)
+	var errs []error
+	for _, rule := range rules {
+		at.SessionID, _ = sessionIDCache.getUserSessionID(rdb, rule.User)
+		// The rules were consistent before, so re-adding them should not
+		// fail.
+		if err := rdb.addNewRule(rule, at, false); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := strutil.JoinErrors(errs...); err != nil {
+		logger.Noticef("cannot restore rule database: %v", err)
+	}
+}
@@ -0,0 +1,319 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package requestrules_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces/prompting"
+	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
+	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
+)
+
+func mustUnmarshalRuleSet(c *C, data string) *requestrules.RuleSet {
+	var ruleSet requestrules.RuleSet
+	c.Assert(json.Unmarshal([]byte(data), &ruleSet), IsNil)
+	return &ruleSet
+}
+
+func (s *requestrulesSuite) isAllowed(c *C, rdb *requestrules.RuleDB, user uint32, path string) (bool, error) {
+	at := prompting.At{
+		Time:      time.Now(),
+		SessionID: s.currSession,
+	}
+	return rdb.IsPathPermAllowed(user, "firefox", "home", path, "read", at)
+}
+
+func (s *requestrulesSuite) TestImportRulesSystemWidePrecedence(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	ruleSet := mustUnmarshalRuleSet(c, `{"rules":[
+		{"snap":"firefox","interface":"home","admin-locked":true,"constraints":{"path-pattern":"/home/test/secret/**","permissions":{"read":{"outcome":"deny","lifespan":"forever"}}}},
+		{"snap":"firefox","interface":"home","constraints":{"path-pattern":"/home/test/**","permissions":{"read":{"outcome":"allow","lifespan":"forever"}}}}
+	]}`)
+	imported, err := rdb.ImportRules(requestrules.SystemWideUser, ruleSet, nil)
+	c.Assert(err, IsNil)
+	c.Assert(imported, HasLen, 2)
+	for _, rule := range imported {
+		c.Check(rule.User, Equals, requestrules.SystemWideUser)
+		c.Check(rule.ID, Not(Equals), prompting.IDType(0))
+	}
+	c.Check(imported[0].AdminLocked, Equals, true)
+	c.Check(imported[1].AdminLocked, Equals, false)
+	s.checkWrittenRuleDB(c, imported)
+	s.checkNewNoticesSimple(c, nil, imported...)
+
+	// System-wide rules apply to every user
+	for _, user := range []uint32{1000, 1001} {
+		allowed, err := s.isAllowed(c, rdb, user, "/home/test/foo.txt")
+		c.Check(err, IsNil)
+		c.Check(allowed, Equals, true)
+		allowed, err = s.isAllowed(c, rdb, user, "/home/test/secret/foo.txt")
+		c.Check(err, IsNil)
+		c.Check(allowed, Equals, false)
+	}
+
+	// User rules take precedence over unlocked system-wide rules, but not
+	// over locked ones, even if the user rule has a more specific pattern.
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		Permissions: []string{"read"},
+		Lifespan:    prompting.LifespanForever,
+	}
+	_, err = addRuleFromTemplate(c, rdb, template, &addRuleContents{PathPattern: "/home/test/private/**", Outcome: prompting.OutcomeDeny})
+	c.Assert(err, IsNil)
+	_, err = addRuleFromTemplate(c, rdb, template, &addRuleContents{PathPattern: "/home/test/secret/mine.txt", Outcome: prompting.OutcomeAllow})
+	c.Assert(err, IsNil)
+
+	allowed, err := s.isAllowed(c, rdb, s.defaultUser, "/home/test/private/foo.txt")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, false)
+	allowed, err = s.isAllowed(c, rdb, s.defaultUser, "/home/test/secret/mine.txt")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, false)
+
+	// Other users are unaffected by the rules of the default user
+	allowed, err = s.isAllowed(c, rdb, 1001, "/home/test/private/foo.txt")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, true)
+
+	// Nothing matches outside the imported rules
+	_, err = s.isAllowed(c, rdb, 1001, "/etc/passwd")
+	c.Check(err, Equals, prompting_errors.ErrNoMatchingRule)
+}
+
+func (s *requestrulesSuite) TestImportRulesAdminLockedForUser(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	ruleSet := mustUnmarshalRuleSet(c, `{"rules":[
+		{"snap":"firefox","interface":"home","admin-locked":true,"constraints":{"path-pattern":"/home/test/Documents/**","permissions":{"read":{"outcome":"allow","lifespan":"forever"}}}}
+	]}`)
+	imported, err := rdb.ImportRules(s.defaultUser, ruleSet, nil)
+	c.Assert(err, IsNil)
+	c.Assert(imported, HasLen, 1)
+	locked := imported[0]
+	c.Check(locked.User, Equals, s.defaultUser)
+	c.Check(locked.AdminLocked, Equals, true)
+	s.checkNewNoticesSimple(c, nil, locked)
+
+	_, err = rdb.RemoveRule(s.defaultUser, locked.ID)
+	c.Check(err, Equals, prompting_errors.ErrRuleLocked)
+	_, err = rdb.PatchRule(s.defaultUser, locked.ID, nil)
+	c.Check(err, Equals, prompting_errors.ErrRuleLocked)
+
+	// Bulk removal leaves locked rules in place
+	removed, err := rdb.RemoveRulesForSnap(s.defaultUser, "firefox")
+	c.Check(err, IsNil)
+	c.Check(removed, HasLen, 0)
+	c.Check(rdb.Rules(s.defaultUser), DeepEquals, []*requestrules.Rule{locked})
+
+	// The user cannot extend the locked rule by adding a rule with an
+	// identical path pattern
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		PathPattern: "/home/test/Documents/**",
+		Permissions: []string{"write"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}
+	_, err = addRuleFromTemplate(c, rdb, template, nil)
+	c.Check(err, ErrorMatches, "cannot add rule: cannot modify rule locked by the administrator: rule .* has identical path pattern")
+	s.checkNewNoticesSimple(c, nil)
+}
+
+func (s *requestrulesSuite) TestImportRulesAdminLockedForUserPrecedence(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	ruleSet := mustUnmarshalRuleSet(c, `{"rules":[
+		{"snap":"firefox","interface":"home","admin-locked":true,"constraints":{"path-pattern":"/home/test/secret/**","permissions":{"read":{"outcome":"deny","lifespan":"forever"}}}}
+	]}`)
+	_, err = rdb.ImportRules(s.defaultUser, ruleSet, nil)
+	c.Assert(err, IsNil)
+
+	// A more specific rule of the user does not override the locked one
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		Permissions: []string{"read"},
+		Lifespan:    prompting.LifespanForever,
+	}
+	_, err = addRuleFromTemplate(c, rdb, template, &addRuleContents{PathPattern: "/home/test/secret/mine.txt", Outcome: prompting.OutcomeAllow})
+	c.Assert(err, IsNil)
+	_, err = addRuleFromTemplate(c, rdb, template, &addRuleContents{PathPattern: "/home/test/**", Outcome: prompting.OutcomeAllow})
+	c.Assert(err, IsNil)
+
+	allowed, err := s.isAllowed(c, rdb, s.defaultUser, "/home/test/secret/mine.txt")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, false)
+
+	// The user rules still apply where the locked rule does not match
+	allowed, err = s.isAllowed(c, rdb, s.defaultUser, "/home/test/foo.txt")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, true)
+}
+
+func (s *requestrulesSuite) TestImportRulesReplace(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		PathPattern: "/home/test/Pictures/**",
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}
+	userRule, err := addRuleFromTemplate(c, rdb, template, nil)
+	c.Assert(err, IsNil)
+	s.checkNewNoticesSimple(c, nil, userRule)
+
+	first := mustUnmarshalRuleSet(c, `{"rules":[
+		{"snap":"firefox","interface":"home","admin-locked":true,"constraints":{"path-pattern":"/home/test/Documents/**","permissions":{"read":{"outcome":"allow","lifespan":"forever"}}}}
+	]}`)
+	oldLocked, err := rdb.ImportRules(s.defaultUser, first, nil)
+	c.Assert(err, IsNil)
+	s.checkNewNoticesSimple(c, nil, oldLocked...)
+
+	second := mustUnmarshalRuleSet(c, `{"rules":[
+		{"snap":"firefox","interface":"home","admin-locked":true,"constraints":{"path-pattern":"/home/test/Music/**","permissions":{"read":{"outcome":"deny","lifespan":"forever"}}}}
+	]}`)
+	newLocked, err := rdb.ImportRules(s.defaultUser, second, &requestrules.ImportOptions{Replace: true})
+	c.Assert(err, IsNil)
+	c.Assert(newLocked, HasLen, 1)
+	s.checkNewNotices(c, []*noticeInfo{
+		{userID: s.defaultUser, ruleID: oldLocked[0].ID, data: map[string]string{"removed": "removed"}},
+		{userID: s.defaultUser, ruleID: newLocked[0].ID},
+	})
+
+	// Only the locked rule was replaced, the user's own rule remains
+	rules := rdb.Rules(s.defaultUser)
+	c.Check(rules, HasLen, 2)
+	ids := map[prompting.IDType]bool{}
+	for _, rule := range rules {
+		ids[rule.ID] = true
+	}
+	c.Check(ids, DeepEquals, map[prompting.IDType]bool{userRule.ID: true, newLocked[0].ID: true})
+}
+
+func (s *requestrulesSuite) TestImportRulesAtomic(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		PathPattern: "/home/test/Documents/**",
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}
+	userRule, err := addRuleFromTemplate(c, rdb, template, nil)
+	c.Assert(err, IsNil)
+	s.checkNewNoticesSimple(c, nil, userRule)
+
+	// The second rule conflicts with the existing user rule
+	ruleSet := mustUnmarshalRuleSet(c, `{"rules":[
+		{"snap":"firefox","interface":"home","constraints":{"path-pattern":"/home/test/Music/**","permissions":{"read":{"outcome":"allow","lifespan":"forever"}}}},
+		{"snap":"firefox","interface":"home","constraints":{"path-pattern":"/home/test/Documents/**","permissions":{"read":{"outcome":"deny","lifespan":"forever"}}}}
+	]}`)
+	_, err = rdb.ImportRules(s.defaultUser, ruleSet, nil)
+	c.Check(err, ErrorMatches, "cannot import rule: a rule with conflicting path pattern and permission already exists in the rule database")
+	c.Check(rdb.Rules(s.defaultUser), DeepEquals, []*requestrules.Rule{userRule})
+	s.checkWrittenRuleDB(c, []*requestrules.Rule{userRule})
+	s.checkNewNoticesSimple(c, nil)
+
+	allowed, err := s.isAllowed(c, rdb, s.defaultUser, "/home/test/Documents/foo.txt")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, true)
+	_, err = s.isAllowed(c, rdb, s.defaultUser, "/home/test/Music/foo.txt")
+	c.Check(err, Equals, prompting_errors.ErrNoMatchingRule)
+}
+
+func (s *requestrulesSuite) TestImportRulesErrors(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	for _, testCase := range []struct {
+		ruleSet string
+		errStr  string
+	}{
+		{
+			`{"rules":[{"snap":"firefox","interface":"home","constraints":{"path-pattern":"/home/test/**","permissions":{"read":{"outcome":"allow","lifespan":"session","session-id":"1234"}}}}]}`,
+			`cannot import rule 0: cannot import rule with lifespan "session"`,
+		},
+		{
+			`{"rules":[{"interface":"home","constraints":{"path-pattern":"/home/test/**","permissions":{"read":{"outcome":"allow","lifespan":"forever"}}}}]}`,
+			`cannot import rule 0: must have non-empty "snap" field`,
+		},
+	} {
+		_, err := rdb.ImportRules(s.defaultUser, mustUnmarshalRuleSet(c, testCase.ruleSet), nil)
+		c.Check(err, ErrorMatches, testCase.errStr)
+	}
+	c.Check(rdb.Rules(s.defaultUser), HasLen, 0)
+}
+
+func (s *requestrulesSuite) TestExportRulesRoundTrip(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		PathPattern: "/home/test/Documents/**",
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}
+	_, err = addRuleFromTemplate(c, rdb, template, nil)
+	c.Assert(err, IsNil)
+	// Session rules are not exported
+	_, err = addRuleFromTemplate(c, rdb, template, &addRuleContents{PathPattern: "/home/test/Music/**", Lifespan: prompting.LifespanSession})
+	c.Assert(err, IsNil)
+
+	exported := rdb.ExportRules(s.defaultUser)
+	c.Assert(exported.Rules, HasLen, 1)
+	c.Check(exported.Rules[0].Constraints.PathPattern().String(), Equals, "/home/test/Documents/**")
+	c.Check(rdb.ExportRules(requestrules.SystemWideUser).Rules, HasLen, 0)
+
+	data, err := json.Marshal(exported)
+	c.Assert(err, IsNil)
+
+	imported, err := rdb.ImportRules(1001, mustUnmarshalRuleSet(c, string(data)), nil)
+	c.Assert(err, IsNil)
+	c.Assert(imported, HasLen, 1)
+	c.Check(imported[0].User, Equals, uint32(1001))
+	c.Check(imported[0].Constraints.PathPattern().String(), Equals, "/home/test/Documents/**")
+	c.Check(imported[0].ID, Not(Equals), exported.Rules[0].ID)
+}
@@ -0,0 +1,98 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package prompting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeoutDefaultType describes how a prompt should be resolved if no client
+// replies to it before the prompt timeout configured for its interface.
+type TimeoutDefaultType string
+
+const (
+	// TimeoutDefaultDeny indicates that a prompt which timed out should be
+	// denied.
+	TimeoutDefaultDeny TimeoutDefaultType = "deny"
+	// TimeoutDefaultAllowOnce indicates that a prompt which timed out should
+	// be allowed, without creating a rule.
+	TimeoutDefaultAllowOnce TimeoutDefaultType = "allow-once"
+	// TimeoutDefaultAllowWithRule indicates that a prompt which timed out
+	// should be allowed, and a rule with lifespan "forever" should be created
+	// for the path and permissions of the prompt.
+	TimeoutDefaultAllowWithRule TimeoutDefaultType = "allow-with-rule"
+)
+
+var supportedTimeoutDefaults = []string{
+	string(TimeoutDefaultDeny),
+	string(TimeoutDefaultAllowOnce),
+	string(TimeoutDefaultAllowWithRule),
+}
+
+// ParseTimeoutDefault parses the given string as a prompt timeout default.
+// An empty string is treated as TimeoutDefaultDeny.
+func ParseTimeoutDefault(s string) (TimeoutDefaultType, error) {
+	switch TimeoutDefaultType(s) {
+	case "":
+		return TimeoutDefaultDeny, nil
+	case TimeoutDefaultDeny, TimeoutDefaultAllowOnce, TimeoutDefaultAllowWithRule:
+		return TimeoutDefaultType(s), nil
+	}
+	return "", fmt.Errorf("invalid prompt timeout default %q: must be one of %s", s, strings.Join(supportedTimeoutDefaults, ", "))
+}
+
+// Outcome returns the outcome with which a prompt which timed out should be
+// resolved.
+func (d TimeoutDefaultType) Outcome() OutcomeType {
+	switch d {
+	case TimeoutDefaultAllowOnce, TimeoutDefaultAllowWithRule:
+		return OutcomeAllow
+	}
+	return OutcomeDeny
+}
+
+// ParseTimeout parses the given string as a prompt timeout duration. An empty
+// string or a zero duration disables the timeout.
+func ParseTimeout(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid prompt timeout %q: %v", s, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid prompt timeout %q: cannot be negative", s)
+	}
+	return d, nil
+}
+
+// TimeoutPolicy describes how long prompts for a particular interface may
+// remain unanswered, and how they should be resolved once that time elapses.
+type TimeoutPolicy struct {
+	// Timeout is the duration after which an unanswered prompt is resolved.
+	// A zero duration means prompts are held until a client replies or the
+	// prompts expire.
+	Timeout time.Duration
+	// Default is the policy applied to prompts which time out.
+	Default TimeoutDefaultType
+}
@@ -0,0 +1,79 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package prompting_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces/prompting"
+)
+
+type timeoutSuite struct{}
+
+var _ = Suite(&timeoutSuite{})
+
+func (s *timeoutSuite) TestParseTimeoutDefault(c *C) {
+	for _, testCase := range []struct {
+		input    string
+		expected prompting.TimeoutDefaultType
+		outcome  prompting.OutcomeType
+	}{
+		{"", prompting.TimeoutDefaultDeny, prompting.OutcomeDeny},
+		{"deny", prompting.TimeoutDefaultDeny, prompting.OutcomeDeny},
+		{"allow-once", prompting.TimeoutDefaultAllowOnce, prompting.OutcomeAllow},
+		{"allow-with-rule", prompting.TimeoutDefaultAllowWithRule, prompting.OutcomeAllow},
+	} {
+		parsed, err := prompting.ParseTimeoutDefault(testCase.input)
+		c.Check(err, IsNil, Commentf("input: %q", testCase.input))
+		c.Check(parsed, Equals, testCase.expected)
+		c.Check(parsed.Outcome(), Equals, testCase.outcome)
+	}
+
+	for _, input := range []string{"allow", "foo", "DENY"} {
+		_, err := prompting.ParseTimeoutDefault(input)
+		c.Check(err, ErrorMatches, `invalid prompt timeout default ".*": must be one of deny, allow-once, allow-with-rule`)
+	}
+}
+
+func (s *timeoutSuite) TestParseTimeout(c *C) {
+	for _, testCase := range []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"0s", 0},
+		{"30s", 30 * time.Second},
+		{"2m30s", 150 * time.Second},
+	} {
+		parsed, err := prompting.ParseTimeout(testCase.input)
+		c.Check(err, IsNil, Commentf("input: %q", testCase.input))
+		c.Check(parsed, Equals, testCase.expected)
+	}
+
+	_, err := prompting.ParseTimeout("foo")
+	c.Check(err, ErrorMatches, `invalid prompt timeout "foo": time: invalid duration "foo"`)
+	_, err = prompting.ParseTimeout("10")
+	c.Check(err, ErrorMatches, `invalid prompt timeout "10": time: missing unit in duration "10"`)
+	_, err = prompting.ParseTimeout("-5s")
+	c.Check(err, ErrorMatches, `invalid prompt timeout "-5s": cannot be negative`)
+}
@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/interfaces"
@@ -39,6 +40,12 @@ func serviceName(snapName, distinctServiceSuffix string) string {
 	return snap.ScopedSecurityTag(snapName, "interface", distinctServiceSuffix) + ".service"
 }
 
+// dropInName returns the name of the drop-in file added by an interface to
+// the service of an app.
+func dropInName(distinctSuffix string) string {
+	return "snap-interface-" + distinctSuffix + ".conf"
+}
+
 // Backend is responsible for maintaining special systemd units.
 type Backend struct {
 	preseed bool
@@ -96,8 +103,12 @@ func (b *Backend) Setup(appSet *interfaces.SnapAppSet, opts interfaces.Confineme
 		logger.Noticef("cannot stop removed services: %s", err)
 	}
 	changed, removed, errEnsure := osutil.EnsureDirState(dir, glob, content)
+	dropInsChanged, err := ensureServiceDropIns(snapName, spec.(*Specification).ServiceDropIns())
+	if errEnsure == nil {
+		errEnsure = err
+	}
 	// Reload systemd whenever something is added or removed
-	if !b.preseed && (len(changed) > 0 || len(removed) > 0) {
+	if !b.preseed && (len(changed) > 0 || len(removed) > 0 || dropInsChanged) {
 		err := systemd.DaemonReload()
 		if err != nil {
 			logger.Noticef("cannot reload systemd state: %s", err)
@@ -137,6 +148,10 @@ func (b *Backend) Remove(snapName string) error {
 	// Remove all the files matching snap glob
 	glob := serviceName(snapName, "*")
 	_, removed, errEnsure := osutil.EnsureDirState(dirs.SnapServicesDir, glob, nil)
+	dropInsChanged, err := ensureServiceDropIns(snapName, nil)
+	if errEnsure == nil {
+		errEnsure = err
+	}
 
 	if len(removed) > 0 {
 		logger.Noticef("systemd-backend: Disable: removed services: %q", removed)
@@ -150,7 +165,7 @@ func (b *Backend) Remove(snapName string) error {
 		}
 	}
 	// Reload systemd whenever something is removed
-	if !b.preseed && len(removed) > 0 {
+	if !b.preseed && (len(removed) > 0 || dropInsChanged) {
 		err := systemd.DaemonReload()
 		if err != nil {
 			logger.Noticef("cannot reload systemd state: %s", err)
@@ -186,6 +201,55 @@ func deriveContent(spec *Specification, appSet *interfaces.SnapAppSet) map[strin
 	return content
 }
 
+// ensureServiceDropIns synchronizes the drop-ins added by interfaces to the
+// services of the given snap with the filesystem. It returns whether any
+// drop-in was added, changed or removed.
+func ensureServiceDropIns(snapName string, dropIns map[string]map[string]*DropIn) (bool, error) {
+	// directories of services which are gone or no longer need drop-ins
+	// are cleaned up as well
+	existing, err := filepath.Glob(filepath.Join(dirs.SnapServicesDir, fmt.Sprintf("snap.%s.*.service.d", snapName)))
+	if err != nil {
+		return false, err
+	}
+	unitDirs := make(map[string]bool, len(existing)+len(dropIns))
+	for _, dir := range existing {
+		unitDirs[filepath.Base(dir)] = true
+	}
+	for serviceName := range dropIns {
+		unitDirs[serviceName+".d"] = true
+	}
+
+	anyChanged := false
+	var firstErr error
+	for unitDir := range unitDirs {
+		dir := filepath.Join(dirs.SnapServicesDir, unitDir)
+		content := make(map[string]osutil.FileState)
+		for suffix, dropIn := range dropIns[strings.TrimSuffix(unitDir, ".d")] {
+			content[dropInName(suffix)] = &osutil.MemoryFileState{
+				Content: []byte(dropIn.String()),
+				Mode:    0644,
+			}
+		}
+		if len(content) > 0 {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return anyChanged, fmt.Errorf("cannot create directory for systemd drop-ins %q: %s", dir, err)
+			}
+		}
+		changed, removed, err := osutil.EnsureDirState(dir, dropInName("*"), content)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if len(changed) > 0 || len(removed) > 0 {
+			anyChanged = true
+		}
+		if len(content) == 0 {
+			// only removes the directory when nothing else uses it
+			os.Remove(dir)
+		}
+	}
+	return anyChanged, firstErr
+}
+
 func (b *Backend) disableRemovedServices(systemd sysd.Systemd, dir, glob string, content map[string]osutil.FileState) error {
 	paths, err := filepath.Glob(filepath.Join(dir, glob))
 	if err != nil {
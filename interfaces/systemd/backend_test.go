@@ -223,3 +223,43 @@ func (s *backendSuite) TestRemovingSnapWhenPreseeding(c *C) {
 		})
 	}
 }
+
+func (s *backendSuite) TestInstallingSnapWritesServiceDropIns(c *C) {
+	s.Iface.SystemdPermanentSlotCallback = func(spec *systemd.Specification, slot *snap.SlotInfo) error {
+		return spec.AddServiceDropIn("snap.samba.smbd.service", "foo", &systemd.DropIn{After: "other.service", Wants: "other.service"})
+	}
+	snapInfo := s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 1)
+	dropIn := filepath.Join(dirs.SnapServicesDir, "snap.samba.smbd.service.d", "snap-interface-foo.conf")
+	c.Check(dropIn, testutil.FileEquals, "[Unit]\nWants=other.service\nAfter=other.service\n")
+	c.Check(s.systemctlArgs, DeepEquals, [][]string{
+		{"systemctl", "daemon-reload"},
+	})
+
+	// the drop-in is gone with the snap, along with its directory
+	s.systemctlArgs = nil
+	s.RemoveSnap(c, snapInfo)
+	c.Check(filepath.Dir(dropIn), testutil.FileAbsent)
+	c.Check(s.systemctlArgs, DeepEquals, [][]string{
+		{"systemctl", "daemon-reload"},
+	})
+}
+
+func (s *backendSuite) TestUpdatingSnapRemovesStaleServiceDropIns(c *C) {
+	s.Iface.SystemdPermanentSlotCallback = func(spec *systemd.Specification, slot *snap.SlotInfo) error {
+		return spec.AddServiceDropIn("snap.samba.smbd.service", "foo", &systemd.DropIn{After: "other.service"})
+	}
+	snapInfo := s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 1)
+	dir := filepath.Join(dirs.SnapServicesDir, "snap.samba.smbd.service.d")
+	c.Check(filepath.Join(dir, "snap-interface-foo.conf"), testutil.FilePresent)
+	// drop-ins from elsewhere are kept
+	c.Assert(os.WriteFile(filepath.Join(dir, "other.conf"), nil, 0644), IsNil)
+
+	s.Iface.SystemdPermanentSlotCallback = nil
+	s.systemctlArgs = nil
+	s.UpdateSnap(c, snapInfo, interfaces.ConfinementOptions{}, ifacetest.SambaYamlV1, 2)
+	c.Check(filepath.Join(dir, "snap-interface-foo.conf"), testutil.FileAbsent)
+	c.Check(filepath.Join(dir, "other.conf"), testutil.FilePresent)
+	c.Check(s.systemctlArgs, DeepEquals, [][]string{
+		{"systemctl", "daemon-reload"},
+	})
+}
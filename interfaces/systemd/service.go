@@ -78,3 +78,22 @@ func (s *Service) String() string {
 	}
 	return buf.String()
 }
+
+// DropIn describes additions to the unit section of a service of a snap,
+// written as a systemd drop-in file for that service.
+type DropIn struct {
+	Wants string
+	After string
+}
+
+func (d *DropIn) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("[Unit]\n")
+	if d.Wants != "" {
+		fmt.Fprintf(&buf, "Wants=%s\n", d.Wants)
+	}
+	if d.After != "" {
+		fmt.Fprintf(&buf, "After=%s\n", d.After)
+	}
+	return buf.String()
+}
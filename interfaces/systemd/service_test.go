@@ -51,3 +51,10 @@ func (s *serviceSuite) TestString(c *C) {
 	service10 := systemd.Service{Before: "snapd.mounts.target"}
 	c.Assert(service10.String(), Equals, "[Unit]\nBefore=snapd.mounts.target\n[Service]\n\n[Install]\nWantedBy=multi-user.target\n")
 }
+
+func (s *serviceSuite) TestDropInString(c *C) {
+	dropIn := systemd.DropIn{After: "snap.db.postgres.service"}
+	c.Assert(dropIn.String(), Equals, "[Unit]\nAfter=snap.db.postgres.service\n")
+	dropIn = systemd.DropIn{Wants: "a.service b.service", After: "a.service b.service"}
+	c.Assert(dropIn.String(), Equals, "[Unit]\nWants=a.service b.service\nAfter=a.service b.service\n")
+}
@@ -31,6 +31,11 @@ type addedService struct {
 	svc   *Service
 }
 
+type addedDropIn struct {
+	iface  string
+	dropIn *DropIn
+}
+
 // Specification assists in collecting custom systemd services associated with an interface.
 //
 // Unlike the Backend itself (which is stateless and non-persistent) this type
@@ -39,6 +44,7 @@ type addedService struct {
 type Specification struct {
 	curIface string
 	services map[string]*addedService
+	dropIns  map[string]map[string]*addedDropIn
 }
 
 // AddService adds a new systemd service unit.
@@ -77,6 +83,43 @@ func (spec *Specification) Services() map[string]*Service {
 	return result
 }
 
+// AddServiceDropIn adds a drop-in for the service unit of an app of the snap.
+// distinctSuffix is used to name the drop-in and needs to be unique among the
+// drop-ins of the service, similarly to the suffix given to AddService.
+func (spec *Specification) AddServiceDropIn(serviceName, distinctSuffix string, d *DropIn) error {
+	if old, ok := spec.dropIns[serviceName][distinctSuffix]; ok && *old.dropIn != *d {
+		return fmt.Errorf("internal error: interface %q has conflicting drop-ins for %q of service %q: %#v and %#v", spec.curIface, distinctSuffix, serviceName, *old.dropIn, *d)
+	}
+	if spec.dropIns == nil {
+		spec.dropIns = make(map[string]map[string]*addedDropIn)
+	}
+	if spec.dropIns[serviceName] == nil {
+		spec.dropIns[serviceName] = make(map[string]*addedDropIn)
+	}
+	spec.dropIns[serviceName][distinctSuffix] = &addedDropIn{
+		dropIn: d,
+		iface:  spec.curIface,
+	}
+	return nil
+}
+
+// ServiceDropIns returns a deep copy of all the added drop-ins keyed by
+// service name and then by drop-in suffix.
+func (spec *Specification) ServiceDropIns() map[string]map[string]*DropIn {
+	if spec.dropIns == nil {
+		return nil
+	}
+	result := make(map[string]map[string]*DropIn, len(spec.dropIns))
+	for serviceName, dropIns := range spec.dropIns {
+		result[serviceName] = make(map[string]*DropIn, len(dropIns))
+		for suffix, v := range dropIns {
+			d := *v.dropIn
+			result[serviceName][suffix] = &d
+		}
+	}
+	return result
+}
+
 // Implementation of methods required by interfaces.Specification
 
 // AddConnectedPlug records systemd-specific side-effects of having a connected plug.
@@ -50,6 +50,30 @@ func (s *specSuite) TestAddService(c *C) {
 	})
 }
 
+func (s *specSuite) TestAddServiceDropIn(c *C) {
+	spec := systemd.Specification{}
+	c.Assert(spec.ServiceDropIns(), IsNil)
+	d1 := &systemd.DropIn{After: "one.service"}
+	c.Assert(spec.AddServiceDropIn("snap.foo.app.service", "d1", d1), IsNil)
+	d2 := &systemd.DropIn{Wants: "two.service"}
+	c.Assert(spec.AddServiceDropIn("snap.foo.app.service", "d2", d2), IsNil)
+	c.Assert(spec.AddServiceDropIn("snap.foo.other.service", "d1", d1), IsNil)
+	// adding the same drop-in again is fine
+	c.Assert(spec.AddServiceDropIn("snap.foo.app.service", "d1", &systemd.DropIn{After: "one.service"}), IsNil)
+	c.Assert(spec.ServiceDropIns(), DeepEquals, map[string]map[string]*systemd.DropIn{
+		"snap.foo.app.service": {
+			"d1": d1,
+			"d2": d2,
+		},
+		"snap.foo.other.service": {
+			"d1": d1,
+		},
+	})
+
+	err := spec.AddServiceDropIn("snap.foo.app.service", "d1", d2)
+	c.Assert(err, ErrorMatches, `internal error: interface "" has conflicting drop-ins for "d1" of service "snap.foo.app.service": .*`)
+}
+
 func (s *specSuite) TestClashingSameIface(c *C) {
 	info1 := snaptest.MockInfo(c, `name: snap1
 version: 0
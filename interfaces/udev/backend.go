@@ -35,20 +35,27 @@ import (
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/sandbox/cgroup"
+	"github.com/snapcore/snapd/sandbox/ebpf"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/systemd"
 	"github.com/snapcore/snapd/timings"
 )
 
+var (
+	ebpfLoadNetworkFilter    = ebpf.LoadNetworkFilter
+	ebpfRemoveNetworkFilters = ebpf.RemoveNetworkFilters
+)
+
 // Backend is responsible for maintaining udev rules.
 type Backend struct {
 	preseed     bool
 	isContainer bool
 }
 
-// Initialize does nothing.
+// Initialize loads the network filters of the installed snaps.
 func (b *Backend) Initialize(opts *interfaces.SecurityBackendOptions) error {
 	if opts != nil && opts.Preseed {
 		b.preseed = true
@@ -74,6 +81,11 @@ func (b *Backend) Initialize(opts *interfaces.SecurityBackendOptions) error {
 	// But we want the backend active when preseeding so preseeded images
 	// actually have the files in /var/lib/snapd/cgroup.
 	b.isContainer = systemd.IsContainer()
+
+	// pinned network filters do not survive a reboot
+	if b.networkFiltersEnabled() && !b.preseed {
+		loadNetworkFilters()
+	}
 	return nil
 }
 
@@ -187,6 +199,16 @@ func (b *Backend) Setup(appSet *interfaces.SnapAppSet, opts interfaces.Confineme
 		devCgroupOpts.NonStrict = true
 	}
 
+	egress := udevSpec.NetworkEgress()
+	if devCgroupOpts.NonStrict {
+		// like the device cgroup, network filters are not used with
+		// non-strict confinement
+		egress = nil
+	}
+	if err := b.setupNetworkFilters(snapName, egress); err != nil {
+		return err
+	}
+
 	cgroupOptsBytes, err := devCgroupOpts.MarshalText()
 	if err != nil {
 		return err
@@ -221,6 +243,10 @@ func (b *Backend) Remove(snapName string) error {
 		return err
 	}
 
+	if err := b.setupNetworkFilters(snapName, nil); err != nil {
+		return err
+	}
+
 	if err := os.Remove(selfManageDeviceCgroupPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return err
 	}
@@ -234,6 +260,98 @@ func (b *Backend) Remove(snapName string) error {
 	return nil
 }
 
+// networkFiltersEnabled returns true if network filters can be attached to
+// the apps and hooks, which requires cgroup v2 tracking.
+func (b *Backend) networkFiltersEnabled() bool {
+	return !b.isContainer && cgroup.IsUnified()
+}
+
+// setupNetworkFilters loads the network filters of the apps and hooks of a
+// snap which can only send traffic to some destinations, and writes their
+// rules next to the device cgroup file. snap-confine attaches the filter of
+// an app or hook when its file is present, and the files are used to load
+// the filters again when snapd starts. Filters which are no longer needed
+// are removed.
+func (b *Backend) setupNetworkFilters(snapName string, egress map[string][]ebpf.NetworkRule) error {
+	if !b.networkFiltersEnabled() {
+		egress = nil
+	}
+
+	content := make(map[string]osutil.FileState, len(egress))
+	tags := make([]string, 0, len(egress))
+	for tag, rules := range egress {
+		// the filter is loaded first, so that it is available once
+		// snap-confine observes the file
+		if !b.preseed {
+			if err := ebpfLoadNetworkFilter(tag, rules); err != nil {
+				return fmt.Errorf("cannot load network filter of %s: %w", tag, err)
+			}
+		}
+		var buf bytes.Buffer
+		buf.WriteString("# This file is automatically generated.\n")
+		for _, rule := range rules {
+			fmt.Fprintf(&buf, "%s\n", rule)
+		}
+		content[filepath.Base(cgroup.SnapNetworkFilterFile(tag))] = &osutil.MemoryFileState{
+			Content: buf.Bytes(),
+			Mode:    0644,
+		}
+		tags = append(tags, tag)
+	}
+
+	glob := fmt.Sprintf("%s.*.network", snap.SecurityTag(snapName))
+	if _, _, err := osutil.EnsureDirState(dirs.SnapCgroupPolicyDir, glob, content); err != nil {
+		return fmt.Errorf("cannot write network filter files: %w", err)
+	}
+	if b.preseed {
+		return nil
+	}
+	return ebpfRemoveNetworkFilters(snapName, tags)
+}
+
+// loadNetworkFilters loads the network filters of all snaps from the rules
+// written by setupNetworkFilters. Errors are only logged, as apps whose
+// filter is not loaded fail to start rather than run unrestricted.
+func loadNetworkFilters() {
+	paths, err := filepath.Glob(filepath.Join(dirs.SnapCgroupPolicyDir, "snap.*.network"))
+	if err != nil {
+		logger.Noticef("cannot list network filter files: %v", err)
+		return
+	}
+	for _, path := range paths {
+		tag := strings.TrimSuffix(filepath.Base(path), ".network")
+		rules, err := readNetworkFilterFile(path)
+		if err == nil {
+			err = ebpfLoadNetworkFilter(tag, rules)
+		}
+		if err != nil {
+			logger.Noticef("cannot load network filter of %s: %v", tag, err)
+		}
+	}
+}
+
+// readNetworkFilterFile reads the rules of a network filter file, skipping
+// empty lines and comments.
+func readNetworkFilterFile(path string) ([]ebpf.NetworkRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []ebpf.NetworkRule
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := ebpf.ParseNetworkRule(line)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
 func (b *Backend) deriveContent(spec *Specification) (content []string) {
 	content = append(content, spec.Snippets()...)
 	return content
@@ -21,6 +21,7 @@ package udev_test
 
 import (
 	"bytes"
+	"net"
 	"os"
 	"path/filepath"
 
@@ -30,7 +31,9 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/ifacetest"
 	"github.com/snapcore/snapd/interfaces/udev"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/sandbox/cgroup"
+	"github.com/snapcore/snapd/sandbox/ebpf"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
 	"github.com/snapcore/snapd/timings"
@@ -659,3 +662,131 @@ func (s *backendSuite) TestPreseed(c *C) {
 
 	c.Check(s.udevadmCmd.Calls(), HasLen, 0)
 }
+
+func (s *backendSuite) mockNetworkFilters(c *C) (loaded map[string][]string, removed *[][]string) {
+	loaded = make(map[string][]string)
+	removed = &[][]string{}
+	s.AddCleanup(udev.MockEbpfLoadNetworkFilter(func(securityTag string, rules []ebpf.NetworkRule) error {
+		loaded[securityTag] = []string{}
+		for _, r := range rules {
+			loaded[securityTag] = append(loaded[securityTag], r.String())
+		}
+		return nil
+	}))
+	s.AddCleanup(udev.MockEbpfRemoveNetworkFilters(func(instanceName string, keep []string) error {
+		*removed = append(*removed, append([]string{instanceName}, keep...))
+		return nil
+	}))
+	return loaded, removed
+}
+
+func (s *backendSuite) TestNetworkFilters(c *C) {
+	s.AddCleanup(cgroup.MockVersion(cgroup.V2, nil))
+	loaded, removed := s.mockNetworkFilters(c)
+
+	s.Iface.UDevPermanentSlotCallback = func(spec *udev.Specification, slot *snap.SlotInfo) error {
+		spec.RestrictNetworkEgress([]ebpf.NetworkRule{
+			{IP: net.ParseIP("10.0.0.1"), Port: 443},
+			{IP: net.ParseIP("fd00::1")},
+		})
+		return nil
+	}
+	fname := filepath.Join(dirs.SnapCgroupPolicyDir, "snap.samba.smbd.network")
+	snapInfo := s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 0)
+	c.Check(fname, testutil.FileEquals, "# This file is automatically generated.\n"+
+		"10.0.0.1:443\n"+
+		"fd00::1\n")
+	c.Check(loaded, DeepEquals, map[string][]string{
+		"snap.samba.smbd": {"10.0.0.1:443", "fd00::1"},
+	})
+	c.Check(*removed, DeepEquals, [][]string{{"samba", "snap.samba.smbd"}})
+
+	// the filter is dropped once the snap is no longer restricted
+	s.Iface.UDevPermanentSlotCallback = nil
+	*removed = nil
+	snapInfo = s.UpdateSnap(c, snapInfo, interfaces.ConfinementOptions{}, ifacetest.SambaYamlV1, 0)
+	c.Check(fname, testutil.FileAbsent)
+	c.Check(*removed, DeepEquals, [][]string{{"samba"}})
+
+	*removed = nil
+	s.RemoveSnap(c, snapInfo)
+	c.Check(*removed, DeepEquals, [][]string{{"samba"}})
+}
+
+func (s *backendSuite) TestNetworkFiltersNotUsed(c *C) {
+	loaded, _ := s.mockNetworkFilters(c)
+
+	s.Iface.UDevPermanentSlotCallback = func(spec *udev.Specification, slot *snap.SlotInfo) error {
+		spec.RestrictNetworkEgress([]ebpf.NetworkRule{{IP: net.ParseIP("10.0.0.1")}})
+		return nil
+	}
+	fname := filepath.Join(dirs.SnapCgroupPolicyDir, "snap.samba.smbd.network")
+
+	// non-strict confinement
+	restore := cgroup.MockVersion(cgroup.V2, nil)
+	for _, opts := range []interfaces.ConfinementOptions{{DevMode: true}, {Classic: true}} {
+		snapInfo := s.InstallSnap(c, opts, "", ifacetest.SambaYamlV1, 0)
+		c.Check(fname, testutil.FileAbsent)
+		s.RemoveSnap(c, snapInfo)
+	}
+	restore()
+
+	// cgroup v1
+	restore = cgroup.MockVersion(cgroup.V1, nil)
+	defer restore()
+	snapInfo := s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 0)
+	c.Check(fname, testutil.FileAbsent)
+	s.RemoveSnap(c, snapInfo)
+
+	c.Check(loaded, HasLen, 0)
+}
+
+func (s *backendSuite) TestInitializeLoadsNetworkFilters(c *C) {
+	s.AddCleanup(cgroup.MockVersion(cgroup.V2, nil))
+	detectVirt := testutil.MockCommand(c, "systemd-detect-virt", "exit 1")
+	defer detectVirt.Restore()
+	loaded, _ := s.mockNetworkFilters(c)
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	c.Assert(os.MkdirAll(dirs.SnapCgroupPolicyDir, 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapCgroupPolicyDir, "snap.foo.app.network"),
+		[]byte("# This file is automatically generated.\n10.0.0.1:443\n[fd00::1]:53\n"), 0644), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapCgroupPolicyDir, "snap.bar.app.network"),
+		[]byte("# This file is automatically generated.\n"), 0644), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapCgroupPolicyDir, "snap.baz.app.network"),
+		[]byte("example.com\n"), 0644), IsNil)
+
+	c.Assert(s.Backend.Initialize(nil), IsNil)
+	c.Check(loaded, DeepEquals, map[string][]string{
+		"snap.foo.app": {"10.0.0.1:443", "[fd00::1]:53"},
+		"snap.bar.app": {},
+	})
+	c.Check(logbuf.String(), testutil.Contains,
+		`cannot load network filter of snap.baz.app: cannot parse network rule "example.com": "example.com" is not an IP address`)
+}
+
+func (s *backendSuite) TestPreseedNetworkFilters(c *C) {
+	s.AddCleanup(cgroup.MockVersion(cgroup.V2, nil))
+	detectVirt := testutil.MockCommand(c, "systemd-detect-virt", "exit 1")
+	defer detectVirt.Restore()
+	loaded, removed := s.mockNetworkFilters(c)
+	c.Assert(os.MkdirAll(dirs.SnapCgroupPolicyDir, 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapCgroupPolicyDir, "snap.foo.app.network"), nil, 0644), IsNil)
+
+	err := s.Backend.Initialize(&interfaces.SecurityBackendOptions{
+		Preseed: true,
+	})
+	c.Assert(err, IsNil)
+
+	s.Iface.UDevPermanentSlotCallback = func(spec *udev.Specification, slot *snap.SlotInfo) error {
+		spec.RestrictNetworkEgress([]ebpf.NetworkRule{{IP: net.ParseIP("10.0.0.1")}})
+		return nil
+	}
+	s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 0)
+	// the filters are loaded on first boot
+	c.Check(filepath.Join(dirs.SnapCgroupPolicyDir, "snap.samba.smbd.network"), testutil.FileEquals,
+		"# This file is automatically generated.\n10.0.0.1\n")
+	c.Check(loaded, HasLen, 0)
+	c.Check(*removed, HasLen, 0)
+}
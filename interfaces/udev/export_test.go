@@ -19,6 +19,19 @@
 
 package udev
 
+import (
+	"github.com/snapcore/snapd/sandbox/ebpf"
+	"github.com/snapcore/snapd/testutil"
+)
+
 func (b *Backend) ReloadRules(subsystemTriggers []string) error {
 	return b.reloadRules(subsystemTriggers)
 }
+
+func MockEbpfLoadNetworkFilter(f func(securityTag string, rules []ebpf.NetworkRule) error) (restore func()) {
+	return testutil.Mock(&ebpfLoadNetworkFilter, f)
+}
+
+func MockEbpfRemoveNetworkFilters(f func(instanceName string, keep []string) error) (restore func()) {
+	return testutil.Mock(&ebpfRemoveNetworkFilters, f)
+}
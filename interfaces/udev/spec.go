@@ -26,6 +26,7 @@ import (
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/sandbox/ebpf"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/strutil"
 )
@@ -48,6 +49,13 @@ type Specification struct {
 	securityTags             []string
 	udevadmSubsystemTriggers []string
 	controlsDeviceCgroup     bool
+
+	// networkEgress holds the destinations which the apps and hooks
+	// can send traffic to, by security tag
+	networkEgress map[string][]ebpf.NetworkRule
+	// unrestrictedEgress holds the security tags of the apps and hooks
+	// which can send traffic anywhere
+	unrestrictedEgress map[string]bool
 }
 
 func NewSpecification(appSet *interfaces.SnapAppSet) *Specification {
@@ -76,6 +84,44 @@ func (spec *Specification) ControlsDeviceCgroup() bool {
 	return spec.controlsDeviceCgroup
 }
 
+// RestrictNetworkEgress restricts the destinations which the apps and hooks
+// bound to the current plug or slot can send traffic to, on top of the
+// loopback addresses and DNS servers. Apps and hooks restricted by several
+// plugs can send traffic to the destinations of all of them.
+func (spec *Specification) RestrictNetworkEgress(rules []ebpf.NetworkRule) {
+	if spec.networkEgress == nil {
+		spec.networkEgress = make(map[string][]ebpf.NetworkRule)
+	}
+	for _, tag := range spec.securityTags {
+		spec.networkEgress[tag] = append(spec.networkEgress[tag], rules...)
+	}
+}
+
+// AllowNetworkEgress marks the apps and hooks bound to the current plug or
+// slot as able to send traffic anywhere, which lifts the restrictions of
+// other plugs.
+func (spec *Specification) AllowNetworkEgress() {
+	if spec.unrestrictedEgress == nil {
+		spec.unrestrictedEgress = make(map[string]bool)
+	}
+	for _, tag := range spec.securityTags {
+		spec.unrestrictedEgress[tag] = true
+	}
+}
+
+// NetworkEgress returns the destinations which the apps and hooks can send
+// traffic to, by security tag. Apps and hooks which are not restricted are
+// not listed.
+func (spec *Specification) NetworkEgress() map[string][]ebpf.NetworkRule {
+	egress := make(map[string][]ebpf.NetworkRule, len(spec.networkEgress))
+	for tag, rules := range spec.networkEgress {
+		if !spec.unrestrictedEgress[tag] {
+			egress[tag] = rules
+		}
+	}
+	return egress
+}
+
 func (spec *Specification) addEntry(snippet, tag string) {
 	if spec.snippets == nil {
 		spec.snippets = make(map[string]bool)
@@ -20,12 +20,16 @@ package certstate
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
 	"gopkg.in/tomb.v2"
@@ -34,9 +38,17 @@ import (
 type CertManager struct {
 	state            *state.State
 	oneTimeChecksRan bool
+	lastExpiryCheck  time.Time
 }
 
-const previousGenerationTaskKey = "cert-db-prev-generation"
+const (
+	previousGenerationTaskKey     = "cert-db-prev-generation"
+	previousSnapGenerationTaskKey = "cert-db-prev-snap-generations"
+	previousCertStateTaskKey      = "prev-cert-state"
+
+	certificateExpiryCheckInterval        = 24 * time.Hour
+	defaultCertificateExpiryWarningWindow = 30 * 24 * time.Hour
+)
 
 var osutilBootID = osutil.BootID
 
@@ -47,6 +59,7 @@ func Manager(st *state.State, runner *state.TaskRunner) *CertManager {
 
 	// register tasks to update the certificate database
 	runner.AddHandler("update-cert-db", m.doUpdateCertificateDatabase, m.undoUpdateCertificateDatabase)
+	runner.AddHandler("set-system-cert-state", m.doSetSystemCertificateState, m.undoSetSystemCertificateState)
 
 	return m
 }
@@ -86,12 +99,6 @@ func (m *CertManager) Ensure() error {
 	st.Lock()
 	defer st.Unlock()
 
-	// Do not perform automatic db generation on classic, or if ensure
-	// has already run
-	if m.oneTimeChecksRan || release.OnClassic {
-		return nil
-	}
-
 	// Expect the system to be seeded, otherwise we ignore this.
 	var seeded bool
 	if err := st.Get("seeded", &seeded); err != nil && !errors.Is(err, state.ErrNoState) {
@@ -101,6 +108,19 @@ func (m *CertManager) Ensure() error {
 		return nil
 	}
 
+	if err := m.ensureCertificateDatabase(); err != nil {
+		return err
+	}
+	return m.ensureExpiryCheck()
+}
+
+func (m *CertManager) ensureCertificateDatabase() error {
+	// Do not perform automatic db generation on classic, or if ensure
+	// has already run
+	if m.oneTimeChecksRan || release.OnClassic {
+		return nil
+	}
+
 	// The reason we set it already, before any of the checks have actually run, is
 	// that in the case of errors we don't want to keep trying the below things. They are
 	// meant to run just once per boot (of snapd is fine too).
@@ -139,7 +159,87 @@ func (m *CertManager) Ensure() error {
 	// TODO: The database will be generated with the lock being held here, which is not
 	// the best, as there is some overhead in file-generation and hashing.
 	logger.Noticef("No CA certificate database found, generating it now")
-	return RefreshCertificateDatabase()
+	// Publish whatever is configured already, any expired certificates are
+	// reported by the expiry check instead.
+	return RefreshCertificateDatabase(&RefreshOptions{AllowExpired: true})
+}
+
+// certificateExpiryWarningWindow returns how long before their expiry
+// certificates of the current generation should be warned about.
+func certificateExpiryWarningWindow(st *state.State) time.Duration {
+	var windowStr string
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "pki.certs.expiry-warning-window", &windowStr); err != nil {
+		if !config.IsNoOption(err) {
+			logger.Noticef("cannot read pki.certs.expiry-warning-window: %v", err)
+		}
+		return defaultCertificateExpiryWarningWindow
+	}
+	window, err := time.ParseDuration(windowStr)
+	if err != nil {
+		logger.Noticef("pki.certs.expiry-warning-window cannot be parsed: %v", err)
+		return defaultCertificateExpiryWarningWindow
+	}
+	return window
+}
+
+// ensureExpiryCheck periodically scans the current certificate generation
+// and records a warning for each custom certificate that has expired or will
+// expire within the configured window.
+func (m *CertManager) ensureExpiryCheck() error {
+	now := timeNow()
+	if !m.lastExpiryCheck.IsZero() && now.Sub(m.lastExpiryCheck) < certificateExpiryCheckInterval {
+		return nil
+	}
+	m.lastExpiryCheck = now
+
+	window := certificateExpiryWarningWindow(m.state)
+	if window == 0 {
+		return nil
+	}
+	certs, err := expiringCertificates(now, window)
+	if err != nil {
+		// The check is repeated on the next interval, do not fail
+		// the whole ensure loop over it.
+		logger.Noticef("cannot check certificates for expiry: %v", err)
+		return nil
+	}
+	for _, cert := range certs {
+		fingerprint := sha256HexForChain([][]byte{cert.Raw})
+		notAfter := cert.NotAfter.UTC().Format(time.RFC3339)
+		if now.After(cert.NotAfter) {
+			m.state.Warnf("certificate %q (%s) in the certificate database expired on %s", cert.Subject.String(), fingerprint, notAfter)
+		} else {
+			m.state.Warnf("certificate %q (%s) in the certificate database expires on %s", cert.Subject.String(), fingerprint, notAfter)
+		}
+	}
+	return nil
+}
+
+// allowExpiredTaskKey is set on the tasks of changes that were explicitly
+// requested to publish custom certificates which have already expired.
+const allowExpiredTaskKey = "allow-expired-certificates"
+
+// AllowExpiredCertificates marks the tasks of the given task set so that
+// refreshing the certificate database as part of them does not refuse
+// custom certificates which have already expired.
+func AllowExpiredCertificates(ts *state.TaskSet) {
+	for _, t := range ts.Tasks() {
+		t.Set(allowExpiredTaskKey, true)
+	}
+}
+
+// RefreshOptionsForTask returns the options for refreshing the certificate
+// database as part of the given task. The task may be nil, in which case
+// the default options are returned.
+func RefreshOptionsForTask(t *state.Task) (*RefreshOptions, error) {
+	var allowExpired bool
+	if t != nil {
+		if err := t.Get(allowExpiredTaskKey, &allowExpired); err != nil && !errors.Is(err, state.ErrNoState) {
+			return nil, err
+		}
+	}
+	return &RefreshOptions{AllowExpired: allowExpired}, nil
 }
 
 // recordCurrentCertificateGeneration is a helper function to make sure
@@ -168,6 +268,33 @@ func recordCurrentCertificateGeneration(t *state.Task, key string) (string, erro
 	return current, nil
 }
 
+// recordCurrentSnapCertificateGenerations is the per-snap counterpart of
+// recordCurrentCertificateGeneration.
+// OBS: This function will lock the state and commit to disk.
+func recordCurrentSnapCertificateGenerations(t *state.Task, key string) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var current map[string]string
+	err := t.Get(key, &current)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	current, err = snapCertificateTargets()
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		current = map[string]string{}
+	}
+	t.Set(key, current)
+	return nil
+}
+
 func (m *CertManager) doUpdateCertificateDatabase(t *state.Task, _ *tomb.Tomb) error {
 	st := t.State()
 
@@ -187,10 +314,17 @@ func (m *CertManager) doUpdateCertificateDatabase(t *state.Task, _ *tomb.Tomb) e
 	if err != nil {
 		return err
 	}
+	if err := recordCurrentSnapCertificateGenerations(t, previousSnapGenerationTaskKey); err != nil {
+		return err
+	}
 
 	st.Lock()
 	defer st.Unlock()
-	return RefreshCertificateDatabase()
+	opts, err := RefreshOptionsForTask(t)
+	if err != nil {
+		return err
+	}
+	return RefreshCertificateDatabase(opts)
 }
 
 func (m *CertManager) undoUpdateCertificateDatabase(t *state.Task, _ *tomb.Tomb) error {
@@ -198,8 +332,18 @@ func (m *CertManager) undoUpdateCertificateDatabase(t *state.Task, _ *tomb.Tomb)
 	st.Lock()
 	defer st.Unlock()
 
+	var previousSnapTargets map[string]string
+	err := t.Get(previousSnapGenerationTaskKey, &previousSnapTargets)
+	if err == nil {
+		if err := restoreSnapCertificateTargets(previousSnapTargets); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+
 	var previousTarget string
-	err := t.Get(previousGenerationTaskKey, &previousTarget)
+	err = t.Get(previousGenerationTaskKey, &previousTarget)
 	if err != nil {
 		if errors.Is(err, state.ErrNoState) {
 			return nil
@@ -231,6 +375,86 @@ func (m *CertManager) undoUpdateCertificateDatabase(t *state.Task, _ *tomb.Tomb)
 	return nil
 }
 
+// SetSystemCertificateState returns a task set that blocks or unblocks the
+// system certificate with the given fingerprint and then refreshes the merged
+// certificate database. Custom certificates are managed through the
+// pki.certs.custom.* core configuration instead.
+func SetSystemCertificateState(st *state.State, fingerprint, certState string) (*state.TaskSet, error) {
+	switch certState {
+	case CertificateStateAccepted, CertificateStateBlocked:
+	default:
+		return nil, fmt.Errorf("invalid certificate state %q", certState)
+	}
+
+	if _, err := findSystemCertificate(fingerprint); err != nil {
+		return nil, err
+	}
+	customCerts, err := CustomCertificates()
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range customCerts {
+		if info.Fingerprint == fingerprint {
+			return nil, fmt.Errorf("cannot change state of system certificate %q: it is also provided by custom certificate %q", fingerprint, info.Name)
+		}
+	}
+
+	var summary string
+	if certState == CertificateStateBlocked {
+		summary = fmt.Sprintf(i18n.G("Block system certificate %s"), fingerprint)
+	} else {
+		summary = fmt.Sprintf(i18n.G("Unblock system certificate %s"), fingerprint)
+	}
+	setState := st.NewTask("set-system-cert-state", summary)
+	setState.Set("fingerprint", fingerprint)
+	setState.Set("cert-state", certState)
+
+	updateCertDB := st.NewTask("update-cert-db", i18n.G("Update certificate database"))
+	updateCertDB.WaitFor(setState)
+	return state.NewTaskSet(setState, updateCertDB), nil
+}
+
+func (m *CertManager) doSetSystemCertificateState(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var fingerprint, certState string
+	if err := t.Get("fingerprint", &fingerprint); err != nil {
+		return err
+	}
+	if err := t.Get("cert-state", &certState); err != nil {
+		return err
+	}
+
+	var previous string
+	if err := t.Get(previousCertStateTaskKey, &previous); err != nil {
+		if !errors.Is(err, state.ErrNoState) {
+			return err
+		}
+		t.Set(previousCertStateTaskKey, systemCertificateState(fingerprint))
+	}
+	return setSystemCertificateState(fingerprint, certState)
+}
+
+func (m *CertManager) undoSetSystemCertificateState(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var fingerprint, previous string
+	if err := t.Get("fingerprint", &fingerprint); err != nil {
+		return err
+	}
+	if err := t.Get(previousCertStateTaskKey, &previous); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return nil
+		}
+		return err
+	}
+	return setSystemCertificateState(fingerprint, previous)
+}
+
 func hasSystemCertsDir() bool {
 	if exists, isDir, err := osutil.DirExists(dirs.SystemCertsDir); !exists || !isDir || err != nil {
 		return false
@@ -29,6 +29,7 @@ import (
 	"gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/certstate"
 	"github.com/snapcore/snapd/overlord/state"
@@ -108,7 +109,7 @@ func setMergedTarget(c *C, target string) {
 
 func (s *certMgrTestSuite) TestEnsureCallsUpdateCertificateDatabase(c *C) {
 	var called bool
-	restore := certstate.MockRefreshCertificateDatabase(func() error {
+	restore := certstate.MockRefreshCertificateDatabase(func(opts *certstate.RefreshOptions) error {
 		called = true
 		return nil
 	})
@@ -132,7 +133,7 @@ func (s *certMgrTestSuite) TestEnsureCallsUpdateCertificateDatabase(c *C) {
 
 func (s *certMgrTestSuite) TestEnsureDoesNothingWhenNotSeeded(c *C) {
 	var called bool
-	restore := certstate.MockRefreshCertificateDatabase(func() error {
+	restore := certstate.MockRefreshCertificateDatabase(func(opts *certstate.RefreshOptions) error {
 		called = true
 		return nil
 	})
@@ -148,7 +149,7 @@ func (s *certMgrTestSuite) TestEnsureSkipsOnClassic(c *C) {
 	defer restore()
 
 	var called bool
-	restore = certstate.MockRefreshCertificateDatabase(func() error {
+	restore = certstate.MockRefreshCertificateDatabase(func(opts *certstate.RefreshOptions) error {
 		called = true
 		return nil
 	})
@@ -175,7 +176,7 @@ func (s *certMgrTestSuite) TestEnsureSkipsOnClassic(c *C) {
 
 func (s *certMgrTestSuite) TestEnsureSkipsWhenCertDbExists(c *C) {
 	var called bool
-	restore := certstate.MockRefreshCertificateDatabase(func() error {
+	restore := certstate.MockRefreshCertificateDatabase(func(opts *certstate.RefreshOptions) error {
 		called = true
 		return nil
 	})
@@ -199,7 +200,7 @@ func (s *certMgrTestSuite) TestEnsureSkipsWhenCertDbExists(c *C) {
 
 func (s *certMgrTestSuite) TestEnsureRegeneratesWhenMergedIsPlainDirectory(c *C) {
 	var called bool
-	restore := certstate.MockRefreshCertificateDatabase(func() error {
+	restore := certstate.MockRefreshCertificateDatabase(func(opts *certstate.RefreshOptions) error {
 		called = true
 		return nil
 	})
@@ -228,7 +229,7 @@ func (s *certMgrTestSuite) TestEnsureRegeneratesWhenMergedIsPlainDirectory(c *C)
 
 func (s *certMgrTestSuite) TestEnsureSkipsWhenNoBaseCertsDir(c *C) {
 	var called bool
-	restore := certstate.MockRefreshCertificateDatabase(func() error {
+	restore := certstate.MockRefreshCertificateDatabase(func(opts *certstate.RefreshOptions) error {
 		called = true
 		return nil
 	})
@@ -249,7 +250,7 @@ func (s *certMgrTestSuite) TestEnsureSkipsWhenNoBaseCertsDir(c *C) {
 
 func (s *certMgrTestSuite) TestEnsureRunsOnlyOnce(c *C) {
 	var calls int
-	restore := certstate.MockRefreshCertificateDatabase(func() error {
+	restore := certstate.MockRefreshCertificateDatabase(func(opts *certstate.RefreshOptions) error {
 		calls++
 		return nil
 	})
@@ -276,7 +277,7 @@ func (s *certMgrTestSuite) TestEnsureRunsOnlyOnce(c *C) {
 }
 
 func (s *certMgrTestSuite) TestEnsurePropagatesGenerateError(c *C) {
-	restore := certstate.MockRefreshCertificateDatabase(func() error {
+	restore := certstate.MockRefreshCertificateDatabase(func(opts *certstate.RefreshOptions) error {
 		return errors.New("boom")
 	})
 	defer restore()
@@ -427,7 +428,7 @@ func (s *certMgrTestSuite) TestDoUpdateCertificateDatabaseCheckpointsPreviousGen
 	st.Unlock()
 	backend.checkpoints = nil
 
-	restore := certstate.MockRefreshCertificateDatabase(func() error {
+	restore := certstate.MockRefreshCertificateDatabase(func(opts *certstate.RefreshOptions) error {
 		c.Assert(backend.checkpoints, HasLen, 1)
 		restoredState, err := state.ReadState(nil, bytes.NewReader(backend.checkpoints[0]))
 		c.Assert(err, IsNil)
@@ -454,7 +455,7 @@ func (s *certMgrTestSuite) TestDoUpdateCertificateDatabasePropagatesCheckpointRe
 	c.Assert(os.MkdirAll(baseCertsDir, 0o755), IsNil)
 
 	var called bool
-	restore := certstate.MockRefreshCertificateDatabase(func() error {
+	restore := certstate.MockRefreshCertificateDatabase(func(opts *certstate.RefreshOptions) error {
 		called = true
 		return nil
 	})
@@ -588,7 +589,7 @@ func (s *certMgrTestSuite) TestUndoUpdateCertificateDatabaseRetryAfterRebootKeep
 	c.Assert(os.WriteFile(filepath.Join(baseCertsDir, "new.crt"), newPEM, 0o644), IsNil)
 	oldTarget := seedCurrentPublishedGeneration(c, "old", oldBundle)
 
-	err = certstate.RefreshCertificateDatabase()
+	err = certstate.RefreshCertificateDatabase(nil)
 	c.Assert(err, IsNil)
 
 	s.state.Lock()
@@ -614,7 +615,7 @@ func (s *certMgrTestSuite) TestUndoUpdateCertificateDatabaseWithoutPriorMergedRe
 	c.Assert(err, IsNil)
 	c.Assert(os.WriteFile(filepath.Join(baseCertsDir, "new.crt"), newPEM, 0o644), IsNil)
 
-	err = certstate.RefreshCertificateDatabase()
+	err = certstate.RefreshCertificateDatabase(nil)
 	c.Assert(err, IsNil)
 
 	s.state.Lock()
@@ -632,3 +633,93 @@ func (s *certMgrTestSuite) TestUndoUpdateCertificateDatabaseWithoutPriorMergedRe
 	_, err = os.Stat(mergedDir)
 	c.Check(os.IsNotExist(err), Equals, true)
 }
+
+func (s *certMgrTestSuite) TestSetSystemCertificateStateBlocksAndUnblocks(c *C) {
+	certA, _, err := makeTestCertPEM("A")
+	c.Assert(err, IsNil)
+	certB, _, err := makeTestCertPEM("B")
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "a.crt"), certA, 0o644), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "b.crt"), certB, 0o644), IsNil)
+	digestB := digestForPEM(c, certB)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	ts, err := certstate.SetSystemCertificateState(s.state, digestB, certstate.CertificateStateBlocked)
+	c.Assert(err, IsNil)
+	tasks := ts.Tasks()
+	c.Assert(tasks, HasLen, 2)
+	c.Check(tasks[0].Kind(), Equals, "set-system-cert-state")
+	c.Check(tasks[1].Kind(), Equals, "update-cert-db")
+	c.Check(tasks[1].WaitTasks(), DeepEquals, []*state.Task{tasks[0]})
+
+	chg := s.state.NewChange("block", "...")
+	chg.AddAll(ts)
+	s.settle(c)
+	c.Assert(chg.Err(), IsNil)
+
+	c.Check(osutil.IsSymlink(filepath.Join(dirs.SnapdPKIV1Dir, "blocked", digestB+".crt")), Equals, true)
+	out, err := os.ReadFile(filepath.Join(dirs.SnapdPKIV1Dir, "merged", "ca-certificates.crt"))
+	c.Assert(err, IsNil)
+	c.Check(bytes.Contains(out, certA), Equals, true)
+	c.Check(bytes.Contains(out, certB), Equals, false)
+
+	ts, err = certstate.SetSystemCertificateState(s.state, digestB, certstate.CertificateStateAccepted)
+	c.Assert(err, IsNil)
+	chg = s.state.NewChange("unblock", "...")
+	chg.AddAll(ts)
+	s.settle(c)
+	c.Assert(chg.Err(), IsNil)
+
+	c.Check(filepath.Join(dirs.SnapdPKIV1Dir, "blocked", digestB+".crt"), testutil.FileAbsent)
+	out, err = os.ReadFile(filepath.Join(dirs.SnapdPKIV1Dir, "merged", "ca-certificates.crt"))
+	c.Assert(err, IsNil)
+	c.Check(bytes.Contains(out, certB), Equals, true)
+}
+
+func (s *certMgrTestSuite) TestSetSystemCertificateStateUndo(c *C) {
+	certA, _, err := makeTestCertPEM("A")
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "a.crt"), certA, 0o644), IsNil)
+	digestA := digestForPEM(c, certA)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	ts, err := certstate.SetSystemCertificateState(s.state, digestA, certstate.CertificateStateBlocked)
+	c.Assert(err, IsNil)
+	chg := s.state.NewChange("block", "...")
+	chg.AddAll(ts)
+	errTask := s.state.NewTask("error-trigger", "provoking undo")
+	errTask.WaitAll(ts)
+	chg.AddTask(errTask)
+	s.settle(c)
+
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(ts.Tasks()[0].Status(), Equals, state.UndoneStatus)
+	c.Check(filepath.Join(dirs.SnapdPKIV1Dir, "blocked", digestA+".crt"), testutil.FileAbsent)
+}
+
+func (s *certMgrTestSuite) TestSetSystemCertificateStateErrors(c *C) {
+	certA, _, err := makeTestCertPEM("A")
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "a.crt"), certA, 0o644), IsNil)
+	digestA := digestForPEM(c, certA)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err = certstate.SetSystemCertificateState(s.state, digestA, certstate.CertificateStateUnset)
+	c.Check(err, ErrorMatches, `invalid certificate state "unset"`)
+
+	_, err = certstate.SetSystemCertificateState(s.state, "1234", certstate.CertificateStateBlocked)
+	c.Check(err, ErrorMatches, `cannot find system certificate with fingerprint "1234"`)
+
+	setAcceptedCustomCertificate(c, "dup", certA)
+	_, err = certstate.SetSystemCertificateState(s.state, digestA, certstate.CertificateStateBlocked)
+	c.Check(err, ErrorMatches, `cannot change state of system certificate ".*": it is also provided by custom certificate "dup"`)
+}
@@ -31,6 +31,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/logger"
@@ -429,12 +430,15 @@ func writeUniqueCACertificates(certs *certificates, certsDir string, bundle io.W
 	// certificates layout, but fall back to a digest-derived name when a
 	// distinct certificate would otherwise overwrite an existing copy.
 	outputNameFor := func(cert certificate) string {
+		base := filepath.Base(cert.RealPath)
 		if cert.RealPath == "" {
-			// individual certificate from a bundle file
-			return ""
+			// individual certificate from a bundle file, only given its
+			// own copy when it must be found through its subject hash
+			if !certs.OpenSSLDir || cert.SubjectNameSha1 == "" {
+				return ""
+			}
+			base = cert.Sha256 + ".crt"
 		}
-
-		base := filepath.Base(cert.RealPath)
 		if ownerDigest, ok := usedOutputNames[base]; !ok || ownerDigest == cert.Sha256 {
 			usedOutputNames[base] = cert.Sha256
 			return base
@@ -602,6 +606,10 @@ type certificates struct {
 	SystemCertificates []certificate
 	AddedCertificates  []certificate
 	BlockedDigests     []string
+	// OpenSSLDir requests every certificate to be available through a
+	// c_rehash-style subject hash link, including those only found in a
+	// bundle, so that the view can be used as SSL_CERT_DIR.
+	OpenSSLDir bool
 }
 
 // loadCertificates builds the certificate view snapd will publish.
@@ -674,6 +682,9 @@ func loadCertificates() (*certificates, error) {
 // /var/lib/snapd/pki/v1/published/<generation>/*.crt
 // /var/lib/snapd/pki/v1/published/<generation>/ca-certificates.crt
 // /var/lib/snapd/pki/v1/merged -> published/<generation>
+// /var/lib/snapd/pki/v1/snap-added/<snap>/<digest>.crt (symlink)
+// /var/lib/snapd/pki/v1/snap-published/<snap>/<generation>/*.crt
+// /var/lib/snapd/pki/v1/snap-merged/<snap> -> ../snap-published/<snap>/<generation>
 // /var/lib/snapd/pki/v1/<name>.crt
 func ensureDirectories() error {
 	dirsToEnsure := []string{
@@ -711,13 +722,6 @@ func PublishedCertificatesDir() string {
 	return filepath.Join(dirs.SnapdPKIV1Dir, "published")
 }
 
-// mergedCertificatesGeneration returns the relative target used by the public
-// generation pointers so they keep working if the snapd state directory moves
-// under a different root.
-func mergedCertificatesGeneration(generation string) string {
-	return filepath.Join("published", generation)
-}
-
 // switchCertificatesLink atomically replaces one of the generation pointers so
 // readers never have to observe a half-updated or missing link.
 func switchCertificatesLink(linkPath, target string) error {
@@ -756,11 +760,11 @@ func resolveCurrentCertificateTarget() (string, error) {
 	return target, nil
 }
 
-// certificateGenerations returns the immutable published generation names.
-// Garbage collection only reasons about these directories; the public symlinks
-// and other metadata are handled separately.
-func certificateGenerations() ([]string, error) {
-	entries, err := os.ReadDir(PublishedCertificatesDir())
+// certificateGenerations returns the immutable generation names published in
+// the given directory. Garbage collection only reasons about these
+// directories; the public symlinks and other metadata are handled separately.
+func certificateGenerations(publishedDir string) ([]string, error) {
+	entries, err := os.ReadDir(publishedDir)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
@@ -780,8 +784,7 @@ func certificateGenerations() ([]string, error) {
 // cleanupStaleStagingDirectories removes any temporary staging directories left behind
 // by a crash during publication. These directories are named with a ".generation-"
 // prefix and are not considered valid published generations.
-func cleanupStaleStagingDirectories() error {
-	publishedDir := PublishedCertificatesDir()
+func cleanupStaleStagingDirectories(publishedDir string) error {
 	entries, err := os.ReadDir(publishedDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -816,17 +819,48 @@ func garbageCollectCertificateGenerations(bootID string) error {
 		return err
 	}
 
-	generations, err := certificateGenerations()
+	// Generations referenced by per-snap views are as current as the one
+	// referenced by merged.
+	snapTargets, err := snapCertificateTargets()
+	if err != nil {
+		return err
+	}
+	activeTargets := map[string]bool{currentTarget: true}
+	for _, target := range snapTargets {
+		activeTargets[target] = true
+	}
+
+	publishedDirs, err := snapPublishedCertificatesDirs()
+	if err != nil {
+		return err
+	}
+	publishedDirs = append([]string{PublishedCertificatesDir()}, publishedDirs...)
+	for _, publishedDir := range publishedDirs {
+		if err := garbageCollectCertificateGenerationsIn(publishedDir, activeTargets, bootID); err != nil {
+			return err
+		}
+	}
+	return removeEmptySnapPublishedCertificatesDirs()
+}
+
+// garbageCollectCertificateGenerationsIn applies the cleanup policy of
+// garbageCollectCertificateGenerations to the generations published in the
+// given directory.
+func garbageCollectCertificateGenerationsIn(publishedDir string, activeTargets map[string]bool, bootID string) error {
+	generations, err := certificateGenerations(publishedDir)
 	if err != nil {
 		return err
 	}
 
 	for _, generation := range generations {
-		target := mergedCertificatesGeneration(generation)
-		genPath := filepath.Join(PublishedCertificatesDir(), generation)
+		genPath := filepath.Join(publishedDir, generation)
+		target, err := filepath.Rel(dirs.SnapdPKIV1Dir, genPath)
+		if err != nil {
+			return err
+		}
 		inactiveFile := filepath.Join(genPath, ".snapd-inactive")
 
-		if target == currentTarget {
+		if activeTargets[target] {
 			// If a generation became current again, clear any stale inactivity mark
 			// so the next boot does not treat the live tree as pending deletion.
 			if osutil.FileExists(inactiveFile) {
@@ -859,15 +893,31 @@ func garbageCollectCertificateGenerations(bootID string) error {
 
 	// cleanup any temporary staging directories left behind
 	// by a crash during publication
-	return cleanupStaleStagingDirectories()
+	return cleanupStaleStagingDirectories(publishedDir)
+}
+
+// RefreshOptions holds options for RefreshCertificateDatabase.
+type RefreshOptions struct {
+	// AllowExpired allows publishing a generation that introduces custom
+	// certificates which have already expired.
+	AllowExpired bool
 }
 
 // RefreshCertificateDatabase does a best-effort of performing an
 // atomic update of the existing cert database. Expects state to be
 // locked when calling this function, to avoid concurrent updates to the database.
+//
+// Unless opts.AllowExpired is set, publishing fails if the new generation
+// would introduce a custom certificate that has already expired. Expired
+// certificates that are already part of the current generation do not block
+// publishing, those are reported by the certificate manager instead.
 var RefreshCertificateDatabase = refreshCertificateDatabaseImpl
 
-func refreshCertificateDatabaseImpl() error {
+func refreshCertificateDatabaseImpl(opts *RefreshOptions) error {
+	if opts == nil {
+		opts = &RefreshOptions{}
+	}
+
 	// Re-entry is safe here because publication is one-way and content-aware:
 	// we build the next tree in a temporary directory, publish it under its
 	// deterministic generation name, and only then flip merged.
@@ -875,26 +925,36 @@ func refreshCertificateDatabaseImpl() error {
 		return err
 	}
 
-	publishedDir := PublishedCertificatesDir()
 	currentTarget, err := resolveCurrentCertificateTarget()
 	if err != nil {
 		return err
 	}
 
-	// Build the next certificate view off to the side so the active generation
-	// stays unchanged until publication is reduced to metadata updates.
-	stagedDir, err := os.MkdirTemp(publishedDir, ".generation-")
+	certs, err := loadCertificates()
 	if err != nil {
-		return fmt.Errorf("cannot create staging directory for published certificates: %v", err)
+		return err
 	}
-	defer os.RemoveAll(stagedDir)
 
-	certs, err := loadCertificates()
+	// Per-snap trust stores are built on top of the same system view, load
+	// them upfront so an expired certificate in any of them refuses the whole
+	// refresh before anything is published.
+	snapCerts, err := loadSnapCertificates(certs)
 	if err != nil {
 		return err
 	}
 
-	manifest, err := generateCACertificates(certs, stagedDir)
+	if !opts.AllowExpired {
+		if err := checkNoNewlyExpiredCertificates(certs, CurrentCertificateDir(), timeNow()); err != nil {
+			return err
+		}
+		for _, sc := range snapCerts {
+			if err := checkNoNewlyExpiredCertificates(sc.certs, SnapCertificatesDir(sc.snapName), timeNow()); err != nil {
+				return err
+			}
+		}
+	}
+
+	nextTarget, err := publishCertificateGeneration(certs, PublishedCertificatesDir())
 	if err != nil {
 		return err
 	}
@@ -902,48 +962,75 @@ func refreshCertificateDatabaseImpl() error {
 	// guard against empty certificate sets, in this case treat it like we do not
 	// have an active generation. This should really only happen on systems that have
 	// an unexpected/unsupported /etc/ssl/certs layout.
-	if len(manifest.records) == 0 {
+	if nextTarget == "" {
 		logger.Debugf("no system or user certificates found, no active generation")
 		if err := os.Remove(CurrentCertificateDir()); err != nil && !os.IsNotExist(err) {
 			return fmt.Errorf("cannot clear merged view: %v", err)
 		}
-		return nil
+		return publishSnapCertificateGenerations(snapCerts)
+	}
+
+	// If the current pointer already resolves to this generation, there is no
+	// visible state change to publish.
+	if currentTarget != nextTarget {
+		// Publish by moving the public pointer, not by mutating generation
+		// contents. A failure before the final pointer swap leaves the active
+		// generation unchanged; a failure after publishing the immutable
+		// generation but before switching merged lets a later retry reuse that
+		// published tree and retry only the metadata update. Callers that need
+		// rollback across reboot must still persist the previous
+		// merged target themselves before calling into this helper.
+		if err := switchCurrentMergedCertificates(nextTarget); err != nil {
+			return err
+		}
+	}
+	return publishSnapCertificateGenerations(snapCerts)
+}
+
+// publishCertificateGeneration renders the given certificate view and
+// publishes it as an immutable generation in the given directory, returning
+// the target relative to the pki directory the public pointers should use.
+// An empty target is returned if the view does not contain any certificates.
+func publishCertificateGeneration(certs *certificates, publishedDir string) (string, error) {
+	// Build the next certificate view off to the side so the active generation
+	// stays unchanged until publication is reduced to metadata updates.
+	stagedDir, err := os.MkdirTemp(publishedDir, ".generation-")
+	if err != nil {
+		return "", fmt.Errorf("cannot create staging directory for published certificates: %v", err)
+	}
+	defer os.RemoveAll(stagedDir)
+
+	manifest, err := generateCACertificates(certs, stagedDir)
+	if err != nil {
+		return "", err
+	}
+	if len(manifest.records) == 0 {
+		return "", nil
 	}
 
 	// Name published generations after semantic certificate content plus the
 	// visible filenames and hash-link targets. Formatting-only PEM changes reuse
 	// the existing immutable generation instead of republishing equivalent bytes.
 	hash := manifest.hash()
-	nextTarget := mergedCertificatesGeneration(hash)
-	nextPath := filepath.Join(dirs.SnapdPKIV1Dir, nextTarget)
+	nextPath := filepath.Join(publishedDir, hash)
+	nextTarget, err := filepath.Rel(dirs.SnapdPKIV1Dir, nextPath)
+	if err != nil {
+		return "", err
+	}
 	if exists, isDir, err := osutil.DirExists(nextPath); err != nil {
-		return err
+		return "", err
 	} else if !exists {
 		// ensure proper permissions on the directory before moving it into place
 		if err := os.Chmod(stagedDir, 0o755); err != nil {
-			return fmt.Errorf("cannot set published certificates staging directory permissions: %v", err)
+			return "", fmt.Errorf("cannot set published certificates staging directory permissions: %v", err)
 		}
 		if err := os.Rename(stagedDir, nextPath); err != nil {
-			return fmt.Errorf("cannot publish certificates generation %q: %v", hash, err)
+			return "", fmt.Errorf("cannot publish certificates generation %q: %v", hash, err)
 		}
 	} else if !isDir {
-		return fmt.Errorf("published certificates generation %q is not a directory", hash)
-	}
-
-	// If the current pointer already resolves to this generation, there is no
-	// visible state change to publish.
-	if currentTarget == nextTarget {
-		return nil
+		return "", fmt.Errorf("published certificates generation %q is not a directory", hash)
 	}
-
-	// Publish by moving the public pointer, not by mutating generation
-	// contents. A failure before the final pointer swap leaves the active
-	// generation unchanged; a failure after publishing the immutable
-	// generation but before switching merged lets a later retry reuse that
-	// published tree and retry only the metadata update. Callers that need
-	// rollback across reboot must still persist the previous
-	// merged target themselves before calling into this helper.
-	return switchCurrentMergedCertificates(nextTarget)
+	return nextTarget, nil
 }
 
 // certificatePathWithExtension returns a path under dir for a certificate name
@@ -961,7 +1048,7 @@ func CertificatePath(name string) string {
 }
 
 // RemoveCertificateSymlinks removes the symlinks for the given certificate digest
-// from the added and blocked directories.
+// from the added and blocked directories, and from the per-snap allow-lists.
 func RemoveCertificateSymlinks(digest string) error {
 	addedDir := filepath.Join(dirs.SnapdPKIV1Dir, "added")
 	blockedDir := filepath.Join(dirs.SnapdPKIV1Dir, "blocked")
@@ -972,7 +1059,7 @@ func RemoveCertificateSymlinks(digest string) error {
 	if err := os.Remove(certificatePathWithExtension(blockedDir, digest)); err != nil && !os.IsNotExist(err) {
 		return err
 	}
-	return nil
+	return removeSnapCertificateSymlinks(digest)
 }
 
 // RemoveCertificate removes the certificate file for the given name. This does
@@ -1026,7 +1113,10 @@ type CertificateInfo struct {
 	Name        string `json:"name"`
 	Fingerprint string `json:"fingerprint"`
 	State       string `json:"state"`
-	Content     string `json:"content,omitempty"`
+	// Snaps lists the snaps an accepted certificate is restricted to, it
+	// is empty for certificates that are part of the system view.
+	Snaps   []string `json:"snaps,omitempty"`
+	Content string   `json:"content,omitempty"`
 }
 
 // certificateDigestAndContent reads a custom certificate file and returns its
@@ -1053,17 +1143,27 @@ func certificateInfo(name, baseDir, addedDir, blockedDir string) (*CertificateIn
 		return nil, err
 	}
 
+	var snapNames []string
 	state := CertificateStateUnset
 	if osutil.IsSymlink(certificatePathWithExtension(blockedDir, digest)) {
 		state = CertificateStateBlocked
 	} else if osutil.IsSymlink(certificatePathWithExtension(addedDir, digest)) {
 		state = CertificateStateAccepted
+	} else {
+		snapNames, err = certificateSnaps(digest)
+		if err != nil {
+			return nil, err
+		}
+		if len(snapNames) != 0 {
+			state = CertificateStateAccepted
+		}
 	}
 
 	return &CertificateInfo{
 		Name:        name,
 		Fingerprint: digest,
 		State:       state,
+		Snaps:       snapNames,
 		Content:     content,
 	}, nil
 }
@@ -1111,3 +1211,207 @@ func CustomCertificates() ([]*CertificateInfo, error) {
 	}
 	return certsInfo, nil
 }
+
+const (
+	// CertificateOriginSystem marks certificates provided by the base in
+	// /etc/ssl/certs.
+	CertificateOriginSystem = "system"
+	// CertificateOriginCustom marks certificates added through snapd.
+	CertificateOriginCustom = "custom"
+)
+
+// CertificateDetails describes a certificate that takes part in the merged
+// certificate database, together with where it comes from and whether it is
+// currently blocked.
+type CertificateDetails struct {
+	// Name is the certificate name, it is empty for system certificates
+	// that are only available through the ca-certificates.crt bundle.
+	Name        string    `json:"name,omitempty"`
+	Fingerprint string    `json:"fingerprint"`
+	Origin      string    `json:"origin"`
+	State       string    `json:"state"`
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	NotBefore   time.Time `json:"not-before"`
+	NotAfter    time.Time `json:"not-after"`
+}
+
+// parseFirstCertificate returns the first certificate of a PEM or DER
+// certificate payload.
+func parseFirstCertificate(certData []byte) (*x509.Certificate, error) {
+	if block, _ := pem.Decode(certData); block != nil {
+		_, raw, err := decodePemBlocks(certData)
+		if err != nil {
+			return nil, err
+		}
+		if raw == nil {
+			return nil, fmt.Errorf("no certificate PEM block found")
+		}
+		return raw, nil
+	}
+	cert, err := x509.ParseCertificate(certData)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse DER certificate: %v", err)
+	}
+	return cert, nil
+}
+
+func certificateDetails(name, digest, origin, state string, certData []byte) (*CertificateDetails, error) {
+	cert, err := parseFirstCertificate(certData)
+	if err != nil {
+		return nil, err
+	}
+	return &CertificateDetails{
+		Name:        name,
+		Fingerprint: digest,
+		Origin:      origin,
+		State:       state,
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		NotBefore:   cert.NotBefore.UTC(),
+		NotAfter:    cert.NotAfter.UTC(),
+	}, nil
+}
+
+// systemCertificates returns the certificates provided by the system, using
+// the same discovery rules as loadCertificates.
+func systemCertificates() ([]certificate, error) {
+	if !hasSystemCertsDir() {
+		return nil, nil
+	}
+	certs, err := parseCertificates(dirs.SystemCertsDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) != 0 {
+		return certs, nil
+	}
+	return parseCertificatesDb(dirs.SystemCertsDir)
+}
+
+// ListCertificates returns the details of all system and custom certificates
+// known to snapd, including the ones that are blocked from the merged
+// certificate database. System certificates are listed first.
+func ListCertificates() ([]*CertificateDetails, error) {
+	blockedDigests, err := readDigests(filepath.Join(dirs.SnapdPKIV1Dir, "blocked"))
+	if err != nil {
+		return nil, err
+	}
+
+	systemCerts, err := systemCertificates()
+	if err != nil {
+		return nil, err
+	}
+
+	var details []*CertificateDetails
+	seen := make(map[string]bool)
+	for _, cert := range systemCerts {
+		if seen[cert.Sha256] {
+			continue
+		}
+		seen[cert.Sha256] = true
+
+		data := cert.Data
+		if len(data) == 0 {
+			data, err = os.ReadFile(cert.RealPath)
+			if err != nil {
+				logger.Noticef("cannot read certificate %q: %v", cert.RealPath, err)
+				continue
+			}
+		}
+		state := CertificateStateAccepted
+		if strutil.ListContains(blockedDigests, cert.Sha256) {
+			state = CertificateStateBlocked
+		}
+		d, err := certificateDetails(cert.Name, cert.Sha256, CertificateOriginSystem, state, data)
+		if err != nil {
+			logger.Noticef("cannot parse certificate %q: %v", cert.Name, err)
+			continue
+		}
+		details = append(details, d)
+	}
+
+	customCerts, err := CustomCertificates()
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range customCerts {
+		d, err := certificateDetails(info.Name, info.Fingerprint, CertificateOriginCustom, info.State, []byte(info.Content))
+		if err != nil {
+			logger.Noticef("cannot parse custom certificate %q: %v", info.Name, err)
+			continue
+		}
+		details = append(details, d)
+	}
+	return details, nil
+}
+
+// CurrentCertificateGeneration returns the name of the published generation
+// the merged certificate database currently points to, or an empty string if
+// no generation has been published yet.
+func CurrentCertificateGeneration() (string, error) {
+	target, err := resolveCurrentCertificateTarget()
+	if err != nil || target == "" {
+		return "", err
+	}
+	return filepath.Base(target), nil
+}
+
+// findSystemCertificate returns the system certificate with the given digest.
+func findSystemCertificate(digest string) (*certificate, error) {
+	systemCerts, err := systemCertificates()
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range systemCerts {
+		if cert.Sha256 == digest {
+			return &cert, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot find system certificate with fingerprint %q", digest)
+}
+
+// systemCertificateState returns whether the system certificate with the
+// given digest is currently blocked or accepted.
+func systemCertificateState(digest string) string {
+	blockedDir := filepath.Join(dirs.SnapdPKIV1Dir, "blocked")
+	if osutil.IsSymlink(certificatePathWithExtension(blockedDir, digest)) {
+		return CertificateStateBlocked
+	}
+	return CertificateStateAccepted
+}
+
+// setSystemCertificateState blocks or unblocks the system certificate with
+// the given digest. Blocking is recorded the same way as for custom
+// certificates, with a link in the blocked directory that points to the
+// system certificate (or the bundle it was read from). The merged
+// certificate database must be refreshed for the change to take effect.
+func setSystemCertificateState(digest, state string) error {
+	cert, err := findSystemCertificate(digest)
+	if err != nil {
+		return err
+	}
+
+	blockedDir := filepath.Join(dirs.SnapdPKIV1Dir, "blocked")
+	blockedPath := certificatePathWithExtension(blockedDir, digest)
+	switch state {
+	case CertificateStateBlocked:
+		if err := os.MkdirAll(blockedDir, 0o755); err != nil {
+			return fmt.Errorf("cannot create directory %q: %v", blockedDir, err)
+		}
+		target := cert.Path
+		if target == "" {
+			target = filepath.Join(dirs.SystemCertsDir, "ca-certificates.crt")
+		}
+		if err := os.Symlink(target, blockedPath); err != nil && !os.IsExist(err) {
+			return err
+		}
+	case CertificateStateAccepted:
+		if err := os.Remove(blockedPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid state for system certificate %q: %q", digest, state)
+	}
+	return nil
+}
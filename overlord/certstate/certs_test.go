@@ -64,6 +64,10 @@ func (s *certsTestSuite) TearDownTest(c *C) {
 var _ = Suite(&certsTestSuite{})
 
 func makeTestCertPEM(commonName string) ([]byte, *x509.Certificate, error) {
+	return makeTestCertPEMWithValidity(commonName, time.Now().Add(-time.Hour), time.Now().Add(24*time.Hour))
+}
+
+func makeTestCertPEMWithValidity(commonName string, notBefore, notAfter time.Time) ([]byte, *x509.Certificate, error) {
 	priv, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
 		return nil, nil, err
@@ -77,8 +81,8 @@ func makeTestCertPEM(commonName string) ([]byte, *x509.Certificate, error) {
 	tmpl := &x509.Certificate{
 		SerialNumber: serial,
 		Subject:      pkix.Name{CommonName: commonName},
-		NotBefore:    time.Now().Add(-time.Hour),
-		NotAfter:     time.Now().Add(24 * time.Hour),
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
 
 		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
 		BasicConstraintsValid: true,
@@ -878,7 +882,7 @@ func (s *certsTestSuite) TestRefreshCertificateDatabaseKeepsHashLinksValidAfterS
 	c.Assert(err, IsNil)
 	c.Assert(parsed, HasLen, 1)
 
-	err = certstate.RefreshCertificateDatabase()
+	err = certstate.RefreshCertificateDatabase(nil)
 	c.Assert(err, IsNil)
 
 	mergedDir := filepath.Join(dirs.SnapdPKIV1Dir, "merged")
@@ -912,7 +916,7 @@ func (s *certsTestSuite) TestRefreshCertificateDatabasePublishesImmutableGenerat
 	bPath := filepath.Join(baseCertsDir, "b.crt")
 	c.Assert(os.WriteFile(aPath, aPEM, 0o644), IsNil)
 
-	err = certstate.RefreshCertificateDatabase()
+	err = certstate.RefreshCertificateDatabase(nil)
 	c.Assert(err, IsNil)
 
 	mergedDir := filepath.Join(dirs.SnapdPKIV1Dir, "merged")
@@ -927,7 +931,7 @@ func (s *certsTestSuite) TestRefreshCertificateDatabasePublishesImmutableGenerat
 	c.Assert(os.Remove(aPath), IsNil)
 	c.Assert(os.WriteFile(bPath, bPEM, 0o644), IsNil)
 
-	err = certstate.RefreshCertificateDatabase()
+	err = certstate.RefreshCertificateDatabase(nil)
 	c.Assert(err, IsNil)
 
 	secondTarget, err := os.Readlink(mergedDir)
@@ -962,7 +966,7 @@ func (s *certsTestSuite) TestRefreshCertificateDatabaseRenamedAcceptedCertificat
 	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
 
 	firstDigest := setAcceptedCustomCertificate(c, "oldcert", customPEM)
-	err = certstate.RefreshCertificateDatabase()
+	err = certstate.RefreshCertificateDatabase(nil)
 	c.Assert(err, IsNil)
 
 	mergedDir := filepath.Join(dirs.SnapdPKIV1Dir, "merged")
@@ -977,7 +981,7 @@ func (s *certsTestSuite) TestRefreshCertificateDatabaseRenamedAcceptedCertificat
 	secondDigest := setAcceptedCustomCertificate(c, "newcert", customPEM)
 	c.Check(secondDigest, Equals, firstDigest)
 
-	err = certstate.RefreshCertificateDatabase()
+	err = certstate.RefreshCertificateDatabase(nil)
 	c.Assert(err, IsNil)
 
 	secondTarget, err := os.Readlink(mergedDir)
@@ -1005,7 +1009,7 @@ func (s *certsTestSuite) TestRefreshCertificateDatabaseCollisionRenameGetsNewGen
 	c.Assert(os.WriteFile(filepath.Join(baseCertsDir, "shared.crt"), systemPEM, 0o644), IsNil)
 
 	customDigest := setAcceptedCustomCertificate(c, "extra", customPEM)
-	err = certstate.RefreshCertificateDatabase()
+	err = certstate.RefreshCertificateDatabase(nil)
 	c.Assert(err, IsNil)
 
 	mergedDir := filepath.Join(dirs.SnapdPKIV1Dir, "merged")
@@ -1020,7 +1024,7 @@ func (s *certsTestSuite) TestRefreshCertificateDatabaseCollisionRenameGetsNewGen
 	secondDigest := setAcceptedCustomCertificate(c, "shared", customPEM)
 	c.Check(secondDigest, Equals, customDigest)
 
-	err = certstate.RefreshCertificateDatabase()
+	err = certstate.RefreshCertificateDatabase(nil)
 	c.Assert(err, IsNil)
 
 	secondTarget, err := os.Readlink(mergedDir)
@@ -1050,7 +1054,7 @@ func (s *certsTestSuite) TestRefreshCertificateDatabaseFormattingOnlyChangeReuse
 	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
 
 	digest := setAcceptedCustomCertificate(c, "samecert", customPEM)
-	err = certstate.RefreshCertificateDatabase()
+	err = certstate.RefreshCertificateDatabase(nil)
 	c.Assert(err, IsNil)
 
 	mergedDir := filepath.Join(dirs.SnapdPKIV1Dir, "merged")
@@ -1068,7 +1072,7 @@ func (s *certsTestSuite) TestRefreshCertificateDatabaseFormattingOnlyChangeReuse
 	c.Check(digestForPEM(c, reformattedPEM), Equals, digest)
 	c.Assert(certstate.WriteCertificate("samecert", string(reformattedPEM)), IsNil)
 
-	err = certstate.RefreshCertificateDatabase()
+	err = certstate.RefreshCertificateDatabase(nil)
 	c.Assert(err, IsNil)
 
 	secondTarget, err := os.Readlink(mergedDir)
@@ -1324,3 +1328,84 @@ func (s *certsTestSuite) TestCustomCertificateInfoMissingCertificate(c *C) {
 	c.Check(errors.Is(err, os.ErrNotExist), Equals, true)
 	c.Check(err, ErrorMatches, `cannot read certificate "does-not-exist": .*`)
 }
+
+func (s *certsTestSuite) TestCertificatesListsSystemAndCustom(c *C) {
+	certSystem, sysX509, err := makeTestCertPEM("system")
+	c.Assert(err, IsNil)
+	certSystemBlocked, _, err := makeTestCertPEM("system-blocked")
+	c.Assert(err, IsNil)
+	certCustom, customX509, err := makeTestCertPEM("custom")
+	c.Assert(err, IsNil)
+
+	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "system.crt"), certSystem, 0o644), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "z-blocked.pem"), certSystemBlocked, 0o644), IsNil)
+
+	blockedDir := filepath.Join(dirs.SnapdPKIV1Dir, "blocked")
+	c.Assert(os.MkdirAll(blockedDir, 0o755), IsNil)
+	blockedDigest := digestForPEM(c, certSystemBlocked)
+	c.Assert(os.Symlink(filepath.Join(dirs.SystemCertsDir, "z-blocked.pem"), filepath.Join(blockedDir, blockedDigest+".crt")), IsNil)
+
+	customDigest := setAcceptedCustomCertificate(c, "my-ca", certCustom)
+
+	details, err := certstate.ListCertificates()
+	c.Assert(err, IsNil)
+	c.Assert(details, HasLen, 3)
+
+	c.Check(details[0], DeepEquals, &certstate.CertificateDetails{
+		Name:        "system",
+		Fingerprint: digestForPEM(c, certSystem),
+		Origin:      certstate.CertificateOriginSystem,
+		State:       certstate.CertificateStateAccepted,
+		Subject:     "CN=system",
+		Issuer:      "CN=system",
+		NotBefore:   sysX509.NotBefore.UTC(),
+		NotAfter:    sysX509.NotAfter.UTC(),
+	})
+	c.Check(details[1].Name, Equals, "z-blocked")
+	c.Check(details[1].Origin, Equals, certstate.CertificateOriginSystem)
+	c.Check(details[1].State, Equals, certstate.CertificateStateBlocked)
+	c.Check(details[2], DeepEquals, &certstate.CertificateDetails{
+		Name:        "my-ca",
+		Fingerprint: customDigest,
+		Origin:      certstate.CertificateOriginCustom,
+		State:       certstate.CertificateStateAccepted,
+		Subject:     "CN=custom",
+		Issuer:      "CN=custom",
+		NotBefore:   customX509.NotBefore.UTC(),
+		NotAfter:    customX509.NotAfter.UTC(),
+	})
+}
+
+func (s *certsTestSuite) TestCertificatesNoSystemCertsDir(c *C) {
+	details, err := certstate.ListCertificates()
+	c.Assert(err, IsNil)
+	c.Check(details, HasLen, 0)
+}
+
+func (s *certsTestSuite) TestCurrentCertificateGeneration(c *C) {
+	generation, err := certstate.CurrentCertificateGeneration()
+	c.Assert(err, IsNil)
+	c.Check(generation, Equals, "")
+
+	certA, _, err := makeTestCertPEM("A")
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "a.crt"), certA, 0o644), IsNil)
+	c.Assert(certstate.RefreshCertificateDatabase(nil), IsNil)
+
+	target, err := os.Readlink(certstate.CurrentCertificateDir())
+	c.Assert(err, IsNil)
+	generation, err = certstate.CurrentCertificateGeneration()
+	c.Assert(err, IsNil)
+	c.Check(generation, Not(Equals), "")
+	c.Check(target, Equals, filepath.Join("published", generation))
+}
+
+func parsedNotAfter(c *C, pemBytes []byte) string {
+	block, _ := pem.Decode(pemBytes)
+	c.Assert(block, NotNil)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	c.Assert(err, IsNil)
+	return cert.NotAfter.UTC().Format(time.RFC3339)
+}
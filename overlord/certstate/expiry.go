@@ -0,0 +1,210 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package certstate
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+)
+
+var timeNow = time.Now
+
+// ExpiredCertificateError is returned when publishing a certificate
+// generation would introduce a custom certificate that has already expired.
+type ExpiredCertificateError struct {
+	Name     string
+	Subject  string
+	NotAfter time.Time
+}
+
+func (e *ExpiredCertificateError) Error() string {
+	return fmt.Sprintf("cannot publish certificate database: custom certificate %q (%s) expired on %s",
+		e.Name, e.Subject, e.NotAfter.UTC().Format(time.RFC3339))
+}
+
+// parseAllCertificates returns every certificate found in a PEM payload,
+// skipping over blocks that cannot be parsed. Non-PEM payloads are parsed as
+// a single DER certificate.
+func parseAllCertificates(data []byte) []*x509.Certificate {
+	if block, _ := pem.Decode(data); block == nil {
+		cert, err := x509.ParseCertificate(data)
+		if err != nil {
+			return nil
+		}
+		return []*x509.Certificate{cert}
+	}
+
+	var certs []*x509.Certificate
+	rest := data
+	for {
+		block, next := pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		rest = next
+		if !certificatePEMBlockTypePattern.MatchString(block.Type) {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			logger.Debugf("cannot parse certificate PEM block: %v", err)
+			continue
+		}
+		certs = append(certs, cert)
+	}
+	return certs
+}
+
+// currentGenerationCertificates returns the certificates that are part of
+// the ca-certificates.crt bundle of the generation the given public pointer
+// resolves to.
+func currentGenerationCertificates(currentDir string) ([]*x509.Certificate, error) {
+	bundlePath := filepath.Join(currentDir, "ca-certificates.crt")
+	data, err := os.ReadFile(bundlePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot read current certificate database: %v", err)
+	}
+	return parseAllCertificates(data), nil
+}
+
+// checkNoNewlyExpiredCertificates returns an error if any accepted custom
+// certificate contains a certificate that has expired at the given time and
+// is not already part of the generation currentDir resolves to.
+func checkNoNewlyExpiredCertificates(certs *certificates, currentDir string, now time.Time) error {
+	current, err := currentGenerationCertificates(currentDir)
+	if err != nil {
+		return err
+	}
+	published := make(map[string]bool, len(current))
+	for _, cert := range current {
+		published[sha256HexForChain([][]byte{cert.Raw})] = true
+	}
+
+	for _, added := range certs.AddedCertificates {
+		if isBlocked(added, certs.BlockedDigests) {
+			continue
+		}
+		data, err := os.ReadFile(added.RealPath)
+		if err != nil {
+			return fmt.Errorf("cannot read certificate %q: %v", added.Name, err)
+		}
+		for _, cert := range parseAllCertificates(data) {
+			if !now.After(cert.NotAfter) || published[sha256HexForChain([][]byte{cert.Raw})] {
+				continue
+			}
+			return &ExpiredCertificateError{
+				Name:     trimExtension(filepath.Base(added.RealPath)),
+				Subject:  cert.Subject.String(),
+				NotAfter: cert.NotAfter,
+			}
+		}
+	}
+	return nil
+}
+
+// customCertificateDigests returns the digests of the certificates added
+// through snapd, either for all snaps or restricted to some of them, as
+// opposed to the certificates provided by the system.
+func customCertificateDigests() (map[string]bool, error) {
+	addedDirs := []string{filepath.Join(dirs.SnapdPKIV1Dir, "added")}
+	snapNames, err := snapsWithCertificates()
+	if err != nil {
+		return nil, err
+	}
+	for _, snapName := range snapNames {
+		addedDirs = append(addedDirs, filepath.Join(snapAddedCertificatesDir(), snapName))
+	}
+
+	digests := make(map[string]bool)
+	for _, addedDir := range addedDirs {
+		if !osutil.IsDirectory(addedDir) {
+			continue
+		}
+		added, err := parseCertificates(addedDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range added {
+			data, err := os.ReadFile(a.RealPath)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read certificate %q: %v", a.Name, err)
+			}
+			for _, cert := range parseAllCertificates(data) {
+				digests[sha256HexForChain([][]byte{cert.Raw})] = true
+			}
+		}
+	}
+	return digests, nil
+}
+
+// expiringCertificates returns the custom certificates of the current
+// generation, and of the current per-snap generations, that have expired or
+// will expire within the given window. Certificates provided by the system
+// are kept up to date by the system itself and are not reported.
+func expiringCertificates(now time.Time, window time.Duration) ([]*x509.Certificate, error) {
+	custom, err := customCertificateDigests()
+	if err != nil {
+		return nil, err
+	}
+	if len(custom) == 0 {
+		return nil, nil
+	}
+
+	current, err := currentGenerationCertificates(CurrentCertificateDir())
+	if err != nil {
+		return nil, err
+	}
+	snapNames, err := publishedSnapCertificates()
+	if err != nil {
+		return nil, err
+	}
+	for _, snapName := range snapNames {
+		snapCurrent, err := currentGenerationCertificates(SnapCertificatesDir(snapName))
+		if err != nil {
+			return nil, err
+		}
+		current = append(current, snapCurrent...)
+	}
+
+	var expiring []*x509.Certificate
+	seen := make(map[string]bool)
+	for _, cert := range current {
+		digest := sha256HexForChain([][]byte{cert.Raw})
+		if seen[digest] || !custom[digest] {
+			continue
+		}
+		seen[digest] = true
+		if now.Add(window).After(cert.NotAfter) {
+			expiring = append(expiring, cert)
+		}
+	}
+	return expiring, nil
+}
@@ -0,0 +1,223 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package certstate_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/certstate"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *certsTestSuite) TestRefreshCertificateDatabaseRefusesExpiredCustomCertificate(c *C) {
+	certA, _, err := makeTestCertPEM("A")
+	c.Assert(err, IsNil)
+	expired, _, err := makeTestCertPEMWithValidity("expired", time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour))
+	c.Assert(err, IsNil)
+
+	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "a.crt"), certA, 0o644), IsNil)
+	c.Assert(certstate.RefreshCertificateDatabase(nil), IsNil)
+	generation, err := certstate.CurrentCertificateGeneration()
+	c.Assert(err, IsNil)
+
+	setAcceptedCustomCertificate(c, "old-ca", expired)
+
+	err = certstate.RefreshCertificateDatabase(nil)
+	c.Assert(err, ErrorMatches, `cannot publish certificate database: custom certificate "old-ca" \(CN=expired\) expired on .*`)
+	c.Check(err, FitsTypeOf, &certstate.ExpiredCertificateError{})
+
+	// the current generation is left untouched
+	current, err := certstate.CurrentCertificateGeneration()
+	c.Assert(err, IsNil)
+	c.Check(current, Equals, generation)
+
+	// unless forced
+	c.Assert(certstate.RefreshCertificateDatabase(&certstate.RefreshOptions{AllowExpired: true}), IsNil)
+	out, err := os.ReadFile(filepath.Join(dirs.SnapdPKIV1Dir, "merged", "ca-certificates.crt"))
+	c.Assert(err, IsNil)
+	c.Check(bytes.Contains(out, expired), Equals, true)
+
+	// once published, the expired certificate does not block further
+	// refreshes
+	certB, _, err := makeTestCertPEM("B")
+	c.Assert(err, IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "b.crt"), certB, 0o644), IsNil)
+	c.Assert(certstate.RefreshCertificateDatabase(nil), IsNil)
+}
+
+func (s *certsTestSuite) TestRefreshCertificateDatabaseIgnoresBlockedExpiredCustomCertificate(c *C) {
+	certA, _, err := makeTestCertPEM("A")
+	c.Assert(err, IsNil)
+	expired, _, err := makeTestCertPEMWithValidity("expired", time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour))
+	c.Assert(err, IsNil)
+
+	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "a.crt"), certA, 0o644), IsNil)
+
+	c.Assert(os.MkdirAll(filepath.Join(dirs.SnapdPKIV1Dir, "blocked"), 0o755), IsNil)
+	c.Assert(certstate.WriteCertificate("old-ca", string(expired)), IsNil)
+	c.Assert(certstate.SetCertificateState("old-ca", digestForPEM(c, expired), certstate.CertificateStateBlocked), IsNil)
+
+	c.Assert(certstate.RefreshCertificateDatabase(nil), IsNil)
+}
+
+func (s *certMgrTestSuite) mockExpiryGeneration(c *C) (expiring, expired []byte) {
+	valid, _, err := makeTestCertPEMWithValidity("valid", time.Now().Add(-time.Hour), time.Now().Add(365*24*time.Hour))
+	c.Assert(err, IsNil)
+	expiring, _, err = makeTestCertPEMWithValidity("expiring", time.Now().Add(-time.Hour), time.Now().Add(5*24*time.Hour))
+	c.Assert(err, IsNil)
+	expired, _, err = makeTestCertPEMWithValidity("expired", time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour))
+	c.Assert(err, IsNil)
+
+	// system certificates are not reported, those are kept up to date
+	// with the system
+	systemExpiring, _, err := makeTestCertPEMWithValidity("system-expiring", time.Now().Add(-time.Hour), time.Now().Add(5*24*time.Hour))
+	c.Assert(err, IsNil)
+
+	var bundle []byte
+	bundle = append(bundle, valid...)
+	bundle = append(bundle, expiring...)
+	bundle = append(bundle, expired...)
+	bundle = append(bundle, systemExpiring...)
+	seedCurrentPublishedGeneration(c, "gen1", bundle)
+
+	setAcceptedCustomCertificate(c, "valid-ca", valid)
+	setAcceptedCustomCertificate(c, "expiring-ca", expiring)
+	setAcceptedCustomCertificate(c, "expired-ca", expired)
+	return expiring, expired
+}
+
+func (s *certMgrTestSuite) warningMessages() []string {
+	var msgs []string
+	for _, w := range s.state.AllWarnings() {
+		msgs = append(msgs, w.String())
+	}
+	return msgs
+}
+
+func (s *certMgrTestSuite) TestEnsureWarnsAboutExpiringCertificates(c *C) {
+	expiring, expired := s.mockExpiryGeneration(c)
+
+	s.state.Lock()
+	s.state.Set("seeded", true)
+	s.state.Unlock()
+
+	c.Assert(s.mgr.Ensure(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	msgs := s.warningMessages()
+	c.Assert(msgs, HasLen, 2)
+	c.Check(msgs, testutil.Contains, fmt.Sprintf(`certificate "CN=expiring" (%s) in the certificate database expires on %s`,
+		digestForPEM(c, expiring), parsedNotAfter(c, expiring)))
+	c.Check(msgs, testutil.Contains, fmt.Sprintf(`certificate "CN=expired" (%s) in the certificate database expired on %s`,
+		digestForPEM(c, expired), parsedNotAfter(c, expired)))
+}
+
+func (s *certMgrTestSuite) TestEnsureExpiryCheckHonoursWindowAndInterval(c *C) {
+	s.mockExpiryGeneration(c)
+
+	now := time.Now()
+	restore := certstate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	s.state.Lock()
+	s.state.Set("seeded", true)
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "pki.certs.expiry-warning-window", "1h"), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	c.Assert(s.mgr.Ensure(), IsNil)
+
+	s.state.Lock()
+	c.Check(s.warningMessages(), HasLen, 1)
+	tr = config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "pki.certs.expiry-warning-window", "720h"), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	// not yet time for the next check
+	now = now.Add(time.Hour)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.state.Lock()
+	c.Check(s.warningMessages(), HasLen, 1)
+	s.state.Unlock()
+
+	now = now.Add(24 * time.Hour)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.state.Lock()
+	c.Check(s.warningMessages(), HasLen, 2)
+	s.state.Unlock()
+}
+
+func (s *certMgrTestSuite) TestEnsureExpiryCheckDisabled(c *C) {
+	s.mockExpiryGeneration(c)
+
+	s.state.Lock()
+	s.state.Set("seeded", true)
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "pki.certs.expiry-warning-window", "0s"), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	c.Assert(s.mgr.Ensure(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.warningMessages(), HasLen, 0)
+}
+
+func (s *certMgrTestSuite) TestUpdateCertificateDatabaseHonoursAllowExpired(c *C) {
+	certA, _, err := makeTestCertPEM("A")
+	c.Assert(err, IsNil)
+	expired, _, err := makeTestCertPEMWithValidity("expired", time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour))
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "a.crt"), certA, 0o644), IsNil)
+	setAcceptedCustomCertificate(c, "old-ca", expired)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chg := s.state.NewChange("foo", "test change")
+	chg.AddTask(s.state.NewTask("update-cert-db", "running handler"))
+	s.settle(c)
+	c.Check(chg.Err(), ErrorMatches, `(?s).*custom certificate "old-ca" \(CN=expired\) expired on .*`)
+
+	// only when explicitly requested for the change
+	chg = s.state.NewChange("foo", "test change")
+	ts := state.NewTaskSet(s.state.NewTask("update-cert-db", "running handler"))
+	certstate.AllowExpiredCertificates(ts)
+	chg.AddAll(ts)
+	s.settle(c)
+	c.Check(chg.Err(), IsNil)
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+}
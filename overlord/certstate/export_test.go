@@ -19,6 +19,8 @@
 package certstate
 
 import (
+	"time"
+
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/testutil"
 	"gopkg.in/tomb.v2"
@@ -56,7 +58,7 @@ func GenerateCACertificates(certs *Certificates, mergedPath string) error {
 	return err
 }
 
-func MockRefreshCertificateDatabase(f func() error) func() {
+func MockRefreshCertificateDatabase(f func(opts *RefreshOptions) error) func() {
 	restore := testutil.Backup(&RefreshCertificateDatabase)
 	RefreshCertificateDatabase = f
 	return restore
@@ -75,3 +77,9 @@ func (m *CertManager) DoUpdateCertificateDatabase(t *state.Task, tb *tomb.Tomb)
 func (m *CertManager) UndoUpdateCertificateDatabase(t *state.Task, tb *tomb.Tomb) error {
 	return m.undoUpdateCertificateDatabase(t, tb)
 }
+
+func MockTimeNow(f func() time.Time) func() {
+	restore := testutil.Backup(&timeNow)
+	timeNow = f
+	return restore
+}
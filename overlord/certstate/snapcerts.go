@@ -0,0 +1,364 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package certstate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/snap"
+)
+
+// Per-snap trust stores use the following structure next to the system one:
+//   - snap-added/<snap>/<digest>.crt links custom certificates that are only
+//     trusted by the given snap, they are not part of the system view
+//   - snap-published/<snap>/<generation> holds the immutable generations of
+//     the system view plus the certificates allowed for that snap
+//   - snap-merged/<snap> points at the current generation of that snap
+//
+// Per-snap generations are kept apart from the system ones, so that the
+// sandbox of a snap can be given access to its own generations only.
+// Garbage collection considers every public pointer as current.
+
+func snapAddedCertificatesDir() string {
+	return filepath.Join(dirs.SnapdPKIV1Dir, "snap-added")
+}
+
+func snapMergedCertificatesDir() string {
+	return filepath.Join(dirs.SnapdPKIV1Dir, "snap-merged")
+}
+
+func snapPublishedCertificatesDir(snapName string) string {
+	return filepath.Join(dirs.SnapdPKIV1Dir, "snap-published", snapName)
+}
+
+// snapPublishedCertificatesDirs returns the directories holding per-snap
+// generations.
+func snapPublishedCertificatesDirs() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dirs.SnapdPKIV1Dir, "snap-published"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot read per-snap published certificates directory: %v", err)
+	}
+
+	var publishedDirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			publishedDirs = append(publishedDirs, snapPublishedCertificatesDir(entry.Name()))
+		}
+	}
+	return publishedDirs, nil
+}
+
+// removeEmptySnapPublishedCertificatesDirs removes the directories of snaps
+// whose generations have all been garbage collected.
+func removeEmptySnapPublishedCertificatesDirs() error {
+	publishedDirs, err := snapPublishedCertificatesDirs()
+	if err != nil {
+		return err
+	}
+	for _, publishedDir := range publishedDirs {
+		if entries, err := os.ReadDir(publishedDir); err == nil && len(entries) == 0 {
+			if err := os.Remove(publishedDir); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SnapCertificatesDir returns the path of the pointer to the dedicated
+// certificate view of the given snap. The pointer only exists while custom
+// certificates are restricted to that snap.
+func SnapCertificatesDir(snapName string) string {
+	return filepath.Join(snapMergedCertificatesDir(), snapName)
+}
+
+// CertificateDirForSnap returns the certificate view the given snap should
+// consume, which is its dedicated view if it has one, or the system view
+// otherwise.
+func CertificateDirForSnap(snapName string) string {
+	snapDir := SnapCertificatesDir(snapName)
+	if osutil.IsSymlink(snapDir) {
+		return snapDir
+	}
+	return CurrentCertificateDir()
+}
+
+// snapCertificatesGeneration returns the target of a per-snap pointer for the
+// given generation target, relative to snap-merged.
+func snapCertificatesGeneration(target string) string {
+	return filepath.Join("..", target)
+}
+
+// SetCertificateSnaps restricts the custom certificate with the given name to
+// the given snaps, by linking it from their allow-lists. The certificate is
+// expected not to be part of the system view, callers that need to clear an
+// existing state must remove old symlinks separately.
+func SetCertificateSnaps(name, digest string, snapNames []string) error {
+	customPath := certificatePathWithExtension(filepath.Join("..", ".."), name)
+	for _, snapName := range snapNames {
+		if err := snap.ValidateInstanceName(snapName); err != nil {
+			return err
+		}
+		allowDir := filepath.Join(snapAddedCertificatesDir(), snapName)
+		if err := os.MkdirAll(allowDir, 0o755); err != nil {
+			return err
+		}
+		allowPath := certificatePathWithExtension(allowDir, digest)
+		if err := os.Symlink(customPath, allowPath); err != nil && !os.IsExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapsWithCertificates returns the snaps that have an allow-list of custom
+// certificates.
+func snapsWithCertificates() ([]string, error) {
+	entries, err := os.ReadDir(snapAddedCertificatesDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot read per-snap certificates directory: %v", err)
+	}
+
+	var snapNames []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			snapNames = append(snapNames, entry.Name())
+		}
+	}
+	return snapNames, nil
+}
+
+// certificateSnaps returns the snaps the certificate with the given digest is
+// restricted to.
+func certificateSnaps(digest string) ([]string, error) {
+	snapNames, err := snapsWithCertificates()
+	if err != nil {
+		return nil, err
+	}
+
+	var allowed []string
+	for _, snapName := range snapNames {
+		allowDir := filepath.Join(snapAddedCertificatesDir(), snapName)
+		if osutil.IsSymlink(certificatePathWithExtension(allowDir, digest)) {
+			allowed = append(allowed, snapName)
+		}
+	}
+	return allowed, nil
+}
+
+// removeSnapCertificateSymlinks removes the certificate with the given digest
+// from all per-snap allow-lists, dropping allow-lists that become empty.
+func removeSnapCertificateSymlinks(digest string) error {
+	snapNames, err := snapsWithCertificates()
+	if err != nil {
+		return err
+	}
+
+	for _, snapName := range snapNames {
+		allowDir := filepath.Join(snapAddedCertificatesDir(), snapName)
+		if err := os.Remove(certificatePathWithExtension(allowDir, digest)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if entries, err := os.ReadDir(allowDir); err == nil && len(entries) == 0 {
+			if err := os.Remove(allowDir); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type snapCertificates struct {
+	snapName string
+	certs    *certificates
+}
+
+// loadSnapCertificates builds the certificate view of every snap with an
+// allow-list, on top of the given system view.
+func loadSnapCertificates(system *certificates) ([]snapCertificates, error) {
+	snapNames, err := snapsWithCertificates()
+	if err != nil {
+		return nil, err
+	}
+
+	var views []snapCertificates
+	for _, snapName := range snapNames {
+		allowed, err := parseCertificates(filepath.Join(snapAddedCertificatesDir(), snapName))
+		if err != nil {
+			return nil, err
+		}
+		if len(allowed) == 0 {
+			continue
+		}
+
+		added := make([]certificate, 0, len(system.AddedCertificates)+len(allowed))
+		added = append(added, system.AddedCertificates...)
+		added = append(added, allowed...)
+		views = append(views, snapCertificates{
+			snapName: snapName,
+			certs: &certificates{
+				SystemCertificates: system.SystemCertificates,
+				AddedCertificates:  added,
+				BlockedDigests:     system.BlockedDigests,
+				// the view is passed to the snap as SSL_CERT_DIR
+				OpenSSLDir: true,
+			},
+		})
+	}
+	return views, nil
+}
+
+// publishSnapCertificateGenerations publishes the given per-snap views and
+// moves their pointers, removing pointers of snaps that no longer have a
+// dedicated view.
+func publishSnapCertificateGenerations(views []snapCertificates) error {
+	if len(views) != 0 {
+		if err := os.MkdirAll(snapMergedCertificatesDir(), 0o755); err != nil {
+			return fmt.Errorf("cannot create directory %q: %v", snapMergedCertificatesDir(), err)
+		}
+	}
+
+	current, err := snapCertificateTargets()
+	if err != nil {
+		return err
+	}
+
+	published := make(map[string]bool, len(views))
+	for _, view := range views {
+		publishedDir := snapPublishedCertificatesDir(view.snapName)
+		if err := os.MkdirAll(publishedDir, 0o755); err != nil {
+			return fmt.Errorf("cannot create directory %q: %v", publishedDir, err)
+		}
+		nextTarget, err := publishCertificateGeneration(view.certs, publishedDir)
+		if err != nil {
+			return err
+		}
+		if nextTarget == "" {
+			continue
+		}
+		published[view.snapName] = true
+		if current[view.snapName] == nextTarget {
+			continue
+		}
+		logger.Debugf("publishing certificate generation %s for snap %q", nextTarget, view.snapName)
+		if err := switchCertificatesLink(SnapCertificatesDir(view.snapName), snapCertificatesGeneration(nextTarget)); err != nil {
+			return err
+		}
+	}
+
+	for snapName := range current {
+		if published[snapName] {
+			continue
+		}
+		if err := os.Remove(SnapCertificatesDir(snapName)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot clear certificate view of snap %q: %v", snapName, err)
+		}
+	}
+	return nil
+}
+
+// publishedSnapCertificates returns the snaps that currently have a dedicated
+// certificate view.
+func publishedSnapCertificates() ([]string, error) {
+	targets, err := snapCertificateTargets()
+	if err != nil {
+		return nil, err
+	}
+	snapNames := make([]string, 0, len(targets))
+	for snapName := range targets {
+		snapNames = append(snapNames, snapName)
+	}
+	sort.Strings(snapNames)
+	return snapNames, nil
+}
+
+// snapCertificateTargets maps snaps with a dedicated certificate view to the
+// generation target their pointer resolves to, in the same form as the target
+// of the system pointer.
+func snapCertificateTargets() (map[string]string, error) {
+	entries, err := os.ReadDir(snapMergedCertificatesDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot read per-snap certificate views: %v", err)
+	}
+
+	targets := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry.Type()&os.ModeSymlink == 0 || strings.HasSuffix(entry.Name(), ".new") {
+			continue
+		}
+		target, err := os.Readlink(filepath.Join(snapMergedCertificatesDir(), entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel("..", target)
+		if err != nil {
+			return nil, err
+		}
+		targets[entry.Name()] = rel
+	}
+	return targets, nil
+}
+
+// restoreSnapCertificateTargets moves the per-snap pointers back to the given
+// targets, as returned by snapCertificateTargets, removing any other pointer.
+func restoreSnapCertificateTargets(targets map[string]string) error {
+	current, err := snapCertificateTargets()
+	if err != nil {
+		return err
+	}
+	for snapName := range current {
+		if _, ok := targets[snapName]; ok {
+			continue
+		}
+		if err := os.Remove(SnapCertificatesDir(snapName)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(snapMergedCertificatesDir(), 0o755); err != nil {
+		return err
+	}
+	for snapName, target := range targets {
+		if current[snapName] == target {
+			continue
+		}
+		if err := switchCertificatesLink(SnapCertificatesDir(snapName), snapCertificatesGeneration(target)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
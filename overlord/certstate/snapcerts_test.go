@@ -0,0 +1,238 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package certstate_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/certstate"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func setRestrictedCustomCertificate(c *C, name string, pemBytes []byte, snapNames ...string) string {
+	digest := digestForPEM(c, pemBytes)
+	c.Assert(os.MkdirAll(dirs.SnapdPKIV1Dir, 0o755), IsNil)
+	c.Assert(certstate.WriteCertificate(name, string(pemBytes)), IsNil)
+	c.Assert(certstate.SetCertificateSnaps(name, digest, snapNames), IsNil)
+	return digest
+}
+
+func readBundle(c *C, dir string) []byte {
+	out, err := os.ReadFile(filepath.Join(dir, "ca-certificates.crt"))
+	c.Assert(err, IsNil)
+	return out
+}
+
+func (s *certsTestSuite) TestRefreshCertificateDatabasePublishesSnapGenerations(c *C) {
+	certA, _, err := makeTestCertPEM("A")
+	c.Assert(err, IsNil)
+	internal, _, err := makeTestCertPEM("internal")
+	c.Assert(err, IsNil)
+	public, _, err := makeTestCertPEM("public")
+	c.Assert(err, IsNil)
+
+	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "a.crt"), certA, 0o644), IsNil)
+	c.Assert(certstate.RefreshCertificateDatabase(nil), IsNil)
+
+	setAcceptedCustomCertificate(c, "public-ca", public)
+	setRestrictedCustomCertificate(c, "internal-ca", internal, "foo", "bar_instance")
+	c.Assert(certstate.RefreshCertificateDatabase(nil), IsNil)
+
+	// the system view does not include the restricted certificate
+	system := readBundle(c, certstate.CurrentCertificateDir())
+	c.Check(bytes.Contains(system, certA), Equals, true)
+	c.Check(bytes.Contains(system, public), Equals, true)
+	c.Check(bytes.Contains(system, internal), Equals, false)
+
+	for _, snapName := range []string{"foo", "bar_instance"} {
+		c.Check(certstate.CertificateDirForSnap(snapName), Equals, certstate.SnapCertificatesDir(snapName))
+		view := readBundle(c, certstate.SnapCertificatesDir(snapName))
+		c.Check(bytes.Contains(view, certA), Equals, true)
+		c.Check(bytes.Contains(view, public), Equals, true)
+		c.Check(bytes.Contains(view, internal), Equals, true)
+
+		// each snap gets generations of its own
+		target, err := os.Readlink(certstate.SnapCertificatesDir(snapName))
+		c.Assert(err, IsNil)
+		c.Check(filepath.Dir(target), Equals, filepath.Join("..", "snap-published", snapName))
+	}
+
+	// other snaps get the system view
+	c.Check(certstate.CertificateDirForSnap("other"), Equals, certstate.CurrentCertificateDir())
+
+	info, err := certstate.CustomCertificateInfo("internal-ca")
+	c.Assert(err, IsNil)
+	c.Check(info.State, Equals, certstate.CertificateStateAccepted)
+	c.Check(info.Snaps, DeepEquals, []string{"bar_instance", "foo"})
+}
+
+func (s *certsTestSuite) TestRefreshCertificateDatabaseSnapGenerationHashLinks(c *C) {
+	certA, _, err := makeTestCertPEM("A")
+	c.Assert(err, IsNil)
+	internal, _, err := makeTestCertPEM("internal")
+	c.Assert(err, IsNil)
+
+	// the system certificates are only available as a bundle
+	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "ca-certificates.crt"), certA, 0o644), IsNil)
+	setRestrictedCustomCertificate(c, "internal-ca", internal, "foo")
+	c.Assert(certstate.RefreshCertificateDatabase(nil), IsNil)
+
+	// every certificate of the snap view can be looked up by subject hash
+	links, err := filepath.Glob(filepath.Join(certstate.SnapCertificatesDir("foo"), "*.0"))
+	c.Assert(err, IsNil)
+	c.Assert(links, HasLen, 2)
+	var linked [][]byte
+	for _, link := range links {
+		data, err := os.ReadFile(link)
+		c.Assert(err, IsNil)
+		linked = append(linked, data)
+	}
+	c.Check(linked, testutil.DeepUnsortedMatches, [][]byte{certA, internal})
+
+	// the system view is unchanged
+	links, err = filepath.Glob(filepath.Join(certstate.CurrentCertificateDir(), "*.0"))
+	c.Assert(err, IsNil)
+	c.Check(links, HasLen, 0)
+}
+
+func (s *certsTestSuite) TestRefreshCertificateDatabaseRemovesStaleSnapGenerations(c *C) {
+	certA, _, err := makeTestCertPEM("A")
+	c.Assert(err, IsNil)
+	internal, _, err := makeTestCertPEM("internal")
+	c.Assert(err, IsNil)
+
+	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "a.crt"), certA, 0o644), IsNil)
+	digest := setRestrictedCustomCertificate(c, "internal-ca", internal, "foo")
+	c.Assert(certstate.RefreshCertificateDatabase(nil), IsNil)
+	c.Check(osutil.IsSymlink(certstate.SnapCertificatesDir("foo")), Equals, true)
+
+	c.Assert(certstate.RemoveCertificateSymlinks(digest), IsNil)
+	c.Check(osutil.IsDirectory(filepath.Join(dirs.SnapdPKIV1Dir, "snap-added", "foo")), Equals, false)
+
+	c.Assert(certstate.RefreshCertificateDatabase(nil), IsNil)
+	c.Check(osutil.IsSymlink(certstate.SnapCertificatesDir("foo")), Equals, false)
+	c.Check(certstate.CertificateDirForSnap("foo"), Equals, certstate.CurrentCertificateDir())
+}
+
+func (s *certsTestSuite) TestRefreshCertificateDatabaseSnapGenerationFollowsSystemRefresh(c *C) {
+	certA, _, err := makeTestCertPEM("A")
+	c.Assert(err, IsNil)
+	certB, _, err := makeTestCertPEM("B")
+	c.Assert(err, IsNil)
+	internal, _, err := makeTestCertPEM("internal")
+	c.Assert(err, IsNil)
+
+	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "a.crt"), certA, 0o644), IsNil)
+	setRestrictedCustomCertificate(c, "internal-ca", internal, "foo")
+	c.Assert(certstate.RefreshCertificateDatabase(nil), IsNil)
+
+	// a base refresh brings in a new system certificate
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "b.crt"), certB, 0o644), IsNil)
+	c.Assert(certstate.RefreshCertificateDatabase(nil), IsNil)
+
+	view := readBundle(c, certstate.SnapCertificatesDir("foo"))
+	c.Check(bytes.Contains(view, certB), Equals, true)
+	c.Check(bytes.Contains(view, internal), Equals, true)
+}
+
+func (s *certsTestSuite) TestRefreshCertificateDatabaseRefusesExpiredRestrictedCertificate(c *C) {
+	certA, _, err := makeTestCertPEM("A")
+	c.Assert(err, IsNil)
+	expired, _, err := makeTestCertPEMWithValidity("expired", time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour))
+	c.Assert(err, IsNil)
+
+	c.Assert(os.MkdirAll(dirs.SystemCertsDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SystemCertsDir, "a.crt"), certA, 0o644), IsNil)
+	setRestrictedCustomCertificate(c, "old-ca", expired, "foo")
+
+	err = certstate.RefreshCertificateDatabase(nil)
+	c.Assert(err, ErrorMatches, `cannot publish certificate database: custom certificate "old-ca" \(CN=expired\) expired on .*`)
+	c.Check(osutil.IsSymlink(certstate.SnapCertificatesDir("foo")), Equals, false)
+
+	c.Assert(certstate.RefreshCertificateDatabase(&certstate.RefreshOptions{AllowExpired: true}), IsNil)
+	c.Check(osutil.IsSymlink(certstate.SnapCertificatesDir("foo")), Equals, true)
+}
+
+func (s *certsTestSuite) TestSetCertificateSnapsInvalidSnapName(c *C) {
+	internal, _, err := makeTestCertPEM("internal")
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(dirs.SnapdPKIV1Dir, 0o755), IsNil)
+	c.Assert(certstate.WriteCertificate("internal-ca", string(internal)), IsNil)
+
+	err = certstate.SetCertificateSnaps("internal-ca", digestForPEM(c, internal), []string{"../foo"})
+	c.Assert(err, ErrorMatches, `invalid snap name: .*`)
+}
+
+func (s *certsTestSuite) TestGarbageCollectCertificateGenerationsProtectsSnapTargets(c *C) {
+	currentDir := filepath.Join(dirs.SnapdPKIV1Dir, "published", "current")
+	snapDir := filepath.Join(dirs.SnapdPKIV1Dir, "snap-published", "foo", "snap")
+	staleDir := filepath.Join(dirs.SnapdPKIV1Dir, "published", "stale")
+	staleSnapDir := filepath.Join(dirs.SnapdPKIV1Dir, "snap-published", "bar", "stale")
+	for _, dir := range []string{currentDir, snapDir, staleDir, staleSnapDir} {
+		c.Assert(os.MkdirAll(dir, 0o755), IsNil)
+	}
+	c.Assert(os.Symlink(filepath.Join("published", "current"), filepath.Join(dirs.SnapdPKIV1Dir, "merged")), IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(dirs.SnapdPKIV1Dir, "snap-merged"), 0o755), IsNil)
+	c.Assert(os.Symlink(filepath.Join("..", "snap-published", "foo", "snap"), certstate.SnapCertificatesDir("foo")), IsNil)
+
+	c.Assert(certstate.GarbageCollectCertificateGenerations("boot-1"), IsNil)
+	c.Assert(certstate.GarbageCollectCertificateGenerations("boot-2"), IsNil)
+
+	c.Check(osutil.IsDirectory(currentDir), Equals, true)
+	c.Check(osutil.IsDirectory(snapDir), Equals, true)
+	c.Check(osutil.FileExists(filepath.Join(snapDir, ".snapd-inactive")), Equals, false)
+	c.Check(osutil.IsDirectory(staleDir), Equals, false)
+	c.Check(osutil.IsDirectory(staleSnapDir), Equals, false)
+	// the directory of a snap without generations is removed as well
+	c.Check(osutil.IsDirectory(filepath.Dir(staleSnapDir)), Equals, false)
+}
+
+func (s *certMgrTestSuite) TestUndoUpdateCertificateDatabaseRestoresSnapGenerations(c *C) {
+	baseCertsDir := dirs.SystemCertsDir
+	c.Assert(os.MkdirAll(baseCertsDir, 0o755), IsNil)
+	certA, _, err := makeTestCertPEM("A")
+	c.Assert(err, IsNil)
+	internal, _, err := makeTestCertPEM("internal")
+	c.Assert(err, IsNil)
+	c.Assert(os.WriteFile(filepath.Join(baseCertsDir, "a.crt"), certA, 0o644), IsNil)
+	c.Assert(certstate.RefreshCertificateDatabase(nil), IsNil)
+
+	s.state.Lock()
+	task := s.state.NewTask("update-cert-db", "restrict certificate")
+	s.state.Unlock()
+
+	setRestrictedCustomCertificate(c, "internal-ca", internal, "foo")
+	c.Assert(s.mgr.DoUpdateCertificateDatabase(task, nil), IsNil)
+	c.Check(osutil.IsSymlink(certstate.SnapCertificatesDir("foo")), Equals, true)
+
+	c.Assert(s.mgr.UndoUpdateCertificateDatabase(task, nil), IsNil)
+	c.Check(osutil.IsSymlink(certstate.SnapCertificatesDir("foo")), Equals, false)
+}
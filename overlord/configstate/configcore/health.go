@@ -0,0 +1,84 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/snap"
+)
+
+func init() {
+	// add supported configuration of this module
+	supportedConfigurations["core.health.check-interval"] = true
+	supportedConfigurations["core.health.rollback.grace-period"] = true
+	supportedConfigurations["core.health.rollback.waiting-timeout"] = true
+}
+
+// validateHealthCheckInterval validates the default interval at which
+// the check-health hook of snaps not declaring their own interval is run.
+func validateHealthCheckInterval(tr RunTransaction) error {
+	intervalStr, err := coreCfg(tr, "health.check-interval")
+	if err != nil {
+		return err
+	}
+	if intervalStr == "" {
+		return nil
+	}
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil {
+		return fmt.Errorf("health.check-interval cannot be parsed: %v", err)
+	}
+	if interval < snap.MinHealthCheckInterval {
+		return fmt.Errorf("health.check-interval cannot be shorter than %s", snap.MinHealthCheckInterval)
+	}
+	return nil
+}
+
+// validateHealthRollback validates the policy for reverting refreshes
+// after which the health of a snap turns bad.
+func validateHealthRollback(tr RunTransaction) error {
+	var durations []time.Duration
+	for _, opt := range []string{"health.rollback.grace-period", "health.rollback.waiting-timeout"} {
+		durationStr, err := coreCfg(tr, opt)
+		if err != nil {
+			return err
+		}
+		if durationStr == "" {
+			durations = append(durations, 0)
+			continue
+		}
+		duration, err := time.ParseDuration(durationStr)
+		if err != nil {
+			return fmt.Errorf("%s cannot be parsed: %v", opt, err)
+		}
+		if duration < 0 {
+			return fmt.Errorf("%s cannot be negative", opt)
+		}
+		durations = append(durations, duration)
+	}
+	// a snap still waiting past the grace period would never be reverted,
+	// a zero grace period disables rollbacks altogether
+	if gracePeriod, waitingTimeout := durations[0], durations[1]; gracePeriod != 0 && waitingTimeout > gracePeriod {
+		return fmt.Errorf("health.rollback.waiting-timeout cannot be longer than health.rollback.grace-period")
+	}
+	return nil
+}
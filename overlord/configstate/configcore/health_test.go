@@ -0,0 +1,115 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type healthSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&healthSuite{})
+
+func (s *healthSuite) TestConfigureHealthCheckIntervalHappy(c *C) {
+	for _, interval := range []string{"", "1m", "6h"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"health.check-interval": interval,
+			},
+		})
+		c.Check(err, IsNil, Commentf(interval))
+	}
+}
+
+func (s *healthSuite) TestConfigureHealthCheckIntervalInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"health.check-interval": "often",
+		},
+	})
+	c.Check(err, ErrorMatches, `health.check-interval cannot be parsed:.*`)
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"health.check-interval": "10s",
+		},
+	})
+	c.Check(err, ErrorMatches, `health.check-interval cannot be shorter than 1m0s`)
+}
+
+func (s *healthSuite) TestConfigureHealthRollbackHappy(c *C) {
+	for _, duration := range []string{"", "0", "10m", "1h"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"health.rollback.grace-period":    duration,
+				"health.rollback.waiting-timeout": duration,
+			},
+		})
+		c.Check(err, IsNil, Commentf(duration))
+	}
+}
+
+func (s *healthSuite) TestConfigureHealthRollbackInvalid(c *C) {
+	for _, opt := range []string{"health.rollback.grace-period", "health.rollback.waiting-timeout"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				opt: "soon",
+			},
+		})
+		c.Check(err, ErrorMatches, opt+` cannot be parsed:.*`)
+
+		err = configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				opt: "-5m",
+			},
+		})
+		c.Check(err, ErrorMatches, opt+` cannot be negative`)
+	}
+}
+
+func (s *healthSuite) TestConfigureHealthRollbackWaitingTimeoutLongerThanGracePeriod(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"health.rollback.grace-period":    "10m",
+			"health.rollback.waiting-timeout": "15m",
+		},
+	})
+	c.Check(err, ErrorMatches, `health.rollback.waiting-timeout cannot be longer than health.rollback.grace-period`)
+
+	// rollbacks are disabled without a grace period
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"health.rollback.waiting-timeout": "15m",
+		},
+	})
+	c.Check(err, IsNil)
+}
@@ -23,18 +23,27 @@ package configcore
 import (
 	"fmt"
 	"strings"
+
+	"github.com/snapcore/snapd/interfaces/prompting"
 )
 
 func isValidInterface(name string) bool {
 	// In the future we can check builtin.Interfaces() for the supported
 	// interfaces if we want to support more than just "x11"
-	return name == "x11"
+	return name == "x11" || isPromptingInterface(name)
+}
+
+func isPromptingInterface(name string) bool {
+	_, err := prompting.AvailablePermissions(name)
+	return err == nil
 }
 
-func isValidInterfaceOption(opt string) bool {
+func isValidInterfaceOption(iface, opt string) bool {
 	switch opt {
 	case "allow-auto-connection":
-		return true
+		return iface == "x11"
+	case "prompt-timeout", "prompt-default":
+		return isPromptingInterface(iface)
 	}
 	return false
 }
@@ -62,7 +71,7 @@ func validateInterfaceChange(opt string) error {
 	if !isValidInterface(tokens[2]) {
 		return fmt.Errorf("unsupported interface %q for configuration change", tokens[2])
 	}
-	if !isValidInterfaceOption(tokens[3]) {
+	if !isValidInterfaceOption(tokens[2], tokens[3]) {
 		return fmt.Errorf("unsupported interface option: %q", tokens[3])
 	}
 	return nil
@@ -92,3 +101,31 @@ func validateAllowAutoConnectionValue(tr RunTransaction) error {
 	}
 	return nil
 }
+
+func validatePromptTimeoutValues(tr RunTransaction) error {
+	for _, name := range tr.Changes() {
+		if !isInterfaceChange(name) {
+			continue
+		}
+		isTimeout := strings.HasSuffix(name, ".prompt-timeout")
+		if !isTimeout && !strings.HasSuffix(name, ".prompt-default") {
+			continue
+		}
+
+		nameWithoutSnap := strings.SplitN(name, ".", 2)[1]
+		value, err := coreCfg(tr, nameWithoutSnap)
+		if err != nil {
+			return fmt.Errorf("internal error: cannot get data for %s: %v", name, err)
+		}
+
+		if isTimeout {
+			_, err = prompting.ParseTimeout(value)
+		} else {
+			_, err = prompting.ParseTimeoutDefault(value)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot set %s: %v", name, err)
+		}
+	}
+	return nil
+}
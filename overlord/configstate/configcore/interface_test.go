@@ -101,3 +101,60 @@ func (s *interfaceSuite) TestConfigureInterfaceHappy(c *C) {
 	})
 	c.Assert(err, IsNil)
 }
+
+func (s *interfaceSuite) TestConfigurePromptTimeoutHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"interface.home.prompt-timeout":   "30s",
+			"interface.home.prompt-default":   "allow-with-rule",
+			"interface.camera.prompt-timeout": "0",
+			"interface.camera.prompt-default": "allow-once",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *interfaceSuite) TestConfigurePromptTimeoutUnsupportedInterface(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"interface.x11.prompt-timeout": "30s",
+		},
+	})
+	c.Assert(err, ErrorMatches, `unsupported interface option: "prompt-timeout"`)
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"interface.home.allow-auto-connection": "true",
+		},
+	})
+	c.Assert(err, ErrorMatches, `unsupported interface option: "allow-auto-connection"`)
+}
+
+func (s *interfaceSuite) TestConfigurePromptTimeoutUnhappyValue(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"interface.home.prompt-timeout": "soon",
+		},
+	})
+	c.Assert(err, ErrorMatches, `cannot set core.interface.home.prompt-timeout: invalid prompt timeout "soon": .*`)
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"interface.home.prompt-timeout": "-1m",
+		},
+	})
+	c.Assert(err, ErrorMatches, `cannot set core.interface.home.prompt-timeout: invalid prompt timeout "-1m": cannot be negative`)
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"interface.home.prompt-default": "allow",
+		},
+	})
+	c.Assert(err, ErrorMatches, `cannot set core.interface.home.prompt-default: invalid prompt timeout default "allow": must be one of deny, allow-once, allow-with-rule`)
+}
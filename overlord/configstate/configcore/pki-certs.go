@@ -24,10 +24,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/snapcore/snapd/overlord/certstate"
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
 )
 
 const (
@@ -36,9 +40,21 @@ const (
 
 func init() {
 	supportedConfigurations["core."+customCertPrefix] = true
+	supportedConfigurations["core.pki.certs.expiry-warning-window"] = true
 	config.RegisterExternalConfig("core", customCertPrefix, handleGetCustomCertificates)
 }
 
+var validCertNameStrict = regexp.MustCompile("^" + validCertRegexp + "$").MatchString
+
+// ValidateCustomCertificateName checks that name can be used for a custom
+// certificate managed under pki.certs.custom.
+func ValidateCustomCertificateName(name string) error {
+	if !validCertNameStrict(name) {
+		return fmt.Errorf("invalid certificate name: %q", name)
+	}
+	return nil
+}
+
 // parseCustomCertKey parses a config key in the format of
 // "pki.certs.custom.<name>[.<field>]"
 func parseCustomCertKey(key string) (name, field string, err error) {
@@ -66,9 +82,12 @@ func parseCustomCertKey(key string) (name, field string, err error) {
 }
 
 type certificate struct {
-	Name       string `json:"name"`
-	Content    string `json:"content"`
-	State      string `json:"state"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	State   string `json:"state"`
+	// Snaps restricts an accepted certificate to the trust stores of the
+	// given snaps instead of the system one.
+	Snaps      []string `json:"snaps,omitempty"`
 	HasContent bool
 }
 
@@ -86,6 +105,7 @@ func maybeExistingCertificate(name string, certs map[string]certificate) (certif
 		info, err := certstate.CustomCertificateInfo(name)
 		if err == nil {
 			cert.State = info.State
+			cert.Snaps = info.Snaps
 			cert.Content = info.Content
 			cert.HasContent = true
 		} else if !errors.Is(err, os.ErrNotExist) {
@@ -156,6 +176,8 @@ func gatherCertificates(tr RunTransaction) (map[string]certificate, error) {
 			}
 		case "state":
 			cert.State = v
+		case "snaps":
+			cert.Snaps = strutil.CommaSeparatedList(v)
 		case "name":
 			// remove the old name from the map so that it doesn't get processed
 			// later as a separate certificate, and update the certificate with the new name.
@@ -166,6 +188,7 @@ func gatherCertificates(tr RunTransaction) (map[string]certificate, error) {
 				Name:       v,
 				Content:    cert.Content,
 				State:      cert.State,
+				Snaps:      cert.Snaps,
 				HasContent: cert.HasContent,
 			}
 
@@ -195,6 +218,15 @@ func ensureCertificateState(fp string, cert certificate) error {
 		return fmt.Errorf("cannot remove existing symlinks for custom certificate %q: %v", cert.Name, err)
 	}
 
+	// Accepted certificates restricted to some snaps only end up in the
+	// trust stores of those snaps.
+	if cert.State == certstate.CertificateStateAccepted && len(cert.Snaps) != 0 {
+		if err := certstate.SetCertificateSnaps(cert.Name, fp, cert.Snaps); err != nil {
+			return fmt.Errorf("cannot restrict custom certificate %q to snaps: %v", cert.Name, err)
+		}
+		return nil
+	}
+
 	if err := certstate.SetCertificateState(cert.Name, fp, cert.State); err != nil {
 		return fmt.Errorf("cannot set state for custom certificate %q: %v", cert.Name, err)
 	}
@@ -309,7 +341,11 @@ func handleCustomCertificateRequest(tr RunTransaction, opts *fsOnlyContext) erro
 	st := tr.State()
 	st.Lock()
 	defer st.Unlock()
-	return certstate.RefreshCertificateDatabase()
+	refreshOpts, err := certstate.RefreshOptionsForTask(tr.Task())
+	if err != nil {
+		return err
+	}
+	return certstate.RefreshCertificateDatabase(refreshOpts)
 }
 
 func validateCustomCertificateRequest(tr RunTransaction) error {
@@ -367,6 +403,12 @@ func validateCustomCertificateRequest(tr RunTransaction) error {
 			default:
 				return fmt.Errorf("invalid state value for %q: %q", key, v)
 			}
+		case "snaps":
+			for _, snapName := range strutil.CommaSeparatedList(v) {
+				if err := snap.ValidateInstanceName(snapName); err != nil {
+					return fmt.Errorf("invalid snap name for %q: %v", key, err)
+				}
+			}
 		case "name":
 			if !validCertName(v) {
 				return fmt.Errorf("invalid certificate name for %q: %q", key, v)
@@ -410,3 +452,20 @@ func handleGetCustomCertificates(key string) (any, error) {
 
 	return filtered, nil
 }
+
+func validateCertificateExpirySettings(tr RunTransaction) error {
+	windowStr, err := coreCfg(tr, "pki.certs.expiry-warning-window")
+	if err != nil {
+		return err
+	}
+	if windowStr != "" {
+		window, err := time.ParseDuration(windowStr)
+		if err != nil {
+			return fmt.Errorf("pki.certs.expiry-warning-window cannot be parsed: %v", err)
+		}
+		if window < 0 {
+			return fmt.Errorf("pki.certs.expiry-warning-window cannot be negative")
+		}
+	}
+	return nil
+}
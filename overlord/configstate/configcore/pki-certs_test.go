@@ -37,6 +37,7 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord/certstate"
 	"github.com/snapcore/snapd/overlord/configstate/configcore"
+	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -60,6 +61,10 @@ func (cfg *orderedMockConf) Changes() []string {
 }
 
 func makePKITestCertPEM(c *C, commonName string) []byte {
+	return makePKITestCertPEMWithValidity(c, commonName, time.Now().Add(-time.Hour), time.Now().Add(24*time.Hour))
+}
+
+func makePKITestCertPEMWithValidity(c *C, commonName string, notBefore, notAfter time.Time) []byte {
 	priv, err := rsa.GenerateKey(rand.Reader, 2048)
 	c.Assert(err, IsNil)
 
@@ -69,8 +74,8 @@ func makePKITestCertPEM(c *C, commonName string) []byte {
 	tmpl := &x509.Certificate{
 		SerialNumber: serial,
 		Subject:      pkix.Name{CommonName: commonName},
-		NotBefore:    time.Now().Add(-time.Hour),
-		NotAfter:     time.Now().Add(24 * time.Hour),
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
 		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
 
 		BasicConstraintsValid: true,
@@ -737,3 +742,146 @@ func (s *pkiCertsSuite) TestParseCustomCertKeyCases(c *C) {
 		c.Check(field, Equals, tc.expField)
 	}
 }
+
+func (s *pkiCertsSuite) TestValidateCustomCertificateName(c *C) {
+	for _, name := range []string{"a", "my-ca", "ca_1", "CA-2024"} {
+		c.Check(configcore.ValidateCustomCertificateName(name), IsNil, Commentf("%q", name))
+	}
+	for _, name := range []string{"", "-a", "a-", "a.b", "a--b", "a/b"} {
+		c.Check(configcore.ValidateCustomCertificateName(name), ErrorMatches, `invalid certificate name: .*`, Commentf("%q", name))
+	}
+}
+
+func (s *pkiCertsSuite) TestValidateCertificateExpirySettings(c *C) {
+	for _, tc := range []struct {
+		key, value, err string
+	}{
+		{"pki.certs.expiry-warning-window", "720h", ""},
+		{"pki.certs.expiry-warning-window", "0s", ""},
+		{"pki.certs.expiry-warning-window", "soon", `pki.certs.expiry-warning-window cannot be parsed: .*`},
+		{"pki.certs.expiry-warning-window", "-1h", `pki.certs.expiry-warning-window cannot be negative`},
+	} {
+		err := configcore.Run(coreDev, &mockConf{
+			state: s.state,
+			changes: map[string]any{
+				tc.key: tc.value,
+			},
+		})
+		if tc.err == "" {
+			c.Check(err, IsNil, Commentf("%s=%s", tc.key, tc.value))
+		} else {
+			c.Check(err, ErrorMatches, tc.err, Commentf("%s=%s", tc.key, tc.value))
+		}
+	}
+}
+
+func (s *pkiCertsSuite) TestHandleCustomCertificateExpiredRefused(c *C) {
+	certPEM := makePKITestCertPEMWithValidity(c, "expired", time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour))
+
+	err := configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"pki.certs.custom.old-ca.content": string(certPEM),
+		},
+	})
+	c.Assert(err, ErrorMatches, `cannot publish certificate database: custom certificate "old-ca" \(CN=expired\) expired on .*`)
+	assertCertificateDatabaseContains(c, certPEM, false)
+}
+
+func (s *pkiCertsSuite) TestHandleCustomCertificateExpiredAllowed(c *C) {
+	certPEM := makePKITestCertPEMWithValidity(c, "expired", time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour))
+
+	s.state.Lock()
+	task := s.state.NewTask("configure", "...")
+	certstate.AllowExpiredCertificates(state.NewTaskSet(task))
+	s.state.Unlock()
+
+	err := configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		task:  task,
+		changes: map[string]any{
+			"pki.certs.custom.old-ca.content": string(certPEM),
+		},
+	})
+	c.Assert(err, IsNil)
+	assertCertificateDatabaseContains(c, certPEM, true)
+}
+
+func (s *pkiCertsSuite) TestAllowExpiredIsNotAnOption(c *C) {
+	err := configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"pki.certs.allow-expired": true,
+		},
+	})
+	c.Assert(err, ErrorMatches, `cannot set "core.pki.certs.allow-expired": unsupported system option`)
+}
+
+func (s *pkiCertsSuite) TestValidateCustomCertificateRequestInvalidSnaps(c *C) {
+	err := configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"pki.certs.custom.cert1.snaps": "foo,../bar",
+		},
+	})
+	c.Assert(err, ErrorMatches, `invalid snap name for "pki.certs.custom.cert1.snaps": invalid snap name: "../bar"`)
+}
+
+func (s *pkiCertsSuite) TestHandleCustomCertificateRestrictedToSnaps(c *C) {
+	certPEM := makePKITestCertPEM(c, "internal")
+	fingerprint := certDigest(c, certPEM)
+	pkiDir := dirs.SnapdPKIV1Dir
+
+	err := configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"pki.certs.custom.internal-ca.content": string(certPEM),
+			"pki.certs.custom.internal-ca.snaps":   "foo,bar",
+		},
+	})
+	c.Assert(err, IsNil)
+
+	assertSymlinkAbsent(c, filepath.Join(pkiDir, "added", fingerprint+".crt"))
+	for _, snapName := range []string{"foo", "bar"} {
+		assertSymlinkTarget(c, filepath.Join(pkiDir, "snap-added", snapName, fingerprint+".crt"), "../../internal-ca.crt")
+		bundle, err := os.ReadFile(filepath.Join(certstate.SnapCertificatesDir(snapName), "ca-certificates.crt"))
+		c.Assert(err, IsNil)
+		c.Check(bytes.Contains(bundle, certPEM), Equals, true)
+	}
+	assertCertificateDatabaseContains(c, certPEM, false)
+
+	// lifting the restriction moves the certificate to the system view
+	err = configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"pki.certs.custom.internal-ca.fingerprint": fingerprint,
+		},
+		changes: map[string]any{
+			"pki.certs.custom.internal-ca.snaps": "",
+		},
+	})
+	c.Assert(err, IsNil)
+
+	assertSymlinkTarget(c, filepath.Join(pkiDir, "added", fingerprint+".crt"), "../internal-ca.crt")
+	for _, snapName := range []string{"foo", "bar"} {
+		assertSymlinkAbsent(c, filepath.Join(pkiDir, "snap-added", snapName, fingerprint+".crt"))
+		assertSymlinkAbsent(c, certstate.SnapCertificatesDir(snapName))
+	}
+	assertCertificateDatabaseContains(c, certPEM, true)
+}
+
+func (s *pkiCertsSuite) TestHandleGetCustomCertificatesIncludesSnaps(c *C) {
+	certPEM := makePKITestCertPEM(c, "internal")
+	fingerprint := certDigest(c, certPEM)
+	c.Assert(certstate.WriteCertificate("internal-ca", string(certPEM)), IsNil)
+	c.Assert(certstate.SetCertificateSnaps("internal-ca", fingerprint, []string{"foo"}), IsNil)
+
+	res, err := configcore.HandleGetCustomCertificates("pki.certs.custom.internal-ca")
+	c.Assert(err, IsNil)
+
+	certs, ok := res.(map[string]*certstate.CertificateInfo)
+	c.Assert(ok, Equals, true)
+	c.Assert(certs["internal-ca"], NotNil)
+	c.Check(certs["internal-ca"].State, Equals, certstate.CertificateStateAccepted)
+	c.Check(certs["internal-ca"].Snaps, DeepEquals, []string{"foo"})
+}
@@ -22,10 +22,12 @@ package configcore
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/features"
+	"github.com/snapcore/snapd/interfaces/prompting/requesthistory"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/restart"
@@ -36,6 +38,12 @@ import (
 	"github.com/snapcore/snapd/snap"
 )
 
+const coreOptionPromptingHistorySize = "core.prompting.history-size"
+
+func init() {
+	supportedConfigurations[coreOptionPromptingHistorySize] = true
+}
+
 var restartRequest = restart.Request
 
 var servicestateControl = servicestate.Control
@@ -155,3 +163,20 @@ func doExperimentalApparmorPromptingDaemonRestart(c RunTransaction, opts *fsOnly
 
 	return nil
 }
+
+// validatePromptingHistorySize checks that the maximum number of prompt
+// outcomes kept in the history for each user is within the supported range.
+func validatePromptingHistorySize(tr RunTransaction) error {
+	sizeStr, err := coreCfg(tr, "prompting.history-size")
+	if err != nil {
+		return err
+	}
+	if sizeStr == "" {
+		return nil
+	}
+	size, err := strconv.Atoi(sizeStr)
+	if err != nil || size < 0 || size > requesthistory.MaxEntriesLimit {
+		return fmt.Errorf("prompting.history-size must be an integer between 0 and %d", requesthistory.MaxEntriesLimit)
+	}
+	return nil
+}
@@ -645,3 +645,25 @@ plugs:
 
 	s.state.Set("conns", conns)
 }
+
+func (s *promptingSuite) TestValidatePromptingHistorySize(c *C) {
+	for _, value := range []any{"", 0, 500, "10000"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			changes: map[string]any{
+				"prompting.history-size": value,
+			},
+		})
+		c.Check(err, IsNil, Commentf("value: %v", value))
+	}
+
+	for _, value := range []any{-1, 10001, "lots", 1.5} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			changes: map[string]any{
+				"prompting.history-size": value,
+			},
+		})
+		c.Check(err, ErrorMatches, `prompting.history-size must be an integer between 0 and 10000`, Commentf("value: %v", value))
+	}
+}
@@ -60,6 +60,7 @@ func init() {
 	addWithStateHandler(validateRefreshSchedule, nil, validateOnly)
 	addWithStateHandler(validateRefreshRateLimit, nil, validateOnly)
 	addWithStateHandler(validateAutomaticSnapshotsExpiration, nil, validateOnly)
+	addWithStateHandler(validateSnapIntegrity, nil, validateOnly)
 
 	// netplan.*
 	addWithStateHandler(validateNetplanSettings, handleNetplanConfiguration, coreOnly)
@@ -76,11 +77,29 @@ func init() {
 	// experimental.apparmor-prompting
 	addWithStateHandler(nil, doExperimentalApparmorPromptingDaemonRestart, nil)
 
+	// prompting.history-size
+	addWithStateHandler(validatePromptingHistorySize, nil, validateOnly)
+
 	// interface.*.allow-auto-connection
 	addWithStateHandler(validateAllowAutoConnectionValue, nil, &flags{validatedOnlyStateConfig: true})
 
+	// interface.*.{prompt-timeout,prompt-default}
+	addWithStateHandler(validatePromptTimeoutValues, nil, &flags{validatedOnlyStateConfig: true})
+
 	// pki.certs.custom.*
 	addWithStateHandler(validateCustomCertificateRequest, handleCustomCertificateRequest, &flags{coreOnlyConfig: true})
+
+	// pki.certs.expiry-warning-window
+	addWithStateHandler(validateCertificateExpirySettings, nil, validateOnly)
+
+	// store.cache.{peer,listen}
+	addWithStateHandler(validateStoreCacheSettings, nil, validateOnly)
+
+	// health.check-interval
+	addWithStateHandler(validateHealthCheckInterval, nil, validateOnly)
+
+	// health.rollback.{grace-period,waiting-timeout}
+	addWithStateHandler(validateHealthRollback, nil, validateOnly)
 }
 
 // RunTransaction is an interface describing how to access
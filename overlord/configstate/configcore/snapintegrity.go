@@ -0,0 +1,57 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package configcore
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+)
+
+func init() {
+	// add supported configuration of this module
+	supportedConfigurations["core.system.snap-integrity"] = true
+}
+
+func validateSnapIntegrity(tr RunTransaction) error {
+	policy, err := coreCfg(tr, "system.snap-integrity")
+	if err != nil {
+		return err
+	}
+	switch policy {
+	case "", snapstate.SnapIntegrityVerify:
+		return nil
+	case snapstate.SnapIntegrityEnforce:
+		// only check the system when the policy is being changed
+		var pristine string
+		if err := tr.GetPristine("core", "system.snap-integrity", &pristine); err != nil && !config.IsNoOption(err) {
+			return err
+		}
+		if pristine == policy {
+			return nil
+		}
+		st := tr.State()
+		st.Lock()
+		defer st.Unlock()
+		return snapstate.CheckSnapIntegrityEnforceable(st)
+	}
+	return fmt.Errorf("system.snap-integrity can only be set to %q or %q", snapstate.SnapIntegrityVerify, snapstate.SnapIntegrityEnforce)
+}
@@ -0,0 +1,122 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/osutil/squashfs"
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/snap"
+)
+
+type snapIntegritySuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&snapIntegritySuite{})
+
+func (s *snapIntegritySuite) SetUpTest(c *C) {
+	s.configcoreSuite.SetUpTest(c)
+	s.AddCleanup(squashfs.MockNeedsFuse(false))
+}
+
+func (s *snapIntegritySuite) TestConfigureSnapIntegrityHappy(c *C) {
+	for _, policy := range []string{"", "verify", "enforce"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"system.snap-integrity": policy,
+			},
+		})
+		c.Check(err, IsNil, Commentf(policy))
+	}
+}
+
+func (s *snapIntegritySuite) TestConfigureSnapIntegrityInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"system.snap-integrity": "always",
+		},
+	})
+	c.Assert(err, ErrorMatches, `system.snap-integrity can only be set to "verify" or "enforce"`)
+}
+
+func (s *snapIntegritySuite) TestConfigureSnapIntegrityEnforceNoSnaps(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"system.snap-integrity": "enforce",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *snapIntegritySuite) TestConfigureSnapIntegrityEnforceSnapWithoutIntegrityData(c *C) {
+	si := &snap.SideInfo{RealName: "test-snap", Revision: snap.R(1)}
+	s.state.Lock()
+	snapstate.Set(s.state, "test-snap", &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  snap.R(1),
+		Active:   true,
+		SnapType: "app",
+	})
+	s.state.Unlock()
+
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"system.snap-integrity": "enforce",
+		},
+	})
+	c.Assert(err, ErrorMatches, `cannot enforce system.snap-integrity: snap "test-snap" revision 1 is mounted without verified integrity data`)
+
+	// already enforced
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"system.snap-integrity": "enforce",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *snapIntegritySuite) TestConfigureSnapIntegrityEnforceSquashfuse(c *C) {
+	s.AddCleanup(squashfs.MockNeedsFuse(true))
+
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"system.snap-integrity": "enforce",
+		},
+	})
+	c.Assert(err, ErrorMatches, `cannot enforce system.snap-integrity: squashfs images are mounted with squashfuse which does not support dm-verity`)
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"system.snap-integrity": "verify",
+		},
+	})
+	c.Assert(err, IsNil)
+}
@@ -22,6 +22,9 @@ package configcore
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 
@@ -32,6 +35,8 @@ import (
 
 func init() {
 	supportedConfigurations["core.store.access"] = true
+	supportedConfigurations["core.store.cache.peer"] = true
+	supportedConfigurations["core.store.cache.listen"] = true
 }
 
 func validateStoreAccess(cfg ConfGetter) error {
@@ -80,3 +85,30 @@ func handleStoreAccess(_ sysconfig.Device, cfg ConfGetter, opts *fsOnlyContext)
 
 	return osutil.AtomicWriteFile(configFilePath, data, 0644, 0)
 }
+
+// validateStoreCacheSettings validates the address of the downloads cache peer
+// queried for snaps before the store, and the address on which the local
+// downloads cache is served to peers.
+func validateStoreCacheSettings(tr RunTransaction) error {
+	peer, err := coreCfg(tr, "store.cache.peer")
+	if err != nil {
+		return err
+	}
+	if peer != "" {
+		u, err := url.Parse(peer)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("cannot set store.cache.peer to %q: must be an http or https URL", peer)
+		}
+	}
+
+	listen, err := coreCfg(tr, "store.cache.listen")
+	if err != nil {
+		return err
+	}
+	if listen != "" {
+		if _, port, err := net.SplitHostPort(listen); err != nil || port == "" {
+			return fmt.Errorf("cannot set store.cache.listen to %q: must be of the form [host]:port", listen)
+		}
+	}
+	return nil
+}
@@ -95,3 +95,38 @@ func (s *storeSuite) TestFilesystemOnlyApply(c *C) {
 
 	c.Check(repairConfig.StoreOffline, Equals, true)
 }
+
+func (s *storeSuite) TestStoreCacheSettingsHappy(c *C) {
+	for _, changes := range []map[string]any{
+		{"store.cache.peer": "http://192.168.1.10:8099"},
+		{"store.cache.peer": "https://cache.example.com/snapd"},
+		{"store.cache.listen": ":8099"},
+		{"store.cache.listen": "192.168.1.10:8099"},
+		{"store.cache.peer": "", "store.cache.listen": ""},
+	} {
+		err := configcore.Run(coreDev, &mockConf{
+			state:   s.state,
+			changes: changes,
+		})
+		c.Check(err, IsNil, Commentf("changes: %v", changes))
+	}
+}
+
+func (s *storeSuite) TestStoreCacheSettingsUnhappy(c *C) {
+	for _, tc := range []struct {
+		changes map[string]any
+		err     string
+	}{
+		{map[string]any{"store.cache.peer": "192.168.1.10:8099"}, `cannot set store.cache.peer to "192.168.1.10:8099": must be an http or https URL`},
+		{map[string]any{"store.cache.peer": "ftp://cache"}, `cannot set store.cache.peer to "ftp://cache": must be an http or https URL`},
+		{map[string]any{"store.cache.peer": "http://"}, `cannot set store.cache.peer to "http://": must be an http or https URL`},
+		{map[string]any{"store.cache.listen": "8099"}, `cannot set store.cache.listen to "8099": must be of the form \[host\]:port`},
+		{map[string]any{"store.cache.listen": "localhost:"}, `cannot set store.cache.listen to "localhost:": must be of the form \[host\]:port`},
+	} {
+		err := configcore.Run(coreDev, &mockConf{
+			state:   s.state,
+			changes: tc.changes,
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf("changes: %v", tc.changes))
+	}
+}
@@ -454,6 +454,57 @@ func (s *deviceMgrGadgetSuite) TestUpdateGadgetOnUC20CoreSimpleSystemRestartImme
 	s.testUpdateGadgetSimple(c, "dangerous", encryption, immediate, uc20gadgetYaml, "", isClassic)
 }
 
+var abSlotsGadgetYaml = `
+volumes:
+  pi:
+    bootloader: u-boot
+    structure:
+      - name: bootloader-a
+        type: bare
+        size: 1M
+        slot:
+          group: bootloader
+          name: a
+      - name: bootloader-b
+        type: bare
+        size: 1M
+        slot:
+          group: bootloader
+          name: b
+`
+
+func (s *deviceMgrGadgetSuite) TestUpdateGadgetOnCoreRecordsTriedABSlots(c *C) {
+	bl := bootloadertest.Mock("mock", c.MkDir()).WithABSlots()
+	bootloader.Force(bl)
+	defer bootloader.Force(nil)
+
+	restore := devicestate.MockGadgetUpdate(func(model gadget.Model, current, update gadget.GadgetData, path string, policy gadget.UpdatePolicyFunc, _ gadget.ContentUpdateObserver) error {
+		// the inactive slot was written and is set to be tried
+		return bl.SetABSlotState("bootloader", &bootloader.ABSlotState{Active: "a", Try: "b", Status: boot.TryStatus})
+	})
+	defer restore()
+
+	chg, t := s.setupGadgetUpdate(c, "", abSlotsGadgetYaml, "", false)
+	restore = devicestate.SetBootOkRanForCurrentBootID(s.mgr, true)
+	defer restore()
+
+	s.state.Lock()
+	s.state.Set("seeded", true)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(t.Status(), Equals, state.WaitStatus)
+	// the tried slots are checked after the reboot when finishing the
+	// installation of the gadget
+	var tried map[string]string
+	c.Assert(chg.Get("gadget-ab-slots", &tried), IsNil)
+	c.Check(tried, DeepEquals, map[string]string{"bootloader": "b"})
+}
+
 func (s *deviceMgrGadgetSuite) TestUpdateGadgetOnCoreNoUpdateNeeded(c *C) {
 	var called bool
 	restore := devicestate.MockGadgetUpdate(func(model gadget.Model, current, update gadget.GadgetData, path string, policy gadget.UpdatePolicyFunc, _ gadget.ContentUpdateObserver) error {
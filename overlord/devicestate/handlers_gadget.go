@@ -89,6 +89,33 @@ func setGadgetRestartRequired(t *state.Task) {
 	chg.Set("gadget-restart-required", true)
 }
 
+// gadgetHasABSlots returns whether the gadget declares A/B slot structures.
+func gadgetHasABSlots(gd *gadget.GadgetData) bool {
+	for _, vol := range gd.Info.Volumes {
+		for _, vs := range vol.Structure {
+			if vs.Slot != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setGadgetABSlotsTried records in the change the A/B slots set to be tried
+// by the gadget update, so that a fallback of the firmware to the active
+// slots is caught after the reboot and fails the change, the same way a
+// kernel rollback does.
+func setGadgetABSlotsTried(t *state.Task, deviceCtx snapstate.DeviceContext) error {
+	tried, err := boot.TriedABSlots(deviceCtx)
+	if err != nil {
+		return fmt.Errorf("cannot find A/B slots to try: %v", err)
+	}
+	if len(tried) != 0 {
+		t.Change().Set("gadget-ab-slots", tried)
+	}
+	return nil
+}
+
 func (m *DeviceManager) doUpdateGadgetAssets(t *state.Task, _ *tomb.Tomb) error {
 	st := t.State()
 	st.Lock()
@@ -235,6 +262,12 @@ func (m *DeviceManager) doUpdateGadgetAssets(t *state.Task, _ *tomb.Tomb) error
 		logger.Noticef("failed to remove gadget update rollback directory %q: %v", snapRollbackDir, err)
 	}
 
+	if gadgetHasABSlots(updateData) {
+		if err := setGadgetABSlotsTried(t, groundDeviceCtx); err != nil {
+			return err
+		}
+	}
+
 	// TODO: consider having the option to do this early via recovery in
 	// core20, have fallback code as well there
 	setGadgetRestartRequired(t)
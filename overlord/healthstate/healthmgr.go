@@ -0,0 +1,335 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package healthstate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+	"github.com/snapcore/snapd/snap"
+)
+
+var timeNow = time.Now
+
+var snapstateRevert = snapstate.Revert
+
+var revertSnapChangeKind = swfeats.RegisterChangeKind("revert-snap")
+
+func init() {
+	swfeats.RegisterEnsure("HealthManager", "ensurePeriodicChecks")
+	swfeats.RegisterEnsure("HealthManager", "ensureRollbacks")
+}
+
+// staleFactor is the number of check intervals after which the last
+// reported health of a snap is considered stale.
+const staleFactor = 2
+
+// CheckInterval returns how often the check-health hook of the given snap
+// is run periodically, or zero if it is not. The health-check-interval
+// declared by the snap takes precedence over the health.check-interval
+// system option.
+// The state must be locked by the caller.
+func CheckInterval(st *state.State, info *snap.Info) (time.Duration, error) {
+	if info.Hooks["check-health"] == nil {
+		return 0, nil
+	}
+	if info.HealthCheckInterval > 0 {
+		return time.Duration(info.HealthCheckInterval), nil
+	}
+
+	var intervalStr string
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "health.check-interval", &intervalStr); err != nil && !config.IsNoOption(err) {
+		return 0, err
+	}
+	if intervalStr == "" {
+		return 0, nil
+	}
+	// the option is validated when set
+	return time.ParseDuration(intervalStr)
+}
+
+// IsStale returns whether the given health of a snap is older than what
+// periodic checks of the snap guarantee, meaning that the check-health hook
+// has not reported for a while.
+// The state must be locked by the caller.
+func IsStale(st *state.State, info *snap.Info, health *HealthState) (bool, error) {
+	if health == nil {
+		return false, nil
+	}
+	interval, err := CheckInterval(st, info)
+	if err != nil || interval == 0 {
+		return false, err
+	}
+	return timeNow().Sub(health.Timestamp) > staleFactor*interval, nil
+}
+
+// HealthManager periodically runs the check-health hook of the snaps that
+// ask for it, either through health-check-interval in their snap.yaml or
+// through the health.check-interval system option.
+type HealthManager struct {
+	state   *state.State
+	hookMgr *hookstate.HookManager
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// Manager returns a new HealthManager.
+func Manager(st *state.State, hookMgr *hookstate.HookManager) *HealthManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HealthManager{
+		state:   st,
+		hookMgr: hookMgr,
+		ctx:     ctx,
+		cancel:  cancel,
+		running: make(map[string]bool),
+	}
+}
+
+// Ensure implements StateManager.Ensure.
+func (m *HealthManager) Ensure() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	if err := m.ensurePeriodicChecks(); err != nil {
+		return err
+	}
+	return m.ensureRollbacks()
+}
+
+// ensurePeriodicChecks starts the checks that are due and schedules the
+// next ensure for when the following one will be.
+func (m *HealthManager) ensurePeriodicChecks() error {
+	snapStates, err := snapstate.All(m.state)
+	if err != nil {
+		return err
+	}
+	healths, err := All(m.state)
+	if err != nil {
+		return err
+	}
+	logger.Trace("ensure", "manager", "HealthManager", "func", "ensurePeriodicChecks")
+
+	now := timeNow()
+	var next time.Time
+	for instanceName, snapst := range snapStates {
+		if !snapst.Active {
+			continue
+		}
+		info, err := snapst.CurrentInfo()
+		if err != nil {
+			continue
+		}
+		interval, err := CheckInterval(m.state, info)
+		if err != nil {
+			return err
+		}
+		if interval == 0 {
+			continue
+		}
+
+		due := now
+		if health := healths[instanceName]; health != nil {
+			due = health.Timestamp.Add(interval)
+		}
+		if due.After(now) {
+			if next.IsZero() || due.Before(next) {
+				next = due
+			}
+			continue
+		}
+		// snaps that are busy in a change are checked again on a
+		// later ensure, refreshes run the hook anyway
+		if snapstate.CheckChangeConflict(m.state, instanceName, nil) == nil {
+			m.startCheck(instanceName, info.Revision)
+		}
+		if again := now.Add(interval); next.IsZero() || again.Before(next) {
+			next = again
+		}
+	}
+
+	if !next.IsZero() {
+		m.state.EnsureBefore(next.Sub(now))
+	}
+	return nil
+}
+
+func (m *HealthManager) startCheck(instanceName string, rev snap.Revision) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running[instanceName] || m.ctx.Err() != nil {
+		return
+	}
+	m.running[instanceName] = true
+
+	hooksup := &hookstate.HookSetup{
+		Snap:     instanceName,
+		Revision: rev,
+		Hook:     "check-health",
+		Optional: true,
+		Timeout:  checkTimeout,
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			m.mu.Lock()
+			delete(m.running, instanceName)
+			m.mu.Unlock()
+		}()
+		// the health handler records the outcome, including failures
+		if _, err := m.hookMgr.EphemeralRunHook(m.ctx, hooksup, nil); err != nil {
+			logger.Noticef("cannot run periodic health check of snap %q: %v", instanceName, err)
+		}
+	}()
+}
+
+// Stop implements StateStopper. It cancels the checks in progress and waits
+// for them to finish.
+func (m *HealthManager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// rollbackPolicy returns for how long after a refresh the health of the
+// given snap turning to error reverts the refresh, and for how long the
+// health can be waiting after a refresh before doing the same. A zero grace
+// period disables rollbacks, a zero waiting timeout lets snaps wait
+// indefinitely. The health-rollback durations declared by the snap take
+// precedence over the health.rollback system options.
+func rollbackPolicy(st *state.State, info *snap.Info) (gracePeriod, waitingTimeout time.Duration, err error) {
+	tr := config.NewTransaction(st)
+	durations := []time.Duration{
+		time.Duration(info.HealthRollback.GracePeriod),
+		time.Duration(info.HealthRollback.WaitingTimeout),
+	}
+	for i, opt := range []string{"health.rollback.grace-period", "health.rollback.waiting-timeout"} {
+		if durations[i] > 0 {
+			continue
+		}
+		var durationStr string
+		if err := tr.Get("core", opt, &durationStr); err != nil && !config.IsNoOption(err) {
+			return 0, 0, err
+		}
+		if durationStr == "" {
+			continue
+		}
+		// the options are validated when set
+		durations[i], err = time.ParseDuration(durationStr)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return durations[0], durations[1], nil
+}
+
+// ensureRollbacks reverts snaps whose health turned bad shortly after they
+// were refreshed, according to the rollback policy. The reverted revision
+// stays blocked from refreshes until the store offers a newer one.
+func (m *HealthManager) ensureRollbacks() error {
+	logger.Trace("ensure", "manager", "HealthManager", "func", "ensureRollbacks")
+
+	snapStates, err := snapstate.All(m.state)
+	if err != nil {
+		return err
+	}
+	healths, err := All(m.state)
+	if err != nil {
+		return err
+	}
+
+	now := timeNow()
+	for instanceName, snapst := range snapStates {
+		health := healths[instanceName]
+		if health == nil || !snapst.Active || snapst.LastRefreshTime == nil || health.Revision != snapst.Current {
+			continue
+		}
+		// only the revision a refresh moved to is reverted, and
+		// only if there is a previous revision to go back to
+		idx := snapst.LastIndex(snapst.Current)
+		if idx < 1 || idx != len(snapst.Sequence.Revisions)-1 {
+			continue
+		}
+		if health.Status != ErrorStatus && health.Status != WaitingStatus {
+			continue
+		}
+		refreshed := *snapst.LastRefreshTime
+		if health.Timestamp.Before(refreshed) {
+			continue
+		}
+
+		info, err := snapst.CurrentInfo()
+		if err != nil {
+			continue
+		}
+		gracePeriod, waitingTimeout, err := rollbackPolicy(m.state, info)
+		if err != nil {
+			return err
+		}
+		if gracePeriod == 0 || health.Timestamp.Sub(refreshed) > gracePeriod {
+			continue
+		}
+
+		switch health.Status {
+		case ErrorStatus:
+			// revert
+		case WaitingStatus:
+			if waitingTimeout == 0 || waitingTimeout > gracePeriod {
+				continue
+			}
+			if deadline := refreshed.Add(waitingTimeout); deadline.After(now) {
+				m.state.EnsureBefore(deadline.Sub(now))
+				continue
+			}
+		default:
+			continue
+		}
+
+		if snapstate.CheckChangeConflict(m.state, instanceName, nil) != nil {
+			// retry once the snap is not busy anymore
+			continue
+		}
+		ts, err := snapstateRevert(m.state, instanceName, snapstate.Flags{}, "")
+		if err != nil {
+			logger.Noticef("cannot revert snap %q after bad health: %v", instanceName, err)
+			continue
+		}
+		chg := m.state.NewChange(revertSnapChangeKind, fmt.Sprintf("Revert %q snap after bad health", instanceName))
+		chg.AddAll(ts)
+
+		// only warn once the revert is actually underway
+		prev := snapst.Sequence.Revisions[idx-1].Snap.Revision
+		m.state.Warnf("snap %q is being reverted to revision %s (change %s): health of refreshed revision %s was %q", instanceName, prev, chg.ID(), snapst.Current, health.Status)
+	}
+	return nil
+}
@@ -174,10 +174,38 @@ func appendHealth(ctx *hookstate.Context, health *HealthState) error {
 		}
 		hs = map[string]*HealthState{}
 	}
-	hs[ctx.InstanceName()] = health
+	instanceName := ctx.InstanceName()
+	previous := UnknownStatus
+	if prev := hs[instanceName]; prev != nil {
+		previous = prev.Status
+	}
+	hs[instanceName] = health
 	st.Set("health", hs)
 
-	return nil
+	if health.Status == previous {
+		return nil
+	}
+	if health.Status == ErrorStatus || health.Status == WaitingStatus {
+		// let the health manager decide promptly whether to revert
+		st.EnsureBefore(0)
+	}
+	return addHealthNotice(st, instanceName, previous, health)
+}
+
+// addHealthNotice records a snap-health notice for a change in the health
+// status of the given snap.
+func addHealthNotice(st *state.State, instanceName string, previous HealthStatus, health *HealthState) error {
+	data := map[string]string{
+		"status":          health.Status.String(),
+		"previous-status": previous.String(),
+		"revision":        health.Revision.String(),
+	}
+	if health.Code != "" {
+		data["code"] = health.Code
+	}
+	opts := &state.AddNoticeOptions{Data: data}
+	_, err := st.AddNotice(nil, state.SnapHealthNotice, instanceName, opts)
+	return err
 }
 
 // SetFromHookContext extracts the health of a snap from a hook
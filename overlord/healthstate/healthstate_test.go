@@ -20,6 +20,8 @@
 package healthstate_test
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -30,11 +32,13 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/confdbstate"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/healthstate"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
 	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats/swfeatstest"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/store/storetest"
@@ -253,3 +257,378 @@ func (s *healthSuite) TestSetFromHookContextEmpty(c *check.C) {
 	// no health in the context -> no health in state
 	c.Check(s.state.Get("health", &hs), testutil.ErrorIs, state.ErrNoState)
 }
+
+func (s *healthSuite) TestSetFromHookContextAddsNoticeOnTransition(c *check.C) {
+	ctx, err := hookstate.NewContext(nil, s.state, &hookstate.HookSetup{Snap: "foo", Revision: snap.R(7)}, nil, "")
+	c.Assert(err, check.IsNil)
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	notices := func() []*state.Notice {
+		return s.state.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.SnapHealthNotice}})
+	}
+
+	ctx.Set("health", &healthstate.HealthState{Revision: snap.R(7), Status: healthstate.OkayStatus})
+	c.Assert(healthstate.SetFromHookContext(ctx), check.IsNil)
+	n := notices()
+	c.Assert(n, check.HasLen, 1)
+	c.Check(n[0].Key(), check.Equals, "foo")
+	c.Check(n[0].LastData(), check.DeepEquals, map[string]string{
+		"status":          "okay",
+		"previous-status": "unknown",
+		"revision":        "7",
+	})
+	lastRepeated := n[0].LastRepeated()
+
+	// no transition, no new occurrence
+	c.Assert(healthstate.SetFromHookContext(ctx), check.IsNil)
+	n = notices()
+	c.Assert(n, check.HasLen, 1)
+	c.Check(n[0].LastRepeated(), check.Equals, lastRepeated)
+
+	ctx.Set("health", &healthstate.HealthState{Revision: snap.R(7), Status: healthstate.BlockedStatus, Code: "no-network"})
+	c.Assert(healthstate.SetFromHookContext(ctx), check.IsNil)
+	n = notices()
+	c.Assert(n, check.HasLen, 1)
+	c.Check(n[0].LastRepeated().After(lastRepeated), check.Equals, true)
+	c.Check(n[0].LastData(), check.DeepEquals, map[string]string{
+		"status":          "blocked",
+		"previous-status": "okay",
+		"revision":        "7",
+		"code":            "no-network",
+	})
+}
+
+func (s *healthSuite) mockCheckHealthHook(c *check.C, snapYaml string) *snap.Info {
+	sideInfo := &snap.SideInfo{RealName: "test-snap", Revision: snap.R(42)}
+	info := snaptest.MockSnap(c, snapYaml, sideInfo)
+	hookFn := filepath.Join(info.MountDir(), "meta", "hooks", "check-health")
+	c.Assert(os.MkdirAll(filepath.Dir(hookFn), 0755), check.IsNil)
+	c.Assert(os.WriteFile(hookFn, nil, 0755), check.IsNil)
+	info, err := snap.ReadInfo("test-snap", sideInfo)
+	c.Assert(err, check.IsNil)
+	return info
+}
+
+func (s *healthSuite) TestCheckInterval(c *check.C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// no check-health hook
+	interval, err := healthstate.CheckInterval(s.state, s.info)
+	c.Assert(err, check.IsNil)
+	c.Check(interval, check.Equals, time.Duration(0))
+
+	info := s.mockCheckHealthHook(c, "{name: test-snap, version: v1}")
+	interval, err = healthstate.CheckInterval(s.state, info)
+	c.Assert(err, check.IsNil)
+	c.Check(interval, check.Equals, time.Duration(0))
+
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "health.check-interval", "10m"), check.IsNil)
+	tr.Commit()
+
+	interval, err = healthstate.CheckInterval(s.state, info)
+	c.Assert(err, check.IsNil)
+	c.Check(interval, check.Equals, 10*time.Minute)
+
+	// the snap interval wins over the system option
+	info = s.mockCheckHealthHook(c, "{name: test-snap, version: v1, health-check-interval: 5m}")
+	interval, err = healthstate.CheckInterval(s.state, info)
+	c.Assert(err, check.IsNil)
+	c.Check(interval, check.Equals, 5*time.Minute)
+}
+
+func (s *healthSuite) TestIsStale(c *check.C) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	s.AddCleanup(healthstate.MockTimeNow(func() time.Time { return now }))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	info := s.mockCheckHealthHook(c, "{name: test-snap, version: v1, health-check-interval: 5m}")
+
+	for _, t := range []struct {
+		age   time.Duration
+		stale bool
+	}{
+		{time.Minute, false},
+		{10 * time.Minute, false},
+		{11 * time.Minute, true},
+	} {
+		stale, err := healthstate.IsStale(s.state, info, &healthstate.HealthState{Timestamp: now.Add(-t.age)})
+		c.Assert(err, check.IsNil)
+		c.Check(stale, check.Equals, t.stale, check.Commentf("%s", t.age))
+	}
+
+	stale, err := healthstate.IsStale(s.state, info, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(stale, check.Equals, false)
+
+	// not checked periodically
+	stale, err = healthstate.IsStale(s.state, s.info, &healthstate.HealthState{Timestamp: now.Add(-time.Hour)})
+	c.Assert(err, check.IsNil)
+	c.Check(stale, check.Equals, false)
+}
+
+func (s *healthSuite) testManagerEnsure(c *check.C, snapYaml string, age time.Duration) (calls [][]string, health *healthstate.HealthState) {
+	cmd := testutil.MockCommand(c, "snap", "exit 0")
+	defer cmd.Restore()
+	s.mockCheckHealthHook(c, snapYaml)
+
+	s.state.Lock()
+	s.state.Set("health", map[string]*healthstate.HealthState{
+		"test-snap": {Revision: snap.R(42), Timestamp: time.Now().Add(-age), Status: healthstate.OkayStatus},
+	})
+	s.state.Unlock()
+
+	mgr := healthstate.Manager(s.state, s.hookMgr)
+	c.Assert(mgr.Ensure(), check.IsNil)
+	mgr.WaitChecks()
+	mgr.Stop()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	health, err := healthstate.Get(s.state, "test-snap")
+	c.Assert(err, check.IsNil)
+	return cmd.Calls(), health
+}
+
+func (s *healthSuite) TestManagerEnsureRunsDueCheck(c *check.C) {
+	calls, health := s.testManagerEnsure(c, "{name: test-snap, version: v1, health-check-interval: 5m}", 10*time.Minute)
+	c.Check(calls, check.DeepEquals, [][]string{{"snap", "run", "--hook", "check-health", "-r", "42", "test-snap"}})
+	c.Check(health.Status, check.Equals, healthstate.UnknownStatus)
+	c.Check(health.Code, check.Equals, "snapd-hook-no-health-set")
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	// no change is created for periodic checks
+	c.Check(s.state.Changes(), check.HasLen, 0)
+	n := s.state.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.SnapHealthNotice}})
+	c.Assert(n, check.HasLen, 1)
+	c.Check(n[0].LastData()["previous-status"], check.Equals, "okay")
+}
+
+func (s *healthSuite) TestManagerEnsureCheckNotDue(c *check.C) {
+	calls, health := s.testManagerEnsure(c, "{name: test-snap, version: v1, health-check-interval: 5m}", time.Minute)
+	c.Check(calls, check.HasLen, 0)
+	c.Check(health.Status, check.Equals, healthstate.OkayStatus)
+}
+
+func (s *healthSuite) TestManagerEnsureNoInterval(c *check.C) {
+	calls, health := s.testManagerEnsure(c, "{name: test-snap, version: v1}", time.Hour)
+	c.Check(calls, check.HasLen, 0)
+	c.Check(health.Status, check.Equals, healthstate.OkayStatus)
+}
+
+func (s *healthSuite) TestManagerEnsureSnapBusy(c *check.C) {
+	s.state.Lock()
+	chg := s.state.NewChange("refresh-snap", "...")
+	t := s.state.NewTask("link-snap", "...")
+	t.Set("snap-setup", &snapstate.SnapSetup{SideInfo: &snap.SideInfo{RealName: "test-snap", Revision: snap.R(42)}})
+	chg.AddTask(t)
+	s.state.Unlock()
+
+	calls, health := s.testManagerEnsure(c, "{name: test-snap, version: v1, health-check-interval: 5m}", time.Hour)
+	c.Check(calls, check.HasLen, 0)
+	c.Check(health.Status, check.Equals, healthstate.OkayStatus)
+}
+
+func (s *healthSuite) TestEnsureLoopHasLogging(c *check.C) {
+	swfeatstest.CheckEnsureLoopLogging("healthmgr.go", c, true)
+}
+
+func (s *healthSuite) mockRefreshed(c *check.C, refreshed time.Time, health *healthstate.HealthState) {
+	s.mockRefreshedTo(c, "{name: test-snap, version: v1}", refreshed, health)
+}
+
+func (s *healthSuite) mockRefreshedTo(c *check.C, snapYaml string, refreshed time.Time, health *healthstate.HealthState) {
+	s.AddCleanup(snapstatetest.UseFallbackDeviceModel())
+	si41 := &snap.SideInfo{RealName: "test-snap", Revision: snap.R(41)}
+	si42 := &snap.SideInfo{RealName: "test-snap", Revision: snap.R(42)}
+	snaptest.MockSnap(c, "{name: test-snap, version: v0}", si41)
+	snaptest.MockSnap(c, snapYaml, si42)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	snapstate.Set(s.state, "test-snap", &snapstate.SnapState{
+		Sequence:        snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si41, si42}),
+		Current:         snap.R(42),
+		Active:          true,
+		SnapType:        "app",
+		LastRefreshTime: &refreshed,
+	})
+	s.state.Set("health", map[string]*healthstate.HealthState{"test-snap": health})
+}
+
+func (s *healthSuite) setRollbackPolicy(c *check.C, gracePeriod, waitingTimeout string) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "health.rollback.grace-period", gracePeriod), check.IsNil)
+	c.Assert(tr.Set("core", "health.rollback.waiting-timeout", waitingTimeout), check.IsNil)
+	tr.Commit()
+}
+
+func (s *healthSuite) ensureRollbacks(c *check.C) (changes []*state.Change, warnings []*state.Warning) {
+	mgr := healthstate.Manager(s.state, s.hookMgr)
+	c.Assert(mgr.Ensure(), check.IsNil)
+	mgr.Stop()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	return s.state.Changes(), s.state.AllWarnings()
+}
+
+func (s *healthSuite) TestEnsureRollbackOnError(c *check.C) {
+	now := time.Now()
+	s.setRollbackPolicy(c, "10m", "")
+	s.mockRefreshed(c, now.Add(-2*time.Minute), &healthstate.HealthState{
+		Revision:  snap.R(42),
+		Timestamp: now.Add(-time.Minute),
+		Status:    healthstate.ErrorStatus,
+	})
+
+	changes, warnings := s.ensureRollbacks(c)
+	c.Assert(changes, check.HasLen, 1)
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(changes[0].Kind(), check.Equals, "revert-snap")
+	c.Check(changes[0].Summary(), check.Equals, `Revert "test-snap" snap after bad health`)
+	c.Check(changes[0].Tasks(), check.Not(check.HasLen), 0)
+	c.Assert(warnings, check.HasLen, 1)
+	c.Check(warnings[0].String(), check.Equals, fmt.Sprintf(`snap "test-snap" is being reverted to revision 41 (change %s): health of refreshed revision 42 was "error"`, changes[0].ID()))
+}
+
+func (s *healthSuite) TestEnsureRollbackRevertErrorNoWarning(c *check.C) {
+	restore := healthstate.MockSnapstateRevert(func(st *state.State, name string, flags snapstate.Flags, fromChange string) (*state.TaskSet, error) {
+		return nil, errors.New("boom")
+	})
+	defer restore()
+
+	now := time.Now()
+	s.setRollbackPolicy(c, "10m", "")
+	s.mockRefreshed(c, now.Add(-2*time.Minute), &healthstate.HealthState{
+		Revision:  snap.R(42),
+		Timestamp: now.Add(-time.Minute),
+		Status:    healthstate.ErrorStatus,
+	})
+
+	changes, warnings := s.ensureRollbacks(c)
+	c.Check(changes, check.HasLen, 0)
+	c.Check(warnings, check.HasLen, 0)
+}
+
+func (s *healthSuite) TestEnsureRollbackWaitingTimeout(c *check.C) {
+	now := time.Now()
+	s.setRollbackPolicy(c, "30m", "5m")
+	s.mockRefreshed(c, now.Add(-6*time.Minute), &healthstate.HealthState{
+		Revision:  snap.R(42),
+		Timestamp: now.Add(-5 * time.Minute),
+		Status:    healthstate.WaitingStatus,
+	})
+
+	changes, warnings := s.ensureRollbacks(c)
+	c.Check(changes, check.HasLen, 1)
+	c.Check(warnings, check.HasLen, 1)
+}
+
+func (s *healthSuite) TestEnsureRollbackSnapPolicy(c *check.C) {
+	now := time.Now()
+	// rollbacks are disabled system-wide but the snap asks for them
+	s.setRollbackPolicy(c, "", "")
+	s.mockRefreshedTo(c, "{name: test-snap, version: v1, health-rollback: {grace-period: 30m, waiting-timeout: 5m}}", now.Add(-6*time.Minute), &healthstate.HealthState{
+		Revision:  snap.R(42),
+		Timestamp: now.Add(-5 * time.Minute),
+		Status:    healthstate.WaitingStatus,
+	})
+
+	changes, warnings := s.ensureRollbacks(c)
+	c.Check(changes, check.HasLen, 1)
+	c.Check(warnings, check.HasLen, 1)
+}
+
+func (s *healthSuite) TestEnsureRollbackSnapPolicyTakesPrecedence(c *check.C) {
+	now := time.Now()
+	// the snap takes longer than the system default to become ready
+	s.setRollbackPolicy(c, "30m", "5m")
+	s.mockRefreshedTo(c, "{name: test-snap, version: v1, health-rollback: {waiting-timeout: 20m}}", now.Add(-6*time.Minute), &healthstate.HealthState{
+		Revision:  snap.R(42),
+		Timestamp: now.Add(-5 * time.Minute),
+		Status:    healthstate.WaitingStatus,
+	})
+
+	changes, warnings := s.ensureRollbacks(c)
+	c.Check(changes, check.HasLen, 0)
+	c.Check(warnings, check.HasLen, 0)
+
+	// while the system grace period still applies to the snap
+	s.mockRefreshedTo(c, "{name: test-snap, version: v1, health-rollback: {waiting-timeout: 20m}}", now.Add(-2*time.Minute), &healthstate.HealthState{
+		Revision:  snap.R(42),
+		Timestamp: now.Add(-time.Minute),
+		Status:    healthstate.ErrorStatus,
+	})
+	changes, warnings = s.ensureRollbacks(c)
+	c.Check(changes, check.HasLen, 1)
+	c.Check(warnings, check.HasLen, 1)
+}
+
+func (s *healthSuite) TestEnsureRollbackNotYet(c *check.C) {
+	now := time.Now()
+	s.setRollbackPolicy(c, "30m", "5m")
+	s.mockRefreshed(c, now.Add(-2*time.Minute), &healthstate.HealthState{
+		Revision:  snap.R(42),
+		Timestamp: now.Add(-time.Minute),
+		Status:    healthstate.WaitingStatus,
+	})
+
+	changes, warnings := s.ensureRollbacks(c)
+	c.Check(changes, check.HasLen, 0)
+	c.Check(warnings, check.HasLen, 0)
+}
+
+func (s *healthSuite) TestEnsureRollbackNotNeeded(c *check.C) {
+	now := time.Now()
+	for _, t := range []struct {
+		gracePeriod string
+		health      *healthstate.HealthState
+	}{
+		// disabled
+		{"", &healthstate.HealthState{Revision: snap.R(42), Timestamp: now, Status: healthstate.ErrorStatus}},
+		// healthy
+		{"10m", &healthstate.HealthState{Revision: snap.R(42), Timestamp: now, Status: healthstate.OkayStatus}},
+		// reported outside of the grace period
+		{"10m", &healthstate.HealthState{Revision: snap.R(42), Timestamp: now.Add(10 * time.Minute), Status: healthstate.ErrorStatus}},
+		// reported by another revision
+		{"10m", &healthstate.HealthState{Revision: snap.R(41), Timestamp: now, Status: healthstate.ErrorStatus}},
+	} {
+		s.setRollbackPolicy(c, t.gracePeriod, "")
+		s.mockRefreshed(c, now.Add(-time.Minute), t.health)
+
+		changes, warnings := s.ensureRollbacks(c)
+		c.Check(changes, check.HasLen, 0)
+		c.Check(warnings, check.HasLen, 0)
+	}
+}
+
+func (s *healthSuite) TestEnsureRollbackOnlyRefreshedRevision(c *check.C) {
+	now := time.Now()
+	s.setRollbackPolicy(c, "10m", "")
+	s.mockRefreshed(c, now.Add(-2*time.Minute), &healthstate.HealthState{
+		Revision:  snap.R(41),
+		Timestamp: now.Add(-time.Minute),
+		Status:    healthstate.ErrorStatus,
+	})
+	// 41 is current after a revert, 42 is blocked
+	s.state.Lock()
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "test-snap", &snapst), check.IsNil)
+	snapst.Current = snap.R(41)
+	snapstate.Set(s.state, "test-snap", &snapst)
+	s.state.Unlock()
+
+	changes, warnings := s.ensureRollbacks(c)
+	c.Check(changes, check.HasLen, 0)
+	c.Check(warnings, check.HasLen, 0)
+}
@@ -22,6 +22,7 @@ package ctlcmd
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"strconv"
@@ -49,6 +50,7 @@ type baseCommand struct {
 	uid            string
 	changeID       *string
 	clientFeatures []string
+	reqCtx         context.Context
 }
 
 func (c *baseCommand) setName(name string) {
@@ -71,6 +73,19 @@ func (c *baseCommand) setClientFeatures(features []string) {
 	c.clientFeatures = features
 }
 
+func (c *baseCommand) setRequestContext(ctx context.Context) {
+	c.reqCtx = ctx
+}
+
+// requestContext returns the context of the snapctl request, which is done
+// when the request goes away.
+func (c *baseCommand) requestContext() context.Context {
+	if c.reqCtx == nil {
+		return context.Background()
+	}
+	return c.reqCtx
+}
+
 func (c *baseCommand) printf(format string, a ...any) {
 	c.print(fmt.Sprintf(format, a...))
 }
@@ -118,6 +133,7 @@ type command interface {
 
 	setChangeID(changeID *string)
 	setClientFeatures(features []string)
+	setRequestContext(ctx context.Context)
 
 	Execute(args []string) error
 }
@@ -161,10 +177,16 @@ func (f ForbiddenCommandError) Error() string {
 
 // nonRootAllowed lists the commands that can be performed even when snapctl
 // is invoked not by root.
-var nonRootAllowed = []string{"get", "services", "set-health", "is-connected", "system-mode", "refresh", "model", "version", "is-ready", "tasks", "change"}
+var nonRootAllowed = []string{"get", "services", "set-health", "is-connected", "system-mode", "refresh", "model", "version", "is-ready", "tasks", "change", "notices", "wait-notice"}
 
 // Run runs the requested command.
-func Run(context *hookstate.Context, args []string, uid uint32, features []string) (stdout, stderr []byte, changeID string, err error) {
+func Run(hookCtx *hookstate.Context, args []string, uid uint32, features []string) (stdout, stderr []byte, changeID string, err error) {
+	return RunWithContext(context.Background(), hookCtx, args, uid, features)
+}
+
+// RunWithContext runs the requested command, commands that wait give up when
+// ctx is done.
+func RunWithContext(ctx context.Context, hookCtx *hookstate.Context, args []string, uid uint32, features []string) (stdout, stderr []byte, changeID string, err error) {
 	if len(args) == 0 {
 		return nil, nil, "", fmt.Errorf("internal error: snapctl cannot run without args")
 	}
@@ -184,9 +206,10 @@ func Run(context *hookstate.Context, args []string, uid uint32, features []strin
 		cmd.setUid(uid)
 		cmd.setStdout(&stdoutBuffer)
 		cmd.setStderr(&stderrBuffer)
-		cmd.setContext(context)
+		cmd.setContext(hookCtx)
 		cmd.setChangeID(&changeID)
 		cmd.setClientFeatures(features)
+		cmd.setRequestContext(ctx)
 
 		theCmd, err := parser.AddCommand(name, cmdInfo.shortHelp, cmdInfo.longHelp, cmd)
 		theCmd.Hidden = cmdInfo.hidden
@@ -81,6 +81,12 @@ func MockServicestateControlFunc(f func(*state.State, []*snap.AppInfo, *services
 	return func() { servicestateControl = old }
 }
 
+func MockServicestateTimerControlFunc(f func(*state.State, *snap.AppInfo, string, bool, *hookstate.Context) (*state.TaskSet, error)) (restore func()) {
+	old := servicestateTimerControl
+	servicestateTimerControl = f
+	return func() { servicestateTimerControl = old }
+}
+
 func MockSnapstateInstallComponentsFunc(f func(ctx context.Context, st *state.State, names []string, info *snap.Info, vsets *snapasserts.ValidationSets, opts snapstate.Options) ([]*state.TaskSet, error)) (restore func()) {
 	old := snapstateInstallComponents
 	snapstateInstallComponents = f
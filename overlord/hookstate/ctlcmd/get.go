@@ -55,7 +55,7 @@ type getCommand struct {
 	ForceSlotSide bool     `long:"slot" description:"return attribute values from the slot side of the connection"`
 	ForcePlugSide bool     `long:"plug" description:"return attribute values from the plug side of the connection"`
 	View          bool     `long:"view" description:"return confdb values from the view declared in the plug"`
-	Previous      bool     `long:"previous" description:"return confdb values disregarding changes from the current transaction"`
+	Previous      bool     `long:"previous" description:"return confdb values disregarding changes from the current transaction, or interface connection settings from before they changed"`
 	With          []string `long:"with" value-name:"<param>=<constraint>" description:"parameter constraints for filtering confdb queries"`
 	Default       string   `long:"default" unquote:"false" description:"a default value to be used when no value is set"`
 	WaitFor       string   `long:"wait-for" description:"maximum duration to wait for confdb access (e.g. 10s)"`
@@ -103,6 +103,12 @@ with optional --plug and --slot command options:
 
 This requests the "usb-vendor" setting from the slot that is connected to
 "myplug".
+
+In the change-plug-<plug> and change-slot-<slot> hooks, which run when the
+settings of a connection changed because a snap was refreshed, the --previous
+flag can be used to print the settings from before the change:
+
+    $ snapctl get --previous :myplug --slot path
 `)
 
 var longConfdbGetHelp = i18n.G(`
@@ -196,12 +202,19 @@ func (c *getCommand) Execute(args []string) error {
 	}
 
 	if c.Previous {
-		if !c.View {
-			return fmt.Errorf("cannot use --previous without --view")
-		}
-
 		hookPref := func(p string) bool { return strings.HasPrefix(context.HookName(), p) }
-		if context == nil || context.IsEphemeral() || !(hookPref("save-view-") ||
+		if !c.View {
+			// the previous attributes of a connection are available
+			// when its attributes changed
+			if context.IsEphemeral() || !(hookPref("change-plug-") || hookPref("change-slot-")) {
+				return fmt.Errorf("cannot use --previous without --view")
+			}
+			// configuration has no previous values, only the attributes
+			// of the connection do
+			if !strings.Contains(c.Positional.PlugOrSlotSpec, ":") {
+				return fmt.Errorf("cannot use --previous without --view or :<plug|slot> argument")
+			}
+		} else if context == nil || context.IsEphemeral() || !(hookPref("save-view-") ||
 			hookPref("change-view-") || hookPref("observe-view-")) {
 			return fmt.Errorf(`cannot use --previous outside of save-view, change-view or observe-view hooks`)
 		}
@@ -290,6 +303,8 @@ const (
 	connectSlotHook
 	disconnectPlugHook
 	disconnectSlotHook
+	changePlugHook
+	changeSlotHook
 	unknownHook
 )
 
@@ -311,6 +326,10 @@ func interfaceHookType(hookName string) (ifaceHookType, error) {
 		return unprepareSlotHook, nil
 	case strings.HasPrefix(hookName, "unprepare-plug-"):
 		return unpreparePlugHook, nil
+	case strings.HasPrefix(hookName, "change-plug-"):
+		return changePlugHook, nil
+	case strings.HasPrefix(hookName, "change-slot-"):
+		return changeSlotHook, nil
 	default:
 		return unknownHook, fmt.Errorf("unknown hook type")
 	}
@@ -378,7 +397,7 @@ func (c *getCommand) getInterfaceSetting(context *hookstate.Context, plugOrSlot
 		return fmt.Errorf("cannot use --plug and --slot together")
 	}
 
-	isPlugSide := (hookType == preparePlugHook || hookType == unpreparePlugHook || hookType == connectPlugHook || hookType == disconnectPlugHook)
+	isPlugSide := (hookType == preparePlugHook || hookType == unpreparePlugHook || hookType == connectPlugHook || hookType == disconnectPlugHook || hookType == changePlugHook)
 	if err = validatePlugOrSlot(attrsTask, isPlugSide, plugOrSlot); err != nil {
 		return err
 	}
@@ -394,11 +413,16 @@ func (c *getCommand) getInterfaceSetting(context *hookstate.Context, plugOrSlot
 	st.Lock()
 	defer st.Unlock()
 
+	attrsKey := which
+	if c.Previous {
+		attrsKey = "old-" + which
+	}
+
 	var staticAttrs, dynamicAttrs map[string]any
-	if err = attrsTask.Get(which+"-static", &staticAttrs); err != nil {
+	if err = attrsTask.Get(attrsKey+"-static", &staticAttrs); err != nil {
 		return fmt.Errorf(i18n.G("internal error: cannot get %s from appropriate task"), which)
 	}
-	if err = attrsTask.Get(which+"-dynamic", &dynamicAttrs); err != nil {
+	if err = attrsTask.Get(attrsKey+"-dynamic", &dynamicAttrs); err != nil {
 		return fmt.Errorf(i18n.G("internal error: cannot get %s from appropriate task"), which)
 	}
 
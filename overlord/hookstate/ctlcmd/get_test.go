@@ -464,6 +464,57 @@ func (s *getAttrSuite) TestSlotHookTests(c *C) {
 	}
 }
 
+func (s *getAttrSuite) TestChangeHooks(c *C) {
+	st := s.mockPlugHookContext.State()
+	st.Lock()
+	hookTask := st.NewTask("run-hook", "my change hook task")
+	hookTask.Set("plug", &interfaces.PlugRef{Snap: "a", Name: "aplug"})
+	hookTask.Set("slot", &interfaces.SlotRef{Snap: "b", Name: "bslot"})
+	hookTask.Set("plug-static", map[string]any{"aattr": "foo"})
+	hookTask.Set("plug-dynamic", map[string]any{})
+	hookTask.Set("slot-static", map[string]any{"battr": "new-bar"})
+	hookTask.Set("slot-dynamic", map[string]any{"dyn-slot-attr": "d"})
+	hookTask.Set("old-plug-static", map[string]any{"aattr": "foo"})
+	hookTask.Set("old-plug-dynamic", map[string]any{})
+	hookTask.Set("old-slot-static", map[string]any{"battr": "bar"})
+	hookTask.Set("old-slot-dynamic", map[string]any{})
+	chg := st.NewChange("refresh", "...")
+	chg.AddTask(hookTask)
+	st.Unlock()
+
+	for hook, name := range map[string]string{
+		"change-plug-aplug": ":aplug",
+		"change-slot-bslot": ":bslot",
+	} {
+		setup := &hookstate.HookSetup{Snap: "test-snap", Revision: snap.R(1), Hook: hook}
+		ctx, err := hookstate.NewContext(hookTask, st, setup, s.mockHandler, "")
+		c.Assert(err, IsNil)
+		ctx.Lock()
+		ctx.Set("attrs-task", hookTask.ID())
+		ctx.Unlock()
+
+		stdout, _, _, err := ctlcmd.Run(ctx, []string{"get", "--slot", name, "battr"}, 0, nil)
+		c.Assert(err, IsNil)
+		c.Check(string(stdout), Equals, "new-bar\n")
+		stdout, _, _, err = ctlcmd.Run(ctx, []string{"get", "--previous", "--slot", name, "battr"}, 0, nil)
+		c.Assert(err, IsNil)
+		c.Check(string(stdout), Equals, "bar\n")
+		stdout, _, _, err = ctlcmd.Run(ctx, []string{"get", "--plug", name, "aattr"}, 0, nil)
+		c.Assert(err, IsNil)
+		c.Check(string(stdout), Equals, "foo\n")
+		// the dynamic attributes changed too
+		_, _, _, err = ctlcmd.Run(ctx, []string{"get", "--previous", "--slot", name, "dyn-slot-attr"}, 0, nil)
+		c.Check(err, ErrorMatches, `no "dyn-slot-attr" attribute`)
+		// configuration has no previous values
+		_, _, _, err = ctlcmd.Run(ctx, []string{"get", "--previous", "foo"}, 0, nil)
+		c.Check(err, ErrorMatches, `cannot use --previous without --view or :<plug\|slot> argument`)
+	}
+
+	// --previous is only meaningful in the change hooks
+	_, _, _, err := ctlcmd.Run(s.mockPlugHookContext, []string{"get", "--previous", ":aplug", "aattr"}, 0, nil)
+	c.Check(err, ErrorMatches, "cannot use --previous without --view")
+}
+
 type confdbSuite struct {
 	testutil.BaseTest
 
@@ -0,0 +1,263 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var (
+	shortNoticesHelp = i18n.G("List notices concerning the snap")
+	longNoticesHelp  = i18n.G(`
+The notices command lists the notices recorded by snapd which concern the
+snap: changes affecting it or initiated by it, inhibition of its refreshes or
+of its apps, and changes of its health.
+
+valid options for --format: json
+
+$ snapctl notices [--type TYPE]... [--key KEY]... [--after TIME] [--format FORMAT]
+`)
+
+	shortWaitNoticeHelp = i18n.G("Wait for a notice concerning the snap")
+	longWaitNoticeHelp  = i18n.G(`
+The wait-notice command waits for snapd to record a notice which concerns the
+snap after the given time, or after the command was invoked if none is given,
+and prints the matching notices. It gives up after the given timeout, which
+defaults to and cannot exceed 60s.
+
+valid options for --format: json
+
+$ snapctl wait-notice [--type TYPE]... [--key KEY]... [--after TIME] [--timeout DURATION] [--format FORMAT]
+`)
+)
+
+// maxWaitNoticeTimeout is bounded so that the snapctl request completes
+// before the client gives up on it.
+const maxWaitNoticeTimeout = 60 * time.Second
+
+func init() {
+	addCommand("notices", shortNoticesHelp, longNoticesHelp, func() command { return &noticesCommand{} })
+	addCommand("wait-notice", shortWaitNoticeHelp, longWaitNoticeHelp, func() command { return &waitNoticeCommand{} })
+}
+
+type noticesFilterOptions struct {
+	Types  []string `long:"type" description:"Only list notices of this type"`
+	Keys   []string `long:"key" description:"Only list notices with this key"`
+	After  string   `long:"after" description:"Only list notices which last occurred after this RFC3339 time"`
+	Format string   `long:"format" choice:"json" description:"Output format (supported: json)"`
+}
+
+func (o *noticesFilterOptions) filter(uid string) (*state.NoticeFilter, error) {
+	userID, err := strconv.ParseUint(uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("internal error: invalid uid %q: %v", uid, err)
+	}
+	requestUID := uint32(userID)
+
+	types := make([]state.NoticeType, 0, len(o.Types))
+	for _, typ := range strutil.MultiCommaSeparatedList(o.Types) {
+		noticeType := state.NoticeType(typ)
+		if !noticeType.Valid() {
+			return nil, fmt.Errorf("invalid notice type %q", typ)
+		}
+		types = append(types, noticeType)
+	}
+
+	var after time.Time
+	if o.After != "" {
+		after, err = time.Parse(time.RFC3339, o.After)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q: %v", o.After, err)
+		}
+	}
+
+	return &state.NoticeFilter{
+		// notices of the calling user, and public ones
+		UserID: &requestUID,
+		Types:  types,
+		Keys:   strutil.MultiCommaSeparatedList(o.Keys),
+		After:  after,
+	}, nil
+}
+
+type noticesCommand struct {
+	baseCommand
+	noticesFilterOptions
+}
+
+func (c *noticesCommand) Execute([]string) error {
+	ctx, err := c.ensureContext()
+	if err != nil {
+		return err
+	}
+	filter, err := c.filter(c.uid)
+	if err != nil {
+		return err
+	}
+
+	st := ctx.State()
+	st.Lock()
+	defer st.Unlock()
+
+	notices := snapNotices(st, st.Notices(filter), ctx.InstanceName(), filter.After)
+	return printNotices(&c.baseCommand, notices, c.Format)
+}
+
+type waitNoticeCommand struct {
+	baseCommand
+	noticesFilterOptions
+	Timeout string `long:"timeout" description:"Give up after this duration"`
+}
+
+func (c *waitNoticeCommand) Execute([]string) error {
+	hookCtx, err := c.ensureContext()
+	if err != nil {
+		return err
+	}
+	filter, err := c.filter(c.uid)
+	if err != nil {
+		return err
+	}
+	if filter.After.IsZero() {
+		filter.After = time.Now()
+	}
+	timeout := maxWaitNoticeTimeout
+	if c.Timeout != "" {
+		timeout, err = time.ParseDuration(c.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q: %v", c.Timeout, err)
+		}
+		if timeout <= 0 || timeout > maxWaitNoticeTimeout {
+			return fmt.Errorf("invalid timeout %q: must be positive and at most %v", c.Timeout, maxWaitNoticeTimeout)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.requestContext(), timeout)
+	defer cancel()
+
+	// Note - state must not be locked while waiting, as adding notices
+	// requires the state lock.
+	st := hookCtx.State()
+	for {
+		notices, err := st.WaitNotices(ctx, filter)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("no notice concerning snap %q within %v", hookCtx.InstanceName(), timeout)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot wait for notices: %v", err)
+		}
+		if len(notices) == 0 {
+			continue
+		}
+
+		st.Lock()
+		concerning := snapNotices(st, notices, hookCtx.InstanceName(), filter.After)
+		st.Unlock()
+		if len(concerning) > 0 {
+			return printNotices(&c.baseCommand, concerning, c.Format)
+		}
+		// notices are sorted by the time they last repeated
+		filter.After = notices[len(notices)-1].LastRepeated()
+	}
+}
+
+func printNotices(c *baseCommand, notices []*state.Notice, format string) error {
+	if format == "json" {
+		if notices == nil {
+			notices = []*state.Notice{} // avoid null result
+		}
+		return json.NewEncoder(c.stdout).Encode(notices)
+	}
+
+	if len(notices) == 0 {
+		return nil
+	}
+	w := newTabWriter(c.stdout)
+	fmt.Fprint(w, i18n.G("ID\tType\tKey\tLast-repeated\n"))
+	for _, n := range notices {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", n.ID(), n.Type(), n.Key(), n.LastRepeated().UTC().Format(time.RFC3339Nano))
+	}
+	return w.Flush()
+}
+
+// snapNotices returns the notices which concern the given snap, the notices
+// must have been retrieved for occurrences after the given time, if it is not
+// zero.
+//
+// It must be called with the state locked.
+func snapNotices(st *state.State, notices []*state.Notice, snapName string, after time.Time) []*state.Notice {
+	var concerning []*state.Notice
+	for _, n := range notices {
+		if noticeConcernsSnap(st, n, snapName, after) {
+			concerning = append(concerning, n)
+		}
+	}
+	return concerning
+}
+
+func noticeConcernsSnap(st *state.State, n *state.Notice, snapName string, after time.Time) bool {
+	switch n.Type() {
+	case state.SnapRunInhibitNotice, state.SnapHealthNotice:
+		return n.Key() == snapName
+	case state.RefreshInhibitNotice:
+		// the notice only lists the snaps whose inhibition changed with
+		// its last occurrence
+		concerns, err := snapstate.RefreshInhibitNoticeConcernsSnap(st, n, snapName, after)
+		return err == nil && concerns
+	case state.ChangeUpdateNotice:
+		chg := st.Change(n.Key())
+		if chg == nil {
+			return false
+		}
+		return changeConcernsSnap(chg, snapName)
+	}
+	return false
+}
+
+func changeConcernsSnap(chg *state.Change, snapName string) bool {
+	var initiatorSnapName string
+	if err := chg.Get("initiated-by-snap", &initiatorSnapName); err == nil && initiatorSnapName == snapName {
+		return true
+	}
+	var snapNames []string
+	if err := chg.Get("snap-names", &snapNames); err == nil && strutil.ListContains(snapNames, snapName) {
+		return true
+	}
+	for _, t := range chg.Tasks() {
+		affected, err := snapstate.SnapsAffectedByTask(t)
+		if err != nil {
+			continue
+		}
+		if strutil.ListContains(affected, snapName) {
+			return true
+		}
+	}
+	return false
+}
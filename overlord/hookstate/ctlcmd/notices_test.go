@@ -0,0 +1,259 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/hookstate/ctlcmd"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type noticesSuite struct {
+	testutil.BaseTest
+	st          *state.State
+	mockContext *hookstate.Context
+}
+
+var _ = Suite(&noticesSuite{})
+
+func (s *noticesSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+
+	s.st = state.New(nil)
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	mockInstalledSnap(c, s.st, "name: snap1", "")
+	mockInstalledSnap(c, s.st, "name: snap2", "")
+
+	ctx, err := hookstate.NewContext(nil, s.st, &hookstate.HookSetup{Snap: "snap1", Revision: snap.R(1)}, nil, "")
+	c.Assert(err, IsNil)
+	s.mockContext = ctx
+}
+
+func (s *noticesSuite) addNotice(c *C, userID *uint32, noticeType state.NoticeType, key string) {
+	_, err := s.st.AddNotice(userID, noticeType, key, nil)
+	c.Assert(err, IsNil)
+}
+
+// addRefreshInhibitNotice records a refresh-inhibit notice for a change of
+// the inhibition of the given snap, as snapstate does.
+func (s *noticesSuite) addRefreshInhibitNotice(c *C, snapName string) {
+	id, err := s.st.AddNotice(nil, state.RefreshInhibitNotice, "-", &state.AddNoticeOptions{
+		Data: map[string]string{"snap-names": snapName},
+	})
+	c.Assert(err, IsNil)
+	var changed map[string]time.Time
+	err = s.st.Get("refresh-inhibit-notice-snaps", &changed)
+	if errors.Is(err, state.ErrNoState) {
+		changed = make(map[string]time.Time)
+	} else {
+		c.Assert(err, IsNil)
+	}
+	changed[snapName] = s.st.Notice(id).LastRepeated()
+	s.st.Set("refresh-inhibit-notice-snaps", changed)
+}
+
+// noticesOf runs the given command with JSON output and returns the type and
+// key of each listed notice.
+func (s *noticesSuite) noticesOf(c *C, uid uint32, args ...string) []string {
+	stdout, stderr, _, err := ctlcmd.Run(s.mockContext, append(args, "--format=json"), uid, nil)
+	c.Assert(err, IsNil)
+	c.Check(string(stderr), Equals, "")
+
+	var notices []struct {
+		Type string `json:"type"`
+		Key  string `json:"key"`
+	}
+	c.Assert(json.Unmarshal(stdout, &notices), IsNil)
+	typeKeys := make([]string, 0, len(notices))
+	for _, n := range notices {
+		typeKeys = append(typeKeys, n.Type+":"+n.Key)
+	}
+	return typeKeys
+}
+
+func (s *noticesSuite) TestMissingContext(c *C) {
+	for _, cmd := range []string{"notices", "wait-notice"} {
+		_, _, _, err := ctlcmd.Run(nil, []string{cmd}, 0, nil)
+		c.Check(err, ErrorMatches, `cannot invoke snapctl operation commands \(here "`+cmd+`"\) from outside of a snap`)
+	}
+}
+
+func (s *noticesSuite) TestNotices(c *C) {
+	s.st.Lock()
+	// changes initiated by, listing or affecting the snap
+	chg1 := s.st.NewChange("snapctl-install", "...")
+	chg1.Set("initiated-by-snap", "snap1")
+	chg2 := s.st.NewChange("connect-snap", "...")
+	chg2.Set("snap-names", []string{"snap2", "snap1"})
+	chg3 := s.st.NewChange("refresh-snap", "...")
+	t := s.st.NewTask("link-snap", "...")
+	t.Set("snap-setup", &snapstate.SnapSetup{SideInfo: &snap.SideInfo{RealName: "snap1"}})
+	chg3.AddTask(t)
+	// changes concerning other snaps only
+	chg4 := s.st.NewChange("remove-snap", "...")
+	chg4.Set("snap-names", []string{"snap2"})
+	s.st.NewChange("other", "...")
+
+	s.addNotice(c, nil, state.SnapRunInhibitNotice, "snap2")
+	s.addNotice(c, nil, state.SnapRunInhibitNotice, "snap1")
+	s.addNotice(c, nil, state.SnapHealthNotice, "snap1")
+	s.addNotice(c, nil, state.SnapHealthNotice, "snap2")
+	s.addNotice(c, nil, state.RefreshInhibitNotice, "-")
+	s.addNotice(c, nil, state.WarningNotice, "danger")
+	uid := uint32(1000)
+	s.addNotice(c, &uid, state.SnapRunInhibitNotice, "snap1")
+	s.st.Unlock()
+
+	c.Check(s.noticesOf(c, 0, "notices"), DeepEquals, []string{
+		"change-update:" + chg1.ID(),
+		"change-update:" + chg2.ID(),
+		"change-update:" + chg3.ID(),
+		"snap-run-inhibit:snap1",
+		"snap-health:snap1",
+	})
+	// notices of the calling user are included
+	c.Check(s.noticesOf(c, 1000, "notices", "--type", "snap-run-inhibit"), DeepEquals, []string{
+		"snap-run-inhibit:snap1",
+		"snap-run-inhibit:snap1",
+	})
+	c.Check(s.noticesOf(c, 0, "notices", "--type", "change-update", "--key", chg2.ID()+","+chg3.ID()), DeepEquals, []string{
+		"change-update:" + chg2.ID(),
+		"change-update:" + chg3.ID(),
+	})
+
+	// refresh-inhibit notices concern the snaps whose inhibition changed
+	// with any of their occurrences
+	s.st.Lock()
+	s.addRefreshInhibitNotice(c, "snap2")
+	s.st.Unlock()
+
+	c.Check(s.noticesOf(c, 0, "notices", "--type", "refresh-inhibit"), HasLen, 0)
+
+	s.st.Lock()
+	s.addRefreshInhibitNotice(c, "snap1")
+	after := time.Now()
+	// the notice repeats for another snap only
+	s.addRefreshInhibitNotice(c, "snap2")
+	s.st.Unlock()
+
+	c.Check(s.noticesOf(c, 0, "notices", "--type", "refresh-inhibit"), DeepEquals, []string{
+		"refresh-inhibit:-",
+	})
+	// but the inhibition of the snap did not change after the given time
+	c.Check(s.noticesOf(c, 0, "notices", "--type", "refresh-inhibit", "--after", after.Format(time.RFC3339Nano)), HasLen, 0)
+}
+
+func (s *noticesSuite) TestNoticesAfter(c *C) {
+	s.st.Lock()
+	s.addNotice(c, nil, state.SnapHealthNotice, "snap1")
+	after := time.Now()
+	s.addNotice(c, nil, state.SnapRunInhibitNotice, "snap1")
+	s.st.Unlock()
+
+	c.Check(s.noticesOf(c, 0, "notices", "--after", after.Format(time.RFC3339Nano)), DeepEquals, []string{
+		"snap-run-inhibit:snap1",
+	})
+}
+
+func (s *noticesSuite) TestNoticesTable(c *C) {
+	stdout, _, _, err := ctlcmd.Run(s.mockContext, []string{"notices"}, 0, nil)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "")
+
+	s.st.Lock()
+	s.addNotice(c, nil, state.SnapHealthNotice, "snap1")
+	s.st.Unlock()
+
+	stdout, _, _, err = ctlcmd.Run(s.mockContext, []string{"notices"}, 0, nil)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Matches, `ID +Type +Key +Last-repeated\n1 +snap-health +snap1 +\d{4}-\d{2}-\d{2}T\S+Z\n`)
+}
+
+func (s *noticesSuite) TestNoticesBadArgs(c *C) {
+	for _, cmd := range []string{"notices", "wait-notice"} {
+		_, _, _, err := ctlcmd.Run(s.mockContext, []string{cmd, "--type", "foo"}, 0, nil)
+		c.Check(err, ErrorMatches, `invalid notice type "foo"`)
+		_, _, _, err = ctlcmd.Run(s.mockContext, []string{cmd, "--after", "yesterday"}, 0, nil)
+		c.Check(err, ErrorMatches, `invalid time "yesterday": .*`)
+	}
+	for _, timeout := range []string{"foo", "0s", "-1s", "2m"} {
+		_, _, _, err := ctlcmd.Run(s.mockContext, []string{"wait-notice", "--timeout=" + timeout}, 0, nil)
+		c.Check(err, ErrorMatches, `invalid timeout "`+timeout+`": .*`)
+	}
+}
+
+func (s *noticesSuite) TestWaitNotice(c *C) {
+	go func() {
+		// a notice concerning another snap does not end the wait
+		for _, key := range []string{"snap2", "snap1"} {
+			time.Sleep(10 * time.Millisecond)
+			s.st.Lock()
+			s.st.AddNotice(nil, state.SnapHealthNotice, key, nil)
+			s.st.Unlock()
+		}
+	}()
+
+	c.Check(s.noticesOf(c, 0, "wait-notice", "--timeout", "5s"), DeepEquals, []string{
+		"snap-health:snap1",
+	})
+}
+
+func (s *noticesSuite) TestWaitNoticeExisting(c *C) {
+	s.st.Lock()
+	before := time.Now()
+	s.addNotice(c, nil, state.SnapHealthNotice, "snap1")
+	s.st.Unlock()
+
+	c.Check(s.noticesOf(c, 0, "wait-notice", "--after", before.Format(time.RFC3339Nano)), DeepEquals, []string{
+		"snap-health:snap1",
+	})
+}
+
+func (s *noticesSuite) TestWaitNoticeTimeout(c *C) {
+	s.st.Lock()
+	s.addNotice(c, nil, state.SnapHealthNotice, "snap1")
+	s.st.Unlock()
+
+	// notices which occurred before the command was invoked are not waited for
+	_, _, _, err := ctlcmd.Run(s.mockContext, []string{"wait-notice", "--timeout", "10ms"}, 0, nil)
+	c.Check(err, ErrorMatches, `no notice concerning snap "snap1" within 10ms`)
+}
+
+func (s *noticesSuite) TestWaitNoticeRequestGone(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, _, err := ctlcmd.RunWithContext(ctx, s.mockContext, []string{"wait-notice"}, 0, nil)
+	c.Check(err, ErrorMatches, `cannot wait for notices: context canceled`)
+}
@@ -0,0 +1,196 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/configstate"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+	"github.com/snapcore/snapd/snap"
+)
+
+var (
+	shortTimerHelp = i18n.G("Manage the timers of the snap apps")
+	longTimerHelp  = i18n.G(`
+The timer command gets, sets or disables the schedule of the timer of the
+given app of the snap. A schedule set this way overrides the one declared in
+the snap and is kept across refreshes. If executed from the "configure" hook
+or "default-configure" hook, the timer will be updated after the hook finishes.
+
+$ snapctl timer get <app>
+$ snapctl timer set <app> <schedule>
+$ snapctl timer disable <app>`)
+
+	servicestateTimerControl = servicestate.TimerControl
+
+	timerControlChangeKind = swfeats.RegisterChangeKind("timer-control")
+)
+
+func init() {
+	addCommand("timer", shortTimerHelp, longTimerHelp, func() command {
+		cmd := &timerCommand{}
+		cmd.GetCmd.timer = cmd
+		cmd.SetCmd.timer = cmd
+		cmd.DisableCmd.timer = cmd
+		return cmd
+	})
+}
+
+type timerCommand struct {
+	baseCommand
+	GetCmd     TimerGetCmd     `command:"get" description:"print the schedule of a timer"`
+	SetCmd     TimerSetCmd     `command:"set" description:"set the schedule of a timer"`
+	DisableCmd TimerDisableCmd `command:"disable" description:"disable a timer"`
+}
+
+func (c *timerCommand) Execute([]string) error {
+	// This is needed in order to implement the interface, but it's never
+	// called.
+	return nil
+}
+
+type timerAppPositional struct {
+	App string `positional-arg-name:"<app>" required:"yes" description:"app with a timer"`
+}
+
+type TimerGetCmd struct {
+	Positional timerAppPositional `positional-args:"yes" required:"yes"`
+	timer      *timerCommand
+}
+
+type TimerSetCmd struct {
+	Positional struct {
+		App      string `positional-arg-name:"<app>" required:"yes" description:"app with a timer"`
+		Schedule string `positional-arg-name:"<schedule>" required:"yes" description:"timer schedule"`
+	} `positional-args:"yes" required:"yes"`
+	timer *timerCommand
+}
+
+type TimerDisableCmd struct {
+	Positional timerAppPositional `positional-args:"yes" required:"yes"`
+	timer      *timerCommand
+}
+
+// timerApp returns the app of the context snap with the given name, which
+// must have a timer.
+func timerApp(st *state.State, snapName, appName string) (*snap.AppInfo, error) {
+	info, err := snapstate.CurrentInfo(st, snapName)
+	if err != nil {
+		return nil, err
+	}
+	app, ok := info.Apps[appName]
+	if !ok {
+		return nil, fmt.Errorf("unknown app %q", appName)
+	}
+	if app.Timer == nil {
+		return nil, fmt.Errorf("app %q has no timer", appName)
+	}
+	return app, nil
+}
+
+func (t *TimerGetCmd) Execute([]string) error {
+	context, err := t.timer.ensureContext()
+	if err != nil {
+		return err
+	}
+
+	st := context.State()
+	st.Lock()
+	defer st.Unlock()
+
+	app, err := timerApp(st, context.InstanceName(), t.Positional.App)
+	if err != nil {
+		return err
+	}
+	schedule, disabled, err := servicestate.TimerSchedule(st, app)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		t.timer.printf("disabled\n")
+		return nil
+	}
+	t.timer.printf("%s\n", schedule)
+	return nil
+}
+
+func (t *TimerSetCmd) Execute([]string) error {
+	context, err := t.timer.ensureContext()
+	if err != nil {
+		return err
+	}
+	return runTimerCommand(context, t.Positional.App, t.Positional.Schedule, false)
+}
+
+func (t *TimerDisableCmd) Execute([]string) error {
+	context, err := t.timer.ensureContext()
+	if err != nil {
+		return err
+	}
+	return runTimerCommand(context, t.Positional.App, "", true)
+}
+
+func runTimerCommand(context *hookstate.Context, appName, schedule string, disable bool) error {
+	st := context.State()
+	st.Lock()
+	app, err := timerApp(st, context.InstanceName(), appName)
+	if err != nil {
+		st.Unlock()
+		return err
+	}
+	// passing context so we can ignore self-conflicts with the current change
+	ts, err := servicestateTimerControl(st, app, schedule, disable, context)
+	st.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if !context.IsEphemeral() {
+		// queue timer command for default-configure and configure hooks.
+		switch context.HookName() {
+		case "configure":
+			return queueCommand(context, []*state.TaskSet{ts})
+		case "default-configure":
+			return queueDefaultConfigureHookCommand(context, []*state.TaskSet{ts})
+		}
+	}
+
+	st.Lock()
+	chg := st.NewChange(timerControlChangeKind, fmt.Sprintf("Running timer command for snap %q", context.InstanceName()))
+	chg.AddAll(ts)
+	st.EnsureBefore(0)
+	st.Unlock()
+
+	select {
+	case <-chg.Ready():
+		st.Lock()
+		defer st.Unlock()
+		return chg.Err()
+	case <-time.After(configstate.ConfigureHookTimeout() / 2):
+		return fmt.Errorf("timer command is taking too long")
+	}
+}
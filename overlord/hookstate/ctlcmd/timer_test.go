@@ -0,0 +1,194 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd_test
+
+import (
+	"fmt"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/hookstate/ctlcmd"
+	"github.com/snapcore/snapd/overlord/hookstate/hooktest"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type timerSuite struct {
+	testutil.BaseTest
+	state       *state.State
+	mockContext *hookstate.Context
+	mockHandler *hooktest.MockHandler
+	chg         *state.Change
+}
+
+var _ = Suite(&timerSuite{})
+
+const timerSnapYaml = `name: snap1
+version: 1.0
+apps:
+  tick:
+    command: bin/tick
+    daemon: oneshot
+    timer: 10:00-12:00
+  user-tick:
+    command: bin/tick
+    daemon: oneshot
+    daemon-scope: user
+    timer: 10:00
+  svc:
+    command: bin/svc
+    daemon: simple
+`
+
+func (s *timerSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+
+	s.mockHandler = hooktest.NewMockHandler()
+
+	s.state = state.New(nil)
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	mockInstalledSnap(c, s.state, timerSnapYaml, "")
+
+	s.chg = s.state.NewChange("configure", "configure snap")
+	task := s.state.NewTask("run-hook", "run configure hook")
+	s.chg.AddTask(task)
+	setup := &hookstate.HookSetup{Snap: "snap1", Revision: snap.R(1), Hook: "configure"}
+	ctx, err := hookstate.NewContext(task, s.state, setup, s.mockHandler, "")
+	c.Assert(err, IsNil)
+	s.mockContext = ctx
+}
+
+func (s *timerSuite) TestMissingContext(c *C) {
+	for _, args := range [][]string{
+		{"timer", "get", "tick"},
+		{"timer", "set", "tick", "mon"},
+		{"timer", "disable", "tick"},
+	} {
+		_, _, _, err := ctlcmd.Run(nil, args, 0, nil)
+		c.Check(err, ErrorMatches, `cannot invoke snapctl operation commands \(here "timer"\) from outside of a snap`)
+	}
+}
+
+func (s *timerSuite) TestForbiddenForNonRoot(c *C) {
+	_, _, _, err := ctlcmd.Run(s.mockContext, []string{"timer", "get", "tick"}, 1000, nil)
+	c.Check(err, ErrorMatches, `cannot use "timer" with uid 1000, try with sudo`)
+}
+
+func (s *timerSuite) TestGet(c *C) {
+	stdout, stderr, _, err := ctlcmd.Run(s.mockContext, []string{"timer", "get", "tick"}, 0, nil)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "10:00-12:00\n")
+	c.Check(string(stderr), Equals, "")
+
+	s.state.Lock()
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "snap1", &snapst), IsNil)
+	snapst.TimerSchedulesByHooks = map[string]string{"tick": "mon,23:00"}
+	snapstate.Set(s.state, "snap1", &snapst)
+	s.state.Unlock()
+
+	stdout, _, _, err = ctlcmd.Run(s.mockContext, []string{"timer", "get", "tick"}, 0, nil)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "mon,23:00\n")
+
+	s.state.Lock()
+	snapst.ServicesDisabledByHooks = []string{"tick"}
+	snapstate.Set(s.state, "snap1", &snapst)
+	s.state.Unlock()
+
+	stdout, _, _, err = ctlcmd.Run(s.mockContext, []string{"timer", "get", "tick"}, 0, nil)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "disabled\n")
+}
+
+func (s *timerSuite) TestBadApp(c *C) {
+	for _, cmd := range []string{"get", "disable"} {
+		_, _, _, err := ctlcmd.Run(s.mockContext, []string{"timer", cmd, "foo"}, 0, nil)
+		c.Check(err, ErrorMatches, `unknown app "foo"`)
+		_, _, _, err = ctlcmd.Run(s.mockContext, []string{"timer", cmd, "svc"}, 0, nil)
+		c.Check(err, ErrorMatches, `app "svc" has no timer`)
+	}
+	_, _, _, err := ctlcmd.Run(s.mockContext, []string{"timer", "set", "svc", "mon"}, 0, nil)
+	c.Check(err, ErrorMatches, `app "svc" has no timer`)
+	_, _, _, err = ctlcmd.Run(s.mockContext, []string{"timer", "set", "user-tick", "mon"}, 0, nil)
+	c.Check(err, ErrorMatches, `cannot control the timer of user daemon "user-tick"`)
+}
+
+func (s *timerSuite) TestSetBadSchedule(c *C) {
+	_, _, _, err := ctlcmd.Run(s.mockContext, []string{"timer", "set", "tick", "bad-timer"}, 0, nil)
+	c.Check(err, ErrorMatches, `cannot use timer schedule "bad-timer": cannot parse "bad-timer": "bad" is not a valid weekday`)
+}
+
+func (s *timerSuite) TestSetQueuedInConfigureHook(c *C) {
+	_, _, _, err := ctlcmd.Run(s.mockContext, []string{"timer", "set", "tick", "mon,23:00"}, 0, nil)
+	c.Assert(err, IsNil)
+	_, _, _, err = ctlcmd.Run(s.mockContext, []string{"timer", "disable", "tick"}, 0, nil)
+	c.Assert(err, IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tasks := s.chg.Tasks()
+	c.Assert(tasks, HasLen, 3)
+	var actions []servicestate.TimerAction
+	for _, t := range tasks[1:] {
+		c.Check(t.Kind(), Equals, "timer-control")
+		var action servicestate.TimerAction
+		c.Assert(t.Get("timer-action", &action), IsNil)
+		actions = append(actions, action)
+	}
+	c.Check(actions, DeepEquals, []servicestate.TimerAction{
+		{SnapName: "snap1", App: "tick", Schedule: "mon,23:00"},
+		{SnapName: "snap1", App: "tick", Disable: true},
+	})
+	// the commands run after the hook and in the order they were issued
+	c.Check(tasks[1].WaitTasks(), DeepEquals, []*state.Task{tasks[0]})
+	c.Check(tasks[2].WaitTasks(), testutil.DeepContains, tasks[1])
+}
+
+func (s *timerSuite) TestSetEphemeralContext(c *C) {
+	var called int
+	restore := ctlcmd.MockServicestateTimerControlFunc(func(st *state.State, app *snap.AppInfo, schedule string, disable bool, context *hookstate.Context) (*state.TaskSet, error) {
+		called++
+		c.Check(app.Name, Equals, "tick")
+		c.Check(schedule, Equals, "")
+		c.Check(disable, Equals, true)
+		return nil, fmt.Errorf("forced error")
+	})
+	defer restore()
+
+	s.state.Lock()
+	context, err := hookstate.NewContext(nil, s.state, &hookstate.HookSetup{Snap: "snap1", Revision: snap.R(1)}, nil, "")
+	s.state.Unlock()
+	c.Assert(err, IsNil)
+
+	_, _, _, err = ctlcmd.Run(context, []string{"timer", "disable", "tick"}, 0, nil)
+	c.Check(err, ErrorMatches, "forced error")
+	c.Check(called, Equals, 1)
+}
@@ -33,7 +33,9 @@ import (
 type ListenerBackend = listenerBackend
 
 func MockListenerRegister(f func() (listenerBackend, error)) (restore func()) {
-	return testutil.Mock(&listenerRegister, f)
+	return testutil.Mock(&listenerRegister, func(prompting.PersonalFilesPlugsFunc) (listenerBackend, error) {
+		return f()
+	})
 }
 
 type fakeListener struct {
@@ -20,28 +20,41 @@
 package apparmorprompting
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/interfaces/prompting"
 	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
+	"github.com/snapcore/snapd/interfaces/prompting/requesthistory"
 	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
 	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/notices"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify/listener"
+	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/strutil"
 )
 
 var (
 	// Allow mocking the listener for tests
-	listenerRegister = func() (listenerBackend, error) {
-		return listener.Register(prompting.NewRequestFromListener)
+	listenerRegister = func(personalFilesPlugs prompting.PersonalFilesPlugsFunc) (listenerBackend, error) {
+		return listener.Register(prompting.NewRequestFromListenerFunc(personalFilesPlugs))
 	}
 )
 
+// TimeoutPolicyFunc returns the timeout policy for prompts for the given
+// interface.
+type TimeoutPolicyFunc func(iface string) (prompting.TimeoutPolicy, error)
+
+// HistorySizeFunc returns the maximum number of prompt outcomes which should
+// be kept in the history for each user. If it returns zero, the history is
+// disabled.
+type HistorySizeFunc func() (int, error)
+
 type listenerBackend interface {
 	Close() error
 	Run() error
@@ -62,6 +75,10 @@ type Manager interface {
 	RuleWithID(userID uint32, ruleID prompting.IDType) (*requestrules.Rule, error)
 	PatchRule(userID uint32, ruleID prompting.IDType, constraintsPatchJSON prompting.ConstraintsJSON) (*requestrules.Rule, error)
 	RemoveRule(userID uint32, ruleID prompting.IDType) (*requestrules.Rule, error)
+	ExportRules(userID uint32) (*requestrules.RuleSet, error)
+	ImportRules(userID uint32, ruleSet *requestrules.RuleSet, replace bool) ([]*requestrules.Rule, error)
+	History(userID uint32, filter *requesthistory.Filter) ([]*requesthistory.Entry, error)
+	ClearHistory(userID uint32) error
 }
 
 // verify that InterfacesRequestsManager implements Manager
@@ -77,6 +94,7 @@ type InterfacesRequestsManager struct {
 	listener listenerBackend
 	prompts  *requestprompts.PromptDB
 	rules    *requestrules.RuleDB
+	history  *requesthistory.History
 
 	// listenerAlreadySignalled is closed when the listener readiness is first
 	// observed. If there are still pending unreceived requests from outside
@@ -93,6 +111,13 @@ type InterfacesRequestsManager struct {
 	shutDownOnce      sync.Once
 
 	askRequests chan *prompting.Request
+
+	// configLock protects the functions used to look up prompting
+	// configuration, which are set after the manager is created.
+	configLock         sync.Mutex
+	timeoutPolicy      TimeoutPolicyFunc
+	historySize        HistorySizeFunc
+	personalFilesPlugs prompting.PersonalFilesPlugsFunc
 }
 
 func New(noticeMgr *notices.NoticeManager) (m *InterfacesRequestsManager, retErr error) {
@@ -105,7 +130,11 @@ func New(noticeMgr *notices.NoticeManager) (m *InterfacesRequestsManager, retErr
 		return nil, fmt.Errorf("cannot initialize prompting notice backend: %w", err)
 	}
 
-	listenerBackend, err := listenerRegister()
+	// The listener only delivers requests once it is run by the manager,
+	// so m is always set by the time the lookup is called.
+	listenerBackend, err := listenerRegister(func(instanceName string) []*snap.PlugInfo {
+		return m.connectedPersonalFilesPlugs(instanceName)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("cannot register prompting listener: %w", err)
 	}
@@ -135,6 +164,11 @@ func New(noticeMgr *notices.NoticeManager) (m *InterfacesRequestsManager, retErr
 		}
 	}()
 
+	history, err := requesthistory.New()
+	if err != nil {
+		return nil, fmt.Errorf("cannot open request history: %w", err)
+	}
+
 	// Now that all prompting managers were successfully initialized, register
 	// the notice backends with the state as notice providers.
 	if err = noticeBackends.registerWithManager(noticeMgr); err != nil {
@@ -146,6 +180,7 @@ func New(noticeMgr *notices.NoticeManager) (m *InterfacesRequestsManager, retErr
 		listener:                 listenerBackend,
 		prompts:                  promptsBackend,
 		rules:                    rulesBackend,
+		history:                  history,
 		listenerAlreadySignalled: make(chan struct{}),
 		snapdShuttingDown:        make(chan struct{}),
 		askRequests:              make(chan *prompting.Request),
@@ -255,6 +290,10 @@ func (m *InterfacesRequestsManager) handleRequest(req *prompting.Request) error
 		return req.Reply(nil)
 	}
 
+	// Look up the timeout policy before taking the lock, as doing so may
+	// require waiting on the state lock.
+	policy := m.timeoutPolicyFor(req.Interface)
+
 	// we're done with early checks, serious business starts now, and we can
 	// take the lock
 	m.lock.Lock()
@@ -300,13 +339,190 @@ func (m *InterfacesRequestsManager) handleRequest(req *prompting.Request) error
 
 	if merged {
 		logger.Debugf("new prompt merged with identical existing prompt: %+v", newPrompt)
-	} else {
-		logger.Debugf("adding prompt to internal storage: %+v", newPrompt)
+		return nil
+	}
+
+	logger.Debugf("adding prompt to internal storage: %+v", newPrompt)
+
+	if policy.Timeout > 0 {
+		user, promptID, timeoutDefault := req.UID, newPrompt.ID, policy.Default
+		callback := func() {
+			m.handlePromptTimeout(user, promptID, timeoutDefault)
+		}
+		if err := m.prompts.SetDecisionTimeout(user, promptID, policy.Timeout, callback); err != nil {
+			logger.Noticef("cannot set timeout for prompt %s: %v", promptID, err)
+		}
 	}
 
 	return nil
 }
 
+// SetTimeoutPolicyFunc sets the function used to look up the timeout policy
+// for prompts for a given interface. If it is never set, or it returns a
+// policy with a zero timeout, prompts are held until a client replies to them
+// or they expire.
+func (m *InterfacesRequestsManager) SetTimeoutPolicyFunc(f TimeoutPolicyFunc) {
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	m.timeoutPolicy = f
+}
+
+// SetHistorySizeFunc sets the function used to look up the maximum number of
+// prompt outcomes kept in the history for each user. If it is never set, the
+// history is disabled.
+func (m *InterfacesRequestsManager) SetHistorySizeFunc(f HistorySizeFunc) {
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	m.historySize = f
+}
+
+// SetPersonalFilesPlugsFunc sets the function used to look up the connected
+// personal-files plugs of a snap, so that requests for paths granted by them
+// are associated with the personal-files interface. If it is never set, such
+// requests are associated with the home interface.
+func (m *InterfacesRequestsManager) SetPersonalFilesPlugsFunc(f prompting.PersonalFilesPlugsFunc) {
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	m.personalFilesPlugs = f
+}
+
+func (m *InterfacesRequestsManager) connectedPersonalFilesPlugs(instanceName string) []*snap.PlugInfo {
+	m.configLock.Lock()
+	f := m.personalFilesPlugs
+	m.configLock.Unlock()
+	if f == nil {
+		return nil
+	}
+	return f(instanceName)
+}
+
+// currentHistorySize returns the maximum number of prompt outcomes which
+// should be kept in the history for each user, or zero if the history is
+// disabled.
+func (m *InterfacesRequestsManager) currentHistorySize() int {
+	m.configLock.Lock()
+	f := m.historySize
+	m.configLock.Unlock()
+	if f == nil {
+		return 0
+	}
+	size, err := f()
+	if err != nil {
+		logger.Noticef("cannot get prompt history size: %v", err)
+		return 0
+	}
+	return size
+}
+
+// recordHistory records the outcome of the given prompt for the given
+// permissions in the history, if the history is enabled.
+func (m *InterfacesRequestsManager) recordHistory(historySize int, userID uint32, prompt *requestprompts.Prompt, permissions []string, outcome prompting.OutcomeType, lifespan prompting.LifespanType, rule *requestrules.Rule, resolution string) {
+	entry := &requesthistory.Entry{
+		Timestamp:   time.Now(),
+		User:        userID,
+		Snap:        prompt.Snap,
+		Interface:   prompt.Interface,
+		Path:        prompt.Constraints.Path(),
+		Permissions: permissions,
+		Outcome:     outcome,
+		Lifespan:    lifespan,
+		Resolution:  resolution,
+	}
+	if rule != nil {
+		entry.RuleID = rule.ID
+	}
+	if err := m.history.Record(entry, historySize); err != nil {
+		logger.Noticef("cannot record outcome of prompt %s in history: %v", prompt.ID, err)
+	}
+}
+
+// timeoutPolicyFor returns the timeout policy for prompts for the given
+// interface. If the policy cannot be retrieved, prompts for the interface
+// have no timeout.
+func (m *InterfacesRequestsManager) timeoutPolicyFor(iface string) prompting.TimeoutPolicy {
+	m.configLock.Lock()
+	f := m.timeoutPolicy
+	m.configLock.Unlock()
+	if f == nil {
+		return prompting.TimeoutPolicy{}
+	}
+	policy, err := f(iface)
+	if err != nil {
+		logger.Noticef("cannot get prompt timeout policy for interface %q: %v", iface, err)
+		return prompting.TimeoutPolicy{}
+	}
+	return policy
+}
+
+// handlePromptTimeout resolves the prompt with the given ID according to the
+// given timeout default, since no client replied to it in time. If the
+// default is "allow-with-rule", a rule with lifespan "forever" is added for
+// the path and outstanding permissions of the prompt, and applied to other
+// outstanding prompts.
+func (m *InterfacesRequestsManager) handlePromptTimeout(userID uint32, promptID prompting.IDType, timeoutDefault prompting.TimeoutDefaultType) {
+	historySize := m.currentHistorySize()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	prompt, err := m.prompts.PromptWithID(userID, promptID, false)
+	if err != nil {
+		// The prompt was resolved in the meantime, or prompting was closed
+		logger.Debugf("cannot resolve prompt %s after timeout: %v", promptID, err)
+		return
+	}
+
+	var newRule *requestrules.Rule
+	if timeoutDefault == prompting.TimeoutDefaultAllowWithRule {
+		newRule, err = m.addRuleForTimedOutPrompt(userID, prompt)
+		if err != nil {
+			// Still allow the request, just without a rule
+			logger.Noticef("cannot add rule for prompt %s after timeout: %v", promptID, err)
+		}
+	}
+
+	if _, err := m.prompts.ResolveOnTimeout(userID, promptID, timeoutDefault); err != nil {
+		logger.Noticef("cannot resolve prompt %s after timeout: %v", promptID, err)
+		if newRule != nil {
+			m.rules.RemoveRule(userID, newRule.ID)
+		}
+		return
+	}
+
+	lifespan := prompting.LifespanSingle
+	if newRule != nil {
+		lifespan = prompting.LifespanForever
+	}
+	m.recordHistory(historySize, userID, prompt, prompt.Constraints.OutstandingPermissions(), timeoutDefault.Outcome(), lifespan, newRule, requesthistory.ResolutionTimeout)
+
+	if newRule != nil {
+		m.applyRuleToOutstandingPrompts(historySize, newRule)
+	}
+}
+
+// addRuleForTimedOutPrompt adds a rule with lifespan "forever" for the given
+// user which allows the outstanding permissions of the given prompt for its
+// path.
+func (m *InterfacesRequestsManager) addRuleForTimedOutPrompt(userID uint32, prompt *requestprompts.Prompt) (*requestrules.Rule, error) {
+	pathJSON, err := json.Marshal(prompt.Constraints.EscapedPath())
+	if err != nil {
+		return nil, err
+	}
+	permissionsJSON, err := json.Marshal(prompt.Constraints.OutstandingPermissions())
+	if err != nil {
+		return nil, err
+	}
+	constraintsJSON := prompting.ConstraintsJSON{
+		"path-pattern": pathJSON,
+		"permissions":  permissionsJSON,
+	}
+	constraints, err := prompting.UnmarshalReplyConstraints(prompt.Interface, prompting.OutcomeAllow, prompting.LifespanForever, "", constraintsJSON)
+	if err != nil {
+		return nil, err
+	}
+	return m.rules.AddRule(userID, prompt.Snap, prompt.Interface, constraints)
+}
+
 func (m *InterfacesRequestsManager) disconnect() error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -446,6 +662,10 @@ func (m *InterfacesRequestsManager) PromptWithID(userID uint32, promptID prompti
 func (m *InterfacesRequestsManager) HandleReply(userID uint32, promptID prompting.IDType, replyConstraintsJSON prompting.ConstraintsJSON, outcome prompting.OutcomeType, lifespan prompting.LifespanType, duration string, clientActivity bool) (satisfiedPromptIDs []prompting.IDType, retErr error) {
 	<-m.prompts.Ready()
 
+	// Look up the history size before taking the lock, as doing so may
+	// require waiting on the state lock.
+	historySize := m.currentHistorySize()
+
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
@@ -526,31 +746,79 @@ func (m *InterfacesRequestsManager) HandleReply(userID uint32, promptID promptin
 		return nil, retErr
 	}
 
+	m.recordHistory(historySize, userID, prompt, prompt.Constraints.OutstandingPermissions(), outcome, lifespan, newRule, requesthistory.ResolutionReplied)
+
 	if lifespan == prompting.LifespanSingle {
 		return []prompting.IDType{}, nil
 	}
 
 	// Apply new rule to outstanding prompts.
-	satisfiedPromptIDs = m.applyRuleToOutstandingPrompts(newRule)
+	satisfiedPromptIDs = m.applyRuleToOutstandingPrompts(historySize, newRule)
 
 	return satisfiedPromptIDs, nil
 }
 
-func (m *InterfacesRequestsManager) applyRuleToOutstandingPrompts(rule *requestrules.Rule) []prompting.IDType {
+// applyRuleToOutstandingPrompts checks the given rule against the outstanding
+// prompts of its user, resolving any prompts which it satisfies and recording
+// their outcome in the history, if the history is enabled.
+func (m *InterfacesRequestsManager) applyRuleToOutstandingPrompts(historySize int, rule *requestrules.Rule) []prompting.IDType {
 	metadata := &prompting.Metadata{
 		User:      rule.User,
 		Snap:      rule.Snap,
 		Interface: rule.Interface,
 	}
+	// The rule modifies the constraints of the prompts it applies to, so
+	// keep track of the permissions which were outstanding beforehand.
+	var outstanding map[prompting.IDType]*requestprompts.Prompt
+	var outstandingPerms map[prompting.IDType][]string
+	if historySize > 0 {
+		prompts, _ := m.prompts.Prompts(rule.User, false)
+		outstanding = make(map[prompting.IDType]*requestprompts.Prompt, len(prompts))
+		outstandingPerms = make(map[prompting.IDType][]string, len(prompts))
+		for _, prompt := range prompts {
+			outstanding[prompt.ID] = prompt
+			outstandingPerms[prompt.ID] = prompt.Constraints.OutstandingPermissions()
+		}
+	}
 	satisfiedPromptIDs, err := m.prompts.HandleNewRule(metadata, rule.Constraints)
 	if err != nil {
 		// The rule's constraints and outcome were already validated, so an
 		// error should not occur here unless the prompt DB was already closed.
 		logger.Noticef("error when handling new rule: %v", err)
 	}
+	for _, id := range satisfiedPromptIDs {
+		prompt, ok := outstanding[id]
+		if !ok {
+			continue
+		}
+		perms := outstandingPerms[id]
+		outcome, lifespan := ruleOutcomeForPermissions(rule, perms)
+		m.recordHistory(historySize, rule.User, prompt, perms, outcome, lifespan, rule, requesthistory.ResolutionRule)
+	}
 	return satisfiedPromptIDs
 }
 
+// ruleOutcomeForPermissions returns the outcome and lifespan with which the
+// given rule resolved a prompt for the given permissions. The prompt was
+// denied if the rule denies any of the permissions, otherwise the rule
+// allowed all of them.
+func ruleOutcomeForPermissions(rule *requestrules.Rule, permissions []string) (prompting.OutcomeType, prompting.LifespanType) {
+	var lifespan prompting.LifespanType
+	for _, perm := range permissions {
+		entry, ok := rule.Constraints.Permissions[perm]
+		if !ok {
+			continue
+		}
+		if entry.Outcome == prompting.OutcomeDeny {
+			return prompting.OutcomeDeny, entry.Lifespan
+		}
+		if lifespan == "" {
+			lifespan = entry.Lifespan
+		}
+	}
+	return prompting.OutcomeAllow, lifespan
+}
+
 // Rules returns all rules for the user with the given user ID and,
 // optionally, only those for the given snap and/or interface.
 func (m *InterfacesRequestsManager) Rules(userID uint32, snap string, iface string) ([]*requestrules.Rule, error) {
@@ -578,6 +846,10 @@ func (m *InterfacesRequestsManager) Rules(userID uint32, snap string, iface stri
 func (m *InterfacesRequestsManager) AddRule(userID uint32, snap string, iface string, constraintsJSON prompting.ConstraintsJSON) (*requestrules.Rule, error) {
 	<-m.prompts.Ready()
 
+	// Look up the history size before taking the lock, as doing so may
+	// require waiting on the state lock.
+	historySize := m.currentHistorySize()
+
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
@@ -590,7 +862,7 @@ func (m *InterfacesRequestsManager) AddRule(userID uint32, snap string, iface st
 	if err != nil {
 		return nil, err
 	}
-	m.applyRuleToOutstandingPrompts(newRule)
+	m.applyRuleToOutstandingPrompts(historySize, newRule)
 	return newRule, nil
 }
 
@@ -633,6 +905,10 @@ func (m *InterfacesRequestsManager) RuleWithID(userID uint32, ruleID prompting.I
 func (m *InterfacesRequestsManager) PatchRule(userID uint32, ruleID prompting.IDType, constraintsPatchJSON prompting.ConstraintsJSON) (*requestrules.Rule, error) {
 	<-m.prompts.Ready()
 
+	// Look up the history size before taking the lock, as doing so may
+	// require waiting on the state lock.
+	historySize := m.currentHistorySize()
+
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
@@ -653,7 +929,7 @@ func (m *InterfacesRequestsManager) PatchRule(userID uint32, ruleID prompting.ID
 		return nil, err
 	}
 	// Apply patched rule to outstanding prompts.
-	m.applyRuleToOutstandingPrompts(patchedRule)
+	m.applyRuleToOutstandingPrompts(historySize, patchedRule)
 	return patchedRule, nil
 }
 
@@ -669,3 +945,73 @@ func (m *InterfacesRequestsManager) RemoveRule(userID uint32, ruleID prompting.I
 	rule, err := m.rules.RemoveRule(userID, ruleID)
 	return rule, err
 }
+
+// ExportRules returns the rules of the user with the given user ID as a rule
+// set. Rules which apply to every user are exported when the user ID is
+// requestrules.SystemWideUser.
+func (m *InterfacesRequestsManager) ExportRules(userID uint32) (*requestrules.RuleSet, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.rules.ExportRules(userID), nil
+}
+
+// ImportRules imports the given rule set for the user with the given user ID,
+// or for every user if the user ID is requestrules.SystemWideUser. If replace
+// is true, the existing rules managed by the administrator for that user are
+// removed first.
+//
+// Rules imported for a particular user are checked against their outstanding
+// prompts, while system-wide rules only apply to subsequent requests.
+func (m *InterfacesRequestsManager) ImportRules(userID uint32, ruleSet *requestrules.RuleSet, replace bool) ([]*requestrules.Rule, error) {
+	<-m.prompts.Ready()
+
+	// Look up the history size before taking the lock, as doing so may
+	// require waiting on the state lock.
+	historySize := m.currentHistorySize()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	opts := &requestrules.ImportOptions{Replace: replace}
+	imported, err := m.rules.ImportRules(userID, ruleSet, opts)
+	if err != nil {
+		return nil, err
+	}
+	if userID != requestrules.SystemWideUser {
+		for _, rule := range imported {
+			m.applyRuleToOutstandingPrompts(historySize, rule)
+		}
+	}
+	return imported, nil
+}
+
+// History returns the recorded outcomes of prompts for the user with the
+// given user ID which match the given filter, oldest first.
+func (m *InterfacesRequestsManager) History(userID uint32, filter *requesthistory.Filter) ([]*requesthistory.Entry, error) {
+	return m.history.Entries(userID, filter), nil
+}
+
+// ClearHistory removes the recorded outcomes of prompts for the user with the
+// given user ID.
+func (m *InterfacesRequestsManager) ClearHistory(userID uint32) error {
+	return m.history.Clear(userID)
+}
+
+// TrimHistory discards the recorded outcomes of prompts which exceed the
+// current history size for each user, so that lowering the size, or
+// disabling the history altogether, takes effect on the outcomes which were
+// already recorded.
+func (m *InterfacesRequestsManager) TrimHistory() error {
+	m.configLock.Lock()
+	f := m.historySize
+	m.configLock.Unlock()
+	if f == nil {
+		return nil
+	}
+	size, err := f()
+	if err != nil {
+		return err
+	}
+	return m.history.Trim(size)
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/interfaces/prompting"
 	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
+	"github.com/snapcore/snapd/interfaces/prompting/requesthistory"
 	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
 	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
 	"github.com/snapcore/snapd/logger"
@@ -1439,6 +1440,309 @@ func (s *apparmorpromptingSuite) testReplyRuleHandlesFuturePrompts(c *C, outcome
 	c.Assert(mgr.Stop(), IsNil)
 }
 
+func (s *apparmorpromptingSuite) TestPromptTimeoutDeny(c *C) {
+	s.testPromptTimeout(c, prompting.TimeoutDefaultDeny, "deny", []string{})
+}
+
+func (s *apparmorpromptingSuite) TestPromptTimeoutAllowOnce(c *C) {
+	s.testPromptTimeout(c, prompting.TimeoutDefaultAllowOnce, "allow", []string{"read", "write"})
+}
+
+func (s *apparmorpromptingSuite) TestPromptTimeoutAllowWithRule(c *C) {
+	s.testPromptTimeout(c, prompting.TimeoutDefaultAllowWithRule, "allow", []string{"read", "write"})
+}
+
+func (s *apparmorpromptingSuite) testPromptTimeout(c *C, timeoutDefault prompting.TimeoutDefaultType, expectedOutcome string, expectedPerms []string) {
+	_, reqChan, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.noticeMgr)
+	c.Assert(err, IsNil)
+	defer mgr.Stop()
+
+	mgr.SetTimeoutPolicyFunc(func(iface string) (prompting.TimeoutPolicy, error) {
+		c.Check(iface, Equals, "home")
+		return prompting.TimeoutPolicy{
+			Timeout: time.Millisecond,
+			Default: timeoutDefault,
+		}, nil
+	})
+
+	whenSent := time.Now()
+	req, replyChan := requestWithReplyChan(&prompting.Request{
+		Path:        "/home/test/foo",
+		Permissions: []string{"read", "write"},
+	})
+	s.fillInPartialRequest(c, req)
+	reqChan <- req
+
+	// The prompt should be resolved without any client reply
+	select {
+	case allowedPerms := <-replyChan:
+		c.Check(allowedPerms, DeepEquals, expectedPerms)
+	case <-time.After(5 * time.Second):
+		c.Fatal("prompt was not resolved after timeout")
+	}
+
+	// The prompt has been removed, and the notice recorded, by the time the
+	// prompt DB lock can be taken again.
+	prompts, err := mgr.Prompts(s.defaultUser, false)
+	c.Assert(err, IsNil)
+	c.Check(prompts, HasLen, 0)
+
+	notices := s.noticeMgr.Notices(&state.NoticeFilter{
+		Types: []state.NoticeType{state.InterfacesRequestsPromptNotice},
+		After: whenSent,
+	})
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].LastData(), DeepEquals, map[string]string{
+		"resolved": "timeout",
+		"outcome":  expectedOutcome,
+		"reason":   string(timeoutDefault),
+	})
+
+	rules, err := mgr.Rules(s.defaultUser, "", "")
+	c.Assert(err, IsNil)
+	if timeoutDefault != prompting.TimeoutDefaultAllowWithRule {
+		c.Check(rules, HasLen, 0)
+		return
+	}
+	c.Assert(rules, HasLen, 1)
+	c.Check(rules[0].Snap, Equals, req.Snap)
+	c.Check(rules[0].Interface, Equals, "home")
+	c.Check(rules[0].Constraints.PathPattern().String(), Equals, "/home/test/foo")
+	for _, perm := range []string{"read", "write"} {
+		entry, ok := rules[0].Constraints.Permissions[perm]
+		c.Assert(ok, Equals, true)
+		c.Check(entry.Outcome, Equals, prompting.OutcomeAllow)
+		c.Check(entry.Lifespan, Equals, prompting.LifespanForever)
+	}
+
+	// Future requests for the same path are allowed by the new rule
+	req2, replyChan2 := requestWithReplyChan(&prompting.Request{
+		Path:        "/home/test/foo",
+		Permissions: []string{"read"},
+	})
+	s.fillInPartialRequest(c, req2)
+	reqChan <- req2
+	allowedPerms, err := waitForReply(replyChan2)
+	c.Assert(err, IsNil)
+	c.Check(allowedPerms, DeepEquals, []string{"read"})
+}
+
+func (s *apparmorpromptingSuite) TestPromptTimeoutNotConfigured(c *C) {
+	_, reqChan, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.noticeMgr)
+	c.Assert(err, IsNil)
+	defer mgr.Stop()
+
+	mgr.SetTimeoutPolicyFunc(func(iface string) (prompting.TimeoutPolicy, error) {
+		return prompting.TimeoutPolicy{}, fmt.Errorf("cannot get policy")
+	})
+
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	req, replyChan := requestWithReplyChan(&prompting.Request{})
+	s.fillInPartialRequest(c, req)
+	reqChan <- req
+
+	// The prompt is held until a client replies
+	_, err = waitForReply(replyChan)
+	c.Check(err, Equals, errNoReply)
+	prompts, err := mgr.Prompts(s.defaultUser, false)
+	c.Assert(err, IsNil)
+	c.Check(prompts, HasLen, 1)
+
+	logger.WithLoggerLock(func() {
+		c.Check(logbuf.String(), testutil.Contains, `cannot get prompt timeout policy for interface "home": cannot get policy`)
+	})
+}
+
+func (s *apparmorpromptingSuite) TestHistory(c *C) {
+	_, reqChan, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.noticeMgr)
+	c.Assert(err, IsNil)
+	defer mgr.Stop()
+
+	historySize := 0
+	mgr.SetHistorySizeFunc(func() (int, error) {
+		return historySize, nil
+	})
+	mgr.SetTimeoutPolicyFunc(func(iface string) (prompting.TimeoutPolicy, error) {
+		return prompting.TimeoutPolicy{}, nil
+	})
+
+	reply := func(path string, outcome prompting.OutcomeType, lifespan prompting.LifespanType) {
+		req, replyChan := requestWithReplyChan(&prompting.Request{Path: path})
+		_, prompt := s.simulateRequest(c, reqChan, mgr, req, false)
+		constraintsJSON := prompting.ConstraintsJSON{
+			"path-pattern": json.RawMessage(fmt.Sprintf("%q", path)),
+			"permissions":  json.RawMessage(`["read"]`),
+		}
+		_, err := mgr.HandleReply(s.defaultUser, prompt.ID, constraintsJSON, outcome, lifespan, "", true)
+		c.Assert(err, IsNil)
+		_, err = waitForReply(replyChan)
+		c.Assert(err, IsNil)
+	}
+
+	// History is disabled, so nothing is recorded
+	reply("/home/test/foo", prompting.OutcomeAllow, prompting.LifespanSingle)
+	entries, err := mgr.History(s.defaultUser, nil)
+	c.Assert(err, IsNil)
+	c.Check(entries, HasLen, 0)
+
+	historySize = 10
+	before := time.Now()
+	reply("/home/test/bar", prompting.OutcomeDeny, prompting.LifespanSingle)
+	reply("/home/test/baz", prompting.OutcomeAllow, prompting.LifespanForever)
+
+	entries, err = mgr.History(s.defaultUser, nil)
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 2)
+	c.Check(entries[0].Timestamp.After(before), Equals, true)
+	c.Check(entries[0].Snap, Equals, "firefox")
+	c.Check(entries[0].Interface, Equals, "home")
+	c.Check(entries[0].Path, Equals, "/home/test/bar")
+	c.Check(entries[0].Permissions, DeepEquals, []string{"read"})
+	c.Check(entries[0].Outcome, Equals, prompting.OutcomeDeny)
+	c.Check(entries[0].Lifespan, Equals, prompting.LifespanSingle)
+	c.Check(entries[0].RuleID, Equals, prompting.IDType(0))
+	c.Check(entries[0].Resolution, Equals, requesthistory.ResolutionReplied)
+
+	rules, err := mgr.Rules(s.defaultUser, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(rules, HasLen, 1)
+	c.Check(entries[1].Path, Equals, "/home/test/baz")
+	c.Check(entries[1].Outcome, Equals, prompting.OutcomeAllow)
+	c.Check(entries[1].Lifespan, Equals, prompting.LifespanForever)
+	c.Check(entries[1].RuleID, Equals, rules[0].ID)
+
+	// Prompts resolved after a timeout are recorded as well
+	mgr.SetTimeoutPolicyFunc(func(iface string) (prompting.TimeoutPolicy, error) {
+		return prompting.TimeoutPolicy{
+			Timeout: time.Millisecond,
+			Default: prompting.TimeoutDefaultAllowOnce,
+		}, nil
+	})
+	req, replyChan := requestWithReplyChan(&prompting.Request{Path: "/home/test/qux"})
+	s.fillInPartialRequest(c, req)
+	reqChan <- req
+	select {
+	case <-replyChan:
+	case <-time.After(5 * time.Second):
+		c.Fatal("prompt was not resolved after timeout")
+	}
+	// Wait until the timeout handler has finished
+	_, err = mgr.Rules(s.defaultUser, "", "")
+	c.Assert(err, IsNil)
+	entries, err = mgr.History(s.defaultUser, &requesthistory.Filter{Snap: "firefox"})
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 3)
+	c.Check(entries[2].Path, Equals, "/home/test/qux")
+	c.Check(entries[2].Outcome, Equals, prompting.OutcomeAllow)
+	c.Check(entries[2].Lifespan, Equals, prompting.LifespanSingle)
+	c.Check(entries[2].Resolution, Equals, requesthistory.ResolutionTimeout)
+
+	// Lowering the history size discards the oldest outcomes
+	historySize = 1
+	c.Assert(mgr.TrimHistory(), IsNil)
+	entries, err = mgr.History(s.defaultUser, nil)
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+	c.Check(entries[0].Path, Equals, "/home/test/qux")
+
+	// Disabling the history discards all outcomes
+	historySize = 0
+	c.Assert(mgr.TrimHistory(), IsNil)
+	entries, err = mgr.History(s.defaultUser, nil)
+	c.Assert(err, IsNil)
+	c.Check(entries, HasLen, 0)
+
+	historySize = 10
+	reply("/home/test/foo", prompting.OutcomeAllow, prompting.LifespanSingle)
+	c.Assert(mgr.ClearHistory(s.defaultUser), IsNil)
+	entries, err = mgr.History(s.defaultUser, nil)
+	c.Assert(err, IsNil)
+	c.Check(entries, HasLen, 0)
+}
+
+func (s *apparmorpromptingSuite) TestHistoryPromptsResolvedByRules(c *C) {
+	_, reqChan, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.noticeMgr)
+	c.Assert(err, IsNil)
+	defer mgr.Stop()
+
+	mgr.SetHistorySizeFunc(func() (int, error) {
+		return 10, nil
+	})
+
+	fooReq, fooReplyChan := requestWithReplyChan(&prompting.Request{Path: "/home/test/foo"})
+	_, fooPrompt := s.simulateRequest(c, reqChan, mgr, fooReq, false)
+	barReq, barReplyChan := requestWithReplyChan(&prompting.Request{Path: "/home/test/bar"})
+	s.simulateRequest(c, reqChan, mgr, barReq, false)
+	bazReq, bazReplyChan := requestWithReplyChan(&prompting.Request{Path: "/home/test/baz", Permissions: []string{"read", "write"}})
+	s.simulateRequest(c, reqChan, mgr, bazReq, false)
+
+	// A reply with a rule resolves other prompts matched by the rule
+	constraintsJSON := prompting.ConstraintsJSON{
+		"path-pattern": json.RawMessage(`"/home/test/{foo,bar}"`),
+		"permissions":  json.RawMessage(`["read"]`),
+	}
+	satisfied, err := mgr.HandleReply(s.defaultUser, fooPrompt.ID, constraintsJSON, prompting.OutcomeAllow, prompting.LifespanForever, "", true)
+	c.Assert(err, IsNil)
+	c.Check(satisfied, HasLen, 1)
+	for _, replyChan := range []chan []string{fooReplyChan, barReplyChan} {
+		_, err = waitForReply(replyChan)
+		c.Assert(err, IsNil)
+	}
+
+	// Adding a rule resolves the prompts it matches
+	ruleJSON := prompting.ConstraintsJSON{
+		"path-pattern": json.RawMessage(`"/home/test/baz"`),
+		"permissions":  json.RawMessage(`{"write":{"outcome":"deny","lifespan":"forever"}}`),
+	}
+	denyRule, err := mgr.AddRule(s.defaultUser, "firefox", "home", ruleJSON)
+	c.Assert(err, IsNil)
+	_, err = waitForReply(bazReplyChan)
+	c.Assert(err, IsNil)
+
+	rules, err := mgr.Rules(s.defaultUser, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(rules, HasLen, 2)
+	allowRule := rules[0]
+	if allowRule.ID == denyRule.ID {
+		allowRule = rules[1]
+	}
+
+	entries, err := mgr.History(s.defaultUser, nil)
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 3)
+	c.Check(entries[0].Path, Equals, "/home/test/foo")
+	c.Check(entries[0].Resolution, Equals, requesthistory.ResolutionReplied)
+	c.Check(entries[0].RuleID, Equals, allowRule.ID)
+
+	c.Check(entries[1].Path, Equals, "/home/test/bar")
+	c.Check(entries[1].Permissions, DeepEquals, []string{"read"})
+	c.Check(entries[1].Outcome, Equals, prompting.OutcomeAllow)
+	c.Check(entries[1].Lifespan, Equals, prompting.LifespanForever)
+	c.Check(entries[1].RuleID, Equals, allowRule.ID)
+	c.Check(entries[1].Resolution, Equals, requesthistory.ResolutionRule)
+
+	c.Check(entries[2].Path, Equals, "/home/test/baz")
+	c.Check(entries[2].Permissions, DeepEquals, []string{"read", "write"})
+	c.Check(entries[2].Outcome, Equals, prompting.OutcomeDeny)
+	c.Check(entries[2].Lifespan, Equals, prompting.LifespanForever)
+	c.Check(entries[2].RuleID, Equals, denyRule.ID)
+	c.Check(entries[2].Resolution, Equals, requesthistory.ResolutionRule)
+}
+
 func (s *apparmorpromptingSuite) TestRequestMerged(c *C) {
 	_, reqChan, restore := apparmorprompting.MockListener()
 	defer restore()
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/prompting"
 	"github.com/snapcore/snapd/overlord/ifacestate/apparmorprompting"
 	"github.com/snapcore/snapd/overlord/ifacestate/schema"
 	"github.com/snapcore/snapd/overlord/ifacestate/udevmonitor"
@@ -136,6 +137,10 @@ func MockCreateUDevMonitor(new func(udevmonitor.DeviceAddedFunc, udevmonitor.Dev
 	}
 }
 
+func MockInterfacesRequestsManagerTrimHistory(new func(m *apparmorprompting.InterfacesRequestsManager) error) (restore func()) {
+	return testutil.Mock(&interfacesRequestsManagerTrimHistory, new)
+}
+
 func MockCreateInterfacesRequestsManager(new func(noticeMgr *notices.NoticeManager) (*apparmorprompting.InterfacesRequestsManager, error)) (restore func()) {
 	return testutil.Mock(&createInterfacesRequestsManager, new)
 }
@@ -236,3 +241,15 @@ func (m *InterfaceManager) SetupSecurityByBackend(task *state.Task, appSets []*i
 func MockIsSnapVerified(new func(st *state.State, snapID string) bool) (restore func()) {
 	return testutil.Mock(&isSnapVerified, new)
 }
+
+func (m *InterfaceManager) PromptTimeoutPolicy(iface string) (prompting.TimeoutPolicy, error) {
+	return m.promptTimeoutPolicy(iface)
+}
+
+func (m *InterfaceManager) PromptHistorySize() (int, error) {
+	return m.promptHistorySize()
+}
+
+func (m *InterfaceManager) ConnectedPersonalFilesPlugs(instanceName string) []*snap.PlugInfo {
+	return m.connectedPersonalFilesPlugs(instanceName)
+}
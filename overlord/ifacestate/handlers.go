@@ -35,6 +35,7 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/builtin"
 	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
@@ -409,6 +410,12 @@ func (m *InterfaceManager) refreshAppSetConnections(task *state.Task, appSet *in
 		if err := snapshotChangedConnectionsForUndo(task, snapName, changedOrDroppedConns); err != nil {
 			return nil, nil, err
 		}
+		// unlike the snapshot for undo, which is kept by one of the
+		// setup-profiles tasks of the snap only, the original states
+		// are always recorded for refresh-connection
+		if err := recordOldConnections(task, changedOrDroppedConns); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	return disconnectedSnaps, reloadedConns, nil
@@ -983,6 +990,15 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) (err error)
 		policyChecker = policyCheck.check
 	}
 
+	if err := builtin.CheckServiceOrderingCycle(m.repo, plug, slot); err != nil {
+		if autoConnect {
+			// do not fail the change that triggered the auto-connection
+			task.Logf("cannot auto-connect %s to %s: %v", connRef.PlugRef, connRef.SlotRef, err)
+			return nil
+		}
+		return err
+	}
+
 	// static attributes of the plug and slot not provided, the ones from snap infos will be used
 	conn, err := m.repo.Connect(connRef, nil, plugDynamicAttrs, nil, slotDynamicAttrs, policyChecker)
 	if err != nil || conn == nil {
@@ -1901,6 +1917,16 @@ func (m *InterfaceManager) doAutoConnect(task *state.Task, _ *tomb.Tomb) error {
 		return err
 	}
 
+	// Recompute the attributes of the existing connections of the snap
+	// and let the snaps know if they changed when it was refreshed. This
+	// never happens when preseeding as there are no existing connections
+	// to begin with.
+	refreshts, err := m.refreshedConnectionsTasks(task, snapName, conns, newconns, conflictError)
+	if err != nil {
+		return err
+	}
+	autots.AddAll(refreshts)
+
 	// If interface hooks are not present then connects can be executed during
 	// preseeding.
 	// Otherwise we will run all connects, their hooks and setup-profiles after
@@ -2007,6 +2033,238 @@ func (m *InterfaceManager) doAutoDisconnect(task *state.Task, _ *tomb.Tomb) erro
 	return nil
 }
 
+// refreshedConnectionsTasks returns the tasks recomputing the attributes of
+// the existing connections of the given snap after it was refreshed, see
+// refreshConnectionTasks. Only the connections with a change-plug-<plug> or
+// change-slot-<slot> hook to learn about changed attributes are considered.
+func (m *InterfaceManager) refreshedConnectionsTasks(task *state.Task, snapName string, conns map[string]*schema.ConnState, newconns map[string]*interfaces.ConnRef, conflictError func(*state.Retry, error) error) (*state.TaskSet, error) {
+	st := task.State()
+
+	// setup-profiles already updated the static attributes of the
+	// connections, their original states were recorded
+	oldConns := make(map[string]*schema.ConnState)
+	for _, t := range task.Change().Tasks() {
+		if t.Kind() != "setup-profiles" {
+			continue
+		}
+		snapsup, err := snapstate.TaskSnapSetup(t)
+		if err != nil || snapsup.InstanceName() != snapName {
+			continue
+		}
+		var recorded map[string]*schema.ConnState
+		if err := t.Get("old-conns", &recorded); err != nil && !errors.Is(err, state.ErrNoState) {
+			return nil, err
+		}
+		for connID, connState := range recorded {
+			if oldConns[connID] == nil {
+				oldConns[connID] = connState
+			}
+		}
+	}
+
+	connIDs := make([]string, 0, len(conns))
+	for connID := range conns {
+		connIDs = append(connIDs, connID)
+	}
+	sort.Strings(connIDs)
+
+	tasks := state.NewTaskSet()
+	for _, connID := range connIDs {
+		connState := conns[connID]
+		if connState.Undesired || connState.HotplugGone || newconns[connID] != nil {
+			continue
+		}
+		connRef, err := interfaces.ParseConnRef(connID)
+		if err != nil {
+			return nil, err
+		}
+		if connRef.PlugRef.Snap != snapName && connRef.SlotRef.Snap != snapName {
+			continue
+		}
+		plug := m.repo.Plug(connRef.PlugRef.Snap, connRef.PlugRef.Name)
+		slot := m.repo.Slot(connRef.SlotRef.Snap, connRef.SlotRef.Name)
+		if plug == nil || slot == nil {
+			continue
+		}
+		if plug.Snap.Hooks["change-plug-"+plug.Name] == nil && slot.Snap.Hooks["change-slot-"+slot.Name] == nil {
+			continue
+		}
+		if err := checkAutoconnectConflicts(st, task, connRef.PlugRef.Snap, connRef.SlotRef.Snap); err != nil {
+			retry, _ := err.(*state.Retry)
+			return nil, conflictError(retry, err)
+		}
+		oldConn := oldConns[connID]
+		if oldConn == nil {
+			oldConn = connState
+		}
+		ts := refreshConnectionTasks(st, plug, slot, oldConn)
+		if lastTasks := tasks.Tasks(); len(lastTasks) > 0 {
+			ts.WaitFor(lastTasks[len(lastTasks)-1])
+		}
+		tasks.AddAll(ts)
+	}
+	return tasks, nil
+}
+
+// connAttrsChanged returns whether the static or dynamic attributes of the
+// plug or slot differ between the two states of a connection.
+func connAttrsChanged(oldConn, newConn *schema.ConnState) bool {
+	attrsEqual := func(a, b map[string]any) bool {
+		if len(a) == 0 && len(b) == 0 {
+			return true
+		}
+		return reflect.DeepEqual(a, b)
+	}
+	return !attrsEqual(oldConn.StaticPlugAttrs, newConn.StaticPlugAttrs) ||
+		!attrsEqual(oldConn.DynamicPlugAttrs, newConn.DynamicPlugAttrs) ||
+		!attrsEqual(oldConn.StaticSlotAttrs, newConn.StaticSlotAttrs) ||
+		!attrsEqual(oldConn.DynamicSlotAttrs, newConn.DynamicSlotAttrs)
+}
+
+// doRefreshConnection recomputes the attributes of an existing connection
+// with the dynamic attributes set by the prepare hooks and the
+// BeforeConnectPlug/Slot code of the interface, as when the connection is
+// made. If they differ from the original ones, the connection and the
+// security profiles of both snaps are updated and the change-slot-<slot> and
+// change-plug-<plug> hooks are run.
+func (m *InterfaceManager) doRefreshConnection(task *state.Task, _ *tomb.Tomb) error {
+	st := task.State()
+	st.Lock()
+	defer st.Unlock()
+
+	perfTimings := state.TimingsForTask(task)
+	defer perfTimings.Save(st)
+
+	plugRef, slotRef, err := getPlugAndSlotRefs(task)
+	if err != nil {
+		return err
+	}
+	connRef := &interfaces.ConnRef{PlugRef: plugRef, SlotRef: slotRef}
+
+	conns, err := getConns(st)
+	if err != nil {
+		return err
+	}
+	connState, ok := conns[connRef.ID()]
+	if !ok || connState.Undesired || connState.HotplugGone {
+		task.Logf("connection %s is gone, nothing to refresh", connRef)
+		return nil
+	}
+	var oldConn schema.ConnState
+	if err := task.Get("old-conn", &oldConn); err != nil {
+		return err
+	}
+
+	plugDynamicAttrs, slotDynamicAttrs, err := getDynamicHookAttributes(task)
+	if err != nil {
+		return fmt.Errorf("failed to get hook attributes: %s", err)
+	}
+
+	// the connection was already allowed when its snaps were set up, the
+	// policy is only given to run the BeforeConnectPlug/Slot code
+	alreadyAllowed := func(*interfaces.ConnectedPlug, *interfaces.ConnectedSlot) (bool, error) {
+		return true, nil
+	}
+	conn, err := m.repo.Connect(connRef, nil, plugDynamicAttrs, nil, slotDynamicAttrs, alreadyAllowed)
+	if err != nil {
+		return err
+	}
+	newConn := *connState
+	newConn.StaticPlugAttrs = conn.Plug.StaticAttrs()
+	newConn.DynamicPlugAttrs = conn.Plug.DynamicAttrs()
+	newConn.StaticSlotAttrs = conn.Slot.StaticAttrs()
+	newConn.DynamicSlotAttrs = conn.Slot.DynamicAttrs()
+	if !connAttrsChanged(&oldConn, &newConn) {
+		// nothing changed for the snaps to learn about
+		return nil
+	}
+
+	task.Set("undo-conn", connState)
+	conns[connRef.ID()] = &newConn
+	setConns(st, conns)
+	setDynamicHookAttributes(task, newConn.DynamicPlugAttrs, newConn.DynamicSlotAttrs)
+
+	if err := m.setupConnectedSnapsSecurity(task, connRef, perfTimings); err != nil {
+		return err
+	}
+
+	changedts, err := connectionChangedTasks(st, connRef, &oldConn, &newConn)
+	if err != nil {
+		return err
+	}
+	if len(changedts.Tasks()) > 0 {
+		snapstate.InjectTasks(task, changedts)
+		st.EnsureBefore(0)
+	}
+
+	task.SetStatus(state.DoneStatus)
+	return nil
+}
+
+func (m *InterfaceManager) undoRefreshConnection(task *state.Task, _ *tomb.Tomb) error {
+	st := task.State()
+	st.Lock()
+	defer st.Unlock()
+
+	perfTimings := state.TimingsForTask(task)
+	defer perfTimings.Save(st)
+
+	var undoConn schema.ConnState
+	if err := task.Get("undo-conn", &undoConn); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			// the connection was left alone
+			return nil
+		}
+		return err
+	}
+
+	plugRef, slotRef, err := getPlugAndSlotRefs(task)
+	if err != nil {
+		return err
+	}
+	connRef := &interfaces.ConnRef{PlugRef: plugRef, SlotRef: slotRef}
+
+	conns, err := getConns(st)
+	if err != nil {
+		return err
+	}
+	conns[connRef.ID()] = &undoConn
+	setConns(st, conns)
+
+	if _, err := m.repo.Connect(connRef, nil, undoConn.DynamicPlugAttrs, nil, undoConn.DynamicSlotAttrs, nil); err != nil {
+		return err
+	}
+	return m.setupConnectedSnapsSecurity(task, connRef, perfTimings)
+}
+
+// setupConnectedSnapsSecurity sets up the security of the snaps on both sides
+// of a connection.
+func (m *InterfaceManager) setupConnectedSnapsSecurity(task *state.Task, connRef *interfaces.ConnRef, tm timings.Measurer) error {
+	st := task.State()
+	for _, snapName := range []string{connRef.SlotRef.Snap, connRef.PlugRef.Snap} {
+		var snapst snapstate.SnapState
+		if err := snapstate.Get(st, snapName, &snapst); err != nil {
+			return err
+		}
+		snapInfo, err := snapst.CurrentInfo()
+		if err != nil {
+			return err
+		}
+		appSet, err := appSetForSnapRevision(st, snapInfo)
+		if err != nil {
+			return fmt.Errorf("building app set for snap %q: %v", snapName, err)
+		}
+		opts, err := m.buildConfinementOptions(st, task, snapInfo, snapst.Flags)
+		if err != nil {
+			return err
+		}
+		if err := m.setupSnapSecurity(task, appSet, opts, tm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (m *InterfaceManager) undoAutoConnect(task *state.Task, _ *tomb.Tomb) error {
 	// TODO Introduce disconnection hooks, and run them here as well to give a chance
 	// for the snap to undo whatever it did when the connection was established.
@@ -481,6 +481,32 @@ func snapshotChangedConnectionsForUndo(task *state.Task, instanceName string, ch
 	return nil
 }
 
+// recordOldConnections records on a setup-profiles task the original states of
+// the connections whose static attributes it changed, or that it dropped.
+func recordOldConnections(task *state.Task, changedConns map[string]*schema.ConnState) error {
+	if len(changedConns) == 0 {
+		return nil
+	}
+
+	var oldConns map[string]*schema.ConnState
+	err := task.Get("old-conns", &oldConns)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if oldConns == nil {
+		oldConns = make(map[string]*schema.ConnState)
+	}
+	for connID, connState := range changedConns {
+		// keep the first state when the task is retried
+		if oldConns[connID] == nil {
+			oldConns[connID] = connState
+		}
+	}
+	task.Set("old-conns", oldConns)
+
+	return nil
+}
+
 // restoreConnectionsForSetupProfiles restores connection states saved by
 // snapshotChangedConnectionsForUndo on a setup-profiles task.
 func restoreConnectionsForSetupProfiles(task *state.Task) error {
@@ -956,6 +982,9 @@ type autoConnectChecker struct {
 	cache                map[string]*asserts.SnapDeclaration
 	baseDecl             *asserts.BaseDeclaration
 	contentCompatEnabled bool
+
+	// trace, if set, records how the declarations were evaluated
+	trace *checkTrace
 }
 
 func newAutoConnectChecker(s *state.State, repo *interfaces.Repository, deviceCtx snapstate.DeviceContext) (*autoConnectChecker, error) {
@@ -1004,6 +1033,7 @@ func (c *autoConnectChecker) check(plug *interfaces.ConnectedPlug, slot *interfa
 		plugDecl, err = c.snapDeclaration(plug.Snap().SnapID)
 		if err != nil {
 			logger.Noticef("error: cannot find snap declaration for %q: %v", plug.Snap().InstanceName(), err)
+			c.trace.notChecked(fmt.Errorf("cannot find snap declaration for %q: %v", plug.Snap().InstanceName(), err))
 			return false, nil, nil
 		}
 	}
@@ -1014,6 +1044,7 @@ func (c *autoConnectChecker) check(plug *interfaces.ConnectedPlug, slot *interfa
 		slotDecl, err = c.snapDeclaration(slot.Snap().SnapID)
 		if err != nil {
 			logger.Noticef("error: cannot find snap declaration for %q: %v", slot.Snap().InstanceName(), err)
+			c.trace.notChecked(fmt.Errorf("cannot find snap declaration for %q: %v", slot.Snap().InstanceName(), err))
 			return false, nil, nil
 		}
 	}
@@ -1030,7 +1061,7 @@ func (c *autoConnectChecker) check(plug *interfaces.ConnectedPlug, slot *interfa
 		CompatEnabled:       allowCompatLabel(c.contentCompatEnabled, plug.Interface()),
 	}
 
-	arity, err := ic.CheckAutoConnect()
+	arity, err := c.trace.checkAutoConnect(&ic)
 	if DebugAutoConnectCheck != nil {
 		DebugAutoConnectCheck(&ic, arity, err)
 	}
@@ -1162,6 +1193,9 @@ type connectChecker struct {
 	deviceCtx            snapstate.DeviceContext
 	baseDecl             *asserts.BaseDeclaration
 	contentCompatEnabled bool
+
+	// trace, if set, records how the declarations were evaluated
+	trace *checkTrace
 }
 
 func newConnectChecker(s *state.State, deviceCtx snapstate.DeviceContext) (*connectChecker, error) {
@@ -1223,7 +1257,7 @@ func (c *connectChecker) check(plug *interfaces.ConnectedPlug, slot *interfaces.
 	// means they were installed with "dangerous", so the security
 	// check should be skipped at this point.
 	if plugDecl != nil && slotDecl != nil {
-		if err := ic.Check(); err != nil {
+		if err := c.trace.check(&ic); err != nil {
 			return false, err
 		}
 	}
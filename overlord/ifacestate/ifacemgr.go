@@ -22,13 +22,16 @@ package ifacestate
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/backends"
+	"github.com/snapcore/snapd/interfaces/prompting"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/ifacestate/apparmorprompting"
 	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
@@ -118,6 +121,7 @@ func Manager(s *state.State, hookManager *hookstate.HookManager, noticeManager *
 	addHandler("remove-profiles", m.doRemoveProfiles, m.doSetupProfiles)
 	addHandler("discard-conns", m.doDiscardConns, m.undoDiscardConns)
 	addHandler("auto-connect", m.doAutoConnect, m.undoAutoConnect)
+	addHandler("refresh-connection", m.doRefreshConnection, m.undoRefreshConnection)
 	addHandler("auto-disconnect", m.doAutoDisconnect, nil)
 	addHandler("hotplug-add-slot", m.doHotplugAddSlot, nil)
 	addHandler("hotplug-connect", m.doHotplugConnect, nil)
@@ -302,6 +306,8 @@ func (m *InterfaceManager) Ensure() error {
 		return nil
 	}
 
+	m.trimPromptHistory()
+
 	if m.udevMonitorDisabled {
 		return nil
 	}
@@ -330,6 +336,26 @@ func (m *InterfaceManager) Ensure() error {
 	return nil
 }
 
+// interfacesRequestsManagerTrimHistory calls TrimHistory on the given manager.
+var interfacesRequestsManagerTrimHistory = func(interfacesRequestsManager *apparmorprompting.InterfacesRequestsManager) error {
+	return interfacesRequestsManager.TrimHistory()
+}
+
+// trimPromptHistory discards the prompt outcomes exceeding the
+// configured history size, which may have been lowered since they were
+// recorded.
+func (m *InterfaceManager) trimPromptHistory() {
+	m.interfacesRequestsManagerMu.Lock()
+	interfacesRequestsManager := m.interfacesRequestsManager
+	m.interfacesRequestsManagerMu.Unlock()
+	if interfacesRequestsManager == nil {
+		return
+	}
+	if err := interfacesRequestsManagerTrimHistory(interfacesRequestsManager); err != nil {
+		logger.Noticef("cannot trim prompt history: %v", err)
+	}
+}
+
 // interfacesRequestsManagerShutDown calls shutdown on the given manager.
 var interfacesRequestsManagerShutDown = func(interfacesRequestsManager *apparmorprompting.InterfacesRequestsManager) {
 	interfacesRequestsManager.ShutDown()
@@ -654,10 +680,89 @@ func (m *InterfaceManager) initInterfacesRequestsManager() error {
 	if err != nil {
 		return err
 	}
+	if interfacesRequestsManager != nil {
+		interfacesRequestsManager.SetTimeoutPolicyFunc(m.promptTimeoutPolicy)
+		interfacesRequestsManager.SetHistorySizeFunc(m.promptHistorySize)
+		interfacesRequestsManager.SetPersonalFilesPlugsFunc(m.connectedPersonalFilesPlugs)
+	}
 	m.interfacesRequestsManager = interfacesRequestsManager
 	return nil
 }
 
+// promptTimeoutPolicy returns the timeout policy for prompts for the given
+// interface, as configured by the core.interface.<iface>.prompt-timeout and
+// core.interface.<iface>.prompt-default options.
+func (m *InterfaceManager) promptTimeoutPolicy(iface string) (prompting.TimeoutPolicy, error) {
+	m.state.Lock()
+	defer m.state.Unlock()
+	tr := config.NewTransaction(m.state)
+
+	timeoutStr, err := getPromptOptionAsString(tr, iface, "prompt-timeout")
+	if err != nil {
+		return prompting.TimeoutPolicy{}, err
+	}
+	defaultStr, err := getPromptOptionAsString(tr, iface, "prompt-default")
+	if err != nil {
+		return prompting.TimeoutPolicy{}, err
+	}
+
+	timeout, err := prompting.ParseTimeout(timeoutStr)
+	if err != nil {
+		return prompting.TimeoutPolicy{}, err
+	}
+	timeoutDefault, err := prompting.ParseTimeoutDefault(defaultStr)
+	if err != nil {
+		return prompting.TimeoutPolicy{}, err
+	}
+	return prompting.TimeoutPolicy{
+		Timeout: timeout,
+		Default: timeoutDefault,
+	}, nil
+}
+
+// promptHistorySize returns the maximum number of prompt outcomes kept in the
+// history for each user, as configured by the core.prompting.history-size
+// option. The history is disabled by default.
+func (m *InterfaceManager) promptHistorySize() (int, error) {
+	m.state.Lock()
+	defer m.state.Unlock()
+	tr := config.NewTransaction(m.state)
+
+	var value any = ""
+	err := tr.Get("core", "prompting.history-size", &value)
+	if err != nil && !config.IsNoOption(err) {
+		return 0, err
+	}
+	sizeStr := fmt.Sprintf("%v", value)
+	if sizeStr == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(sizeStr)
+}
+
+// connectedPersonalFilesPlugs returns the connected personal-files plugs of
+// the given snap instance.
+func (m *InterfaceManager) connectedPersonalFilesPlugs(instanceName string) []*snap.PlugInfo {
+	var plugs []*snap.PlugInfo
+	for _, plug := range m.repo.ConnectedPlugs(instanceName) {
+		if plug.Interface == "personal-files" {
+			plugs = append(plugs, plug)
+		}
+	}
+	return plugs
+}
+
+func getPromptOptionAsString(tr *config.Transaction, iface, option string) (string, error) {
+	// Values such as "0" are stored as numbers, so convert them as the
+	// configcore validation does.
+	var value any = ""
+	err := tr.Get("core", fmt.Sprintf("interface.%s.%s", iface, option), &value)
+	if err != nil && !config.IsNoOption(err) {
+		return "", err
+	}
+	return fmt.Sprintf("%v", value), nil
+}
+
 var securityBackendsOverride []interfaces.SecurityBackend
 
 // allSecurityBackends returns a set of the available security backends or the mocked ones, ready to be initialized.
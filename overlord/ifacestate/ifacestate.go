@@ -34,6 +34,7 @@ import (
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/ifacestate/schema"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/overlord/swfeats"
@@ -318,6 +319,115 @@ func initialConnectAttributes(st *state.State, plugSnapInfo *snap.Info, plugSnap
 	return plug.Attrs, slot.Attrs, nil
 }
 
+// connectionChangedTasks returns the tasks running the change-slot-<slot> and
+// change-plug-<plug> hooks, whichever are present, to let the snaps on both
+// sides of the connection know that its attributes changed from those of
+// oldConn to those of newConn, e.g. because one of the snaps was refreshed.
+func connectionChangedTasks(st *state.State, connRef *interfaces.ConnRef, oldConn, newConn *schema.ConnState) (*state.TaskSet, error) {
+	plugSnapInfo, err := snapstate.CurrentInfo(st, connRef.PlugRef.Snap)
+	if err != nil {
+		return nil, err
+	}
+	slotSnapInfo, err := snapstate.CurrentInfo(st, connRef.SlotRef.Snap)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := state.NewTaskSet()
+	var prev *state.Task
+	addHookTask := func(snapInfo *snap.Info, hookName string) {
+		if snapInfo.Hooks[hookName] == nil {
+			return
+		}
+		hookSetup := &hookstate.HookSetup{
+			Snap:     snapInfo.InstanceName(),
+			Hook:     hookName,
+			Optional: true,
+		}
+		summary := fmt.Sprintf(i18n.G("Run hook %s of snap %q"), hookSetup.Hook, hookSetup.Snap)
+		hookTask := hookstate.HookTask(st, summary, hookSetup, nil)
+		// There is no connect or disconnect task to carry the attributes
+		// here, so the hook task carries them itself. The attributes are
+		// read-only for the hooks, the connection being already updated.
+		hookTask.Set("plug", connRef.PlugRef)
+		hookTask.Set("slot", connRef.SlotRef)
+		hookTask.Set("plug-static", newConn.StaticPlugAttrs)
+		hookTask.Set("plug-dynamic", newConn.DynamicPlugAttrs)
+		hookTask.Set("slot-static", newConn.StaticSlotAttrs)
+		hookTask.Set("slot-dynamic", newConn.DynamicSlotAttrs)
+		hookTask.Set("old-plug-static", oldConn.StaticPlugAttrs)
+		hookTask.Set("old-plug-dynamic", oldConn.DynamicPlugAttrs)
+		hookTask.Set("old-slot-static", oldConn.StaticSlotAttrs)
+		hookTask.Set("old-slot-dynamic", oldConn.DynamicSlotAttrs)
+		hookTask.Set("hook-context", map[string]any{"attrs-task": hookTask.ID()})
+		if prev != nil {
+			hookTask.WaitFor(prev)
+		}
+		tasks.AddTask(hookTask)
+		prev = hookTask
+	}
+
+	addHookTask(slotSnapInfo, "change-slot-"+connRef.SlotRef.Name)
+	addHookTask(plugSnapInfo, "change-plug-"+connRef.PlugRef.Name)
+	return tasks, nil
+}
+
+// refreshConnectionTasks returns the tasks recomputing the attributes of an
+// existing connection the same way they are computed when it is made:
+//   - prepare-plug-<plug> hook
+//   - prepare-slot-<slot> hook
+//   - refresh-connection task
+//
+// The refresh-connection task compares the recomputed attributes with those
+// of oldConn and, if they differ, updates the connection and runs the
+// change-slot-<slot> and change-plug-<plug> hooks (see connectionChangedTasks).
+func refreshConnectionTasks(st *state.State, plug *snap.PlugInfo, slot *snap.SlotInfo, oldConn *schema.ConnState) *state.TaskSet {
+	plugSnap, slotSnap := plug.Snap.InstanceName(), slot.Snap.InstanceName()
+	refreshConnection := st.NewTask("refresh-connection", fmt.Sprintf(i18n.G("Refresh connection of %s:%s to %s:%s"), plugSnap, plug.Name, slotSnap, slot.Name))
+	initialContext := map[string]any{"attrs-task": refreshConnection.ID()}
+
+	tasks := state.NewTaskSet()
+	var prev *state.Task
+	addTask := func(t *state.Task) {
+		if prev != nil {
+			t.WaitFor(prev)
+		}
+		tasks.AddTask(t)
+		prev = t
+	}
+
+	// the prepare hooks are not undone, the connection stays in place
+	// if the refresh fails
+	addHookTask := func(snapInfo *snap.Info, hookName string) {
+		if snapInfo.Hooks[hookName] == nil {
+			return
+		}
+		hookSetup := &hookstate.HookSetup{
+			Snap:     snapInfo.InstanceName(),
+			Hook:     hookName,
+			Optional: true,
+		}
+		summary := fmt.Sprintf(i18n.G("Run hook %s of snap %q"), hookSetup.Hook, hookSetup.Snap)
+		addTask(hookstate.HookTask(st, summary, hookSetup, initialContext))
+	}
+	addHookTask(plug.Snap, "prepare-plug-"+plug.Name)
+	addHookTask(slot.Snap, "prepare-slot-"+slot.Name)
+
+	refreshConnection.Set("plug", interfaces.PlugRef{Snap: plugSnap, Name: plug.Name})
+	refreshConnection.Set("slot", interfaces.SlotRef{Snap: slotSnap, Name: slot.Name})
+	// as in connect, the hooks start from the static attributes and
+	// compute the dynamic ones from scratch
+	emptyDynamicAttrs := map[string]any{}
+	refreshConnection.Set("plug-static", plug.Attrs)
+	refreshConnection.Set("slot-static", slot.Attrs)
+	refreshConnection.Set("plug-dynamic", emptyDynamicAttrs)
+	refreshConnection.Set("slot-dynamic", emptyDynamicAttrs)
+	refreshConnection.Set("old-conn", oldConn)
+	addTask(refreshConnection)
+
+	return tasks
+}
+
 // Disconnect returns a set of tasks for disconnecting an interface.
 func Disconnect(st *state.State, conn *interfaces.Connection) (*state.TaskSet, error) {
 	plugSnap := conn.Plug.Snap().InstanceName()
@@ -559,6 +669,7 @@ func delayedCrossMgrInit() {
 		// hook into conflict checks mechanisms
 		snapstate.RegisterAffectedSnapsByKind("connect", connectDisconnectAffectedSnaps)
 		snapstate.RegisterAffectedSnapsByKind("disconnect", connectDisconnectAffectedSnaps)
+		snapstate.RegisterAffectedSnapsByKind("refresh-connection", connectDisconnectAffectedSnaps)
 
 		// hook into snap linking/unlinking and activation state changes
 		snapstate.AddLinkSnapParticipant(snapstate.LinkSnapParticipantFunc(OnSnapLinkageChanged))
@@ -41,6 +41,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/builtin"
 	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/interfaces/prompting"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
@@ -4562,8 +4563,15 @@ slots:
 	if byGadget {
 		expectedConns["test-consumer:test test-producer:test"].(map[string]any)["by-gadget"] = true
 	}
+	var oldConns map[string]any
+	err := task.Get("old-conns", &oldConns)
 	if shouldUpdate {
+		// the original state is kept for refresh-connection
+		c.Assert(err, IsNil)
+		c.Check(oldConns, DeepEquals, expectedConns)
 		expectedConns["test-consumer:test test-producer:test"].(map[string]any)["plug-static"].(map[string]any)["test-update"] = testUpdateVal
+	} else {
+		c.Check(err, testutil.ErrorIs, state.ErrNoState)
 	}
 	c.Check(conns, DeepEquals, expectedConns)
 }
@@ -5528,6 +5536,186 @@ func (s *interfaceManagerSuite) TestAutoConnectSetupSecurityForConnectedSlots(c
 	c.Check(s.secBackend.SetupCalls[1].AppSet.Info().Revision, Equals, coreSnapInfo.Revision)
 }
 
+func (s *interfaceManagerSuite) mockRefreshedConnection(c *C, oldConns map[string]any) (chg *state.Change, autoConnect *state.Task) {
+	s.MockModel(c, nil)
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"})
+
+	s.mockSnap(c, consumerYaml+" change-plug-plug:\n")
+	s.mockSnap(c, producerYaml+"  change-slot-slot:\n")
+	s.manager(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// the producer was refreshed, setup-profiles updated the static
+	// attributes of the existing connection
+	s.state.Set("conns", map[string]any{
+		"consumer:plug producer:slot": map[string]any{
+			"interface":   "test",
+			"plug-static": map[string]any{"attr1": "value1"},
+			"slot-static": map[string]any{"attr2": "value2"},
+		},
+	})
+	chg = s.state.NewChange("refresh-snap", "...")
+	snapsup := &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{
+			RealName: "producer",
+			Revision: snap.R(1),
+		},
+	}
+	setupProfiles := s.state.NewTask("setup-profiles", "...")
+	setupProfiles.Set("snap-setup", snapsup)
+	if oldConns != nil {
+		setupProfiles.Set("old-conns", oldConns)
+	}
+	setupProfiles.SetStatus(state.DoneStatus)
+	chg.AddTask(setupProfiles)
+	autoConnect = s.state.NewTask("auto-connect", "...")
+	autoConnect.Set("snap-setup", snapsup)
+	autoConnect.WaitFor(setupProfiles)
+	chg.AddTask(autoConnect)
+
+	s.state.Unlock()
+	s.se.Ensure()
+	s.se.Wait()
+	s.state.Lock()
+
+	c.Assert(autoConnect.Status(), Equals, state.DoneStatus)
+	return chg, autoConnect
+}
+
+func (s *interfaceManagerSuite) TestAutoConnectRefreshesExistingConnections(c *C) {
+	chg, autoConnect := s.mockRefreshedConnection(c, map[string]any{
+		"consumer:plug producer:slot": map[string]any{
+			"interface":   "test",
+			"plug-static": map[string]any{"attr1": "value1"},
+			"slot-static": map[string]any{"attr2": "value2-old"},
+		},
+	})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// setup-profiles is always injected by auto-connect, the attributes
+	// of the existing connection are recomputed like when connecting
+	tasks := chg.Tasks()
+	c.Assert(tasks, HasLen, 6)
+	c.Check(tasks[2].Kind(), Equals, "setup-profiles")
+	preparePlug, prepareSlot, refreshConn := tasks[3], tasks[4], tasks[5]
+	c.Assert(refreshConn.Kind(), Equals, "refresh-connection")
+	for i, expected := range []hookstate.HookSetup{
+		{Snap: "consumer", Hook: "prepare-plug-plug", Optional: true},
+		{Snap: "producer", Hook: "prepare-slot-slot", Optional: true},
+	} {
+		t := tasks[3+i]
+		c.Assert(t.Kind(), Equals, "run-hook")
+		var hs hookstate.HookSetup
+		c.Assert(t.Get("hook-setup", &hs), IsNil)
+		c.Check(hs, Equals, expected)
+		var hookContext map[string]any
+		c.Assert(t.Get("hook-context", &hookContext), IsNil)
+		c.Check(hookContext, DeepEquals, map[string]any{"attrs-task": refreshConn.ID()})
+	}
+	c.Check(preparePlug.WaitTasks(), testutil.Contains, autoConnect)
+	c.Check(prepareSlot.WaitTasks(), testutil.Contains, preparePlug)
+	c.Check(refreshConn.WaitTasks(), testutil.Contains, prepareSlot)
+	var staticAttrs, dynamicAttrs map[string]any
+	c.Assert(refreshConn.Get("slot-static", &staticAttrs), IsNil)
+	c.Check(staticAttrs, DeepEquals, map[string]any{"attr2": "value2"})
+	c.Assert(refreshConn.Get("slot-dynamic", &dynamicAttrs), IsNil)
+	c.Check(dynamicAttrs, HasLen, 0)
+
+	// the prepare-slot hook sets a dynamic attribute
+	refreshConn.Set("slot-dynamic", map[string]any{"dyn": "value"})
+	preparePlug.SetStatus(state.DoneStatus)
+	prepareSlot.SetStatus(state.DoneStatus)
+
+	// setup-profiles and refresh-connection cannot run together
+	s.state.Unlock()
+	for i := 0; i < 2; i++ {
+		s.se.Ensure()
+		s.se.Wait()
+	}
+	s.state.Lock()
+
+	c.Assert(refreshConn.Status(), Equals, state.DoneStatus)
+	var conns map[string]any
+	c.Assert(s.state.Get("conns", &conns), IsNil)
+	c.Check(conns, DeepEquals, map[string]any{
+		"consumer:plug producer:slot": map[string]any{
+			"interface":    "test",
+			"plug-static":  map[string]any{"attr1": "value1"},
+			"slot-static":  map[string]any{"attr2": "value2"},
+			"slot-dynamic": map[string]any{"dyn": "value"},
+		},
+	})
+	conn, err := s.manager(c).Repository().Connection(&interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+	})
+	c.Assert(err, IsNil)
+	c.Check(conn.Slot.DynamicAttrs(), DeepEquals, map[string]any{"dyn": "value"})
+
+	// the snaps are told about the changed attributes
+	tasks = chg.Tasks()
+	c.Assert(tasks, HasLen, 8)
+	slotHook, plugHook := tasks[6], tasks[7]
+	for i, expected := range []hookstate.HookSetup{
+		{Snap: "producer", Hook: "change-slot-slot", Optional: true},
+		{Snap: "consumer", Hook: "change-plug-plug", Optional: true},
+	} {
+		t := tasks[6+i]
+		c.Assert(t.Kind(), Equals, "run-hook")
+		var hs hookstate.HookSetup
+		c.Assert(t.Get("hook-setup", &hs), IsNil)
+		c.Check(hs, Equals, expected)
+
+		// the attributes are carried by the hook task itself
+		var hookContext map[string]any
+		c.Assert(t.Get("hook-context", &hookContext), IsNil)
+		c.Check(hookContext, DeepEquals, map[string]any{"attrs-task": t.ID()})
+		var staticAttrs, oldStaticAttrs, dynamicAttrs, oldDynamicAttrs map[string]any
+		c.Assert(t.Get("slot-static", &staticAttrs), IsNil)
+		c.Check(staticAttrs, DeepEquals, map[string]any{"attr2": "value2"})
+		c.Assert(t.Get("old-slot-static", &oldStaticAttrs), IsNil)
+		c.Check(oldStaticAttrs, DeepEquals, map[string]any{"attr2": "value2-old"})
+		c.Assert(t.Get("slot-dynamic", &dynamicAttrs), IsNil)
+		c.Check(dynamicAttrs, DeepEquals, map[string]any{"dyn": "value"})
+		c.Assert(t.Get("old-slot-dynamic", &oldDynamicAttrs), IsNil)
+		c.Check(oldDynamicAttrs, HasLen, 0)
+	}
+	c.Check(plugHook.WaitTasks(), testutil.Contains, slotHook)
+	c.Check(slotHook.WaitTasks(), testutil.Contains, refreshConn)
+}
+
+func (s *interfaceManagerSuite) TestAutoConnectRefreshesExistingConnectionsUnchanged(c *C) {
+	chg, _ := s.mockRefreshedConnection(c, nil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tasks := chg.Tasks()
+	c.Assert(tasks, HasLen, 6)
+	refreshConn := tasks[5]
+	c.Assert(refreshConn.Kind(), Equals, "refresh-connection")
+	tasks[3].SetStatus(state.DoneStatus)
+	tasks[4].SetStatus(state.DoneStatus)
+
+	s.state.Unlock()
+	for i := 0; i < 2; i++ {
+		s.se.Ensure()
+		s.se.Wait()
+	}
+	s.state.Lock()
+
+	// the prepare hooks computed the same attributes, there is nothing
+	// to tell the snaps about
+	c.Assert(refreshConn.Status(), Equals, state.DoneStatus)
+	c.Check(chg.Tasks(), HasLen, 6)
+	var undoConn map[string]any
+	c.Check(refreshConn.Get("undo-conn", &undoConn), testutil.ErrorIs, state.ErrNoState)
+}
+
 // auto-connect needs to setup security for connected slots after autoconnection
 func (s *interfaceManagerSuite) TestAutoConnectSetupSecurityOnceWithMultiplePlugs(c *C) {
 	s.MockModel(c, nil)
@@ -7991,6 +8179,171 @@ func (s *interfaceManagerSuite) TestInitInterfacesRequestsManagerError(c *C) {
 	c.Check(warns[0].String(), Matches, fmt.Sprintf(`cannot start prompting backend: %v; prompting will be inactive until snapd is restarted`, createError))
 }
 
+func (s *interfaceManagerSuite) TestPromptTimeoutPolicy(c *C) {
+	mgr := s.manager(c)
+
+	// No timeout configured
+	policy, err := mgr.PromptTimeoutPolicy("home")
+	c.Assert(err, IsNil)
+	c.Check(policy, Equals, prompting.TimeoutPolicy{Default: prompting.TimeoutDefaultDeny})
+
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "interface.home.prompt-timeout", "30s"), IsNil)
+	c.Assert(tr.Set("core", "interface.home.prompt-default", "allow-with-rule"), IsNil)
+	c.Assert(tr.Set("core", "interface.camera.prompt-timeout", "1m"), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	policy, err = mgr.PromptTimeoutPolicy("home")
+	c.Assert(err, IsNil)
+	c.Check(policy, Equals, prompting.TimeoutPolicy{
+		Timeout: 30 * time.Second,
+		Default: prompting.TimeoutDefaultAllowWithRule,
+	})
+
+	policy, err = mgr.PromptTimeoutPolicy("camera")
+	c.Assert(err, IsNil)
+	c.Check(policy, Equals, prompting.TimeoutPolicy{
+		Timeout: time.Minute,
+		Default: prompting.TimeoutDefaultDeny,
+	})
+
+	// Numeric values are accepted as well
+	s.state.Lock()
+	tr = config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "interface.camera.prompt-timeout", 0), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	policy, err = mgr.PromptTimeoutPolicy("camera")
+	c.Assert(err, IsNil)
+	c.Check(policy, Equals, prompting.TimeoutPolicy{Default: prompting.TimeoutDefaultDeny})
+
+	s.state.Lock()
+	tr = config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "interface.home.prompt-default", "foo"), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	_, err = mgr.PromptTimeoutPolicy("home")
+	c.Check(err, ErrorMatches, `invalid prompt timeout default "foo": .*`)
+}
+
+func (s *interfaceManagerSuite) TestPromptHistorySize(c *C) {
+	mgr := s.manager(c)
+
+	// History is disabled by default
+	size, err := mgr.PromptHistorySize()
+	c.Assert(err, IsNil)
+	c.Check(size, Equals, 0)
+
+	for _, value := range []any{500, "500"} {
+		s.state.Lock()
+		tr := config.NewTransaction(s.state)
+		c.Assert(tr.Set("core", "prompting.history-size", value), IsNil)
+		tr.Commit()
+		s.state.Unlock()
+
+		size, err = mgr.PromptHistorySize()
+		c.Assert(err, IsNil)
+		c.Check(size, Equals, 500)
+	}
+}
+
+func (s *interfaceManagerSuite) TestConnectedPersonalFilesPlugs(c *C) {
+	mgr := s.manager(c)
+	repo := mgr.Repository()
+
+	for _, name := range []string{"personal-files", "test"} {
+		if repo.Interface(name) == nil {
+			c.Assert(repo.AddInterface(&ifacetest.TestInterface{InterfaceName: name}), IsNil)
+		}
+	}
+	siC := s.mockAppSet(c, `name: consumer
+version: 1
+plugs:
+ config:
+  interface: personal-files
+  read: [$HOME/.config/consumer]
+ data:
+  interface: personal-files
+  write: [$HOME/.local/share/consumer]
+ plug:
+  interface: test
+`)
+	siP := s.mockAppSet(c, `name: producer
+version: 1
+slots:
+ personal-files:
+ slot:
+  interface: test
+`)
+	c.Assert(repo.AddAppSet(siC), IsNil)
+	c.Assert(repo.AddAppSet(siP), IsNil)
+
+	c.Check(mgr.ConnectedPersonalFilesPlugs("consumer"), HasLen, 0)
+
+	for _, plug := range []string{"config", "plug"} {
+		slot := "slot"
+		if plug == "config" {
+			slot = "personal-files"
+		}
+		_, err := repo.Connect(&interfaces.ConnRef{
+			PlugRef: interfaces.PlugRef{Snap: "consumer", Name: plug},
+			SlotRef: interfaces.SlotRef{Snap: "producer", Name: slot},
+		}, nil, nil, nil, nil, nil)
+		c.Assert(err, IsNil)
+	}
+
+	// only the connected personal-files plug is returned
+	plugs := mgr.ConnectedPersonalFilesPlugs("consumer")
+	c.Assert(plugs, HasLen, 1)
+	c.Check(plugs[0].Name, Equals, "config")
+	c.Check(mgr.ConnectedPersonalFilesPlugs("producer"), HasLen, 0)
+}
+
+func (s *interfaceManagerSuite) TestEnsureTrimsPromptHistory(c *C) {
+	restore := ifacestate.MockAssessAppArmorPrompting(func(m *ifacestate.InterfaceManager) bool {
+		return true
+	})
+	defer restore()
+	restore = ifacestate.MockInterfacesRequestsControlHandlerServicePresent(func(m *ifacestate.InterfaceManager) (bool, error) {
+		return true, nil
+	})
+	defer restore()
+
+	fakeManager := &apparmorprompting.InterfacesRequestsManager{}
+	restore = ifacestate.MockCreateInterfacesRequestsManager(func(noticeMgr *notices.NoticeManager) (*apparmorprompting.InterfacesRequestsManager, error) {
+		return fakeManager, nil
+	})
+	defer restore()
+
+	trimCount := 0
+	var trimErr error
+	restore = ifacestate.MockInterfacesRequestsManagerTrimHistory(func(m *apparmorprompting.InterfacesRequestsManager) error {
+		c.Check(m, Equals, fakeManager)
+		trimCount++
+		return trimErr
+	})
+	defer restore()
+
+	mgr, err := ifacestate.Manager(s.state, nil, nil, s.o.TaskRunner(), nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(mgr.StartUp(), IsNil)
+
+	c.Check(mgr.Ensure(), IsNil)
+	c.Check(trimCount, Equals, 1)
+
+	// errors are logged, they do not fail the ensure
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+	trimErr = fmt.Errorf("boom")
+	c.Check(mgr.Ensure(), IsNil)
+	c.Check(trimCount, Equals, 2)
+	c.Check(logbuf.String(), testutil.Contains, "cannot trim prompt history: boom")
+}
+
 func (s *interfaceManagerSuite) TestShutDownInterfacesRequestsManager(c *C) {
 	shutDownCount := 0
 	restore := ifacestate.MockInterfacesRequestsManagerShutDown(func(m *apparmorprompting.InterfacesRequestsManager) {
@@ -0,0 +1,188 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/policy"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// PolicyCheckResult describes the outcome of checking a candidate connection
+// against the declarations for either connecting or auto-connecting.
+type PolicyCheckResult struct {
+	// Allowed is whether the connection is allowed.
+	Allowed bool
+	// Error explains why the connection is not allowed.
+	Error string
+	// Rule describes the declaration rule that decided, it is empty if no
+	// rule applies to the interface or if the declarations were not
+	// checked at all.
+	Rule string
+	// Steps describes, in order, the evaluation of the constraints of the
+	// rule and of any check done beyond the declarations.
+	Steps []string
+}
+
+// ConnectionPolicyCheck describes how the policy applies to a candidate
+// connection, to help understanding why a connection was or was not
+// established.
+type ConnectionPolicyCheck struct {
+	Plug        interfaces.PlugRef
+	Slot        interfaces.SlotRef
+	Interface   string
+	Connect     PolicyCheckResult
+	AutoConnect PolicyCheckResult
+}
+
+// checkTrace records how a connectChecker or autoConnectChecker evaluated
+// the declarations for a candidate connection. Its methods can be called on
+// a nil checkTrace, in which case nothing is recorded.
+type checkTrace struct {
+	policy.CheckTrace
+	// checked is whether the declarations were evaluated
+	checked bool
+	// err is the outcome of the evaluation, or why it was not done
+	err error
+}
+
+func (t *checkTrace) check(ic *policy.ConnectCandidate) error {
+	if t == nil {
+		return ic.Check()
+	}
+	trace, err := ic.TraceCheck()
+	t.CheckTrace, t.checked, t.err = *trace, true, err
+	return err
+}
+
+func (t *checkTrace) checkAutoConnect(ic *policy.ConnectCandidate) (interfaces.SideArity, error) {
+	if t == nil {
+		return ic.CheckAutoConnect()
+	}
+	arity, trace, err := ic.TraceCheckAutoConnect()
+	t.CheckTrace, t.checked, t.err = *trace, true, err
+	return arity, err
+}
+
+func (t *checkTrace) notChecked(err error) {
+	if t == nil {
+		return
+	}
+	t.err = err
+}
+
+func (t *checkTrace) result(allowed bool, err error) PolicyCheckResult {
+	if err == nil {
+		err = t.err
+	}
+	res := PolicyCheckResult{
+		Allowed: allowed,
+		Rule:    t.Rule,
+		Steps:   t.Steps,
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// CheckConnectionPolicy checks the connection of the given plug and slot
+// against the current base-declaration, snap-declarations and model, like
+// when connecting or auto-connecting them, and returns a description of the
+// evaluation. The plug and slot are resolved as for connecting them, and
+// they don't need to be connected.
+func (m *InterfaceManager) CheckConnectionPolicy(plugSnapName, plugName, slotSnapName, slotName string) (*ConnectionPolicyCheck, error) {
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	connRef, err := m.repo.ResolveConnect(plugSnapName, plugName, slotSnapName, slotName)
+	if err != nil {
+		return nil, err
+	}
+	plugInfo := m.repo.Plug(connRef.PlugRef.Snap, connRef.PlugRef.Name)
+	slotInfo := m.repo.Slot(connRef.SlotRef.Snap, connRef.SlotRef.Name)
+	if plugInfo == nil || slotInfo == nil {
+		return nil, fmt.Errorf("internal error: cannot find plug or slot of %s", connRef)
+	}
+
+	deviceCtx, err := snapstate.DeviceCtx(st, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	connChecker, err := newConnectChecker(st, deviceCtx)
+	if err != nil {
+		return nil, err
+	}
+	autoChecker, err := newAutoConnectChecker(st, m.repo, deviceCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	plugAppSet, err := interfaces.NewSnapAppSet(plugInfo.Snap, interfaces.NoComponents)
+	if err != nil {
+		return nil, err
+	}
+	slotAppSet, err := interfaces.NewSnapAppSet(slotInfo.Snap, interfaces.NoComponents)
+	if err != nil {
+		return nil, err
+	}
+	plug := interfaces.NewConnectedPlug(plugInfo, plugAppSet, nil, nil)
+	slot := interfaces.NewConnectedSlot(slotInfo, slotAppSet, nil, nil)
+
+	check := &ConnectionPolicyCheck{
+		Plug:      connRef.PlugRef,
+		Slot:      connRef.SlotRef,
+		Interface: plugInfo.Interface,
+	}
+
+	connChecker.trace = &checkTrace{}
+	ok, err := connChecker.check(plug, slot)
+	check.Connect = connChecker.trace.result(ok, err)
+	if ok && !connChecker.trace.checked {
+		// snaps without a snap-id were installed with --dangerous
+		// and have no snap-declaration
+		var notDeclared []string
+		for _, sn := range []*snap.Info{plugInfo.Snap, slotInfo.Snap} {
+			if sn.SnapID == "" {
+				notDeclared = append(notDeclared, sn.InstanceName())
+			}
+		}
+		check.Connect.Steps = []string{fmt.Sprintf("declarations not checked: no snap-declaration for %s", strutil.Quoted(notDeclared))}
+	}
+
+	// like in addAutoConnections, the interface is consulted before the
+	// declarations
+	iface := m.repo.Interface(plugInfo.Interface)
+	if iface != nil && !iface.AutoConnect(plugInfo, slotInfo) {
+		check.AutoConnect = PolicyCheckResult{
+			Error: fmt.Sprintf("auto-connection not supported by interface %q for this plug and slot", plugInfo.Interface),
+		}
+	} else {
+		autoChecker.trace = &checkTrace{}
+		ok, _, err := autoChecker.check(plug, slot)
+		check.AutoConnect = autoChecker.trace.result(ok, err)
+	}
+	return check, nil
+}
@@ -217,6 +217,7 @@ func New(restartHandler restart.Handler) (*Overlord, error) {
 		return nil, err
 	}
 	healthstate.Init(hookMgr)
+	o.addManager(healthstate.Manager(s, hookMgr))
 
 	o.addManager(devicemgmtstate.Manager(s, o.runner, deviceMgr))
 
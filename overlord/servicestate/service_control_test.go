@@ -30,6 +30,7 @@ import (
 	"time"
 
 	. "gopkg.in/check.v1"
+	tomb "gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/dirs"
@@ -1593,3 +1594,231 @@ func (s *serviceControlSuite) TestUpdateSnapstateUserServicesFailsOnInvalidUID(c
 	c.Assert(snapst.UserServicesEnabledByHooks, HasLen, 0)
 	c.Assert(snapst.UserServicesDisabledByHooks, HasLen, 0)
 }
+
+const timerSnapYaml = `name: timer-snap
+version: 1.0
+apps:
+  tick:
+    command: cmd
+    daemon: oneshot
+    timer: 10:00-12:00
+  foo:
+    daemon: simple
+`
+
+func (s *serviceControlSuite) mockTimerSnap(c *C) *snap.Info {
+	si := snap.SideInfo{
+		RealName: "timer-snap",
+		Revision: snap.R(3),
+	}
+	info := snaptest.MockSnap(c, timerSnapYaml, &si)
+	snapstate.Set(s.state, "timer-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{&si}),
+		Current:  snap.R(3),
+		SnapType: "app",
+	})
+	s.AddCleanup(snapstatetest.UseFallbackDeviceModel())
+	return info
+}
+
+func (s *serviceControlSuite) TestTimerControlSet(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	info := s.mockTimerSnap(c)
+	app := info.Apps["tick"]
+
+	ts, err := servicestate.TimerControl(st, app, "mon,23:00", false, nil)
+	c.Assert(err, IsNil)
+	c.Assert(ts.Tasks(), HasLen, 1)
+	t := ts.Tasks()[0]
+	c.Check(t.Kind(), Equals, "timer-control")
+	c.Check(t.Summary(), Equals, `Set timer of app "tick" of snap "timer-snap" to "mon,23:00"`)
+	chg := st.NewChange("timer-control", "...")
+	chg.AddAll(ts)
+
+	st.Unlock()
+	defer s.se.Stop()
+	err = s.o.Settle(5 * time.Second)
+	st.Lock()
+	c.Assert(err, IsNil)
+
+	c.Assert(t.Status(), Equals, state.DoneStatus)
+	c.Check(app.Timer.File(), testutil.FileContains, "\nOnCalendar=Mon *-*-* 23:00\n")
+	c.Check(app.ServiceFile(), testutil.FileContains, `--timer="mon,23:00"`)
+	// only the units of the timer app are written
+	c.Check(info.Apps["foo"].ServiceFile(), testutil.FileAbsent)
+	c.Check(s.sysctlArgs, DeepEquals, [][]string{
+		{"daemon-reload"},
+		{"--no-reload", "enable", "snap.timer-snap.tick.timer"},
+		{"daemon-reload"},
+		{"start", "snap.timer-snap.tick.timer"},
+	})
+
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(st, "timer-snap", &snapst), IsNil)
+	c.Check(snapst.TimerSchedulesByHooks, DeepEquals, map[string]string{"tick": "mon,23:00"})
+	c.Check(snapst.ServicesEnabledByHooks, DeepEquals, []string{"tick"})
+
+	schedule, disabled, err := servicestate.TimerSchedule(st, app)
+	c.Assert(err, IsNil)
+	c.Check(schedule, Equals, "mon,23:00")
+	c.Check(disabled, Equals, false)
+
+	// the override is used whenever the units are rendered
+	opts, err := servicestate.SnapServiceOptions(st, info, nil)
+	c.Assert(err, IsNil)
+	c.Check(opts.TimerSchedules, DeepEquals, map[string]string{"tick": "mon,23:00"})
+}
+
+func (s *serviceControlSuite) TestTimerControlDisable(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	info := s.mockTimerSnap(c)
+	app := info.Apps["tick"]
+
+	ts, err := servicestate.TimerControl(st, app, "", true, nil)
+	c.Assert(err, IsNil)
+	c.Check(ts.Tasks()[0].Summary(), Equals, `Disable timer of app "tick" of snap "timer-snap"`)
+	chg := st.NewChange("timer-control", "...")
+	chg.AddAll(ts)
+
+	st.Unlock()
+	defer s.se.Stop()
+	err = s.o.Settle(5 * time.Second)
+	st.Lock()
+	c.Assert(err, IsNil)
+
+	c.Assert(chg.Status(), Equals, state.DoneStatus)
+	// the declared schedule is kept
+	c.Check(app.Timer.File(), testutil.FileContains, "\nOnCalendar=*-*-* 10:00\n")
+	c.Check(s.sysctlArgs, DeepEquals, [][]string{
+		{"daemon-reload"},
+		{"stop", "snap.timer-snap.tick.timer"},
+		{"show", "--property=ActiveState", "snap.timer-snap.tick.timer"},
+		{"stop", "snap.timer-snap.tick.service"},
+		{"show", "--property=ActiveState", "snap.timer-snap.tick.service"},
+		{"--no-reload", "disable", "snap.timer-snap.tick.timer", "snap.timer-snap.tick.service"},
+		{"daemon-reload"},
+	})
+
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(st, "timer-snap", &snapst), IsNil)
+	c.Check(snapst.TimerSchedulesByHooks, IsNil)
+	c.Check(snapst.ServicesDisabledByHooks, DeepEquals, []string{"tick"})
+
+	schedule, disabled, err := servicestate.TimerSchedule(st, app)
+	c.Assert(err, IsNil)
+	c.Check(schedule, Equals, "10:00-12:00")
+	c.Check(disabled, Equals, true)
+}
+
+func (s *serviceControlSuite) runTimerControlUndo(c *C, schedule string, disable bool) *state.Task {
+	s.o.TaskRunner().AddHandler("error-trigger", func(t *state.Task, _ *tomb.Tomb) error {
+		return fmt.Errorf("boom")
+	}, nil)
+
+	info, err := snap.ReadInfo("timer-snap", &snap.SideInfo{RealName: "timer-snap", Revision: snap.R(3)})
+	c.Assert(err, IsNil)
+	ts, err := servicestate.TimerControl(s.state, info.Apps["tick"], schedule, disable, nil)
+	c.Assert(err, IsNil)
+	chg := s.state.NewChange("timer-control", "...")
+	chg.AddAll(ts)
+	errTask := s.state.NewTask("error-trigger", "provoking undo")
+	errTask.WaitAll(ts)
+	chg.AddTask(errTask)
+
+	s.state.Unlock()
+	defer s.se.Stop()
+	err = s.o.Settle(5 * time.Second)
+	s.state.Lock()
+	c.Assert(err, IsNil)
+
+	c.Assert(chg.Status(), Equals, state.ErrorStatus)
+	t := ts.Tasks()[0]
+	c.Assert(t.Status(), Equals, state.UndoneStatus)
+	return t
+}
+
+func (s *serviceControlSuite) TestTimerControlSetUndo(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	info := s.mockTimerSnap(c)
+	app := info.Apps["tick"]
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(st, "timer-snap", &snapst), IsNil)
+	snapst.TimerSchedulesByHooks = map[string]string{"tick": "fri,23:00"}
+	snapstate.Set(st, "timer-snap", &snapst)
+
+	s.runTimerControlUndo(c, "mon,23:00", false)
+
+	// the previous override is restored and used for the units
+	c.Check(app.Timer.File(), testutil.FileContains, "\nOnCalendar=Fri *-*-* 23:00\n")
+	c.Check(app.ServiceFile(), testutil.FileContains, `--timer="fri,23:00"`)
+	// the timer is not stopped, it was not disabled before
+	c.Check(s.sysctlArgs, DeepEquals, [][]string{
+		{"daemon-reload"},
+		{"--no-reload", "enable", "snap.timer-snap.tick.timer"},
+		{"daemon-reload"},
+		{"start", "snap.timer-snap.tick.timer"},
+		{"daemon-reload"},
+	})
+
+	c.Assert(snapstate.Get(st, "timer-snap", &snapst), IsNil)
+	c.Check(snapst.TimerSchedulesByHooks, DeepEquals, map[string]string{"tick": "fri,23:00"})
+	c.Check(snapst.ServicesEnabledByHooks, HasLen, 0)
+}
+
+func (s *serviceControlSuite) TestTimerControlDisableUndo(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	info := s.mockTimerSnap(c)
+	app := info.Apps["tick"]
+
+	s.runTimerControlUndo(c, "", true)
+
+	c.Check(app.Timer.File(), testutil.FileContains, "\nOnCalendar=*-*-* 10:00\n")
+	// the timer is enabled and started again
+	c.Check(s.sysctlArgs[len(s.sysctlArgs)-4:], DeepEquals, [][]string{
+		{"daemon-reload"},
+		{"--no-reload", "enable", "snap.timer-snap.tick.timer"},
+		{"daemon-reload"},
+		{"start", "snap.timer-snap.tick.timer"},
+	})
+
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(st, "timer-snap", &snapst), IsNil)
+	c.Check(snapst.TimerSchedulesByHooks, IsNil)
+	c.Check(snapst.ServicesDisabledByHooks, HasLen, 0)
+	c.Check(snapst.ServicesEnabledByHooks, HasLen, 0)
+
+	schedule, disabled, err := servicestate.TimerSchedule(st, app)
+	c.Assert(err, IsNil)
+	c.Check(schedule, Equals, "10:00-12:00")
+	c.Check(disabled, Equals, false)
+}
+
+func (s *serviceControlSuite) TestTimerControlErrors(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	info := s.mockTimerSnap(c)
+
+	_, err := servicestate.TimerControl(st, info.Apps["foo"], "mon", false, nil)
+	c.Check(err, ErrorMatches, `app "foo" has no timer`)
+	_, err = servicestate.TimerControl(st, info.Apps["tick"], "bad-timer", false, nil)
+	c.Check(err, ErrorMatches, `cannot use timer schedule "bad-timer": cannot parse "bad-timer": "bad" is not a valid weekday`)
+	_, err = servicestate.TimerControl(st, info.Apps["tick"], "mon", true, nil)
+	c.Check(err, ErrorMatches, `internal error: .*`)
+	_, _, err = servicestate.TimerSchedule(st, info.Apps["foo"])
+	c.Check(err, ErrorMatches, `app "foo" has no timer`)
+}
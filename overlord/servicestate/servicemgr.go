@@ -64,6 +64,8 @@ func Manager(st *state.State, runner *state.TaskRunner) *ServiceManager {
 	// TODO: undo handler
 	runner.AddHandler("service-control", m.doServiceControl, nil)
 
+	runner.AddHandler("timer-control", m.doTimerControl, m.undoTimerControl)
+
 	// TODO: undo handler
 	runner.AddHandler("quota-control", m.doQuotaControl, nil)
 	RegisterAffectedQuotasByKind("quota-control", affectedQuotasForQuotaControl)
@@ -217,6 +219,7 @@ func (m *ServiceManager) Ensure() error {
 func delayedCrossMgrInit() {
 	// hook into conflict checks mechanisms
 	snapstate.RegisterAffectedSnapsByAttr("service-action", serviceControlAffectedSnaps)
+	snapstate.RegisterAffectedSnapsByAttr("timer-action", timerControlAffectedSnaps)
 	snapstate.SnapServiceOptions = SnapServiceOptions
 	snapstate.EnsureSnapAbsentFromQuotaGroup = EnsureSnapAbsentFromQuota
 }
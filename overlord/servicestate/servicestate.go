@@ -31,7 +31,6 @@ import (
 	"time"
 
 	"github.com/snapcore/snapd/client"
-	"github.com/snapcore/snapd/client/clientutil"
 	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/overlord/cmdstate"
 	"github.com/snapcore/snapd/overlord/configstate/config"
@@ -356,6 +355,7 @@ type StatusDecorator struct {
 	globalUserSysd systemd.Systemd
 	context        context.Context
 	uid            string
+	runtime        bool
 }
 
 // NewStatusDecorator returns a new StatusDecorator.
@@ -365,7 +365,7 @@ type StatusDecorator struct {
 // user.
 func NewStatusDecorator(rep interface {
 	Notify(string)
-}) clientutil.StatusDecorator {
+}) *StatusDecorator {
 	return &StatusDecorator{
 		sysd:           systemd.New(systemd.SystemMode, rep),
 		globalUserSysd: systemd.New(systemd.GlobalUserMode, rep),
@@ -377,7 +377,7 @@ func NewStatusDecorator(rep interface {
 // user-services for a specific user.
 func NewStatusDecoratorForUid(rep interface {
 	Notify(string)
-}, context context.Context, uid string) clientutil.StatusDecorator {
+}, context context.Context, uid string) *StatusDecorator {
 	return &StatusDecorator{
 		sysd:           systemd.New(systemd.SystemMode, rep),
 		globalUserSysd: systemd.New(systemd.GlobalUserMode, rep),
@@ -386,6 +386,12 @@ func NewStatusDecoratorForUid(rep interface {
 	}
 }
 
+// SetRuntime sets whether the runtime status of system services, such as
+// their restart count or the PID of their main process, is also added.
+func (sd *StatusDecorator) SetRuntime(runtime bool) {
+	sd.runtime = runtime
+}
+
 func (sd *StatusDecorator) hasEnabledActivator(appInfo *client.AppInfo) bool {
 	// Just one activator should be enabled in order for the service to be able
 	// to become enabled. For slot activated services this is always true as we
@@ -542,9 +548,31 @@ func (sd *StatusDecorator) DecorateWithStatus(appInfo *client.AppInfo, snapApp *
 	if len(appInfo.Activators) > 0 {
 		appInfo.Enabled = sd.hasEnabledActivator(appInfo)
 	}
+	// Runtime status is only tracked for system services
+	if sd.runtime && snapApp.DaemonScope == snap.SystemDaemon {
+		rsts, err := sd.sysd.RuntimeStatus([]string{snapApp.ServiceName()})
+		if err != nil {
+			return fmt.Errorf("cannot get runtime status of service of app %q: %v", appInfo.Name, err)
+		}
+		appInfo.Runtime = clientAppRuntime(rsts[0])
+	}
 	return nil
 }
 
+func clientAppRuntime(rst *systemd.ServiceRuntimeStatus) *client.AppRuntime {
+	runtime := &client.AppRuntime{
+		Restarts:   rst.Restarts,
+		ExitStatus: rst.ExecMainStatus,
+		MainPID:    rst.MainPID,
+		Memory:     rst.MemoryCurrent,
+	}
+	if !rst.ActiveEnterTimestamp.IsZero() {
+		activeSince := rst.ActiveEnterTimestamp
+		runtime.ActiveSince = &activeSince
+	}
+	return runtime
+}
+
 // SnapServiceOptions computes the options to configure services for
 // the given snap. It also takes as argument a map of all quota groups as an
 // optimization, the map if non-nil is used in place of checking state for
@@ -583,13 +611,21 @@ func SnapServiceOptions(st *state.State, snapInfo *snap.Info, quotaGroups map[st
 		}
 	}
 
+	// and for timer schedules overridden by the snap hooks
+	var snapst snapstate.SnapState
+	err = snapstate.Get(st, snapInfo.InstanceName(), &snapst)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	opts.TimerSchedules = snapst.TimerSchedulesByHooks
+
 	return opts, nil
 }
 
 // LogReader returns an io.ReadCloser which produce logs for the provided
-// snap AppInfo's. It is a convenience wrapper around the systemd.LogReader
-// implementation.
-func LogReader(appInfos []*snap.AppInfo, n int, follow bool) (io.ReadCloser, error) {
+// snap AppInfo's, restricted to the ones matching filter if it is not nil.
+// It is a convenience wrapper around the systemd.LogReader implementation.
+func LogReader(appInfos []*snap.AppInfo, n int, follow bool, filter *systemd.LogFilter) (io.ReadCloser, error) {
 	serviceNames := make([]string, len(appInfos))
 	for i, appInfo := range appInfos {
 		if !appInfo.IsService() {
@@ -609,5 +645,5 @@ func LogReader(appInfos []*snap.AppInfo, n int, follow bool) (io.ReadCloser, err
 	}
 
 	sysd := systemd.New(systemd.SystemMode, progress.Null)
-	return sysd.LogReader(serviceNames, n, follow, includeNamespaces)
+	return sysd.LogReader(serviceNames, n, follow, includeNamespaces, filter)
 }
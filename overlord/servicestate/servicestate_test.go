@@ -303,6 +303,82 @@ NeedDaemonReload=no
 	}
 }
 
+func (s *statusDecoratorSuite) TestDecorateWithStatusRuntime(c *C) {
+	snp := &snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "foo",
+			Revision: snap.R(1),
+		},
+	}
+	err := os.MkdirAll(snp.MountDir(), 0755)
+	c.Assert(err, IsNil)
+	err = os.Symlink(snp.Revision.String(), filepath.Join(filepath.Dir(snp.MountDir()), "current"))
+	c.Assert(err, IsNil)
+
+	var calls [][]string
+	r := systemd.MockSystemctl(func(args ...string) (buf []byte, err error) {
+		calls = append(calls, args)
+		c.Assert(args[0], Equals, "show")
+		if args[1] == "--property=Id,NRestarts,ExecMainStatus,ActiveEnterTimestamp,MainPID,MemoryCurrent" {
+			return []byte(fmt.Sprintf(`Id=%s
+NRestarts=4
+ExecMainStatus=143
+ActiveEnterTimestamp=
+MainPID=0
+MemoryCurrent=[not set]
+`, args[2])), nil
+		}
+		return []byte(fmt.Sprintf(`Id=%s
+Names=%[1]s
+Type=simple
+ActiveState=inactive
+UnitFileState=enabled
+NeedDaemonReload=no
+`, args[2])), nil
+	})
+	defer r()
+
+	sd := servicestate.NewStatusDecorator(nil)
+	snapApp := &snap.AppInfo{
+		Snap:        snp,
+		Name:        "svc",
+		Daemon:      "simple",
+		DaemonScope: snap.SystemDaemon,
+	}
+
+	// not requested
+	app := &client.AppInfo{Snap: "foo", Name: "svc", Daemon: "simple"}
+	err = sd.DecorateWithStatus(app, snapApp)
+	c.Assert(err, IsNil)
+	c.Check(app.Runtime, IsNil)
+	c.Check(calls, HasLen, 1)
+
+	calls = nil
+	sd.SetRuntime(true)
+	app = &client.AppInfo{Snap: "foo", Name: "svc", Daemon: "simple"}
+	err = sd.DecorateWithStatus(app, snapApp)
+	c.Assert(err, IsNil)
+	c.Check(app.Runtime, DeepEquals, &client.AppRuntime{
+		Restarts:   4,
+		ExitStatus: 143,
+	})
+	c.Check(calls, HasLen, 2)
+
+	// not tracked for user services
+	calls = nil
+	snapApp.DaemonScope = snap.UserDaemon
+	r = systemd.MockSystemctl(func(args ...string) (buf []byte, err error) {
+		calls = append(calls, args)
+		return []byte("enabled\n"), nil
+	})
+	defer r()
+	app = &client.AppInfo{Snap: "foo", Name: "svc", Daemon: "simple"}
+	err = sd.DecorateWithStatus(app, snapApp)
+	c.Assert(err, IsNil)
+	c.Check(app.Runtime, IsNil)
+	c.Check(calls, DeepEquals, [][]string{{"--user", "--global", "is-enabled", "snap.foo.svc.service"}})
+}
+
 func (s *statusDecoratorSuite) TestUserServiceDecorateWithStatus(c *C) {
 	snp := &snap.Info{
 		SideInfo: snap.SideInfo{
@@ -949,17 +1025,18 @@ func (s *snapServiceOptionsSuite) TestLogReader(c *C) {
 	defer restore()
 
 	var jctlCalls int
-	restore = systemd.MockJournalctl(func(svcs []string, n int, follow, namespaces bool) (rc io.ReadCloser, err error) {
+	restore = systemd.MockJournalctl(func(svcs []string, n int, follow, namespaces bool, filter *systemd.LogFilter) (rc io.ReadCloser, err error) {
 		jctlCalls++
 		c.Check(svcs, DeepEquals, []string{"snap.foo.svc1.service", "snap.foo.svc2.service"})
 		c.Check(n, Equals, 100)
 		c.Check(follow, Equals, false)
 		c.Check(namespaces, Equals, false)
+		c.Check(filter, IsNil)
 		return io.NopCloser(strings.NewReader("")), nil
 	})
 	defer restore()
 
-	_, err := servicestate.LogReader(appInfos, 100, false)
+	_, err := servicestate.LogReader(appInfos, 100, false, nil)
 	c.Assert(err, IsNil)
 	c.Check(jctlCalls, Equals, 1)
 }
@@ -991,7 +1068,7 @@ func (s *snapServiceOptionsSuite) TestLogReaderFailsWithNonServices(c *C) {
 		},
 	}
 
-	_, err := servicestate.LogReader(appInfos, 100, false)
+	_, err := servicestate.LogReader(appInfos, 100, false, nil)
 	c.Assert(err.Error(), Equals, `cannot read logs for app "app1": not a service`)
 }
 
@@ -1027,17 +1104,18 @@ func (s *snapServiceOptionsSuite) TestLogReaderNamespaces(c *C) {
 
 	restore := systemd.MockSystemdVersion(245, nil)
 	defer restore()
-	restore = systemd.MockJournalctl(func(svcs []string, n int, follow, namespaces bool) (rc io.ReadCloser, err error) {
+	restore = systemd.MockJournalctl(func(svcs []string, n int, follow, namespaces bool, filter *systemd.LogFilter) (rc io.ReadCloser, err error) {
 		jctlCalls++
 		c.Check(svcs, DeepEquals, []string{"snap.foo.svc1.service", "snap.foo.svc2.service"})
 		c.Check(n, Equals, 100)
 		c.Check(follow, Equals, false)
 		c.Check(namespaces, Equals, true)
+		c.Check(filter, DeepEquals, &systemd.LogFilter{Priority: "err"})
 		return io.NopCloser(strings.NewReader("")), nil
 	})
 	defer restore()
 
-	_, err := servicestate.LogReader(appInfos, 100, false)
+	_, err := servicestate.LogReader(appInfos, 100, false, &systemd.LogFilter{Priority: "err"})
 	c.Assert(err, IsNil)
 	c.Check(jctlCalls, Equals, 1)
 }
@@ -0,0 +1,315 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package servicestate
+
+import (
+	"errors"
+	"fmt"
+
+	tomb "gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snapdenv"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
+	"github.com/snapcore/snapd/timings"
+	"github.com/snapcore/snapd/wrappers"
+)
+
+// TimerAction encapsulates overriding the schedule of the timer of an app,
+// or disabling the timer when Disable is set.
+type TimerAction struct {
+	SnapName string `json:"snap-name"`
+	App      string `json:"app"`
+	Schedule string `json:"schedule,omitempty"`
+	Disable  bool   `json:"disable,omitempty"`
+}
+
+// TimerSchedule returns the effective schedule of the timer of the given app,
+// taking into account the schedule overridden by the snap hooks, and whether
+// the timer was disabled by the snap hooks.
+func TimerSchedule(st *state.State, app *snap.AppInfo) (schedule string, disabled bool, err error) {
+	if app.Timer == nil {
+		return "", false, fmt.Errorf("app %q has no timer", app.Name)
+	}
+
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, app.Snap.InstanceName(), &snapst); err != nil {
+		return "", false, err
+	}
+
+	schedule = app.Timer.Timer
+	if override := snapst.TimerSchedulesByHooks[app.Name]; override != "" {
+		schedule = override
+	}
+	for _, svc := range snapst.ServicesDisabledByHooks {
+		if svc == app.Name {
+			disabled = true
+			break
+		}
+	}
+	return schedule, disabled, nil
+}
+
+// TimerControl creates a taskset for setting the schedule of the timer of the
+// given app, or for disabling the timer when disable is true.
+// Context is used to determine change conflicts - we will not conflict with
+// tasks from same change as that of context's.
+func TimerControl(st *state.State, app *snap.AppInfo, schedule string, disable bool, context *hookstate.Context) (*state.TaskSet, error) {
+	if app.Timer == nil {
+		return nil, fmt.Errorf("app %q has no timer", app.Name)
+	}
+	if app.DaemonScope != snap.SystemDaemon {
+		return nil, fmt.Errorf("cannot control the timer of user daemon %q", app.Name)
+	}
+	if disable == (schedule != "") {
+		return nil, fmt.Errorf("internal error: either a schedule or disabling the timer must be requested")
+	}
+	if schedule != "" {
+		if _, err := timeutil.ParseSchedule(schedule); err != nil {
+			return nil, fmt.Errorf("cannot use timer schedule %q: %v", schedule, err)
+		}
+	}
+
+	var ignoreChangeID string
+	if context != nil {
+		ignoreChangeID = context.ChangeID()
+	}
+	if err := snapstate.CheckChangeConflictMany(st, []string{app.Snap.InstanceName()}, ignoreChangeID); err != nil {
+		return nil, &ServiceActionConflictError{err}
+	}
+
+	action := &TimerAction{
+		SnapName: app.Snap.InstanceName(),
+		App:      app.Name,
+		Schedule: schedule,
+		Disable:  disable,
+	}
+	var summary string
+	if disable {
+		summary = fmt.Sprintf("Disable timer of app %q of snap %q", action.App, action.SnapName)
+	} else {
+		summary = fmt.Sprintf("Set timer of app %q of snap %q to %q", action.App, action.SnapName, schedule)
+	}
+	task := st.NewTask("timer-control", summary)
+	task.Set("timer-action", action)
+	return state.NewTaskSet(task), nil
+}
+
+// timerControlUndoState holds the state of the timer of an app before a
+// timer-control task changed it.
+type timerControlUndoState struct {
+	Schedule        string `json:"schedule,omitempty"`
+	EnabledByHooks  bool   `json:"enabled-by-hooks,omitempty"`
+	DisabledByHooks bool   `json:"disabled-by-hooks,omitempty"`
+}
+
+func timerControlApp(st *state.State, action *TimerAction) (*snapstate.SnapState, *snap.Info, *snap.AppInfo, error) {
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, action.SnapName, &snapst); err != nil {
+		return nil, nil, nil, err
+	}
+	info, err := snapst.CurrentInfo()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	app := info.Apps[action.App]
+	if app == nil || app.Timer == nil {
+		return nil, nil, nil, fmt.Errorf("no timer for app %q of snap %q", action.App, action.SnapName)
+	}
+	return &snapst, info, app, nil
+}
+
+func (m *ServiceManager) doTimerControl(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	perfTimings := state.TimingsForTask(t)
+	defer perfTimings.Save(st)
+
+	var action TimerAction
+	if err := t.Get("timer-action", &action); err != nil {
+		return fmt.Errorf("internal error: cannot get timer-action: %v", err)
+	}
+
+	snapst, info, app, err := timerControlApp(st, &action)
+	if err != nil {
+		return err
+	}
+
+	// keep the original state of the timer if the task is re-run
+	var undoState timerControlUndoState
+	if err := t.Get("old-timer-state", &undoState); errors.Is(err, state.ErrNoState) {
+		t.Set("old-timer-state", &timerControlUndoState{
+			Schedule:        snapst.TimerSchedulesByHooks[action.App],
+			EnabledByHooks:  strutil.ListContains(snapst.ServicesEnabledByHooks, action.App),
+			DisabledByHooks: strutil.ListContains(snapst.ServicesDisabledByHooks, action.App),
+		})
+	} else if err != nil {
+		return err
+	}
+
+	if !action.Disable {
+		if snapst.TimerSchedulesByHooks == nil {
+			snapst.TimerSchedulesByHooks = make(map[string]string)
+		}
+		snapst.TimerSchedulesByHooks[action.App] = action.Schedule
+		snapstate.Set(st, action.SnapName, snapst)
+	}
+
+	if err := renderTimer(t, info, app, !action.Disable, action.Disable, perfTimings); err != nil {
+		return err
+	}
+
+	// re-read snapst after reacquiring the lock as it could have changed.
+	if err := snapstate.Get(st, action.SnapName, snapst); err != nil {
+		return err
+	}
+	var enabled, disabled []*snap.AppInfo
+	if action.Disable {
+		disabled = []*snap.AppInfo{app}
+	} else {
+		enabled = []*snap.AppInfo{app}
+	}
+	changed, err := updateSnapstateServices(snapst, enabled, disabled, wrappers.ScopeOptions{})
+	if err != nil {
+		return err
+	}
+	if changed {
+		snapstate.Set(st, action.SnapName, snapst)
+	}
+	return nil
+}
+
+func (m *ServiceManager) undoTimerControl(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	perfTimings := state.TimingsForTask(t)
+	defer perfTimings.Save(st)
+
+	var action TimerAction
+	if err := t.Get("timer-action", &action); err != nil {
+		return fmt.Errorf("internal error: cannot get timer-action: %v", err)
+	}
+	var undoState timerControlUndoState
+	if err := t.Get("old-timer-state", &undoState); err != nil {
+		return fmt.Errorf("internal error: cannot get old-timer-state: %v", err)
+	}
+
+	snapst, info, app, err := timerControlApp(st, &action)
+	if err != nil {
+		return err
+	}
+
+	if undoState.Schedule != "" {
+		if snapst.TimerSchedulesByHooks == nil {
+			snapst.TimerSchedulesByHooks = make(map[string]string)
+		}
+		snapst.TimerSchedulesByHooks[action.App] = undoState.Schedule
+	} else {
+		delete(snapst.TimerSchedulesByHooks, action.App)
+		if len(snapst.TimerSchedulesByHooks) == 0 {
+			snapst.TimerSchedulesByHooks = nil
+		}
+	}
+	snapst.ServicesEnabledByHooks = setListMember(snapst.ServicesEnabledByHooks, action.App, undoState.EnabledByHooks)
+	snapst.ServicesDisabledByHooks = setListMember(snapst.ServicesDisabledByHooks, action.App, undoState.DisabledByHooks)
+	snapstate.Set(st, action.SnapName, snapst)
+
+	// start or stop the timer again only if the task changed whether
+	// it was disabled
+	start := action.Disable && !undoState.DisabledByHooks
+	stop := !action.Disable && undoState.DisabledByHooks
+	return renderTimer(t, info, app, start, stop, perfTimings)
+}
+
+// setListMember returns the list with name added to or removed from it.
+func setListMember(list []string, name string, member bool) []string {
+	if member == strutil.ListContains(list, name) {
+		return list
+	}
+	if member {
+		return append(list, name)
+	}
+	var res []string
+	for _, n := range list {
+		if n != name {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+// renderTimer writes the units of the timer of the given app, and then
+// starts and enables the timer, or stops and disables it, as requested.
+// The state must be locked by the caller, it is unlocked while the units are
+// written and the timer is started or stopped.
+func renderTimer(t *state.Task, info *snap.Info, app *snap.AppInfo, start, stop bool, tm timings.Measurer) error {
+	st := t.State()
+	snapSvcOpts, err := SnapServiceOptions(st, info, nil)
+	if err != nil {
+		return err
+	}
+	ensureOpts := &wrappers.EnsureSnapServicesOptions{
+		Preseeding:      snapdenv.Preseeding(),
+		IncludeServices: []string{app.String()},
+	}
+	// set RequireMountedSnapdSnap if we are on UC18+ only
+	deviceCtx, err := snapstate.DeviceCtx(st, nil, nil)
+	if err != nil {
+		return err
+	}
+	if !deviceCtx.Classic() && deviceCtx.Model().Base() != "" {
+		ensureOpts.RequireMountedSnapdSnap = true
+	}
+
+	meter := snapstate.NewTaskProgressAdapterUnlocked(t)
+	services := []*snap.AppInfo{app}
+
+	// Note - state must be unlocked when calling wrappers below.
+	st.Unlock()
+	defer st.Lock()
+	err = wrappers.EnsureSnapServices(map[*snap.Info]*wrappers.SnapServiceOptions{info: snapSvcOpts}, ensureOpts, nil, progress.Null)
+	if err != nil || ensureOpts.Preseeding {
+		return err
+	}
+	switch {
+	case stop:
+		return wrappers.StopServices(services, nil, &wrappers.StopServicesOptions{Disable: true}, snap.StopReasonOther, meter, tm)
+	case start:
+		return wrappers.StartServices(services, nil, &wrappers.StartServicesOptions{Enable: true}, meter, tm)
+	}
+	return nil
+}
+
+func timerControlAffectedSnaps(t *state.Task) ([]string, error) {
+	var action TimerAction
+	if err := t.Get("timer-action", &action); err != nil {
+		return nil, fmt.Errorf("internal error: cannot obtain timer action from task: %s", t.Summary())
+	}
+	return []string{action.SnapName}, nil
+}
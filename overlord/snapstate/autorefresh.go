@@ -865,7 +865,10 @@ func processInhibitedAutoRefresh(chg *state.Change, old state.Status, new state.
 }
 
 // maybeAddRefreshInhibitNotice records a refresh-inhibit notice if the set of
-// inhibited snaps was changed since the last notice.
+// inhibited snaps was changed since the last notice. The "snap-names" data of
+// the notice lists the snaps whose inhibition changed with its last
+// occurrence, when the inhibition of each snap last changed is kept in the
+// state as the notice only has a single key.
 func maybeAddRefreshInhibitNotice(st *state.State) error {
 	var lastRecordedInhibitedSnaps map[string]bool
 	if err := st.Get("last-recorded-inhibited-snaps", &lastRecordedInhibitedSnaps); err != nil && !errors.Is(err, state.ErrNoState) {
@@ -885,21 +888,32 @@ func maybeAddRefreshInhibitNotice(st *state.State) error {
 		curInhibitedSnaps[snapst.InstanceName()] = true
 	}
 
-	changed := len(lastRecordedInhibitedSnaps) != len(curInhibitedSnaps)
-	if !changed {
-		for snapName := range curInhibitedSnaps {
-			if !lastRecordedInhibitedSnaps[snapName] {
-				changed = true
-				break
-			}
+	// snaps which became inhibited or stopped being inhibited
+	var changedSnaps []string
+	for snapName := range curInhibitedSnaps {
+		if !lastRecordedInhibitedSnaps[snapName] {
+			changedSnaps = append(changedSnaps, snapName)
+		}
+	}
+	for snapName := range lastRecordedInhibitedSnaps {
+		if !curInhibitedSnaps[snapName] {
+			changedSnaps = append(changedSnaps, snapName)
 		}
 	}
+	sort.Strings(changedSnaps)
 
-	if changed {
-		if _, err := st.AddNotice(nil, state.RefreshInhibitNotice, "-", nil); err != nil {
+	if len(changedSnaps) > 0 {
+		opts := &state.AddNoticeOptions{
+			Data: map[string]string{"snap-names": strings.Join(changedSnaps, ",")},
+		}
+		id, err := st.AddNotice(nil, state.RefreshInhibitNotice, "-", opts)
+		if err != nil {
 			return err
 		}
 		st.Set("last-recorded-inhibited-snaps", curInhibitedSnaps)
+		if err := recordRefreshInhibitNoticeSnaps(st, st.Notice(id), changedSnaps); err != nil {
+			return err
+		}
 	}
 
 	if err := maybeAddRefreshInhibitWarningFallback(st, curInhibitedSnaps); err != nil {
@@ -909,6 +923,45 @@ func maybeAddRefreshInhibitNotice(st *state.State) error {
 	return nil
 }
 
+// recordRefreshInhibitNoticeSnaps records that the inhibition of the given
+// snaps changed with the last occurrence of the refresh-inhibit notice.
+func recordRefreshInhibitNoticeSnaps(st *state.State, n *state.Notice, snapNames []string) error {
+	var changed map[string]time.Time
+	if err := st.Get("refresh-inhibit-notice-snaps", &changed); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if changed == nil {
+		changed = make(map[string]time.Time, len(snapNames))
+	}
+	// changes from before the notice first occurred were recorded for an
+	// expired notice
+	for snapName, t := range changed {
+		if t.Before(n.FirstOccurred()) {
+			delete(changed, snapName)
+		}
+	}
+	for _, snapName := range snapNames {
+		changed[snapName] = n.LastRepeated()
+	}
+	st.Set("refresh-inhibit-notice-snaps", changed)
+	return nil
+}
+
+// RefreshInhibitNoticeConcernsSnap returns whether the refresh inhibition of
+// the given snap changed with an occurrence of the given refresh-inhibit
+// notice, after the given time if it is not zero.
+func RefreshInhibitNoticeConcernsSnap(st *state.State, n *state.Notice, snapName string, after time.Time) (bool, error) {
+	var changed map[string]time.Time
+	if err := st.Get("refresh-inhibit-notice-snaps", &changed); err != nil && !errors.Is(err, state.ErrNoState) {
+		return false, err
+	}
+	t, ok := changed[snapName]
+	if !ok || t.Before(n.FirstOccurred()) || t.After(n.LastRepeated()) {
+		return false, nil
+	}
+	return after.IsZero() || t.After(after), nil
+}
+
 // maybeAddRefreshInhibitWarningFallback records a warning if the set of
 // inhibited snaps was changed since the last notice.
 //
@@ -1291,6 +1291,7 @@ func (s *autoRefreshTestSuite) testMaybeAddRefreshInhibitNotice(c *C, markerInte
 	// notice recorded
 	expectedOccurrances := 1
 	checkRefreshInhibitNotice(c, st, expectedOccurrances)
+	checkRefreshInhibitNoticeSnaps(c, st, "some-snap")
 	// check warnings fallback
 	if warningFallback {
 		checkRefreshInhibitWarning(c, st, []string{"some-snap"}, warningTime)
@@ -1345,6 +1346,7 @@ func (s *autoRefreshTestSuite) testMaybeAddRefreshInhibitNotice(c *C, markerInte
 	// notice recorded
 	expectedOccurrances++
 	checkRefreshInhibitNotice(c, st, expectedOccurrances)
+	checkRefreshInhibitNoticeSnaps(c, st, "some-other-snap,some-snap")
 	// check warnings fallback
 	if warningFallback {
 		checkRefreshInhibitWarning(c, st, []string{"some-other-snap"}, warningTime)
@@ -1372,6 +1374,7 @@ func (s *autoRefreshTestSuite) testMaybeAddRefreshInhibitNotice(c *C, markerInte
 	// notice recorded
 	expectedOccurrances++
 	checkRefreshInhibitNotice(c, st, expectedOccurrances)
+	checkRefreshInhibitNoticeSnaps(c, st, "some-other-snap")
 	// no warning should be recorded and existing warning should be
 	// removed if inhibited snaps set is empty
 	checkNoRefreshInhibitWarning(c, st)
@@ -1407,6 +1410,7 @@ func (s *autoRefreshTestSuite) testMaybeAddRefreshInhibitNotice(c *C, markerInte
 	// notice recorded
 	expectedOccurrances++
 	checkRefreshInhibitNotice(c, st, expectedOccurrances)
+	checkRefreshInhibitNoticeSnaps(c, st, "some-other-snap,some-snap")
 	// check warnings fallback
 	if warningFallback {
 		checkRefreshInhibitWarning(c, st, []string{"some-snap", "some-other-snap"}, warningTime)
@@ -1417,6 +1421,68 @@ func (s *autoRefreshTestSuite) testMaybeAddRefreshInhibitNotice(c *C, markerInte
 	checkLastRecordedInhibitedSnaps(c, st, []string{"some-snap", "some-other-snap"})
 }
 
+func checkRefreshInhibitNoticeSnaps(c *C, st *state.State, snapNames string) {
+	notices := st.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.RefreshInhibitNotice}})
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].LastData(), DeepEquals, map[string]string{"snap-names": snapNames})
+}
+
+func (s *autoRefreshTestSuite) TestRefreshInhibitNoticeConcernsSnap(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	inhibit := func(snapName string) {
+		now := time.Now()
+		snapstate.Set(st, snapName, &snapstate.SnapState{
+			Active: true,
+			Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+				{RealName: snapName, SnapID: snapName + "-id", Revision: snap.R(1)},
+			}),
+			Current:              snap.R(1),
+			SnapType:             string(snap.TypeApp),
+			RefreshInhibitedTime: &now,
+		})
+	}
+
+	t0 := time.Now()
+	restore := state.MockTime(t0)
+	defer restore()
+	inhibit("some-snap")
+	c.Assert(snapstate.MaybeAddRefreshInhibitNotice(st), IsNil)
+
+	t1 := t0.Add(time.Hour)
+	restore = state.MockTime(t1)
+	defer restore()
+	inhibit("some-other-snap")
+	c.Assert(snapstate.MaybeAddRefreshInhibitNotice(st), IsNil)
+
+	notices := st.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.RefreshInhibitNotice}})
+	c.Assert(notices, HasLen, 1)
+	n := notices[0]
+	// the last occurrence only lists some-other-snap
+	c.Check(n.LastData(), DeepEquals, map[string]string{"snap-names": "some-other-snap"})
+
+	for _, t := range []struct {
+		snapName string
+		after    time.Time
+		concerns bool
+	}{
+		// some-snap still matches after the notice repeated
+		{"some-snap", time.Time{}, true},
+		{"some-snap", t0.Add(-time.Minute), true},
+		{"some-snap", t0.Add(time.Minute), false},
+		{"some-other-snap", time.Time{}, true},
+		{"some-other-snap", t0.Add(time.Minute), true},
+		{"some-other-snap", t1, false},
+		{"unrelated-snap", time.Time{}, false},
+	} {
+		concerns, err := snapstate.RefreshInhibitNoticeConcernsSnap(st, n, t.snapName, t.after)
+		c.Assert(err, IsNil)
+		c.Check(concerns, Equals, t.concerns, Commentf("%s after %v", t.snapName, t.after))
+	}
+}
+
 func (s *autoRefreshTestSuite) TestMaybeAddRefreshInhibitNotice(c *C) {
 	s.enableRefreshAppAwarenessUX()
 	const markerInterfaceConnected = true
@@ -26,6 +26,7 @@ import (
 	"github.com/snapcore/snapd/kernel"
 	"github.com/snapcore/snapd/osutil/sys"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/integrity"
 	"github.com/snapcore/snapd/testutil"
 	"github.com/snapcore/snapd/timings"
 	"github.com/snapcore/snapd/wrappers"
@@ -77,6 +78,10 @@ func MockKernelEnsureKernelDriversTree(f func(kMntPts kernel.MountPoints, compsM
 	return testutil.Mock(&kernelEnsureKernelDriversTree, f)
 }
 
+func MockLookupDmVerityDataAndCrossCheck(f func(snapPath string, params *integrity.IntegrityDataParams) (string, error)) func() {
+	return testutil.Mock(&lookupDmVerityDataAndCrossCheck, f)
+}
+
 func MockCgroupKillSnapProcesses(f func(ctx context.Context, snapName string) error) func() {
 	return testutil.Mock(&cgroupKillSnapProcesses, f)
 }
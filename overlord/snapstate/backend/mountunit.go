@@ -20,6 +20,7 @@
 package backend
 
 import (
+	"fmt"
 	"path/filepath"
 	"strings"
 
@@ -27,6 +28,7 @@ import (
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/integrity"
 	"github.com/snapcore/snapd/systemd"
 )
 
@@ -40,7 +42,20 @@ type MountUnitFlags struct {
 	StartBeforeDriversLoad bool
 }
 
-func addMountUnit(c snap.ContainerPlaceInfo, sysd systemd.Systemd, mountFlags MountUnitFlags) error {
+var lookupDmVerityDataAndCrossCheck = integrity.LookupDmVerityDataAndCrossCheck
+
+// DmVerityMountOptions adds to the mount unit options of the given container
+// the options to mount it with dm-verity, using the dm-verity data installed
+// next to its file which must match the given integrity data.
+func DmVerityMountOptions(c snap.ContainerPlaceInfo, idp *integrity.IntegrityDataParams, mountOptions *systemd.MountUnitOptions) error {
+	hashDevice, err := lookupDmVerityDataAndCrossCheck(c.MountFile(), idp)
+	if err != nil {
+		return fmt.Errorf("cannot mount %q with dm-verity: %w", c.ContainerName(), err)
+	}
+	return systemd.AddDmVerityMountOptions(mountOptions, dirs.StripRootDir(hashDevice), idp.Digest)
+}
+
+func addMountUnit(c snap.ContainerPlaceInfo, sysd systemd.Systemd, mountFlags MountUnitFlags, idp *integrity.IntegrityDataParams) error {
 	squashfsPath := dirs.StripRootDir(c.MountFile())
 	whereDir := dirs.StripRootDir(c.MountDir())
 
@@ -55,6 +70,11 @@ func addMountUnit(c snap.ContainerPlaceInfo, sysd systemd.Systemd, mountFlags Mo
 	if err := sysd.ConfigureMountUnitOptions(mountOptions, "squashfs", mountFlags.StartBeforeDriversLoad); err != nil {
 		return err
 	}
+	if idp != nil {
+		if err := DmVerityMountOptions(c, idp, mountOptions); err != nil {
+			return err
+		}
+	}
 
 	_, err := sysd.EnsureMountUnitFile(mountOptions)
 	return err
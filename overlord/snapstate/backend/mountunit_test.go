@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	. "gopkg.in/check.v1"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/snapcore/snapd/overlord/snapstate/backend"
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/integrity"
 	"github.com/snapcore/snapd/systemd"
 	"github.com/snapcore/snapd/systemd/systemdtest"
 	"github.com/snapcore/snapd/testutil"
@@ -77,7 +79,7 @@ func (s *mountunitSuite) testAddMountUnit(c *C, flags backend.MountUnitFlags) {
 		Version:       "1.1",
 		Architectures: []string{"all"},
 	}
-	err := backend.AddMountUnit(info, systemd.New(systemd.SystemMode, progress.Null), flags)
+	err := backend.AddMountUnit(info, systemd.New(systemd.SystemMode, progress.Null), flags, nil)
 	c.Check(err, Equals, expectedErr)
 
 	// ensure correct parameters
@@ -101,6 +103,65 @@ func (s *mountunitSuite) testAddMountUnit(c *C, flags backend.MountUnitFlags) {
 	})
 }
 
+func (s *mountunitSuite) TestAddMountUnitWithIntegrityData(c *C) {
+	var sysd *systemdtest.FakeSystemd
+	restore := systemd.MockNewSystemd(func(be systemd.Backend, roodDir string, mode systemd.InstanceMode, meter systemd.Reporter) systemd.Systemd {
+		sysd = &systemdtest.FakeSystemd{}
+		sysd.ConfigureMountUnitOptionsResults.Fstype = "squashfs"
+		sysd.ConfigureMountUnitOptionsResults.Options = []string{"nodev", "ro"}
+		return sysd
+	})
+	defer restore()
+
+	idp := &integrity.IntegrityDataParams{Type: "dm-verity", Digest: "abcd"}
+	restore = backend.MockLookupDmVerityDataAndCrossCheck(func(snapPath string, params *integrity.IntegrityDataParams) (string, error) {
+		c.Check(snapPath, Equals, filepath.Join(dirs.SnapBlobDir, "foo_13.snap"))
+		c.Check(params, Equals, idp)
+		return snapPath + ".dmverity_abcd", nil
+	})
+	defer restore()
+
+	info := &snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "foo",
+			Revision: snap.R(13),
+		},
+		Version: "1.1",
+	}
+	err := backend.AddMountUnit(info, systemd.New(systemd.SystemMode, progress.Null), backend.MountUnitFlags{}, idp)
+	c.Assert(err, IsNil)
+
+	c.Assert(sysd.EnsureMountUnitFileCalls, HasLen, 1)
+	c.Check(sysd.EnsureMountUnitFileCalls[0].Options, DeepEquals, []string{
+		"nodev", "ro",
+		"verity.hashdevice=/var/lib/snapd/snaps/foo_13.snap.dmverity_abcd",
+		"verity.roothash=abcd",
+	})
+}
+
+func (s *mountunitSuite) TestAddMountUnitWithIntegrityDataNotFound(c *C) {
+	var sysd *systemdtest.FakeSystemd
+	restore := systemd.MockNewSystemd(func(be systemd.Backend, roodDir string, mode systemd.InstanceMode, meter systemd.Reporter) systemd.Systemd {
+		sysd = &systemdtest.FakeSystemd{}
+		sysd.ConfigureMountUnitOptionsResults.Fstype = "squashfs"
+		return sysd
+	})
+	defer restore()
+
+	info := &snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "foo",
+			Revision: snap.R(13),
+		},
+		Version: "1.1",
+	}
+	idp := &integrity.IntegrityDataParams{Type: "dm-verity", Digest: "abcd"}
+	err := backend.AddMountUnit(info, systemd.New(systemd.SystemMode, progress.Null), backend.MountUnitFlags{}, idp)
+	c.Assert(err, ErrorMatches, `cannot mount "foo" with dm-verity: dm-verity data not found: .*foo_13.snap.dmverity_abcd" doesn't exist.`)
+	c.Check(errors.Is(err, integrity.ErrDmVerityDataNotFound), Equals, true)
+	c.Check(sysd.EnsureMountUnitFileCalls, HasLen, 0)
+}
+
 func (s *mountunitSuite) TestRemoveMountUnit(c *C) {
 	expectedErr := errors.New("removal error")
 
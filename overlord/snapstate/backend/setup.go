@@ -35,6 +35,7 @@ import (
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/integrity"
 	"github.com/snapcore/snapd/systemd"
 )
 
@@ -50,6 +51,10 @@ type InstallRecord struct {
 
 type SetupSnapOptions struct {
 	SkipKernelExtraction bool
+	// IntegrityDataParams are the verified integrity data of the snap, if
+	// set the dm-verity data next to the snap file are installed with it
+	// and the snap is mounted with dm-verity.
+	IntegrityDataParams *integrity.IntegrityDataParams
 }
 
 // SetupSnap does prepare and mount the snap for further processing.
@@ -95,7 +100,9 @@ func (b Backend) SetupSnap(snapFilePath, instanceName string, sideInfo *snap.Sid
 
 	// in uc20+ and classic with modes run mode, all snaps must be on the
 	// same device
-	opts := &snap.InstallOptions{}
+	opts := &snap.InstallOptions{
+		IntegrityDataParams: setupOpts.IntegrityDataParams,
+	}
 	if dev.HasModeenv() && dev.RunMode() {
 		opts.MustNotCrossDevices = true
 	}
@@ -113,7 +120,7 @@ func (b Backend) SetupSnap(snapFilePath, instanceName string, sideInfo *snap.Sid
 		// systemd seems to be buggy if we enable this.
 		StartBeforeDriversLoad: t == snap.TypeKernel && dev.HasModeenv(),
 	}
-	if err := addMountUnit(s, newSystemd(b.preseed, meter), mountFlags); err != nil {
+	if err := addMountUnit(s, newSystemd(b.preseed, meter), mountFlags, setupOpts.IntegrityDataParams); err != nil {
 		return snapType, nil, err
 	}
 
@@ -196,7 +203,9 @@ func (b Backend) SetupComponent(compFilePath string, compPi snap.ContainerPlaceI
 		// systemd seems to be buggy if we enable this.
 		StartBeforeDriversLoad: compInfo.Type == snap.KernelModulesComponent && dev.HasModeenv(),
 	}
-	if err := addMountUnit(compPi, newSystemd(b.preseed, meter), mountFlags); err != nil {
+	// components are mounted without dm-verity, snapstate refuses to
+	// set them up when it is enforced
+	if err := addMountUnit(compPi, newSystemd(b.preseed, meter), mountFlags, nil); err != nil {
 		return nil, err
 	}
 
@@ -530,6 +530,43 @@ func (bs *bootedSuite) TestFinishRestartKernelClassicWithModes(c *C) {
 	c.Check(err, ErrorMatches, `cannot finish kernel installation, there was a rollback across reboot`)
 }
 
+func (bs *bootedSuite) TestFinishRestartGadgetABSlots(c *C) {
+	bl := bootloadertest.Mock("mock", c.MkDir()).WithABSlots()
+	bootloader.Force(bl)
+
+	st := bs.state
+	st.Lock()
+	defer st.Unlock()
+
+	chg := st.NewChange("refresh", "...")
+	task := st.NewTask("auto-connect", "...")
+	chg.AddTask(task)
+
+	si := &snap.SideInfo{RealName: "gadget", Revision: snap.R(2)}
+	snapsup := &snapstate.SnapSetup{SideInfo: si, Type: snap.TypeGadget}
+
+	// no A/B slots were tried
+	err := snapstate.FinishRestart(task, snapsup, snapstate.FinishRestartOptions{FinishRestartDefault: true})
+	c.Check(err, IsNil)
+
+	chg.Set("gadget-ab-slots", map[string]string{"bootloader": "b"})
+
+	// restarted, the try is not committed yet
+	bl.SetABSlotState("bootloader", &bootloader.ABSlotState{Active: "a", Try: "b", Status: boot.TryingStatus})
+	err = snapstate.FinishRestart(task, snapsup, snapstate.FinishRestartOptions{FinishRestartDefault: true})
+	c.Check(err, DeepEquals, &state.Retry{After: 5 * time.Second})
+
+	// the try slot was booted and committed
+	bl.SetABSlotState("bootloader", &bootloader.ABSlotState{Active: "b", Status: boot.DefaultStatus})
+	err = snapstate.FinishRestart(task, snapsup, snapstate.FinishRestartOptions{FinishRestartDefault: true})
+	c.Check(err, IsNil)
+
+	// the firmware fell back to the active slot, rollback!
+	bl.SetABSlotState("bootloader", &bootloader.ABSlotState{Active: "a", Status: boot.DefaultStatus})
+	err = snapstate.FinishRestart(task, snapsup, snapstate.FinishRestartOptions{FinishRestartDefault: true})
+	c.Check(err, ErrorMatches, `cannot finish gadget installation, there was a rollback across reboot: slot "b" of group "bootloader" was not booted, the firmware fell back to slot "a"`)
+}
+
 func (bs *bootedSuite) TestFinishRestartEphemeralModeSkipsRollbackDetection(c *C) {
 	r := snapstatetest.MockDeviceModel(DefaultModel())
 	defer r()
@@ -28,6 +28,7 @@ import (
 	"github.com/snapcore/snapd/overlord/snapstate/backend"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/integrity"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/testutil"
 	userclient "github.com/snapcore/snapd/usersession/client"
@@ -154,6 +155,16 @@ var (
 	ResealingTaskBlocked = resealingTaskBlocked
 )
 
+var IntegrityDataForMount = integrityDataForMount
+
+func MockGenerateDmVerityData(f func(snapPath string, idp *integrity.IntegrityDataParams) (string, error)) (restore func()) {
+	return testutil.Mock(&generateDmVerityData, f)
+}
+
+func MockValidatedIntegrityData(f func(st *state.State, snapID string, rev snap.Revision) (*integrity.IntegrityDataParams, error)) (restore func()) {
+	return testutil.Mock(&ValidatedIntegrityData, f)
+}
+
 func ResealingTaskKinds() []string {
 	kinds := make([]string, 0, len(resealingTaskKindCheckers))
 	for kind := range resealingTaskKindCheckers {
@@ -852,8 +852,16 @@ func (m *SnapManager) doMountSnap(t *state.Task, _ *tomb.Tomb) error {
 
 	}
 
+	st.Lock()
+	idp, err := integrityDataForMount(st, snapsup)
+	st.Unlock()
+	if err != nil {
+		return err
+	}
+
 	setupOpts := &backend.SetupSnapOptions{
 		SkipKernelExtraction: snapsup.SkipKernelExtraction,
+		IntegrityDataParams:  idp,
 	}
 	pb := NewTaskProgressAdapterUnlocked(t)
 	// TODO Use snapsup.Revision() to obtain the right info to mount
@@ -254,6 +254,13 @@ func (m *SnapManager) doMountComponent(t *state.Task, _ *tomb.Tomb) (retErr erro
 	cpi := snap.MinimalComponentContainerPlaceInfo(compSetup.ComponentName(),
 		csi.Revision, snapsup.InstanceName())
 
+	st.Lock()
+	err = checkComponentIntegrity(st, csi)
+	st.Unlock()
+	if err != nil {
+		return err
+	}
+
 	defer func() {
 		st.Lock()
 		defer st.Unlock()
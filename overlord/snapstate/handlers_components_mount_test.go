@@ -21,9 +21,11 @@ package snapstate_test
 
 import (
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
 	"github.com/snapcore/snapd/snap"
@@ -86,6 +88,40 @@ func (s *mountCompSnapSuite) TestDoMountComponent(c *C) {
 	c.Assert(osutil.FileExists(compPath), Equals, true)
 }
 
+func (s *mountCompSnapSuite) TestDoMountComponentFailsIntegrityEnforced(c *C) {
+	const snapName = "mysnap"
+	const compName = "mycomp"
+	snapRev := snap.R(1)
+	compRev := snap.R(7)
+	si := createTestSnapInfoForComponent(c, snapName, snapRev, compName)
+	compPath := filepath.Join(c.MkDir(), "mysnap+mycomp.comp")
+	ssu := createTestSnapSetup(si, snapstate.Flags{})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "system.snap-integrity", "enforce"), IsNil)
+	tr.Commit()
+
+	t := s.state.NewTask("mount-component", "task desc")
+	cref := naming.NewComponentRef(snapName, compName)
+	csi := snap.NewComponentSideInfo(cref, compRev)
+	t.Set("component-setup", snapstate.NewComponentSetup(csi, snap.StandardComponent, compPath))
+	t.Set("snap-setup", ssu)
+	chg := s.state.NewChange("test change", "change desc")
+	chg.AddTask(t)
+
+	s.state.Unlock()
+	s.se.Ensure()
+	s.se.Wait()
+	s.state.Lock()
+
+	c.Check(chg.Err().Error(), Equals, "cannot perform the following tasks:\n"+
+		"- task desc (cannot mount component \"mysnap+mycomp\" without verified integrity data as required by system.snap-integrity)")
+	c.Check(s.fakeBackend.ops, HasLen, 0)
+}
+
 func (s *mountCompSnapSuite) TestDoMountComponentFailsUnassertedComponentAssertedSnap(c *C) {
 	const snapName = "mysnap"
 	const compName = "mycomp"
@@ -20,16 +20,30 @@
 package snapstate_test
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
 	"path/filepath"
 	"time"
 
 	. "gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/squashfs"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/sequence"
 	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/integrity"
+	"github.com/snapcore/snapd/snap/integrity/dmverity"
+	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/snap/snaptest"
 )
 
@@ -530,3 +544,229 @@ func (s *mountSnapSuite) TestDoMountNotMountedRetryRetry(c *C) {
 		},
 	})
 }
+
+func writeDmVerityData(c *C, snapPath string, idp *integrity.IntegrityDataParams) {
+	sb := dmverity.VeritySuperblock{
+		Version:       dmverity.DefaultSuperblockVersion,
+		HashType:      dmverity.DefaultVerityFormat,
+		DataBlockSize: uint32(idp.DataBlockSize),
+		HashBlockSize: uint32(idp.HashBlockSize),
+	}
+	copy(sb.Signature[:], "verity")
+	copy(sb.Algorithm[:], idp.HashAlg)
+	salt, err := hex.DecodeString(idp.Salt)
+	c.Assert(err, IsNil)
+	sb.SaltSize = uint16(copy(sb.Salt[:], salt))
+
+	var buf bytes.Buffer
+	c.Assert(binary.Write(&buf, binary.LittleEndian, &sb), IsNil)
+	hashFile, err := idp.IntegrityFile(snapPath)
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(filepath.Dir(hashFile), 0755), IsNil)
+	c.Assert(os.WriteFile(hashFile, buf.Bytes(), 0644), IsNil)
+}
+
+func (s *mountSnapSuite) TestIntegrityDataForMount(c *C) {
+	idp := &integrity.IntegrityDataParams{
+		Type:          "dm-verity",
+		Version:       1,
+		HashAlg:       "sha256",
+		DataBlockSize: 4096,
+		HashBlockSize: 4096,
+		Digest:        "abcd",
+		Salt:          "0102",
+	}
+	restore := snapstate.MockValidatedIntegrityData(func(st *state.State, snapID string, rev snap.Revision) (*integrity.IntegrityDataParams, error) {
+		switch snapID {
+		case "foo-id":
+			return idp, nil
+		case "bar-id":
+			return nil, integrity.ErrNoIntegrityDataFoundInRevision
+		}
+		return nil, fmt.Errorf("unexpected snap-id %q", snapID)
+	})
+	defer restore()
+
+	snapPath := filepath.Join(c.MkDir(), "foo_33.snap")
+	snapsup := func(snapID string) *snapstate.SnapSetup {
+		return &snapstate.SnapSetup{
+			SideInfo: &snap.SideInfo{RealName: "foo", SnapID: snapID, Revision: snap.R(33)},
+			SnapPath: snapPath,
+		}
+	}
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	setPolicy := func(policy string) {
+		tr := config.NewTransaction(s.state)
+		c.Assert(tr.Set("core", "system.snap-integrity", policy), IsNil)
+		tr.Commit()
+	}
+
+	// not enabled
+	res, err := snapstate.IntegrityDataForMount(s.state, snapsup("foo-id"))
+	c.Assert(err, IsNil)
+	c.Check(res, IsNil)
+
+	var generateErr error
+	restore = snapstate.MockGenerateDmVerityData(func(path string, params *integrity.IntegrityDataParams) (string, error) {
+		c.Check(path, Equals, snapPath)
+		c.Check(params, Equals, idp)
+		return "", generateErr
+	})
+	defer restore()
+
+	for _, policy := range []string{snapstate.SnapIntegrityVerify, snapstate.SnapIntegrityEnforce} {
+		setPolicy(policy)
+		logbuf, restore := logger.MockLogger()
+
+		// the dm-verity data cannot be generated
+		generateErr = errors.New("veritysetup failed")
+		res, err = snapstate.IntegrityDataForMount(s.state, snapsup("foo-id"))
+		if policy == snapstate.SnapIntegrityEnforce {
+			c.Check(err, ErrorMatches, `cannot use integrity data of snap "foo": veritysetup failed`)
+		} else {
+			c.Check(err, IsNil)
+			c.Check(res, IsNil)
+			c.Check(logbuf.String(), Matches, `(?s).*WARNING: cannot generate dm-verity data of snap "foo", mounting it without dm-verity: veritysetup failed\n`)
+		}
+
+		// the generated dm-verity data do not match the snap-revision
+		generateErr = fmt.Errorf("%w: unexpected root hash", integrity.ErrUnexpectedDmVerityData)
+		res, err = snapstate.IntegrityDataForMount(s.state, snapsup("foo-id"))
+		c.Check(err, ErrorMatches, `cannot use integrity data of snap "foo": unexpected dm-verity data: unexpected root hash`)
+		c.Check(res, IsNil)
+
+		// the dm-verity data are generated
+		generateErr = nil
+		res, err = snapstate.IntegrityDataForMount(s.state, snapsup("foo-id"))
+		c.Check(err, IsNil)
+		c.Check(res, Equals, idp)
+
+		// no integrity data in the snap-revision
+		res, err = snapstate.IntegrityDataForMount(s.state, snapsup("bar-id"))
+		if policy == snapstate.SnapIntegrityEnforce {
+			c.Check(err, ErrorMatches, `cannot mount snap "foo" without verified integrity data as required by system.snap-integrity`)
+		} else {
+			c.Check(err, IsNil)
+			c.Check(res, IsNil)
+			c.Check(logbuf.String(), Matches, `(?s).*WARNING: snap "foo" has no verified integrity data, mounting it without dm-verity\n`)
+		}
+
+		// installed with --dangerous
+		res, err = snapstate.IntegrityDataForMount(s.state, snapsup(""))
+		if policy == snapstate.SnapIntegrityEnforce {
+			c.Check(err, ErrorMatches, `cannot mount snap "foo" without verified integrity data as required by system.snap-integrity`)
+		} else {
+			c.Check(err, IsNil)
+			c.Check(res, IsNil)
+		}
+		restore()
+	}
+
+	// the dm-verity data were found next to the snap
+	generateErr = errors.New("unexpected call")
+	// the dm-verity data do not match the snap-revision
+	otherIdp := *idp
+	otherIdp.Salt = "0304"
+	writeDmVerityData(c, snapPath, &otherIdp)
+	res, err = snapstate.IntegrityDataForMount(s.state, snapsup("foo-id"))
+	c.Check(err, ErrorMatches, `cannot use integrity data of snap "foo": unexpected dm-verity data .*: unexpected salt: 0304 != 0102`)
+	c.Check(res, IsNil)
+}
+
+func (s *mountSnapSuite) TestIntegrityDataForMountSquashfuse(c *C) {
+	restore := squashfs.MockNeedsFuse(true)
+	defer restore()
+	restore = snapstate.MockValidatedIntegrityData(func(st *state.State, snapID string, rev snap.Revision) (*integrity.IntegrityDataParams, error) {
+		c.Error("unexpected call")
+		return nil, nil
+	})
+	defer restore()
+
+	snapsup := &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{RealName: "foo", SnapID: "foo-id", Revision: snap.R(33)},
+		SnapPath: filepath.Join(c.MkDir(), "foo_33.snap"),
+	}
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "system.snap-integrity", snapstate.SnapIntegrityVerify), IsNil)
+	tr.Commit()
+
+	// dm-verity is not supported for squashfuse mounts
+	res, err := snapstate.IntegrityDataForMount(s.state, snapsup)
+	c.Assert(err, IsNil)
+	c.Check(res, IsNil)
+
+	tr = config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "system.snap-integrity", snapstate.SnapIntegrityEnforce), IsNil)
+	tr.Commit()
+
+	res, err = snapstate.IntegrityDataForMount(s.state, snapsup)
+	c.Assert(err, ErrorMatches, `cannot mount snaps with dm-verity as required by system.snap-integrity: squashfs images are mounted with squashfuse`)
+	c.Check(res, IsNil)
+}
+
+func (s *mountSnapSuite) TestCheckSnapIntegrityEnforceable(c *C) {
+	idp := &integrity.IntegrityDataParams{
+		Type:          "dm-verity",
+		Version:       1,
+		HashAlg:       "sha256",
+		DataBlockSize: 4096,
+		HashBlockSize: 4096,
+		Digest:        "abcd",
+		Salt:          "0102",
+	}
+	restore := snapstate.MockValidatedIntegrityData(func(st *state.State, snapID string, rev snap.Revision) (*integrity.IntegrityDataParams, error) {
+		if snapID != "foo-id" {
+			return nil, fmt.Errorf("unexpected snap-id %q", snapID)
+		}
+		return idp, nil
+	})
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	si1 := &snap.SideInfo{RealName: "foo", SnapID: "foo-id", Revision: snap.R(1)}
+	si2 := &snap.SideInfo{RealName: "foo", SnapID: "foo-id", Revision: snap.R(2)}
+	snapstate.Set(s.state, "foo", &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si1, si2}),
+		Current:  snap.R(2),
+		Active:   true,
+	})
+
+	// the dm-verity data of the current revision were installed but not
+	// those of the previous one
+	writeDmVerityData(c, snap.MinimalPlaceInfo("foo", snap.R(2)).MountFile(), idp)
+	err := snapstate.CheckSnapIntegrityEnforceable(s.state)
+	c.Assert(err, ErrorMatches, `cannot enforce system.snap-integrity: snap "foo" revision 1 is mounted without verified integrity data`)
+
+	writeDmVerityData(c, snap.MinimalPlaceInfo("foo", snap.R(1)).MountFile(), idp)
+	err = snapstate.CheckSnapIntegrityEnforceable(s.state)
+	c.Assert(err, IsNil)
+
+	// components are always mounted without dm-verity
+	csi := snap.NewComponentSideInfo(naming.NewComponentRef("foo", "comp"), snap.R(11))
+	snapstate.Set(s.state, "foo", &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromRevisionSideInfos([]*sequence.RevisionSideState{
+			sequence.NewRevisionSideState(si1, nil),
+			sequence.NewRevisionSideState(si2, []*sequence.ComponentState{
+				sequence.NewComponentState(csi, snap.StandardComponent),
+			}),
+		}),
+		Current: snap.R(2),
+		Active:  true,
+	})
+	err = snapstate.CheckSnapIntegrityEnforceable(s.state)
+	c.Assert(err, ErrorMatches, `cannot enforce system.snap-integrity: components of snap "foo" are mounted without verified integrity data`)
+
+	restore = squashfs.MockNeedsFuse(true)
+	defer restore()
+	err = snapstate.CheckSnapIntegrityEnforceable(s.state)
+	c.Assert(err, ErrorMatches, `cannot enforce system.snap-integrity: squashfs images are mounted with squashfuse which does not support dm-verity`)
+}
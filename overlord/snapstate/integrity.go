@@ -0,0 +1,235 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil/squashfs"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/integrity"
+)
+
+// Values of the system.snap-integrity core option.
+const (
+	// SnapIntegrityVerify mounts snaps with dm-verity when verified
+	// integrity data are available for them.
+	SnapIntegrityVerify = "verify"
+	// SnapIntegrityEnforce mounts snaps with dm-verity and refuses to
+	// mount snaps without verified integrity data.
+	SnapIntegrityEnforce = "enforce"
+)
+
+// snapIntegrityPolicy returns the value of the system.snap-integrity option,
+// an empty string means that snaps are mounted without dm-verity.
+func snapIntegrityPolicy(st *state.State) (string, error) {
+	var policy string
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "system.snap-integrity", &policy); err != nil && !config.IsNoOption(err) {
+		return "", err
+	}
+	return policy, nil
+}
+
+// validatedIntegrityData returns the integrity data of the given snap revision
+// found in its snap-revision assertion, or nil if the snap has no snap-id or
+// its snap-revision has no integrity data.
+func validatedIntegrityData(st *state.State, snapID string, rev snap.Revision) (*integrity.IntegrityDataParams, error) {
+	if snapID == "" || ValidatedIntegrityData == nil {
+		return nil, nil
+	}
+	idp, err := ValidatedIntegrityData(st, snapID, rev)
+	if errors.Is(err, integrity.ErrNoIntegrityDataFoundInRevision) {
+		return nil, nil
+	}
+	return idp, err
+}
+
+// generateDmVerityData is used to generate the dm-verity data of snaps which
+// come without them.
+var generateDmVerityData = integrity.GenerateDmVerityDataAndCrossCheck
+
+// integrityDataForMount returns the verified integrity data to use to mount
+// the snap being set up with dm-verity, according to the system.snap-integrity
+// option. The dm-verity data are generated next to the snap when they are
+// missing and their root hash is checked against the snap-revision. It returns
+// nil if the snap is to be mounted without dm-verity and an error if the
+// policy is enforced and the snap has no verified integrity data or its
+// dm-verity data cannot be generated.
+func integrityDataForMount(st *state.State, snapsup *SnapSetup) (*integrity.IntegrityDataParams, error) {
+	policy, err := snapIntegrityPolicy(st)
+	if err != nil {
+		return nil, err
+	}
+	if policy == "" {
+		return nil, nil
+	}
+	if err := checkDmVeritySupported(policy); err != nil {
+		return nil, err
+	}
+	if squashfs.NeedsFuse() {
+		return nil, nil
+	}
+
+	idp, err := validatedIntegrityData(st, snapsup.SideInfo.SnapID, snapsup.Revision())
+	if err != nil {
+		return nil, fmt.Errorf("cannot find integrity data of snap %q: %v", snapsup.InstanceName(), err)
+	}
+	if idp == nil {
+		if policy == SnapIntegrityEnforce {
+			return nil, fmt.Errorf("cannot mount snap %q without verified integrity data as required by system.snap-integrity", snapsup.InstanceName())
+		}
+		logger.Noticef("WARNING: snap %q has no verified integrity data, mounting it without dm-verity", snapsup.InstanceName())
+		return nil, nil
+	}
+
+	// the dm-verity data are expected next to the snap file, they are
+	// not downloaded from the store so generate them otherwise
+	_, err = integrity.LookupDmVerityDataAndCrossCheck(snapsup.SnapPath, idp)
+	if errors.Is(err, integrity.ErrDmVerityDataNotFound) {
+		_, err = generateDmVerityData(snapsup.SnapPath, idp)
+		if err != nil && !errors.Is(err, integrity.ErrUnexpectedDmVerityData) && policy != SnapIntegrityEnforce {
+			logger.Noticef("WARNING: cannot generate dm-verity data of snap %q, mounting it without dm-verity: %v", snapsup.InstanceName(), err)
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot use integrity data of snap %q: %v", snapsup.InstanceName(), err)
+	}
+	return idp, nil
+}
+
+// checkDmVeritySupported returns an error if the given policy requires
+// dm-verity but snaps cannot be mounted with it on this system, because
+// dm-verity is not supported for squashfuse mounts. When dm-verity is
+// not enforced snaps are mounted without it instead.
+func checkDmVeritySupported(policy string) error {
+	if policy == SnapIntegrityEnforce && squashfs.NeedsFuse() {
+		return errors.New("cannot mount snaps with dm-verity as required by system.snap-integrity: squashfs images are mounted with squashfuse")
+	}
+	return nil
+}
+
+// checkComponentIntegrity returns an error if the given component cannot be
+// mounted according to the system.snap-integrity option. The integrity data
+// of components are not used yet so they are always mounted without
+// dm-verity, which is refused when the policy is enforced.
+func checkComponentIntegrity(st *state.State, csi *snap.ComponentSideInfo) error {
+	policy, err := snapIntegrityPolicy(st)
+	if err != nil {
+		return err
+	}
+	if policy == SnapIntegrityEnforce {
+		return fmt.Errorf("cannot mount component %q without verified integrity data as required by system.snap-integrity", csi.Component)
+	}
+	return nil
+}
+
+// installedIntegrityData returns the verified integrity data of an installed
+// snap revision, or nil if the revision was not installed with its dm-verity
+// data.
+func installedIntegrityData(st *state.State, instanceName string, si *snap.SideInfo) (*integrity.IntegrityDataParams, error) {
+	idp, err := validatedIntegrityData(st, si.SnapID, si.Revision)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find integrity data of snap %q: %v", instanceName, err)
+	}
+	if idp == nil {
+		return nil, nil
+	}
+	mountFile := snap.MinimalPlaceInfo(instanceName, si.Revision).MountFile()
+	if _, err := integrity.LookupDmVerityDataAndCrossCheck(mountFile, idp); err != nil {
+		if errors.Is(err, integrity.ErrDmVerityDataNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot use integrity data of snap %q: %v", instanceName, err)
+	}
+	return idp, nil
+}
+
+// integrityDataForMountedSnap returns the verified integrity data to use when
+// regenerating the mount unit of an installed snap, it returns nil if the
+// snap was not installed with its dm-verity data and an error if the policy
+// is enforced in that case.
+func integrityDataForMountedSnap(st *state.State, info *snap.Info) (*integrity.IntegrityDataParams, error) {
+	policy, err := snapIntegrityPolicy(st)
+	if err != nil {
+		return nil, err
+	}
+	if policy == "" {
+		return nil, nil
+	}
+	if err := checkDmVeritySupported(policy); err != nil {
+		return nil, err
+	}
+	if squashfs.NeedsFuse() {
+		return nil, nil
+	}
+	idp, err := installedIntegrityData(st, info.InstanceName(), &info.SideInfo)
+	if err != nil {
+		return nil, err
+	}
+	if idp == nil && policy == SnapIntegrityEnforce {
+		return nil, fmt.Errorf("cannot mount snap %q without verified integrity data as required by system.snap-integrity", info.InstanceName())
+	}
+	return idp, nil
+}
+
+// CheckSnapIntegrityEnforceable returns an error if the system.snap-integrity
+// option cannot be set to enforce, because snaps cannot be mounted with
+// dm-verity on this system or because some installed snap revisions or
+// components were not installed with verified dm-verity data.
+func CheckSnapIntegrityEnforceable(st *state.State) error {
+	if squashfs.NeedsFuse() {
+		return errors.New("cannot enforce system.snap-integrity: squashfs images are mounted with squashfuse which does not support dm-verity")
+	}
+	snapStates, err := All(st)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(snapStates))
+	for name := range snapStates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		snapst := snapStates[name]
+		for _, si := range snapst.Sequence.SideInfos() {
+			idp, err := installedIntegrityData(st, name, si)
+			if err != nil {
+				return err
+			}
+			if idp == nil {
+				return fmt.Errorf("cannot enforce system.snap-integrity: snap %q revision %s is mounted without verified integrity data", name, si.Revision)
+			}
+		}
+		for _, rev := range snapst.Sequence.Revisions {
+			if len(rev.Components) != 0 {
+				return fmt.Errorf("cannot enforce system.snap-integrity: components of snap %q are mounted without verified integrity data", name)
+			}
+		}
+	}
+	return nil
+}
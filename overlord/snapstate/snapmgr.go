@@ -381,6 +381,9 @@ type SnapState struct {
 	ServicesDisabledByHooks     []string         `json:"services-disabled-by-hooks,omitempty"`
 	UserServicesDisabledByHooks map[int][]string `json:"user-services-disabled-by-hooks,omitempty"`
 
+	// timer schedules of apps overridden by hooks, keyed by app name
+	TimerSchedulesByHooks map[string]string `json:"timer-schedules-by-hooks,omitempty"`
+
 	// Current indicates the current active revision if Active is
 	// true or the last active revision if Active is false
 	// (usually while a snap is being operated on or disabled)
@@ -1546,6 +1549,17 @@ func (m *SnapManager) ensureMountsUpdated() error {
 			if err := sysd.ConfigureMountUnitOptions(mountOptions, "squashfs", startBeforeDriversLoad); err != nil {
 				return err
 			}
+			// keep using dm-verity for snaps installed with their
+			// integrity data
+			idp, err := integrityDataForMountedSnap(m.state, info)
+			if err != nil {
+				return err
+			}
+			if idp != nil {
+				if err := backend.DmVerityMountOptions(info, idp, mountOptions); err != nil {
+					return err
+				}
+			}
 
 			if _, err := sysd.EnsureMountUnitFile(mountOptions); err != nil {
 				return err
@@ -326,6 +326,14 @@ func FinishRestart(task *state.Task, snapsup *SnapSetup, opts FinishRestartOptio
 		return err
 	}
 
+	// The firmware may fall back from the A/B slots of raw structures
+	// updated with a gadget, which is a rollback of the gadget
+	if deviceCtx.RunMode() && snapsup.Type == snap.TypeGadget {
+		if err := checkGadgetABSlotsBooted(task, snapsup, deviceCtx); err != nil {
+			return err
+		}
+	}
+
 	// Check if there was a rollback. A reboot can be triggered by:
 	// - core (old core16 world, system-reboot)
 	// - bootable base snap (new core18 world, system-reboot)
@@ -385,6 +393,27 @@ func FinishRestart(task *state.Task, snapsup *SnapSetup, opts FinishRestartOptio
 	return nil
 }
 
+// checkGadgetABSlotsBooted checks that the A/B slots set to be tried by the
+// gadget update of the change of the task were booted, see
+// boot.CheckABSlotsBooted.
+func checkGadgetABSlotsBooted(task *state.Task, snapsup *SnapSetup, deviceCtx DeviceContext) error {
+	var tried map[string]string
+	if err := task.Change().Get("gadget-ab-slots", &tried); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return nil
+		}
+		return err
+	}
+	err := boot.CheckABSlotsBooted(deviceCtx, tried)
+	if err == boot.ErrBootNameAndRevisionNotReady {
+		return &state.Retry{After: 5 * time.Second}
+	}
+	if err != nil {
+		return fmt.Errorf("cannot finish %s installation, there was a rollback across reboot: %v", snapsup.InstanceName(), err)
+	}
+	return nil
+}
+
 // FinishTaskWithRestart will finish a task that needs a restart, by
 // setting its status and requesting a restart.
 // It should usually be invoked returning its result immediately
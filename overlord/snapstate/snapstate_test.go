@@ -69,6 +69,7 @@ import (
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/sandbox"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/integrity"
 	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/snapdenv"
@@ -10874,6 +10875,84 @@ WantedBy=multi-user.target
 	c.Assert(mountFile, testutil.FileEquals, expectedContent)
 }
 
+func (s *snapmgrTestSuite) TestEnsureSnapStateRewriteMountsIntegrityEnforced(c *C) {
+	testSnapSideInfo := &snap.SideInfo{RealName: "test-snap", Revision: snap.R(42)}
+	testSnapState := &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{testSnapSideInfo}),
+		Current:  snap.R(42),
+		Active:   true,
+		SnapType: "app",
+	}
+	testYaml := `name: test-snap
+version: v1
+`
+
+	s.state.Lock()
+	snapstate.Set(s.state, "test-snap", testSnapState)
+	snaptest.MockSnapCurrent(c, testYaml, testSnapSideInfo)
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "system.snap-integrity", "enforce"), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	unitName := systemd.EscapeUnitNamePath(dirs.StripRootDir(filepath.Join(dirs.SnapMountDir, "test-snap", "42.mount")))
+	mountFile := filepath.Join(dirs.SnapServicesDir, unitName)
+	if osutil.FileExists(mountFile) {
+		c.Assert(os.Remove(mountFile), IsNil)
+	}
+
+	restore := snapstate.MockEnsuredMountsUpdated(s.snapmgr, false)
+	defer restore()
+
+	err := s.snapmgr.Ensure()
+	c.Assert(err, ErrorMatches, `cannot mount snap ".*" without verified integrity data as required by system.snap-integrity`)
+	c.Check(mountFile, testutil.FileAbsent)
+}
+
+func (s *snapmgrTestSuite) TestEnsureSnapStateRewriteMountsIntegrityVerifySquashfuse(c *C) {
+	restore := squashfs.MockNeedsFuse(true)
+	defer restore()
+	fuseCmd := testutil.MockCommand(c, "squashfuse", "")
+	defer fuseCmd.Restore()
+
+	testSnapSideInfo := &snap.SideInfo{RealName: "test-snap", SnapID: "test-snap-id", Revision: snap.R(42)}
+	testSnapState := &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{testSnapSideInfo}),
+		Current:  snap.R(42),
+		Active:   true,
+		SnapType: "app",
+	}
+	testYaml := `name: test-snap
+version: v1
+`
+
+	s.state.Lock()
+	snapstate.Set(s.state, "test-snap", testSnapState)
+	snaptest.MockSnapCurrent(c, testYaml, testSnapSideInfo)
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "system.snap-integrity", "verify"), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	restore = snapstate.MockValidatedIntegrityData(func(st *state.State, snapID string, rev snap.Revision) (*integrity.IntegrityDataParams, error) {
+		c.Error("unexpected call")
+		return nil, nil
+	})
+	defer restore()
+
+	unitName := systemd.EscapeUnitNamePath(dirs.StripRootDir(filepath.Join(dirs.SnapMountDir, "test-snap", "42.mount")))
+	mountFile := filepath.Join(dirs.SnapServicesDir, unitName)
+
+	restore = snapstate.MockEnsuredMountsUpdated(s.snapmgr, false)
+	defer restore()
+
+	// dm-verity is not used for squashfuse mounts
+	err := s.snapmgr.Ensure()
+	c.Assert(err, IsNil)
+	c.Check(mountFile, testutil.FileContains, "Type=fuse.squashfuse")
+	c.Check(mountFile, Not(testutil.FileContains), "verity")
+}
+
 func (s *snapmgrTestSuite) TestEnsureSnapStateRewriteDesktopFiles(c *C) {
 	restore := snapstate.MockEnsuredDesktopFilesUpdated(s.snapmgr, false)
 	defer restore()
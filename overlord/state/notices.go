@@ -191,6 +191,11 @@ func (n *Notice) Key() string {
 	return n.key
 }
 
+// FirstOccurred returns the first occurred timestamp for this notice.
+func (n *Notice) FirstOccurred() time.Time {
+	return n.firstOccurred
+}
+
 // LastRepeated returns the last repeated timestamp for this notice.
 func (n *Notice) LastRepeated() time.Time {
 	return n.lastRepeated
@@ -307,11 +312,15 @@ const (
 	// expired. The key for interfaces-requests-rule-update notices is the
 	// rule ID.
 	InterfacesRequestsRuleUpdateNotice NoticeType = "interfaces-requests-rule-update"
+
+	// Recorded whenever the health status of a snap changes. The key for
+	// snap-health notices is the snap instance name.
+	SnapHealthNotice NoticeType = "snap-health"
 )
 
 func (t NoticeType) Valid() bool {
 	switch t {
-	case ChangeUpdateNotice, WarningNotice, RefreshInhibitNotice, SnapRunInhibitNotice, InterfacesRequestsPromptNotice, InterfacesRequestsRuleUpdateNotice:
+	case ChangeUpdateNotice, WarningNotice, RefreshInhibitNotice, SnapRunInhibitNotice, InterfacesRequestsPromptNotice, InterfacesRequestsRuleUpdateNotice, SnapHealthNotice:
 		return true
 	}
 	return false
@@ -281,6 +281,23 @@ func (sc *storeContext) WithSnapStoreDelta() bool {
 	return enabled
 }
 
+// CachePeer returns the URL of the snap downloads cache peer configured with
+// store.cache.peer, if any.
+func (sc *storeContext) CachePeer() (*url.URL, error) {
+	sc.state.Lock()
+	defer sc.state.Unlock()
+
+	tr := config.NewTransaction(sc.state)
+	var peer string
+	if err := tr.GetMaybe("core", "store.cache.peer", &peer); err != nil {
+		return nil, err
+	}
+	if peer == "" {
+		return nil, nil
+	}
+	return url.Parse(peer)
+}
+
 // CloudInfo returns the cloud instance information (if available).
 func (sc *storeContext) CloudInfo() (*auth.CloudInfo, error) {
 	sc.state.Lock()
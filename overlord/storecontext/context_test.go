@@ -524,3 +524,21 @@ func (s *storeCtxSuite) TestStoreOffline(c *C) {
 	c.Check(err, IsNil)
 	c.Check(offline, Equals, true)
 }
+
+func (s *storeCtxSuite) TestCachePeer(c *C) {
+	storeCtx := storecontext.New(s.state, &testBackend{nothing: true})
+
+	peer, err := storeCtx.CachePeer()
+	c.Assert(err, IsNil)
+	c.Check(peer, IsNil)
+
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "store.cache.peer", "http://10.0.0.5:8099")
+	tr.Commit()
+	s.state.Unlock()
+
+	peer, err = storeCtx.CachePeer()
+	c.Assert(err, IsNil)
+	c.Check(peer.String(), Equals, "http://10.0.0.5:8099")
+}
@@ -503,6 +503,13 @@ func SnapDeviceFile(securityTag string) string {
 	return filepath.Join(dirs.SnapCgroupPolicyDir, fmt.Sprintf("%s.device", securityTag))
 }
 
+// SnapNetworkFilterFile returns the path of the per app or hook file listing
+// the destinations which it can send traffic to. snap-confine attaches the
+// network filter of the security tag when the file is present.
+func SnapNetworkFilterFile(securityTag string) string {
+	return filepath.Join(dirs.SnapCgroupPolicyDir, fmt.Sprintf("%s.network", securityTag))
+}
+
 // LoadSnapDeviceCgroupOptions loads the device cgroup options for a given snap
 // security tag.
 func LoadSnapDeviceCgroupOptions(snapSecurityTag string) (opts SnapDeviceCgroupOptions, err error) {
@@ -18,4 +18,45 @@
  */
 package ebpf
 
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"golang.org/x/sys/unix"
+)
+
 var BpffsPinnedNameToSecurityTag = bpffsPinnedNameToSecurityTag
+
+// AttachNetworkFilter attaches the network filter pinned for the security
+// tag to the given cgroup, the same way snap-confine does.
+func AttachNetworkFilter(securityTag, cgroupPath string) error {
+	dir, err := SecurityTagToNetworkBPFDir(securityTag)
+	if err != nil {
+		return err
+	}
+	cgroup, err := os.Open(cgroupPath)
+	if err != nil {
+		return err
+	}
+	defer cgroup.Close()
+
+	for _, p := range networkPrograms {
+		prog, err := ebpf.LoadPinnedProgram(filepath.Join(dir, p.name), nil)
+		if err != nil {
+			return err
+		}
+		defer prog.Close()
+		err = link.RawAttachProgram(link.RawAttachProgramOptions{
+			Target:  int(cgroup.Fd()),
+			Program: prog,
+			Attach:  p.attach,
+			Flags:   unix.BPF_F_ALLOW_MULTI,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,745 @@
+// -*- Mode: Go; indent-tabs-mode: t; tab-width: 4 -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ebpf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/snap/naming"
+)
+
+// NetworkRule describes a destination which the snap is allowed to send
+// traffic to. A zero Port allows any port of the address.
+type NetworkRule struct {
+	IP   net.IP
+	Port uint16
+}
+
+// String returns the rule in the format accepted by ParseNetworkRule.
+func (r NetworkRule) String() string {
+	if r.Port == 0 {
+		return r.IP.String()
+	}
+	return net.JoinHostPort(r.IP.String(), strconv.Itoa(int(r.Port)))
+}
+
+// ParseNetworkRule parses a network rule in one of the forms <ipv4>,
+// <ipv4>:<port>, <ipv6> or [<ipv6>]:<port>. Host names are not supported as
+// the rules are enforced by the kernel on addresses.
+func ParseNetworkRule(s string) (NetworkRule, error) {
+	host, port := s, ""
+	if strings.HasPrefix(s, "[") || strings.Count(s, ":") == 1 {
+		var err error
+		host, port, err = net.SplitHostPort(s)
+		if err != nil {
+			return NetworkRule{}, fmt.Errorf("cannot parse network rule %q: %v", s, err)
+		}
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return NetworkRule{}, fmt.Errorf("cannot parse network rule %q: %q is not an IP address", s, host)
+	}
+	rule := NetworkRule{IP: ip}
+	if port != "" {
+		p, err := strconv.ParseUint(port, 10, 16)
+		if err != nil || p == 0 {
+			return NetworkRule{}, fmt.Errorf("cannot parse network rule %q: invalid port %q", s, port)
+		}
+		rule.Port = uint16(p)
+	}
+	return rule, nil
+}
+
+// NetworkKey is the key structure of the BPF map describing the destinations
+// which the snap is allowed to send traffic to. The layout is fixed and is
+// built on the stack by the network filter programs.
+type NetworkKey struct {
+	// Addr is the IPv4 address in the first 4 bytes with the remaining
+	// bytes zeroed, or the IPv6 address.
+	Addr [16]byte
+	// Port in host byte order, 0 matches any port.
+	Port uint16
+	// Family is 4 or 6.
+	Family uint8
+}
+
+// NetworkKeySize is the size of a marshaled NetworkKey, including a trailing
+// padding byte.
+const NetworkKeySize = 20
+
+// NetworkKeyFromRule returns the map key for the given rule.
+func NetworkKeyFromRule(r NetworkRule) NetworkKey {
+	k := NetworkKey{Port: r.Port}
+	if ip4 := r.IP.To4(); ip4 != nil {
+		k.Family = 4
+		copy(k.Addr[:], ip4)
+	} else {
+		k.Family = 6
+		copy(k.Addr[:], r.IP.To16())
+	}
+	return k
+}
+
+// MarshalBinary encodes the network key in the format expected by the BPF
+// map, with the address and port in network byte order. Implements
+// encoding.BinaryMarshaler.
+func (k *NetworkKey) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, NetworkKeySize)
+	copy(buf[0:16], k.Addr[:])
+	binary.BigEndian.PutUint16(buf[16:18], k.Port)
+	buf[18] = k.Family
+	return buf, nil
+}
+
+// UnmarshalBinary decodes the network key from the BPF map format.
+// Implements encoding.BinaryUnmarshaler.
+func (k *NetworkKey) UnmarshalBinary(data []byte) error {
+	if l := len(data); l < NetworkKeySize {
+		return fmt.Errorf("cannot unmarshal network key: unexpected size %v", l)
+	}
+	copy(k.Addr[:], data[0:16])
+	k.Port = binary.BigEndian.Uint16(data[16:18])
+	k.Family = data[18]
+	return nil
+}
+
+// NetworkMaxRules is the maximum number of rules of a network map.
+const NetworkMaxRules = 1024
+
+// networkMaxAccepted is the number of connections accepted by the snap which
+// are remembered by a network filter, older connections are evicted first.
+const networkMaxAccepted = 4096
+
+// SecurityTagToNetworkBPFDir returns the directory where the network filter
+// of the given snap security tag is pinned. The filters of a snap are kept
+// in a per snap directory, separate from the device maps.
+func SecurityTagToNetworkBPFDir(securityTag string) (string, error) {
+	tag, err := naming.ParseSecurityTag(securityTag)
+	if err != nil {
+		return "", err
+	}
+	name := strings.ReplaceAll(securityTag, ".", "_")
+	return filepath.Join(dirs.SnapBPFFSDir, "net", tag.InstanceName(), name), nil
+}
+
+// Stack layout shared by the network programs, the key is built at
+// keyOff, the packet headers are copied to hdrOff and map values are
+// built at valOff.
+const (
+	keyOff    = -24
+	keyPort   = keyOff + 16
+	keyFamily = keyOff + 18
+	hdrOff    = -64
+	valOff    = -72
+
+	// offsets in struct bpf_sock_addr
+	sockAddrUserIP4  = 4
+	sockAddrUserIP6  = 8
+	sockAddrUserPort = 24
+
+	// offsets in struct bpf_sock_ops
+	sockOpsOp = 0
+
+	// BPF_SOCK_OPS_PASSIVE_ESTABLISHED_CB and BPF_SOCK_OPS_TCP_LISTEN_CB
+	sockOpsPassiveEstablished = 5
+	sockOpsTCPListen          = 11
+
+	ipprotoTCP = 6
+	ipprotoUDP = 17
+)
+
+// networkMapFDs holds the maps used by the network programs.
+type networkMapFDs struct {
+	// rules is the map of the allowed destinations
+	rules int
+	// accepted is the map of the cookies of the sockets of the
+	// connections accepted by the snap
+	accepted int
+}
+
+// zeroKey clears the key area of the stack.
+func zeroKey() asm.Instructions {
+	return asm.Instructions{
+		asm.Mov.Imm(asm.R0, 0),
+		asm.StoreMem(asm.RFP, keyOff, asm.R0, asm.DWord),
+		asm.StoreMem(asm.RFP, keyOff+8, asm.R0, asm.DWord),
+		asm.StoreMem(asm.RFP, keyOff+16, asm.R0, asm.DWord),
+	}
+}
+
+// implicitAllowInstructions returns instructions jumping to label when the
+// key built on the stack is a loopback address, which is always allowed, and
+// to next otherwise. Name resolution through the local stub resolver keeps
+// working this way, other name servers are added to the rules map.
+func implicitAllowInstructions(label, next string) asm.Instructions {
+	return asm.Instructions{
+		asm.LoadMem(asm.R2, asm.RFP, keyFamily, asm.Byte),
+		asm.JNE.Imm(asm.R2, 4, "loopback6"),
+		// 127.0.0.0/8
+		asm.LoadMem(asm.R2, asm.RFP, keyOff, asm.Byte),
+		asm.JEq.Imm(asm.R2, 127, label),
+		asm.Ja.Label(next),
+		// ::1, the first 15 bytes must be zero whatever the byte order
+		asm.LoadMem(asm.R2, asm.RFP, keyOff, asm.DWord).WithSymbol("loopback6"),
+		asm.LoadMem(asm.R3, asm.RFP, keyOff+8, asm.Word),
+		asm.Or.Reg(asm.R2, asm.R3),
+		asm.LoadMem(asm.R3, asm.RFP, keyOff+12, asm.Half),
+		asm.Or.Reg(asm.R2, asm.R3),
+		asm.LoadMem(asm.R3, asm.RFP, keyOff+14, asm.Byte),
+		asm.Or.Reg(asm.R2, asm.R3),
+		asm.JNE.Imm(asm.R2, 0, next),
+		asm.LoadMem(asm.R2, asm.RFP, keyOff+15, asm.Byte),
+		asm.JEq.Imm(asm.R2, 1, label),
+		asm.Ja.Label(next),
+	}
+}
+
+// lookupSocketCookie returns instructions jumping to label when the cookie
+// of the socket of the context in R6 is found in the map.
+func lookupSocketCookie(mapFD int, label string) asm.Instructions {
+	return asm.Instructions{
+		asm.Mov.Reg(asm.R1, asm.R6),
+		asm.FnGetSocketCookie.Call(),
+		asm.StoreMem(asm.RFP, valOff, asm.R0, asm.DWord),
+		asm.LoadMapPtr(asm.R1, mapFD),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, valOff),
+		asm.FnMapLookupElem.Call(),
+		asm.JNE.Imm(asm.R0, 0, label),
+	}
+}
+
+// lookupKey returns instructions looking up the key built on the stack in
+// the map, first with the destination port and then with any port, returning
+// allowed when found and denied otherwise. The first instruction is labeled
+// "lookup".
+func lookupKey(mapFD int, allowed, denied int32) asm.Instructions {
+	return asm.Instructions{
+		asm.LoadMapPtr(asm.R1, mapFD).WithSymbol("lookup"),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, keyOff),
+		asm.FnMapLookupElem.Call(),
+		asm.JNE.Imm(asm.R0, 0, "allow"),
+		asm.StoreImm(asm.RFP, keyPort, 0, asm.Half),
+		asm.LoadMapPtr(asm.R1, mapFD),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, keyOff),
+		asm.FnMapLookupElem.Call(),
+		asm.JNE.Imm(asm.R0, 0, "allow"),
+		asm.Mov.Imm(asm.R0, denied),
+		asm.Return(),
+		asm.Mov.Imm(asm.R0, allowed).WithSymbol("allow"),
+		asm.Return(),
+	}
+}
+
+// connect4Instructions returns a cgroup/connect4 program rejecting
+// connections to destinations not found in the rules map.
+func connect4Instructions(maps networkMapFDs) asm.Instructions {
+	insns := asm.Instructions{asm.Mov.Reg(asm.R6, asm.R1)}
+	insns = append(insns, zeroKey()...)
+	insns = append(insns,
+		asm.LoadMem(asm.R2, asm.R6, sockAddrUserIP4, asm.Word),
+		asm.StoreMem(asm.RFP, keyOff, asm.R2, asm.Word),
+		// the port is kept in network byte order
+		asm.LoadMem(asm.R2, asm.R6, sockAddrUserPort, asm.Word),
+		asm.StoreMem(asm.RFP, keyPort, asm.R2, asm.Half),
+		asm.StoreImm(asm.RFP, keyFamily, 4, asm.Byte),
+	)
+	insns = append(insns, implicitAllowInstructions("allow", "lookup")...)
+	return append(insns, lookupKey(maps.rules, 1, 0)...)
+}
+
+// connect6Instructions returns a cgroup/connect6 program rejecting
+// connections to destinations not found in the rules map.
+func connect6Instructions(maps networkMapFDs) asm.Instructions {
+	insns := asm.Instructions{asm.Mov.Reg(asm.R6, asm.R1)}
+	insns = append(insns, zeroKey()...)
+	for i := int16(0); i < 16; i += 4 {
+		insns = append(insns,
+			asm.LoadMem(asm.R2, asm.R6, sockAddrUserIP6+i, asm.Word),
+			asm.StoreMem(asm.RFP, keyOff+i, asm.R2, asm.Word),
+		)
+	}
+	insns = append(insns,
+		asm.LoadMem(asm.R2, asm.R6, sockAddrUserPort, asm.Word),
+		asm.StoreMem(asm.RFP, keyPort, asm.R2, asm.Half),
+		asm.StoreImm(asm.RFP, keyFamily, 6, asm.Byte),
+	)
+	insns = append(insns, implicitAllowInstructions("allow", "lookup")...)
+	return append(insns, lookupKey(maps.rules, 1, 0)...)
+}
+
+// loadBytes returns instructions copying size bytes of the packet, starting
+// at the offset held in register off, to the stack at the given offset and
+// jumping to label on failure.
+func loadBytes(off asm.Register, to int32, size int32, label string) asm.Instructions {
+	return asm.Instructions{
+		asm.Mov.Reg(asm.R1, asm.R6),
+		asm.Mov.Reg(asm.R2, off),
+		asm.Mov.Reg(asm.R3, asm.RFP),
+		asm.Add.Imm(asm.R3, to),
+		asm.Mov.Imm(asm.R4, size),
+		asm.FnSkbLoadBytes.Call(),
+		asm.JNE.Imm(asm.R0, 0, label),
+	}
+}
+
+// packetKeyInstructions returns instructions building the key on the stack
+// from the destination address and port of an IPv4 or IPv6 packet. The
+// instruction following them must be labeled "key". Packets which are
+// neither IPv4 nor IPv6 jump to "pass" and truncated packets jump to fail.
+func packetKeyInstructions(fail string) asm.Instructions {
+	// offsets of the destination address in the IPv4 and IPv6 headers,
+	// and of the destination port in the TCP and UDP headers
+	addr4, addr6, port := int16(16), int16(24), int32(2)
+
+	insns := asm.Instructions{asm.Mov.Imm(asm.R7, 0)}
+	insns = append(insns, zeroKey()...)
+	// the IP version is in the upper half of the first byte
+	insns = append(insns, loadBytes(asm.R7, hdrOff, 1, "pass")...)
+	insns = append(insns,
+		asm.LoadMem(asm.R2, asm.RFP, hdrOff, asm.Byte),
+		asm.RSh.Imm(asm.R2, 4),
+		asm.JEq.Imm(asm.R2, 4, "ipv4"),
+		asm.JEq.Imm(asm.R2, 6, "ipv6"),
+		asm.Ja.Label("pass"),
+	)
+
+	// IPv4
+	insns = append(insns, asm.Mov.Imm(asm.R7, 0).WithSymbol("ipv4"))
+	insns = append(insns, loadBytes(asm.R7, hdrOff, 20, fail)...)
+	insns = append(insns,
+		asm.LoadMem(asm.R2, asm.RFP, hdrOff+addr4, asm.Word),
+		asm.StoreMem(asm.RFP, keyOff, asm.R2, asm.Word),
+		asm.StoreImm(asm.RFP, keyFamily, 4, asm.Byte),
+		// non-first fragments carry no transport header
+		asm.LoadMem(asm.R2, asm.RFP, hdrOff+6, asm.Byte),
+		asm.And.Imm(asm.R2, 0x1f),
+		asm.JNE.Imm(asm.R2, 0, "key"),
+		asm.LoadMem(asm.R2, asm.RFP, hdrOff+7, asm.Byte),
+		asm.JNE.Imm(asm.R2, 0, "key"),
+		asm.LoadMem(asm.R2, asm.RFP, hdrOff+9, asm.Byte),
+		// the source port is followed by the destination port in both
+		// the TCP and UDP headers
+		asm.LoadMem(asm.R7, asm.RFP, hdrOff, asm.Byte),
+		asm.And.Imm(asm.R7, 0x0f),
+		asm.LSh.Imm(asm.R7, 2),
+		asm.Add.Imm(asm.R7, port),
+		asm.JEq.Imm(asm.R2, ipprotoTCP, "port"),
+		asm.JEq.Imm(asm.R2, ipprotoUDP, "port"),
+		asm.Ja.Label("key"),
+	)
+
+	// IPv6, extension headers are not followed
+	insns = append(insns, asm.Mov.Imm(asm.R7, 0).WithSymbol("ipv6"))
+	insns = append(insns, loadBytes(asm.R7, hdrOff, 40, fail)...)
+	for i := int16(0); i < 16; i += 4 {
+		insns = append(insns,
+			asm.LoadMem(asm.R2, asm.RFP, hdrOff+addr6+i, asm.Word),
+			asm.StoreMem(asm.RFP, keyOff+i, asm.R2, asm.Word),
+		)
+	}
+	insns = append(insns,
+		asm.StoreImm(asm.RFP, keyFamily, 6, asm.Byte),
+		asm.LoadMem(asm.R2, asm.RFP, hdrOff+6, asm.Byte),
+		asm.Mov.Imm(asm.R7, 40+port),
+		asm.JEq.Imm(asm.R2, ipprotoTCP, "port"),
+		asm.JEq.Imm(asm.R2, ipprotoUDP, "port"),
+		asm.Ja.Label("key"),
+	)
+
+	portInsns := loadBytes(asm.R7, keyPort, 2, fail)
+	portInsns[0] = portInsns[0].WithSymbol("port")
+	return append(insns, portInsns...)
+}
+
+// egressInstructions returns a cgroup/skb egress program dropping IPv4 and
+// IPv6 packets sent to destinations not found in the rules map. This covers
+// traffic not going through connect(), like datagrams sent with sendto(),
+// and replies sent by servers. Only the packets of the TCP connections
+// accepted by the snap, as recorded by the sock_ops program, are allowed to
+// go to any destination.
+func egressInstructions(maps networkMapFDs) asm.Instructions {
+	insns := asm.Instructions{asm.Mov.Reg(asm.R6, asm.R1)}
+	insns = append(insns, packetKeyInstructions("drop")...)
+	allow := implicitAllowInstructions("pass", "accepted")
+	allow[0] = allow[0].WithSymbol("key")
+	insns = append(insns, allow...)
+	accepted := lookupSocketCookie(maps.accepted, "pass")
+	accepted[0] = accepted[0].WithSymbol("accepted")
+	insns = append(insns, accepted...)
+	insns = append(insns, lookupKey(maps.rules, 1, 0)...)
+	insns = append(insns,
+		asm.Mov.Imm(asm.R0, 1).WithSymbol("pass"),
+		asm.Return(),
+		asm.Mov.Imm(asm.R0, 0).WithSymbol("drop"),
+		asm.Return(),
+	)
+	return insns
+}
+
+// sockOpsInstructions returns a cgroup/sock_ops program recording the
+// cookies of the sockets of the TCP connections accepted by the snap in the
+// accepted map: the listening socket, on behalf of which the kernel sends the
+// SYN-ACK, and the socket of the established connection.
+func sockOpsInstructions(maps networkMapFDs) asm.Instructions {
+	return asm.Instructions{
+		asm.Mov.Reg(asm.R6, asm.R1),
+		asm.LoadMem(asm.R2, asm.R6, sockOpsOp, asm.Word),
+		asm.JEq.Imm(asm.R2, sockOpsPassiveEstablished, "record"),
+		asm.JNE.Imm(asm.R2, sockOpsTCPListen, "done"),
+		asm.Mov.Reg(asm.R1, asm.R6).WithSymbol("record"),
+		asm.FnGetSocketCookie.Call(),
+		asm.StoreMem(asm.RFP, valOff, asm.R0, asm.DWord),
+		asm.StoreImm(asm.RFP, keyOff, 1, asm.Byte),
+		asm.LoadMapPtr(asm.R1, maps.accepted),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, valOff),
+		asm.Mov.Reg(asm.R3, asm.RFP),
+		asm.Add.Imm(asm.R3, keyOff),
+		asm.Mov.Imm(asm.R4, int32(ebpf.UpdateAny)),
+		asm.FnMapUpdateElem.Call(),
+		asm.Mov.Imm(asm.R0, 1).WithSymbol("done"),
+		asm.Return(),
+	}
+}
+
+// networkPrograms describes the programs of a network filter. The programs
+// are pinned with the given names next to the rules map, and snap-confine
+// attaches them to the cgroup of the app when it starts.
+var networkPrograms = []struct {
+	name   string
+	typ    ebpf.ProgramType
+	attach ebpf.AttachType
+	insns  func(maps networkMapFDs) asm.Instructions
+}{
+	{"connect4", ebpf.CGroupSockAddr, ebpf.AttachCGroupInet4Connect, connect4Instructions},
+	{"connect6", ebpf.CGroupSockAddr, ebpf.AttachCGroupInet6Connect, connect6Instructions},
+	{"egress", ebpf.CGroupSKB, ebpf.AttachCGroupInetEgress, egressInstructions},
+	{"sockops", ebpf.SockOps, ebpf.AttachCGroupSockOps, sockOpsInstructions},
+}
+
+// networkRulesPinName is the name of the pinned rules map of a network
+// filter.
+const networkRulesPinName = "rules"
+
+// NetworkMap wraps the underlying eBPF map capturing the destinations which
+// the snap is allowed to send traffic to.
+type NetworkMap struct {
+	m *ebpf.Map
+}
+
+func newNetworkMap(rules []NetworkRule) (*NetworkMap, error) {
+	m, err := ebpf.NewMap(&ebpf.MapSpec{
+		Name:       "snap_net",
+		Type:       ebpf.Hash,
+		KeySize:    NetworkKeySize,
+		ValueSize:  1,
+		MaxEntries: NetworkMaxRules,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot create network map: %v", err)
+	}
+	nm := &NetworkMap{m: m}
+	if err := nm.update(rules); err != nil {
+		nm.Close()
+		return nil, err
+	}
+	return nm, nil
+}
+
+// LoadNetworkMap opens the pinned BPF network hash map for the given
+// security tag. The caller is responsible for closing the returned map.
+func LoadNetworkMap(securityTag string) (*NetworkMap, error) {
+	dir, err := SecurityTagToNetworkBPFDir(securityTag)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, networkRulesPinName)
+	m, err := ebpf.LoadPinnedMap(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load network map at %s: %v", path, err)
+	}
+	return &NetworkMap{m: m}, nil
+}
+
+// Close the map.
+func (n *NetworkMap) Close() error {
+	return n.m.Close()
+}
+
+// Iterate over all entries in the BPF network hash map and calls fn for each
+// key. The iteration stops if fn returns an error.
+func (n *NetworkMap) Iterate(fn func(key NetworkKey) error) error {
+	iter := n.m.Iterate()
+	keyBuf := make([]byte, NetworkKeySize)
+	valBuf := make([]byte, 1) // value is uint8, always 1
+	for iter.Next(&keyBuf, &valBuf) {
+		var key NetworkKey
+		if err := key.UnmarshalBinary(keyBuf); err != nil {
+			return err
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// update replaces the content of the map with the given rules.
+func (n *NetworkMap) update(rules []NetworkRule) error {
+	wanted := make(map[NetworkKey]bool, len(rules))
+	for _, r := range rules {
+		wanted[NetworkKeyFromRule(r)] = true
+	}
+	var stale []NetworkKey
+	err := n.Iterate(func(key NetworkKey) error {
+		if !wanted[key] {
+			stale = append(stale, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cannot iterate over network map: %v", err)
+	}
+	// stale rules are removed first to not go over the map size
+	for _, key := range stale {
+		if err := n.m.Delete(&key); err != nil {
+			return fmt.Errorf("cannot remove network rule: %v", err)
+		}
+	}
+	for _, r := range rules {
+		key := NetworkKeyFromRule(r)
+		if err := n.m.Put(&key, uint8(1)); err != nil {
+			return fmt.Errorf("cannot add network rule %s: %v", r, err)
+		}
+	}
+	return nil
+}
+
+// loadNetworkPrograms loads the network filter programs using the given maps.
+// The caller is responsible for closing the returned programs.
+func loadNetworkPrograms(maps networkMapFDs) ([]*ebpf.Program, error) {
+	var progs []*ebpf.Program
+	for _, p := range networkPrograms {
+		prog, err := ebpf.NewProgram(&ebpf.ProgramSpec{
+			Name:         "snap_" + p.name,
+			Type:         p.typ,
+			AttachType:   p.attach,
+			Instructions: p.insns(maps),
+			License:      "GPL",
+		})
+		if err != nil {
+			for _, prog := range progs {
+				prog.Close()
+			}
+			return nil, fmt.Errorf("cannot load %s program: %v", p.name, err)
+		}
+		progs = append(progs, prog)
+	}
+	return progs, nil
+}
+
+// pinNetworkFilter creates the maps and programs of a network filter and
+// pins them in the given directory.
+func pinNetworkFilter(dir string, rules []NetworkRule) error {
+	n, err := newNetworkMap(rules)
+	if err != nil {
+		return err
+	}
+	defer n.Close()
+
+	// the accepted map is only referenced by the programs, socket
+	// cookies are never reused so entries of closed sockets are only
+	// evicted
+	accepted, err := ebpf.NewMap(&ebpf.MapSpec{
+		Name:       "snap_net_accept",
+		Type:       ebpf.LRUHash,
+		KeySize:    8,
+		ValueSize:  1,
+		MaxEntries: networkMaxAccepted,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create network accepted map: %v", err)
+	}
+	defer accepted.Close()
+
+	progs, err := loadNetworkPrograms(networkMapFDs{rules: n.m.FD(), accepted: accepted.FD()})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, prog := range progs {
+			prog.Close()
+		}
+	}()
+
+	if err := n.m.Pin(filepath.Join(dir, networkRulesPinName)); err != nil {
+		return fmt.Errorf("cannot pin network map: %v", err)
+	}
+	for i, p := range networkPrograms {
+		if err := progs[i].Pin(filepath.Join(dir, p.name)); err != nil {
+			return fmt.Errorf("cannot pin %s program: %v", p.name, err)
+		}
+	}
+	return nil
+}
+
+// networkFilterPinned returns true if all the programs of the network filter
+// are pinned in the given directory.
+func networkFilterPinned(dir string) bool {
+	for _, p := range networkPrograms {
+		if !osutil.FileExists(filepath.Join(dir, p.name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolverRules returns the rules allowing DNS traffic to the name servers
+// listed in resolv.conf.
+func resolverRules() ([]NetworkRule, error) {
+	f, err := os.Open(filepath.Join(dirs.GlobalRootDir, "/etc/resolv.conf"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []NetworkRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		// link-local IPv6 addresses may come with a zone, which
+		// the filter cannot match on anyway
+		addr, _, _ := strings.Cut(fields[1], "%")
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+		rules = append(rules, NetworkRule{IP: ip, Port: 53})
+	}
+	return rules, scanner.Err()
+}
+
+// LoadNetworkFilter pins the network filter restricting the traffic sent by
+// the apps of the given security tag to the destinations matching the rules.
+// Traffic to the loopback addresses, which includes the local stub resolver,
+// and to port 53 of the name servers listed in resolv.conf at the time of the
+// call is always allowed, as are the TCP connections accepted by the apps.
+// The filter is attached by snap-confine when an app starts.
+//
+// The rules of a filter pinned earlier are updated in place, which also
+// applies them to the apps which are already running.
+func LoadNetworkFilter(securityTag string, rules []NetworkRule) error {
+	resolvers, err := resolverRules()
+	if err != nil {
+		return fmt.Errorf("cannot read name servers: %v", err)
+	}
+	rules = append(rules[:len(rules):len(rules)], resolvers...)
+	if len(rules) > NetworkMaxRules {
+		return fmt.Errorf("cannot use more than %d network rules", NetworkMaxRules)
+	}
+	dir, err := SecurityTagToNetworkBPFDir(securityTag)
+	if err != nil {
+		return err
+	}
+
+	if n, err := LoadNetworkMap(securityTag); err == nil {
+		defer n.Close()
+		if networkFilterPinned(dir) {
+			return n.update(rules)
+		}
+	}
+
+	// the filter is pinned in a temporary directory first, so that
+	// snap-confine never observes a partially pinned filter, security
+	// tags cannot start with the "tmp_" prefix
+	tmpDir := filepath.Join(filepath.Dir(dir), "tmp_"+filepath.Base(dir))
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmpDir, 0700); err != nil {
+		return err
+	}
+	if err := pinNetworkFilter(tmpDir, rules); err != nil {
+		os.RemoveAll(tmpDir)
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		os.RemoveAll(tmpDir)
+		return err
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		os.RemoveAll(tmpDir)
+		return fmt.Errorf("cannot pin network filter: %v", err)
+	}
+	return nil
+}
+
+// RemoveNetworkFilters removes the pinned network filters of the given snap,
+// except the ones of the security tags to keep. Apps which are running keep
+// the filter they were started with.
+func RemoveNetworkFilters(instanceName string, keep []string) error {
+	snapDir := filepath.Join(dirs.SnapBPFFSDir, "net", instanceName)
+	entries, err := os.ReadDir(snapDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	kept := make(map[string]bool, len(keep))
+	for _, tag := range keep {
+		kept[strings.ReplaceAll(tag, ".", "_")] = true
+	}
+	for _, e := range entries {
+		if kept[e.Name()] {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(snapDir, e.Name())); err != nil {
+			return err
+		}
+	}
+	if len(keep) == 0 {
+		if err := os.Remove(snapDir); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
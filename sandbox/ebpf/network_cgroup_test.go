@@ -0,0 +1,304 @@
+// -*- Mode: Go; indent-tabs-mode: t; tab-width: 4 -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ebpf_test
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/unix"
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/sandbox/ebpf"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *ebpfSuite) TestParseNetworkRule(c *C) {
+	for _, t := range []struct {
+		in   string
+		ip   string
+		port uint16
+	}{
+		{"10.0.0.1", "10.0.0.1", 0},
+		{"10.0.0.1:443", "10.0.0.1", 443},
+		{"::1", "::1", 0},
+		{"fd00::1", "fd00::1", 0},
+		{"[fd00::1]:53", "fd00::1", 53},
+	} {
+		rule, err := ebpf.ParseNetworkRule(t.in)
+		c.Assert(err, IsNil, Commentf(t.in))
+		c.Check(rule.IP.Equal(net.ParseIP(t.ip)), Equals, true, Commentf(t.in))
+		c.Check(rule.Port, Equals, t.port, Commentf(t.in))
+		c.Check(rule.String(), Equals, t.in)
+	}
+
+	for _, t := range []struct {
+		in  string
+		err string
+	}{
+		{"example.com", `cannot parse network rule "example.com": "example.com" is not an IP address`},
+		{"example.com:80", `cannot parse network rule "example.com:80": "example.com" is not an IP address`},
+		{"10.0.0.1:0", `cannot parse network rule "10.0.0.1:0": invalid port "0"`},
+		{"10.0.0.1:http", `cannot parse network rule "10.0.0.1:http": invalid port "http"`},
+		{"10.0.0.1:65536", `cannot parse network rule "10.0.0.1:65536": invalid port "65536"`},
+		{"[fd00::1", `cannot parse network rule "\[fd00::1": .*missing ']' in address`},
+	} {
+		_, err := ebpf.ParseNetworkRule(t.in)
+		c.Check(err, ErrorMatches, t.err)
+	}
+}
+
+func (s *ebpfSuite) TestNetworkKeyMarshalRoundTrip(c *C) {
+	key := ebpf.NetworkKeyFromRule(ebpf.NetworkRule{IP: net.ParseIP("10.0.0.1"), Port: 443})
+	c.Check(key.Family, Equals, uint8(4))
+	data, err := key.MarshalBinary()
+	c.Assert(err, IsNil)
+	c.Check(data, DeepEquals, []byte{
+		10, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0x01, 0xbb, // port in network byte order
+		4, 0,
+	})
+
+	var key2 ebpf.NetworkKey
+	c.Assert(key2.UnmarshalBinary(data), IsNil)
+	c.Check(key2, Equals, key)
+
+	key = ebpf.NetworkKeyFromRule(ebpf.NetworkRule{IP: net.ParseIP("fd00::1")})
+	c.Check(key.Family, Equals, uint8(6))
+	data, err = key.MarshalBinary()
+	c.Assert(err, IsNil)
+	c.Check(data, DeepEquals, []byte{
+		0xfd, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1,
+		0, 0,
+		6, 0,
+	})
+
+	c.Check(key2.UnmarshalBinary(data[:10]), ErrorMatches, "cannot unmarshal network key: unexpected size 10")
+}
+
+func (s *ebpfSuite) TestSecurityTagToNetworkBPFDir(c *C) {
+	dir, err := ebpf.SecurityTagToNetworkBPFDir("snap.foo.bar")
+	c.Assert(err, IsNil)
+	c.Check(dir, Equals, filepath.Join(dirs.SnapBPFFSDir, "net", "foo", "snap_foo_bar"))
+
+	dir, err = ebpf.SecurityTagToNetworkBPFDir("snap.foo_inst.hook.install")
+	c.Assert(err, IsNil)
+	c.Check(dir, Equals, filepath.Join(dirs.SnapBPFFSDir, "net", "foo_inst", "snap_foo_inst_hook_install"))
+
+	_, err = ebpf.SecurityTagToNetworkBPFDir("foo")
+	c.Check(err, ErrorMatches, "invalid security tag")
+}
+
+func (s *ebpfSuite) TestRemoveNetworkFilters(c *C) {
+	for _, tag := range []string{"snap.foo.bar", "snap.foo.baz", "snap.foo_inst.bar"} {
+		dir, err := ebpf.SecurityTagToNetworkBPFDir(tag)
+		c.Assert(err, IsNil)
+		c.Assert(os.MkdirAll(dir, 0700), IsNil)
+		c.Assert(os.WriteFile(filepath.Join(dir, "rules"), nil, 0600), IsNil)
+	}
+
+	c.Assert(ebpf.RemoveNetworkFilters("foo", []string{"snap.foo.baz"}), IsNil)
+	c.Check(filepath.Join(dirs.SnapBPFFSDir, "net/foo/snap_foo_bar"), testutil.FileAbsent)
+	c.Check(filepath.Join(dirs.SnapBPFFSDir, "net/foo/snap_foo_baz/rules"), testutil.FilePresent)
+
+	c.Assert(ebpf.RemoveNetworkFilters("foo", nil), IsNil)
+	c.Check(filepath.Join(dirs.SnapBPFFSDir, "net/foo"), testutil.FileAbsent)
+	// other instances are not affected
+	c.Check(filepath.Join(dirs.SnapBPFFSDir, "net/foo_inst/snap_foo_inst_bar/rules"), testutil.FilePresent)
+
+	// nothing to remove
+	c.Assert(ebpf.RemoveNetworkFilters("other", nil), IsNil)
+}
+
+func (s *ebpfSuite) TestFindActiveDeviceMapsIgnoresNetworkFilters(c *C) {
+	dir, err := ebpf.SecurityTagToNetworkBPFDir("snap.foo.bar")
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(dir, 0700), IsNil)
+
+	tags, err := ebpf.FindActiveDeviceMapsForSnap("foo")
+	c.Assert(err, IsNil)
+	c.Check(tags, HasLen, 0)
+}
+
+// cgroup2Mount returns the mount point of the cgroup v2 hierarchy.
+func cgroup2Mount() string {
+	restore := osutil.MockProcSelfMountInfoLocation("/proc/self/mountinfo")
+	defer restore()
+	entries, err := osutil.LoadMountInfo()
+	if err != nil {
+		return ""
+	}
+	for _, e := range entries {
+		if e.FsType == "cgroup2" {
+			return e.MountDir
+		}
+	}
+	return ""
+}
+
+// networkNamespaceSetup sets up the loopback interface of a new network
+// namespace with additional addresses, which are not loopback addresses.
+const networkNamespaceSetup = `ip link set lo up && ` +
+	`ip addr add 10.11.0.1/32 dev lo && ip addr add 10.11.0.2/32 dev lo && ` +
+	`ip addr add 10.11.0.3/32 dev lo && ip addr add 10.11.0.4/32 dev lo && ` +
+	`ip addr add 10.11.0.5/32 dev lo && ` +
+	`ip addr add fd00::1/128 dev lo nodad`
+
+// TestNetworkFilter runs connections from a cgroup with a network filter
+// attached to addresses of the loopback interface of a separate network
+// namespace. As nothing listens there, allowed connections are refused while
+// denied connections fail with EPERM.
+func (s *ebpfSuite) TestNetworkFilter(c *C) {
+	if os.Geteuid() != 0 {
+		c.Skip("loading eBPF programs requires root")
+	}
+	cgroupRoot := cgroup2Mount()
+	if cgroupRoot == "" {
+		c.Skip("cgroup v2 is not available")
+	}
+	for _, cmd := range []string{"bash", "unshare", "ip", "timeout", "python3"} {
+		if _, err := exec.LookPath(cmd); err != nil {
+			c.Skip(fmt.Sprintf("%s is not available", cmd))
+		}
+	}
+
+	c.Assert(os.MkdirAll(dirs.SnapBPFFSDir, 0700), IsNil)
+	if err := unix.Mount("bpf", dirs.SnapBPFFSDir, "bpf", 0, ""); err != nil {
+		c.Skip(fmt.Sprintf("cannot mount bpffs: %v", err))
+	}
+	defer unix.Unmount(dirs.SnapBPFFSDir, 0)
+
+	cgroup := filepath.Join(cgroupRoot, fmt.Sprintf("snapd-ebpf-test-%d", os.Getpid()))
+	if err := os.Mkdir(cgroup, 0755); err != nil {
+		c.Skip(fmt.Sprintf("cannot create cgroup: %v", err))
+	}
+	defer func() {
+		// the processes which ran in the cgroup may still be exiting
+		for i := 0; i < 50; i++ {
+			if err := os.Remove(cgroup); err == nil || os.IsNotExist(err) {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	// name servers are always allowed, the local stub resolver is
+	// covered by the loopback addresses
+	resolvConf := filepath.Join(dirs.GlobalRootDir, "/etc/resolv.conf")
+	c.Assert(os.MkdirAll(filepath.Dir(resolvConf), 0755), IsNil)
+	c.Assert(os.WriteFile(resolvConf, []byte("# comment\nnameserver 127.0.0.53\nnameserver 10.11.0.5\nnameserver fe80::1%eth0\noptions edns0\n"), 0644), IsNil)
+
+	rules := []ebpf.NetworkRule{
+		{IP: net.ParseIP("10.11.0.1"), Port: 8080},
+		{IP: net.ParseIP("10.11.0.2")},
+		{IP: net.ParseIP("fd00::1"), Port: 8080},
+	}
+	err := ebpf.LoadNetworkFilter("snap.foo.bar", append(rules, ebpf.NetworkRule{IP: net.ParseIP("10.11.0.3")}))
+	c.Assert(err, IsNil)
+	dir, err := ebpf.SecurityTagToNetworkBPFDir("snap.foo.bar")
+	c.Assert(err, IsNil)
+	for _, name := range []string{"rules", "connect4", "connect6", "egress", "sockops"} {
+		c.Check(filepath.Join(dir, name), testutil.FilePresent)
+	}
+	c.Assert(ebpf.AttachNetworkFilter("snap.foo.bar", cgroup), IsNil)
+
+	// the rules are updated in place for the cgroups the filter is
+	// attached to
+	c.Assert(ebpf.LoadNetworkFilter("snap.foo.bar", rules), IsNil)
+
+	m, err := ebpf.LoadNetworkMap("snap.foo.bar")
+	c.Assert(err, IsNil)
+	var keys []ebpf.NetworkKey
+	err = m.Iterate(func(key ebpf.NetworkKey) error {
+		keys = append(keys, key)
+		return nil
+	})
+	c.Assert(err, IsNil)
+	m.Close()
+	c.Check(keys, HasLen, 6)
+	c.Check(keys, testutil.Contains, ebpf.NetworkKeyFromRule(ebpf.NetworkRule{IP: net.ParseIP("10.11.0.5"), Port: 53}))
+	c.Check(keys, testutil.Contains, ebpf.NetworkKeyFromRule(ebpf.NetworkRule{IP: net.ParseIP("fe80::1"), Port: 53}))
+
+	for _, t := range []struct {
+		addr    string
+		port    int
+		allowed bool
+	}{
+		{"10.11.0.1", 8080, true},
+		{"10.11.0.1", 8081, false},
+		{"10.11.0.2", 9000, true},
+		// no longer in the rules
+		{"10.11.0.3", 9000, false},
+		{"fd00::1", 8080, true},
+		{"fd00::1", 8081, false},
+		// DNS is only allowed to the name servers
+		{"10.11.0.5", 53, true},
+		{"10.11.0.5", 54, false},
+		{"10.11.0.3", 53, false},
+		{"fd00::1", 53, false},
+		// loopback addresses are always allowed
+		{"127.0.0.1", 8081, true},
+		{"127.0.0.2", 8081, true},
+		{"::1", 8081, true},
+	} {
+		script := fmt.Sprintf(`%s && echo $$ > %s/cgroup.procs && exec 3<>/dev/tcp/%s/%d`,
+			networkNamespaceSetup, cgroup, t.addr, t.port)
+		out, err := exec.Command("unshare", "--net", "bash", "-c", script).CombinedOutput()
+		c.Assert(err, NotNil)
+		comment := Commentf("%s:%d: %s", t.addr, t.port, out)
+		if t.allowed {
+			c.Check(string(out), Matches, "(?s).*Connection refused.*", comment)
+		} else {
+			c.Check(string(out), Matches, "(?s).*Operation not permitted.*", comment)
+		}
+	}
+
+	// a server in the cgroup can reply to clients which are not in the
+	// rules
+	server := `import socket; s = socket.socket(); s.bind(("10.11.0.4", 7000)); s.listen(1); c, _ = s.accept(); c.sendall(b"hello")`
+	script := fmt.Sprintf(`set -e
+%s
+(echo $BASHPID > %s/cgroup.procs && exec python3 -c '%s') &
+timeout 10 bash -c 'until exec 3<>/dev/tcp/10.11.0.4/7000; do sleep 0.1; done 2>/dev/null; cat <&3' || { kill $!; exit 1; }`,
+		networkNamespaceSetup, cgroup, server)
+	out, err := exec.Command("unshare", "--net", "bash", "-c", script).CombinedOutput()
+	c.Assert(err, IsNil, Commentf("%s", out))
+	c.Check(string(out), Equals, "hello")
+
+	// receiving a datagram does not allow sending to its source
+	server = `import socket; s = socket.socket(socket.AF_INET, socket.SOCK_DGRAM); s.bind(("10.11.0.4", 7001)); _, peer = s.recvfrom(16); s.sendto(b"hello", peer)`
+	script = fmt.Sprintf(`%s
+(echo $BASHPID > %s/cgroup.procs && exec python3 -c '%s') &
+for i in $(seq 100); do echo ping > /dev/udp/10.11.0.4/7001; kill -0 $! 2>/dev/null || break; sleep 0.1; done
+wait $!`,
+		networkNamespaceSetup, cgroup, server)
+	out, err = exec.Command("unshare", "--net", "bash", "-c", script).CombinedOutput()
+	c.Assert(err, NotNil, Commentf("%s", out))
+	c.Check(string(out), Matches, "(?s).*PermissionError.*Operation not permitted.*")
+
+	c.Assert(ebpf.RemoveNetworkFilters("foo", nil), IsNil)
+	c.Check(filepath.Join(dirs.SnapBPFFSDir, "net", "foo"), testutil.FileAbsent)
+}
@@ -20,7 +20,10 @@
 package seedwriter
 
 import (
+	"time"
+
 	"github.com/snapcore/snapd/seed/internal"
+	"github.com/snapcore/snapd/testutil"
 )
 
 type (
@@ -32,3 +35,12 @@ var (
 	InternalReadSeedYaml  = internal.ReadSeedYaml
 	InternalReadOptions20 = internal.ReadOptions20
 )
+
+var (
+	SpdxLicense  = spdxLicense
+	SpdxSHA3_384 = spdxSHA3_384
+)
+
+func MockTimeNow(f func() time.Time) (restore func()) {
+	return testutil.Mock(&timeNow, f)
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/snapcore/snapd/asserts/snapasserts"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/strutil"
 )
 
@@ -46,6 +47,37 @@ func (s *ManifestSnapRevision) String() string {
 	return fmt.Sprintf("%s %s", s.SnapName, s.Revision)
 }
 
+// ManifestComponentRevision represents a component revision as noted
+// in the seed manifest.
+type ManifestComponentRevision struct {
+	Component naming.ComponentRef
+	Revision  snap.Revision
+}
+
+func (c *ManifestComponentRevision) String() string {
+	return fmt.Sprintf("%s %s", c.Component, c.Revision)
+}
+
+// ManifestModel represents the model assertion as noted in the seed
+// manifest.
+type ManifestModel struct {
+	BrandID  string
+	Model    string
+	Revision int
+}
+
+// modelLineKeyword starts the model line of the seed manifest.
+const modelLineKeyword = "model"
+
+func (m *ManifestModel) String() string {
+	return fmt.Sprintf("%s %s/%s %d", modelLineKeyword, m.BrandID, m.Model, m.Revision)
+}
+
+// Unique returns the <brand-id>/<model> name of the model.
+func (m *ManifestModel) Unique() string {
+	return fmt.Sprintf("%s/%s", m.BrandID, m.Model)
+}
+
 // ManifestValidationSet represents a validation set as noted
 // in the seed manifest. A validation set can optionally be pinned,
 // but the sequence will always be set to the sequence that was used
@@ -84,27 +116,37 @@ func (vs *ManifestValidationSet) hasSnap(snapName string) bool {
 	return strutil.ListContains(vs.Snaps, snapName)
 }
 
-// Represents the validation-sets and snaps that are used to build
-// an image seed. The manifest will only allow adding entries once to support
-// a pre-provided manifest.
+// Represents the model, validation-sets, snaps and components that are used
+// to build an image seed. The manifest will only allow adding entries once to
+// support a pre-provided manifest.
 // The seed.manifest generated by ubuntu-image contains entries in the following
 // format:
+// model <brand-id>/<model> <model-revision>
 // <account-id>/<name>=<sequence>
 // <account-id>/<name> <sequence>
 // <snap-name> <snap-revision>
+// <snap-name>+<component-name> <component-revision>
+// Readers that predate the model and component entries fail to parse them
+// instead of silently ignoring them.
 type Manifest struct {
-	revsAllowed map[string]*ManifestSnapRevision
-	revsSeeded  map[string]*ManifestSnapRevision
-	vsAllowed   map[string]*ManifestValidationSet
-	vsSeeded    map[string]*ManifestValidationSet
+	modelAllowed *ManifestModel
+	modelSeeded  *ManifestModel
+	revsAllowed  map[string]*ManifestSnapRevision
+	revsSeeded   map[string]*ManifestSnapRevision
+	compsAllowed map[string]*ManifestComponentRevision
+	compsSeeded  map[string]*ManifestComponentRevision
+	vsAllowed    map[string]*ManifestValidationSet
+	vsSeeded     map[string]*ManifestValidationSet
 }
 
 func NewManifest() *Manifest {
 	return &Manifest{
-		revsAllowed: make(map[string]*ManifestSnapRevision),
-		revsSeeded:  make(map[string]*ManifestSnapRevision),
-		vsAllowed:   make(map[string]*ManifestValidationSet),
-		vsSeeded:    make(map[string]*ManifestValidationSet),
+		revsAllowed:  make(map[string]*ManifestSnapRevision),
+		revsSeeded:   make(map[string]*ManifestSnapRevision),
+		compsAllowed: make(map[string]*ManifestComponentRevision),
+		compsSeeded:  make(map[string]*ManifestComponentRevision),
+		vsAllowed:    make(map[string]*ManifestValidationSet),
+		vsSeeded:     make(map[string]*ManifestValidationSet),
 	}
 }
 
@@ -159,6 +201,42 @@ func (sm *Manifest) SetAllowedSnapRevision(snapName string, revision snap.Revisi
 	return nil
 }
 
+// SetAllowedModel adds a rule for the model assertion, meaning that the model
+// marked seeded through MarkModelSeeded must match it. As for snaps, only the
+// first rule set is retained.
+func (sm *Manifest) SetAllowedModel(brandID, model string, revision int) error {
+	if revision < 0 {
+		return fmt.Errorf("model revision for %q in manifest cannot be negative", fmt.Sprintf("%s/%s", brandID, model))
+	}
+
+	if sm.modelAllowed == nil {
+		sm.modelAllowed = &ManifestModel{
+			BrandID:  brandID,
+			Model:    model,
+			Revision: revision,
+		}
+	}
+	return nil
+}
+
+// SetAllowedComponentRevision adds a revision rule for the given component,
+// meaning that any component marked seeded through MarkComponentRevisionSeeded
+// will be validated against this rule. As for snaps, only the first revision
+// set for a component is retained.
+func (sm *Manifest) SetAllowedComponentRevision(cref naming.ComponentRef, revision snap.Revision) error {
+	if revision.Unset() {
+		return fmt.Errorf("component revision for %q in manifest cannot be 0 (unset)", cref)
+	}
+
+	if _, ok := sm.compsAllowed[cref.String()]; !ok {
+		sm.compsAllowed[cref.String()] = &ManifestComponentRevision{
+			Component: cref,
+			Revision:  revision,
+		}
+	}
+	return nil
+}
+
 // SetAllowedValidationSet adds a sequence rule for the given validation set, meaning
 // that any validation set marked for use through MarkValidationSetUsed must match the
 // given parameters. The manifest will only allow one sequence per validation set,
@@ -206,6 +284,57 @@ func (sm *Manifest) MarkSnapRevisionSeeded(snapName string, revision snap.Revisi
 	return nil
 }
 
+// MarkModelSeeded marks the model assertion of the seed as seeded. It is
+// verified against any model rule previously set by SetAllowedModel.
+func (sm *Manifest) MarkModelSeeded(model *asserts.Model) error {
+	m := &ManifestModel{
+		BrandID:  model.BrandID(),
+		Model:    model.Model(),
+		Revision: model.Revision(),
+	}
+	if sm.modelSeeded != nil {
+		return fmt.Errorf("cannot mark model %q as seeded, it has already been marked seeded", m.Unique())
+	}
+
+	if allowed := sm.modelAllowed; allowed != nil {
+		if allowed.Unique() != m.Unique() {
+			return fmt.Errorf("model %q does not match the allowed model %q", m.Unique(), allowed.Unique())
+		}
+		if allowed.Revision != m.Revision {
+			return fmt.Errorf("model %q (%d) does not match the allowed revision %d",
+				m.Unique(), m.Revision, allowed.Revision)
+		}
+	}
+
+	sm.modelSeeded = m
+	return nil
+}
+
+// MarkComponentRevisionSeeded attempts to mark a component revision as seeded
+// in the manifest. The seeded revision will be validated against any
+// previously allowed revision for the component.
+func (sm *Manifest) MarkComponentRevisionSeeded(cref naming.ComponentRef, revision snap.Revision) error {
+	if rev, ok := sm.compsAllowed[cref.String()]; ok {
+		// Allowed revision specified, it must match.
+		if rev.Revision != revision {
+			return fmt.Errorf("component %q (%s) does not match the allowed revision %s",
+				cref, revision, rev.Revision)
+		}
+	}
+
+	if rev, ok := sm.compsSeeded[cref.String()]; ok {
+		// Already marked as seeding.
+		return fmt.Errorf("cannot mark %q (%s) as seeded, it has already been marked seeded for revision %s",
+			cref, revision, rev.Revision)
+	}
+
+	sm.compsSeeded[cref.String()] = &ManifestComponentRevision{
+		Component: cref,
+		Revision:  revision,
+	}
+	return nil
+}
+
 // MarkValidationSetSeeded marks a validation-set as seeded. It verifies against any previously
 // set rules by SetAllowedValidationSet, and sets up new rules based on the snaps defined in the
 // validation set.
@@ -253,6 +382,12 @@ func (sm *Manifest) MarkValidationSetSeeded(vsa *asserts.ValidationSet, pinned b
 	return nil
 }
 
+// AllowedModel returns the model specified as allowed, or nil if the
+// manifest does not specify one.
+func (sm *Manifest) AllowedModel() *ManifestModel {
+	return sm.modelAllowed
+}
+
 // AllowedSnapRevision retrieves any specified revision rule for the snap
 // name.
 func (sm *Manifest) AllowedSnapRevision(snapName string) snap.Revision {
@@ -263,6 +398,41 @@ func (sm *Manifest) AllowedSnapRevision(snapName string) snap.Revision {
 	return snap.Revision{}
 }
 
+// AllowedSnapRevisions returns the snap revisions specified as allowed,
+// sorted by snap name.
+func (sm *Manifest) AllowedSnapRevisions() []*ManifestSnapRevision {
+	revs := make([]*ManifestSnapRevision, 0, len(sm.revsAllowed))
+	for _, rev := range sm.revsAllowed {
+		revs = append(revs, rev)
+	}
+	sort.Slice(revs, func(i, j int) bool {
+		return revs[i].SnapName < revs[j].SnapName
+	})
+	return revs
+}
+
+// AllowedComponentRevision retrieves any specified revision rule for the
+// component.
+func (sm *Manifest) AllowedComponentRevision(cref naming.ComponentRef) snap.Revision {
+	if rev, ok := sm.compsAllowed[cref.String()]; ok {
+		return rev.Revision
+	}
+	return snap.Revision{}
+}
+
+// AllowedComponentRevisions returns the component revisions specified as
+// allowed, sorted by their full component name.
+func (sm *Manifest) AllowedComponentRevisions() []*ManifestComponentRevision {
+	revs := make([]*ManifestComponentRevision, 0, len(sm.compsAllowed))
+	for _, rev := range sm.compsAllowed {
+		revs = append(revs, rev)
+	}
+	sort.Slice(revs, func(i, j int) bool {
+		return revs[i].Component.String() < revs[j].Component.String()
+	})
+	return revs
+}
+
 // AllowedValidationSets returns the validation sets specified as allowed.
 func (sm *Manifest) AllowedValidationSets() []*ManifestValidationSet {
 	var vss []*ManifestValidationSet
@@ -309,6 +479,39 @@ func parseSnapRevision(sm *Manifest, sn, revStr string) error {
 	return sm.SetAllowedSnapRevision(sn, rev)
 }
 
+func parseModel(sm *Manifest, model, revStr string) error {
+	parts := strings.Split(model, "/")
+	if len(parts) != 2 || parts[1] == "" {
+		return fmt.Errorf("cannot parse model %q: expected a single brand-id/model", model)
+	}
+	brandID, name := parts[0], parts[1]
+	if !asserts.IsValidAccountID(brandID) {
+		return fmt.Errorf("cannot parse model %q: invalid brand ID %q", model, brandID)
+	}
+	rev, err := strconv.Atoi(revStr)
+	if err != nil {
+		return fmt.Errorf("invalid model revision: %q", revStr)
+	}
+	return sm.SetAllowedModel(brandID, name, rev)
+}
+
+func parseComponentRevision(sm *Manifest, comp, revStr string) error {
+	snapName, compName, err := naming.SplitFullComponentName(comp)
+	if err != nil {
+		return err
+	}
+	cref := naming.NewComponentRef(snapName, compName)
+	if err := cref.Validate(); err != nil {
+		return err
+	}
+
+	rev, err := snap.ParseRevision(revStr)
+	if err != nil {
+		return err
+	}
+	return sm.SetAllowedComponentRevision(cref, rev)
+}
+
 // ReadManifest reads a seed.manifest previously generated by Manifest.Write
 // and returns a new Manifest structure reflecting the contents.
 func ReadManifest(manifestFile string) (*Manifest, error) {
@@ -332,6 +535,11 @@ func ReadManifest(manifestFile string) (*Manifest, error) {
 		tokens := strings.Fields(line)
 
 		switch {
+		case len(tokens) == 3 && tokens[0] == modelLineKeyword:
+			// Model: model <brand-id>/<model> <revision>
+			if err := parseModel(sm, tokens[1], tokens[2]); err != nil {
+				return nil, err
+			}
 		case len(tokens) == 1 && strings.Contains(tokens[0], "/"):
 			// Pinned validation-set: <account-id>/<name>=<sequence>
 			if err := parsePinnedValidationSet(sm, tokens[0]); err != nil {
@@ -342,6 +550,11 @@ func ReadManifest(manifestFile string) (*Manifest, error) {
 			if err := parseUnpinnedValidationSet(sm, tokens[0], tokens[1]); err != nil {
 				return nil, err
 			}
+		case len(tokens) == 2 && strings.Contains(tokens[0], "+"):
+			// Component revision: <snap>+<component> <revision>
+			if err := parseComponentRevision(sm, tokens[0], tokens[1]); err != nil {
+				return nil, err
+			}
 		case len(tokens) == 2:
 			// Snap revision: <snap> <revision>
 			if err := parseSnapRevision(sm, tokens[0], tokens[1]); err != nil {
@@ -382,12 +595,24 @@ func (sm *Manifest) Write(filePath string) error {
 	}
 	sort.Strings(revisionKeys)
 
+	compKeys := make([]string, 0, len(sm.compsSeeded))
+	for k := range sm.compsSeeded {
+		compKeys = append(compKeys, k)
+	}
+	sort.Strings(compKeys)
+
 	buf := bytes.NewBuffer(nil)
+	if sm.modelSeeded != nil {
+		fmt.Fprintf(buf, "%s\n", sm.modelSeeded)
+	}
 	for _, key := range vsKeys {
 		fmt.Fprintf(buf, "%s\n", sm.vsSeeded[key])
 	}
 	for _, key := range revisionKeys {
 		fmt.Fprintf(buf, "%s\n", sm.revsSeeded[key])
 	}
+	for _, key := range compKeys {
+		fmt.Fprintf(buf, "%s\n", sm.compsSeeded[key])
+	}
 	return os.WriteFile(filePath, buf.Bytes(), 0755)
 }
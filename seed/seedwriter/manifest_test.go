@@ -31,6 +31,7 @@ import (
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/seed/seedwriter"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -99,6 +100,15 @@ func (s *manifestSuite) TestReadManifestParseFails(c *C) {
 		{"core\n", `cannot parse line: "core"`},
 		{" test\n", `line cannot start with any spaces: " test"`},
 		{"core 14 14\n", `cannot parse line: "core 14 14"`},
+		{"pc+comp+extra 4\n", `incorrect component name "pc\+comp\+extra"`},
+		{"pc+-comp 4\n", `invalid snap name: "-comp"`},
+		{"pc+comp 0\n", `invalid snap revision: "0"`},
+		{"pc+comp 4 4\n", `cannot parse line: "pc\+comp 4 4"`},
+		{"model my-brand 1\n", `cannot parse model "my-brand": expected a single brand-id/model`},
+		{"model my/brand/model 1\n", `cannot parse model "my/brand/model": expected a single brand-id/model`},
+		{"model &&brand/my-model 1\n", `cannot parse model "&&brand/my-model": invalid brand ID "&&brand"`},
+		{"model my-brand/my-model x\n", `invalid model revision: "x"`},
+		{"model my-brand/my-model -1\n", `model revision for "my-brand/my-model" in manifest cannot be negative`},
 	}
 
 	for _, t := range tests {
@@ -108,6 +118,24 @@ func (s *manifestSuite) TestReadManifestParseFails(c *C) {
 	}
 }
 
+func (s *manifestSuite) TestReadManifestComponents(c *C) {
+	manifestFile := s.writeManifest(c, `pc 128
+pc+kmod 3
+pc+firmware x1
+`)
+	manifest, err := seedwriter.ReadManifest(manifestFile)
+	c.Assert(err, IsNil)
+	c.Check(manifest.AllowedSnapRevisions(), DeepEquals, []*seedwriter.ManifestSnapRevision{
+		{SnapName: "pc", Revision: snap.R(128)},
+	})
+	c.Check(manifest.AllowedComponentRevisions(), DeepEquals, []*seedwriter.ManifestComponentRevision{
+		{Component: naming.NewComponentRef("pc", "firmware"), Revision: snap.R(-1)},
+		{Component: naming.NewComponentRef("pc", "kmod"), Revision: snap.R(3)},
+	})
+	c.Check(manifest.AllowedComponentRevision(naming.NewComponentRef("pc", "kmod")), Equals, snap.R(3))
+	c.Check(manifest.AllowedComponentRevision(naming.NewComponentRef("pc", "other")).Unset(), Equals, true)
+}
+
 func (s *manifestSuite) TestReadManifestNoFile(c *C) {
 	snapRevs, err := seedwriter.ReadManifest("noexists.manifest")
 	c.Assert(err, NotNil)
@@ -157,6 +185,110 @@ test x4
 `)
 }
 
+func (s *manifestSuite) TestWriteManifestComponents(c *C) {
+	manifest := seedwriter.NewManifest()
+	c.Assert(manifest.MarkSnapRevisionSeeded("pc", snap.R(128)), IsNil)
+	c.Assert(manifest.MarkComponentRevisionSeeded(naming.NewComponentRef("pc", "kmod"), snap.R(3)), IsNil)
+	c.Assert(manifest.MarkComponentRevisionSeeded(naming.NewComponentRef("pc", "firmware"), snap.R(-1)), IsNil)
+
+	filePath := filepath.Join(s.root, "seed.manifest")
+	c.Assert(manifest.Write(filePath), IsNil)
+	contents, err := os.ReadFile(filePath)
+	c.Assert(err, IsNil)
+	c.Check(string(contents), Equals, `pc 128
+pc+firmware x1
+pc+kmod 3
+`)
+}
+
+func (s *manifestSuite) TestReadManifestModel(c *C) {
+	manifestFile := s.writeManifest(c, `model my-brand/my-model 3
+core22 275
+`)
+	manifest, err := seedwriter.ReadManifest(manifestFile)
+	c.Assert(err, IsNil)
+	c.Check(manifest.AllowedModel(), DeepEquals, &seedwriter.ManifestModel{
+		BrandID:  "my-brand",
+		Model:    "my-model",
+		Revision: 3,
+	})
+	c.Check(manifest.AllowedSnapRevision("core22"), Equals, snap.R(275))
+}
+
+func (s *manifestSuite) TestWriteManifestModel(c *C) {
+	model := assertstest.FakeAssertion(map[string]interface{}{
+		"type":         "model",
+		"authority-id": "my-brand",
+		"series":       "16",
+		"brand-id":     "my-brand",
+		"model":        "my-model",
+		"revision":     "2",
+		"architecture": "amd64",
+		"gadget":       "pc",
+		"kernel":       "pc-kernel",
+		"timestamp":    "2026-01-01T00:00:00Z",
+	}).(*asserts.Model)
+
+	manifest := seedwriter.NewManifest()
+	c.Assert(manifest.MarkModelSeeded(model), IsNil)
+	c.Assert(manifest.MarkSnapRevisionSeeded("core", snap.R(12)), IsNil)
+
+	filePath := filepath.Join(s.root, "seed.manifest")
+	c.Assert(manifest.Write(filePath), IsNil)
+	contents, err := os.ReadFile(filePath)
+	c.Assert(err, IsNil)
+	c.Check(string(contents), Equals, `model my-brand/my-model 2
+core 12
+`)
+}
+
+func (s *manifestSuite) TestManifestMarkModelSeeded(c *C) {
+	model := assertstest.FakeAssertion(map[string]interface{}{
+		"type":         "model",
+		"authority-id": "my-brand",
+		"series":       "16",
+		"brand-id":     "my-brand",
+		"model":        "my-model",
+		"revision":     "2",
+		"architecture": "amd64",
+		"gadget":       "pc",
+		"kernel":       "pc-kernel",
+		"timestamp":    "2026-01-01T00:00:00Z",
+	}).(*asserts.Model)
+
+	manifest := seedwriter.NewManifest()
+	c.Assert(manifest.SetAllowedModel("my-brand", "other-model", 2), IsNil)
+	err := manifest.MarkModelSeeded(model)
+	c.Check(err, ErrorMatches, `model "my-brand/my-model" does not match the allowed model "my-brand/other-model"`)
+
+	manifest = seedwriter.NewManifest()
+	c.Assert(manifest.SetAllowedModel("my-brand", "my-model", 1), IsNil)
+	// only the first rule is retained
+	c.Assert(manifest.SetAllowedModel("my-brand", "my-model", 2), IsNil)
+	err = manifest.MarkModelSeeded(model)
+	c.Check(err, ErrorMatches, `model "my-brand/my-model" \(2\) does not match the allowed revision 1`)
+
+	manifest = seedwriter.NewManifest()
+	c.Assert(manifest.SetAllowedModel("my-brand", "my-model", 2), IsNil)
+	c.Assert(manifest.MarkModelSeeded(model), IsNil)
+	err = manifest.MarkModelSeeded(model)
+	c.Check(err, ErrorMatches, `cannot mark model "my-brand/my-model" as seeded, it has already been marked seeded`)
+}
+
+func (s *manifestSuite) TestManifestMarkComponentRevisionSeeded(c *C) {
+	cref := naming.NewComponentRef("pc", "kmod")
+	manifest := seedwriter.NewManifest()
+	err := manifest.SetAllowedComponentRevision(cref, snap.R(0))
+	c.Assert(err, ErrorMatches, `component revision for "pc\+kmod" in manifest cannot be 0 \(unset\)`)
+	c.Assert(manifest.SetAllowedComponentRevision(cref, snap.R(3)), IsNil)
+
+	err = manifest.MarkComponentRevisionSeeded(cref, snap.R(4))
+	c.Assert(err, ErrorMatches, `component "pc\+kmod" \(4\) does not match the allowed revision 3`)
+	c.Assert(manifest.MarkComponentRevisionSeeded(cref, snap.R(3)), IsNil)
+	err = manifest.MarkComponentRevisionSeeded(cref, snap.R(3))
+	c.Assert(err, ErrorMatches, `cannot mark "pc\+kmod" \(3\) as seeded, it has already been marked seeded for revision 3`)
+}
+
 func (s *manifestSuite) TestManifestSetAllowedSnapRevisionInvalidRevision(c *C) {
 	manifest := seedwriter.NewManifest()
 	err := manifest.SetAllowedSnapRevision("core", snap.R(0))
@@ -0,0 +1,334 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package seedwriter
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/snapdtool"
+	"github.com/snapcore/snapd/spdx"
+)
+
+var timeNow = time.Now
+
+const (
+	spdxVersion     = "SPDX-2.3"
+	spdxDataLicense = "CC0-1.0"
+	spdxNoAssertion = "NOASSERTION"
+	// spdxNamespacePrefix is used to build the unique document
+	// namespace, it is completed with the model and a digest of
+	// the seed contents
+	spdxNamespacePrefix = "https://snapcraft.io/spdx/seeds/"
+)
+
+// The types below cover the subset of the SPDX 2.3 JSON format that is
+// produced for seeds.
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	DocumentDescribes []string           `json:"documentDescribes"`
+	Packages          []*spdxPackage     `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxPackage struct {
+	SPDXID           string         `json:"SPDXID"`
+	Name             string         `json:"name"`
+	VersionInfo      string         `json:"versionInfo,omitempty"`
+	Supplier         string         `json:"supplier"`
+	DownloadLocation string         `json:"downloadLocation"`
+	FilesAnalyzed    bool           `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum `json:"checksums,omitempty"`
+	LicenseConcluded string         `json:"licenseConcluded"`
+	LicenseDeclared  string         `json:"licenseDeclared"`
+	CopyrightText    string         `json:"copyrightText"`
+	Comment          string         `json:"comment,omitempty"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func spdxSupplier(accountID string) string {
+	if accountID == "" {
+		return spdxNoAssertion
+	}
+	return fmt.Sprintf("Organization: %s", accountID)
+}
+
+// spdxSHA3_384 converts a snap or component digest as used in
+// assertions to a SPDX SHA3-384 checksum.
+func spdxSHA3_384(digest string) ([]spdxChecksum, error) {
+	b, err := base64.RawURLEncoding.DecodeString(digest)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode digest %q: %v", digest, err)
+	}
+	return []spdxChecksum{{Algorithm: "SHA3-384", ChecksumValue: hex.EncodeToString(b)}}, nil
+}
+
+// spdxLicense returns the license to declare for a license as found in
+// snap metadata, together with a comment explaining why it was not used
+// if it is not a valid SPDX expression.
+func spdxLicense(license string) (declared, comment string) {
+	if license == "" {
+		return spdxNoAssertion, ""
+	}
+	if err := spdx.ValidateLicense(license); err != nil {
+		return spdxNoAssertion, fmt.Sprintf("declared license %q is not a valid SPDX expression: %v", license, err)
+	}
+	return license, ""
+}
+
+func newSPDXDocument(model *asserts.Model) *spdxDocument {
+	modelID := "SPDXRef-Model"
+	doc := &spdxDocument{
+		SPDXVersion: spdxVersion,
+		DataLicense: spdxDataLicense,
+		SPDXID:      "SPDXRef-DOCUMENT",
+		Name:        fmt.Sprintf("%s-%s-seed", model.BrandID(), model.Model()),
+		CreationInfo: spdxCreationInfo{
+			Created:  timeNow().UTC().Format(time.RFC3339),
+			Creators: []string{fmt.Sprintf("Tool: snapd-%s", snapdtool.Version)},
+		},
+		DocumentDescribes: []string{modelID},
+	}
+	doc.Packages = append(doc.Packages, &spdxPackage{
+		SPDXID:           modelID,
+		Name:             fmt.Sprintf("%s/%s", model.BrandID(), model.Model()),
+		VersionInfo:      fmt.Sprintf("%d", model.Revision()),
+		Supplier:         spdxSupplier(model.BrandID()),
+		DownloadLocation: spdxNoAssertion,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxNoAssertion,
+		CopyrightText:    spdxNoAssertion,
+		Comment:          "model assertion the seed was built for",
+	})
+	doc.relate(doc.SPDXID, "DESCRIBES", modelID)
+	return doc
+}
+
+func (doc *spdxDocument) relate(from, relType, to string) {
+	doc.Relationships = append(doc.Relationships, spdxRelationship{
+		SPDXElementID:      from,
+		RelationshipType:   relType,
+		RelatedSPDXElement: to,
+	})
+}
+
+func (doc *spdxDocument) addValidationSet(vs *asserts.ValidationSet) {
+	id := fmt.Sprintf("SPDXRef-ValidationSet-%s.%s", vs.AccountID(), vs.Name())
+	doc.Packages = append(doc.Packages, &spdxPackage{
+		SPDXID:           id,
+		Name:             fmt.Sprintf("%s/%s", vs.AccountID(), vs.Name()),
+		VersionInfo:      fmt.Sprintf("%d", vs.Sequence()),
+		Supplier:         spdxSupplier(vs.AccountID()),
+		DownloadLocation: spdxNoAssertion,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxNoAssertion,
+		CopyrightText:    spdxNoAssertion,
+		Comment:          "validation set the seed was built against",
+	})
+	doc.relate("SPDXRef-Model", "DEPENDS_ON", id)
+}
+
+// finish sets the document namespace, which is derived from the
+// seed contents to be unique per distinct seed.
+func (doc *spdxDocument) finish() {
+	h := sha256.New()
+	for _, pkg := range doc.Packages {
+		fmt.Fprintf(h, "%s %s", pkg.SPDXID, pkg.VersionInfo)
+		for _, sum := range pkg.Checksums {
+			fmt.Fprintf(h, " %s", sum.ChecksumValue)
+		}
+		fmt.Fprintf(h, "\n")
+	}
+	doc.DocumentNamespace = fmt.Sprintf("%s%s-%x", spdxNamespacePrefix, doc.Name, h.Sum(nil))
+}
+
+func (w *Writer) snapDigest(sn *SeedSnap) (digest, publisher string, err error) {
+	for _, ref := range sn.aRefs {
+		switch ref.Type {
+		case asserts.SnapRevisionType:
+			a, err := ref.Resolve(w.db.Find)
+			if err != nil {
+				return "", "", fmt.Errorf("internal error: lost saved assertion")
+			}
+			digest = a.(*asserts.SnapRevision).SnapSHA3_384()
+		case asserts.SnapDeclarationType:
+			a, err := ref.Resolve(w.db.Find)
+			if err != nil {
+				return "", "", fmt.Errorf("internal error: lost saved assertion")
+			}
+			publisher = a.(*asserts.SnapDeclaration).PublisherID()
+		}
+	}
+	if digest == "" {
+		// unasserted local snap
+		digest, _, err = asserts.SnapFileSHA3_384(sn.Path)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return digest, publisher, nil
+}
+
+func (w *Writer) componentDigest(sn *SeedSnap, comp *SeedComponent) (string, error) {
+	for _, ref := range sn.aRefs {
+		if ref.Type != asserts.SnapResourceRevisionType {
+			continue
+		}
+		a, err := ref.Resolve(w.db.Find)
+		if err != nil {
+			return "", fmt.Errorf("internal error: lost saved assertion")
+		}
+		resRev := a.(*asserts.SnapResourceRevision)
+		if resRev.ResourceName() == comp.ComponentName {
+			return resRev.ResourceSHA3_384(), nil
+		}
+	}
+	// unasserted local component
+	digest, _, err := asserts.SnapFileSHA3_384(comp.Path)
+	return digest, err
+}
+
+func (w *Writer) addSnapToSBOM(doc *spdxDocument, sn *SeedSnap) error {
+	digest, publisher, err := w.snapDigest(sn)
+	if err != nil {
+		return err
+	}
+	checksums, err := spdxSHA3_384(digest)
+	if err != nil {
+		return err
+	}
+	license, comment := spdxLicense(sn.Info.License)
+	id := fmt.Sprintf("SPDXRef-Snap-%s", sn.SnapName())
+	doc.Packages = append(doc.Packages, &spdxPackage{
+		SPDXID:           id,
+		Name:             sn.SnapName(),
+		VersionInfo:      sn.Info.Revision.String(),
+		Supplier:         spdxSupplier(publisher),
+		DownloadLocation: spdxNoAssertion,
+		Checksums:        checksums,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  license,
+		CopyrightText:    spdxNoAssertion,
+		Comment:          comment,
+	})
+	doc.relate("SPDXRef-Model", "CONTAINS", id)
+
+	for i := range sn.Components {
+		comp := &sn.Components[i]
+		digest, err := w.componentDigest(sn, comp)
+		if err != nil {
+			return err
+		}
+		checksums, err := spdxSHA3_384(digest)
+		if err != nil {
+			return err
+		}
+		var rev string
+		if comp.Info != nil {
+			rev = comp.Info.Revision.String()
+		}
+		// component names cannot contain dots, which makes the
+		// identifier unambiguous
+		compID := fmt.Sprintf("SPDXRef-Component-%s.%s", comp.SnapName, comp.ComponentName)
+		doc.Packages = append(doc.Packages, &spdxPackage{
+			SPDXID:           compID,
+			Name:             comp.ComponentRef.String(),
+			VersionInfo:      rev,
+			Supplier:         spdxSupplier(publisher),
+			DownloadLocation: spdxNoAssertion,
+			Checksums:        checksums,
+			LicenseConcluded: spdxNoAssertion,
+			// components do not declare a license
+			LicenseDeclared: spdxNoAssertion,
+			CopyrightText:   spdxNoAssertion,
+		})
+		doc.relate(id, "CONTAINS", compID)
+	}
+	return nil
+}
+
+// writeSBOM writes an SPDX SBOM document in JSON format describing the
+// seeded snaps and components together with the model and the
+// validation sets used.
+func (w *Writer) writeSBOM(path string) error {
+	doc := newSPDXDocument(w.model)
+
+	var vss []*asserts.ValidationSet
+	for _, mvs := range w.model.ValidationSets() {
+		atSeq, err := w.finalValidationSetAtSequence(mvs)
+		if err != nil {
+			return err
+		}
+		a, err := w.resolveValidationSetAssertion(atSeq)
+		if err != nil {
+			return fmt.Errorf("internal error: cannot resolve validation-set: %v", err)
+		}
+		vss = append(vss, a.(*asserts.ValidationSet))
+	}
+	sort.Slice(vss, func(i, j int) bool {
+		return vss[i].AccountID()+"/"+vss[i].Name() < vss[j].AccountID()+"/"+vss[j].Name()
+	})
+	for _, vs := range vss {
+		doc.addValidationSet(vs)
+	}
+
+	for _, sns := range [][]*SeedSnap{w.snapsFromModel, w.extraSnaps} {
+		for _, sn := range sns {
+			if err := w.addSnapToSBOM(doc, sn); err != nil {
+				return fmt.Errorf("cannot describe snap %q in SBOM: %v", sn.SnapName(), err)
+			}
+		}
+	}
+	doc.finish()
+
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(path, append(b, '\n'), 0644, 0)
+}
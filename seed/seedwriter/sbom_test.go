@@ -0,0 +1,59 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package seedwriter_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/seed/seedwriter"
+)
+
+type sbomSuite struct{}
+
+var _ = Suite(&sbomSuite{})
+
+func (s *sbomSuite) TestSpdxLicense(c *C) {
+	for _, t := range []struct {
+		license  string
+		declared string
+		comment  string
+	}{
+		{"", "NOASSERTION", ""},
+		{"MIT", "MIT", ""},
+		{"GPL-3.0-only OR MIT", "GPL-3.0-only OR MIT", ""},
+		{"Foo Bar", "NOASSERTION", `declared license "Foo Bar" is not a valid SPDX expression: .*`},
+	} {
+		declared, comment := seedwriter.SpdxLicense(t.license)
+		c.Check(declared, Equals, t.declared, Commentf(t.license))
+		c.Check(comment, Matches, t.comment, Commentf(t.license))
+	}
+}
+
+func (s *sbomSuite) TestSpdxSHA3_384(c *C) {
+	// the digest of an empty file
+	sums, err := seedwriter.SpdxSHA3_384("DGOnW4ReT30BEH2FLkwkhcUaUKqqlPxhmV5xu-6YOirDcTgxJkrbR_tr0eBY1fAE")
+	c.Assert(err, IsNil)
+	c.Assert(sums, HasLen, 1)
+	c.Check(sums[0].Algorithm, Equals, "SHA3-384")
+	c.Check(sums[0].ChecksumValue, Equals, "0c63a75b845e4f7d01107d852e4c2485c51a50aaaa94fc61995e71bbee983a2ac3713831264adb47fb6bd1e058d5f004")
+
+	_, err = seedwriter.SpdxSHA3_384("%%")
+	c.Check(err, ErrorMatches, `cannot decode digest "%%": .*`)
+}
@@ -57,6 +57,10 @@ type Options struct {
 	// seed.manifest file should be written.
 	ManifestPath string
 
+	// SBOMPath if set, specifies the file path where an SPDX SBOM
+	// document in JSON format describing the seed should be written.
+	SBOMPath string
+
 	// IgnoreOptionFileExtentions if set, snaps and components will not be
 	// required to end in .snap or .comp, respectively.
 	IgnoreOptionFileExtentions bool
@@ -326,6 +330,9 @@ func New(model *asserts.Model, opts *Options) (*Writer, error) {
 		byRefLocalSnaps: naming.NewSnapSet(nil),
 		manifest:        opts.manifest(),
 	}
+	if err := w.manifest.MarkModelSeeded(model); err != nil {
+		return nil, fmt.Errorf("cannot record model for manifest: %s", err)
+	}
 
 	var treeImpl tree
 	var pol policy
@@ -1686,6 +1693,14 @@ func (w *Writer) SeedSnaps(copySnap func(name, src, dst string) error) error {
 					return fmt.Errorf("cannot record snap for manifest: %s", err)
 				}
 			}
+			for _, comp := range sn.Components {
+				if comp.Info == nil || comp.Info.Revision.Unset() {
+					continue
+				}
+				if err := w.manifest.MarkComponentRevisionSeeded(comp.ComponentRef, comp.Info.Revision); err != nil {
+					return fmt.Errorf("cannot record component for manifest: %s", err)
+				}
+			}
 		}
 		return nil
 	}
@@ -1730,6 +1745,12 @@ func (w *Writer) WriteMeta() error {
 		}
 	}
 
+	if w.opts.SBOMPath != "" {
+		if err := w.writeSBOM(w.opts.SBOMPath); err != nil {
+			return err
+		}
+	}
+
 	snapsFromModel := w.snapsFromModel
 	extraSnaps := w.extraSnaps
 
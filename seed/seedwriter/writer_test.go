@@ -20,6 +20,8 @@
 package seedwriter_test
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -4806,13 +4808,138 @@ func (s *writerSuite) TestManifestCorrectlyProduced(c *C) {
 
 	b, err := os.ReadFile(path.Join(s.opts.SeedDir, "seed.manifest"))
 	c.Assert(err, IsNil)
-	c.Check(string(b), Equals, `core20 1
+	c.Check(string(b), Equals, `model my-brand/my-model 0
+core20 1
 pc 1
 pc-kernel 1
 snapd 1
 `)
 }
 
+func (s *writerSuite) TestSBOMCorrectlyProduced(c *C) {
+	model := s.Brands.Model("my-brand", "my-model", map[string]any{
+		"display-name": "my model",
+		"architecture": "amd64",
+		"base":         "core20",
+		"grade":        "dangerous",
+		"snaps": []any{
+			map[string]any{
+				"name":            "pc-kernel",
+				"id":              s.AssertedSnapID("pc-kernel"),
+				"type":            "kernel",
+				"default-channel": "20",
+			},
+			map[string]any{
+				"name":            "pc",
+				"id":              s.AssertedSnapID("pc"),
+				"type":            "gadget",
+				"default-channel": "20",
+			}},
+	})
+
+	s.makeSnap(c, "snapd", "")
+	s.makeSnap(c, "core20", "")
+	s.makeSnap(c, "pc-kernel=20", "")
+	s.makeSnap(c, "pc=20", "")
+
+	restore := seedwriter.MockTimeNow(func() time.Time {
+		return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	})
+	defer restore()
+
+	s.opts.Label = "20191122"
+	s.opts.SBOMPath = path.Join(s.opts.SeedDir, "seed.spdx.json")
+	w, err := seedwriter.New(model, s.opts)
+	c.Assert(err, IsNil)
+
+	err = w.Start(s.db, s.rf)
+	c.Assert(err, IsNil)
+
+	_, err = w.LocalSnaps()
+	c.Assert(err, IsNil)
+	err = w.InfoDerived()
+	c.Assert(err, IsNil)
+
+	snaps, err := w.SnapsToDownload()
+	c.Assert(err, IsNil)
+	c.Check(snaps, HasLen, 4)
+	for _, sn := range snaps {
+		s.fillDownloadedSnap(c, w, sn)
+	}
+
+	complete, err := w.Downloaded(s.fetchAsserts(c))
+	c.Assert(err, IsNil)
+	c.Check(complete, Equals, true)
+
+	err = w.SeedSnaps(func(name, src, dst string) error {
+		return osutil.CopyFile(src, dst, 0)
+	})
+	c.Assert(err, IsNil)
+
+	err = w.WriteMeta()
+	c.Assert(err, IsNil)
+
+	b, err := os.ReadFile(s.opts.SBOMPath)
+	c.Assert(err, IsNil)
+	var doc struct {
+		SPDXVersion       string `json:"spdxVersion"`
+		DocumentNamespace string `json:"documentNamespace"`
+		CreationInfo      struct {
+			Created string `json:"created"`
+		} `json:"creationInfo"`
+		DocumentDescribes []string `json:"documentDescribes"`
+		Packages          []struct {
+			SPDXID      string `json:"SPDXID"`
+			Name        string `json:"name"`
+			VersionInfo string `json:"versionInfo"`
+			Supplier    string `json:"supplier"`
+			Checksums   []struct {
+				Algorithm     string `json:"algorithm"`
+				ChecksumValue string `json:"checksumValue"`
+			} `json:"checksums"`
+			LicenseDeclared string `json:"licenseDeclared"`
+		} `json:"packages"`
+		Relationships []struct {
+			SPDXElementID      string `json:"spdxElementId"`
+			RelationshipType   string `json:"relationshipType"`
+			RelatedSPDXElement string `json:"relatedSpdxElement"`
+		} `json:"relationships"`
+	}
+	c.Assert(json.Unmarshal(b, &doc), IsNil)
+
+	c.Check(doc.SPDXVersion, Equals, "SPDX-2.3")
+	c.Check(doc.CreationInfo.Created, Equals, "2026-01-02T03:04:05Z")
+	c.Check(doc.DocumentNamespace, Matches, `https://snapcraft.io/spdx/seeds/my-brand-my-model-seed-[0-9a-f]{64}`)
+	c.Check(doc.DocumentDescribes, DeepEquals, []string{"SPDXRef-Model"})
+
+	c.Assert(doc.Packages, HasLen, 5)
+	c.Check(doc.Packages[0].SPDXID, Equals, "SPDXRef-Model")
+	c.Check(doc.Packages[0].Name, Equals, "my-brand/my-model")
+	c.Check(doc.Packages[0].Supplier, Equals, "Organization: my-brand")
+	var names []string
+	for _, pkg := range doc.Packages[1:] {
+		names = append(names, pkg.Name)
+		c.Check(pkg.SPDXID, Equals, "SPDXRef-Snap-"+pkg.Name)
+		c.Check(pkg.VersionInfo, Equals, "1")
+		c.Check(pkg.Supplier, Equals, "Organization: canonical")
+		c.Check(pkg.LicenseDeclared, Equals, "NOASSERTION")
+		c.Assert(pkg.Checksums, HasLen, 1)
+		c.Check(pkg.Checksums[0].Algorithm, Equals, "SHA3-384")
+		digest, err := base64.RawURLEncoding.DecodeString(s.AssertedSnapRevision(pkg.Name).SnapSHA3_384())
+		c.Assert(err, IsNil)
+		c.Check(pkg.Checksums[0].ChecksumValue, Equals, hex.EncodeToString(digest))
+	}
+	c.Check(names, DeepEquals, []string{"snapd", "pc-kernel", "core20", "pc"})
+
+	c.Assert(doc.Relationships, HasLen, 5)
+	c.Check(doc.Relationships[0].SPDXElementID, Equals, "SPDXRef-DOCUMENT")
+	c.Check(doc.Relationships[0].RelationshipType, Equals, "DESCRIBES")
+	for _, rel := range doc.Relationships[1:] {
+		c.Check(rel.SPDXElementID, Equals, "SPDXRef-Model")
+		c.Check(rel.RelationshipType, Equals, "CONTAINS")
+	}
+}
+
 func (s *writerSuite) TestManifestPreProvidedFailsMarkSeeding(c *C) {
 	model := s.Brands.Model("my-brand", "my-model", map[string]any{
 		"display-name": "my model",
@@ -5097,7 +5224,8 @@ sequence: 1`)
 	// the validation-set tracking those.
 	m, err := os.ReadFile(s.opts.ManifestPath)
 	c.Assert(err, IsNil)
-	c.Check(string(m), Equals, `canonical/base-set 1
+	c.Check(string(m), Equals, `model my-brand/my-model 0
+canonical/base-set 1
 core20 1
 snapd 1
 `)
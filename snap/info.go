@@ -407,6 +407,14 @@ type Info struct {
 
 	Components map[string]*Component
 
+	// HealthCheckInterval is how often snapd should run the check-health
+	// hook of the snap, zero if only at install and refresh.
+	HealthCheckInterval timeout.Timeout
+
+	// HealthRollback is the policy declared by the snap for reverting
+	// refreshes to it whose health turns bad.
+	HealthRollback HealthRollbackInfo
+
 	// Plugs or slots with issues (they are not included in Plugs or Slots)
 	BadInterfaces map[string]string // slot or plug => message
 
@@ -459,6 +467,18 @@ type Info struct {
 	IntegrityData *IntegrityDataInfo
 }
 
+// HealthRollbackInfo holds the policy declared by a snap for reverting
+// refreshes to it whose health turns bad. Unset durations fall back to the
+// health.rollback system options.
+type HealthRollbackInfo struct {
+	// GracePeriod is for how long after a refresh the health turning to
+	// error reverts the refresh.
+	GracePeriod timeout.Timeout
+	// WaitingTimeout is for how long after a refresh the health can be
+	// waiting before the refresh is reverted.
+	WaitingTimeout timeout.Timeout
+}
+
 // StoreAccount holds information about a store account, for example of snap
 // publisher.
 type StoreAccount struct {
@@ -58,6 +58,9 @@ type snapYaml struct {
 	Links           map[string][]string      `yaml:"links,omitempty"`
 	Components      map[string]componentYaml `yaml:"components,omitempty"`
 
+	HealthCheckInterval timeout.Timeout    `yaml:"health-check-interval,omitempty"`
+	HealthRollback      healthRollbackYaml `yaml:"health-rollback,omitempty"`
+
 	// TypoLayouts is used to detect the use of the incorrect plural form of "layout"
 	TypoLayouts typoDetector `yaml:"layouts,omitempty"`
 }
@@ -119,6 +122,11 @@ type hookYaml struct {
 	CommandChain []string           `yaml:"command-chain,omitempty"`
 }
 
+type healthRollbackYaml struct {
+	GracePeriod    timeout.Timeout `yaml:"grace-period,omitempty"`
+	WaitingTimeout timeout.Timeout `yaml:"waiting-timeout,omitempty"`
+}
+
 type componentYaml struct {
 	Type        ComponentType       `yaml:"type"`
 	Summary     string              `yaml:"summary"`
@@ -310,6 +318,11 @@ func infoSkeletonFromSnapYaml(y snapYaml) *Info {
 		Environment:         y.Environment,
 		SystemUsernames:     make(map[string]*SystemUsernameInfo),
 		OriginalLinks:       make(map[string][]string),
+		HealthCheckInterval: y.HealthCheckInterval,
+		HealthRollback: HealthRollbackInfo{
+			GracePeriod:    y.HealthRollback.GracePeriod,
+			WaitingTimeout: y.HealthRollback.WaitingTimeout,
+		},
 	}
 
 	sort.Strings(snap.Assumes)
@@ -87,6 +87,35 @@ version: 1.0`))
 	c.Check(info.SnapProvenance, Equals, "delegated-prov")
 }
 
+func (s *InfoSnapYamlTestSuite) TestHealthCheckInterval(c *C) {
+	info, err := snap.InfoFromSnapYaml([]byte(`name: foo
+version: 1.0
+health-check-interval: 15m`))
+	c.Assert(err, IsNil)
+	c.Check(info.HealthCheckInterval, Equals, timeout.Timeout(15*time.Minute))
+
+	info, err = snap.InfoFromSnapYaml(mockYaml)
+	c.Assert(err, IsNil)
+	c.Check(info.HealthCheckInterval, Equals, timeout.Timeout(0))
+}
+
+func (s *InfoSnapYamlTestSuite) TestHealthRollback(c *C) {
+	info, err := snap.InfoFromSnapYaml([]byte(`name: foo
+version: 1.0
+health-rollback:
+  grace-period: 30m
+  waiting-timeout: 5m`))
+	c.Assert(err, IsNil)
+	c.Check(info.HealthRollback, Equals, snap.HealthRollbackInfo{
+		GracePeriod:    timeout.Timeout(30 * time.Minute),
+		WaitingTimeout: timeout.Timeout(5 * time.Minute),
+	})
+
+	info, err = snap.InfoFromSnapYaml(mockYaml)
+	c.Assert(err, IsNil)
+	c.Check(info.HealthRollback, Equals, snap.HealthRollbackInfo{})
+}
+
 func (s *InfoSnapYamlTestSuite) TestFail(c *C) {
 	_, err := snap.InfoFromSnapYaml([]byte("random-crap"))
 	c.Assert(err, ErrorMatches, "(?m)cannot parse snap.yaml:.*")
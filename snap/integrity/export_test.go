@@ -30,3 +30,11 @@ func MockReadDmVeritySuperblock(f func(filename string) (*dmverity.VeritySuperbl
 		readDmVeritySuperblock = origReadDmVeritySuperblock
 	}
 }
+
+func MockFormatDmVerity(f func(dataDevice, hashDevice string, opts *dmverity.DmVerityParams) (string, error)) (restore func()) {
+	origFormatDmVerity := formatDmVerity
+	formatDmVerity = f
+	return func() {
+		formatDmVerity = origFormatDmVerity
+	}
+}
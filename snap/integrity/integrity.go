@@ -31,6 +31,7 @@ import (
 
 var (
 	readDmVeritySuperblock = dmverity.ReadSuperblock
+	formatDmVerity         = dmverity.Format
 )
 
 // IntegrityDataParams struct includes all the parameters that are necessary
@@ -169,3 +170,46 @@ func LookupDmVerityDataAndCrossCheck(snapPath string, params *IntegrityDataParam
 
 	return hashFileName, nil
 }
+
+// GenerateDmVerityDataAndCrossCheck generates the dm-verity data of a snap
+// next to it, where LookupDmVerityDataAndCrossCheck expects them, with the
+// passed parameters, and validates that their root hash matches the digest of
+// the parameters. The generated data are removed if they do not match.
+func GenerateDmVerityDataAndCrossCheck(snapPath string, params *IntegrityDataParams) (_ string, err error) {
+	if params == nil {
+		return "", ErrIntegrityDataParamsNotFound
+	}
+
+	if params.Type != "dm-verity" {
+		return "", fmt.Errorf("%w: expected %q but found %q.", ErrUnexpectedIntegrityDataType, "dm-verity", params.Type)
+	}
+
+	hashFileName, err := params.IntegrityFile(snapPath)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		if err != nil {
+			os.Remove(hashFileName)
+		}
+	}()
+
+	rootHash, err := formatDmVerity(snapPath, hashFileName, &dmverity.DmVerityParams{
+		Format:        uint8(params.Version),
+		Hash:          params.HashAlg,
+		DataBlocks:    params.DataBlocks,
+		DataBlockSize: params.DataBlockSize,
+		HashBlockSize: params.HashBlockSize,
+		Salt:          params.Salt,
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot generate dm-verity data %q: %v", hashFileName, err)
+	}
+
+	if rootHash != params.Digest {
+		return "", fmt.Errorf("%w %q: unexpected root hash: %s != %s", ErrUnexpectedDmVerityData, hashFileName, rootHash, params.Digest)
+	}
+
+	return hashFileName, nil
+}
@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -320,3 +321,78 @@ func (s *IntegrityTestSuite) TestIntegrityDataParamsIntegrityFile(c *C) {
 	_, err = idp.IntegrityFile("/path/to/instance.snap")
 	c.Assert(err, ErrorMatches, `unexpected integrity data type "bad-type"`)
 }
+
+func (s *IntegrityTestSuite) TestGenerateDmVerityDataAndCrossCheck(c *C) {
+	snapPath := filepath.Join(c.MkDir(), "foo.snap")
+	verityFilePath := snapPath + ".dmverity_aaa"
+
+	restore := integrity.MockFormatDmVerity(func(dataDevice, hashDevice string, opts *dmverity.DmVerityParams) (string, error) {
+		c.Check(dataDevice, Equals, snapPath)
+		c.Check(hashDevice, Equals, verityFilePath)
+		c.Check(opts, DeepEquals, &dmverity.DmVerityParams{
+			Format:        1,
+			Hash:          "sha256",
+			DataBlocks:    2048,
+			DataBlockSize: 4096,
+			HashBlockSize: 4096,
+			Salt:          "bbb",
+		})
+		return "aaa", os.WriteFile(hashDevice, nil, 0644)
+	})
+	defer restore()
+
+	idp := &integrity.IntegrityDataParams{
+		Type:          "dm-verity",
+		Version:       1,
+		HashAlg:       "sha256",
+		DataBlocks:    2048,
+		DataBlockSize: 4096,
+		HashBlockSize: 4096,
+		Digest:        "aaa",
+		Salt:          "bbb",
+	}
+
+	hashFileName, err := integrity.GenerateDmVerityDataAndCrossCheck(snapPath, idp)
+	c.Assert(err, IsNil)
+	c.Check(hashFileName, Equals, verityFilePath)
+	c.Check(verityFilePath, testutil.FilePresent)
+}
+
+func (s *IntegrityTestSuite) TestGenerateDmVerityDataAndCrossCheckRootHashMismatch(c *C) {
+	snapPath := filepath.Join(c.MkDir(), "foo.snap")
+	verityFilePath := snapPath + ".dmverity_aaa"
+
+	restore := integrity.MockFormatDmVerity(func(dataDevice, hashDevice string, opts *dmverity.DmVerityParams) (string, error) {
+		return "ccc", os.WriteFile(hashDevice, nil, 0644)
+	})
+	defer restore()
+
+	idp := &integrity.IntegrityDataParams{
+		Type:   "dm-verity",
+		Digest: "aaa",
+	}
+
+	_, err := integrity.GenerateDmVerityDataAndCrossCheck(snapPath, idp)
+	c.Check(errors.Is(err, integrity.ErrUnexpectedDmVerityData), Equals, true)
+	c.Check(err, ErrorMatches, `unexpected dm-verity data ".*/foo.snap.dmverity_aaa": unexpected root hash: ccc != aaa`)
+	c.Check(verityFilePath, testutil.FileAbsent)
+}
+
+func (s *IntegrityTestSuite) TestGenerateDmVerityDataAndCrossCheckFormatError(c *C) {
+	restore := integrity.MockFormatDmVerity(func(dataDevice, hashDevice string, opts *dmverity.DmVerityParams) (string, error) {
+		return "", errors.New("boom")
+	})
+	defer restore()
+
+	idp := &integrity.IntegrityDataParams{
+		Type:   "dm-verity",
+		Digest: "aaa",
+	}
+
+	_, err := integrity.GenerateDmVerityDataAndCrossCheck("foo.snap", idp)
+	c.Check(err, ErrorMatches, `cannot generate dm-verity data "foo.snap.dmverity_aaa": boom`)
+
+	idp.Type = "bad-type"
+	_, err = integrity.GenerateDmVerityDataAndCrossCheck("foo.snap", idp)
+	c.Check(errors.Is(err, integrity.ErrUnexpectedIntegrityDataType), Equals, true)
+}
@@ -29,6 +29,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/snapcore/snapd/osutil"
@@ -503,6 +504,11 @@ func Validate(info *Info) error {
 		return err
 	}
 
+	// validate ordering of services across snaps
+	if err := validateServiceOrdering(info); err != nil {
+		return err
+	}
+
 	// validate aliases
 	for alias, app := range info.LegacyAliases {
 		if err := naming.ValidateAlias(alias); err != nil {
@@ -515,6 +521,14 @@ func Validate(info *Info) error {
 		return err
 	}
 
+	if err := validateHealthCheckInterval(info); err != nil {
+		return err
+	}
+
+	if err := validateHealthRollback(info); err != nil {
+		return err
+	}
+
 	// Ensure that plugs and slots have appropriate names and interface names.
 	if err := plugsSlotsInterfacesNames(info); err != nil {
 		return err
@@ -725,6 +739,38 @@ func validateAppOrderCycles(apps []*AppInfo) error {
 	return nil
 }
 
+// validateServiceOrdering checks that the apps listing plugs or slots of
+// the service-ordering interface are system services, as the services bound
+// to a plug are ordered after the ones bound to the connected slot. Unscoped
+// plugs and slots apply to whatever system services the snap has.
+func validateServiceOrdering(info *Info) error {
+	checkApps := func(kind, name string, apps map[string]*AppInfo) error {
+		for _, app := range apps {
+			if !app.IsService() || app.DaemonScope != SystemDaemon {
+				return fmt.Errorf("service-ordering %s %q cannot be bound to %q, which is not a system service", kind, name, app.Name)
+			}
+		}
+		return nil
+	}
+	for _, plug := range info.Plugs {
+		if plug.Interface != "service-ordering" || plug.Unscoped {
+			continue
+		}
+		if err := checkApps("plug", plug.Name, plug.Apps); err != nil {
+			return err
+		}
+	}
+	for _, slot := range info.Slots {
+		if slot.Interface != "service-ordering" || slot.Unscoped {
+			continue
+		}
+		if err := checkApps("slot", slot.Name, slot.Apps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func validateAppOrderNames(app *AppInfo, dependencies []string) error {
 	// we must be a service to request ordering
 	if len(dependencies) > 0 && !app.IsService() {
@@ -772,6 +818,38 @@ func validateAppTimeouts(app *AppInfo) error {
 	return nil
 }
 
+// MinHealthCheckInterval is the shortest interval at which snapd can be
+// asked to run the check-health hook of a snap.
+const MinHealthCheckInterval = time.Minute
+
+func validateHealthCheckInterval(info *Info) error {
+	interval := info.HealthCheckInterval
+	if interval == 0 {
+		return nil
+	}
+	if interval < 0 {
+		return fmt.Errorf("health-check-interval cannot be negative")
+	}
+	if time.Duration(interval) < MinHealthCheckInterval {
+		return fmt.Errorf("health-check-interval cannot be shorter than %s", MinHealthCheckInterval)
+	}
+	return nil
+}
+
+func validateHealthRollback(info *Info) error {
+	rollback := info.HealthRollback
+	if rollback.GracePeriod < 0 {
+		return fmt.Errorf("health-rollback grace-period cannot be negative")
+	}
+	if rollback.WaitingTimeout < 0 {
+		return fmt.Errorf("health-rollback waiting-timeout cannot be negative")
+	}
+	if rollback.GracePeriod > 0 && rollback.WaitingTimeout > rollback.GracePeriod {
+		return fmt.Errorf("health-rollback waiting-timeout cannot be longer than its grace-period")
+	}
+	return nil
+}
+
 func validateAppTimer(app *AppInfo) error {
 	if app.Timer == nil {
 		return nil
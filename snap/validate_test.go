@@ -1629,6 +1629,68 @@ apps:
 	}
 }
 
+func (s *ValidateSuite) TestValidateServiceOrdering(c *C) {
+	meta := []byte(`
+name: foo
+version: 1.0
+plugs:
+  db:
+    interface: service-ordering
+slots:
+  ready:
+    interface: service-ordering
+`)
+	tcs := []struct {
+		desc string
+		err  string
+	}{{
+		// unscoped plugs and slots
+		desc: `
+apps:
+  svc:
+    daemon: simple
+  cli:
+    command: cli
+`,
+	}, {
+		desc: `
+apps:
+  svc:
+    daemon: simple
+    plugs: [db]
+    slots: [ready]
+`,
+	}, {
+		desc: `
+apps:
+  cli:
+    command: cli
+    plugs: [db]
+`,
+		err: `service-ordering plug "db" cannot be bound to "cli", which is not a system service`,
+	}, {
+		desc: `
+apps:
+  svc:
+    daemon: simple
+    daemon-scope: user
+    slots: [ready]
+`,
+		err: `service-ordering slot "ready" cannot be bound to "svc", which is not a system service`,
+	}}
+	for _, tc := range tcs {
+		info, err := InfoFromSnapYaml(append(meta, tc.desc...))
+		c.Assert(err, IsNil)
+
+		err = Validate(info)
+		if tc.err != "" {
+			c.Check(err, ErrorMatches, tc.err)
+		} else {
+			c.Check(err, IsNil)
+		}
+	}
+}
+
 func (s *ValidateSuite) TestValidateAppWatchdogTimeout(c *C) {
 	s.testValidateAppTimeout(c, "watchdog")
 }
@@ -1639,6 +1701,61 @@ func (s *ValidateSuite) TestValidateAppStopTimeout(c *C) {
 	s.testValidateAppTimeout(c, "stop")
 }
 
+func (s *ValidateSuite) TestValidateHealthCheckInterval(c *C) {
+	for _, t := range []struct {
+		interval string
+		err      string
+	}{
+		{"", ""},
+		{"1m", ""},
+		{"2h", ""},
+		{"30s", `health-check-interval cannot be shorter than 1m0s`},
+		{"-5m", `health-check-interval cannot be negative`},
+	} {
+		yaml := "name: foo\nversion: 1.0\n"
+		if t.interval != "" {
+			yaml += "health-check-interval: " + t.interval + "\n"
+		}
+		info, err := InfoFromSnapYaml([]byte(yaml))
+		c.Assert(err, IsNil)
+		err = Validate(info)
+		if t.err == "" {
+			c.Check(err, IsNil, Commentf(t.interval))
+		} else {
+			c.Check(err, ErrorMatches, t.err, Commentf(t.interval))
+		}
+	}
+}
+
+func (s *ValidateSuite) TestValidateHealthRollback(c *C) {
+	for _, t := range []struct {
+		rollback string
+		err      string
+	}{
+		{"", ""},
+		{"grace-period: 30m", ""},
+		{"waiting-timeout: 5m", ""},
+		{"{grace-period: 30m, waiting-timeout: 5m}", ""},
+		{"{grace-period: 30m, waiting-timeout: 30m}", ""},
+		{"grace-period: -5m", `health-rollback grace-period cannot be negative`},
+		{"waiting-timeout: -5m", `health-rollback waiting-timeout cannot be negative`},
+		{"{grace-period: 5m, waiting-timeout: 30m}", `health-rollback waiting-timeout cannot be longer than its grace-period`},
+	} {
+		yaml := "name: foo\nversion: 1.0\n"
+		if t.rollback != "" {
+			yaml += "health-rollback: {" + strings.Trim(t.rollback, "{}") + "}\n"
+		}
+		info, err := InfoFromSnapYaml([]byte(yaml))
+		c.Assert(err, IsNil)
+		err = Validate(info)
+		if t.err == "" {
+			c.Check(err, IsNil, Commentf(t.rollback))
+		} else {
+			c.Check(err, ErrorMatches, t.err, Commentf(t.rollback))
+		}
+	}
+}
+
 func (s *ValidateSuite) testValidateAppTimeout(c *C, timeout string) {
 	timeout += "-timeout"
 	meta := []byte(`
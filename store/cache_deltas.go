@@ -0,0 +1,397 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/squashfs"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// cachePeerDeltasPathPrefix is the path under which deltas between snaps in
+// the downloads cache are served to peers, as
+// <prefix><format>/<source sha3-384>/<target sha3-384>.
+const cachePeerDeltasPathPrefix = "/v2/cache/deltas/"
+
+// maxGeneratedDeltas is the number of generated deltas which are kept around
+// to be served again to other peers.
+var maxGeneratedDeltas = 20
+
+var squashfsGenerateDelta = squashfs.GenerateDelta
+
+var errNoDeltaSource = errors.New("no installed revision to use as delta source")
+
+func generatedDeltasDir() string {
+	return filepath.Join(dirs.SnapCacheDir, "deltas")
+}
+
+func generatedDeltaPath(format, sourceSha3, targetSha3 string) string {
+	return filepath.Join(generatedDeltasDir(), fmt.Sprintf("%s-to-%s.%s", sourceSha3, targetSha3, format))
+}
+
+// serveCacheDelta serves a delta between two snaps in the downloads cache.
+// Peers are not authenticated, so deltas are never generated while serving
+// the request. A delta from a snap in the cache which is not available yet is
+// generated in the background instead, for peers asking for it later on.
+func (s *Store) serveCacheDelta(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, cachePeerDeltasPathPrefix), "/")
+	if len(parts) != 3 || !isSha3_384Digest(parts[1]) || !isSha3_384Digest(parts[2]) || parts[1] == parts[2] {
+		http.NotFound(w, r)
+		return
+	}
+	format, sourceSha3, targetSha3 := parts[0], parts[1], parts[2]
+	if !strutil.ListContains(s.supportedDeltaFormats(), format) {
+		http.Error(w, fmt.Sprintf("unsupported delta format %q", format), http.StatusNotFound)
+		return
+	}
+
+	deltaPath := generatedDeltaPath(format, sourceSha3, targetSha3)
+	f, err := os.Open(deltaPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.generateRequestedCacheDeltaInBackground(format, sourceSha3, targetSha3)
+		}
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	// mark as recently used
+	now := time.Now()
+	os.Chtimes(deltaPath, now, now)
+	http.ServeContent(w, r, filepath.Base(deltaPath), time.Time{}, f)
+}
+
+// maxRequestedDeltas is the number of deltas requested by peers which may be
+// waiting to be generated at the same time.
+var maxRequestedDeltas = 4
+
+// startServingCache enables the generation of deltas for cache peers.
+func (s *Store) startServingCache() {
+	s.cacheServeMu.Lock()
+	defer s.cacheServeMu.Unlock()
+	if s.deltaGenCtx == nil {
+		s.deltaGenCtx, s.deltaGenCancel = context.WithCancel(context.Background())
+	}
+}
+
+// StopServingCache stops the generation of deltas for cache peers enabled by
+// CacheHandler, and waits for the deltas being generated to be done.
+func (s *Store) StopServingCache() {
+	s.cacheServeMu.Lock()
+	cancel := s.deltaGenCancel
+	s.deltaGenCtx = nil
+	s.deltaGenCancel = nil
+	s.cacheServeMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	s.deltaGenWG.Wait()
+}
+
+// goGenerateDelta runs the given delta generation in the background, if the
+// downloads cache is served to peers. Generation is cancelled by
+// StopServingCache.
+//
+// The caller must ensure that cacheServeMu is held.
+func (s *Store) goGenerateDelta(name string, generate func(ctx context.Context) error, done func()) bool {
+	if s.deltaGenCtx == nil {
+		return false
+	}
+	ctx := s.deltaGenCtx
+	s.deltaGenWG.Add(1)
+	go func() {
+		defer s.deltaGenWG.Done()
+		if done != nil {
+			defer done()
+		}
+		err := generate(ctx)
+		if err != nil && !errors.Is(err, errNoDeltaSource) && ctx.Err() == nil {
+			logger.Noticef("Cannot generate delta for %s for cache peers: %v", name, err)
+		}
+	}()
+	return true
+}
+
+// generateCacheDeltaInBackground generates a delta for the just downloaded
+// snap addressed by download info in the background, if the downloads cache
+// is served to peers.
+func (s *Store) generateCacheDeltaInBackground(name, targetPath string, downloadInfo *snap.DownloadInfo) {
+	s.cacheServeMu.Lock()
+	defer s.cacheServeMu.Unlock()
+	s.goGenerateDelta(name, func(ctx context.Context) error {
+		return s.generateCacheDelta(ctx, name, targetPath, downloadInfo)
+	}, nil)
+}
+
+// generateRequestedCacheDeltaInBackground generates a delta requested by a
+// peer in the background, if both the snap the peer has and the target snap
+// are in the downloads cache. Only a few requested deltas are generated at
+// the same time, further requests are ignored until those are done.
+func (s *Store) generateRequestedCacheDeltaInBackground(format, sourceSha3, targetSha3 string) {
+	sourcePath := s.cacher.GetPath(sourceSha3)
+	targetPath := s.cacher.GetPath(targetSha3)
+	if sourcePath == "" || targetPath == "" {
+		return
+	}
+
+	s.cacheServeMu.Lock()
+	defer s.cacheServeMu.Unlock()
+	key := generatedDeltaPath(format, sourceSha3, targetSha3)
+	if s.deltaGenRequested[key] || len(s.deltaGenRequested) >= maxRequestedDeltas {
+		return
+	}
+	started := s.goGenerateDelta(targetSha3, func(ctx context.Context) error {
+		return s.generateDelta(ctx, format, sourcePath, sourceSha3, targetPath, targetSha3)
+	}, func() {
+		s.cacheServeMu.Lock()
+		defer s.cacheServeMu.Unlock()
+		delete(s.deltaGenRequested, key)
+	})
+	if started {
+		if s.deltaGenRequested == nil {
+			s.deltaGenRequested = make(map[string]bool)
+		}
+		s.deltaGenRequested[key] = true
+	}
+}
+
+// generateCacheDelta generates a delta in the preferred format from the latest
+// installed revision of the given snap, other than the one at targetPath, to
+// the snap addressed by download info in the downloads cache. Peers updating
+// from the same revision can then download the delta instead of the snap.
+func (s *Store) generateCacheDelta(ctx context.Context, name, targetPath string, downloadInfo *snap.DownloadInfo) error {
+	deltaFormats := s.supportedDeltaFormats()
+	if len(deltaFormats) == 0 {
+		return nil
+	}
+	cachedPath := s.cacher.GetPath(downloadInfo.Sha3_384)
+	if cachedPath == "" {
+		return fmt.Errorf("snap not in cache")
+	}
+	fromRev, err := deltaSourceRevision(name, targetPath)
+	if err != nil {
+		return err
+	}
+	sourcePath := filepath.Join(dirs.SnapBlobDir, fmt.Sprintf("%s_%s.snap", name, fromRev))
+	sourceDigest, _, err := osutil.FileDigest(sourcePath, crypto.SHA3_384)
+	if err != nil {
+		return err
+	}
+	sourceSha3 := fmt.Sprintf("%x", sourceDigest)
+	if sourceSha3 == downloadInfo.Sha3_384 {
+		return nil
+	}
+
+	// the formats are ordered by preference, which is also the format
+	// peers ask for
+	format := deltaFormats[0]
+	if err := s.generateDelta(ctx, format, sourcePath, sourceSha3, cachedPath, downloadInfo.Sha3_384); err != nil {
+		return err
+	}
+	logger.Debugf("Generated %s delta for %s from revision %s.", format, name, fromRev)
+	return nil
+}
+
+// generateDelta generates a delta in the given format between the given
+// snaps, to be served to peers, unless it was generated already.
+func (s *Store) generateDelta(ctx context.Context, format, sourcePath, sourceSha3, targetPath, targetSha3 string) error {
+	// generating deltas is expensive, so only do one at a time
+	s.deltaGenMu.Lock()
+	defer s.deltaGenMu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	deltaPath := generatedDeltaPath(format, sourceSha3, targetSha3)
+	if osutil.FileExists(deltaPath) {
+		return nil
+	}
+	if err := os.MkdirAll(generatedDeltasDir(), 0700); err != nil {
+		return err
+	}
+	partialPath := deltaPath + ".partial"
+	if err := squashfsGenerateDelta(ctx, sourcePath, targetPath, partialPath, format); err != nil {
+		os.Remove(partialPath)
+		return err
+	}
+	if err := os.Rename(partialPath, deltaPath); err != nil {
+		os.Remove(partialPath)
+		return err
+	}
+
+	pruneGeneratedDeltas()
+	return nil
+}
+
+// pruneGeneratedDeltas removes the least recently used generated deltas
+// beyond maxGeneratedDeltas.
+func pruneGeneratedDeltas() {
+	entries, err := os.ReadDir(generatedDeltasDir())
+	if err != nil {
+		return
+	}
+	var infos []os.FileInfo
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".partial") {
+			continue
+		}
+		if fi, err := e.Info(); err == nil {
+			infos = append(infos, fi)
+		}
+	}
+	if len(infos) <= maxGeneratedDeltas {
+		return
+	}
+	sort.Sort(entriesByMtime(infos))
+	for _, fi := range infos[:len(infos)-maxGeneratedDeltas] {
+		if err := os.Remove(filepath.Join(generatedDeltasDir(), fi.Name())); err != nil {
+			logger.Noticef("cannot remove generated delta: %v", err)
+		}
+	}
+}
+
+// deltaSourceRevision returns the latest installed revision of the given snap
+// which is not a local revision, to be used as the source of a delta. The snap
+// at excludePath, if any, is not considered.
+func deltaSourceRevision(name, excludePath string) (snap.Revision, error) {
+	matches, err := filepath.Glob(filepath.Join(dirs.SnapBlobDir, name+"_*.snap"))
+	if err != nil {
+		return snap.Revision{}, err
+	}
+	if excludePath != "" {
+		excludePath = filepath.Clean(excludePath)
+	}
+	var latest snap.Revision
+	for _, m := range matches {
+		if m == excludePath {
+			continue
+		}
+		revStr := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), name+"_"), ".snap")
+		rev, err := snap.ParseRevision(revStr)
+		if err != nil || rev.Local() {
+			continue
+		}
+		if rev.N > latest.N {
+			latest = rev
+		}
+	}
+	if latest.Unset() {
+		return snap.Revision{}, errNoDeltaSource
+	}
+	return latest, nil
+}
+
+// downloadDeltaFromCachePeer asks the cache peer for a delta between the
+// latest installed revision of the snap and the snap addressed by download
+// info, and applies it to produce the snap at targetPath. The result is
+// verified against the sha3-384 provided by the store. The download is subject
+// to the same rate limit as downloads from the store.
+func (s *Store) downloadDeltaFromCachePeer(ctx context.Context, name string, peer *url.URL, targetPath string, downloadInfo *snap.DownloadInfo, pbar progress.Meter, dlOpts *DownloadOptions) (err error) {
+	deltaFormats := s.supportedDeltaFormats()
+	if len(deltaFormats) == 0 {
+		return fmt.Errorf("no supported delta formats")
+	}
+	fromRev, err := deltaSourceRevision(name, targetPath)
+	if err != nil {
+		return err
+	}
+	sourcePath := filepath.Join(dirs.SnapBlobDir, fmt.Sprintf("%s_%s.snap", name, fromRev))
+	sourceDigest, _, err := osutil.FileDigest(sourcePath, crypto.SHA3_384)
+	if err != nil {
+		return err
+	}
+	sourceSha3 := fmt.Sprintf("%x", sourceDigest)
+	if sourceSha3 == downloadInfo.Sha3_384 {
+		return fmt.Errorf("revision %s is already the target", fromRev)
+	}
+
+	// the formats are ordered by preference
+	format := deltaFormats[0]
+	deltaURL := *peer
+	deltaURL.Path = strings.TrimSuffix(peer.Path, "/") + cachePeerDeltasPathPrefix + format + "/" + sourceSha3 + "/" + downloadInfo.Sha3_384
+
+	req, err := http.NewRequestWithContext(ctx, "GET", deltaURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.newHTTPClient(nil).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case 200: // OK
+	case 404: // Not Found
+		return fmt.Errorf("delta not available")
+	default:
+		return &DownloadError{Code: resp.StatusCode, URL: resp.Request.URL}
+	}
+
+	deltaPath := fmt.Sprintf("%s.%s-%s-to-target.peer.partial", targetPath, format, fromRev)
+	w, err := os.OpenFile(deltaPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := w.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		os.Remove(deltaPath)
+	}()
+
+	if pbar == nil {
+		pbar = progress.Null
+	}
+	pbar.Start(fmt.Sprintf(i18n.G("%s (delta)"), name), float64(resp.ContentLength))
+	n, err := io.Copy(io.MultiWriter(w, pbar), rateLimited(resp.Body, dlOpts))
+	pbar.Finished()
+	if err != nil {
+		return err
+	}
+
+	deltaInfo := &snap.DeltaInfo{
+		FromRevision: fromRev.N,
+		Format:       format,
+		Size:         n,
+	}
+	if err := applyDelta(ctx, s, name, deltaPath, deltaInfo, targetPath, downloadInfo.Sha3_384); err != nil {
+		return err
+	}
+	logger.Debugf("Successfully applied delta for %q from cache peer, saving %d bytes.", name, downloadInfo.Size-n)
+	return nil
+}
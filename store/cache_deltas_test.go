@@ -0,0 +1,345 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store_test
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/squashfs"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type cacheDeltasSuite struct {
+	baseStoreSuite
+
+	generated []string
+}
+
+var _ = Suite(&cacheDeltasSuite{})
+
+func (s *cacheDeltasSuite) SetUpTest(c *C) {
+	s.baseStoreSuite.SetUpTest(c)
+
+	s.generated = nil
+	s.AddCleanup(store.MockSupportedDeltaFormats(func(squashfs.DeltaFormatOpts) []string {
+		return []string{"snap-1-1-xdelta3", "xdelta3"}
+	}))
+	s.AddCleanup(store.MockSquashfsGenerateDelta(func(ctx context.Context, sourceSnap, targetSnap, delta string, deltaFormat string) error {
+		source, err := os.ReadFile(sourceSnap)
+		c.Assert(err, IsNil)
+		target, err := os.ReadFile(targetSnap)
+		c.Assert(err, IsNil)
+		s.generated = append(s.generated, deltaFormat)
+		return os.WriteFile(delta, []byte(fmt.Sprintf("%s:%s->%s", deltaFormat, source, target)), 0600)
+	}))
+}
+
+func sha3_384Of(content string) string {
+	h := crypto.SHA3_384.New()
+	io.WriteString(h, content)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// mockCachePeerWithBlobs serves a downloads cache with the given blobs and
+// returns its URL along with the store serving it.
+func (s *cacheDeltasSuite) mockCachePeerWithBlobs(c *C, blobs ...string) (*url.URL, *store.Store) {
+	peerStore := store.New(nil, nil)
+	s.AddCleanup(peerStore.MockCacher(store.NewCacheManager(c.MkDir(), store.CachePolicy{MaxItems: 10})))
+	for _, blob := range blobs {
+		blobPath := filepath.Join(c.MkDir(), "blob")
+		c.Assert(os.WriteFile(blobPath, []byte(blob), 0600), IsNil)
+		c.Assert(store.CacherPut(peerStore, sha3_384Of(blob), blobPath), IsNil)
+	}
+
+	peerServer := httptest.NewServer(peerStore.CacheHandler())
+	s.AddCleanup(peerServer.Close)
+	s.AddCleanup(peerStore.StopServingCache)
+	peerURL, err := url.Parse(peerServer.URL)
+	c.Assert(err, IsNil)
+	return peerURL, peerStore
+}
+
+func (s *cacheDeltasSuite) get(c *C, u string) (int, string) {
+	resp, err := http.Get(u)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	return resp.StatusCode, string(body)
+}
+
+// mockGeneratedDelta places a delta in the given format between the given
+// snaps among the generated deltas.
+func mockGeneratedDelta(c *C, format, source, target string) {
+	deltasDir := filepath.Join(dirs.SnapCacheDir, "deltas")
+	c.Assert(os.MkdirAll(deltasDir, 0700), IsNil)
+	deltaPath := filepath.Join(deltasDir, fmt.Sprintf("%s-to-%s.%s", sha3_384Of(source), sha3_384Of(target), format))
+	c.Assert(os.WriteFile(deltaPath, []byte(fmt.Sprintf("%s:%s->%s", format, source, target)), 0600), IsNil)
+}
+
+// downloadFromCache places the given blob in the downloads cache of the given
+// store and downloads it as the given revision of snap foo, after which any
+// delta generated in the background is done.
+func (s *cacheDeltasSuite) downloadFromCache(c *C, sto *store.Store, blob string, rev int) {
+	blobPath := filepath.Join(c.MkDir(), "blob")
+	c.Assert(os.WriteFile(blobPath, []byte(blob), 0600), IsNil)
+	c.Assert(store.CacherPut(sto, sha3_384Of(blob), blobPath), IsNil)
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = "http://store.invalid"
+	snap.Sha3_384 = sha3_384Of(blob)
+	snap.Size = int64(len(blob))
+
+	c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0755), IsNil)
+	targetFn := filepath.Join(dirs.SnapBlobDir, fmt.Sprintf("foo_%d.snap", rev))
+	err := sto.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	sto.WaitGeneratedDeltas()
+}
+
+func (s *cacheDeltasSuite) TestCacheHandlerDelta(c *C) {
+	peerURL, peerStore := s.mockCachePeerWithBlobs(c, "old", "new")
+	mockGeneratedDelta(c, "xdelta3", "old", "new")
+	deltaURL := fmt.Sprintf("%s/v2/cache/deltas/xdelta3/%s/%s", peerURL, sha3_384Of("old"), sha3_384Of("new"))
+
+	code, body := s.get(c, deltaURL)
+	c.Check(code, Equals, 200)
+	c.Check(body, Equals, "xdelta3:old->new")
+	peerStore.WaitGeneratedDeltas()
+	c.Check(s.generated, HasLen, 0)
+}
+
+func (s *cacheDeltasSuite) TestCacheHandlerDeltaGeneratedAfterRequest(c *C) {
+	peerURL, peerStore := s.mockCachePeerWithBlobs(c, "old", "new")
+	deltaURL := fmt.Sprintf("%s/v2/cache/deltas/snap-1-1-xdelta3/%s/%s", peerURL, sha3_384Of("old"), sha3_384Of("new"))
+
+	// deltas are never generated while serving a request
+	code, _ := s.get(c, deltaURL)
+	c.Check(code, Equals, 404)
+
+	// but the delta from the snap the peer has is generated in the
+	// background for later requests
+	peerStore.WaitGeneratedDeltas()
+	c.Check(s.generated, DeepEquals, []string{"snap-1-1-xdelta3"})
+	code, body := s.get(c, deltaURL)
+	c.Check(code, Equals, 200)
+	c.Check(body, Equals, "snap-1-1-xdelta3:old->new")
+	peerStore.WaitGeneratedDeltas()
+	c.Check(s.generated, HasLen, 1)
+}
+
+func (s *cacheDeltasSuite) TestCacheHandlerStopServingCancelsDeltaGeneration(c *C) {
+	started := make(chan struct{})
+	restore := store.MockSquashfsGenerateDelta(func(ctx context.Context, sourceSnap, targetSnap, delta string, deltaFormat string) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	defer restore()
+
+	peerURL, peerStore := s.mockCachePeerWithBlobs(c, "old", "new")
+	deltaURL := fmt.Sprintf("%s/v2/cache/deltas/snap-1-1-xdelta3/%s/%s", peerURL, sha3_384Of("old"), sha3_384Of("new"))
+	code, _ := s.get(c, deltaURL)
+	c.Check(code, Equals, 404)
+	<-started
+
+	// stopping waits for the cancelled generation
+	peerStore.StopServingCache()
+	matches, err := filepath.Glob(filepath.Join(dirs.SnapCacheDir, "deltas", "*"))
+	c.Assert(err, IsNil)
+	c.Check(matches, HasLen, 0)
+	c.Check(s.logbuf.String(), Not(testutil.Contains), "Cannot generate delta")
+
+	// and no more deltas are generated
+	code, _ = s.get(c, deltaURL)
+	c.Check(code, Equals, 404)
+	peerStore.WaitGeneratedDeltas()
+}
+
+func (s *cacheDeltasSuite) TestCacheHandlerDeltaNotFound(c *C) {
+	peerURL, peerStore := s.mockCachePeerWithBlobs(c, "old", "new")
+	mockGeneratedDelta(c, "bsdiff", "old", "new")
+	mockGeneratedDelta(c, "xdelta3", "new", "new")
+
+	for _, path := range []string{
+		// source not in cache
+		fmt.Sprintf("/v2/cache/deltas/xdelta3/%s/%s", sha3_384Of("other"), sha3_384Of("new")),
+		// target not in cache
+		fmt.Sprintf("/v2/cache/deltas/xdelta3/%s/%s", sha3_384Of("old"), sha3_384Of("other")),
+		// unsupported format
+		fmt.Sprintf("/v2/cache/deltas/bsdiff/%s/%s", sha3_384Of("old"), sha3_384Of("new")),
+		// same source and target
+		fmt.Sprintf("/v2/cache/deltas/xdelta3/%s/%s", sha3_384Of("new"), sha3_384Of("new")),
+		// malformed
+		fmt.Sprintf("/v2/cache/deltas/xdelta3/%s", sha3_384Of("new")),
+		fmt.Sprintf("/v2/cache/deltas/xdelta3/%s/%s", sha3_384Of("old")[:10], sha3_384Of("new")),
+	} {
+		code, _ := s.get(c, peerURL.String()+path)
+		c.Check(code, Equals, 404, Commentf("path: %s", path))
+	}
+	peerStore.WaitGeneratedDeltas()
+	c.Check(s.generated, HasLen, 0)
+}
+
+func (s *cacheDeltasSuite) TestDownloadGeneratesCacheDelta(c *C) {
+	sto := store.New(nil, nil)
+	s.AddCleanup(sto.MockCacher(store.NewCacheManager(c.MkDir(), store.CachePolicy{MaxItems: 10})))
+	peerServer := httptest.NewServer(sto.CacheHandler())
+	defer peerServer.Close()
+	defer sto.StopServingCache()
+
+	// nothing to generate a delta from
+	s.downloadFromCache(c, sto, "old", 3)
+	c.Check(s.generated, HasLen, 0)
+
+	// a delta in the preferred format is generated from the previously
+	// installed revision
+	s.downloadFromCache(c, sto, "new", 4)
+	c.Check(s.generated, DeepEquals, []string{"snap-1-1-xdelta3"})
+	code, body := s.get(c, fmt.Sprintf("%s/v2/cache/deltas/snap-1-1-xdelta3/%s/%s", peerServer.URL, sha3_384Of("old"), sha3_384Of("new")))
+	c.Check(code, Equals, 200)
+	c.Check(body, Equals, "snap-1-1-xdelta3:old->new")
+
+	// the delta is only generated once
+	s.downloadFromCache(c, sto, "new", 4)
+	c.Check(s.generated, HasLen, 1)
+
+	matches, err := filepath.Glob(filepath.Join(dirs.SnapCacheDir, "deltas", "*"))
+	c.Assert(err, IsNil)
+	c.Check(matches, HasLen, 1)
+}
+
+func (s *cacheDeltasSuite) TestDownloadGeneratesNoCacheDeltaWhenNotServed(c *C) {
+	sto := store.New(nil, nil)
+	s.AddCleanup(sto.MockCacher(store.NewCacheManager(c.MkDir(), store.CachePolicy{MaxItems: 10})))
+
+	s.downloadFromCache(c, sto, "old", 3)
+	s.downloadFromCache(c, sto, "new", 4)
+	c.Check(s.generated, HasLen, 0)
+	c.Check(filepath.Join(dirs.SnapCacheDir, "deltas"), testutil.FileAbsent)
+}
+
+func (s *cacheDeltasSuite) TestGeneratedCacheDeltasPruned(c *C) {
+	restore := store.MockMaxGeneratedDeltas(1)
+	defer restore()
+
+	sto := store.New(nil, nil)
+	s.AddCleanup(sto.MockCacher(store.NewCacheManager(c.MkDir(), store.CachePolicy{MaxItems: 10})))
+	sto.CacheHandler()
+	defer sto.StopServingCache()
+
+	s.downloadFromCache(c, sto, "old", 3)
+	s.downloadFromCache(c, sto, "new", 4)
+	s.downloadFromCache(c, sto, "newer", 5)
+	c.Check(s.generated, HasLen, 2)
+
+	entries, err := os.ReadDir(filepath.Join(dirs.SnapCacheDir, "deltas"))
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+	c.Check(entries[0].Name(), Equals, fmt.Sprintf("%s-to-%s.snap-1-1-xdelta3", sha3_384Of("new"), sha3_384Of("newer")))
+}
+
+func (s *cacheDeltasSuite) TestDownloadDeltaFromCachePeer(c *C) {
+	peerURL, _ := s.mockCachePeerWithBlobs(c, "old", "new")
+	mockGeneratedDelta(c, "snap-1-1-xdelta3", "old", "new")
+
+	// revision 3 is installed, along with a local revision which is ignored
+	c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapBlobDir, "foo_3.snap"), []byte("old"), 0600), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapBlobDir, "foo_x1.snap"), []byte("local"), 0600), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapBlobDir, "foo_other_7.snap"), []byte("instance"), 0600), IsNil)
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Errorf("unexpected request to the store")
+	}))
+	defer mockServer.Close()
+
+	applied := 0
+	restore := store.MockApplyDelta(func(_ context.Context, s *store.Store, name string, deltaPath string, deltaInfo *snap.DeltaInfo, targetPath string, targetSha3_384 string) error {
+		applied++
+		c.Check(name, Equals, "foo")
+		c.Check(deltaPath, testutil.FileEquals, "snap-1-1-xdelta3:old->new")
+		c.Check(deltaInfo.FromRevision, Equals, 3)
+		c.Check(deltaInfo.Format, Equals, "snap-1-1-xdelta3")
+		c.Check(targetSha3_384, Equals, sha3_384Of("new"))
+		return os.WriteFile(targetPath, []byte("new"), 0600)
+	})
+	defer restore()
+
+	sto := store.New(nil, &testDauthContext{c: c, cachePeer: peerURL})
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = sha3_384Of("new")
+	snap.Size = 3
+
+	targetFn := filepath.Join(c.MkDir(), "foo_4.snap")
+	err := sto.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(applied, Equals, 1)
+	c.Check(targetFn, testutil.FileEquals, "new")
+
+	matches, err := filepath.Glob(targetFn + ".*")
+	c.Assert(err, IsNil)
+	c.Check(matches, HasLen, 0)
+}
+
+func (s *cacheDeltasSuite) TestDownloadDeltaFromCachePeerFallsBackToFullSnap(c *C) {
+	// the peer does not have the installed revision
+	peerURL, _ := s.mockCachePeerWithBlobs(c, "new")
+
+	c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapBlobDir, "foo_3.snap"), []byte("old"), 0600), IsNil)
+
+	restore := store.MockApplyDelta(func(_ context.Context, s *store.Store, name string, deltaPath string, deltaInfo *snap.DeltaInfo, targetPath string, targetSha3_384 string) error {
+		c.Errorf("unexpected delta application")
+		return nil
+	})
+	defer restore()
+
+	sto := store.New(nil, &testDauthContext{c: c, cachePeer: peerURL})
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = "http://store.invalid"
+	snap.Sha3_384 = sha3_384Of("new")
+	snap.Size = 3
+
+	targetFn := filepath.Join(c.MkDir(), "foo_4.snap")
+	err := sto.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, "new")
+	c.Check(s.logbuf.String(), Matches, `(?s).*Cannot download delta for foo from cache peer .*: delta not available.*`)
+}
@@ -459,6 +459,7 @@ func (s *detailsV2Suite) TestInfoFromStoreSnap(c *C) {
 		"Channels", // handled at a different level (see TestInfo)
 		"Tracks",   // handled at a different level (see TestInfo)
 		"Layout",
+		"HealthCheckInterval", // only set from snap.yaml
 		"SideInfo.Channel",
 		"LegacyWebsite",
 	}
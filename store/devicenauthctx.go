@@ -51,6 +51,11 @@ type DeviceAndAuthContext interface {
 
 	// WithSnapStoreDelta returns whether snap store delta format experimental flag is set or not.
 	WithSnapStoreDelta() bool
+
+	// CachePeer returns the URL of a peer serving a snap downloads cache on
+	// the local network, which is queried for snaps before the store. Returns
+	// nil if no cache peer is configured.
+	CachePeer() (*url.URL, error)
 }
 
 // DeviceSessionRequestParams gathers the assertions and information to be sent to request a device session.
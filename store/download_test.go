@@ -334,6 +334,8 @@ func (s *downloadSuite) TestActualDownloadResume(c *C) {
 	n := 0
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		n++
+		c.Check(r.Header.Get("Range"), Equals, "bytes=5-")
+		w.Header().Set("Content-Range", "bytes 5-8/9")
 		w.WriteHeader(206)
 		io.WriteString(w, "data")
 	}))
@@ -352,6 +354,39 @@ func (s *downloadSuite) TestActualDownloadResume(c *C) {
 	c.Check(n, Equals, 1)
 }
 
+func (s *downloadSuite) TestActualDownloadResumeUnexpectedRange(c *C) {
+	n := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		switch n {
+		case 1:
+			// the server sends another range than the one requested
+			c.Check(r.Header.Get("Range"), Equals, "bytes=5-")
+			w.Header().Set("Content-Range", "bytes 4-8/9")
+			w.WriteHeader(206)
+			io.WriteString(w, " data")
+		case 2:
+			// the download is restarted from the start
+			c.Check(r.Header["Range"], HasLen, 0)
+			io.WriteString(w, "some data")
+		default:
+			c.Fatal("only two requests expected")
+		}
+	}))
+	c.Assert(mockServer, NotNil)
+	defer mockServer.Close()
+
+	theStore := store.New(&store.Config{}, nil)
+	buf := NewSillyBufferString("some ")
+	h := crypto.SHA3_384.New()
+	h.Write([]byte("some data"))
+	sha3 := fmt.Sprintf("%x", h.Sum(nil))
+	err := store.Download(context.TODO(), "foo", sha3, mockServer.URL, nil, theStore, buf, int64(len("some ")), nil, nil)
+	c.Check(err, IsNil)
+	c.Check(buf.String(), Equals, "some data")
+	c.Check(n, Equals, 2)
+}
+
 func (s *downloadSuite) TestActualDownloadServerNoResumeHandeled(c *C) {
 	n := 0
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -187,6 +187,10 @@ func MockSquashfsApplyDelta(f func(ctx context.Context, sourceSnap, deltaFile, t
 	}
 }
 
+func CacherPut(sto *Store, cacheKey, sourcePath string) error {
+	return sto.cacher.Put(cacheKey, sourcePath)
+}
+
 func (sto *Store) MockCacher(obs downloadCache) (restore func()) {
 	oldCacher := sto.cacher
 	sto.cacher = obs
@@ -276,3 +280,28 @@ type (
 )
 
 var ReportFetchAssertionsError = reportFetchAssertionsError
+
+func MockChunkedDownloadParams(minSize, chunkSize int64, workers int) (restore func()) {
+	restoreMinSize := testutil.Mock(&chunkedDownloadMinSize, minSize)
+	restoreChunkSize := testutil.Mock(&chunkedDownloadChunkSize, chunkSize)
+	restoreWorkers := testutil.Mock(&chunkedDownloadWorkers, workers)
+	return func() {
+		restoreWorkers()
+		restoreChunkSize()
+		restoreMinSize()
+	}
+}
+
+func MockSquashfsGenerateDelta(f func(ctx context.Context, sourceSnap, targetSnap, delta string, deltaFormat string) error) (restore func()) {
+	return testutil.Mock(&squashfsGenerateDelta, f)
+}
+
+func MockMaxGeneratedDeltas(n int) (restore func()) {
+	return testutil.Mock(&maxGeneratedDeltas, n)
+}
+
+// WaitGeneratedDeltas waits for the deltas being generated in the background
+// to be done.
+func (sto *Store) WaitGeneratedDeltas() {
+	sto.deltaGenWG.Wait()
+}
@@ -166,6 +166,19 @@ type Store struct {
 	suggestedCurrency string
 
 	cacher downloadCache
+	// deltaGenMu serializes the generation of deltas for cache peers
+	deltaGenMu sync.Mutex
+	// deltaGenWG tracks deltas being generated in the background
+	deltaGenWG sync.WaitGroup
+	// cacheServeMu protects the state of serving the downloads cache below
+	cacheServeMu sync.Mutex
+	// deltaGenCtx is set while the downloads cache is served to peers, it
+	// is cancelled by StopServingCache
+	deltaGenCtx    context.Context
+	deltaGenCancel context.CancelFunc
+	// deltaGenRequested holds the deltas requested by peers which are
+	// being generated
+	deltaGenRequested map[string]bool
 
 	proxy              func(*http.Request) (*url.URL, error)
 	proxyConnectHeader http.Header
@@ -133,7 +133,13 @@ func isDownloadLikelyNotCorrupted(targetPath string, downloadInfo *snap.Download
 // filename.
 // The file is saved in temporary storage, and should be removed
 // after use to prevent the disk from running out of space.
-func (s *Store) Download(ctx context.Context, name string, targetPath string, downloadInfo *snap.DownloadInfo, pbar progress.Meter, user *auth.UserState, dlOpts *DownloadOptions) error {
+func (s *Store) Download(ctx context.Context, name string, targetPath string, downloadInfo *snap.DownloadInfo, pbar progress.Meter, user *auth.UserState, dlOpts *DownloadOptions) (err error) {
+	defer func() {
+		if err == nil && downloadInfo.Sha3_384 != "" {
+			s.generateCacheDeltaInBackground(name, targetPath, downloadInfo)
+		}
+	}()
+
 	// most other store network operations use s.endpointURL, which returns an
 	// error if the store is offline. this doesn't, so we need to explicitly
 	// check.
@@ -174,6 +180,20 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 		logger.Debugf("Cache entry for SHA3_384 …%.5s has unexpected size, re-downloading.", downloadInfo.Sha3_384)
 	}
 
+	if peer := s.cachePeer(); peer != nil && downloadInfo.Sha3_384 != "" {
+		err := s.downloadDeltaFromCachePeer(ctx, name, peer, targetPath, downloadInfo, pbar, dlOpts)
+		if err != nil {
+			logger.Debugf("Cannot download delta for %s from cache peer %s: %v", name, peer.Redacted(), err)
+			err = s.downloadFromCachePeer(ctx, name, peer, targetPath, downloadInfo, pbar, dlOpts)
+		}
+		if err == nil {
+			logger.Debugf("Downloaded %s from cache peer %s.", name, peer.Redacted())
+			return s.cacher.Put(downloadInfo.Sha3_384, targetPath)
+		}
+		// We revert to downloading from the store if there is any error.
+		logger.Noticef("Cannot download %s from cache peer %s: %v", name, peer.Redacted(), err)
+	}
+
 	if len(s.supportedDeltaFormats()) > 0 {
 		logger.Debugf("Available deltas returned by store: %v", downloadInfo.Deltas)
 		if len(downloadInfo.Deltas) > 0 {
@@ -201,6 +221,33 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 	defer d.Close()
 
 	partialPath := targetPath + ".partial"
+	if shouldDownloadChunked(partialPath, downloadInfo) {
+		err := s.downloadChunked(ctx, name, partialPath, downloadInfo, pbar, user, dlOpts)
+		if err == nil {
+			if err := os.Rename(partialPath, targetPath); err != nil {
+				return err
+			}
+			if err := d.Sync(); err != nil {
+				return err
+			}
+			return s.cacher.Put(downloadInfo.Sha3_384, targetPath)
+		}
+		if _, ok := err.(HashError); !ok && !errors.Is(err, errRangeNotSupported) {
+			logger.Debugf("chunked download of %q failed: %v", downloadInfo.DownloadURL, err)
+			if dlOpts == nil || !dlOpts.LeavePartialOnError {
+				removeChunkedPartial(partialPath)
+				if serr := d.Sync(); serr != nil {
+					// TODO:GOVERSION: use errors.Join
+					err = strutil.JoinErrors(err, serr)
+				}
+			}
+			return err
+		}
+		// fall back to downloading in a single stream from scratch
+		logger.Noticef("Cannot download %s in chunks: %v; downloading it in a single stream.", name, err)
+		removeChunkedPartial(partialPath)
+	}
+
 	w, err := os.OpenFile(partialPath, os.O_RDWR|os.O_CREATE, 0600)
 	if err != nil {
 		return err
@@ -502,6 +549,45 @@ func (w *TransferSpeedMonitoringWriter) Err() error {
 
 var ratelimitReader = ratelimit.Reader
 
+// rateLimited returns a reader which limits reading from r to the rate given
+// by the download options, if any.
+func rateLimited(r io.Reader, dlOpts *DownloadOptions) io.Reader {
+	if dlOpts == nil || dlOpts.RateLimit <= 0 {
+		return r
+	}
+	bucket := ratelimit.NewBucketWithRate(float64(dlOpts.RateLimit), 2*dlOpts.RateLimit)
+	return ratelimitReader(r, bucket)
+}
+
+// newDownloadHTTPClient returns a client for downloading snaps which does not
+// send user or device authorization headers when following redirects.
+func (s *Store) newDownloadHTTPClient(apiLevel apiLevel) *http.Client {
+	cli := s.newHTTPClient(nil) // XXX: there's no timeout defined for this client, and the context is context.TODO(), so it won't be cancelled
+	oldCheckRedirect := cli.CheckRedirect
+	if oldCheckRedirect == nil {
+		panic("internal error: the httputil.NewHTTPClient-produced http.Client must have CheckRedirect defined")
+	}
+	cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		// remove user/device auth headers from being sent in "CDN" redirects
+		// see also: https://bugs.launchpad.net/snapd/+bug/2027993
+		// TODO: do we need to remove other identifying headers?
+		dropAuthorization(req, &AuthorizeOptions{deviceAuth: true, apiLevel: apiLevel})
+		return oldCheckRedirect(req, via)
+	}
+	return cli
+}
+
+// parseContentRange returns the offsets of the first and last bytes sent in
+// a partial content response, as given by its Content-Range header.
+func parseContentRange(resp *http.Response) (first, last int64, err error) {
+	contentRange := resp.Header.Get("Content-Range")
+	var size string
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%s", &first, &last, &size); err != nil || first < 0 || last < first {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+	return first, last, nil
+}
+
 var download = downloadImpl
 
 // download writes an http.Request showing a progress.Meter
@@ -551,18 +637,7 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 			return fmt.Errorf("the download has been cancelled: %s", downloadCtx.Err())
 		}
 		var resp *http.Response
-		cli := s.newHTTPClient(nil) // XXX: there's no timeout defined for this client, and the context is context.TODO(), so it won't be cancelled
-		oldCheckRedirect := cli.CheckRedirect
-		if oldCheckRedirect == nil {
-			panic("internal error: the httputil.NewHTTPClient-produced http.Client must have CheckRedirect defined")
-		}
-		cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			// remove user/device auth headers from being sent in "CDN" redirects
-			// see also: https://bugs.launchpad.net/snapd/+bug/2027993
-			// TODO: do we need to remove other identifying headers?
-			dropAuthorization(req, &AuthorizeOptions{deviceAuth: true, apiLevel: reqOptions.APILevel})
-			return oldCheckRedirect(req, via)
-		}
+		cli := s.newDownloadHTTPClient(reqOptions.APILevel)
 		resp, finalErr = s.doRequest(downloadCtx, cli, reqOptions, user)
 		if cancelled(downloadCtx) {
 			return fmt.Errorf("the download has been cancelled: %s", downloadCtx.Err())
@@ -573,6 +648,20 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 			}
 			break
 		}
+		if resume > 0 && resp.StatusCode == 206 {
+			if first, _, err := parseContentRange(resp); err != nil || first != resume {
+				// the server did not send what was asked for, start over
+				// without a range
+				resp.Body.Close()
+				finalErr = fmt.Errorf("cannot resume download at %d: unexpected range %q", resume, resp.Header.Get("Content-Range"))
+				logger.Noticef("%v, restarting download of %s from the start", finalErr, name)
+				if _, err := w.Seek(0, io.SeekStart); err != nil {
+					return err
+				}
+				resume = 0
+				continue
+			}
+		}
 		if resume > 0 && resp.StatusCode != 206 {
 			logger.Debugf("server does not support resume")
 			if _, err := w.Seek(0, io.SeekStart); err != nil {
@@ -609,12 +698,7 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 		}
 		pbar.Start(name, dlSize)
 		mw := io.MultiWriter(w, h, pbar, tc)
-		var limiter io.Reader
-		limiter = resp.Body
-		if limit := dlOpts.RateLimit; limit > 0 {
-			bucket := ratelimit.NewBucketWithRate(float64(limit), 2*limit)
-			limiter = ratelimitReader(resp.Body, bucket)
-		}
+		limiter := rateLimited(resp.Body, dlOpts)
 
 		stopMonitorCh := tc.Monitor()
 		_, finalErr = io.Copy(mw, limiter)
@@ -952,6 +1036,151 @@ func (s *Store) downloadAndApplyDelta(ctx context.Context, name, targetPath stri
 	return nil
 }
 
+// cachePeerPathPrefix is the path under which snaps in the downloads cache are
+// served to peers, addressed by their sha3-384 digest.
+const cachePeerPathPrefix = "/v2/cache/snaps/"
+
+// cachePeer returns the URL of the configured downloads cache peer, or nil if
+// there is none.
+func (s *Store) cachePeer() *url.URL {
+	if s.dauthCtx == nil {
+		return nil
+	}
+	peer, err := s.dauthCtx.CachePeer()
+	if err != nil {
+		logger.Noticef("cannot get downloads cache peer: %v", err)
+		return nil
+	}
+	return peer
+}
+
+func isSha3_384Digest(s string) bool {
+	if len(s) != 2*crypto.SHA3_384.Size() {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// downloadFromCachePeer fetches the snap blob addressed by download info from
+// the downloads cache of the given peer into targetPath. The blob is only
+// accepted if its size and sha3-384 match those provided by the store, which
+// are also what the snap assertions are checked against, so the peer does not
+// need to be trusted. The download is subject to the same rate limit as
+// downloads from the store.
+func (s *Store) downloadFromCachePeer(ctx context.Context, name string, peer *url.URL, targetPath string, downloadInfo *snap.DownloadInfo, pbar progress.Meter, dlOpts *DownloadOptions) (err error) {
+	if pbar == nil {
+		pbar = progress.Null
+	}
+
+	blobURL := *peer
+	blobURL.Path = strings.TrimSuffix(peer.Path, "/") + cachePeerPathPrefix + downloadInfo.Sha3_384
+
+	tc, downloadCtx := NewTransferSpeedMonitoringWriterAndContext(ctx, downloadSpeedMeasureWindow, downloadSpeedMin)
+	req, err := http.NewRequestWithContext(downloadCtx, "GET", blobURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.newHTTPClient(nil).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case 200: // OK
+	case 404: // Not Found
+		return fmt.Errorf("snap not in cache")
+	default:
+		return &DownloadError{Code: resp.StatusCode, URL: resp.Request.URL}
+	}
+	if downloadInfo.Size > 0 && resp.ContentLength >= 0 && resp.ContentLength != downloadInfo.Size {
+		return fmt.Errorf("unexpected size %d, expected %d", resp.ContentLength, downloadInfo.Size)
+	}
+
+	partialPath := targetPath + ".peer.partial"
+	w, err := os.OpenFile(partialPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := w.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(partialPath)
+		}
+	}()
+
+	h := crypto.SHA3_384.New()
+	pbar.Start(name, float64(downloadInfo.Size))
+	stopMonitorCh := tc.Monitor()
+	n, err := io.Copy(io.MultiWriter(w, h, pbar, tc), rateLimited(resp.Body, dlOpts))
+	close(stopMonitorCh)
+	pbar.Finished()
+	if err := tc.Err(); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if downloadInfo.Size > 0 && n != downloadInfo.Size {
+		return fmt.Errorf("unexpected size %d, expected %d", n, downloadInfo.Size)
+	}
+	actualSha3 := fmt.Sprintf("%x", h.Sum(nil))
+	if actualSha3 != downloadInfo.Sha3_384 {
+		return HashError{name, actualSha3, downloadInfo.Sha3_384}
+	}
+
+	if err := w.Sync(); err != nil {
+		return err
+	}
+	return os.Rename(partialPath, targetPath)
+}
+
+// CacheHandler returns an HTTP handler serving the snap downloads cache
+// read-only to peers on the local network, with blobs addressed by their
+// sha3-384 digest. Peers verify the digest of everything they fetch, so
+// requests are not authenticated. Nothing is served if the cache policy
+// disables caching.
+//
+// Once the cache is served, deltas from the previously installed revision
+// are generated in the background for downloaded snaps, as well as deltas
+// from the snaps peers report having, to be served to peers along with the
+// snaps. StopServingCache stops this once the cache is no longer served.
+func (s *Store) CacheHandler() http.Handler {
+	s.startServingCache()
+	return http.HandlerFunc(s.serveCache)
+}
+
+func (s *Store) serveCache(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, cachePeerDeltasPathPrefix) {
+		s.serveCacheDelta(w, r)
+		return
+	}
+	digest := strings.TrimPrefix(r.URL.Path, cachePeerPathPrefix)
+	if digest == r.URL.Path || !isSha3_384Digest(digest) {
+		http.NotFound(w, r)
+		return
+	}
+	f, _, err := s.cacher.Open(digest)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	http.ServeContent(w, r, digest, time.Time{}, f)
+}
+
 // CacheDownloads returns the configured cache policy.
 func (s *Store) CachePolicy() CachePolicy {
 	return s.cfg.CachePolicy
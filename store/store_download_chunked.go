@@ -0,0 +1,460 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/juju/ratelimit"
+	"gopkg.in/retry.v1"
+
+	"github.com/snapcore/snapd/httputil"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+)
+
+var (
+	// chunkedDownloadMinSize is the smallest snap size for which the snap is
+	// downloaded in parallel chunks rather than in a single stream.
+	chunkedDownloadMinSize int64 = 64 * 1024 * 1024
+	// chunkedDownloadChunkSize is the size of each chunk of a chunked
+	// download.
+	chunkedDownloadChunkSize int64 = 16 * 1024 * 1024
+	// chunkedDownloadWorkers is the number of chunks which are downloaded
+	// concurrently.
+	chunkedDownloadWorkers = 4
+)
+
+// errRangeNotSupported is returned when the server ignores the range of a
+// chunk request, or answers it with another range, in which case the snap
+// must be downloaded in a single stream.
+var errRangeNotSupported = errors.New("server does not support range requests")
+
+// chunkedDownloadState records which chunks of a chunked download have been
+// completed, so that the download can be resumed after snapd restarts.
+type chunkedDownloadState struct {
+	Sha3_384  string `json:"sha3-384"`
+	Size      int64  `json:"size"`
+	ChunkSize int64  `json:"chunk-size"`
+	// Chunks holds the sha3-384 of the data of each completed chunk, or an
+	// empty string for chunks which have not been downloaded yet.
+	Chunks []string `json:"chunks"`
+}
+
+func chunkedStatePath(partialPath string) string {
+	return partialPath + ".chunks"
+}
+
+func newChunkedDownloadState(downloadInfo *snap.DownloadInfo) *chunkedDownloadState {
+	n := (downloadInfo.Size + chunkedDownloadChunkSize - 1) / chunkedDownloadChunkSize
+	return &chunkedDownloadState{
+		Sha3_384:  downloadInfo.Sha3_384,
+		Size:      downloadInfo.Size,
+		ChunkSize: chunkedDownloadChunkSize,
+		Chunks:    make([]string, n),
+	}
+}
+
+// loadChunkedDownloadState loads the state of a previous chunked download of
+// the same snap blob. If there is no such state, or it does not match the
+// given download info, a new state is returned instead.
+func loadChunkedDownloadState(path string, downloadInfo *snap.DownloadInfo) *chunkedDownloadState {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return newChunkedDownloadState(downloadInfo)
+	}
+	var state chunkedDownloadState
+	if err := json.Unmarshal(b, &state); err != nil {
+		logger.Noticef("cannot load state of chunked download from %q: %v", path, err)
+		return newChunkedDownloadState(downloadInfo)
+	}
+	if state.Sha3_384 != downloadInfo.Sha3_384 || state.Size != downloadInfo.Size || state.ChunkSize <= 0 ||
+		int64(len(state.Chunks)) != (state.Size+state.ChunkSize-1)/state.ChunkSize {
+		return newChunkedDownloadState(downloadInfo)
+	}
+	return &state
+}
+
+func (st *chunkedDownloadState) save(path string) error {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(path, b, 0600, 0)
+}
+
+// bounds returns the offset of the first byte of the given chunk and the
+// offset following its last byte.
+func (st *chunkedDownloadState) bounds(chunk int) (start, end int64) {
+	start = int64(chunk) * st.ChunkSize
+	end = start + st.ChunkSize
+	if end > st.Size {
+		end = st.Size
+	}
+	return start, end
+}
+
+// shouldDownloadChunked returns whether the snap described by the download
+// info should be downloaded in chunks. This is the case for large snaps with
+// a known size and hash, unless a partial download which was started in a
+// single stream is already present.
+func shouldDownloadChunked(partialPath string, downloadInfo *snap.DownloadInfo) bool {
+	if downloadInfo.Size < chunkedDownloadMinSize || downloadInfo.Sha3_384 == "" {
+		return false
+	}
+	if osutil.FileExists(chunkedStatePath(partialPath)) {
+		return true
+	}
+	fi, err := os.Stat(partialPath)
+	return err != nil || fi.Size() == 0
+}
+
+// removeChunkedPartial removes the partial file of a chunked download along
+// with its state.
+func removeChunkedPartial(partialPath string) {
+	os.Remove(chunkedStatePath(partialPath))
+	os.Remove(partialPath)
+}
+
+// offsetWriter writes sequentially to a file starting from the given offset.
+type offsetWriter struct {
+	f   *os.File
+	off int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.f.WriteAt(p, w.off)
+	w.off += int64(n)
+	return n, err
+}
+
+// chunkedProgress reports the combined progress of all chunks of a download.
+type chunkedProgress struct {
+	mu      sync.Mutex
+	pbar    progress.Meter
+	current int64
+}
+
+func (p *chunkedProgress) add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current += n
+	p.pbar.Set(float64(p.current))
+}
+
+// chunkProgressWriter forwards the number of bytes written to a chunk to the
+// progress of the whole download, and remembers how many bytes were written
+// so that they can be discounted if the chunk is retried.
+type chunkProgressWriter struct {
+	progress *chunkedProgress
+	written  int64
+}
+
+func (w *chunkProgressWriter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+	w.progress.add(int64(len(p)))
+	return len(p), nil
+}
+
+func (w *chunkProgressWriter) reset() {
+	w.progress.add(-w.written)
+	w.written = 0
+}
+
+func chunkSha3_384(f *os.File, start, end int64) (string, error) {
+	h := crypto.SHA3_384.New()
+	if _, err := io.Copy(h, io.NewSectionReader(f, start, end-start)); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// downloadChunked downloads the snap described by the download info into the
+// partial file, fetching chunks of the snap in parallel using range requests.
+// Completed chunks are recorded in a state file next to the partial file, so
+// that an interrupted download resumes with the chunks which are still
+// missing. Once all chunks are present, the whole file is verified against
+// the sha3-384 of the download info.
+//
+// If the server does not support range requests, errRangeNotSupported is
+// returned, and if the downloaded file does not match the expected hash,
+// a HashError is returned.
+func (s *Store) downloadChunked(ctx context.Context, name, partialPath string, downloadInfo *snap.DownloadInfo, pbar progress.Meter, user *auth.UserState, dlOpts *DownloadOptions) error {
+	if dlOpts == nil {
+		dlOpts = &DownloadOptions{}
+	}
+	if pbar == nil {
+		pbar = progress.Null
+	}
+
+	storeURL, err := url.Parse(downloadInfo.DownloadURL)
+	if err != nil {
+		return err
+	}
+	cdnHeader, err := s.cdnHeader()
+	if err != nil {
+		return err
+	}
+
+	statePath := chunkedStatePath(partialPath)
+	state := loadChunkedDownloadState(statePath, downloadInfo)
+
+	f, err := os.OpenFile(partialPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := f.Truncate(state.Size); err != nil {
+		return err
+	}
+
+	// verify the chunks which were completed before, the partial file may
+	// have been corrupted since
+	var done int64
+	var pending []int
+	for i, sum := range state.Chunks {
+		start, end := state.bounds(i)
+		if sum != "" {
+			actual, err := chunkSha3_384(f, start, end)
+			if err != nil {
+				return err
+			}
+			if actual == sum {
+				done += end - start
+				continue
+			}
+			logger.Debugf("Chunk %d of %q is corrupted, downloading it again.", i, partialPath)
+			state.Chunks[i] = ""
+		}
+		pending = append(pending, i)
+	}
+	if err := state.save(statePath); err != nil {
+		return err
+	}
+
+	if done > 0 {
+		logger.Debugf("Resuming chunked download of %q with %d of %d chunks missing.", partialPath, len(pending), len(state.Chunks))
+	} else {
+		logger.Debugf("Starting chunked download of %q in %d chunks.", partialPath, len(state.Chunks))
+	}
+
+	pbar.Start(name, float64(state.Size))
+	defer pbar.Finished()
+	prog := &chunkedProgress{pbar: pbar}
+	prog.add(done)
+
+	tc, downloadCtx := NewTransferSpeedMonitoringWriterAndContext(ctx, downloadSpeedMeasureWindow, downloadSpeedMin)
+	downloadCtx, cancel := context.WithCancel(downloadCtx)
+	defer cancel()
+
+	var bucket *ratelimit.Bucket
+	if limit := dlOpts.RateLimit; limit > 0 {
+		bucket = ratelimit.NewBucketWithRate(float64(limit), 2*limit)
+	}
+
+	queue := make(chan int, len(pending))
+	for _, i := range pending {
+		queue <- i
+	}
+	close(queue)
+
+	var mu sync.Mutex
+	var firstErr error
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	workers := chunkedDownloadWorkers
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+	stopMonitorCh := tc.Monitor()
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				if cancelled(downloadCtx) {
+					return
+				}
+				start, end := state.bounds(i)
+				req := &chunkRequest{
+					name:      name,
+					storeURL:  storeURL,
+					cdnHeader: cdnHeader,
+					user:      user,
+					dlOpts:    dlOpts,
+					start:     start,
+					end:       end,
+				}
+				sum, err := s.downloadChunk(downloadCtx, req, f, bucket, prog, tc)
+				if err != nil {
+					fail(err)
+					return
+				}
+				mu.Lock()
+				state.Chunks[i] = sum
+				err = state.save(statePath)
+				mu.Unlock()
+				if err != nil {
+					fail(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(stopMonitorCh)
+
+	if err := tc.Err(); err != nil {
+		return err
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	if cancelled(ctx) {
+		return fmt.Errorf("the download has been cancelled: %s", ctx.Err())
+	}
+
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	actualSha3, err := chunkSha3_384(f, 0, state.Size)
+	if err != nil {
+		return err
+	}
+	if actualSha3 != downloadInfo.Sha3_384 {
+		return HashError{name, actualSha3, downloadInfo.Sha3_384}
+	}
+	return os.Remove(statePath)
+}
+
+// chunkRequest describes the range of a snap to be downloaded as one chunk.
+type chunkRequest struct {
+	name      string
+	storeURL  *url.URL
+	cdnHeader string
+	user      *auth.UserState
+	dlOpts    *DownloadOptions
+	// start and end are the offset of the first byte of the chunk and the
+	// offset following its last byte.
+	start, end int64
+}
+
+// downloadChunk downloads a single chunk into the given file at the offset of
+// the chunk, retrying on transient errors, and returns the sha3-384 of the
+// chunk data.
+func (s *Store) downloadChunk(ctx context.Context, req *chunkRequest, f *os.File, bucket *ratelimit.Bucket, prog *chunkedProgress, tc *TransferSpeedMonitoringWriter) (string, error) {
+	size := req.end - req.start
+	pw := &chunkProgressWriter{progress: prog}
+	var finalErr error
+	startTime := time.Now()
+	for attempt := retry.Start(downloadRetryStrategy, nil); attempt.Next(); {
+		reqOptions := downloadReqOpts(req.storeURL, req.cdnHeader, req.dlOpts)
+		reqOptions.ExtraHeaders["Range"] = fmt.Sprintf("bytes=%d-%d", req.start, req.end-1)
+
+		httputil.MaybeLogRetryAttempt(reqOptions.URL.String(), attempt, startTime)
+
+		var resp *http.Response
+		cli := s.newDownloadHTTPClient(reqOptions.APILevel)
+		resp, finalErr = s.doRequest(ctx, cli, reqOptions, req.user)
+		if cancelled(ctx) {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return "", fmt.Errorf("the download has been cancelled: %s", ctx.Err())
+		}
+		if finalErr != nil {
+			if httputil.ShouldRetryAttempt(attempt, finalErr) {
+				continue
+			}
+			break
+		}
+		if httputil.ShouldRetryHttpResponse(attempt, resp) {
+			resp.Body.Close()
+			continue
+		}
+
+		switch resp.StatusCode {
+		case 206: // Partial Content
+			if first, last, err := parseContentRange(resp); err != nil || first != req.start || last != req.end-1 {
+				resp.Body.Close()
+				return "", fmt.Errorf("%w: unexpected range %q for chunk at offset %d", errRangeNotSupported, resp.Header.Get("Content-Range"), req.start)
+			}
+		case 200: // OK, the range was ignored
+			resp.Body.Close()
+			return "", errRangeNotSupported
+		case 402: // Payment Required
+			resp.Body.Close()
+			return "", fmt.Errorf("please buy %s before installing it", req.name)
+		default:
+			resp.Body.Close()
+			return "", &DownloadError{Code: resp.StatusCode, URL: resp.Request.URL}
+		}
+
+		h := crypto.SHA3_384.New()
+		mw := io.MultiWriter(&offsetWriter{f: f, off: req.start}, h, pw, tc)
+		var body io.Reader = io.LimitReader(resp.Body, size)
+		if bucket != nil {
+			body = ratelimitReader(body, bucket)
+		}
+		var n int64
+		n, finalErr = io.Copy(mw, body)
+		resp.Body.Close()
+		if cancelled(ctx) {
+			return "", fmt.Errorf("the download has been cancelled: %s", ctx.Err())
+		}
+		if finalErr == nil && n != size {
+			finalErr = io.ErrUnexpectedEOF
+		}
+		if finalErr != nil {
+			pw.reset()
+			if httputil.ShouldRetryAttempt(attempt, finalErr) {
+				continue
+			}
+			break
+		}
+		return fmt.Sprintf("%x", h.Sum(nil)), nil
+	}
+	if finalErr == nil {
+		// retries were exhausted on retryable HTTP responses
+		finalErr = fmt.Errorf("cannot download chunk at offset %d of %s", req.start, req.name)
+	}
+	return "", finalErr
+}
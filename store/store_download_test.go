@@ -23,6 +23,7 @@ import (
 	"bytes"
 	"context"
 	"crypto"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -32,8 +33,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/juju/ratelimit"
 	"golang.org/x/crypto/sha3"
 	"golang.org/x/sys/unix"
 	. "gopkg.in/check.v1"
@@ -222,6 +225,7 @@ func (s *storeDownloadSuite) TestDownloadEOFHandlesResumeHashCorrectly(c *C) {
 			return
 		}
 		if len(r.Header["Range"]) > 0 {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", len(buf)-5, len(buf)-1, len(buf)))
 			w.WriteHeader(206)
 		}
 		w.Write(buf[len(buf)-5:])
@@ -2164,3 +2168,396 @@ func (s *storeDownloadSuite) TestDownloadIconUsesProxy(c *C) {
 
 	c.Assert(path, testutil.FileAbsent)
 }
+
+func (s *storeDownloadSuite) mockChunkedDownloadServer(c *C, content []byte, handleRange func(w http.ResponseWriter, r *http.Request) bool) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var ranges []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		if handleRange != nil && r.Header.Get("Range") != "" && handleRange(w, r) {
+			return
+		}
+		http.ServeContent(w, r, "foo.snap", time.Time{}, bytes.NewReader(content))
+	}))
+	c.Assert(mockServer, NotNil)
+	s.AddCleanup(mockServer.Close)
+	requestedRanges := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ranges...)
+	}
+	return mockServer, requestedRanges
+}
+
+func mockChunkedDownloadContent() (content []byte, sha3_384 string) {
+	content = make([]byte, 10500)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	h := crypto.SHA3_384.New()
+	h.Write(content)
+	return content, fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func (s *storeDownloadSuite) TestDownloadChunked(c *C) {
+	restore := store.MockChunkedDownloadParams(1000, 1000, 3)
+	defer restore()
+
+	content, sha3_384 := mockChunkedDownloadContent()
+	mockServer, requestedRanges := s.mockChunkedDownloadServer(c, content, nil)
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = sha3_384
+	snap.Size = int64(len(content))
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, content)
+	c.Check(targetFn+".partial", testutil.FileAbsent)
+	c.Check(targetFn+".partial.chunks", testutil.FileAbsent)
+
+	ranges := requestedRanges()
+	c.Check(ranges, HasLen, 11)
+	c.Check(ranges, testutil.Contains, "bytes=0-999")
+	c.Check(ranges, testutil.Contains, "bytes=10000-10499")
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedResume(c *C) {
+	restore := store.MockChunkedDownloadParams(1000, 1000, 2)
+	defer restore()
+
+	content, sha3_384 := mockChunkedDownloadContent()
+	mockServer, requestedRanges := s.mockChunkedDownloadServer(c, content, nil)
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+
+	// chunks 0 to 4 were downloaded before snapd restarted, but chunk 2 was
+	// corrupted since
+	partial := make([]byte, len(content))
+	copy(partial, content[:5000])
+	partial[2500] ^= 0xff
+	c.Assert(os.WriteFile(targetFn+".partial", partial, 0600), IsNil)
+	chunks := make([]string, 11)
+	for i := 0; i < 5; i++ {
+		h := crypto.SHA3_384.New()
+		h.Write(content[i*1000 : (i+1)*1000])
+		chunks[i] = fmt.Sprintf("%x", h.Sum(nil))
+	}
+	state, err := json.Marshal(map[string]any{
+		"sha3-384":   sha3_384,
+		"size":       len(content),
+		"chunk-size": 1000,
+		"chunks":     chunks,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(os.WriteFile(targetFn+".partial.chunks", state, 0600), IsNil)
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = sha3_384
+	snap.Size = int64(len(content))
+
+	err = s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, content)
+	c.Check(targetFn+".partial.chunks", testutil.FileAbsent)
+
+	ranges := requestedRanges()
+	c.Check(ranges, HasLen, 7)
+	c.Check(ranges, testutil.Contains, "bytes=2000-2999")
+	for _, r := range []string{"bytes=0-999", "bytes=1000-1999", "bytes=3000-3999", "bytes=4000-4999"} {
+		c.Check(ranges, Not(testutil.Contains), r)
+	}
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedRangeNotSupported(c *C) {
+	restore := store.MockChunkedDownloadParams(1000, 1000, 2)
+	defer restore()
+
+	content, sha3_384 := mockChunkedDownloadContent()
+	mockServer, requestedRanges := s.mockChunkedDownloadServer(c, content, func(w http.ResponseWriter, r *http.Request) bool {
+		// ignore the range and send the whole snap
+		w.Write(content)
+		return true
+	})
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = sha3_384
+	snap.Size = int64(len(content))
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, content)
+	c.Check(targetFn+".partial.chunks", testutil.FileAbsent)
+	c.Check(s.logbuf.String(), testutil.Contains, "Cannot download foo in chunks: server does not support range requests; downloading it in a single stream.")
+
+	// the single stream download does not use ranges
+	ranges := requestedRanges()
+	c.Check(ranges[len(ranges)-1], Equals, "")
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedHashMismatchFallsBack(c *C) {
+	restore := store.MockChunkedDownloadParams(1000, 1000, 2)
+	defer restore()
+
+	content, sha3_384 := mockChunkedDownloadContent()
+	mockServer, requestedRanges := s.mockChunkedDownloadServer(c, content, func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("Range") != "bytes=3000-3999" {
+			return false
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 3000-3999/%d", len(content)))
+		w.WriteHeader(206)
+		w.Write(bytes.Repeat([]byte{'x'}, 1000))
+		return true
+	})
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = sha3_384
+	snap.Size = int64(len(content))
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, content)
+	c.Check(targetFn+".partial.chunks", testutil.FileAbsent)
+	c.Check(s.logbuf.String(), Matches, `(?s).*Cannot download foo in chunks: sha3-384 mismatch for "foo".*`)
+
+	ranges := requestedRanges()
+	c.Check(ranges, HasLen, 12)
+	c.Check(ranges[11], Equals, "")
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedUnexpectedRangeFallsBack(c *C) {
+	restore := store.MockChunkedDownloadParams(1000, 1000, 2)
+	defer restore()
+
+	content, sha3_384 := mockChunkedDownloadContent()
+	mockServer, requestedRanges := s.mockChunkedDownloadServer(c, content, func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("Range") != "bytes=3000-3999" {
+			return false
+		}
+		// the range starting at the beginning is sent instead
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-999/%d", len(content)))
+		w.WriteHeader(206)
+		w.Write(content[:1000])
+		return true
+	})
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = sha3_384
+	snap.Size = int64(len(content))
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, content)
+	c.Check(targetFn+".partial.chunks", testutil.FileAbsent)
+	c.Check(s.logbuf.String(), testutil.Contains, `Cannot download foo in chunks: server does not support range requests: unexpected range "bytes 0-999/10500" for chunk at offset 3000; downloading it in a single stream.`)
+
+	// the single stream download starts over without a range
+	ranges := requestedRanges()
+	c.Check(ranges[len(ranges)-1], Equals, "")
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedErrorLeavePartial(c *C) {
+	restore := store.MockChunkedDownloadParams(1000, 1000, 1)
+	defer restore()
+
+	content, sha3_384 := mockChunkedDownloadContent()
+	mockServer, _ := s.mockChunkedDownloadServer(c, content, func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("Range") != "bytes=2000-2999" {
+			return false
+		}
+		w.WriteHeader(404)
+		return true
+	})
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = sha3_384
+	snap.Size = int64(len(content))
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{LeavePartialOnError: true})
+	c.Assert(err, ErrorMatches, `received an unexpected http response code \(404\) when trying to download .*`)
+	c.Check(targetFn, testutil.FileAbsent)
+	c.Check(targetFn+".partial", testutil.FilePresent)
+
+	// the chunks downloaded so far are recorded for the next attempt
+	var state struct {
+		Chunks []string `json:"chunks"`
+	}
+	b, err := os.ReadFile(targetFn + ".partial.chunks")
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(b, &state), IsNil)
+	c.Check(state.Chunks[0], Not(Equals), "")
+	c.Check(state.Chunks[1], Not(Equals), "")
+	c.Check(state.Chunks[2], Equals, "")
+
+	// without LeavePartialOnError everything is cleaned up
+	err = s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, NotNil)
+	c.Check(targetFn+".partial", testutil.FileAbsent)
+	c.Check(targetFn+".partial.chunks", testutil.FileAbsent)
+}
+
+func (s *storeDownloadSuite) mockCachePeer(c *C, content []byte, sha3_384 string) *url.URL {
+	peerStore := store.New(nil, nil)
+	s.AddCleanup(peerStore.MockCacher(store.NewCacheManager(c.MkDir(), store.CachePolicy{MaxItems: 10})))
+	blob := filepath.Join(c.MkDir(), "blob")
+	c.Assert(os.WriteFile(blob, content, 0600), IsNil)
+	c.Assert(store.CacherPut(peerStore, sha3_384, blob), IsNil)
+
+	peerServer := httptest.NewServer(peerStore.CacheHandler())
+	s.AddCleanup(peerServer.Close)
+	peerURL, err := url.Parse(peerServer.URL)
+	c.Assert(err, IsNil)
+	return peerURL
+}
+
+func (s *storeDownloadSuite) TestDownloadFromCachePeer(c *C) {
+	content, sha3_384 := mockChunkedDownloadContent()
+	peerURL := s.mockCachePeer(c, content, sha3_384)
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Errorf("unexpected request to the store")
+	}))
+	defer mockServer.Close()
+
+	sto := store.New(nil, &testDauthContext{c: c, cachePeer: peerURL})
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = sha3_384
+	snap.Size = int64(len(content))
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := sto.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, content)
+	c.Check(targetFn+".peer.partial", testutil.FileAbsent)
+}
+
+func (s *storeDownloadSuite) TestDownloadFromCachePeerRateLimited(c *C) {
+	content, sha3_384 := mockChunkedDownloadContent()
+	peerURL := s.mockCachePeer(c, content, sha3_384)
+
+	var ratelimitReaderUsed bool
+	restore := store.MockRatelimitReader(func(r io.Reader, bucket *ratelimit.Bucket) io.Reader {
+		ratelimitReaderUsed = true
+		return r
+	})
+	defer restore()
+
+	sto := store.New(nil, &testDauthContext{c: c, cachePeer: peerURL})
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = "http://store.invalid"
+	snap.Sha3_384 = sha3_384
+	snap.Size = int64(len(content))
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := sto.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{RateLimit: 1})
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, content)
+	c.Check(ratelimitReaderUsed, Equals, true)
+}
+
+func (s *storeDownloadSuite) TestDownloadFromCachePeerMismatchFallsBack(c *C) {
+	content, sha3_384 := mockChunkedDownloadContent()
+	// the peer serves different content under the expected digest
+	bad := bytes.Repeat([]byte{'x'}, len(content))
+	peerURL := s.mockCachePeer(c, bad, sha3_384)
+
+	mockServer, requestedRanges := s.mockChunkedDownloadServer(c, content, nil)
+
+	sto := store.New(nil, &testDauthContext{c: c, cachePeer: peerURL})
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = sha3_384
+	snap.Size = int64(len(content))
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := sto.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, content)
+	c.Check(targetFn+".peer.partial", testutil.FileAbsent)
+	c.Check(requestedRanges(), HasLen, 1)
+	c.Check(s.logbuf.String(), Matches, `(?s).*Cannot download foo from cache peer http://127.0.0.1:[0-9]+: sha3-384 mismatch for "foo".*`)
+}
+
+func (s *storeDownloadSuite) TestDownloadFromCachePeerMissFallsBack(c *C) {
+	content, sha3_384 := mockChunkedDownloadContent()
+	peerURL := s.mockCachePeer(c, content, strings.Repeat("a", 96))
+
+	mockServer, requestedRanges := s.mockChunkedDownloadServer(c, content, nil)
+
+	sto := store.New(nil, &testDauthContext{c: c, cachePeer: peerURL})
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = sha3_384
+	snap.Size = int64(len(content))
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := sto.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, content)
+	c.Check(requestedRanges(), HasLen, 1)
+	c.Check(s.logbuf.String(), Matches, `(?s).*Cannot download foo from cache peer .*: snap not in cache.*`)
+}
+
+func (s *storeDownloadSuite) TestCacheHandler(c *C) {
+	content, sha3_384 := mockChunkedDownloadContent()
+	peerURL := s.mockCachePeer(c, content, sha3_384)
+
+	get := func(method, path string) (int, []byte) {
+		req, err := http.NewRequest(method, peerURL.String()+path, nil)
+		c.Assert(err, IsNil)
+		resp, err := http.DefaultClient.Do(req)
+		c.Assert(err, IsNil)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		c.Assert(err, IsNil)
+		return resp.StatusCode, body
+	}
+
+	code, body := get("GET", "/v2/cache/snaps/"+sha3_384)
+	c.Check(code, Equals, 200)
+	c.Check(body, DeepEquals, content)
+
+	code, _ = get("HEAD", "/v2/cache/snaps/"+sha3_384)
+	c.Check(code, Equals, 200)
+
+	code, _ = get("POST", "/v2/cache/snaps/"+sha3_384)
+	c.Check(code, Equals, 405)
+
+	for _, path := range []string{
+		"/v2/cache/snaps/" + strings.Repeat("b", 96),
+		"/v2/cache/snaps/" + sha3_384[:10],
+		"/v2/cache/snaps/../" + sha3_384,
+		"/" + sha3_384,
+	} {
+		code, _ = get("GET", path)
+		c.Check(code, Equals, 404, Commentf("path: %s", path))
+	}
+}
@@ -247,6 +247,8 @@ type testDauthContext struct {
 
 	storeOffline bool
 
+	cachePeer *url.URL
+
 	cloudInfo *auth.CloudInfo
 }
 
@@ -325,6 +327,10 @@ func (sc *testDauthContext) WithSnapStoreDelta() bool {
 	return true
 }
 
+func (dac *testDauthContext) CachePeer() (*url.URL, error) {
+	return dac.cachePeer, nil
+}
+
 func (dac *testDauthContext) CloudInfo() (*auth.CloudInfo, error) {
 	return dac.cloudInfo, nil
 }
@@ -319,12 +319,54 @@ func EnsureAtLeast(requiredVersion int) error {
 
 var osutilStreamCommand = osutil.StreamCommand
 
+// LogFilter restricts the logs read from the journal. The zero value does
+// not filter anything.
+type LogFilter struct {
+	// Since and Until restrict the logs to the given time range, zero
+	// values leave the range open.
+	Since time.Time
+	Until time.Time
+	// Priority restricts the logs to the given priority level or range,
+	// as accepted by journalctl -p, e.g. "err" or "4" or "debug..warning".
+	Priority string
+	// Boot restricts the logs to the boot with the given ID or offset, as
+	// accepted by journalctl -b, e.g. "-1".
+	Boot string
+	// Grep restricts the logs to messages matching the given
+	// PCRE2 pattern.
+	Grep string
+}
+
+func (f *LogFilter) args() []string {
+	if f == nil {
+		return nil
+	}
+	var args []string
+	if !f.Since.IsZero() {
+		args = append(args, "--since=@"+strconv.FormatInt(f.Since.Unix(), 10))
+	}
+	if !f.Until.IsZero() {
+		args = append(args, "--until=@"+strconv.FormatInt(f.Until.Unix(), 10))
+	}
+	if f.Priority != "" {
+		args = append(args, "--priority="+f.Priority)
+	}
+	if f.Boot != "" {
+		args = append(args, "--boot="+f.Boot)
+	}
+	if f.Grep != "" {
+		args = append(args, "--grep="+f.Grep)
+	}
+	return args
+}
+
 // jctl calls journalctl to get the JSON logs of the given services.
-var jctl = func(svcs []string, n int, follow, namespaces bool) (io.ReadCloser, error) {
+var jctl = func(svcs []string, n int, follow, namespaces bool, filter *LogFilter) (io.ReadCloser, error) {
+	filterArgs := filter.args()
 	// args will need two entries per service, plus a fixed number (give or take
-	// one) for the initial options.
-	args := make([]string, 0, 2*len(svcs)+7)        // We have at most 7 extra arguments
-	args = append(args, "-o", "json", "--no-pager") //   3...
+	// one) for the initial options and the filter ones.
+	args := make([]string, 0, 2*len(svcs)+7+len(filterArgs)) // We have at most 7 extra arguments
+	args = append(args, "-o", "json", "--no-pager")          //   3...
 	if n < 0 {
 		args = append(args, "--no-tail") // < 2
 	} else {
@@ -336,6 +378,7 @@ var jctl = func(svcs []string, n int, follow, namespaces bool) (io.ReadCloser, e
 	if namespaces {
 		args = append(args, "--namespace=*") // ... + 1 == 7
 	}
+	args = append(args, filterArgs...)
 
 	for i := range svcs {
 		args = append(args, "-u", svcs[i]) // this is why 2×
@@ -344,7 +387,7 @@ var jctl = func(svcs []string, n int, follow, namespaces bool) (io.ReadCloser, e
 	return osutilStreamCommand("journalctl", args...)
 }
 
-func MockJournalctl(f func(svcs []string, n int, follow, namespaces bool) (io.ReadCloser, error)) func() {
+func MockJournalctl(f func(svcs []string, n int, follow, namespaces bool, filter *LogFilter) (io.ReadCloser, error)) func() {
 	oldJctl := jctl
 	jctl = f
 	return func() {
@@ -463,6 +506,11 @@ type Systemd interface {
 	// unit's transition to inactive.
 	// TODO: incorporate this result into Status instead?
 	InactiveEnterTimestamp(unit string) (time.Time, error)
+	// RuntimeStatus fetches runtime information about the given services,
+	// such as how many times they were restarted or their main PID.
+	// Statuses are returned in the same order as service names passed in
+	// argument.
+	RuntimeStatus(services []string) ([]*ServiceRuntimeStatus, error)
 	// IsEnabled checks whether the given service is enabled.
 	IsEnabled(service string) (bool, error)
 	// IsActive checks whether the given service is Active
@@ -472,7 +520,8 @@ type Systemd interface {
 	// as it grows.
 	// If namespaces is set to true, the log reader will include journal namespace
 	// logs, and is required to get logs for services which are in journal namespaces.
-	LogReader(services []string, n int, follow, namespaces bool) (io.ReadCloser, error)
+	// If filter is not nil, only the logs matching it are returned.
+	LogReader(services []string, n int, follow, namespaces bool, filter *LogFilter) (io.ReadCloser, error)
 	// ConfigureMountUnitOptions configures several options of the mount unit in-place.
 	ConfigureMountUnitOptions(o *MountUnitOptions, fstype string, startBeforeDrivers bool) error
 	// EnsureMountUnitFile adds/enables/starts a mount unit with options.
@@ -750,8 +799,8 @@ func (s *systemd) StartNoBlock(serviceNames []string) error {
 	return err
 }
 
-func (*systemd) LogReader(serviceNames []string, n int, follow, namespaces bool) (io.ReadCloser, error) {
-	return jctl(serviceNames, n, follow, namespaces)
+func (*systemd) LogReader(serviceNames []string, n int, follow, namespaces bool, filter *LogFilter) (io.ReadCloser, error) {
+	return jctl(serviceNames, n, follow, namespaces, filter)
 }
 
 var statusregex = regexp.MustCompile(`(?m)^(?:(.+?)=(.*)|(.*))?$`)
@@ -1071,6 +1120,126 @@ func (s *systemd) Status(unitNames []string) ([]*UnitStatus, error) {
 	return sts, nil
 }
 
+// ServiceRuntimeStatus holds runtime information about a service unit, as
+// tracked by systemd during the current boot.
+type ServiceRuntimeStatus struct {
+	Name string
+	// Restarts is the number of times the service was restarted
+	// automatically.
+	Restarts int
+	// ExecMainStatus is the exit status of the last main process of the
+	// service, or the signal that terminated it.
+	ExecMainStatus int
+	// ActiveEnterTimestamp is the last time the service entered the
+	// active state, it is the zero time if this never happened.
+	ActiveEnterTimestamp time.Time
+	// MainPID is the PID of the main process of the service, or zero if
+	// it is not running.
+	MainPID int
+	// MemoryCurrent is the memory used by the service, or zero if memory
+	// accounting is not available.
+	MemoryCurrent quantity.Size
+}
+
+var runtimeProperties = []string{"Id", "NRestarts", "ExecMainStatus", "ActiveEnterTimestamp", "MainPID", "MemoryCurrent"}
+
+// memoryCurrentUnset is reported by systemd for MemoryCurrent when memory
+// accounting is not available for the unit.
+const memoryCurrentUnset = "18446744073709551615"
+
+func (s *systemd) RuntimeStatus(services []string) ([]*ServiceRuntimeStatus, error) {
+	if s.mode == GlobalUserMode {
+		return nil, fmt.Errorf("cannot get runtime status of services in global user mode")
+	}
+	if len(services) == 0 {
+		return nil, nil
+	}
+
+	cmd := make([]string, len(services)+2)
+	cmd[0] = "show"
+	cmd[1] = "--property=" + strings.Join(runtimeProperties, ",")
+	copy(cmd[2:], services)
+	bs, err := s.systemctl(cmd...)
+	if err != nil {
+		return nil, err
+	}
+
+	sts := make([]*ServiceRuntimeStatus, 0, len(services))
+	cur := &ServiceRuntimeStatus{}
+	seen := map[string]bool{}
+	for _, bs := range statusregex.FindAllSubmatch(bs, -1) {
+		if len(bs[0]) == 0 {
+			// systemctl separates the properties of each unit by
+			// an empty line
+			if len(sts) >= len(services) {
+				return nil, fmt.Errorf("cannot get runtime status: got more results than expected")
+			}
+			cur.Name = services[len(sts)]
+			if !seen["Id"] {
+				return nil, fmt.Errorf("cannot get unit %q runtime status: missing Id in ‘systemctl show’ output", cur.Name)
+			}
+			sts = append(sts, cur)
+			cur = &ServiceRuntimeStatus{}
+			seen = map[string]bool{}
+			continue
+		}
+		if len(bs[3]) > 0 {
+			return nil, fmt.Errorf("cannot get runtime status: bad line %q in ‘systemctl show’ output", bs[3])
+		}
+		k := string(bs[1])
+		v := string(bs[2])
+		if seen[k] {
+			return nil, fmt.Errorf("cannot get runtime status: duplicate field %q in ‘systemctl show’ output", k)
+		}
+		seen[k] = true
+
+		// properties of inactive or missing units may be empty or
+		// not set, keep the zero value for those
+		if v == "" || v == "[not set]" {
+			continue
+		}
+		switch k {
+		case "Id":
+			// the order of the reply is the one of the request
+		case "NRestarts", "ExecMainStatus", "MainPID":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("cannot get runtime status: invalid %s value %q in ‘systemctl show’ output", k, v)
+			}
+			switch k {
+			case "NRestarts":
+				cur.Restarts = n
+			case "ExecMainStatus":
+				cur.ExecMainStatus = n
+			case "MainPID":
+				cur.MainPID = n
+			}
+		case "ActiveEnterTimestamp":
+			t, err := time.Parse("Mon 2006-01-02 15:04:05 MST", v)
+			if err != nil {
+				return nil, fmt.Errorf("cannot get runtime status: invalid %s value %q in ‘systemctl show’ output", k, v)
+			}
+			cur.ActiveEnterTimestamp = t
+		case "MemoryCurrent":
+			if v == memoryCurrentUnset {
+				continue
+			}
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot get runtime status: invalid %s value %q in ‘systemctl show’ output", k, v)
+			}
+			cur.MemoryCurrent = quantity.Size(n)
+		default:
+			return nil, fmt.Errorf("cannot get runtime status: unexpected field %q in ‘systemctl show’ output", k)
+		}
+	}
+
+	if len(sts) != len(services) {
+		return nil, fmt.Errorf("cannot get runtime status: expected %d results, got %d", len(services), len(sts))
+	}
+	return sts, nil
+}
+
 func (s *systemd) IsEnabled(serviceName string) (bool, error) {
 	var err error
 	if s.rootDir != "" {
@@ -1602,6 +1771,25 @@ func (s *systemd) ConfigureMountUnitOptions(o *MountUnitOptions, fstype string,
 	return nil
 }
 
+// AddDmVerityMountOptions adds to configured mount unit options the options
+// to set up dm-verity for the mounted image, with the hash tree found in the
+// hashDevice file and rootHash being the hex encoded root hash. It must be
+// called after ConfigureMountUnitOptions, dm-verity is only supported for
+// images mounted by the kernel.
+func AddDmVerityMountOptions(o *MountUnitOptions, hashDevice, rootHash string) error {
+	if o.Fstype != "squashfs" {
+		return fmt.Errorf("cannot use dm-verity for %q mounted with %q", o.What, o.Fstype)
+	}
+	if hashDevice == "" || rootHash == "" {
+		return errors.New("internal error: cannot use dm-verity without a hash device and a root hash")
+	}
+	if strings.ContainsAny(hashDevice, ",\n") {
+		return fmt.Errorf("cannot use dm-verity hash device with unsupported characters %q", hashDevice)
+	}
+	o.Options = append(o.Options, "verity.hashdevice="+hashDevice, "verity.roothash="+rootHash)
+	return nil
+}
+
 func (s *systemd) EnsureMountUnitFile(unitOptions *MountUnitOptions) (string, error) {
 	daemonReloadLock.Lock()
 	defer daemonReloadLock.Unlock()
@@ -164,7 +164,7 @@ func (s *SystemdTestSuite) myRun(args ...string) (out []byte, delay time.Duratio
 	return out, delayReq, err
 }
 
-func (s *SystemdTestSuite) myJctl(svcs []string, n int, follow, namespaces bool) (io.ReadCloser, error) {
+func (s *SystemdTestSuite) myJctl(svcs []string, n int, follow, namespaces bool, filter *LogFilter) (io.ReadCloser, error) {
 	var err error
 	var out []byte
 
@@ -938,7 +938,7 @@ func (s *SystemdTestSuite) TestKill(c *C) {
 func (s *SystemdTestSuite) TestLogErrJctl(c *C) {
 	s.jerrs = []error{errors.New("mock journalctl error")}
 
-	reader, err := New(SystemMode, s.rep).LogReader([]string{"foo"}, 24, false, false)
+	reader, err := New(SystemMode, s.rep).LogReader([]string{"foo"}, 24, false, false, nil)
 	c.Check(err, NotNil)
 	c.Check(reader, IsNil)
 	c.Check(s.jns, DeepEquals, []string{"24"})
@@ -954,7 +954,7 @@ func (s *SystemdTestSuite) TestLogs(c *C) {
 `
 	s.jouts = [][]byte{[]byte(expected)}
 
-	reader, err := New(SystemMode, s.rep).LogReader([]string{"foo"}, 24, false, false)
+	reader, err := New(SystemMode, s.rep).LogReader([]string{"foo"}, 24, false, false, nil)
 	c.Check(err, IsNil)
 	logs, err := io.ReadAll(reader)
 	c.Assert(err, IsNil)
@@ -1352,6 +1352,37 @@ func (s *SystemdTestSuite) TestConfigureMountUnitOptionsErrors(c *C) {
 	c.Assert(err, ErrorMatches, `internal error: cannot configure mount unit options: "Options" cannot be set`)
 }
 
+func (s *SystemdTestSuite) TestAddDmVerityMountOptions(c *C) {
+	restore := squashfs.MockNeedsFuse(false)
+	defer restore()
+
+	sysd := NewUnderRoot(dirs.GlobalRootDir, SystemMode, nil)
+	mountOptions := &systemd.MountUnitOptions{
+		What: "/var/lib/snapd/snaps/foo_1.snap",
+	}
+	err := sysd.ConfigureMountUnitOptions(mountOptions, "squashfs", false)
+	c.Assert(err, IsNil)
+	err = systemd.AddDmVerityMountOptions(mountOptions, "/var/lib/snapd/snaps/foo_1.snap.dmverity_abcd", "abcd")
+	c.Assert(err, IsNil)
+	c.Check(mountOptions.Options, DeepEquals, []string{"nodev", "ro", "x-gdu.hide", "x-gvfs-hide",
+		"verity.hashdevice=/var/lib/snapd/snaps/foo_1.snap.dmverity_abcd", "verity.roothash=abcd"})
+
+	err = systemd.AddDmVerityMountOptions(mountOptions, "/var/lib/snapd/snaps/foo,bar.dmverity_abcd", "abcd")
+	c.Check(err, ErrorMatches, `cannot use dm-verity hash device with unsupported characters "/var/lib/snapd/snaps/foo,bar.dmverity_abcd"`)
+	err = systemd.AddDmVerityMountOptions(mountOptions, "", "abcd")
+	c.Check(err, ErrorMatches, `internal error: cannot use dm-verity without a hash device and a root hash`)
+}
+
+func (s *SystemdTestSuite) TestAddDmVerityMountOptionsFuse(c *C) {
+	mountOptions := &systemd.MountUnitOptions{
+		What:    "/var/lib/snapd/snaps/foo_1.snap",
+		Fstype:  "fuse.squashfuse",
+		Options: []string{"nodev", "ro", "x-gdu.hide", "x-gvfs-hide", "allow_other"},
+	}
+	err := systemd.AddDmVerityMountOptions(mountOptions, "/var/lib/snapd/snaps/foo_1.snap.dmverity_abcd", "abcd")
+	c.Check(err, ErrorMatches, `cannot use dm-verity for "/var/lib/snapd/snaps/foo_1.snap" mounted with "fuse.squashfuse"`)
+}
+
 func (s *SystemdTestSuite) TestEnsureMountUnitUnchanged(c *C) {
 	rootDir := dirs.GlobalRootDir
 
@@ -1971,18 +2002,30 @@ func (s *SystemdTestSuite) TestJctl(c *C) {
 		return nil, nil
 	})
 
-	_, err = Jctl([]string{"foo", "bar"}, 10, false, false)
+	_, err = Jctl([]string{"foo", "bar"}, 10, false, false, nil)
 	c.Assert(err, IsNil)
 	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "-n", "10", "-u", "foo", "-u", "bar"})
-	_, err = Jctl([]string{"foo", "bar", "baz"}, 99, true, false)
+	_, err = Jctl([]string{"foo", "bar", "baz"}, 99, true, false, nil)
 	c.Assert(err, IsNil)
 	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "-n", "99", "-f", "-u", "foo", "-u", "bar", "-u", "baz"})
-	_, err = Jctl([]string{"foo", "bar"}, -1, false, false)
+	_, err = Jctl([]string{"foo", "bar"}, -1, false, false, nil)
 	c.Assert(err, IsNil)
 	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "--no-tail", "-u", "foo", "-u", "bar"})
-	_, err = Jctl([]string{"foo", "bar"}, -1, false, true)
+	_, err = Jctl([]string{"foo", "bar"}, -1, false, true, nil)
 	c.Assert(err, IsNil)
 	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "--no-tail", "--namespace=*", "-u", "foo", "-u", "bar"})
+	_, err = Jctl([]string{"foo"}, 10, true, true, &LogFilter{
+		Since:    time.Date(2021, time.April, 16, 15, 32, 21, 0, time.UTC),
+		Until:    time.Date(2021, time.April, 16, 16, 32, 21, 0, time.UTC),
+		Priority: "err",
+		Boot:     "-1",
+		Grep:     "connection refused",
+	})
+	c.Assert(err, IsNil)
+	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "-n", "10", "-f", "--namespace=*", "--since=@1618587141", "--until=@1618590741", "--priority=err", "--boot=-1", "--grep=connection refused", "-u", "foo"})
+	_, err = Jctl([]string{"foo"}, 10, false, false, &LogFilter{Grep: "oops"})
+	c.Assert(err, IsNil)
+	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "-n", "10", "--grep=oops", "-u", "foo"})
 }
 
 func (s *SystemdTestSuite) TestIsActiveUnderRoot(c *C) {
@@ -3261,6 +3304,82 @@ func (s *SystemdTestSuite) TestCurrentUsageFamilyHappy(c *C) {
 	})
 }
 
+func (s *SystemdTestSuite) TestRuntimeStatus(c *C) {
+	s.outs = [][]byte{
+		[]byte(`
+Id=foo.service
+NRestarts=3
+ExecMainStatus=1
+ActiveEnterTimestamp=Fri 2021-04-16 15:32:21 UTC
+MainPID=1234
+MemoryCurrent=1048576
+
+Id=bar.service
+NRestarts=0
+ExecMainStatus=0
+ActiveEnterTimestamp=
+MainPID=0
+MemoryCurrent=[not set]
+
+Id=baz.service
+NRestarts=0
+ExecMainStatus=0
+ActiveEnterTimestamp=Fri 2021-04-16 15:32:21 UTC
+MainPID=42
+MemoryCurrent=18446744073709551615
+`[1:]),
+	}
+	sts, err := New(SystemMode, s.rep).RuntimeStatus([]string{"foo.service", "bar.service", "baz.service"})
+	c.Assert(err, IsNil)
+	c.Check(s.argses, DeepEquals, [][]string{
+		{"show", "--property=Id,NRestarts,ExecMainStatus,ActiveEnterTimestamp,MainPID,MemoryCurrent", "foo.service", "bar.service", "baz.service"},
+	})
+	stamp := time.Date(2021, time.April, 16, 15, 32, 21, 0, time.UTC)
+	c.Assert(sts, HasLen, 3)
+	c.Check(sts[0].ActiveEnterTimestamp.Equal(stamp), Equals, true)
+	sts[0].ActiveEnterTimestamp = time.Time{}
+	c.Check(sts[2].ActiveEnterTimestamp.Equal(stamp), Equals, true)
+	sts[2].ActiveEnterTimestamp = time.Time{}
+	c.Check(sts, DeepEquals, []*ServiceRuntimeStatus{{
+		Name:           "foo.service",
+		Restarts:       3,
+		ExecMainStatus: 1,
+		MainPID:        1234,
+		MemoryCurrent:  quantity.SizeMiB,
+	}, {
+		Name: "bar.service",
+	}, {
+		Name:    "baz.service",
+		MainPID: 42,
+	}})
+}
+
+func (s *SystemdTestSuite) TestRuntimeStatusErrors(c *C) {
+	for _, t := range []struct {
+		out string
+		err string
+	}{
+		{"Id=foo.service\nNRestarts=many\n", `cannot get runtime status: invalid NRestarts value "many" in ‘systemctl show’ output`},
+		{"Id=foo.service\nActiveEnterTimestamp=yesterday\n", `cannot get runtime status: invalid ActiveEnterTimestamp value "yesterday" in ‘systemctl show’ output`},
+		{"Id=foo.service\nPotato=1\n", `cannot get runtime status: unexpected field "Potato" in ‘systemctl show’ output`},
+		{"Id=foo.service\nId=foo.service\n", `cannot get runtime status: duplicate field "Id" in ‘systemctl show’ output`},
+		{"Id=foo.service\nsome garbage\n", `cannot get runtime status: bad line "some garbage" in ‘systemctl show’ output`},
+		{"MainPID=1\n", `cannot get unit "foo.service" runtime status: missing Id in ‘systemctl show’ output`},
+		{"Id=foo.service\n\nId=bar.service\n", `cannot get runtime status: got more results than expected`},
+	} {
+		s.outs = [][]byte{[]byte(t.out)}
+		s.i = 0
+		_, err := New(SystemMode, s.rep).RuntimeStatus([]string{"foo.service"})
+		c.Check(err, ErrorMatches, t.err, Commentf("%q", t.out))
+	}
+}
+
+func (s *SystemdTestSuite) TestRuntimeStatusGlobalUserMode(c *C) {
+	_, err := New(GlobalUserMode, s.rep).RuntimeStatus([]string{"foo.service"})
+	c.Check(err, ErrorMatches, `cannot get runtime status of services in global user mode`)
+	c.Check(s.argses, HasLen, 0)
+}
+
 func (s *SystemdTestSuite) TestInactiveEnterTimestampZero(c *C) {
 	s.outs = [][]byte{
 		[]byte(`InactiveEnterTimestamp=`),
@@ -451,6 +451,10 @@ type SnapServiceOptions struct {
 
 	// QuotaGroup is the quota group for the specified snap.
 	QuotaGroup *quota.Group
+
+	// TimerSchedules maps app names to a schedule overriding the timer
+	// declared by the app.
+	TimerSchedules map[string]string
 }
 
 // ObserveChangeCallback can be invoked by EnsureSnapServices to observe
@@ -562,7 +566,7 @@ func (es *ensureSnapServicesContext) reloadModified() error {
 
 // ensureSnapServiceSystemdUnits takes care of writing .service files for all services
 // registered in snap.Info apps.
-func (es *ensureSnapServicesContext) ensureSnapServiceSystemdUnits(snapInfo *snap.Info, opts *internal.SnapServicesUnitOptions) error {
+func (es *ensureSnapServicesContext) ensureSnapServiceSystemdUnits(snapInfo *snap.Info, opts *internal.SnapServicesUnitOptions, timerSchedules map[string]string) error {
 	handleFileModification := func(app *snap.AppInfo, unitType string, name, path string, content []byte) error {
 		old, modifiedFile, err := tryFileUpdate(path, content)
 		if err != nil {
@@ -614,6 +618,12 @@ func (es *ensureSnapServicesContext) ensureSnapServiceSystemdUnits(snapInfo *sna
 			continue
 		}
 
+		// the schedule is part of both the service and the timer unit, so
+		// render them from an app carrying the overridden schedule
+		if schedule := timerSchedules[svc.Name]; schedule != "" && svc.Timer != nil {
+			svc = appWithTimerSchedule(svc, schedule)
+		}
+
 		// create services first; this doesn't trigger systemd
 
 		// get the correct quota group for the service we are generating.
@@ -670,6 +680,17 @@ func (es *ensureSnapServicesContext) ensureSnapServiceSystemdUnits(snapInfo *sna
 	return nil
 }
 
+// appWithTimerSchedule returns a copy of the given app with its timer
+// schedule replaced by the given one.
+func appWithTimerSchedule(app *snap.AppInfo, schedule string) *snap.AppInfo {
+	appCopy := *app
+	timerCopy := *app.Timer
+	timerCopy.App = &appCopy
+	timerCopy.Timer = schedule
+	appCopy.Timer = &timerCopy
+	return &appCopy
+}
+
 // ensureSnapsSystemdServices takes care of writing .service files for all apps in the provided snaps
 // list, and also returns a quota group set that represents all quota groups for the set of snaps
 // provided if they are a part of any.
@@ -707,7 +728,7 @@ func (es *ensureSnapServicesContext) ensureSnapsSystemdServices() (*quota.QuotaG
 			}
 		}
 
-		if err := es.ensureSnapServiceSystemdUnits(s, genServiceOpts); err != nil {
+		if err := es.ensureSnapServiceSystemdUnits(s, genServiceOpts, snapSvcOpts.TimerSchedules); err != nil {
 			return nil, err
 		}
 	}
@@ -4837,6 +4837,27 @@ func (s *servicesTestSuite) TestAddRemoveSnapWithTimersAddsRemovesTimerFiles(c *
 	c.Check(osutil.FileExists(app.ServiceFile()), Equals, false)
 }
 
+func (s *servicesTestSuite) TestEnsureSnapServicesTimerSchedules(c *C) {
+	info := snaptest.MockSnap(c, packageHello+`
+ svc2:
+  command: bin/hello
+  daemon: simple
+  timer: 10:00-12:00
+`, &snap.SideInfo{Revision: snap.R(12)})
+
+	m := map[*snap.Info]*wrappers.SnapServiceOptions{
+		info: {TimerSchedules: map[string]string{"svc2": "mon,23:00"}},
+	}
+	err := wrappers.EnsureSnapServices(m, nil, nil, progress.Null)
+	c.Assert(err, IsNil)
+
+	app := info.Apps["svc2"]
+	c.Check(app.Timer.File(), testutil.FileContains, "\nOnCalendar=Mon *-*-* 23:00\n")
+	c.Check(app.ServiceFile(), testutil.FileContains, `ExecStart=/usr/bin/snap run --timer="mon,23:00" hello-snap.svc2`)
+	// the snap info is left untouched
+	c.Check(app.Timer.Timer, Equals, "10:00-12:00")
+}
+
 func (s *servicesTestSuite) TestFailedAddSnapCleansUp(c *C) {
 	info := snaptest.MockSnap(c, packageHello+`
  svc2: